@@ -2,27 +2,39 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/google/uuid"
 	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/atna"
 	"github.com/otcheredev/ris-dicom-connector/internal/cache"
 	"github.com/otcheredev/ris-dicom-connector/internal/config"
 	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/events"
+	"github.com/otcheredev/ris-dicom-connector/internal/grpcapi"
 	"github.com/otcheredev/ris-dicom-connector/internal/handlers"
+	"github.com/otcheredev/ris-dicom-connector/internal/hl7"
 	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/ratelimit"
 	"github.com/otcheredev/ris-dicom-connector/internal/repository"
 	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/otcheredev/ris-dicom-connector/internal/viewer"
 	"github.com/otcheredev/ris-dicom-connector/pkg/logger"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -58,38 +70,580 @@ func main() {
 
 	// Initialize cache
 	var cacheImpl cache.Cache
+	var memoryCache *cache.MemoryCache
+	// Studies pinned through the API, whose entries the caches do not evict
+	cachePins := cache.NewPins()
+	redisConfig := cache.RedisConfig{
+		Mode:             cfg.Redis.Mode,
+		Addrs:            cfg.Redis.Addresses(),
+		MasterName:       cfg.Redis.MasterName,
+		Username:         cfg.Redis.Username,
+		Password:         cfg.Redis.Password,
+		DB:               cfg.Redis.DB,
+		SentinelUsername: cfg.Redis.SentinelUsername,
+		SentinelPassword: cfg.Redis.SentinelPassword,
+		TLS:              cfg.Redis.TLS,
+		TLSCAFile:        cfg.Redis.TLSCAFile,
+	}
+	// newKeyWrapper returns the key wrapper of a configured master key; nil
+	// when encryption is disabled
+	newKeyWrapper := func(encryption config.EncryptionConfig) (cache.KeyWrapper, error) {
+		switch encryption.Provider {
+		case "local":
+			masterKey, _ := base64.StdEncoding.DecodeString(encryption.Key)
+			return cache.NewLocalKeyWrapper(masterKey)
+		case "aws-kms":
+			return adapters.NewAWSKMSKeyWrapper(adapters.AWSKMSConfig{
+				KeyID:           encryption.KMSKeyID,
+				Region:          encryption.KMSRegion,
+				Endpoint:        encryption.KMSEndpoint,
+				AccessKeyID:     encryption.AccessKeyID,
+				SecretAccessKey: encryption.SecretAccessKey,
+			})
+		}
+		return nil, nil
+	}
+	// Key wrapper of the master key the redis and s3 tiers are encrypted under
+	keyWrapper, err := newKeyWrapper(cfg.Cache.Encryption)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize cache encryption")
+	}
+	// compressTier wraps the cache of a tier listed in CACHE_COMPRESS_TIERS
+	compressTier := func(tier string, c cache.Cache) cache.Cache {
+		if !slices.Contains(cfg.Cache.CompressTiers, tier) {
+			return c
+		}
+		compressed, err := cache.NewCompressedCache(c)
+		if err != nil {
+			log.Fatal().Err(err).Str("tier", tier).Msg("Failed to initialize cache compression")
+		}
+		return compressed
+	}
+	// storeTier wraps the cache of a tier kept outside the process so its values
+	// are encrypted at rest, and compresses them first when the tier is listed in
+	// CACHE_COMPRESS_TIERS
+	storeTier := func(tier string, c cache.Cache) cache.Cache {
+		if keyWrapper != nil && tier != "memory" {
+			c = cache.NewEncryptedCache(c, keyWrapper)
+		}
+		return compressTier(tier, c)
+	}
 	if cfg.Cache.Enabled {
-		if cfg.Cache.Type == "redis" {
-			addr := fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)
-			cacheImpl, err = cache.NewRedisCache(addr, cfg.Redis.Password, cfg.Redis.DB)
+		switch cfg.Cache.Type {
+		case "redis":
+			redisCache, err := cache.NewRedisCache(redisConfig)
 			if err != nil {
 				log.Fatal().Err(err).Msg("Failed to connect to Redis")
 			}
+			cacheImpl = storeTier("redis", redisCache)
 			log.Info().Msg("Redis cache initialized")
-		} else {
-			cacheImpl = cache.NewMemoryCache()
+		case "tiered":
+			tiers := make([]cache.Tier, 0, len(cfg.Cache.Tiers))
+			for _, name := range cfg.Cache.Tiers {
+				tier := cache.Tier{Name: name}
+				switch name {
+				case "memory":
+					// The tiered cache bounds the tier and demotes from it, so entries
+					// the memory cache evicted itself would be lost
+					memoryCache = cache.NewMemoryCache(0, 0)
+					tier.Cache = compressTier(name, memoryCache)
+					tier.MaxBytes = int64(cfg.Cache.MemoryMaxMB) << 20
+				case "redis":
+					tier.Cache, err = cache.NewRedisCache(redisConfig)
+					if err != nil {
+						log.Fatal().Err(err).Msg("Failed to connect to Redis")
+					}
+					tier.Cache = storeTier(name, tier.Cache)
+					tier.MaxBytes = int64(cfg.Cache.RedisMaxMB) << 20
+				case "s3":
+					tier.Cache, err = adapters.NewS3Cache(adapters.S3CacheConfig{
+						Bucket:          cfg.Cache.S3.Bucket,
+						Region:          cfg.Cache.S3.Region,
+						Endpoint:        cfg.Cache.S3.Endpoint,
+						PathStyle:       cfg.Cache.S3.PathStyle,
+						Prefix:          cfg.Cache.S3.Prefix,
+						AccessKeyID:     cfg.Cache.S3.AccessKeyID,
+						SecretAccessKey: cfg.Cache.S3.SecretAccessKey,
+					})
+					if err != nil {
+						log.Fatal().Err(err).Msg("Failed to initialize S3 cache tier")
+					}
+					tier.Cache = storeTier(name, tier.Cache)
+					tier.MaxBytes = int64(cfg.Cache.S3MaxMB) << 20
+				}
+				tiers = append(tiers, tier)
+			}
+			tiered := cache.NewTieredCache(tiers, cfg.Cache.PromoteHits, cfg.Cache.DefaultTTL)
+			tiered.KeepPinned(cachePins)
+			defer tiered.Close()
+			cacheImpl = tiered
+			log.Info().Strs("tiers", cfg.Cache.Tiers).Msg("Tiered cache initialized")
+		default:
+			memoryCache = cache.NewMemoryCache(cfg.Cache.MemoryMaxEntries, int64(cfg.Cache.MemoryMaxMB)<<20)
+			cacheImpl = compressTier("memory", memoryCache)
 			log.Info().Msg("Memory cache initialized")
 		}
 	} else {
-		cacheImpl = cache.NewMemoryCache() // Fallback
+		memoryCache = cache.NewMemoryCache(cfg.Cache.MemoryMaxEntries, int64(cfg.Cache.MemoryMaxMB)<<20) // Fallback
+		cacheImpl = memoryCache
 		log.Info().Msg("Cache disabled, using memory cache as fallback")
 	}
+	if memoryCache != nil {
+		memoryCache.KeepPinned(cachePins)
+	}
+	if cfg.Cache.Enabled && cfg.Cache.Type != "memory" && cfg.Cache.ChunkSizeMB > 0 {
+		// Large instances exceed what Redis and S3 tiers should hold as one value
+		cacheImpl = cache.NewChunkedCache(cacheImpl, cfg.Cache.ChunkSizeMB<<20)
+	}
+	// Per-tenant quotas, looked up from the tenants' PACS configs once the PACS service exists
+	quotaCache := cache.NewQuotaCache(cacheImpl, nil)
+	quotaCache.KeepPinned(cachePins)
+	cacheImpl = quotaCache
+	cacheTier := cfg.Cache.Type
+	if !cfg.Cache.Enabled {
+		cacheTier = "memory"
+	}
+	cacheStats := cache.NewStats()
+	instrumentedCache := cache.Instrument(cacheImpl, cacheTier, cacheStats)
+	cacheImpl = instrumentedCache
+	if cfg.Metrics.Enabled {
+		cache.RegisterStatsMetrics(cacheStats)
+		if memoryCache != nil {
+			cache.RegisterMemoryCacheMetrics(memoryCache)
+		}
+	}
 
 	// Initialize repositories
 	pacsRepo := repository.NewPACSRepository()
 	auditRepo := repository.NewAuditRepository()
+	worklistRepo := repository.NewWorklistRepository()
+	webhookRepo := repository.NewWebhookRepository()
+	exportRepo := repository.NewExportRepository()
+	viewerGrantRepo := repository.NewViewerGrantRepository()
+	prefetchRepo := repository.NewPrefetchRepository()
+	pinRepo := repository.NewPinRepository()
+	verificationRepo := repository.NewVerificationRepository()
+	studyIndexRepo := repository.NewStudyIndexRepository()
+	retrieveJobRepo := repository.NewRetrieveJobRepository()
+	cacheMetricsRepo := repository.NewCacheMetricsRepository()
+	usageRepo := repository.NewUsageRepository()
+	routingRepo := repository.NewRoutingRepository()
+	settingsRepo := repository.NewTenantSettingsRepository()
+	apiKeyRepo := repository.NewAPIKeyRepository()
+	tenantRepo := repository.NewTenantRepository()
 
-	// Initialize adapter factory
-	adapterFactory := adapters.NewAdapterFactory()
+	// Initialize adapter factory, which decrypts the PACS credentials stored
+	// encrypted under the credential master key
+	var credentialCipher *adapters.CredentialCipher
+	credentialWrapper, err := newKeyWrapper(cfg.Auth.CredentialEncryption)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize credential encryption")
+	}
+	if credentialWrapper != nil {
+		credentialCipher = adapters.NewCredentialCipher(credentialWrapper)
+	} else {
+		log.Warn().Msg("PACS credentials are stored in plaintext; set CREDENTIAL_ENCRYPTION to encrypt them")
+	}
+	adapterFactory := adapters.NewAdapterFactory(cfg.Adapters.IdleTimeout, credentialCipher)
 	defer adapterFactory.CloseAll()
+	// Secret stores PACS credentials may reference instead of being stored
+	secretStores := make(map[string]adapters.SecretStore)
+	if cfg.Adapters.VaultAddress != "" {
+		vault, err := adapters.NewVaultSecretStore(adapters.VaultConfig{
+			Address:   cfg.Adapters.VaultAddress,
+			Token:     cfg.Adapters.VaultToken,
+			Namespace: cfg.Adapters.VaultNamespace,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize the Vault secret store")
+		}
+		secretStores[adapters.SecretStoreVault] = vault
+	}
+	if cfg.Adapters.SecretsManagerAccessKeyID != "" {
+		secretsManager, err := adapters.NewAWSSecretsManagerStore(adapters.AWSSecretsManagerConfig{
+			Region:          cfg.Adapters.SecretsManagerRegion,
+			Endpoint:        cfg.Adapters.SecretsManagerEndpoint,
+			AccessKeyID:     cfg.Adapters.SecretsManagerAccessKeyID,
+			SecretAccessKey: cfg.Adapters.SecretsManagerSecretAccessKey,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize the Secrets Manager secret store")
+		}
+		secretStores[adapters.SecretStoreAWSSecretsManager] = secretsManager
+	}
+	if len(secretStores) > 0 {
+		adapterFactory.EnableSecretStores(adapters.NewSecretResolver(secretStores, cfg.Adapters.SecretRefreshInterval), cfg.Adapters.SecretRefreshInterval)
+	}
 
 	// Initialize services
-	pacsService := services.NewPACSService(pacsRepo, auditRepo, adapterFactory, cacheImpl)
+	webhookService := services.NewWebhookService(webhookRepo, services.WebhookConfig{
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+		Timeout:      cfg.Webhook.Timeout,
+		PollInterval: cfg.Webhook.PollInterval,
+	})
+	if credentialCipher != nil {
+		webhookService.EnableSecretEncryption(credentialCipher)
+		// Webhook secrets stored before encryption was enabled are encrypted now
+		if encrypted, err := webhookService.EncryptWebhookSecrets(context.Background()); err != nil {
+			log.Fatal().Err(err).Msg("Failed to encrypt stored webhook secrets")
+		} else if encrypted > 0 {
+			log.Info().Int("webhooks", encrypted).Msg("Encrypted stored webhook secrets")
+		}
+	}
+	webhookService.Start()
+	defer webhookService.Stop()
+
+	publishers := services.EventPublishers{webhookService}
+	if cfg.EventBus.Driver != "" {
+		bus, err := events.New(events.Config{
+			Driver:      cfg.EventBus.Driver,
+			Brokers:     cfg.EventBus.Brokers,
+			TopicPrefix: cfg.EventBus.TopicPrefix,
+			Username:    cfg.EventBus.Username,
+			Password:    cfg.EventBus.Password,
+			TLS:         cfg.EventBus.TLS,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to connect to event bus")
+		}
+		defer bus.Close()
+		publishers = append(publishers, bus)
+		log.Info().Str("driver", cfg.EventBus.Driver).Msg("Event bus publisher initialized")
+	}
+
+	pacsService := services.NewPACSService(pacsRepo, auditRepo, routingRepo, settingsRepo, tenantRepo, adapterFactory, cacheImpl, services.CacheTTLs{
+		Instance:   cfg.Cache.DefaultTTL,
+		Metadata:   cfg.Cache.MetadataTTL,
+		Query:      cfg.Cache.QueryTTL,
+		QueryStale: cfg.Cache.QueryStaleTTL,
+		Thumbnail:  cfg.Cache.ThumbnailTTL,
+		Exists:     cfg.Cache.ExistsTTL,
+		NotFound:   cfg.Cache.NotFoundTTL,
+
+		MaxInstanceSize: int64(cfg.Cache.MaxInstanceMB) << 20,
+
+		TenantQuota: cache.Quota{
+			MaxBytes:   int64(cfg.Cache.TenantMaxMB) << 20,
+			MaxObjects: cfg.Cache.TenantMaxObjects,
+		},
+	}, publishers)
+	quotaCache.SetQuotas(pacsService.CacheQuota)
+	if cfg.Failover.Enabled {
+		pacsService.EnableFailover(services.FailoverConfig{
+			ErrorThreshold: cfg.Failover.ErrorThreshold,
+			RetryInterval:  cfg.Failover.RetryInterval,
+		})
+	}
+	if err := pacsService.EnableDeidentification(services.DeidentConfig{
+		Secret:    cfg.Deident.Secret,
+		Overrides: cfg.Deident.Overrides,
+	}); err != nil {
+		log.Fatal().Err(err).Msg("Failed to enable de-identification")
+	}
+	// Study-level authorization of every study, series and instance served to clients
+	if cfg.Auth.StudyAuthzURL != "" {
+		authorizer, err := services.NewHTTPStudyAuthorizer(cfg.Auth.StudyAuthzURL, cfg.Auth.StudyAuthzToken)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create study authorizer")
+		}
+		pacsService.EnableStudyAuthorization(authorizer, cfg.Auth.StudyAuthzCacheTTL)
+		log.Info().Str("url", cfg.Auth.StudyAuthzURL).Msg("Study authorization enabled")
+	}
+	// PACS credentials stored before encryption was enabled are encrypted now
+	if encrypted, err := pacsService.EncryptPACSCredentials(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("Failed to encrypt stored PACS credentials")
+	} else if encrypted > 0 {
+		log.Info().Int("configs", encrypted).Msg("Encrypted stored PACS credentials")
+	}
+	// Request rate limits per tenant and per PACS, shared by every instance
+	// when the buckets are kept in Redis
+	var rateLimiter ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		switch cfg.RateLimit.Store {
+		case "redis":
+			redisClient, err := cache.NewRedisClient(redisConfig)
+			if err != nil {
+				log.Fatal().Err(err).Msg("Failed to connect to Redis for rate limiting")
+			}
+			defer redisClient.Close()
+			rateLimiter = ratelimit.NewRedisLimiter(redisClient)
+		default:
+			rateLimiter = ratelimit.NewMemoryLimiter()
+		}
+		pacsService.EnableRateLimits(rateLimiter, services.RateLimitConfig{
+			Tenant:  ratelimit.PerSecond(cfg.RateLimit.TenantRate, cfg.RateLimit.TenantBurst),
+			PACS:    ratelimit.PerSecond(cfg.RateLimit.PACSRate, cfg.RateLimit.PACSBurst),
+			MaxWait: cfg.RateLimit.PACSMaxWait,
+		})
+		log.Info().Str("store", cfg.RateLimit.Store).Msg("Rate limiting enabled")
+	}
+	if cfg.Breaker.Enabled {
+		pacsService.EnableCircuitBreakers(services.CircuitBreakerConfig{
+			Window:           cfg.Breaker.Window,
+			MinRequests:      cfg.Breaker.MinRequests,
+			ErrorRate:        float64(cfg.Breaker.ErrorRate) / 100,
+			OpenDuration:     cfg.Breaker.OpenDuration,
+			HalfOpenRequests: cfg.Breaker.HalfOpenRequests,
+		})
+	}
+	if cfg.Metrics.Enabled {
+		pacsService.RegisterFailoverMetrics()
+		services.RegisterHealthCheckMetrics()
+		services.RegisterCircuitBreakerMetrics()
+		services.RegisterJobQueueMetrics()
+	}
+	worklistService := services.NewWorklistService(worklistRepo)
+
+	exportService, err := services.NewExportService(pacsService, exportRepo, services.ExportConfig{
+		Dir:        cfg.Export.Dir,
+		Workers:    cfg.Export.Workers,
+		LinkTTL:    cfg.Export.LinkTTL,
+		LinkSecret: cfg.Export.LinkSecret,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize study exports")
+	}
+	exportService.Start()
+	defer exportService.Stop()
+
+	retrieveJobService := services.NewRetrieveJobService(pacsService, retrieveJobRepo, services.RetrieveJobConfig{
+		Workers:          cfg.Jobs.Workers,
+		PollInterval:     cfg.Jobs.PollInterval,
+		InstanceAttempts: cfg.Jobs.InstanceAttempts,
+	})
+	retrieveJobService.Start()
+	defer retrieveJobService.Stop()
+
+	viewerGrantService := services.NewViewerGrantService(pacsService, viewerGrantRepo, services.ViewerGrantConfig{
+		IntrospectionURL: cfg.SMART.IntrospectionURL,
+		ClientID:         cfg.SMART.ClientID,
+		ClientSecret:     cfg.SMART.ClientSecret,
+		TTL:              cfg.SMART.GrantTTL,
+	})
+	apiKeyService := services.NewAPIKeyService(pacsService, apiKeyRepo)
+	if cfg.Auth.APIKeySigningSecret != "" {
+		apiKeyService.EnableRequestSigning([]byte(cfg.Auth.APIKeySigningSecret))
+	}
+	// Only provisioned, active tenants are served; tenants configured before
+	// tenants were kept are provisioned now
+	tenantService := services.NewTenantService(pacsService, apiKeyService, tenantRepo)
+	if err := tenantService.ImportExistingTenants(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("Failed to provision existing tenants")
+	}
+
+	// Refresh of metadata read often, before its cache entry expires
+	if cfg.Cache.RefreshEnabled {
+		metadataRefresher := services.NewMetadataRefresher(pacsService, services.MetadataRefreshConfig{
+			Interval:   cfg.Cache.RefreshInterval,
+			Ahead:      cfg.Cache.RefreshAhead,
+			MinHits:    cfg.Cache.RefreshMinHits,
+			MaxTracked: cfg.Cache.RefreshMaxKeys,
+		})
+		metadataRefresher.Start()
+		defer metadataRefresher.Stop()
+	}
+
+	// Per-read cache metrics, written to the database in batches
+	cacheMetricsService := services.NewCacheMetricsService(cacheMetricsRepo, services.CacheMetricsConfig{
+		Retention: cfg.Cache.MetricsRetention,
+	})
+	cacheMetricsService.Start()
+	defer cacheMetricsService.Stop()
+	if cfg.Cache.MetricsEnabled {
+		instrumentedCache.RecordTo(cacheMetricsService)
+	}
+
+	// Per-tenant usage for billing, added to the database every minute
+	usageService := services.NewUsageService(usageRepo)
+	usageService.Start()
+	defer usageService.Stop()
+	if cfg.Usage.Enabled {
+		pacsService.EnableUsageMetering(usageService)
+		instrumentedCache.RecordTo(usageService)
+	}
+
+	// Audit log of every data-access request, written to the database in batches
+	var auditService *services.AuditService
+	if cfg.Audit.Enabled {
+		auditService = services.NewAuditService(auditRepo, services.AuditConfig{
+			Retention: cfg.Audit.Retention,
+		})
+		if cfg.Audit.ATNAAddress != "" {
+			emitter, err := atna.NewEmitter(atna.Config{
+				Address:  cfg.Audit.ATNAAddress,
+				CAFile:   cfg.Audit.ATNACAFile,
+				CertFile: cfg.Audit.ATNACertFile,
+				KeyFile:  cfg.Audit.ATNAKeyFile,
+				SourceID: cfg.Audit.ATNASourceID,
+				SiteID:   cfg.Audit.ATNASiteID,
+				AppName:  cfg.Audit.ATNAAppName,
+			})
+			if err != nil {
+				log.Fatal().Err(err).Msg("Failed to initialize ATNA audit messages")
+			}
+			emitter.Start()
+			defer emitter.Stop()
+			auditService.EnableATNA(emitter)
+		}
+		auditService.Start()
+		defer auditService.Stop()
+		pacsService.EnableAuditWriter(auditService)
+	}
+
+	// PACS health checks, which record connection statuses, raise
+	// pacs.down/pacs.up events and drive failover
+	if cfg.Webhook.PACSCheckInterval > 0 {
+		connectionMonitor := services.NewConnectionMonitor(pacsService, cfg.Webhook.PACSCheckInterval, cfg.Webhook.PACSDegradeAfter, cfg.Webhook.PACSTestRetention)
+		connectionMonitor.Start()
+		defer connectionMonitor.Stop()
+	}
+
+	// Cache warm-up, requested through the API or driven by HL7 orders
+	prefetchConfig := services.PrefetchConfig{
+		FacilityTenants: make(map[string]uuid.UUID, len(cfg.HL7.FacilityTenants)),
+		Workers:         cfg.HL7.PrefetchWorkers,
+		Options: services.PrefetchOptions{
+			MaxPriors:    cfg.HL7.PrefetchPriors,
+			Lookback:     cfg.HL7.PrefetchLookback,
+			SameModality: cfg.HL7.PrefetchSameModality,
+			SameBodyPart: cfg.HL7.PrefetchSameBodyPart,
+			TTL:          cfg.HL7.PrefetchTTL,
+		},
+		OnStudyOpen: cfg.HL7.PrefetchOnOpen,
+	}
+	if cfg.HL7.Enabled {
+		if cfg.HL7.TenantID != "" {
+			prefetchConfig.DefaultTenant = uuid.MustParse(cfg.HL7.TenantID)
+		}
+		for facility, tenantID := range cfg.HL7.FacilityTenants {
+			prefetchConfig.FacilityTenants[facility] = uuid.MustParse(tenantID)
+		}
+	}
+	prefetchService := services.NewPrefetchService(pacsService, prefetchRepo, prefetchConfig)
+	prefetchService.Start()
+	defer prefetchService.Stop()
+
+	// Pinned studies are kept in the cache and fetched again when missing
+	pinService := services.NewPinService(pacsService, pinRepo, cachePins, services.PinConfig{
+		RefreshInterval: cfg.Cache.PinRefresh,
+		TTL:             cfg.Cache.PinTTL,
+	})
+	pinService.Start()
+	defer pinService.Stop()
+
+	// The cache is compared with the PACS on request and, with an interval, on a schedule
+	verificationService := services.NewVerificationService(pacsService, pacsRepo, verificationRepo, services.VerificationConfig{
+		Interval:     cfg.Cache.VerifyInterval,
+		LookbackDays: cfg.Cache.VerifyLookback,
+		Repair:       cfg.Cache.VerifyRepair,
+	})
+	verificationService.Start()
+	defer verificationService.Stop()
+
+	// New studies on each tenant's primary PACS are indexed on a schedule
+	syncService := services.NewSyncService(pacsService, pacsRepo, studyIndexRepo, services.SyncConfig{
+		Interval:    cfg.Sync.Interval,
+		InitialDays: cfg.Sync.InitialDays,
+		OverlapDays: cfg.Sync.OverlapDays,
+	})
+	if cfg.Sync.Enabled {
+		syncService.Start()
+		defer syncService.Stop()
+	}
+	// Search results are kept in the study index, which may answer later searches
+	if cfg.Sync.IndexMode != services.StudyIndexOff {
+		pacsService.EnableStudyIndex(studyIndexRepo, services.StudyIndexConfig{
+			Mode:   cfg.Sync.IndexMode,
+			MaxAge: cfg.Sync.IndexMaxAge,
+		})
+	}
+
+	// Order-driven prefetch of prior studies over HL7 MLLP
+	var hl7Server *hl7.Server
+	if cfg.HL7.Enabled {
+		hl7Addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.HL7.Port)
+		hl7Server = hl7.NewServer(hl7Addr, prefetchService)
+		go func() {
+			log.Info().Str("addr", hl7Addr).Msg("HL7 listener starting")
+			if err := hl7Server.ListenAndServe(); err != nil && err != hl7.ErrServerClosed {
+				log.Fatal().Err(err).Msg("HL7 listener failed to start")
+			}
+		}()
+	}
+
+	// Role-based access control; each route group and RPC declares the scopes it needs
+	authz := middleware.NewAuthorizer(middleware.AuthzConfig{
+		Enabled:    cfg.Auth.RBACEnabled,
+		JWTSecret:  []byte(cfg.Auth.JWTSecret),
+		AdminToken: cfg.Auth.AdminToken,
+	})
+
+	// gRPC API for internal services
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled {
+		grpcAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.GRPC.Port)
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			log.Fatal().Err(err).Str("addr", grpcAddr).Msg("gRPC API failed to listen")
+		}
+		grpcServer = grpcapi.Register(grpcapi.NewServer(pacsService, cfg.Auth.AdminToken, authz, apiKeyService, tenantService.LookupTenant))
+		go func() {
+			log.Info().Str("addr", grpcAddr).Msg("gRPC API starting")
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Fatal().Err(err).Msg("gRPC API failed to start")
+			}
+		}()
+	}
 
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler()
 	dicomwebHandler := handlers.NewDICOMWebHandler(pacsService)
 	managementHandler := handlers.NewManagementHandler(pacsService)
+	workitemHandler := handlers.NewWorkitemHandler(worklistService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	exportHandler := handlers.NewExportHandler(exportService, cfg.Server.PublicURL)
+	researchHandler := handlers.NewResearchHandler(pacsService)
+	patientHandler := handlers.NewPatientHandler(pacsService)
+	prefetchHandler := handlers.NewPrefetchHandler(prefetchService)
+	pinHandler := handlers.NewPinHandler(pinService)
+	verificationHandler := handlers.NewVerificationHandler(verificationService)
+	syncHandler := handlers.NewSyncHandler(syncService)
+	jobHandler := handlers.NewJobHandler(retrieveJobService)
+	cacheMetricsHandler := handlers.NewCacheMetricsHandler(cacheMetricsService)
+	usageHandler := handlers.NewUsageHandler(usageService)
+	fhirHandler := handlers.NewFHIRHandler(pacsService, cfg.Server.PublicURL)
+	smartHandler := handlers.NewSMARTHandler(viewerGrantService, cfg.Server.PublicURL)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	tenantHandler := handlers.NewTenantHandler(tenantService)
+	graphqlHandler, err := handlers.NewGraphQLHandler(pacsService)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to build GraphQL schema")
+	}
+	iidHandler := handlers.NewIIDHandler(pacsService,
+		viewer.NewLauncher(cfg.Viewer.LaunchURL, cfg.Viewer.TokenSecret, cfg.Viewer.TokenTTL),
+		cfg.Server.PublicURL)
+
+	// Audit middleware per route group; requests pass through unrecorded when
+	// auditing is disabled
+	auditConfig := middleware.AuditConfig{
+		JWTSecret: []byte(cfg.Audit.JWTSecret),
+		UserClaim: cfg.Audit.UserClaim,
+	}
+	auditRequests := func(category string) func(http.Handler) http.Handler {
+		if auditService == nil {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		return middleware.Audit(category, auditConfig, auditService.Record)
+	}
+
+	// Usage middleware per route group; requests pass through unmetered when
+	// metering is disabled
+	meterRequests := func(category string) func(http.Handler) http.Handler {
+		if !cfg.Usage.Enabled {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		return middleware.Usage(category, usageService.RecordRequest)
+	}
 
 	// Setup router
 	r := chi.NewRouter()
@@ -99,14 +653,17 @@ func main() {
 	r.Use(chimiddleware.RealIP)
 	r.Use(middleware.Recovery)
 	r.Use(middleware.Logging)
-	r.Use(chimiddleware.Compress(5))
+
+	// Compression applies to JSON responses only; binary DICOM and JPEG
+	// payloads are streamed as-is so viewers can start rendering sooner
+	compress := chimiddleware.Compress(5, "application/json", "application/dicom+json", "application/fhir+json", "text/plain")
 
 	// CORS
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   cfg.CORS.AllowedOrigins,
 		AllowedMethods:   cfg.CORS.AllowedMethods,
 		AllowedHeaders:   cfg.CORS.AllowedHeaders,
-		ExposedHeaders:   []string{"Content-Length", "Content-Type"},
+		ExposedHeaders:   []string{"Content-Length", "Content-Type", "Content-Range", "Accept-Ranges", "ETag", "Warning", "Retry-After", "X-Query-Source", "X-Index-Updated-At"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
@@ -120,36 +677,242 @@ func main() {
 		r.Handle("/metrics", promhttp.Handler())
 	}
 
-	// DICOMweb endpoints (require tenant ID)
+	// DICOMweb endpoints (require tenant ID, an API key, a bearer JWT, or a viewer grant scoped to one study)
 	r.Route("/dicom-web", func(r chi.Router) {
-		r.Use(middleware.TenantID)
+		r.Use(smartHandler.ViewerGrants)
+		r.Use(apiKeyHandler.APIKeys)
+		r.Use(authz.Authenticate)
+		r.Use(middleware.TenantID(tenantService.LookupTenant))
+		r.Use(authz.RequireReadWrite(models.ScopeDICOMWebRead, models.ScopeDICOMWebWrite))
+		r.Use(handlers.StudyAuthorization)
+		r.Use(auditRequests("dicomweb"))
+		r.Use(meterRequests("dicomweb"))
+		if rateLimiter != nil {
+			r.Use(middleware.RateLimit(rateLimiter, pacsService.TenantRateLimit))
+		}
+		r.Use(handlers.RouteHints)
+
+		r.Group(func(r chi.Router) {
+			r.Use(compress)
 
-		// QIDO-RS (Query)
-		r.Get("/studies", dicomwebHandler.SearchStudies)
-		r.Get("/studies/{studyUID}/series", dicomwebHandler.SearchSeries)
-		r.Get("/studies/{studyUID}/series/{seriesUID}/instances", dicomwebHandler.SearchInstances)
+			// Capabilities / conformance
+			r.Options("/", dicomwebHandler.Capabilities)
+			r.Get("/capabilities", dicomwebHandler.Capabilities)
 
-		// WADO-RS (Retrieve)
-		r.Get("/studies/{studyUID}/metadata", dicomwebHandler.GetStudyMetadata)
+			// QIDO-RS (Query); HEAD runs the query and discards the body
+			r.Get("/studies", dicomwebHandler.SearchStudies)
+			r.Get("/studies/{studyUID}/series", dicomwebHandler.SearchSeries)
+			r.Get("/studies/{studyUID}/series/{seriesUID}/instances", dicomwebHandler.SearchInstances)
+			r.Head("/studies", dicomwebHandler.SearchStudies)
+			r.Head("/studies/{studyUID}/series", dicomwebHandler.SearchSeries)
+			r.Head("/studies/{studyUID}/series/{seriesUID}/instances", dicomwebHandler.SearchInstances)
+
+			// WADO-RS (Metadata)
+			r.Get("/studies/{studyUID}/metadata", dicomwebHandler.GetStudyMetadata)
+			r.Get("/studies/{studyUID}/series/{seriesUID}/metadata", dicomwebHandler.GetSeriesMetadata)
+			r.Get("/studies/{studyUID}/series/{seriesUID}/instances/{instanceUID}/metadata", dicomwebHandler.GetInstanceMetadata)
+
+			// UPS-RS (Worklist)
+			r.Post("/workitems", workitemHandler.CreateWorkitem)
+			r.Get("/workitems", workitemHandler.SearchWorkitems)
+			r.Get("/workitems/{workitemUID}", workitemHandler.RetrieveWorkitem)
+			r.Post("/workitems/{workitemUID}", workitemHandler.UpdateWorkitem)
+			r.Put("/workitems/{workitemUID}/state", workitemHandler.ChangeWorkitemState)
+		})
+
+		// WADO-RS (Retrieve, uncompressed streaming)
+		r.Get("/studies/{studyUID}", dicomwebHandler.RetrieveStudy)
+		r.Get("/studies/{studyUID}/series/{seriesUID}", dicomwebHandler.RetrieveSeries)
 		r.Get("/studies/{studyUID}/series/{seriesUID}/instances/{instanceUID}", dicomwebHandler.RetrieveInstance)
+		r.Get("/studies/{studyUID}/series/{seriesUID}/instances/{instanceUID}/frames/{frameList}", dicomwebHandler.RetrieveFrames)
+		r.Get("/studies/{studyUID}/series/{seriesUID}/instances/{instanceUID}/rendered", dicomwebHandler.RetrieveRendered)
+
+		// Existence checks
+		r.Head("/studies/{studyUID}", dicomwebHandler.ObjectExists)
+		r.Head("/studies/{studyUID}/series/{seriesUID}", dicomwebHandler.ObjectExists)
+		r.Head("/studies/{studyUID}/series/{seriesUID}/instances/{instanceUID}", dicomwebHandler.ObjectExists)
+
+		// WADO-RS (Rendered thumbnails)
+		r.Get("/studies/{studyUID}/thumbnail", dicomwebHandler.RetrieveStudyThumbnail)
+		r.Get("/studies/{studyUID}/series/{seriesUID}/thumbnail", dicomwebHandler.RetrieveSeriesThumbnail)
+		r.Get("/studies/{studyUID}/series/{seriesUID}/instances/{instanceUID}/thumbnail", dicomwebHandler.RetrieveInstanceThumbnail)
+
+		// Study deletion (admin only)
+		r.With(authz.Require(models.ScopeAdmin), middleware.RequireAdmin(cfg.Auth.AdminToken)).Delete("/studies/{studyUID}", dicomwebHandler.DeleteStudy)
+	})
+
+	// FHIR R4 ImagingStudy facade over QIDO-RS (require tenant ID, an API key or a bearer JWT)
+	r.Route("/fhir", func(r chi.Router) {
+		r.Use(apiKeyHandler.APIKeys)
+		r.Use(authz.Authenticate)
+		r.Use(middleware.TenantID(tenantService.LookupTenant))
+		r.Use(authz.Require(models.ScopeFHIRRead))
+		r.Use(handlers.StudyAuthorization)
+		r.Use(auditRequests("fhir"))
+		r.Use(meterRequests("fhir"))
+		r.Use(handlers.RouteHints)
+		r.Use(compress)
+
+		r.Get("/ImagingStudy", fhirHandler.SearchImagingStudy)
+		r.Get("/ImagingStudy/{id}", fhirHandler.ReadImagingStudy)
 	})
 
-	// Management API
+	// GraphQL over the imaging hierarchy (require tenant ID, an API key or a
+	// bearer JWT); queries only read, so POST needs the read scope as well
+	r.Group(func(r chi.Router) {
+		r.Use(apiKeyHandler.APIKeys)
+		r.Use(authz.Authenticate)
+		r.Use(middleware.TenantID(tenantService.LookupTenant))
+		r.Use(authz.Require(models.ScopeDICOMWebRead))
+		r.Use(handlers.StudyAuthorization)
+		r.Use(auditRequests("graphql"), meterRequests("graphql"), handlers.RouteHints, compress)
+
+		r.Get("/graphql", graphqlHandler.Query)
+		r.Post("/graphql", graphqlHandler.Query)
+	})
+
+	// IHE Invoke Image Display; launched from the RIS in a browser, so the
+	// tenant may also be given as a query parameter, next to a credential
+	r.With(
+		apiKeyHandler.APIKeys,
+		authz.Authenticate,
+		middleware.TenantIDOrQuery("tenantID", tenantService.LookupTenant),
+		authz.Require(models.ScopeDICOMWebRead),
+		handlers.StudyAuthorization,
+		auditRequests("iid"),
+	).Get("/IHEInvokeImageDisplay", iidHandler.InvokeImageDisplay)
+
+	// Export archive and metadata downloads, authorized by their signed link instead of a tenant header
+	r.Get("/api/v1/export/downloads/{id}", exportHandler.DownloadExport)
+	r.Get("/api/v1/export/metadata/downloads/{id}", exportHandler.DownloadMetadataExport)
+
+	// Tenant provisioning and lifecycle; spans tenants, so only the admin token
+	// is accepted
+	r.Route("/api/v1/tenants", func(r chi.Router) {
+		r.Use(middleware.RequireAdmin(cfg.Auth.AdminToken))
+		r.Use(compress)
+
+		r.Post("/", tenantHandler.CreateTenant)
+		r.Get("/", tenantHandler.GetTenants)
+		r.Get("/{id}", tenantHandler.GetTenant)
+		r.Put("/{id}", tenantHandler.UpdateTenant)
+		r.Delete("/{id}", tenantHandler.DeleteTenant)
+		r.Post("/{id}/disable", tenantHandler.DisableTenant)
+		r.Post("/{id}/enable", tenantHandler.EnableTenant)
+		r.Post("/{id}/keys/rotate", tenantHandler.RotateTenantKeys)
+	})
+
+	// Management API (require tenant ID, an API key, a request signed with an API key or a bearer JWT)
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Use(middleware.TenantID)
-
-		// PACS configuration
-		r.Post("/pacs/config", managementHandler.CreatePACSConfig)
-		r.Get("/pacs/config", managementHandler.GetPACSConfigs)
-		r.Get("/pacs/config/{id}", managementHandler.GetPACSConfig)
-
-		// Connection testing (no tenant ID required)
-		r.With(func(next http.Handler) http.Handler {
-			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Skip tenant middleware for this route
-				next.ServeHTTP(w, r)
-			})
-		}).Post("/pacs/test", managementHandler.TestConnection)
+		r.Use(apiKeyHandler.SignedRequests)
+		r.Use(apiKeyHandler.APIKeys)
+		r.Use(authz.Authenticate)
+		r.Use(middleware.TenantID(tenantService.LookupTenant))
+		r.Use(handlers.StudyAuthorization)
+		r.Use(auditRequests("management"))
+		r.Use(compress)
+
+		// Tenant management
+		r.Group(func(r chi.Router) {
+			r.Use(authz.RequireReadWrite(models.ScopeManagementRead, models.ScopeManagementWrite))
+
+			// PACS configuration
+			r.Post("/pacs/config", managementHandler.CreatePACSConfig)
+			r.Get("/pacs/config", managementHandler.GetPACSConfigs)
+			r.Get("/pacs/config/{id}", managementHandler.GetPACSConfig)
+			r.Put("/pacs/config/{id}", managementHandler.UpdatePACSConfig)
+			r.Delete("/pacs/config/{id}", managementHandler.DeletePACSConfig)
+			r.Post("/pacs/config/{id}/deactivate", managementHandler.DeactivatePACSConfig)
+			r.Post("/pacs/config/{id}/activate", managementHandler.ActivatePACSConfig)
+			r.Post("/pacs/config/{id}/restore", managementHandler.RestorePACSConfig)
+			r.Get("/pacs/config/{id}/tests", managementHandler.GetConnectionTests)
+
+			// Routing of requests to the tenant's PACS configs
+			r.Post("/pacs/routing-rules", managementHandler.CreateRoutingRule)
+			r.Get("/pacs/routing-rules", managementHandler.GetRoutingRules)
+			r.Delete("/pacs/routing-rules/{id}", managementHandler.DeleteRoutingRule)
+			r.Get("/tenant/settings", managementHandler.GetTenantSettings)
+			r.Put("/tenant/settings", managementHandler.UpdateTenantSettings)
+			r.Delete("/tenant/settings", managementHandler.ResetTenantSettings)
+
+			// Outbound event webhooks
+			r.Post("/webhooks", webhookHandler.CreateWebhook)
+			r.Get("/webhooks", webhookHandler.GetWebhooks)
+			r.Delete("/webhooks/{id}", webhookHandler.DeleteWebhook)
+			r.Get("/webhooks/deliveries", webhookHandler.GetDeliveries)
+			r.Post("/webhooks/deliveries/{id}/redeliver", webhookHandler.RedeliverDelivery)
+
+			// Patient imaging history across every PACS, for the RIS timeline
+			r.Get("/patients/{patientID}/studies", patientHandler.GetStudies)
+
+			// Research mode: studies de-identified for a de-identification context
+			r.Get("/research/{context}/studies/{studyUID}/metadata", researchHandler.GetStudyMetadata)
+			r.Get("/research/{context}/studies/{studyUID}/series/{seriesUID}/instances/{instanceUID}", researchHandler.RetrieveInstance)
+
+			// Studies pinned in the cache for teaching files and tumor boards
+			r.Put("/pinned-studies/{studyUID}", pinHandler.PinStudy)
+			r.Get("/pinned-studies", pinHandler.GetPinnedStudies)
+			r.Delete("/pinned-studies/{studyUID}", pinHandler.UnpinStudy)
+
+			// SMART on FHIR launch hand-off to the viewer
+			r.Post("/smart/viewer-grants", smartHandler.CreateViewerGrant)
+
+			// XDS-I.b retrieve (RAD-69) from the tenant's imaging document source
+			r.Post("/xds/retrieve", managementHandler.RetrieveImagingDocumentSet)
+
+			// Connection testing (no tenant ID required)
+			r.With(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					// Skip tenant middleware for this route
+					next.ServeHTTP(w, r)
+				})
+			}).Post("/pacs/test", managementHandler.TestConnection)
+		})
+
+		// Study export to a ZIP archive with a DICOMDIR
+		r.Group(func(r chi.Router) {
+			r.Use(authz.Require(models.ScopeExport))
+			r.Post("/export/studies/{studyUID}", exportHandler.ExportStudy)
+			r.Get("/export/jobs/{id}", exportHandler.GetExportJob)
+			r.Post("/export/destinations", exportHandler.CreateDestination)
+			r.Get("/export/destinations", exportHandler.GetDestinations)
+			r.Delete("/export/destinations/{id}", exportHandler.DeleteDestination)
+		})
+
+		// Cache warm-up jobs and background retrieval of studies and series into the cache
+		r.Group(func(r chi.Router) {
+			r.Use(authz.Require(models.ScopePrefetch))
+			r.Post("/prefetch", prefetchHandler.PrefetchStudies)
+			r.Get("/prefetch/jobs/{id}", prefetchHandler.GetPrefetchJob)
+			r.Post("/jobs", jobHandler.CreateJob)
+			r.Get("/jobs/{id}", jobHandler.GetJob)
+			r.Post("/jobs/{id}/cancel", jobHandler.CancelJob)
+			r.Post("/jobs/{id}/retry", jobHandler.RetryJob)
+		})
+
+		// Archive extensions (dcm4chee), object store indexing (s3), PACS synchronization, cache administration and verification, usage reports, metadata exports, audit chain verification and API keys; admin only
+		r.Group(func(r chi.Router) {
+			r.Use(authz.Require(models.ScopeAdmin))
+			r.Use(middleware.RequireAdmin(cfg.Auth.AdminToken))
+			r.Post("/keys", apiKeyHandler.CreateKey)
+			r.Get("/keys", apiKeyHandler.GetKeys)
+			r.Post("/keys/{id}/rotate", apiKeyHandler.RotateKey)
+			r.Delete("/keys/{id}", apiKeyHandler.RevokeKey)
+			r.Post("/pacs/reindex", managementHandler.ReindexObjectStore)
+			r.Get("/pacs/sync", syncHandler.GetSyncState)
+			r.Delete("/cache", managementHandler.PurgeCache)
+			r.Get("/cache/stats", managementHandler.GetCacheStats)
+			r.Get("/cache/metrics", cacheMetricsHandler.GetCacheMetrics)
+			r.Post("/cache/verifications", verificationHandler.VerifyCache)
+			r.Get("/cache/verifications/{id}", verificationHandler.GetVerification)
+			r.Get("/usage", usageHandler.GetUsage)
+			r.Get("/audit/verify", managementHandler.VerifyAuditChain)
+			r.Post("/export/metadata", exportHandler.ExportMetadata)
+			r.Get("/export/metadata/{id}", exportHandler.GetMetadataExportJob)
+			r.Post("/archive/studies/{studyUID}/reject", managementHandler.RejectStudy)
+			r.Post("/archive/studies/{studyUID}/export", managementHandler.ExportStudy)
+			r.Post("/archive/patients/merge", managementHandler.MergePatients)
+		})
 	})
 
 	// Create server
@@ -161,10 +924,30 @@ func main() {
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
 
+	// HTTPS, and mutual TLS with a client CA bundle, on the listener itself so
+	// the connector needs no reverse proxy in front of it
+	if cfg.Server.TLSCertFile != "" {
+		tlsConfig, err := serverTLSConfig(cfg.Server)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to configure TLS")
+		}
+		srv.TLSConfig = tlsConfig
+	}
+
 	// Start server in a goroutine
 	go func() {
-		log.Info().Str("addr", addr).Msg("Server starting")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if srv.TLSConfig != nil {
+			log.Info().
+				Str("addr", addr).
+				Bool("mutual_tls", srv.TLSConfig.ClientCAs != nil).
+				Msg("Server starting with TLS")
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			log.Info().Str("addr", addr).Msg("Server starting")
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("Server failed to start")
 		}
 	}()
@@ -180,6 +963,16 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if hl7Server != nil {
+		if err := hl7Server.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close HL7 listener")
+		}
+	}
+
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal().Err(err).Msg("Server forced to shutdown")
 	}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// certificateCheckInterval is how often the listener's certificate files are
+// checked for a renewed certificate
+const certificateCheckInterval = 30 * time.Second
+
+// serverTLSConfig builds the TLS configuration of the HTTP listener: its
+// certificate, reloaded when the files are renewed, and client certificates
+// verified against the client CA bundle when mutual TLS is enabled
+func serverTLSConfig(cfg config.ServerConfig) (*tls.Config, error) {
+	certs, err := newCertificateReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+
+	if cfg.TLSClientCAFile == "" {
+		return tlsConfig, nil
+	}
+	pem, err := os.ReadFile(cfg.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("failed to parse TLS client CA bundle %s", cfg.TLSClientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+	switch cfg.TLSClientAuth {
+	case config.TLSClientAuthOptional:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// certificateReloader serves the certificate of a certificate and key file
+// pair, loading it again once the files change so renewed certificates are
+// picked up without a restart
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time // of the newer file, when last loaded
+	checked time.Time
+}
+
+// newCertificateReloader loads a certificate and key file pair
+func newCertificateReloader(certFile, keyFile string) (*certificateReloader, error) {
+	r := &certificateReloader{certFile: certFile, keyFile: keyFile}
+	modTime, err := r.modified()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) >= certificateCheckInterval {
+		r.checked = time.Now()
+		modTime, err := r.modified()
+		if err == nil && modTime.After(r.modTime) {
+			err = r.load(modTime)
+			if err == nil {
+				log.Info().Str("cert_file", r.certFile).Msg("TLS certificate reloaded")
+			}
+		}
+		if err != nil {
+			// A renewal caught halfway is picked up at the next check
+			log.Warn().Err(err).Str("cert_file", r.certFile).Msg("Failed to reload TLS certificate, serving the previous one")
+		}
+	}
+	return r.cert, nil
+}
+
+// load reads the certificate and key files
+func (r *certificateReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	r.checked = time.Now()
+	return nil
+}
+
+// modified returns when the certificate or key file last changed
+func (r *certificateReloader) modified() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to read TLS certificate: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
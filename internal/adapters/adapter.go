@@ -2,11 +2,31 @@ package adapters
 
 import (
 	"context"
+	"errors"
 	"io"
 
 	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
 )
 
+// ErrRangeNotSatisfiable is returned when the PACS rejects a byte range request
+var ErrRangeNotSatisfiable = errors.New("requested range not satisfiable")
+
+// ErrNotFound is returned when the PACS has no object for the requested UID
+var ErrNotFound = errors.New("object not found")
+
+// ErrNotSupported is returned when the PACS does not support the requested operation
+var ErrNotSupported = errors.New("operation not supported by PACS")
+
+// ErrInvalidRequest is returned when the PACS rejects a request as malformed
+var ErrInvalidRequest = errors.New("request rejected by PACS")
+
+// ErrTooLarge is returned when the PACS refuses a request whose result would be too large
+var ErrTooLarge = errors.New("result too large")
+
+// ErrUnavailable is returned when the PACS cannot be reached or reports itself unavailable
+var ErrUnavailable = errors.New("PACS unavailable")
+
 // PACSAdapter defines the interface that all PACS adapters must implement
 type PACSAdapter interface {
 	// Query operations
@@ -14,13 +34,26 @@ type PACSAdapter interface {
 	FindSeries(ctx context.Context, studyUID string) ([]models.Series, error)
 	FindInstances(ctx context.Context, studyUID, seriesUID string) ([]models.Instance, error)
 
+	// ObjectExists probes for a study, series or instance; empty series/instance
+	// UIDs select the study or series level
+	ObjectExists(ctx context.Context, studyUID, seriesUID, instanceUID string) (bool, error)
+
 	// Retrieve operations
-	GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string) (io.ReadCloser, string, error)
+	GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error)
 	GetInstanceMetadata(ctx context.Context, studyUID, seriesUID, instanceUID string) (*models.Metadata, error)
+	GetSeriesMetadata(ctx context.Context, studyUID, seriesUID string) ([]models.Metadata, error)
 	GetStudyMetadata(ctx context.Context, studyUID string) ([]models.Metadata, error)
 
+	// GetFrame retrieves the pixel data of a single frame (1-based) of a multi-frame instance
+	GetFrame(ctx context.Context, studyUID, seriesUID, instanceUID string, frame int) (io.ReadCloser, string, error)
+	// GetRendered retrieves a server-side rendering of an instance in the given media type (e.g. video/mp4)
+	GetRendered(ctx context.Context, studyUID, seriesUID, instanceUID, mediaType string) (io.ReadCloser, string, error)
+
+	// Storage management
+	DeleteStudy(ctx context.Context, studyUID string) error
+
 	// Thumbnail operations
-	GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, size int) ([]byte, error)
+	GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error)
 
 	// Connection management
 	TestConnection(ctx context.Context) (*models.ConnectionStatus, error)
@@ -31,6 +64,28 @@ type PACSAdapter interface {
 	Capabilities() []string
 }
 
+// ArchiveManager is implemented by adapters whose archive exposes management
+// extensions beyond DICOMweb: IOCM rejection notes, exports and patient merges
+type ArchiveManager interface {
+	Reject(ctx context.Context, req models.RejectionRequest) error
+	Export(ctx context.Context, req models.ExportRequest) error
+	MergePatients(ctx context.Context, req models.PatientMergeRequest) error
+}
+
+// ObjectIndexer is implemented by adapters that answer queries from an index of
+// the objects they store, which must be rebuilt when objects are added outside
+// the connector
+type ObjectIndexer interface {
+	Reindex(ctx context.Context) (*models.ReindexResult, error)
+}
+
+// ImagingDocumentRetriever is implemented by adapters that pull instances from an
+// XDS-I.b Imaging Document Source with the RAD-69 transaction. visit is called for
+// each returned document and must consume data before returning.
+type ImagingDocumentRetriever interface {
+	RetrieveImagingDocumentSet(ctx context.Context, req models.ImagingDocumentSetRequest, visit func(doc models.ImagingDocument, data io.Reader) error) error
+}
+
 // BaseAdapter provides common functionality for all adapters
 type BaseAdapter struct {
 	config models.PACSConfig
@@ -0,0 +1,184 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/cache"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// S3CacheConfig locates the bucket an S3Cache keeps its entries in
+type S3CacheConfig struct {
+	Bucket          string
+	Region          string
+	Endpoint        string // S3-compatible endpoint; empty for AWS
+	PathStyle       bool
+	Prefix          string // key prefix of cache entries
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3Cache is a cache kept in an S3 or S3-compatible bucket, suited as the
+// slowest and largest tier of a cache.TieredCache. S3 has no per-object TTL, so
+// an entry's expiry is stored in its Expires header; expired entries read back
+// are deleted and reported as missing. A bucket lifecycle rule on the prefix
+// should remove the entries that are never read again.
+type S3Cache struct {
+	client *s3Client
+	prefix string
+}
+
+// NewS3Cache creates an S3 cache
+func NewS3Cache(config S3CacheConfig) (*S3Cache, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("s3 cache requires a bucket")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 cache requires an access key ID and secret access key")
+	}
+
+	region := config.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	endpoint, err := s3EndpointURL(models.PACSConfig{Endpoint: config.Endpoint}, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Cache{
+		client: &s3Client{
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+			endpoint:   endpoint,
+			bucket:     config.Bucket,
+			region:     region,
+			pathStyle:  config.PathStyle,
+			accessKey:  config.AccessKeyID,
+			secretKey:  config.SecretAccessKey,
+		},
+		prefix: config.Prefix,
+	}, nil
+}
+
+// Get retrieves a value from cache
+func (c *S3Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := c.client.getObject(ctx, c.prefix+key, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, cache.ErrCacheMiss
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, s3CacheStatusError(resp)
+	}
+	if s3CacheExpired(resp) {
+		c.client.deleteObject(ctx, c.prefix+key)
+		return nil, cache.ErrCacheMiss
+	}
+
+	value, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	return value, nil
+}
+
+// Set stores a value in cache
+func (c *S3Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	req, err := c.client.newRequest(ctx, "PUT", c.prefix+key, nil)
+	if err != nil {
+		return err
+	}
+	setRequestBody(req, bytes.NewReader(value), int64(len(value)))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if ttl > 0 {
+		req.Header.Set("Expires", time.Now().Add(ttl).UTC().Format(http.TimeFormat))
+	}
+
+	resp, err := c.client.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3CacheStatusError(resp)
+	}
+	return nil
+}
+
+// Delete removes a value from cache
+func (c *S3Cache) Delete(ctx context.Context, key string) error {
+	return c.client.deleteObject(ctx, c.prefix+key)
+}
+
+// Exists checks if a key exists
+func (c *S3Cache) Exists(ctx context.Context, key string) (bool, error) {
+	req, err := c.client.newRequest(ctx, "HEAD", c.prefix+key, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.client.do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return !s3CacheExpired(resp), nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, s3CacheStatusError(resp)
+}
+
+// Clear removes all keys matching a glob pattern. Keys are listed by the
+// pattern's literal prefix and matched against the rest.
+func (c *S3Cache) Clear(ctx context.Context, pattern string) error {
+	literal, _, _ := strings.Cut(pattern, "*")
+
+	token := ""
+	for {
+		page, err := c.client.listObjects(ctx, c.prefix+literal, token, 1000)
+		if err != nil {
+			return fmt.Errorf("failed to list cache entries: %w", err)
+		}
+		for _, object := range page.Contents {
+			if ok, _ := path.Match(pattern, strings.TrimPrefix(object.Key, c.prefix)); !ok {
+				continue
+			}
+			if err := c.client.deleteObject(ctx, object.Key); err != nil {
+				return fmt.Errorf("failed to delete key %s: %w", object.Key, err)
+			}
+		}
+		if !page.IsTruncated {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// s3CacheExpired reports whether a cache entry's Expires header has passed
+func s3CacheExpired(resp *http.Response) bool {
+	expires, err := http.ParseTime(resp.Header.Get("Expires"))
+	return err == nil && time.Now().After(expires)
+}
+
+// s3CacheStatusError converts an unexpected S3 response into an error
+func s3CacheStatusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("s3 cache returned status %d: %s", resp.StatusCode, string(body))
+}
@@ -0,0 +1,456 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+	"github.com/rs/zerolog/log"
+)
+
+// compositeRouteLimit bounds the remembered study locations; the table is
+// cleared when it fills up
+const compositeRouteLimit = 10000
+
+// compositeMember is one PACS of a federation
+type compositeMember struct {
+	name    string
+	adapter PACSAdapter
+}
+
+// CompositeAdapter federates several PACS as one archive. Queries run against
+// every member concurrently and are merged by UID, earlier members winning for
+// duplicates; retrievals go to the member known to hold the study and fall
+// through the others in order when it does not have the object.
+type CompositeAdapter struct {
+	BaseAdapter
+	members []compositeMember
+
+	mu     sync.Mutex
+	routes map[string]int // study UID to the index of the member that returned it
+}
+
+// NewCompositeAdapter creates a composite adapter over the member configs, in
+// priority order. The composite owns the member adapters and closes them.
+func NewCompositeAdapter(config models.PACSConfig, members []models.PACSConfig) (*CompositeAdapter, error) {
+	if len(members) == 0 {
+		return nil, fmt.Errorf("federated PACS requires at least one member")
+	}
+
+	c := &CompositeAdapter{
+		BaseAdapter: BaseAdapter{config: config},
+		routes:      make(map[string]int),
+	}
+	for _, member := range members {
+		if member.Type == models.PACSTypeFederated {
+			return nil, fmt.Errorf("federated PACS %s cannot be a member of another federation", member.Name)
+		}
+		adapter, err := newAdapter(member)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to create member %s: %w", member.Name, err)
+		}
+		c.members = append(c.members, compositeMember{name: member.Name, adapter: adapter})
+	}
+
+	return c, nil
+}
+
+func (c *CompositeAdapter) Type() models.PACSType {
+	return models.PACSTypeFederated
+}
+
+// Capabilities returns the union of the members' capabilities
+func (c *CompositeAdapter) Capabilities() []string {
+	seen := make(map[string]bool)
+	capabilities := []string{"FEDERATED"}
+	for _, member := range c.members {
+		for _, capability := range member.adapter.Capabilities() {
+			if !seen[capability] {
+				seen[capability] = true
+				capabilities = append(capabilities, capability)
+			}
+		}
+	}
+	return capabilities
+}
+
+// memberResult is the outcome of a query against one member
+type memberResult[T any] struct {
+	items []T
+	err   error
+}
+
+// queryMembers runs a query against every member concurrently. Members that
+// fail are logged and skipped; an error is returned only when none succeeded.
+func queryMembers[T any](ctx context.Context, c *CompositeAdapter, operation string, query func(ctx context.Context, adapter PACSAdapter) ([]T, error)) ([]memberResult[T], error) {
+	results := make([]memberResult[T], len(c.members))
+
+	var wg sync.WaitGroup
+	for i, member := range c.members {
+		wg.Add(1)
+		go func(i int, member compositeMember) {
+			defer wg.Done()
+			items, err := query(ctx, member.adapter)
+			results[i] = memberResult[T]{items: items, err: err}
+		}(i, member)
+	}
+	wg.Wait()
+
+	var firstErr error
+	succeeded := 0
+	for i, result := range results {
+		if result.err == nil {
+			succeeded++
+			continue
+		}
+		// Members that lack an operation or the object simply contribute nothing
+		if errors.Is(result.err, ErrNotSupported) || errors.Is(result.err, ErrNotFound) {
+			continue
+		}
+		if firstErr == nil {
+			firstErr = result.err
+		}
+		log.Warn().
+			Err(result.err).
+			Str("tenant_id", c.config.TenantID.String()).
+			Str("member", c.members[i].name).
+			Str("operation", operation).
+			Msg("Federated PACS member failed, returning partial results")
+	}
+
+	if succeeded == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// mergeResults concatenates member results, dropping items whose key was
+// already returned by an earlier member
+func mergeResults[T any](results []memberResult[T], key func(T) string, seen func(item T, member int)) []T {
+	keys := make(map[string]bool)
+	var merged []T
+	for member, result := range results {
+		for _, item := range result.items {
+			k := key(item)
+			if k != "" && keys[k] {
+				continue
+			}
+			keys[k] = true
+			if seen != nil {
+				seen(item, member)
+			}
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+// FindStudies queries every member and merges the studies by Study Instance UID.
+// Paging is applied to the merged list.
+func (c *CompositeAdapter) FindStudies(ctx context.Context, params models.QueryParams) ([]models.Study, error) {
+	memberParams := params
+	memberParams.Offset = 0
+	if params.Limit > 0 {
+		memberParams.Limit = params.Offset + params.Limit
+	}
+
+	results, err := queryMembers(ctx, c, "find_studies", func(ctx context.Context, adapter PACSAdapter) ([]models.Study, error) {
+		return adapter.FindStudies(ctx, memberParams)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	studies := mergeResults(results, func(s models.Study) string { return s.StudyInstanceUID }, func(s models.Study, member int) {
+		c.remember(s.StudyInstanceUID, member)
+	})
+
+	if params.Offset > 0 {
+		if params.Offset >= len(studies) {
+			return []models.Study{}, nil
+		}
+		studies = studies[params.Offset:]
+	}
+	if params.Limit > 0 && len(studies) > params.Limit {
+		studies = studies[:params.Limit]
+	}
+	return studies, nil
+}
+
+// FindSeries queries every member and merges the series of a study, which may
+// be split across archives
+func (c *CompositeAdapter) FindSeries(ctx context.Context, studyUID string) ([]models.Series, error) {
+	results, err := queryMembers(ctx, c, "find_series", func(ctx context.Context, adapter PACSAdapter) ([]models.Series, error) {
+		return adapter.FindSeries(ctx, studyUID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeResults(results, func(s models.Series) string { return s.SeriesInstanceUID }, func(_ models.Series, member int) {
+		c.remember(studyUID, member)
+	}), nil
+}
+
+// FindInstances queries every member and merges the instances of a series
+func (c *CompositeAdapter) FindInstances(ctx context.Context, studyUID, seriesUID string) ([]models.Instance, error) {
+	results, err := queryMembers(ctx, c, "find_instances", func(ctx context.Context, adapter PACSAdapter) ([]models.Instance, error) {
+		return adapter.FindInstances(ctx, studyUID, seriesUID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeResults(results, func(i models.Instance) string { return i.SOPInstanceUID }, nil), nil
+}
+
+// ObjectExists reports whether any member holds the object
+func (c *CompositeAdapter) ObjectExists(ctx context.Context, studyUID, seriesUID, instanceUID string) (bool, error) {
+	var found bool
+	err := c.route(ctx, studyUID, func(ctx context.Context, adapter PACSAdapter) error {
+		exists, err := adapter.ObjectExists(ctx, studyUID, seriesUID, instanceUID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+		found = true
+		return nil
+	})
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return found, err
+}
+
+// GetInstance retrieves an instance from the member holding it
+func (c *CompositeAdapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
+	var body io.ReadCloser
+	var contentType string
+	err := c.route(ctx, studyUID, func(ctx context.Context, adapter PACSAdapter) error {
+		var err error
+		body, contentType, err = adapter.GetInstance(ctx, studyUID, seriesUID, instanceUID, opts)
+		return err
+	})
+	return body, contentType, err
+}
+
+// GetInstanceMetadata retrieves instance metadata from the member holding it
+func (c *CompositeAdapter) GetInstanceMetadata(ctx context.Context, studyUID, seriesUID, instanceUID string) (*models.Metadata, error) {
+	var metadata *models.Metadata
+	err := c.route(ctx, studyUID, func(ctx context.Context, adapter PACSAdapter) error {
+		var err error
+		metadata, err = adapter.GetInstanceMetadata(ctx, studyUID, seriesUID, instanceUID)
+		return err
+	})
+	return metadata, err
+}
+
+// GetSeriesMetadata merges the series metadata of every member
+func (c *CompositeAdapter) GetSeriesMetadata(ctx context.Context, studyUID, seriesUID string) ([]models.Metadata, error) {
+	results, err := queryMembers(ctx, c, "series_metadata", func(ctx context.Context, adapter PACSAdapter) ([]models.Metadata, error) {
+		return adapter.GetSeriesMetadata(ctx, studyUID, seriesUID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.mergeMetadata(results)
+}
+
+// GetStudyMetadata merges the study metadata of every member
+func (c *CompositeAdapter) GetStudyMetadata(ctx context.Context, studyUID string) ([]models.Metadata, error) {
+	results, err := queryMembers(ctx, c, "study_metadata", func(ctx context.Context, adapter PACSAdapter) ([]models.Metadata, error) {
+		return adapter.GetStudyMetadata(ctx, studyUID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.mergeMetadata(results)
+}
+
+// mergeMetadata merges metadata by SOP Instance UID, reporting not found when
+// no member returned any
+func (c *CompositeAdapter) mergeMetadata(results []memberResult[models.Metadata]) ([]models.Metadata, error) {
+	metadata := mergeResults(results, func(m models.Metadata) string { return m.SOPInstanceUID }, nil)
+	if len(metadata) == 0 {
+		return nil, fmt.Errorf("%w: no member holds the object", ErrNotFound)
+	}
+	return metadata, nil
+}
+
+// GetFrame retrieves a frame from the member holding the instance
+func (c *CompositeAdapter) GetFrame(ctx context.Context, studyUID, seriesUID, instanceUID string, frame int) (io.ReadCloser, string, error) {
+	var body io.ReadCloser
+	var contentType string
+	err := c.route(ctx, studyUID, func(ctx context.Context, adapter PACSAdapter) error {
+		var err error
+		body, contentType, err = adapter.GetFrame(ctx, studyUID, seriesUID, instanceUID, frame)
+		return err
+	})
+	return body, contentType, err
+}
+
+// GetRendered retrieves a rendering from the member holding the instance
+func (c *CompositeAdapter) GetRendered(ctx context.Context, studyUID, seriesUID, instanceUID, mediaType string) (io.ReadCloser, string, error) {
+	var body io.ReadCloser
+	var contentType string
+	err := c.route(ctx, studyUID, func(ctx context.Context, adapter PACSAdapter) error {
+		var err error
+		body, contentType, err = adapter.GetRendered(ctx, studyUID, seriesUID, instanceUID, mediaType)
+		return err
+	})
+	return body, contentType, err
+}
+
+// GetThumbnail renders a thumbnail through the member holding the instance
+func (c *CompositeAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	var data []byte
+	err := c.route(ctx, studyUID, func(ctx context.Context, adapter PACSAdapter) error {
+		var err error
+		data, err = adapter.GetThumbnail(ctx, studyUID, seriesUID, instanceUID, opts)
+		return err
+	})
+	return data, err
+}
+
+// DeleteStudy deletes the study from every member that holds it
+func (c *CompositeAdapter) DeleteStudy(ctx context.Context, studyUID string) error {
+	deleted := 0
+	var firstErr error
+	for _, member := range c.members {
+		err := member.adapter.DeleteStudy(ctx, studyUID)
+		switch {
+		case err == nil:
+			deleted++
+		case errors.Is(err, ErrNotFound), errors.Is(err, ErrNotSupported):
+		default:
+			if firstErr == nil {
+				firstErr = fmt.Errorf("member %s: %w", member.name, err)
+			}
+		}
+	}
+
+	c.forget(studyUID)
+	if firstErr != nil {
+		return firstErr
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: no member could delete the study", ErrNotSupported)
+	}
+	return nil
+}
+
+// TestConnection tests every member; the federation is connected when all members are
+func (c *CompositeAdapter) TestConnection(ctx context.Context) (*models.ConnectionStatus, error) {
+	start := time.Now()
+	status := &models.ConnectionStatus{LastChecked: start, IsConnected: true}
+
+	var failures []string
+	for _, member := range c.members {
+		if _, err := member.adapter.TestConnection(ctx); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", member.name, err))
+		}
+	}
+	status.ResponseTime = time.Since(start).Milliseconds()
+
+	if len(failures) > 0 {
+		status.IsConnected = false
+		status.ErrorMessage = strings.Join(failures, "; ")
+		return status, fmt.Errorf("%d of %d federated PACS failed: %s", len(failures), len(c.members), status.ErrorMessage)
+	}
+	status.Capabilities = c.Capabilities()
+	return status, nil
+}
+
+// Close closes every member adapter
+func (c *CompositeAdapter) Close() error {
+	var firstErr error
+	for _, member := range c.members {
+		if err := member.adapter.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// route calls op on the member remembered for the study first, then on the
+// others in priority order while they report the object missing or the
+// operation unsupported. Other errors are returned if no member succeeds.
+func (c *CompositeAdapter) route(ctx context.Context, studyUID string, op func(ctx context.Context, adapter PACSAdapter) error) error {
+	var firstErr error
+	for _, i := range c.order(studyUID) {
+		err := op(ctx, c.members[i].adapter)
+		if err == nil {
+			c.remember(studyUID, i)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrNotSupported) {
+			if firstErr == nil || errors.Is(firstErr, ErrNotSupported) {
+				firstErr = err
+			}
+			continue
+		}
+		log.Warn().
+			Err(err).
+			Str("tenant_id", c.config.TenantID.String()).
+			Str("member", c.members[i].name).
+			Str("study_uid", studyUID).
+			Msg("Federated PACS member failed, trying next")
+		if firstErr == nil || errors.Is(firstErr, ErrNotFound) || errors.Is(firstErr, ErrNotSupported) {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// order returns the member indexes to try for a study, the remembered one first
+func (c *CompositeAdapter) order(studyUID string) []int {
+	c.mu.Lock()
+	preferred, ok := c.routes[studyUID]
+	c.mu.Unlock()
+
+	order := make([]int, 0, len(c.members))
+	if ok {
+		order = append(order, preferred)
+	}
+	for i := range c.members {
+		if !ok || i != preferred {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// remember records the member holding a study unless an earlier member already does
+func (c *CompositeAdapter) remember(studyUID string, member int) {
+	if studyUID == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if current, ok := c.routes[studyUID]; ok && current <= member {
+		return
+	}
+	if len(c.routes) >= compositeRouteLimit {
+		c.routes = make(map[string]int)
+	}
+	c.routes[studyUID] = member
+}
+
+func (c *CompositeAdapter) forget(studyUID string) {
+	c.mu.Lock()
+	delete(c.routes, studyUID)
+	c.mu.Unlock()
+}
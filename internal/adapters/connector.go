@@ -0,0 +1,99 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+)
+
+// ConnectorAdapter implements PACSAdapter by proxying the DICOMweb API of
+// another ris-dicom-connector, so a central instance can front site-level
+// connectors. Every request carries the upstream tenant in X-Tenant-ID; the
+// API key is sent as the bearer token, which the upstream admin routes such as
+// study deletion require.
+type ConnectorAdapter struct {
+	*DICOMWebAdapter
+}
+
+// NewConnectorAdapter creates an adapter for the connector at the config's
+// endpoint. The tenant sent upstream is RemoteTenantID, or the config's own
+// tenant when the deployments share tenant IDs.
+func NewConnectorAdapter(config models.PACSConfig) (*ConnectorAdapter, error) {
+	tenantID := config.TenantID.String()
+	if config.RemoteTenantID != "" {
+		tenantID = config.RemoteTenantID
+	}
+
+	dicomweb, err := NewDICOMWebAdapter(config)
+	if err != nil {
+		return nil, err
+	}
+	dicomweb.headers = http.Header{}
+	dicomweb.headers.Set("X-Tenant-ID", tenantID)
+
+	return &ConnectorAdapter{DICOMWebAdapter: dicomweb}, nil
+}
+
+func (c *ConnectorAdapter) Type() models.PACSType {
+	return models.PACSTypeConnector
+}
+
+func (c *ConnectorAdapter) Capabilities() []string {
+	return []string{"QIDO-RS", "WADO-RS", "WADO-URI", "THUMBNAIL", "DELETE"}
+}
+
+// GetThumbnail uses the upstream connector's thumbnail resource, which renders
+// next to the archive so the instance is not transferred
+func (c *ConnectorAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	params := url.Values{}
+	if opts.Size > 0 {
+		params.Set("viewport", strconv.Itoa(opts.Size)+","+strconv.Itoa(opts.Size))
+	}
+	if opts.Quality > 0 {
+		params.Set("quality", strconv.Itoa(opts.Quality))
+	}
+
+	thumbnailURL := fmt.Sprintf("%s/studies/%s/series/%s/instances/%s/thumbnail?%s",
+		c.baseURL, url.PathEscape(studyUID), url.PathEscape(seriesUID), url.PathEscape(instanceUID), params.Encode())
+
+	req, err := c.newRequest(ctx, "GET", thumbnailURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/jpeg")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotImplemented:
+		return nil, fmt.Errorf("%w: upstream connector cannot render thumbnails", ErrNotSupported)
+	default:
+		return nil, statusError(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read thumbnail: %w", err)
+	}
+	return data, nil
+}
+
+// TestConnection tests the upstream connector with a minimal study query
+func (c *ConnectorAdapter) TestConnection(ctx context.Context) (*models.ConnectionStatus, error) {
+	status, err := c.DICOMWebAdapter.TestConnection(ctx)
+	if status != nil && status.IsConnected {
+		status.Capabilities = c.Capabilities()
+	}
+	return status, err
+}
@@ -0,0 +1,280 @@
+package adapters
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/cache"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// ErrCredentialsEncrypted is returned when a PACS config holds encrypted
+// credentials but the connector has no credential encryption key to open them
+var ErrCredentialsEncrypted = errors.New("PACS credentials are encrypted but credential encryption is not configured")
+
+// ErrWebhookSecretEncrypted is returned when a webhook's signing secret is
+// encrypted but the connector has no credential encryption key to open it
+var ErrWebhookSecretEncrypted = errors.New("webhook secret is encrypted but credential encryption is not configured")
+
+// sealedCredentialPrefix starts a credential stored encrypted; the base64 of
+// the wrapped data key, the nonce and the sealed credential follows
+const sealedCredentialPrefix = "enc:v1:"
+
+const (
+	// credentialKeySize is the length of the AES-256 data keys credentials are sealed with
+	credentialKeySize = 32
+	// credentialKeyLifetime is how long one data key seals new credentials before it is replaced
+	credentialKeyLifetime = 24 * time.Hour
+	// credentialKeyTimeout bounds a call to wrap or unwrap a data key
+	credentialKeyTimeout = 10 * time.Second
+)
+
+// CredentialCipher encrypts the credentials of PACS configs with AES-256-GCM
+// before they are stored. Like the encrypted cache tiers, credentials are
+// sealed under a data key stored wrapped by the master key alongside each one,
+// so a KMS master key is only called when a data key is created or first read.
+// A credential is bound to its tenant and field, so it cannot be moved to
+// another config. Only the adapters open credentials, when they connect.
+type CredentialCipher struct {
+	wrapper cache.KeyWrapper
+
+	mu        sync.Mutex
+	current   *credentialKey
+	unwrapped map[string]cipher.AEAD // wrapped data key to its cipher
+}
+
+// credentialKey is the data key new credentials are sealed with
+type credentialKey struct {
+	wrapped []byte
+	aead    cipher.AEAD
+	created time.Time
+}
+
+// NewCredentialCipher creates a credential cipher under a master key, such as
+// cache.LocalKeyWrapper or AWSKMSKeyWrapper
+func NewCredentialCipher(wrapper cache.KeyWrapper) *CredentialCipher {
+	return &CredentialCipher{
+		wrapper:   wrapper,
+		unwrapped: make(map[string]cipher.AEAD),
+	}
+}
+
+// SealPACSCredentials encrypts the credentials of a PACS config still held in
+// plaintext and returns how many it encrypted. Without a cipher they are left
+// as they are, as are references to a secret store, which hold no secret.
+func (c *CredentialCipher) SealPACSCredentials(ctx context.Context, config *models.PACSConfig) (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+
+	sealed := 0
+	for field, value := range config.Credentials() {
+		if *value == "" || strings.HasPrefix(*value, sealedCredentialPrefix) {
+			continue
+		}
+		if _, ok := parseSecretReference(*value); ok {
+			continue
+		}
+		ciphertext, err := c.seal(ctx, credentialBinding(config, field), *value)
+		if err != nil {
+			return sealed, fmt.Errorf("failed to encrypt %s: %w", field, err)
+		}
+		*value = ciphertext
+		sealed++
+	}
+	return sealed, nil
+}
+
+// openPACSCredentials returns a copy of a PACS config with its credentials
+// decrypted. Credentials stored before encryption was enabled are used as they are.
+func (c *CredentialCipher) openPACSCredentials(config models.PACSConfig) (models.PACSConfig, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialKeyTimeout)
+	defer cancel()
+
+	for field, value := range config.Credentials() {
+		sealed, ok := strings.CutPrefix(*value, sealedCredentialPrefix)
+		if !ok {
+			continue
+		}
+		if c == nil {
+			return config, ErrCredentialsEncrypted
+		}
+		plaintext, err := c.open(ctx, credentialBinding(&config, field), sealed)
+		if err != nil {
+			return config, fmt.Errorf("failed to decrypt %s: %w", field, err)
+		}
+		*value = plaintext
+	}
+	return config, nil
+}
+
+// SealWebhookSecret encrypts the signing secret of a webhook still held in
+// plaintext and reports whether it did. Without a cipher it is left as it is.
+func (c *CredentialCipher) SealWebhookSecret(ctx context.Context, webhook *models.Webhook) (bool, error) {
+	if c == nil || webhook.Secret == "" || strings.HasPrefix(webhook.Secret, sealedCredentialPrefix) {
+		return false, nil
+	}
+	ciphertext, err := c.seal(ctx, webhookSecretBinding(webhook), webhook.Secret)
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+	webhook.Secret = ciphertext
+	return true, nil
+}
+
+// OpenWebhookSecret returns the signing secret of a webhook, decrypted.
+// Secrets stored before encryption was enabled are returned as they are.
+func (c *CredentialCipher) OpenWebhookSecret(ctx context.Context, webhook *models.Webhook) (string, error) {
+	sealed, ok := strings.CutPrefix(webhook.Secret, sealedCredentialPrefix)
+	if !ok {
+		return webhook.Secret, nil
+	}
+	if c == nil {
+		return "", ErrWebhookSecretEncrypted
+	}
+	ctx, cancel := context.WithTimeout(ctx, credentialKeyTimeout)
+	defer cancel()
+	secret, err := c.open(ctx, webhookSecretBinding(webhook), sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt webhook secret: %w", err)
+	}
+	return secret, nil
+}
+
+// seal encrypts a credential under the current data key
+func (c *CredentialCipher) seal(ctx context.Context, binding, plaintext string) (string, error) {
+	key, err := c.dataKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := make([]byte, 0, 2+len(key.wrapped)+len(nonce)+len(plaintext)+key.aead.Overhead())
+	sealed = binary.BigEndian.AppendUint16(sealed, uint16(len(key.wrapped)))
+	sealed = append(sealed, key.wrapped...)
+	sealed = append(sealed, nonce...)
+	sealed = key.aead.Seal(sealed, nonce, []byte(plaintext), []byte(binding))
+	return sealedCredentialPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a sealed credential, without its prefix
+func (c *CredentialCipher) open(ctx context.Context, binding, encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < 2 {
+		return "", fmt.Errorf("malformed encrypted credential")
+	}
+	wrappedLen := int(binary.BigEndian.Uint16(sealed))
+	sealed = sealed[2:]
+	if len(sealed) < wrappedLen {
+		return "", fmt.Errorf("malformed encrypted credential")
+	}
+	wrapped, sealed := sealed[:wrappedLen], sealed[wrappedLen:]
+
+	aead, err := c.cipherFor(ctx, wrapped)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted credential")
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(binding))
+	if err != nil {
+		return "", fmt.Errorf("failed to open encrypted credential: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// dataKey returns the data key new credentials are sealed with, creating a
+// new one when there is none yet or the current one is due for replacement
+func (c *CredentialCipher) dataKey(ctx context.Context) (*credentialKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.current != nil && time.Since(c.current.created) < credentialKeyLifetime {
+		return c.current, nil
+	}
+
+	key := make([]byte, credentialKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	aead, err := newCredentialGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, credentialKeyTimeout)
+	defer cancel()
+	wrapped, err := c.wrapper.WrapKey(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	if len(wrapped) > 0xFFFF {
+		return nil, fmt.Errorf("wrapped data key is too long: %d bytes", len(wrapped))
+	}
+
+	c.current = &credentialKey{wrapped: wrapped, aead: aead, created: time.Now()}
+	c.unwrapped[string(wrapped)] = aead
+	return c.current, nil
+}
+
+// cipherFor returns the cipher of a wrapped data key, unwrapping it with the
+// master key the first time it is seen
+func (c *CredentialCipher) cipherFor(ctx context.Context, wrapped []byte) (cipher.AEAD, error) {
+	c.mu.Lock()
+	aead, ok := c.unwrapped[string(wrapped)]
+	c.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+
+	key, err := c.wrapper.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err = newCredentialGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.unwrapped[string(wrapped)] = aead
+	c.mu.Unlock()
+	return aead, nil
+}
+
+// credentialBinding is the additional data a credential is sealed with
+func credentialBinding(config *models.PACSConfig, field string) string {
+	return config.TenantID.String() + "/" + field
+}
+
+// webhookSecretBinding is the additional data a webhook secret is sealed with
+func webhookSecretBinding(webhook *models.Webhook) string {
+	return webhook.TenantID.String() + "/webhook_secret"
+}
+
+func newCredentialGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return aead, nil
+}
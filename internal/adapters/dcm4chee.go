@@ -0,0 +1,152 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// defaultDcm4cheeAETitle is the archive AE title of a stock dcm4chee-arc installation
+const defaultDcm4cheeAETitle = "DCM4CHEE"
+
+// dcm4cheeRejectionCodes maps rejection reasons to the IOCM rejection note codes
+var dcm4cheeRejectionCodes = map[string]string{
+	models.RejectionQuality:          "113001^DCM",
+	models.RejectionPatientSafety:    "113037^DCM",
+	models.RejectionIncorrectMWL:     "113038^DCM",
+	models.RejectionRetentionExpired: "113039^DCM",
+}
+
+// Dcm4cheeAdapter implements PACSAdapter for dcm4chee-arc. Queries and retrieval
+// use its DICOMweb services under /dcm4chee-arc/aets/{AE}/rs; the archive's REST
+// extensions on the same path are exposed through ArchiveManager.
+type Dcm4cheeAdapter struct {
+	*DICOMWebAdapter
+}
+
+// NewDcm4cheeAdapter creates a new dcm4chee-arc adapter. Without an explicit
+// BaseURL or BasePath the DICOMweb root is derived from the archive AE title.
+func NewDcm4cheeAdapter(config models.PACSConfig) (*Dcm4cheeAdapter, error) {
+	if config.BaseURL == "" && config.BasePath == "" {
+		aeTitle := config.AETitle
+		if aeTitle == "" {
+			aeTitle = defaultDcm4cheeAETitle
+		}
+		config.BasePath = fmt.Sprintf("/dcm4chee-arc/aets/%s/rs", url.PathEscape(aeTitle))
+
+		// An endpoint with a scheme is otherwise used as the root unchanged
+		if strings.HasPrefix(config.Endpoint, "http://") || strings.HasPrefix(config.Endpoint, "https://") {
+			config.BaseURL = strings.TrimSuffix(config.Endpoint, "/") + config.BasePath
+		}
+	}
+
+	dicomweb, err := NewDICOMWebAdapter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Dcm4cheeAdapter{DICOMWebAdapter: dicomweb}, nil
+}
+
+func (d *Dcm4cheeAdapter) Type() models.PACSType {
+	return models.PACSTypeDcm4chee
+}
+
+func (d *Dcm4cheeAdapter) Capabilities() []string {
+	return []string{"QIDO-RS", "WADO-RS", "WADO-URI", "DELETE", "REJECT", "EXPORT", "PATIENT-MERGE"}
+}
+
+// TestConnection tests the archive with a minimal study query
+func (d *Dcm4cheeAdapter) TestConnection(ctx context.Context) (*models.ConnectionStatus, error) {
+	status, err := d.DICOMWebAdapter.TestConnection(ctx)
+	if status != nil && status.IsConnected {
+		status.Capabilities = d.Capabilities()
+	}
+	return status, err
+}
+
+// RejectionCode resolves a rejection reason to its CODE^SCHEME rejection note
+// code. Codes already in that form are accepted as-is for archive-specific notes.
+func RejectionCode(reason string) (string, bool) {
+	if code, ok := dcm4cheeRejectionCodes[strings.ToLower(strings.TrimSpace(reason))]; ok {
+		return code, true
+	}
+	value, scheme, found := strings.Cut(reason, "^")
+	if found && value != "" && scheme != "" && !strings.ContainsAny(reason, "/ ") {
+		return reason, true
+	}
+	return "", false
+}
+
+// Reject hides a study, series or instance behind a rejection note
+func (d *Dcm4cheeAdapter) Reject(ctx context.Context, req models.RejectionRequest) error {
+	code, ok := RejectionCode(req.Reason)
+	if !ok {
+		return fmt.Errorf("%w: unknown rejection reason %q", ErrInvalidRequest, req.Reason)
+	}
+
+	return d.send(ctx, "POST", d.objectURL(req.StudyUID, req.SeriesUID, req.InstanceUID)+"/reject/"+url.PathEscape(code))
+}
+
+// Export schedules a study, series or instance on one of the archive's exporters
+func (d *Dcm4cheeAdapter) Export(ctx context.Context, req models.ExportRequest) error {
+	return d.send(ctx, "POST", d.objectURL(req.StudyUID, req.SeriesUID, req.InstanceUID)+"/export/"+url.PathEscape(req.ExporterID))
+}
+
+// MergePatients merges the prior patient into the target patient, moving its studies
+func (d *Dcm4cheeAdapter) MergePatients(ctx context.Context, req models.PatientMergeRequest) error {
+	mergeURL := fmt.Sprintf("%s/patients/%s/merge/%s",
+		d.baseURL,
+		url.PathEscape(dcm4cheePatientID(req.PriorPatientID, req.PriorIssuer)),
+		url.PathEscape(dcm4cheePatientID(req.PatientID, req.IssuerOfPatient)))
+
+	return d.send(ctx, "PUT", mergeURL)
+}
+
+// objectURL builds the DICOMweb URL of a study, series or instance
+func (d *Dcm4cheeAdapter) objectURL(studyUID, seriesUID, instanceUID string) string {
+	objectURL := fmt.Sprintf("%s/studies/%s", d.baseURL, url.PathEscape(studyUID))
+	if seriesUID != "" {
+		objectURL += "/series/" + url.PathEscape(seriesUID)
+		if instanceUID != "" {
+			objectURL += "/instances/" + url.PathEscape(instanceUID)
+		}
+	}
+	return objectURL
+}
+
+// dcm4cheePatientID formats a patient identifier as ID^^^Issuer
+func dcm4cheePatientID(patientID, issuer string) string {
+	if issuer == "" {
+		return patientID
+	}
+	return patientID + "^^^" + issuer
+}
+
+// send issues a body-less archive REST request and maps the response status to adapter errors
+func (d *Dcm4cheeAdapter) send(ctx context.Context, method, requestURL string) error {
+	req, err := d.newRequest(ctx, method, requestURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := d.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		return nil
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return ErrNotSupported
+	default:
+		return statusError(resp)
+	}
+}
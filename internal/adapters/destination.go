@@ -0,0 +1,71 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// destinationContentType is the media type of the archives pushed to destinations
+const destinationContentType = "application/zip"
+
+// DestinationUploader pushes export archives to a tenant's external storage
+type DestinationUploader interface {
+	// Upload stores size bytes of r as name under the destination's prefix and
+	// returns the location of the stored archive
+	Upload(ctx context.Context, name string, r io.ReaderAt, size int64) (string, error)
+}
+
+// NewDestinationUploader creates the uploader for an export destination,
+// validating its settings
+func NewDestinationUploader(destination models.ExportDestination) (DestinationUploader, error) {
+	switch destination.Type {
+	case models.DestinationTypeS3:
+		return newS3Destination(destination)
+	case models.DestinationTypeGCS:
+		return newGCSDestination(destination)
+	case models.DestinationTypeAzureBlob:
+		return newAzureBlobDestination(destination)
+	case models.DestinationTypeSFTP:
+		return newSFTPDestination(destination)
+	default:
+		return nil, fmt.Errorf("unsupported destination type: %s", destination.Type)
+	}
+}
+
+// destinationKey places an archive name under a destination prefix
+func destinationKey(prefix, name string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return name
+	}
+	return path.Join(prefix, name)
+}
+
+// destinationHTTPClient creates the client for uploads to a destination. It has
+// no overall timeout: archives may be large, and uploads end with the export job.
+func destinationHTTPClient() *http.Client {
+	return &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+}
+
+// setRequestBody sets a request body of known size; net/http only infers the
+// length of in-memory readers
+func setRequestBody(req *http.Request, body io.Reader, size int64) {
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+		return
+	}
+	req.Body = io.NopCloser(body)
+}
+
+// destinationStatusError converts an unexpected destination response into an error
+func destinationStatusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("destination returned status %d: %s", resp.StatusCode, string(body))
+}
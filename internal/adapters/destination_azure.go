@@ -0,0 +1,151 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+const (
+	azureBlobAPIVersion = "2021-08-06"
+	// azureBlockSize is the block size of staged uploads; archives up to one
+	// block are uploaded with a single Put Blob
+	azureBlockSize = 64 << 20
+)
+
+// azureBlobDestination uploads export archives to an Azure Blob Storage
+// container, authorized with a shared access signature
+type azureBlobDestination struct {
+	client    *http.Client
+	container *url.URL
+	sas       url.Values
+	prefix    string
+}
+
+// azureBlockList is a Put Block List request body
+type azureBlockList struct {
+	XMLName xml.Name `xml:"BlockList"`
+	Latest  []string `xml:"Latest"`
+}
+
+func newAzureBlobDestination(destination models.ExportDestination) (*azureBlobDestination, error) {
+	container, err := url.Parse(strings.TrimSuffix(destination.ContainerURL, "/"))
+	if err != nil || container.Scheme != "https" || container.Host == "" || strings.Trim(container.Path, "/") == "" {
+		return nil, fmt.Errorf("azure-blob destination requires an https container URL")
+	}
+	if container.RawQuery != "" {
+		return nil, fmt.Errorf("azure-blob container URL must not include the SAS token")
+	}
+
+	sas, err := url.ParseQuery(strings.TrimPrefix(destination.SASToken, "?"))
+	if err != nil || sas.Get("sig") == "" {
+		return nil, fmt.Errorf("azure-blob destination requires a SAS token")
+	}
+
+	return &azureBlobDestination{
+		client:    destinationHTTPClient(),
+		container: container,
+		sas:       sas,
+		prefix:    destination.Prefix,
+	}, nil
+}
+
+// Upload puts the archive as a block blob, staging it in blocks when it is
+// larger than one block
+func (d *azureBlobDestination) Upload(ctx context.Context, name string, r io.ReaderAt, size int64) (string, error) {
+	key := destinationKey(d.prefix, name)
+	location := d.container.String() + "/" + key
+
+	if size <= azureBlockSize {
+		req, err := d.newRequest(ctx, key, nil, io.NewSectionReader(r, 0, size), size)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("x-ms-blob-type", "BlockBlob")
+		req.Header.Set("Content-Type", destinationContentType)
+		if err := d.do(req); err != nil {
+			return "", err
+		}
+		return location, nil
+	}
+
+	var blocks []string
+	for offset := int64(0); offset < size; offset += azureBlockSize {
+		blockSize := min(azureBlockSize, size-offset)
+		// Block IDs must all have the same length
+		blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%08d", len(blocks))))
+
+		query := url.Values{}
+		query.Set("comp", "block")
+		query.Set("blockid", blockID)
+		req, err := d.newRequest(ctx, key, query, io.NewSectionReader(r, offset, blockSize), blockSize)
+		if err != nil {
+			return "", err
+		}
+		if err := d.do(req); err != nil {
+			return "", err
+		}
+		blocks = append(blocks, blockID)
+	}
+
+	// Uncommitted blocks are discarded by the service after a week
+	body, err := xml.Marshal(azureBlockList{Latest: blocks})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode block list: %w", err)
+	}
+	req, err := d.newRequest(ctx, key, url.Values{"comp": {"blocklist"}}, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("x-ms-blob-content-type", destinationContentType)
+	if err := d.do(req); err != nil {
+		return "", err
+	}
+	return location, nil
+}
+
+// newRequest creates a PUT request for a blob, authorized with the SAS token
+func (d *azureBlobDestination) newRequest(ctx context.Context, key string, query url.Values, body io.Reader, size int64) (*http.Request, error) {
+	u := *d.container
+	u.Path = d.container.Path + "/" + key
+
+	values := url.Values{}
+	for name, value := range d.sas {
+		values[name] = value
+	}
+	for name, value := range query {
+		values[name] = value
+	}
+	u.RawQuery = values.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	setRequestBody(req, body, size)
+	req.Header.Set("x-ms-version", azureBlobAPIVersion)
+	return req, nil
+}
+
+// do executes a blob request, which succeeds with 201 Created
+func (d *azureBlobDestination) do(req *http.Request) error {
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return destinationStatusError(resp)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+const (
+	gcsUploadBaseURL = "https://storage.googleapis.com/upload/storage/v1"
+	gcsScope         = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+// gcsDestination uploads export archives to a Google Cloud Storage bucket,
+// authenticated with a service account
+type gcsDestination struct {
+	client *http.Client
+	tokens tokenSource
+	bucket string
+	prefix string
+}
+
+func newGCSDestination(destination models.ExportDestination) (*gcsDestination, error) {
+	if destination.Bucket == "" {
+		return nil, fmt.Errorf("gcs destination requires a bucket")
+	}
+	if destination.ServiceAccountKey == "" {
+		return nil, fmt.Errorf("gcs destination requires a service account key")
+	}
+
+	client := destinationHTTPClient()
+	tokens, err := newServiceAccountTokenSource(client, destination.ServiceAccountKey, gcsScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcsDestination{
+		client: client,
+		tokens: tokens,
+		bucket: destination.Bucket,
+		prefix: destination.Prefix,
+	}, nil
+}
+
+// Upload streams the archive in a single media upload, which Cloud Storage
+// accepts for objects of any size
+func (d *gcsDestination) Upload(ctx context.Context, name string, r io.ReaderAt, size int64) (string, error) {
+	key := destinationKey(d.prefix, name)
+
+	query := url.Values{}
+	query.Set("uploadType", "media")
+	query.Set("name", key)
+	uploadURL := fmt.Sprintf("%s/b/%s/o?%s", gcsUploadBaseURL, url.PathEscape(d.bucket), query.Encode())
+
+	token, err := d.tokens.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", uploadURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	setRequestBody(req, io.NewSectionReader(r, 0, size), size)
+	req.Header.Set("Content-Type", destinationContentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", destinationStatusError(resp)
+	}
+	return fmt.Sprintf("gs://%s/%s", d.bucket, key), nil
+}
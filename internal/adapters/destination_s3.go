@@ -0,0 +1,99 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+const (
+	// s3PartSize is the part size of multipart uploads; archives up to one part
+	// are uploaded in a single request
+	s3PartSize = 64 << 20
+	// s3AbortTimeout bounds the cleanup of a failed multipart upload
+	s3AbortTimeout = 30 * time.Second
+)
+
+// s3Destination uploads export archives to an S3 or S3-compatible bucket
+type s3Destination struct {
+	client *s3Client
+	prefix string
+}
+
+func newS3Destination(destination models.ExportDestination) (*s3Destination, error) {
+	if destination.Bucket == "" {
+		return nil, fmt.Errorf("s3 destination requires a bucket")
+	}
+	if destination.AccessKeyID == "" || destination.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 destination requires an access key ID and secret access key")
+	}
+
+	region := destination.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	endpoint, err := s3EndpointURL(models.PACSConfig{Endpoint: destination.Endpoint}, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Destination{
+		client: &s3Client{
+			httpClient: destinationHTTPClient(),
+			endpoint:   endpoint,
+			bucket:     destination.Bucket,
+			region:     region,
+			pathStyle:  destination.PathStyle,
+			accessKey:  destination.AccessKeyID,
+			secretKey:  destination.SecretAccessKey, // In production, decrypt this
+		},
+		prefix: destination.Prefix,
+	}, nil
+}
+
+// Upload puts the archive in one request, or in parts when it is larger than a part
+func (d *s3Destination) Upload(ctx context.Context, name string, r io.ReaderAt, size int64) (string, error) {
+	key := destinationKey(d.prefix, name)
+	location := fmt.Sprintf("s3://%s/%s", d.client.bucket, key)
+
+	if size <= s3PartSize {
+		if err := d.client.putObject(ctx, key, io.NewSectionReader(r, 0, size), size, destinationContentType); err != nil {
+			return "", err
+		}
+		return location, nil
+	}
+
+	uploadID, err := d.client.createMultipartUpload(ctx, key, destinationContentType)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []s3CompletedPart
+	for offset := int64(0); offset < size; offset += s3PartSize {
+		partSize := min(s3PartSize, size-offset)
+		etag, err := d.client.uploadPart(ctx, key, uploadID, len(parts)+1, io.NewSectionReader(r, offset, partSize), partSize)
+		if err != nil {
+			d.abort(ctx, key, uploadID)
+			return "", err
+		}
+		parts = append(parts, s3CompletedPart{PartNumber: len(parts) + 1, ETag: etag})
+	}
+
+	if err := d.client.completeMultipartUpload(ctx, key, uploadID, parts); err != nil {
+		d.abort(ctx, key, uploadID)
+		return "", err
+	}
+	return location, nil
+}
+
+// abort discards the uploaded parts, which the bucket would otherwise keep
+// (and bill) until a lifecycle rule removes them
+func (d *s3Destination) abort(ctx context.Context, key, uploadID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s3AbortTimeout)
+	defer cancel()
+	d.client.abortMultipartUpload(ctx, key, uploadID)
+}
@@ -0,0 +1,141 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	sftpDefaultPort = 22
+	sftpDialTimeout = 30 * time.Second
+)
+
+// sftpDestination uploads export archives to a directory of an SFTP server.
+// The server's host key must be configured; unknown keys are rejected.
+type sftpDestination struct {
+	addr   string
+	config *ssh.ClientConfig
+	dir    string
+	user   string
+}
+
+func newSFTPDestination(destination models.ExportDestination) (*sftpDestination, error) {
+	if destination.Host == "" || destination.Username == "" {
+		return nil, fmt.Errorf("sftp destination requires a host and username")
+	}
+	if destination.HostKey == "" {
+		return nil, fmt.Errorf("sftp destination requires the server host key")
+	}
+
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(destination.HostKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid sftp host key: %w", err)
+	}
+
+	var auth []ssh.AuthMethod
+	if destination.PrivateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(destination.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid sftp private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if destination.Password != "" {
+		auth = append(auth, ssh.Password(destination.Password))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("sftp destination requires a password or private key")
+	}
+
+	port := destination.Port
+	if port == 0 {
+		port = sftpDefaultPort
+	}
+
+	// Relative directories are resolved against the login directory
+	dir := "."
+	if destination.Prefix != "" {
+		dir = path.Clean(destination.Prefix)
+	}
+
+	return &sftpDestination{
+		addr: net.JoinHostPort(destination.Host, strconv.Itoa(port)),
+		config: &ssh.ClientConfig{
+			User:            destination.Username,
+			Auth:            auth,
+			HostKeyCallback: ssh.FixedHostKey(hostKey),
+			Timeout:         sftpDialTimeout,
+		},
+		dir:  dir,
+		user: destination.Username,
+	}, nil
+}
+
+// Upload writes the archive to a temporary name and renames it once complete,
+// so the receiving side never picks up a partial archive
+func (d *sftpDestination) Upload(ctx context.Context, name string, r io.ReaderAt, size int64) (string, error) {
+	dialer := net.Dialer{Timeout: sftpDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", d.addr)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	// Closing the connection aborts the transfer when the export is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, d.addr, d.config)
+	if err != nil {
+		conn.Close()
+		return "", fmt.Errorf("failed to establish ssh connection: %w", err)
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+	defer sshClient.Close()
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return "", fmt.Errorf("failed to start sftp session: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.MkdirAll(d.dir); err != nil {
+		return "", fmt.Errorf("failed to create remote directory %s: %w", d.dir, err)
+	}
+
+	remote := path.Join(d.dir, name)
+	partial := remote + ".part"
+	file, err := client.OpenFile(partial, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", partial, err)
+	}
+	if _, err := io.Copy(file, io.NewSectionReader(r, 0, size)); err != nil {
+		file.Close()
+		client.Remove(partial)
+		return "", fmt.Errorf("failed to write %s: %w", partial, err)
+	}
+	if err := file.Close(); err != nil {
+		client.Remove(partial)
+		return "", fmt.Errorf("failed to write %s: %w", partial, err)
+	}
+
+	// Plain SFTP rename fails when the target exists; prefer the POSIX extension
+	if err := client.PosixRename(partial, remote); err != nil {
+		client.Remove(remote)
+		if err := client.Rename(partial, remote); err != nil {
+			client.Remove(partial)
+			return "", fmt.Errorf("failed to rename %s: %w", partial, err)
+		}
+	}
+
+	return fmt.Sprintf("sftp://%s@%s/%s", d.user, d.addr, strings.TrimPrefix(remote, "/")), nil
+}
@@ -0,0 +1,175 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/dicomquery"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// dicomJSONAttribute is a single attribute in the DICOM JSON model (PS3.18 Annex F)
+type dicomJSONAttribute struct {
+	VR           string            `json:"vr"`
+	Value        []json.RawMessage `json:"Value,omitempty"`
+	BulkDataURI  string            `json:"BulkDataURI,omitempty"`
+	InlineBinary string            `json:"InlineBinary,omitempty"`
+}
+
+// dicomJSONDataset is a dataset keyed by 8 character hex tag (e.g. "0020000D")
+type dicomJSONDataset map[string]dicomJSONAttribute
+
+// decodeDICOMJSON decodes a DICOM JSON array response body
+func decodeDICOMJSON(body io.Reader) ([]dicomJSONDataset, error) {
+	var datasets []dicomJSONDataset
+	if err := json.NewDecoder(body).Decode(&datasets); err != nil {
+		return nil, fmt.Errorf("failed to decode DICOM JSON: %w", err)
+	}
+	return datasets, nil
+}
+
+// Strings returns all values of an attribute as strings. Person names use their
+// alphabetic representation and numbers are formatted without loss.
+func (ds dicomJSONDataset) Strings(tag string) []string {
+	attr, ok := ds[strings.ToUpper(tag)]
+	if !ok {
+		return nil
+	}
+
+	values := make([]string, 0, len(attr.Value))
+	for _, raw := range attr.Value {
+		values = append(values, rawValueString(attr.VR, raw))
+	}
+	return values
+}
+
+// String returns the first value of an attribute, or "" when absent
+func (ds dicomJSONDataset) String(tag string) string {
+	values := ds.Strings(tag)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Int returns the first value of an attribute as an integer, or 0 when absent or invalid
+func (ds dicomJSONDataset) Int(tag string) int {
+	value := ds.String(tag)
+	if value == "" {
+		return 0
+	}
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return int(f)
+	}
+	return 0
+}
+
+// Attributes converts the dataset into a generic map keyed by tag
+func (ds dicomJSONDataset) Attributes() map[string]interface{} {
+	attrs := make(map[string]interface{}, len(ds))
+	for tag, attr := range ds {
+		var decoded interface{}
+		raw, _ := json.Marshal(attr)
+		json.Unmarshal(raw, &decoded)
+		attrs[tag] = decoded
+	}
+	return attrs
+}
+
+func rawValueString(vr string, raw json.RawMessage) string {
+	if vr == "PN" {
+		var pn struct {
+			Alphabetic string `json:"Alphabetic"`
+		}
+		if err := json.Unmarshal(raw, &pn); err == nil {
+			return pn.Alphabetic
+		}
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String()
+	}
+
+	return strings.Trim(string(raw), `"`)
+}
+
+func datasetToStudy(ds dicomJSONDataset) models.Study {
+	return models.Study{
+		StudyInstanceUID:   ds.String("0020000D"),
+		PatientID:          ds.String("00100020"),
+		PatientName:        dicomquery.FormatPersonName(ds.String("00100010")),
+		PatientBirthDate:   ds.String("00100030"),
+		PatientSex:         ds.String("00100040"),
+		StudyDate:          ds.String("00080020"),
+		StudyTime:          ds.String("00080030"),
+		StudyDescription:   ds.String("00081030"),
+		AccessionNumber:    ds.String("00080050"),
+		ReferringPhysician: dicomquery.FormatPersonName(ds.String("00080090")),
+		NumberOfSeries:     ds.Int("00201206"),
+		NumberOfInstances:  ds.Int("00201208"),
+		ModalitiesInStudy:  ds.Strings("00080061"),
+		RetrieveURL:        ds.String("00081190"),
+	}
+}
+
+func datasetToSeries(ds dicomJSONDataset) models.Series {
+	return models.Series{
+		SeriesInstanceUID:  ds.String("0020000E"),
+		SeriesNumber:       ds.Int("00200011"),
+		Modality:           ds.String("00080060"),
+		SeriesDescription:  ds.String("0008103E"),
+		SeriesDate:         ds.String("00080021"),
+		SeriesTime:         ds.String("00080031"),
+		BodyPartExamined:   ds.String("00180015"),
+		NumberOfInstances:  ds.Int("00201209"),
+		ProtocolName:       ds.String("00181030"),
+		PerformedProcedure: ds.String("00400254"),
+		RetrieveURL:        ds.String("00081190"),
+	}
+}
+
+func datasetToInstance(ds dicomJSONDataset) models.Instance {
+	return models.Instance{
+		SOPInstanceUID:            ds.String("00080018"),
+		SOPClassUID:               ds.String("00080016"),
+		InstanceNumber:            ds.Int("00200013"),
+		TransferSyntaxUID:         ds.String("00020010"),
+		Rows:                      ds.Int("00280010"),
+		Columns:                   ds.Int("00280011"),
+		BitsAllocated:             ds.Int("00280100"),
+		BitsStored:                ds.Int("00280101"),
+		HighBit:                   ds.Int("00280102"),
+		PixelRepresentation:       ds.Int("00280103"),
+		PhotometricInterpretation: ds.String("00280004"),
+		SamplesPerPixel:           ds.Int("00280002"),
+		NumberOfFrames:            ds.Int("00280008"),
+		RetrieveURL:               ds.String("00081190"),
+	}
+}
+
+func datasetToMetadata(ds dicomJSONDataset) models.Metadata {
+	transferSyntax := ds.String("00020010")
+	if transferSyntax == "" {
+		// Available Transfer Syntax UID, returned by some archives in metadata
+		transferSyntax = ds.String("00083002")
+	}
+
+	return models.Metadata{
+		SOPInstanceUID:    ds.String("00080018"),
+		SOPClassUID:       ds.String("00080016"),
+		TransferSyntaxUID: transferSyntax,
+		Attributes:        ds.Attributes(),
+	}
+}
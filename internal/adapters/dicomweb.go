@@ -1,15 +1,19 @@
 package adapters
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/otcheredev/ris-dicom-connector/internal/dicomquery"
 	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
 )
 
 // DICOMWebAdapter implements PACSAdapter for DICOMweb protocol
@@ -20,27 +24,120 @@ type DICOMWebAdapter struct {
 	username string
 	password string
 	apiKey   string
+
+	tokenSource tokenSource  // set when AuthMode is oauth2 or by token-authenticated adapters
+	signer      *sigV4Signer // set when AuthMode is sigv4
+
+	headers http.Header // sent with every upstream request, set by adapters that need them
+
+	// storedTransferSyntax asks for transfer-syntax=* when the client accepts any
+	// syntax, for servers that otherwise transcode to Explicit VR Little Endian
+	storedTransferSyntax bool
+
+	validators *validatorCache // metadata responses to revalidate with the PACS
+
+	quirks Quirks
 }
 
 // NewDICOMWebAdapter creates a new DICOMweb adapter
 func NewDICOMWebAdapter(config models.PACSConfig) (*DICOMWebAdapter, error) {
-	// Build base URL
-	scheme := "http"
-	if config.Port == 443 {
-		scheme = "https"
+	baseURL, err := buildBaseURL(config)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := buildTLSConfig(config)
+	if err != nil {
+		return nil, err
 	}
-	baseURL := fmt.Sprintf("%s://%s:%d/dicom-web", scheme, config.Endpoint, config.Port)
 
-	return &DICOMWebAdapter{
+	quirks, err := LookupQuirks(config.Quirks)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	adapter := &DICOMWebAdapter{
 		BaseAdapter: BaseAdapter{config: config},
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: transport,
 		},
-		baseURL:  baseURL,
-		username: config.Username,
-		password: config.PasswordHash, // In production, decrypt this
-		apiKey:   config.APIKey,
-	}, nil
+		baseURL:    baseURL,
+		username:   config.Username,
+		password:   config.PasswordHash,
+		apiKey:     config.APIKey,
+		validators: newValidatorCache(),
+		quirks:     quirks,
+	}
+
+	if config.AuthMode == models.AuthModeOAuth2 {
+		if config.OAuthTokenURL == "" || config.OAuthClientID == "" {
+			return nil, fmt.Errorf("oauth2 auth mode requires token URL and client ID")
+		}
+		adapter.tokenSource = newOAuth2TokenSource(adapter.client, config)
+	}
+
+	if config.AuthMode == models.AuthModeSigV4 {
+		if config.SigV4Region == "" || config.SigV4AccessKeyID == "" || config.SigV4SecretAccessKey == "" {
+			return nil, fmt.Errorf("sigv4 auth mode requires region, access key ID and secret access key")
+		}
+		service := config.SigV4Service
+		if service == "" {
+			service = sigV4DefaultService
+		}
+		adapter.signer = &sigV4Signer{
+			accessKey:    config.SigV4AccessKeyID,
+			secretKey:    config.SigV4SecretAccessKey,
+			sessionToken: config.SigV4SessionToken,
+			region:       config.SigV4Region,
+			service:      service,
+		}
+	}
+
+	return adapter, nil
+}
+
+// defaultBasePath is the DICOMweb root used when the PACS config does not set one
+const defaultBasePath = "/dicom-web"
+
+// buildBaseURL resolves the DICOMweb root URL for a PACS config. An explicit BaseURL
+// wins and may reference {host}, {port} and {ae_title}; otherwise the URL is built
+// from the endpoint, port and BasePath.
+func buildBaseURL(config models.PACSConfig) (string, error) {
+	if config.BaseURL != "" {
+		baseURL := strings.NewReplacer(
+			"{host}", config.Endpoint,
+			"{port}", strconv.Itoa(config.Port),
+			"{ae_title}", config.AETitle,
+		).Replace(config.BaseURL)
+
+		parsed, err := url.Parse(baseURL)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return "", fmt.Errorf("invalid DICOMweb base URL: %s", baseURL)
+		}
+		return strings.TrimSuffix(baseURL, "/"), nil
+	}
+
+	// An endpoint that already carries a scheme is used as-is
+	if strings.HasPrefix(config.Endpoint, "http://") || strings.HasPrefix(config.Endpoint, "https://") {
+		return strings.TrimSuffix(config.Endpoint, "/"), nil
+	}
+
+	scheme := "http"
+	if config.Port == 443 {
+		scheme = "https"
+	}
+
+	basePath := config.BasePath
+	if basePath == "" {
+		basePath = defaultBasePath
+	}
+	basePath = "/" + strings.Trim(basePath, "/")
+
+	return fmt.Sprintf("%s://%s:%d%s", scheme, config.Endpoint, config.Port, basePath), nil
 }
 
 func (d *DICOMWebAdapter) Type() models.PACSType {
@@ -62,20 +159,27 @@ func (d *DICOMWebAdapter) FindStudies(ctx context.Context, params models.QueryPa
 		urlParams.Add("PatientID", params.PatientID)
 	}
 	if params.PatientName != "" {
-		urlParams.Add("PatientName", params.PatientName)
+		urlParams.Add("PatientName", dicomquery.PersonNameQuery(params.PatientName))
 	}
 	if params.StudyDate != "" {
 		urlParams.Add("StudyDate", params.StudyDate)
 	}
+	if params.StudyTime != "" {
+		urlParams.Add("StudyTime", params.StudyTime)
+	}
 	if params.AccessionNumber != "" {
 		urlParams.Add("AccessionNumber", params.AccessionNumber)
 	}
-	if params.Modality != "" {
-		urlParams.Add("ModalitiesInStudy", params.Modality)
+	if len(params.Modalities) > 0 {
+		// QIDO-RS list matching uses comma separated values
+		urlParams.Add("ModalitiesInStudy", strings.Join(params.Modalities, ","))
 	}
 	if params.StudyDescription != "" {
 		urlParams.Add("StudyDescription", params.StudyDescription)
 	}
+	for tag, value := range params.Filters {
+		urlParams.Add(tag, value)
+	}
 	if params.Limit > 0 {
 		urlParams.Add("limit", fmt.Sprintf("%d", params.Limit))
 	}
@@ -94,29 +198,39 @@ func (d *DICOMWebAdapter) FindStudies(ctx context.Context, params models.QueryPa
 	}
 
 	// Add authentication
-	d.addAuth(req)
+	if err := d.addAuth(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
+	}
 
 	// Set headers
 	req.Header.Set("Accept", "application/dicom+json")
 
 	// Execute request
-	resp, err := d.client.Do(req)
+	resp, err := d.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("PACS returned status %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp)
 	}
 
 	// Parse response
-	var studies []models.Study
-	if err := json.NewDecoder(resp.Body).Decode(&studies); err != nil {
+	datasets, err := decodeDICOMJSON(resp.Body)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	studies := make([]models.Study, 0, len(datasets))
+	for _, ds := range datasets {
+		studies = append(studies, datasetToStudy(ds))
+	}
+	d.quirks.normalizeStudies(studies)
+	if err := d.quirks.fillStudyCounts(ctx, studies, d.FindSeries); err != nil {
+		return nil, err
+	}
+
 	return studies, nil
 }
 
@@ -129,25 +243,32 @@ func (d *DICOMWebAdapter) FindSeries(ctx context.Context, studyUID string) ([]mo
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	d.addAuth(req)
+	if err := d.addAuth(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
+	}
 	req.Header.Set("Accept", "application/dicom+json")
 
-	resp, err := d.client.Do(req)
+	resp, err := d.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("PACS returned status %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp)
 	}
 
-	var series []models.Series
-	if err := json.NewDecoder(resp.Body).Decode(&series); err != nil {
+	datasets, err := decodeDICOMJSON(resp.Body)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	series := make([]models.Series, 0, len(datasets))
+	for _, ds := range datasets {
+		series = append(series, datasetToSeries(ds))
+	}
+	d.quirks.normalizeSeries(series)
+
 	return series, nil
 }
 
@@ -160,30 +281,86 @@ func (d *DICOMWebAdapter) FindInstances(ctx context.Context, studyUID, seriesUID
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	d.addAuth(req)
+	if err := d.addAuth(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
+	}
 	req.Header.Set("Accept", "application/dicom+json")
 
-	resp, err := d.client.Do(req)
+	resp, err := d.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("PACS returned status %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp)
 	}
 
-	var instances []models.Instance
-	if err := json.NewDecoder(resp.Body).Decode(&instances); err != nil {
+	datasets, err := decodeDICOMJSON(resp.Body)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	instances := make([]models.Instance, 0, len(datasets))
+	for _, ds := range datasets {
+		instances = append(instances, datasetToInstance(ds))
+	}
+
 	return instances, nil
 }
 
+// ObjectExists issues a minimal QIDO-RS query matching the object's UID
+func (d *DICOMWebAdapter) ObjectExists(ctx context.Context, studyUID, seriesUID, instanceUID string) (bool, error) {
+	var queryURL string
+	urlParams := url.Values{}
+	urlParams.Set("limit", "1")
+
+	switch {
+	case instanceUID != "":
+		queryURL = fmt.Sprintf("%s/studies/%s/series/%s/instances", d.baseURL, studyUID, seriesUID)
+		urlParams.Set("SOPInstanceUID", instanceUID)
+	case seriesUID != "":
+		queryURL = fmt.Sprintf("%s/studies/%s/series", d.baseURL, studyUID)
+		urlParams.Set("SeriesInstanceUID", seriesUID)
+	default:
+		queryURL = fmt.Sprintf("%s/studies", d.baseURL)
+		urlParams.Set("StudyInstanceUID", studyUID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", queryURL+"?"+urlParams.Encode(), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := d.addAuth(req); err != nil {
+		return false, fmt.Errorf("failed to authenticate request: %w", err)
+	}
+	req.Header.Set("Accept", "application/dicom+json")
+
+	resp, err := d.do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotFound:
+		return false, nil
+	default:
+		return false, statusError(resp)
+	}
+
+	datasets, err := decodeDICOMJSON(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return len(datasets) > 0, nil
+}
+
 // GetInstance retrieves an instance using WADO-RS
-func (d *DICOMWebAdapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string) (io.ReadCloser, string, error) {
+func (d *DICOMWebAdapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
 	retrieveURL := fmt.Sprintf("%s/studies/%s/series/%s/instances/%s",
 		d.baseURL, studyUID, seriesUID, instanceUID)
 
@@ -192,92 +369,271 @@ func (d *DICOMWebAdapter) GetInstance(ctx context.Context, studyUID, seriesUID,
 		return nil, "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	d.addAuth(req)
-	req.Header.Set("Accept", "application/dicom, multipart/related; type=application/dicom")
+	if err := d.addAuth(req); err != nil {
+		return nil, "", fmt.Errorf("failed to authenticate request: %w", err)
+	}
+	transferSyntax := opts.TransferSyntax
+	if d.storedTransferSyntax && transferSyntax == "" {
+		transferSyntax = "*"
+	}
+	req.Header.Set("Accept", retrieveAcceptHeader(transferSyntax))
+	if opts.Range != "" {
+		// Byte ranges only apply to the single-part representation
+		req.Header.Set("Accept", singlePartAcceptHeader(transferSyntax))
+		req.Header.Set("Range", opts.Range)
+	}
 
-	resp, err := d.client.Do(req)
+	resp, err := d.do(req)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to execute request: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusPartialContent {
+		return &models.InstanceBody{
+			ReadCloser:    resp.Body,
+			ContentLength: resp.ContentLength,
+			ContentRange:  resp.Header.Get("Content-Range"),
+		}, resp.Header.Get("Content-Type"), nil
+	}
+
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		resp.Body.Close()
+		return nil, "", ErrRangeNotSatisfiable
+	}
+
 	if resp.StatusCode != http.StatusOK {
+		err := statusError(resp)
 		resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, "", fmt.Errorf("PACS returned status %d: %s", resp.StatusCode, string(body))
+		return nil, "", err
+	}
+
+	// Normalize multipart/related responses to a single application/dicom body
+	body, contentType, err := singlePart(resp.Body, resp.Header.Get("Content-Type"), d.quirks)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The length is only known when the PACS returned the instance as a single part
+	contentLength := int64(-1)
+	if body == resp.Body {
+		contentLength = resp.ContentLength
 	}
 
-	contentType := resp.Header.Get("Content-Type")
-	return resp.Body, contentType, nil
+	return &models.InstanceBody{ReadCloser: body, ContentLength: contentLength}, contentType, nil
 }
 
-// GetInstanceMetadata retrieves instance metadata
-func (d *DICOMWebAdapter) GetInstanceMetadata(ctx context.Context, studyUID, seriesUID, instanceUID string) (*models.Metadata, error) {
-	metadataURL := fmt.Sprintf("%s/studies/%s/series/%s/instances/%s/metadata",
-		d.baseURL, studyUID, seriesUID, instanceUID)
+// GetFrame retrieves a single frame using WADO-RS frame retrieval. Uncompressed frames
+// are returned as application/octet-stream, compressed ones in their image media type.
+func (d *DICOMWebAdapter) GetFrame(ctx context.Context, studyUID, seriesUID, instanceUID string, frame int) (io.ReadCloser, string, error) {
+	frameURL := fmt.Sprintf("%s/studies/%s/series/%s/instances/%s/frames/%d",
+		d.baseURL, studyUID, seriesUID, instanceUID, frame)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", metadataURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", frameURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	d.addAuth(req)
-	req.Header.Set("Accept", "application/dicom+json")
+	if err := d.addAuth(req); err != nil {
+		return nil, "", fmt.Errorf("failed to authenticate request: %w", err)
+	}
+	req.Header.Set("Accept", `multipart/related; type="application/octet-stream"; transfer-syntax=*, multipart/related; type="image/*"; transfer-syntax=*`)
 
-	resp, err := d.client.Do(req)
+	resp, err := d.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, "", fmt.Errorf("failed to execute request: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("PACS returned status %d: %s", resp.StatusCode, string(body))
+		err := statusError(resp)
+		resp.Body.Close()
+		return nil, "", err
 	}
 
-	var metadata models.Metadata
-	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	return firstPart(resp.Body, resp.Header.Get("Content-Type"), d.quirks)
+}
+
+// GetRendered retrieves a rendered representation of an instance using WADO-RS
+func (d *DICOMWebAdapter) GetRendered(ctx context.Context, studyUID, seriesUID, instanceUID, mediaType string) (io.ReadCloser, string, error) {
+	renderedURL := fmt.Sprintf("%s/studies/%s/series/%s/instances/%s/rendered",
+		d.baseURL, studyUID, seriesUID, instanceUID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", renderedURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := d.addAuth(req); err != nil {
+		return nil, "", fmt.Errorf("failed to authenticate request: %w", err)
 	}
+	req.Header.Set("Accept", mediaType)
 
-	return &metadata, nil
+	resp, err := d.do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to execute request: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotAcceptable:
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("%w: rendering as %s", ErrNotSupported, mediaType)
+	default:
+		err := statusError(resp)
+		resp.Body.Close()
+		return nil, "", err
+	}
+
+	return resp.Body, resp.Header.Get("Content-Type"), nil
+}
+
+// retrieveAcceptHeader builds the upstream Accept header for a WADO-RS retrieve,
+// asking for a specific transfer syntax when the client requested one
+func retrieveAcceptHeader(transferSyntax string) string {
+	switch transferSyntax {
+	case "":
+		return `application/dicom, multipart/related; type="application/dicom"`
+	case "*":
+		return `application/dicom; transfer-syntax=*, multipart/related; type="application/dicom"; transfer-syntax=*`
+	}
+	// Any stored transfer syntax is accepted at a lower preference so the
+	// connector can transcode when the archive cannot convert
+	return fmt.Sprintf(`application/dicom; transfer-syntax=%s, multipart/related; type="application/dicom"; transfer-syntax=%s, `+
+		`application/dicom; transfer-syntax=*; q=0.5, multipart/related; type="application/dicom"; transfer-syntax=*; q=0.5`,
+		transferSyntax, transferSyntax)
+}
+
+// singlePartAcceptHeader builds an upstream Accept header that only allows application/dicom
+func singlePartAcceptHeader(transferSyntax string) string {
+	switch transferSyntax {
+	case "":
+		return "application/dicom"
+	case "*":
+		return "application/dicom; transfer-syntax=*"
+	}
+	return fmt.Sprintf("application/dicom; transfer-syntax=%s, application/dicom; transfer-syntax=*; q=0.5", transferSyntax)
+}
+
+// GetInstanceMetadata retrieves instance metadata
+func (d *DICOMWebAdapter) GetInstanceMetadata(ctx context.Context, studyUID, seriesUID, instanceUID string) (*models.Metadata, error) {
+	metadataURL := fmt.Sprintf("%s/studies/%s/series/%s/instances/%s/metadata",
+		d.baseURL, studyUID, seriesUID, instanceUID)
+
+	// Instance metadata is returned as a single-element DICOM JSON array
+	metadata, err := d.fetchMetadata(ctx, metadataURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata) == 0 {
+		return nil, fmt.Errorf("%w: instance metadata", ErrNotFound)
+	}
+
+	return &metadata[0], nil
 }
 
 // GetStudyMetadata retrieves metadata for all instances in a study
 func (d *DICOMWebAdapter) GetStudyMetadata(ctx context.Context, studyUID string) ([]models.Metadata, error) {
-	metadataURL := fmt.Sprintf("%s/studies/%s/metadata", d.baseURL, studyUID)
+	return d.fetchMetadata(ctx, fmt.Sprintf("%s/studies/%s/metadata", d.baseURL, studyUID))
+}
+
+// GetSeriesMetadata retrieves metadata for all instances in a series
+func (d *DICOMWebAdapter) GetSeriesMetadata(ctx context.Context, studyUID, seriesUID string) ([]models.Metadata, error) {
+	return d.fetchMetadata(ctx, fmt.Sprintf("%s/studies/%s/series/%s/metadata", d.baseURL, studyUID, seriesUID))
+}
 
+// fetchMetadata retrieves and decodes a WADO-RS metadata resource. A resource
+// fetched before with an ETag or Last-Modified is requested conditionally, and
+// the kept response is decoded again when the PACS answers 304 Not Modified.
+func (d *DICOMWebAdapter) fetchMetadata(ctx context.Context, metadataURL string) ([]models.Metadata, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", metadataURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	d.addAuth(req)
 	req.Header.Set("Accept", "application/dicom+json")
+	kept := d.validators.condition(metadataURL, req)
+	if err := d.addAuth(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
+	}
 
-	resp, err := d.client.Do(req)
+	resp, err := d.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("PACS returned status %d: %s", resp.StatusCode, string(body))
+	var body []byte
+	switch {
+	case resp.StatusCode == http.StatusNotModified && kept != nil:
+		body = kept.body
+	case resp.StatusCode == http.StatusOK:
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		d.validators.store(metadataURL, resp.Header, body)
+	default:
+		return nil, statusError(resp)
+	}
+
+	datasets, err := decodeDICOMJSON(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	var metadata []models.Metadata
-	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	metadata := make([]models.Metadata, 0, len(datasets))
+	for _, ds := range datasets {
+		metadata = append(metadata, datasetToMetadata(ds))
 	}
 
 	return metadata, nil
 }
 
-// GetThumbnail generates a thumbnail (placeholder for now)
-func (d *DICOMWebAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, size int) ([]byte, error) {
-	// TODO: Implement thumbnail generation
-	// For now, return error indicating not implemented
-	return nil, fmt.Errorf("thumbnail generation not yet implemented")
+// GetThumbnail retrieves the instance and renders a JPEG thumbnail from its pixel data
+func (d *DICOMWebAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	data, _, err := d.GetInstance(ctx, studyUID, seriesUID, instanceUID, models.RetrieveOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer data.Close()
+
+	body, err := io.ReadAll(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance: %w", err)
+	}
+
+	return thumbnail.Generate(body, opts)
+}
+
+// DeleteStudy removes a study from the PACS with a DELETE on the DICOMweb study
+// resource, as supported by dcm4chee
+func (d *DICOMWebAdapter) DeleteStudy(ctx context.Context, studyUID string) error {
+	return d.delete(ctx, fmt.Sprintf("%s/studies/%s", d.baseURL, url.PathEscape(studyUID)))
+}
+
+// delete issues a DELETE request and maps the response status to adapter errors
+func (d *DICOMWebAdapter) delete(ctx context.Context, deleteURL string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", deleteURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := d.addAuth(req); err != nil {
+		return fmt.Errorf("failed to authenticate request: %w", err)
+	}
+
+	resp, err := d.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		return nil
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return ErrNotSupported
+	default:
+		return statusError(resp)
+	}
 }
 
 // TestConnection tests the PACS connection
@@ -309,11 +665,85 @@ func (d *DICOMWebAdapter) Close() error {
 	return nil
 }
 
-// addAuth adds authentication to the request
-func (d *DICOMWebAdapter) addAuth(req *http.Request) {
-	if d.apiKey != "" {
+// newRequest creates an authenticated upstream request
+func (d *DICOMWebAdapter) newRequest(ctx context.Context, method, requestURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := d.addAuth(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
+	}
+	return req, nil
+}
+
+// do executes an upstream request, negotiating compression and transparently
+// decoding gzip/deflate response bodies
+func (d *DICOMWebAdapter) do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Range") != "" {
+		// Byte ranges refer to the identity encoding
+		req.Header.Set("Accept-Encoding", "identity")
+	} else {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	if err := decodeResponseBody(resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// statusError converts an unexpected upstream response into an error, classifying
+// well-known status codes with the adapter sentinel errors
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	err := fmt.Errorf("PACS returned status %d: %s", resp.StatusCode, string(body))
+
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	case http.StatusNotFound, http.StatusGone:
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	case http.StatusRequestEntityTooLarge:
+		return fmt.Errorf("%w: %v", ErrTooLarge, err)
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
+
+// addAuth adds authentication and the adapter's fixed headers to the request
+func (d *DICOMWebAdapter) addAuth(req *http.Request) error {
+	for name, values := range d.headers {
+		req.Header[name] = values
+	}
+
+	switch {
+	case d.signer != nil:
+		hash, err := payloadHash(req)
+		if err != nil {
+			return err
+		}
+		d.signer.sign(req, hash, time.Now().UTC())
+	case d.tokenSource != nil:
+		token, err := d.tokenSource.Token(req.Context())
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	case d.apiKey != "":
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", d.apiKey))
-	} else if d.username != "" && d.password != "" {
+	case d.username != "" && d.password != "":
 		req.SetBasicAuth(d.username, d.password)
 	}
+	return nil
 }
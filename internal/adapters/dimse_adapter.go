@@ -4,13 +4,17 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/dictionary/tags"
 	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/media"
 	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/network"
 	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/services"
+	"github.com/otcheredev/ris-dicom-connector/internal/dicomquery"
 	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
 	"github.com/rs/zerolog/log"
 )
 
@@ -30,6 +34,7 @@ type DIMSEAdapter struct {
 	BaseAdapter
 	config      models.PACSConfig
 	destination *network.Destination
+	quirks      Quirks
 }
 
 // NewDIMSEAdapter creates a new DIMSE adapter
@@ -45,6 +50,11 @@ func NewDIMSEAdapter(config models.PACSConfig) (*DIMSEAdapter, error) {
 		return nil, fmt.Errorf("port is required for DIMSE connection")
 	}
 
+	quirks, err := LookupQuirks(config.Quirks)
+	if err != nil {
+		return nil, err
+	}
+
 	destination := &network.Destination{
 		HostName:  config.Endpoint,
 		Port:      config.Port,
@@ -67,6 +77,7 @@ func NewDIMSEAdapter(config models.PACSConfig) (*DIMSEAdapter, error) {
 		BaseAdapter: BaseAdapter{config: config},
 		config:      config,
 		destination: destination,
+		quirks:      quirks,
 	}, nil
 }
 
@@ -146,7 +157,7 @@ func (d *DIMSEAdapter) FindStudies(ctx context.Context, params models.QueryParam
 	}
 
 	if params.PatientName != "" {
-		query.WriteString(tags.PatientName, params.PatientName)
+		query.WriteString(tags.PatientName, dicomquery.PersonNameQuery(params.PatientName))
 	} else {
 		query.WriteString(tags.PatientName, "")
 	}
@@ -163,8 +174,9 @@ func (d *DIMSEAdapter) FindStudies(ctx context.Context, params models.QueryParam
 		query.WriteString(tags.AccessionNumber, "")
 	}
 
-	if params.Modality != "" {
-		query.WriteString(tags.ModalitiesInStudy, params.Modality)
+	if len(params.Modalities) > 0 {
+		// Multi-valued matching key: a study matches if it contains any of the modalities
+		query.WriteString(tags.ModalitiesInStudy, strings.Join(params.Modalities, "\\"))
 	} else {
 		query.WriteString(tags.ModalitiesInStudy, "")
 	}
@@ -175,13 +187,18 @@ func (d *DIMSEAdapter) FindStudies(ctx context.Context, params models.QueryParam
 
 	// Required return keys for study level
 	query.WriteString(tags.StudyInstanceUID, "")
-	query.WriteString(tags.StudyTime, "")
+	query.WriteString(tags.StudyTime, params.StudyTime)
 	query.WriteString(tags.ReferringPhysicianName, "")
 	query.WriteString(tags.PatientBirthDate, "")
 	query.WriteString(tags.PatientSex, "")
 	query.WriteString(tags.NumberOfStudyRelatedSeries, "")
 	query.WriteString(tags.NumberOfStudyRelatedInstances, "")
 
+	// Additional matching keys, replacing any return key for the same attribute
+	if err := writeQueryFilters(query, params.Filters); err != nil {
+		return nil, err
+	}
+
 	// Store results
 	var studies []models.Study
 
@@ -221,6 +238,11 @@ func (d *DIMSEAdapter) FindStudies(ctx context.Context, params models.QueryParam
 		Str("endpoint", d.config.Endpoint).
 		Msg("C-FIND for studies completed successfully")
 
+	d.quirks.normalizeStudies(studies)
+	if err := d.quirks.fillStudyCounts(ctx, studies, d.FindSeries); err != nil {
+		return nil, err
+	}
+
 	return studies, nil
 }
 
@@ -289,6 +311,7 @@ func (d *DIMSEAdapter) FindSeries(ctx context.Context, studyUID string) ([]model
 		Dur("duration", duration).
 		Msg("C-FIND for series completed successfully")
 
+	d.quirks.normalizeSeries(series)
 	return series, nil
 }
 
@@ -307,7 +330,7 @@ func (d *DIMSEAdapter) FindInstances(ctx context.Context, studyUID, seriesUID st
 	query := media.NewEmptyDCMObj()
 
 	// Set query level (IMAGE is the DICOM standard, some PACS use INSTANCE)
-	query.WriteString(tags.QueryRetrieveLevel, "IMAGE")
+	query.WriteString(tags.QueryRetrieveLevel, d.quirks.instanceLevel())
 
 	// Required keys
 	query.WriteString(tags.StudyInstanceUID, studyUID)
@@ -365,7 +388,7 @@ func (d *DIMSEAdapter) FindInstances(ctx context.Context, studyUID, seriesUID st
 }
 
 // GetInstance retrieves an instance (NOT IMPLEMENTED - Phase 2B)
-func (d *DIMSEAdapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string) (io.ReadCloser, string, error) {
+func (d *DIMSEAdapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
 	log.Warn().
 		Str("study_uid", studyUID).
 		Str("series_uid", seriesUID).
@@ -388,7 +411,7 @@ func (d *DIMSEAdapter) GetInstanceMetadata(ctx context.Context, studyUID, series
 
 	// Build query dataset
 	query := media.NewEmptyDCMObj()
-	query.WriteString(tags.QueryRetrieveLevel, "IMAGE")
+	query.WriteString(tags.QueryRetrieveLevel, d.quirks.instanceLevel())
 	query.WriteString(tags.StudyInstanceUID, studyUID)
 	query.WriteString(tags.SeriesInstanceUID, seriesUID)
 	query.WriteString(tags.SOPInstanceUID, instanceUID)
@@ -414,7 +437,7 @@ func (d *DIMSEAdapter) GetInstanceMetadata(ctx context.Context, studyUID, series
 			SOPInstanceUID:    result.GetString(tags.SOPInstanceUID),
 			SOPClassUID:       result.GetString(tags.SOPClassUID),
 			TransferSyntaxUID: "", // Not available via C-FIND
-			Attributes:        d.extractAttributes(result),
+			Attributes:        dcmObjToAttributes(result),
 		}
 	})
 
@@ -429,7 +452,56 @@ func (d *DIMSEAdapter) GetInstanceMetadata(ctx context.Context, studyUID, series
 	}
 
 	if metadata == nil {
-		return nil, fmt.Errorf("instance not found")
+		return nil, fmt.Errorf("%w: instance", ErrNotFound)
+	}
+
+	return metadata, nil
+}
+
+// GetSeriesMetadata retrieves metadata for all instances in a series using C-FIND at IMAGE level
+func (d *DIMSEAdapter) GetSeriesMetadata(ctx context.Context, studyUID, seriesUID string) ([]models.Metadata, error) {
+	log.Debug().
+		Str("study_uid", studyUID).
+		Str("series_uid", seriesUID).
+		Msg("Getting series metadata via C-FIND")
+
+	scu := services.NewSCU(d.destination)
+
+	query := media.NewEmptyDCMObj()
+	query.WriteString(tags.QueryRetrieveLevel, d.quirks.instanceLevel())
+	query.WriteString(tags.StudyInstanceUID, studyUID)
+	query.WriteString(tags.SeriesInstanceUID, seriesUID)
+	query.WriteString(tags.SOPInstanceUID, "")
+
+	// Request the attributes commonly supported at IMAGE level
+	query.WriteString(tags.SOPClassUID, "")
+	query.WriteString(tags.InstanceNumber, "")
+	query.WriteString(tags.Rows, "")
+	query.WriteString(tags.Columns, "")
+	query.WriteString(tags.BitsAllocated, "")
+	query.WriteString(tags.BitsStored, "")
+	query.WriteString(tags.HighBit, "")
+	query.WriteString(tags.PixelRepresentation, "")
+	query.WriteString(tags.PhotometricInterpretation, "")
+	query.WriteString(tags.SamplesPerPixel, "")
+	query.WriteString(tags.NumberOfFrames, "")
+
+	var metadata []models.Metadata
+	scu.SetOnCFindResult(func(result media.DcmObj) {
+		metadata = append(metadata, models.Metadata{
+			SOPInstanceUID: result.GetString(tags.SOPInstanceUID),
+			SOPClassUID:    result.GetString(tags.SOPClassUID),
+			Attributes:     dcmObjToAttributes(result),
+		})
+	})
+
+	_, status, err := scu.FindSCU(query, TimeoutCFind)
+	if err != nil {
+		return nil, fmt.Errorf("C-FIND failed: %w", err)
+	}
+
+	if status != 0x0000 {
+		return nil, fmt.Errorf("C-FIND completed with status: 0x%04X", status)
 	}
 
 	return metadata, nil
@@ -449,8 +521,7 @@ func (d *DIMSEAdapter) GetStudyMetadata(ctx context.Context, studyUID string) ([
 
 	var allMetadata []models.Metadata
 	for _, s := range series {
-		// Get instances in series
-		instances, err := d.FindInstances(ctx, studyUID, s.SeriesInstanceUID)
+		metadata, err := d.GetSeriesMetadata(ctx, studyUID, s.SeriesInstanceUID)
 		if err != nil {
 			log.Warn().
 				Err(err).
@@ -459,16 +530,7 @@ func (d *DIMSEAdapter) GetStudyMetadata(ctx context.Context, studyUID string) ([
 				Msg("Failed to get instances for series, skipping")
 			continue
 		}
-
-		for _, inst := range instances {
-			metadata := models.Metadata{
-				SOPInstanceUID:    inst.SOPInstanceUID,
-				SOPClassUID:       inst.SOPClassUID,
-				TransferSyntaxUID: inst.TransferSyntaxUID,
-				Attributes:        make(map[string]interface{}),
-			}
-			allMetadata = append(allMetadata, metadata)
-		}
+		allMetadata = append(allMetadata, metadata...)
 	}
 
 	log.Info().
@@ -479,9 +541,60 @@ func (d *DIMSEAdapter) GetStudyMetadata(ctx context.Context, studyUID string) ([
 	return allMetadata, nil
 }
 
-// GetThumbnail generates a thumbnail (not supported via DIMSE)
-func (d *DIMSEAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, size int) ([]byte, error) {
-	return nil, fmt.Errorf("thumbnail generation not supported via DIMSE protocol")
+// GetThumbnail is not supported, as instances cannot be retrieved over DIMSE yet
+func (d *DIMSEAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+// ObjectExists probes for an object using C-FIND at the matching level
+func (d *DIMSEAdapter) ObjectExists(ctx context.Context, studyUID, seriesUID, instanceUID string) (bool, error) {
+	switch {
+	case instanceUID != "":
+		instances, err := d.FindInstances(ctx, studyUID, seriesUID)
+		if err != nil {
+			return false, err
+		}
+		for _, instance := range instances {
+			if instance.SOPInstanceUID == instanceUID {
+				return true, nil
+			}
+		}
+		return false, nil
+	case seriesUID != "":
+		series, err := d.FindSeries(ctx, studyUID)
+		if err != nil {
+			return false, err
+		}
+		for _, s := range series {
+			if s.SeriesInstanceUID == seriesUID {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		studies, err := d.FindStudies(ctx, models.QueryParams{
+			Filters: map[string]string{"0020000D": studyUID},
+		})
+		if err != nil {
+			return false, err
+		}
+		return len(studies) > 0, nil
+	}
+}
+
+// GetFrame is not available over DIMSE; frames can only be extracted from a retrieved instance
+func (d *DIMSEAdapter) GetFrame(ctx context.Context, studyUID, seriesUID, instanceUID string, frame int) (io.ReadCloser, string, error) {
+	return nil, "", fmt.Errorf("%w: frame retrieval over DIMSE", ErrNotSupported)
+}
+
+// GetRendered is not available over DIMSE; there is no rendering service
+func (d *DIMSEAdapter) GetRendered(ctx context.Context, studyUID, seriesUID, instanceUID, mediaType string) (io.ReadCloser, string, error) {
+	return nil, "", fmt.Errorf("%w: rendering over DIMSE", ErrNotSupported)
+}
+
+// DeleteStudy is not available over DIMSE; there is no standard deletion service
+func (d *DIMSEAdapter) DeleteStudy(ctx context.Context, studyUID string) error {
+	return ErrNotSupported
 }
 
 // Close closes the adapter (no persistent connections with this implementation)
@@ -498,14 +611,14 @@ func (d *DIMSEAdapter) dicomToStudy(dcmObj media.DcmObj) models.Study {
 	return models.Study{
 		StudyInstanceUID:   dcmObj.GetString(tags.StudyInstanceUID),
 		PatientID:          dcmObj.GetString(tags.PatientID),
-		PatientName:        dcmObj.GetString(tags.PatientName),
+		PatientName:        dicomquery.FormatPersonName(dcmObj.GetString(tags.PatientName)),
 		PatientBirthDate:   dcmObj.GetString(tags.PatientBirthDate),
 		PatientSex:         dcmObj.GetString(tags.PatientSex),
 		StudyDate:          dcmObj.GetString(tags.StudyDate),
 		StudyTime:          dcmObj.GetString(tags.StudyTime),
 		StudyDescription:   dcmObj.GetString(tags.StudyDescription),
 		AccessionNumber:    dcmObj.GetString(tags.AccessionNumber),
-		ReferringPhysician: dcmObj.GetString(tags.ReferringPhysicianName),
+		ReferringPhysician: dicomquery.FormatPersonName(dcmObj.GetString(tags.ReferringPhysicianName)),
 		NumberOfSeries:     d.getIntValue(dcmObj, tags.NumberOfStudyRelatedSeries),
 		NumberOfInstances:  d.getIntValue(dcmObj, tags.NumberOfStudyRelatedInstances),
 		ModalitiesInStudy:  d.getModalitiesInStudy(dcmObj),
@@ -583,31 +696,93 @@ func (d *DIMSEAdapter) getModalitiesInStudy(dcmObj media.DcmObj) []string {
 	return modalities
 }
 
-func (d *DIMSEAdapter) extractAttributes(dcmObj media.DcmObj) map[string]interface{} {
+// dcmObjToAttributes converts the top-level attributes of a C-FIND response into
+// DICOM JSON model attributes keyed by tag. Sequences and binary values are skipped.
+func dcmObjToAttributes(obj media.DcmObj) map[string]interface{} {
 	attrs := make(map[string]interface{})
+	depth := 0
 
-	// Extract common attributes
-	if val := dcmObj.GetString(tags.Rows); val != "" {
-		attrs["Rows"] = val
-	}
-	if val := dcmObj.GetString(tags.Columns); val != "" {
-		attrs["Columns"] = val
-	}
-	if val := dcmObj.GetString(tags.BitsAllocated); val != "" {
-		attrs["BitsAllocated"] = val
+	for _, tag := range obj.GetTags() {
+		// Track sequence nesting so only top-level attributes are converted
+		if (tag.VR == "SQ" && tag.Length == 0xFFFFFFFF) || (tag.Group == 0xFFFE && tag.Element == 0xE000 && tag.Length == 0xFFFFFFFF) {
+			depth++
+			continue
+		}
+		if tag.Group == 0xFFFE && (tag.Element == 0xE00D || tag.Element == 0xE0DD) {
+			depth--
+			continue
+		}
+		if depth > 0 || tag.Group == 0xFFFE || tag.Group <= 0x0002 || tag.VR == "SQ" {
+			continue
+		}
+
+		key := fmt.Sprintf("%04X%04X", tag.Group, tag.Element)
+		attr := map[string]interface{}{"vr": tag.VR}
+		if values := dcmTagValues(tag); len(values) > 0 {
+			attr["Value"] = values
+		}
+		attrs[key] = attr
 	}
-	if val := dcmObj.GetString(tags.BitsStored); val != "" {
-		attrs["BitsStored"] = val
+
+	return attrs
+}
+
+// dcmTagValues returns the values of a tag in DICOM JSON form
+func dcmTagValues(tag *media.DcmTag) []interface{} {
+	if tag.Length == 0 {
+		return nil
 	}
-	if val := dcmObj.GetString(tags.PhotometricInterpretation); val != "" {
-		attrs["PhotometricInterpretation"] = val
+
+	switch tag.VR {
+	case "US":
+		return []interface{}{tag.GetUShort()}
+	case "UL":
+		return []interface{}{tag.GetUInt()}
+	case "OB", "OD", "OF", "OL", "OV", "OW", "UN":
+		return nil
 	}
-	if val := dcmObj.GetString(tags.SamplesPerPixel); val != "" {
-		attrs["SamplesPerPixel"] = val
+
+	raw := strings.TrimRight(tag.GetString(), " \x00")
+	if raw == "" {
+		return nil
 	}
-	if val := dcmObj.GetString(tags.NumberOfFrames); val != "" {
-		attrs["NumberOfFrames"] = val
+
+	var values []interface{}
+	for _, v := range strings.Split(raw, "\\") {
+		v = strings.TrimSpace(v)
+		switch tag.VR {
+		case "PN":
+			values = append(values, map[string]interface{}{"Alphabetic": v})
+		case "IS", "DS":
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				values = append(values, n)
+			}
+		default:
+			values = append(values, v)
+		}
 	}
+	return values
+}
 
-	return attrs
+// writeQueryFilters adds additional C-FIND matching keys given as GGGGEEEE tags
+func writeQueryFilters(query media.DcmObj, filters map[string]string) error {
+	for key, value := range filters {
+		tagValue, err := strconv.ParseUint(key, 16, 32)
+		if err != nil || len(key) != 8 {
+			return fmt.Errorf("invalid matching key tag: %s", key)
+		}
+
+		tag := tags.GetTag(uint16(tagValue>>16), uint16(tagValue))
+		if tag.Name == "" {
+			return fmt.Errorf("unknown matching key tag: %s", key)
+		}
+
+		for i := query.TagCount() - 1; i >= 0; i-- {
+			if existing := query.GetTagAt(i); existing.Group == tag.Group && existing.Element == tag.Element {
+				query.DelTag(i)
+			}
+		}
+		query.WriteString(tag, value)
+	}
+	return nil
 }
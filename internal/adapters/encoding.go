@@ -0,0 +1,74 @@
+package adapters
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// acceptEncoding is advertised to upstream PACS servers
+const acceptEncoding = "gzip, deflate"
+
+// decodedBody closes both the decompressor and the underlying response body
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (d *decodedBody) Close() error {
+	var firstErr error
+	for _, c := range d.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// decodeResponseBody replaces a gzip or deflate encoded response body with its
+// decompressed stream. Some PACS compress regardless of Accept-Encoding, so this
+// is applied to every upstream response.
+func decodeResponseBody(resp *http.Response) error {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	switch encoding {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return fmt.Errorf("failed to decode gzip response: %w", err)
+		}
+		resp.Body = &decodedBody{Reader: zr, closers: []io.Closer{zr, resp.Body}}
+	case "deflate":
+		// "deflate" is zlib-wrapped per RFC 9110, but some servers send raw DEFLATE
+		br := bufio.NewReader(resp.Body)
+		header, _ := br.Peek(2)
+		if len(header) == 2 && header[0]&0x0F == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				resp.Body.Close()
+				return fmt.Errorf("failed to decode deflate response: %w", err)
+			}
+			resp.Body = &decodedBody{Reader: zr, closers: []io.Closer{zr, resp.Body}}
+		} else {
+			fr := flate.NewReader(br)
+			resp.Body = &decodedBody{Reader: fr, closers: []io.Closer{fr, resp.Body}}
+		}
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
@@ -1,58 +1,221 @@
 package adapters
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/otcheredev/ris-dicom-connector/internal/models"
 	"github.com/rs/zerolog/log"
 )
 
-// AdapterFactory manages PACS adapter instances
+// adapterEvictionInterval is how often idle adapters are looked for
+const adapterEvictionInterval = time.Minute
+
+// AdapterFactory manages PACS adapter instances, one per PACS config. Adapters
+// not used for the idle timeout are closed, and those removed while calls are
+// using them are closed once the last call is done. The credentials of a
+// config are decrypted, or fetched from the secret store they reference, only
+// to create its adapter.
 type AdapterFactory struct {
-	mu       sync.RWMutex
-	adapters map[uuid.UUID]PACSAdapter // keyed by tenant ID
+	mu          sync.RWMutex
+	adapters    map[uuid.UUID]*sharedAdapter // keyed by PACS config ID
+	idleTimeout time.Duration
+	credentials *CredentialCipher // nil when credentials are stored in plaintext
+	secrets     *SecretResolver   // nil when no secret store is configured
+
+	stop    chan struct{}
+	stopped sync.Once
+	wg      sync.WaitGroup
 }
 
-// NewAdapterFactory creates a new adapter factory
-func NewAdapterFactory() *AdapterFactory {
-	return &AdapterFactory{
-		adapters: make(map[uuid.UUID]PACSAdapter),
+// NewAdapterFactory creates a new adapter factory evicting adapters idle for
+// idleTimeout; 0 keeps them until they are removed. Credentials are opened
+// with the cipher they were sealed with, nil when they are not encrypted.
+func NewAdapterFactory(idleTimeout time.Duration, credentials *CredentialCipher) *AdapterFactory {
+	f := &AdapterFactory{
+		adapters:    make(map[uuid.UUID]*sharedAdapter),
+		idleTimeout: idleTimeout,
+		credentials: credentials,
+		stop:        make(chan struct{}),
+	}
+	if idleTimeout > 0 {
+		f.wg.Add(1)
+		go f.evictIdle()
 	}
+	return f
 }
 
-// GetAdapter gets or creates an adapter for a tenant
+// GetAdapter gets or creates the adapter of a PACS config
 func (f *AdapterFactory) GetAdapter(config models.PACSConfig) (PACSAdapter, error) {
-	f.mu.RLock()
-	adapter, exists := f.adapters[config.TenantID]
-	f.mu.RUnlock()
-
-	if exists {
+	if adapter, exists := f.cached(config); exists {
 		log.Debug().
 			Str("tenant_id", config.TenantID.String()).
+			Str("config_id", config.ID.String()).
 			Str("type", string(config.Type)).
 			Msg("Reusing existing adapter")
 		return adapter, nil
 	}
 
-	// Create new adapter
+	sources := []models.PACSConfig{config}
+	return f.create(config, sources, func() (PACSAdapter, string, error) {
+		opened, fingerprint, err := f.prepare(config)
+		if err != nil {
+			return nil, "", err
+		}
+		adapter, err := newAdapter(opened)
+		return adapter, fingerprint, err
+	})
+}
+
+// GetFederatedAdapter gets or creates the composite adapter of a PACS config of
+// type federated, querying the given member configs
+func (f *AdapterFactory) GetFederatedAdapter(config models.PACSConfig, members []models.PACSConfig) (PACSAdapter, error) {
+	if adapter, exists := f.cached(config); exists {
+		return adapter, nil
+	}
+
+	return f.create(config, members, func() (PACSAdapter, string, error) {
+		opened, fingerprint, err := f.prepareAll(members)
+		if err != nil {
+			return nil, "", err
+		}
+		log.Info().
+			Str("tenant_id", config.TenantID.String()).
+			Int("members", len(members)).
+			Msg("Creating federated composite adapter")
+		adapter, err := NewCompositeAdapter(config, opened)
+		return adapter, fingerprint, err
+	})
+}
+
+// EnableSecretStores lets PACS credentials reference the secret stores of a
+// resolver. Every refreshInterval the secrets referenced by the cached
+// adapters are fetched again, and adapters whose secrets were rotated are
+// removed so the next request connects with the new ones.
+func (f *AdapterFactory) EnableSecretStores(resolver *SecretResolver, refreshInterval time.Duration) {
+	f.secrets = resolver
+	f.wg.Add(1)
+	go f.refreshSecrets(refreshInterval)
+}
+
+// CheckCredentials validates the secret store references of a PACS config
+// before it is stored
+func (f *AdapterFactory) CheckCredentials(config *models.PACSConfig) error {
+	return f.secrets.checkPACSCredentials(config)
+}
+
+// ResolveCredentials returns a copy of a PACS config that is not stored, such
+// as one whose connection is tested, with the credentials referencing a
+// secret store replaced by the secret
+func (f *AdapterFactory) ResolveCredentials(ctx context.Context, config models.PACSConfig) (models.PACSConfig, error) {
+	resolved, _, err := f.secrets.resolvePACSCredentials(ctx, config)
+	return resolved, err
+}
+
+// SealCredentials encrypts the credentials of a PACS config still held in
+// plaintext before it is stored, and returns how many it encrypted
+func (f *AdapterFactory) SealCredentials(ctx context.Context, config *models.PACSConfig) (int, error) {
+	return f.credentials.SealPACSCredentials(ctx, config)
+}
+
+// EncryptsCredentials reports whether the credentials of PACS configs are
+// stored encrypted
+func (f *AdapterFactory) EncryptsCredentials() bool {
+	return f.credentials != nil
+}
+
+// cached returns the cached adapter of a config, marking it used so it is not
+// evicted before the caller's calls start
+func (f *AdapterFactory) cached(config models.PACSConfig) (*sharedAdapter, bool) {
+	f.mu.RLock()
+	adapter, exists := f.adapters[config.ID]
+	f.mu.RUnlock()
+	if exists {
+		adapter.touch()
+	}
+	return adapter, exists
+}
+
+// create builds and caches a config's adapter unless another request already
+// did. build returns the fingerprint of the secrets the adapter's sources
+// reference, kept to notice when they are rotated.
+func (f *AdapterFactory) create(config models.PACSConfig, sources []models.PACSConfig, build func() (PACSAdapter, string, error)) (PACSAdapter, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if adapter, exists := f.adapters[config.TenantID]; exists {
+	if adapter, exists := f.adapters[config.ID]; exists {
+		adapter.touch()
 		return adapter, nil
 	}
 
-	var err error
+	adapter, fingerprint, err := build()
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("tenant_id", config.TenantID.String()).
+			Str("config_id", config.ID.String()).
+			Str("type", string(config.Type)).
+			Msg("Failed to create adapter")
+		return nil, fmt.Errorf("failed to create adapter: %w", err)
+	}
+
+	shared := newSharedAdapter(adapter, config)
+	if fingerprint != "" {
+		shared.sources = sources
+		shared.secrets = fingerprint
+	}
+	f.adapters[config.ID] = shared
+
+	log.Info().
+		Str("tenant_id", config.TenantID.String()).
+		Str("config_id", config.ID.String()).
+		Str("type", string(config.Type)).
+		Strs("capabilities", adapter.Capabilities()).
+		Msg("Adapter created and cached")
+
+	return shared, nil
+}
+
+// prepare returns a copy of a PACS config with its credentials decrypted and
+// those referencing a secret store fetched, and the fingerprint of the secrets
+func (f *AdapterFactory) prepare(config models.PACSConfig) (models.PACSConfig, string, error) {
+	opened, err := f.credentials.openPACSCredentials(config)
+	if err != nil {
+		return config, "", err
+	}
+	return f.secrets.resolvePACSCredentials(context.Background(), opened)
+}
+
+// prepareAll prepares the member configs of a federated PACS; the fingerprint
+// covers the secrets of every member, empty when none references any
+func (f *AdapterFactory) prepareAll(members []models.PACSConfig) ([]models.PACSConfig, string, error) {
+	prepared := make([]models.PACSConfig, len(members))
+	fingerprints := ""
+	for i, member := range members {
+		var fingerprint string
+		var err error
+		if prepared[i], fingerprint, err = f.prepare(member); err != nil {
+			return nil, "", fmt.Errorf("PACS config %s: %w", member.Name, err)
+		}
+		fingerprints += fingerprint
+	}
+	return prepared, fingerprints, nil
+}
+
+// newAdapter creates the adapter for a PACS config of any concrete type
+func newAdapter(config models.PACSConfig) (PACSAdapter, error) {
 	switch config.Type {
 	case models.PACSTypeDICOMWeb:
 		log.Info().
 			Str("tenant_id", config.TenantID.String()).
 			Str("endpoint", config.Endpoint).
 			Msg("Creating DICOMweb adapter")
-		adapter, err = NewDICOMWebAdapter(config)
+		return NewDICOMWebAdapter(config)
 
 	case models.PACSTypeDIMSE:
 		log.Info().
@@ -61,73 +224,228 @@ func (f *AdapterFactory) GetAdapter(config models.PACSConfig) (PACSAdapter, erro
 			Int("port", config.Port).
 			Str("ae_title", config.AETitle).
 			Msg("Creating DIMSE adapter")
-		adapter, err = NewDIMSEAdapter(config)
+		return NewDIMSEAdapter(config)
 
 	case models.PACSTypeOrthanc:
-		// Orthanc supports both DICOMweb and DIMSE
-		// For now, use DICOMweb as it's more feature-complete
 		log.Info().
 			Str("tenant_id", config.TenantID.String()).
 			Str("endpoint", config.Endpoint).
-			Msg("Creating Orthanc adapter (using DICOMweb)")
-		adapter, err = NewDICOMWebAdapter(config)
+			Msg("Creating Orthanc REST adapter")
+		return NewOrthancAdapter(config)
 
-	default:
-		return nil, fmt.Errorf("unsupported PACS type: %s", config.Type)
-	}
+	case models.PACSTypeDcm4chee:
+		log.Info().
+			Str("tenant_id", config.TenantID.String()).
+			Str("endpoint", config.Endpoint).
+			Str("ae_title", config.AETitle).
+			Msg("Creating dcm4chee-arc adapter")
+		return NewDcm4cheeAdapter(config)
 
-	if err != nil {
-		log.Error().
-			Err(err).
+	case models.PACSTypeS3:
+		log.Info().
 			Str("tenant_id", config.TenantID.String()).
-			Str("type", string(config.Type)).
-			Msg("Failed to create adapter")
-		return nil, fmt.Errorf("failed to create adapter: %w", err)
-	}
+			Str("endpoint", config.Endpoint).
+			Str("bucket", config.S3Bucket).
+			Msg("Creating S3 object store adapter")
+		return NewS3Adapter(config)
 
-	f.adapters[config.TenantID] = adapter
+	case models.PACSTypeXDSI:
+		log.Info().
+			Str("tenant_id", config.TenantID.String()).
+			Str("base_url", config.BaseURL).
+			Str("repository_unique_id", config.XDSRepositoryUniqueID).
+			Msg("Creating XDS-I.b imaging document source adapter")
+		return NewXDSIAdapter(config)
 
-	log.Info().
-		Str("tenant_id", config.TenantID.String()).
-		Str("type", string(config.Type)).
-		Strs("capabilities", adapter.Capabilities()).
-		Msg("Adapter created and cached")
+	case models.PACSTypeGRPC:
+		log.Info().
+			Str("tenant_id", config.TenantID.String()).
+			Str("endpoint", config.Endpoint).
+			Int("port", config.Port).
+			Msg("Creating gRPC plugin adapter")
+		return NewGRPCAdapter(config)
+
+	case models.PACSTypeConnector:
+		log.Info().
+			Str("tenant_id", config.TenantID.String()).
+			Str("endpoint", config.Endpoint).
+			Str("remote_tenant_id", config.RemoteTenantID).
+			Msg("Creating upstream connector adapter")
+		return NewConnectorAdapter(config)
+
+	case models.PACSTypeGoogleHealthcare:
+		log.Info().
+			Str("tenant_id", config.TenantID.String()).
+			Str("project", config.GCPProject).
+			Str("dicom_store", config.GCPDICOMStore).
+			Msg("Creating Google Cloud Healthcare adapter")
+		return NewGoogleHealthcareAdapter(config)
 
-	return adapter, nil
+	case models.PACSTypeFederated:
+		return nil, fmt.Errorf("federated PACS configs are created with their members")
+
+	default:
+		return nil, fmt.Errorf("unsupported PACS type: %s", config.Type)
+	}
 }
 
-// RemoveAdapter removes an adapter for a tenant
-func (f *AdapterFactory) RemoveAdapter(tenantID uuid.UUID) error {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+// RemoveAdapter removes the adapter of a PACS config, and the federated
+// adapters of its tenant, whose members may include it. An adapter in use is
+// closed once its last call is done.
+func (f *AdapterFactory) RemoveAdapter(configID uuid.UUID) error {
+	_, err := f.remove(configID)
+	return err
+}
+
+// DrainAdapter removes the adapter of a PACS config like RemoveAdapter, then
+// waits until the calls using the removed adapters are done or ctx ends. It
+// returns the number of calls still running when ctx ended.
+func (f *AdapterFactory) DrainAdapter(ctx context.Context, configID uuid.UUID) (int, error) {
+	removed, err := f.remove(configID)
+
+	running := 0
+	for _, shared := range removed {
+		select {
+		case <-shared.done:
+		case <-ctx.Done():
+		}
+		running += shared.calls()
+	}
+	return running, err
+}
 
-	adapter, exists := f.adapters[tenantID]
+// remove retires the adapter of a PACS config and the federated adapters of
+// its tenant, and returns those it removed
+func (f *AdapterFactory) remove(configID uuid.UUID) ([]*sharedAdapter, error) {
+	f.mu.Lock()
+	adapter, exists := f.adapters[configID]
 	if !exists {
+		f.mu.Unlock()
 		log.Debug().
-			Str("tenant_id", tenantID.String()).
+			Str("config_id", configID.String()).
 			Msg("Adapter not found, nothing to remove")
-		return nil
+		return nil, nil
 	}
 
-	if err := adapter.Close(); err != nil {
-		log.Error().
-			Err(err).
-			Str("tenant_id", tenantID.String()).
-			Msg("Failed to close adapter")
-		return fmt.Errorf("failed to close adapter: %w", err)
+	removed := []*sharedAdapter{adapter}
+	delete(f.adapters, configID)
+	for id, other := range f.adapters {
+		if other.tenantID == adapter.tenantID && other.Type() == models.PACSTypeFederated {
+			removed = append(removed, other)
+			delete(f.adapters, id)
+		}
 	}
+	f.mu.Unlock()
 
-	delete(f.adapters, tenantID)
+	var errs []error
+	for _, shared := range removed {
+		if err := shared.retire(); err != nil {
+			log.Error().
+				Err(err).
+				Str("config_id", shared.configID.String()).
+				Msg("Failed to close adapter")
+			errs = append(errs, err)
+		}
+	}
 
 	log.Info().
-		Str("tenant_id", tenantID.String()).
+		Str("tenant_id", adapter.tenantID.String()).
+		Str("config_id", configID.String()).
+		Int("removed", len(removed)).
 		Msg("Adapter removed")
 
-	return nil
+	if len(errs) > 0 {
+		return removed, fmt.Errorf("failed to close adapter: %w", errors.Join(errs...))
+	}
+	return removed, nil
+}
+
+// evictIdle closes the adapters no call has used for the idle timeout
+func (f *AdapterFactory) evictIdle() {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(min(adapterEvictionInterval, f.idleTimeout))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		var idle []*sharedAdapter
+		f.mu.Lock()
+		for id, adapter := range f.adapters {
+			if adapter.idleSince(now.Add(-f.idleTimeout)) {
+				idle = append(idle, adapter)
+				delete(f.adapters, id)
+			}
+		}
+		f.mu.Unlock()
+
+		for _, adapter := range idle {
+			if err := adapter.retire(); err != nil {
+				log.Warn().Err(err).Str("config_id", adapter.configID.String()).Msg("Failed to close idle adapter")
+				continue
+			}
+			log.Debug().
+				Str("tenant_id", adapter.tenantID.String()).
+				Str("config_id", adapter.configID.String()).
+				Msg("Idle adapter evicted")
+		}
+	}
 }
 
-// CloseAll closes all adapters
+// refreshSecrets fetches the secrets referenced by the cached adapters every
+// interval and removes the adapters whose secrets changed
+func (f *AdapterFactory) refreshSecrets(interval time.Duration) {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+		}
+
+		f.mu.RLock()
+		var referencing []*sharedAdapter
+		for _, adapter := range f.adapters {
+			if adapter.secrets != "" {
+				referencing = append(referencing, adapter)
+			}
+		}
+		f.mu.RUnlock()
+
+		for _, adapter := range referencing {
+			_, fingerprint, err := f.prepareAll(adapter.sources)
+			if err != nil {
+				// The adapter keeps the secrets it has until they can be fetched
+				log.Warn().Err(err).Str("config_id", adapter.configID.String()).Msg("Failed to refresh PACS credentials from the secret store")
+				continue
+			}
+			if fingerprint == adapter.secrets {
+				continue
+			}
+			log.Info().
+				Str("tenant_id", adapter.tenantID.String()).
+				Str("config_id", adapter.configID.String()).
+				Msg("PACS credentials rotated in the secret store; recreating the adapter")
+			f.remove(adapter.configID)
+		}
+	}
+}
+
+// CloseAll stops the eviction of idle adapters and closes all adapters
 func (f *AdapterFactory) CloseAll() error {
+	f.stopped.Do(func() { close(f.stop) })
+	f.wg.Wait()
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -135,23 +453,23 @@ func (f *AdapterFactory) CloseAll() error {
 		Int("num_adapters", len(f.adapters)).
 		Msg("Closing all adapters")
 
-	var errors []error
-	for tenantID, adapter := range f.adapters {
-		if err := adapter.Close(); err != nil {
+	var errs []error
+	for configID, adapter := range f.adapters {
+		if err := adapter.retire(); err != nil {
 			log.Error().
 				Err(err).
-				Str("tenant_id", tenantID.String()).
+				Str("config_id", configID.String()).
 				Msg("Failed to close adapter")
-			errors = append(errors, fmt.Errorf("failed to close adapter for tenant %s: %w", tenantID, err))
+			errs = append(errs, fmt.Errorf("failed to close adapter of PACS config %s: %w", configID, err))
 		}
-		delete(f.adapters, tenantID)
+		delete(f.adapters, configID)
 	}
 
-	if len(errors) > 0 {
+	if len(errs) > 0 {
 		log.Warn().
-			Int("num_errors", len(errors)).
+			Int("num_errors", len(errs)).
 			Msg("Encountered errors while closing adapters")
-		return fmt.Errorf("encountered %d errors while closing adapters", len(errors))
+		return fmt.Errorf("encountered %d errors while closing adapters", len(errs))
 	}
 
 	log.Info().Msg("All adapters closed successfully")
@@ -171,6 +489,9 @@ func (f *AdapterFactory) GetStats() AdapterStats {
 	for _, adapter := range f.adapters {
 		adapterType := string(adapter.Type())
 		stats.AdapterTypes[adapterType]++
+		if adapter.inUse() {
+			stats.InUse++
+		}
 	}
 
 	return stats
@@ -180,4 +501,5 @@ func (f *AdapterFactory) GetStats() AdapterStats {
 type AdapterStats struct {
 	TotalAdapters int            `json:"total_adapters"`
 	AdapterTypes  map[string]int `json:"adapter_types"` // e.g., {"dicomweb": 5, "dimse": 3}
+	InUse         int            `json:"in_use"`        // adapters with calls in progress
 }
@@ -0,0 +1,258 @@
+package adapters
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+)
+
+const (
+	googleHealthcareBaseURL = "https://healthcare.googleapis.com/v1"
+	googleHealthcareScope   = "https://www.googleapis.com/auth/cloud-healthcare"
+	googleTokenURL          = "https://oauth2.googleapis.com/token"
+	googleJWTBearerGrant    = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	googleAssertionLifetime = time.Hour
+)
+
+// GoogleHealthcareAdapter implements PACSAdapter for a Google Cloud Healthcare
+// API DICOM store. The store speaks DICOMweb under its resource path and is
+// authenticated with a service account.
+type GoogleHealthcareAdapter struct {
+	*DICOMWebAdapter
+}
+
+// NewGoogleHealthcareAdapter creates an adapter for the DICOM store named by the
+// config's GCP project, location, dataset and store
+func NewGoogleHealthcareAdapter(config models.PACSConfig) (*GoogleHealthcareAdapter, error) {
+	if config.GCPProject == "" || config.GCPLocation == "" || config.GCPDataset == "" || config.GCPDICOMStore == "" {
+		return nil, fmt.Errorf("google-healthcare requires project, location, dataset and DICOM store")
+	}
+	if config.GCPServiceAccountKey == "" {
+		return nil, fmt.Errorf("google-healthcare requires a service account key")
+	}
+
+	config.BaseURL = googleDICOMWebURL(config)
+	config.AuthMode = models.AuthModeNone
+
+	dicomweb, err := NewDICOMWebAdapter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := newServiceAccountTokenSource(dicomweb.client, config.GCPServiceAccountKey, googleHealthcareScope)
+	if err != nil {
+		return nil, err
+	}
+	dicomweb.tokenSource = tokens
+	// The Healthcare API transcodes to Explicit VR Little Endian unless asked for transfer-syntax=*
+	dicomweb.storedTransferSyntax = true
+
+	return &GoogleHealthcareAdapter{DICOMWebAdapter: dicomweb}, nil
+}
+
+// googleDICOMWebURL builds the DICOMweb root of a Healthcare API DICOM store
+func googleDICOMWebURL(config models.PACSConfig) string {
+	return fmt.Sprintf("%s/projects/%s/locations/%s/datasets/%s/dicomStores/%s/dicomWeb",
+		googleHealthcareBaseURL,
+		url.PathEscape(config.GCPProject),
+		url.PathEscape(config.GCPLocation),
+		url.PathEscape(config.GCPDataset),
+		url.PathEscape(config.GCPDICOMStore))
+}
+
+func (g *GoogleHealthcareAdapter) Type() models.PACSType {
+	return models.PACSTypeGoogleHealthcare
+}
+
+func (g *GoogleHealthcareAdapter) Capabilities() []string {
+	return []string{"QIDO-RS", "WADO-RS", "STOW-RS", "DELETE"}
+}
+
+// GetThumbnail uses the store's server-side JPEG rendering so the instance
+// itself never has to be transferred
+func (g *GoogleHealthcareAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	data, _, err := g.GetRendered(ctx, studyUID, seriesUID, instanceUID, "image/jpeg")
+	if err != nil {
+		return nil, err
+	}
+	defer data.Close()
+
+	rendered, err := io.ReadAll(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rendered instance: %w", err)
+	}
+
+	return thumbnail.Resize(rendered, opts)
+}
+
+// TestConnection tests the DICOM store with a minimal study query
+func (g *GoogleHealthcareAdapter) TestConnection(ctx context.Context) (*models.ConnectionStatus, error) {
+	status, err := g.DICOMWebAdapter.TestConnection(ctx)
+	if status != nil && status.IsConnected {
+		status.Capabilities = g.Capabilities()
+	}
+	return status, err
+}
+
+// serviceAccountKey is the subset of a Google service account JSON key used for signing
+type serviceAccountKey struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// serviceAccountTokenSource obtains and caches access tokens with the OAuth2
+// JWT bearer grant, signing assertions with a service account key
+type serviceAccountTokenSource struct {
+	client   *http.Client
+	email    string
+	keyID    string
+	key      *rsa.PrivateKey
+	tokenURL string
+	scope    string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func newServiceAccountTokenSource(client *http.Client, keyJSON, scope string) (*serviceAccountTokenSource, error) {
+	var sa serviceAccountKey
+	if err := json.Unmarshal([]byte(keyJSON), &sa); err != nil {
+		return nil, fmt.Errorf("invalid service account key: %w", err)
+	}
+	if sa.Type != "service_account" || sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, fmt.Errorf("invalid service account key: missing client email or private key")
+	}
+
+	block, _ := pem.Decode([]byte(sa.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid service account key: private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid service account private key: not an RSA key")
+	}
+
+	tokenURL := sa.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+
+	return &serviceAccountTokenSource{
+		client:   client,
+		email:    sa.ClientEmail,
+		keyID:    sa.PrivateKeyID,
+		key:      key,
+		tokenURL: tokenURL,
+		scope:    scope,
+	}, nil
+}
+
+// Token returns a cached token, refreshing it when missing or about to expire
+func (t *serviceAccountTokenSource) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Now().Add(tokenExpiryLeeway).Before(t.expiry) {
+		return t.token, nil
+	}
+
+	assertion, err := t.assertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", googleJWTBearerGrant)
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp oauth2TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned no access token")
+	}
+
+	t.token = tokenResp.AccessToken
+	t.expiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	if tokenResp.ExpiresIn <= 0 {
+		t.expiry = time.Now().Add(5 * time.Minute)
+	}
+
+	return t.token, nil
+}
+
+// assertion builds the RS256 signed JWT exchanged for an access token
+func (t *serviceAccountTokenSource) assertion(now time.Time) (string, error) {
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	if t.keyID != "" {
+		header["kid"] = t.keyID
+	}
+	claims := map[string]interface{}{
+		"iss":   t.email,
+		"scope": t.scope,
+		"aud":   t.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(googleAssertionLifetime).Unix(),
+	}
+
+	encodedHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(encodedHeader) + "." + base64.RawURLEncoding.EncodeToString(encodedClaims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token assertion: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
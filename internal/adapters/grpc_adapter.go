@@ -0,0 +1,523 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+	pacspluginv1 "github.com/otcheredev/ris-dicom-connector/pkg/pacsplugin/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// grpcCallTimeout bounds unary calls to the sidecar when the caller set no deadline
+	grpcCallTimeout = 30 * time.Second
+	// grpcCapabilitiesTimeout bounds the lazy capabilities lookup
+	grpcCapabilitiesTimeout = 5 * time.Second
+	// grpcMaxMessageSize allows large query and metadata responses from the sidecar
+	grpcMaxMessageSize = 64 << 20
+	// grpcMaxThumbnailSource bounds instances read to render a thumbnail locally
+	grpcMaxThumbnailSource = 512 << 20
+)
+
+// GRPCAdapter implements PACSAdapter by proxying every call to an out-of-process
+// plugin that serves the pacsplugin.v1 PACSAdapter gRPC service, so vendor SDKs
+// can live in a sidecar instead of this binary
+type GRPCAdapter struct {
+	BaseAdapter
+	conn   *grpc.ClientConn
+	client pacspluginv1.PACSAdapterClient
+	apiKey string
+
+	mu           sync.Mutex
+	capabilities []string
+}
+
+// NewGRPCAdapter creates an adapter for the sidecar at the config's endpoint and
+// port. TLS is used when a CA or client certificate is configured; the API key,
+// if any, is sent as a bearer token.
+func NewGRPCAdapter(config models.PACSConfig) (*GRPCAdapter, error) {
+	if config.Endpoint == "" || config.Port == 0 {
+		return nil, fmt.Errorf("grpc requires the sidecar endpoint and port")
+	}
+
+	creds := insecure.NewCredentials()
+	if config.TLSCACert != "" || config.TLSClientCert != "" || config.TLSInsecureSkipVerify {
+		tlsConfig, err := buildTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	target := net.JoinHostPort(config.Endpoint, strconv.Itoa(config.Port))
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(grpcMaxMessageSize)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client for %s: %w", target, err)
+	}
+
+	return &GRPCAdapter{
+		BaseAdapter: BaseAdapter{config: config},
+		conn:        conn,
+		client:      pacspluginv1.NewPACSAdapterClient(conn),
+		apiKey:      config.APIKey,
+	}, nil
+}
+
+func (g *GRPCAdapter) Type() models.PACSType {
+	return models.PACSTypeGRPC
+}
+
+// Capabilities returns the capabilities reported by the sidecar. They are looked
+// up once; until the sidecar answers only "gRPC" is reported.
+func (g *GRPCAdapter) Capabilities() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.capabilities != nil {
+		return g.capabilities
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), grpcCapabilitiesTimeout)
+	defer cancel()
+	resp, err := g.client.Capabilities(g.outgoing(ctx), &pacspluginv1.CapabilitiesRequest{})
+	if err != nil {
+		return []string{"gRPC"}
+	}
+	g.capabilities = resp.GetCapabilities()
+	return g.capabilities
+}
+
+// outgoing attaches the tenant, config and credentials to a call
+func (g *GRPCAdapter) outgoing(ctx context.Context) context.Context {
+	pairs := []string{
+		"x-tenant-id", g.config.TenantID.String(),
+		"x-pacs-config-id", g.config.ID.String(),
+	}
+	if g.apiKey != "" {
+		pairs = append(pairs, "authorization", "Bearer "+g.apiKey)
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// unary prepares the context of a unary call, applying the default timeout
+// when the caller has no deadline
+func (g *GRPCAdapter) unary(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); !ok {
+		ctx, cancel := context.WithTimeout(ctx, grpcCallTimeout)
+		return g.outgoing(ctx), cancel
+	}
+	return g.outgoing(ctx), func() {}
+}
+
+// FindStudies queries the sidecar for studies
+func (g *GRPCAdapter) FindStudies(ctx context.Context, params models.QueryParams) ([]models.Study, error) {
+	ctx, cancel := g.unary(ctx)
+	defer cancel()
+
+	resp, err := g.client.FindStudies(ctx, &pacspluginv1.FindStudiesRequest{
+		PatientId:        params.PatientID,
+		PatientName:      params.PatientName,
+		StudyDate:        params.StudyDate,
+		StudyTime:        params.StudyTime,
+		AccessionNumber:  params.AccessionNumber,
+		Modalities:       params.Modalities,
+		StudyDescription: params.StudyDescription,
+		Filters:          params.Filters,
+		Limit:            int32(params.Limit),
+		Offset:           int32(params.Offset),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	studies := make([]models.Study, 0, len(resp.GetStudies()))
+	for _, s := range resp.GetStudies() {
+		studies = append(studies, models.Study{
+			StudyInstanceUID:   s.GetStudyInstanceUid(),
+			PatientID:          s.GetPatientId(),
+			PatientName:        s.GetPatientName(),
+			PatientBirthDate:   s.GetPatientBirthDate(),
+			PatientSex:         s.GetPatientSex(),
+			StudyDate:          s.GetStudyDate(),
+			StudyTime:          s.GetStudyTime(),
+			StudyDescription:   s.GetStudyDescription(),
+			AccessionNumber:    s.GetAccessionNumber(),
+			ReferringPhysician: s.GetReferringPhysician(),
+			NumberOfSeries:     int(s.GetNumberOfSeries()),
+			NumberOfInstances:  int(s.GetNumberOfInstances()),
+			ModalitiesInStudy:  s.GetModalitiesInStudy(),
+		})
+	}
+	return studies, nil
+}
+
+// FindSeries queries the sidecar for the series of a study
+func (g *GRPCAdapter) FindSeries(ctx context.Context, studyUID string) ([]models.Series, error) {
+	ctx, cancel := g.unary(ctx)
+	defer cancel()
+
+	resp, err := g.client.FindSeries(ctx, &pacspluginv1.FindSeriesRequest{StudyUid: studyUID})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	series := make([]models.Series, 0, len(resp.GetSeries()))
+	for _, s := range resp.GetSeries() {
+		series = append(series, models.Series{
+			SeriesInstanceUID:  s.GetSeriesInstanceUid(),
+			SeriesNumber:       int(s.GetSeriesNumber()),
+			Modality:           s.GetModality(),
+			SeriesDescription:  s.GetSeriesDescription(),
+			SeriesDate:         s.GetSeriesDate(),
+			SeriesTime:         s.GetSeriesTime(),
+			BodyPartExamined:   s.GetBodyPartExamined(),
+			NumberOfInstances:  int(s.GetNumberOfInstances()),
+			ProtocolName:       s.GetProtocolName(),
+			PerformedProcedure: s.GetPerformedProcedure(),
+		})
+	}
+	return series, nil
+}
+
+// FindInstances queries the sidecar for the instances of a series
+func (g *GRPCAdapter) FindInstances(ctx context.Context, studyUID, seriesUID string) ([]models.Instance, error) {
+	ctx, cancel := g.unary(ctx)
+	defer cancel()
+
+	resp, err := g.client.FindInstances(ctx, &pacspluginv1.FindInstancesRequest{StudyUid: studyUID, SeriesUid: seriesUID})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	instances := make([]models.Instance, 0, len(resp.GetInstances()))
+	for _, i := range resp.GetInstances() {
+		instances = append(instances, models.Instance{
+			SOPInstanceUID:            i.GetSopInstanceUid(),
+			SOPClassUID:               i.GetSopClassUid(),
+			InstanceNumber:            int(i.GetInstanceNumber()),
+			TransferSyntaxUID:         i.GetTransferSyntaxUid(),
+			Rows:                      int(i.GetRows()),
+			Columns:                   int(i.GetColumns()),
+			BitsAllocated:             int(i.GetBitsAllocated()),
+			BitsStored:                int(i.GetBitsStored()),
+			HighBit:                   int(i.GetHighBit()),
+			PixelRepresentation:       int(i.GetPixelRepresentation()),
+			PhotometricInterpretation: i.GetPhotometricInterpretation(),
+			SamplesPerPixel:           int(i.GetSamplesPerPixel()),
+			NumberOfFrames:            int(i.GetNumberOfFrames()),
+		})
+	}
+	return instances, nil
+}
+
+// ObjectExists asks the sidecar whether a study, series or instance exists
+func (g *GRPCAdapter) ObjectExists(ctx context.Context, studyUID, seriesUID, instanceUID string) (bool, error) {
+	ctx, cancel := g.unary(ctx)
+	defer cancel()
+
+	resp, err := g.client.ObjectExists(ctx, objectRef(studyUID, seriesUID, instanceUID))
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return false, nil
+		}
+		return false, grpcError(err)
+	}
+	return resp.GetExists(), nil
+}
+
+// GetInstance streams an instance from the sidecar
+func (g *GRPCAdapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
+	ctx, cancel := context.WithCancel(g.outgoing(ctx))
+	stream, err := g.client.GetInstance(ctx, &pacspluginv1.GetInstanceRequest{
+		Object:         objectRef(studyUID, seriesUID, instanceUID),
+		TransferSyntax: opts.TransferSyntax,
+		Range:          opts.Range,
+	})
+	if err != nil {
+		cancel()
+		return nil, "", grpcError(err)
+	}
+	return openChunkStream(stream, cancel)
+}
+
+// GetInstanceMetadata retrieves the metadata of one instance from the sidecar
+func (g *GRPCAdapter) GetInstanceMetadata(ctx context.Context, studyUID, seriesUID, instanceUID string) (*models.Metadata, error) {
+	ctx, cancel := g.unary(ctx)
+	defer cancel()
+
+	resp, err := g.client.GetInstanceMetadata(ctx, objectRef(studyUID, seriesUID, instanceUID))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metadata, err := pluginMetadata(resp)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// GetSeriesMetadata retrieves the metadata of a series' instances from the sidecar
+func (g *GRPCAdapter) GetSeriesMetadata(ctx context.Context, studyUID, seriesUID string) ([]models.Metadata, error) {
+	ctx, cancel := g.unary(ctx)
+	defer cancel()
+
+	resp, err := g.client.GetSeriesMetadata(ctx, objectRef(studyUID, seriesUID, ""))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return pluginMetadataList(resp)
+}
+
+// GetStudyMetadata retrieves the metadata of a study's instances from the sidecar
+func (g *GRPCAdapter) GetStudyMetadata(ctx context.Context, studyUID string) ([]models.Metadata, error) {
+	ctx, cancel := g.unary(ctx)
+	defer cancel()
+
+	resp, err := g.client.GetStudyMetadata(ctx, objectRef(studyUID, "", ""))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return pluginMetadataList(resp)
+}
+
+// GetFrame streams a single frame from the sidecar
+func (g *GRPCAdapter) GetFrame(ctx context.Context, studyUID, seriesUID, instanceUID string, frame int) (io.ReadCloser, string, error) {
+	ctx, cancel := context.WithCancel(g.outgoing(ctx))
+	stream, err := g.client.GetFrame(ctx, &pacspluginv1.GetFrameRequest{
+		Object: objectRef(studyUID, seriesUID, instanceUID),
+		Frame:  int32(frame),
+	})
+	if err != nil {
+		cancel()
+		return nil, "", grpcError(err)
+	}
+	return openChunkStream(stream, cancel)
+}
+
+// GetRendered streams a rendered representation from the sidecar
+func (g *GRPCAdapter) GetRendered(ctx context.Context, studyUID, seriesUID, instanceUID, mediaType string) (io.ReadCloser, string, error) {
+	ctx, cancel := context.WithCancel(g.outgoing(ctx))
+	stream, err := g.client.GetRendered(ctx, &pacspluginv1.GetRenderedRequest{
+		Object:    objectRef(studyUID, seriesUID, instanceUID),
+		MediaType: mediaType,
+	})
+	if err != nil {
+		cancel()
+		return nil, "", grpcError(err)
+	}
+	return openChunkStream(stream, cancel)
+}
+
+// DeleteStudy asks the sidecar to delete a study
+func (g *GRPCAdapter) DeleteStudy(ctx context.Context, studyUID string) error {
+	ctx, cancel := g.unary(ctx)
+	defer cancel()
+
+	if _, err := g.client.DeleteStudy(ctx, objectRef(studyUID, "", "")); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+// GetThumbnail asks the sidecar for a thumbnail, rendering one from the
+// instance when the sidecar does not implement it
+func (g *GRPCAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	callCtx, cancel := g.unary(ctx)
+	resp, err := g.client.GetThumbnail(callCtx, &pacspluginv1.GetThumbnailRequest{
+		Object:  objectRef(studyUID, seriesUID, instanceUID),
+		Size:    int32(opts.Size),
+		Quality: int32(opts.Quality),
+	})
+	cancel()
+	if err == nil {
+		return resp.GetJpeg(), nil
+	}
+	if status.Code(err) != codes.Unimplemented {
+		return nil, grpcError(err)
+	}
+
+	body, _, err := g.GetInstance(ctx, studyUID, seriesUID, instanceUID, models.RetrieveOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, grpcMaxThumbnailSource+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance: %w", err)
+	}
+	if len(data) > grpcMaxThumbnailSource {
+		return nil, fmt.Errorf("%w: instance exceeds %d bytes", ErrTooLarge, grpcMaxThumbnailSource)
+	}
+	return thumbnail.Generate(data, opts)
+}
+
+// TestConnection asks the sidecar to check its own connection to the archive
+func (g *GRPCAdapter) TestConnection(ctx context.Context) (*models.ConnectionStatus, error) {
+	start := time.Now()
+	connStatus := &models.ConnectionStatus{LastChecked: start}
+
+	callCtx, cancel := g.unary(ctx)
+	defer cancel()
+
+	resp, err := g.client.TestConnection(callCtx, &pacspluginv1.TestConnectionRequest{})
+	connStatus.ResponseTime = time.Since(start).Milliseconds()
+	if err != nil {
+		connStatus.ErrorMessage = err.Error()
+		return connStatus, grpcError(err)
+	}
+
+	connStatus.IsConnected = resp.GetIsConnected()
+	connStatus.ErrorMessage = resp.GetErrorMessage()
+	connStatus.Capabilities = resp.GetCapabilities()
+	if len(connStatus.Capabilities) > 0 {
+		g.mu.Lock()
+		g.capabilities = connStatus.Capabilities
+		g.mu.Unlock()
+	}
+	if !connStatus.IsConnected {
+		return connStatus, fmt.Errorf("sidecar reported connection failure: %s", connStatus.ErrorMessage)
+	}
+	return connStatus, nil
+}
+
+// Close closes the connection to the sidecar
+func (g *GRPCAdapter) Close() error {
+	return g.conn.Close()
+}
+
+func objectRef(studyUID, seriesUID, instanceUID string) *pacspluginv1.ObjectRef {
+	return &pacspluginv1.ObjectRef{StudyUid: studyUID, SeriesUid: seriesUID, InstanceUid: instanceUID}
+}
+
+// pluginMetadata converts sidecar metadata, whose attributes are DICOM JSON
+func pluginMetadata(m *pacspluginv1.Metadata) (models.Metadata, error) {
+	metadata := models.Metadata{
+		SOPInstanceUID:    m.GetSopInstanceUid(),
+		SOPClassUID:       m.GetSopClassUid(),
+		TransferSyntaxUID: m.GetTransferSyntaxUid(),
+	}
+	if len(m.GetDicomJson()) > 0 {
+		if err := json.Unmarshal(m.GetDicomJson(), &metadata.Attributes); err != nil {
+			return metadata, fmt.Errorf("failed to decode sidecar metadata: %w", err)
+		}
+	}
+	return metadata, nil
+}
+
+func pluginMetadataList(list *pacspluginv1.MetadataList) ([]models.Metadata, error) {
+	metadata := make([]models.Metadata, 0, len(list.GetMetadata()))
+	for _, m := range list.GetMetadata() {
+		converted, err := pluginMetadata(m)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, converted)
+	}
+	return metadata, nil
+}
+
+// chunkStream reads the data of a streamed object from the sidecar
+type chunkStream struct {
+	stream  grpc.ServerStreamingClient[pacspluginv1.DataChunk]
+	cancel  context.CancelFunc
+	pending []byte
+	err     error
+}
+
+// openChunkStream waits for the first chunk so errors are returned before any
+// response is written, then wraps the stream as an InstanceBody
+func openChunkStream(stream grpc.ServerStreamingClient[pacspluginv1.DataChunk], cancel context.CancelFunc) (io.ReadCloser, string, error) {
+	first, err := stream.Recv()
+	if err != nil {
+		cancel()
+		if errors.Is(err, io.EOF) {
+			return nil, "", fmt.Errorf("sidecar returned an empty stream")
+		}
+		return nil, "", grpcError(err)
+	}
+
+	contentLength := first.GetContentLength()
+	if contentLength <= 0 {
+		contentLength = -1
+	}
+	body := &models.InstanceBody{
+		ReadCloser:    &chunkStream{stream: stream, cancel: cancel, pending: first.GetData()},
+		ContentLength: contentLength,
+		ContentRange:  first.GetContentRange(),
+	}
+	return body, first.GetContentType(), nil
+}
+
+func (c *chunkStream) Read(p []byte) (int, error) {
+	for len(c.pending) == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+		chunk, err := c.stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				c.err = io.EOF
+			} else {
+				c.err = grpcError(err)
+			}
+			continue
+		}
+		c.pending = chunk.GetData()
+	}
+
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *chunkStream) Close() error {
+	c.cancel()
+	return nil
+}
+
+// grpcError maps a sidecar status onto the adapter errors
+func grpcError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	err = fmt.Errorf("sidecar returned %s: %s", st.Code(), st.Message())
+	switch st.Code() {
+	case codes.NotFound:
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	case codes.Unimplemented:
+		return fmt.Errorf("%w: %v", ErrNotSupported, err)
+	case codes.InvalidArgument:
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	case codes.ResourceExhausted:
+		return fmt.Errorf("%w: %v", ErrTooLarge, err)
+	case codes.OutOfRange:
+		return fmt.Errorf("%w: %v", ErrRangeNotSatisfiable, err)
+	case codes.Unavailable:
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	case codes.DeadlineExceeded:
+		return fmt.Errorf("%w: %v", context.DeadlineExceeded, err)
+	case codes.Canceled:
+		return fmt.Errorf("%w: %v", context.Canceled, err)
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package adapters
+
+import (
+	"fmt"
+	"io"
+	"mime"
+)
+
+const (
+	mediaTypeDICOM            = "application/dicom"
+	mediaTypeMultipartRelated = "multipart/related"
+)
+
+// partReadCloser reads a single multipart part and closes the underlying response body
+type partReadCloser struct {
+	io.Reader
+	body io.Closer
+}
+
+func (p *partReadCloser) Close() error {
+	return p.body.Close()
+}
+
+// firstPart unwraps the first part of a multipart/related body, returning it with
+// its own Content-Type. Single-part bodies are returned unchanged.
+func firstPart(body io.ReadCloser, contentType string, quirks Quirks) (io.ReadCloser, string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != mediaTypeMultipartRelated {
+		return body, contentType, nil
+	}
+
+	part, err := quirks.multipartReader(body, params["boundary"]).NextPart()
+	if err != nil {
+		body.Close()
+		return nil, "", fmt.Errorf("failed to read multipart response: %w", err)
+	}
+
+	partType := part.Header.Get("Content-Type")
+	if partType == "" {
+		partType = params["type"]
+	}
+
+	return &partReadCloser{Reader: part, body: body}, partType, nil
+}
+
+// singlePart normalizes a WADO-RS instance body to a single application/dicom payload.
+// Multipart/related responses are unwrapped to their first part; the returned content
+// type carries the part's transfer-syntax parameter when the PACS reported one.
+func singlePart(body io.ReadCloser, contentType string, quirks Quirks) (io.ReadCloser, string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != mediaTypeMultipartRelated {
+		return body, contentType, nil
+	}
+
+	part, err := quirks.multipartReader(body, params["boundary"]).NextPart()
+	if err != nil {
+		body.Close()
+		return nil, "", fmt.Errorf("failed to read multipart response: %w", err)
+	}
+
+	transferSyntax := params["transfer-syntax"]
+	partType := part.Header.Get("Content-Type")
+	if partMediaType, partParams, err := mime.ParseMediaType(partType); err == nil {
+		if partMediaType != mediaTypeDICOM {
+			body.Close()
+			return nil, "", fmt.Errorf("unexpected multipart content type: %s", partMediaType)
+		}
+		if ts := partParams["transfer-syntax"]; ts != "" {
+			transferSyntax = ts
+		}
+	}
+
+	normalized := mediaTypeDICOM
+	if transferSyntax != "" {
+		normalized = mime.FormatMediaType(mediaTypeDICOM, map[string]string{"transfer-syntax": transferSyntax})
+	}
+
+	return &partReadCloser{Reader: part, body: body}, normalized, nil
+}
@@ -0,0 +1,105 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// tokenExpiryLeeway refreshes tokens slightly before they expire
+const tokenExpiryLeeway = 30 * time.Second
+
+// tokenSource supplies bearer tokens for upstream requests
+type tokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// oauth2TokenSource obtains and caches bearer tokens using the OAuth2
+// client-credentials grant (Azure DICOM service, Keycloak, ...)
+type oauth2TokenSource struct {
+	client       *http.Client
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func newOAuth2TokenSource(client *http.Client, config models.PACSConfig) *oauth2TokenSource {
+	return &oauth2TokenSource{
+		client:       client,
+		tokenURL:     config.OAuthTokenURL,
+		clientID:     config.OAuthClientID,
+		clientSecret: config.OAuthClientSecret,
+		scopes:       config.OAuthScopes,
+	}
+}
+
+// Token returns a cached token, refreshing it when missing or about to expire
+func (t *oauth2TokenSource) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Now().Add(tokenExpiryLeeway).Before(t.expiry) {
+		return t.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if t.scopes != "" {
+		form.Set("scope", t.scopes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(t.clientID), url.QueryEscape(t.clientSecret))
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp oauth2TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned no access token")
+	}
+
+	t.token = tokenResp.AccessToken
+	t.expiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	if tokenResp.ExpiresIn <= 0 {
+		// No lifetime reported; refresh on the next leeway window
+		t.expiry = time.Now().Add(5 * time.Minute)
+	}
+
+	return t.token, nil
+}
@@ -0,0 +1,525 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/dicomquery"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+)
+
+// Orthanc resource levels used by /tools/find and /tools/lookup
+const (
+	orthancLevelStudy    = "Study"
+	orthancLevelSeries   = "Series"
+	orthancLevelInstance = "Instance"
+)
+
+// Attributes requested from /tools/find in addition to Orthanc's main DICOM tags
+var (
+	orthancStudyTags    = []string{"ModalitiesInStudy", "NumberOfStudyRelatedSeries", "NumberOfStudyRelatedInstances"}
+	orthancSeriesTags   = []string{"NumberOfSeriesRelatedInstances", "PerformedProcedureStepDescription"}
+	orthancInstanceTags = []string{
+		"SOPClassUID", "Rows", "Columns", "BitsAllocated", "BitsStored", "HighBit",
+		"PixelRepresentation", "PhotometricInterpretation", "SamplesPerPixel",
+	}
+)
+
+// OrthancAdapter implements PACSAdapter with Orthanc's native REST API, which
+// offers server-side previews and raw frame access that DICOMweb cannot.
+// Metadata and rendered retrieval go through the embedded DICOMweb adapter
+// (Orthanc's DICOMweb plugin), which also provides the HTTP client and auth.
+type OrthancAdapter struct {
+	*DICOMWebAdapter
+	restURL string
+}
+
+// orthancResource is an expanded /tools/find result
+type orthancResource struct {
+	ID                   string            `json:"ID"`
+	MainDicomTags        map[string]string `json:"MainDicomTags"`
+	PatientMainDicomTags map[string]string `json:"PatientMainDicomTags"`
+	RequestedTags        map[string]string `json:"RequestedTags"`
+	Series               []string          `json:"Series"`
+	Instances            []string          `json:"Instances"`
+}
+
+// tag returns an attribute of the resource by keyword
+func (r orthancResource) tag(name string) string {
+	for _, tags := range []map[string]string{r.MainDicomTags, r.PatientMainDicomTags, r.RequestedTags} {
+		if value, ok := tags[name]; ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func (r orthancResource) intTag(name string) int {
+	value := r.tag(name)
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return int(f)
+	}
+	return 0
+}
+
+// NewOrthancAdapter creates a new Orthanc REST adapter
+func NewOrthancAdapter(config models.PACSConfig) (*OrthancAdapter, error) {
+	dicomweb, err := NewDICOMWebAdapter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrthancAdapter{
+		DICOMWebAdapter: dicomweb,
+		restURL:         orthancRESTURL(dicomweb.baseURL),
+	}, nil
+}
+
+// orthancRESTURL derives the Orthanc REST root from its DICOMweb root
+func orthancRESTURL(baseURL string) string {
+	if idx := strings.LastIndex(baseURL, defaultBasePath); idx >= 0 {
+		return baseURL[:idx]
+	}
+	return baseURL
+}
+
+func (o *OrthancAdapter) Type() models.PACSType {
+	return models.PACSTypeOrthanc
+}
+
+func (o *OrthancAdapter) Capabilities() []string {
+	return []string{"QIDO-RS", "WADO-RS", "WADO-URI", "REST", "PREVIEW", "DELETE"}
+}
+
+// FindStudies queries for studies using /tools/find
+func (o *OrthancAdapter) FindStudies(ctx context.Context, params models.QueryParams) ([]models.Study, error) {
+	query := map[string]string{}
+	if params.PatientID != "" {
+		query["PatientID"] = params.PatientID
+	}
+	if params.PatientName != "" {
+		query["PatientName"] = dicomquery.PersonNameQuery(params.PatientName)
+	}
+	if params.StudyDate != "" {
+		query["StudyDate"] = params.StudyDate
+	}
+	if params.StudyTime != "" {
+		query["StudyTime"] = params.StudyTime
+	}
+	if params.AccessionNumber != "" {
+		query["AccessionNumber"] = params.AccessionNumber
+	}
+	if len(params.Modalities) > 0 {
+		query["ModalitiesInStudy"] = strings.Join(params.Modalities, "\\")
+	}
+	if params.StudyDescription != "" {
+		query["StudyDescription"] = params.StudyDescription
+	}
+	for key, value := range params.Filters {
+		if len(key) != 8 {
+			return nil, fmt.Errorf("invalid matching key tag: %s", key)
+		}
+		// Orthanc addresses tags as "gggg,eeee"
+		query[strings.ToLower(key[:4]+","+key[4:])] = value
+	}
+
+	resources, err := o.find(ctx, orthancLevelStudy, query, orthancStudyTags, params.Limit, params.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	studies := make([]models.Study, 0, len(resources))
+	for _, r := range resources {
+		study := models.Study{
+			StudyInstanceUID:   r.tag("StudyInstanceUID"),
+			PatientID:          r.tag("PatientID"),
+			PatientName:        dicomquery.FormatPersonName(r.tag("PatientName")),
+			PatientBirthDate:   r.tag("PatientBirthDate"),
+			PatientSex:         r.tag("PatientSex"),
+			StudyDate:          r.tag("StudyDate"),
+			StudyTime:          r.tag("StudyTime"),
+			StudyDescription:   r.tag("StudyDescription"),
+			AccessionNumber:    r.tag("AccessionNumber"),
+			ReferringPhysician: dicomquery.FormatPersonName(r.tag("ReferringPhysicianName")),
+			NumberOfSeries:     r.intTag("NumberOfStudyRelatedSeries"),
+			NumberOfInstances:  r.intTag("NumberOfStudyRelatedInstances"),
+		}
+		if study.NumberOfSeries == 0 {
+			study.NumberOfSeries = len(r.Series)
+		}
+		if modalities := r.tag("ModalitiesInStudy"); modalities != "" {
+			study.ModalitiesInStudy = strings.Split(modalities, "\\")
+		}
+		studies = append(studies, study)
+	}
+
+	return studies, nil
+}
+
+// FindSeries queries for the series of a study using /tools/find
+func (o *OrthancAdapter) FindSeries(ctx context.Context, studyUID string) ([]models.Series, error) {
+	resources, err := o.find(ctx, orthancLevelSeries, map[string]string{"StudyInstanceUID": studyUID}, orthancSeriesTags, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]models.Series, 0, len(resources))
+	for _, r := range resources {
+		s := models.Series{
+			SeriesInstanceUID:  r.tag("SeriesInstanceUID"),
+			SeriesNumber:       r.intTag("SeriesNumber"),
+			Modality:           r.tag("Modality"),
+			SeriesDescription:  r.tag("SeriesDescription"),
+			SeriesDate:         r.tag("SeriesDate"),
+			SeriesTime:         r.tag("SeriesTime"),
+			BodyPartExamined:   r.tag("BodyPartExamined"),
+			NumberOfInstances:  r.intTag("NumberOfSeriesRelatedInstances"),
+			ProtocolName:       r.tag("ProtocolName"),
+			PerformedProcedure: r.tag("PerformedProcedureStepDescription"),
+		}
+		if s.NumberOfInstances == 0 {
+			s.NumberOfInstances = len(r.Instances)
+		}
+		series = append(series, s)
+	}
+
+	return series, nil
+}
+
+// FindInstances queries for the instances of a series using /tools/find
+func (o *OrthancAdapter) FindInstances(ctx context.Context, studyUID, seriesUID string) ([]models.Instance, error) {
+	query := map[string]string{
+		"StudyInstanceUID":  studyUID,
+		"SeriesInstanceUID": seriesUID,
+	}
+	resources, err := o.find(ctx, orthancLevelInstance, query, orthancInstanceTags, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]models.Instance, 0, len(resources))
+	for _, r := range resources {
+		instances = append(instances, models.Instance{
+			SOPInstanceUID:            r.tag("SOPInstanceUID"),
+			SOPClassUID:               r.tag("SOPClassUID"),
+			InstanceNumber:            r.intTag("InstanceNumber"),
+			Rows:                      r.intTag("Rows"),
+			Columns:                   r.intTag("Columns"),
+			BitsAllocated:             r.intTag("BitsAllocated"),
+			BitsStored:                r.intTag("BitsStored"),
+			HighBit:                   r.intTag("HighBit"),
+			PixelRepresentation:       r.intTag("PixelRepresentation"),
+			PhotometricInterpretation: r.tag("PhotometricInterpretation"),
+			SamplesPerPixel:           r.intTag("SamplesPerPixel"),
+			NumberOfFrames:            r.intTag("NumberOfFrames"),
+		})
+	}
+
+	return instances, nil
+}
+
+// ObjectExists probes for a study, series or instance using /tools/find
+func (o *OrthancAdapter) ObjectExists(ctx context.Context, studyUID, seriesUID, instanceUID string) (bool, error) {
+	level := orthancLevelStudy
+	query := map[string]string{"StudyInstanceUID": studyUID}
+	if seriesUID != "" {
+		level = orthancLevelSeries
+		query["SeriesInstanceUID"] = seriesUID
+	}
+	if instanceUID != "" {
+		level = orthancLevelInstance
+		query["SOPInstanceUID"] = instanceUID
+	}
+
+	resources, err := o.find(ctx, level, query, nil, 1, 0)
+	if err != nil {
+		return false, err
+	}
+	return len(resources) > 0, nil
+}
+
+// GetInstance retrieves the stored DICOM file of an instance
+func (o *OrthancAdapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
+	id, err := o.lookup(ctx, instanceUID, orthancLevelInstance)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Orthanc serves the file as stored; report its transfer syntax so a
+	// different requested syntax can be transcoded
+	contentType := mediaTypeDICOM
+	if opts.TransferSyntax != "" && opts.TransferSyntax != "*" {
+		ts, err := o.instanceTransferSyntax(ctx, id)
+		if err != nil {
+			return nil, "", err
+		}
+		if ts != "" {
+			contentType = mime.FormatMediaType(mediaTypeDICOM, map[string]string{"transfer-syntax": ts})
+		}
+	}
+
+	req, err := o.newRequest(ctx, "GET", fmt.Sprintf("%s/instances/%s/file", o.restURL, id), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if opts.Range != "" {
+		req.Header.Set("Range", opts.Range)
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to execute request: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return &models.InstanceBody{ReadCloser: resp.Body, ContentLength: resp.ContentLength}, contentType, nil
+	case http.StatusPartialContent:
+		return &models.InstanceBody{
+			ReadCloser:    resp.Body,
+			ContentLength: resp.ContentLength,
+			ContentRange:  resp.Header.Get("Content-Range"),
+		}, contentType, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return nil, "", ErrRangeNotSatisfiable
+	default:
+		err := statusError(resp)
+		resp.Body.Close()
+		return nil, "", err
+	}
+}
+
+// instanceTransferSyntax reads the transfer syntax Orthanc recorded for an instance
+func (o *OrthancAdapter) instanceTransferSyntax(ctx context.Context, id string) (string, error) {
+	req, err := o.newRequest(ctx, "GET", fmt.Sprintf("%s/instances/%s/metadata/TransferSyntax", o.restURL, id), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", nil
+	default:
+		return "", statusError(resp)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// GetFrame retrieves the raw pixel data of a frame (1-based) from /instances/{id}/frames
+func (o *OrthancAdapter) GetFrame(ctx context.Context, studyUID, seriesUID, instanceUID string, frame int) (io.ReadCloser, string, error) {
+	id, err := o.lookup(ctx, instanceUID, orthancLevelInstance)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Orthanc numbers frames from zero
+	req, err := o.newRequest(ctx, "GET", fmt.Sprintf("%s/instances/%s/frames/%d/raw", o.restURL, id, frame-1), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to execute request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := statusError(resp)
+		resp.Body.Close()
+		return nil, "", err
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return resp.Body, contentType, nil
+}
+
+// GetThumbnail renders a thumbnail from Orthanc's server-side preview, so the
+// instance itself never has to be transferred
+func (o *OrthancAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	id, err := o.lookup(ctx, instanceUID, orthancLevelInstance)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := o.newRequest(ctx, "GET", fmt.Sprintf("%s/instances/%s/preview", o.restURL, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/jpeg")
+
+	resp, err := o.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	preview, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preview: %w", err)
+	}
+
+	return thumbnail.Resize(preview, opts)
+}
+
+// DeleteStudy resolves the Orthanc identifier of a study and deletes it
+func (o *OrthancAdapter) DeleteStudy(ctx context.Context, studyUID string) error {
+	id, err := o.lookup(ctx, studyUID, orthancLevelStudy)
+	if err != nil {
+		return err
+	}
+
+	return o.delete(ctx, fmt.Sprintf("%s/studies/%s", o.restURL, url.PathEscape(id)))
+}
+
+// TestConnection tests the PACS connection using the /system endpoint
+func (o *OrthancAdapter) TestConnection(ctx context.Context) (*models.ConnectionStatus, error) {
+	start := time.Now()
+	status := &models.ConnectionStatus{
+		LastChecked: start,
+	}
+
+	err := o.ping(ctx)
+	status.ResponseTime = time.Since(start).Milliseconds()
+
+	if err != nil {
+		status.IsConnected = false
+		status.ErrorMessage = err.Error()
+		return status, err
+	}
+
+	status.IsConnected = true
+	status.Capabilities = o.Capabilities()
+	return status, nil
+}
+
+func (o *OrthancAdapter) ping(ctx context.Context) error {
+	req, err := o.newRequest(ctx, "GET", o.restURL+"/system", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	return nil
+}
+
+// find runs a /tools/find query at the given level with expanded results
+func (o *OrthancAdapter) find(ctx context.Context, level string, query map[string]string, requestedTags []string, limit, offset int) ([]orthancResource, error) {
+	request := map[string]interface{}{
+		"Level":         level,
+		"Query":         query,
+		"Expand":        true,
+		"CaseSensitive": false,
+	}
+	if len(requestedTags) > 0 {
+		request["RequestedTags"] = requestedTags
+	}
+	if limit > 0 {
+		request["Limit"] = limit
+	}
+	if offset > 0 {
+		request["Since"] = offset
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	req, err := o.newRequest(ctx, "POST", o.restURL+"/tools/find", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var resources []orthancResource
+	if err := json.NewDecoder(resp.Body).Decode(&resources); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return resources, nil
+}
+
+// lookup resolves a DICOM UID to the Orthanc identifier of the resource at level
+func (o *OrthancAdapter) lookup(ctx context.Context, uid, level string) (string, error) {
+	req, err := o.newRequest(ctx, "POST", o.restURL+"/tools/lookup", strings.NewReader(uid))
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(resp)
+	}
+
+	var matches []struct {
+		ID   string `json:"ID"`
+		Type string `json:"Type"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&matches); err != nil {
+		return "", fmt.Errorf("failed to decode lookup response: %w", err)
+	}
+
+	for _, match := range matches {
+		if match.Type == level {
+			return match.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s %s", ErrNotFound, strings.ToLower(level), uid)
+}
@@ -0,0 +1,149 @@
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"sort"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// Quirks toggles workarounds for known deviations of a PACS product from the
+// DICOM standard. Adapters read them from the config's quirk profile so sites
+// with the same product share one set of workarounds instead of forked code.
+type Quirks struct {
+	// InstanceQueryLevel replaces IMAGE as the Query/Retrieve Level of
+	// instance-level C-FIND requests, for archives that expect INSTANCE
+	InstanceQueryLevel string
+	// SeparatedDates normalizes dates returned as YYYY-MM-DD, YYYY.MM.DD or
+	// YYYY/MM/DD to the DICOM YYYYMMDD format
+	SeparatedDates bool
+	// MissingStudyCounts derives Number of Study Related Series/Instances from a
+	// series query for studies the archive returns without them
+	MissingStudyCounts bool
+	// SniffMultipartBoundary takes the multipart boundary from the first
+	// delimiter line of the body when it does not match the Content-Type header
+	SniffMultipartBoundary bool
+}
+
+// quirkProfiles are the named quirk profiles selectable on a PACS config
+var quirkProfiles = map[string]Quirks{
+	"sectra": {
+		SeparatedDates:         true,
+		SniffMultipartBoundary: true,
+	},
+	"ge-centricity": {
+		SeparatedDates:     true,
+		MissingStudyCounts: true,
+	},
+	"agfa": {
+		InstanceQueryLevel: "INSTANCE",
+		MissingStudyCounts: true,
+	},
+}
+
+// LookupQuirks returns the quirk profile with the given name. The empty name
+// selects no workarounds.
+func LookupQuirks(name string) (Quirks, error) {
+	if name == "" {
+		return Quirks{}, nil
+	}
+	quirks, ok := quirkProfiles[name]
+	if !ok {
+		return Quirks{}, fmt.Errorf("unknown quirk profile %q (known: %v)", name, QuirkProfiles())
+	}
+	return quirks, nil
+}
+
+// QuirkProfiles returns the names of the known quirk profiles
+func QuirkProfiles() []string {
+	names := make([]string, 0, len(quirkProfiles))
+	for name := range quirkProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// instanceLevel returns the Query/Retrieve Level for instance-level C-FIND
+func (q Quirks) instanceLevel() string {
+	if q.InstanceQueryLevel != "" {
+		return q.InstanceQueryLevel
+	}
+	return "IMAGE"
+}
+
+// date normalizes a date value when the archive uses separated dates
+func (q Quirks) date(value string) string {
+	if !q.SeparatedDates || len(value) != 10 {
+		return value
+	}
+	switch value[4] {
+	case '-', '.', '/':
+		if value[7] == value[4] {
+			return value[:4] + value[5:7] + value[8:]
+		}
+	}
+	return value
+}
+
+// normalizeStudies applies date workarounds to study results
+func (q Quirks) normalizeStudies(studies []models.Study) {
+	for i := range studies {
+		studies[i].StudyDate = q.date(studies[i].StudyDate)
+		studies[i].PatientBirthDate = q.date(studies[i].PatientBirthDate)
+	}
+}
+
+// normalizeSeries applies date workarounds to series results
+func (q Quirks) normalizeSeries(series []models.Series) {
+	for i := range series {
+		series[i].SeriesDate = q.date(series[i].SeriesDate)
+	}
+}
+
+// fillStudyCounts derives missing study-related counts from a series query
+// for each study returned without them
+func (q Quirks) fillStudyCounts(ctx context.Context, studies []models.Study, findSeries func(ctx context.Context, studyUID string) ([]models.Series, error)) error {
+	if !q.MissingStudyCounts {
+		return nil
+	}
+	for i := range studies {
+		study := &studies[i]
+		if study.NumberOfSeries > 0 || study.StudyInstanceUID == "" {
+			continue
+		}
+		series, err := findSeries(ctx, study.StudyInstanceUID)
+		if err != nil {
+			return fmt.Errorf("failed to count series of study %s: %w", study.StudyInstanceUID, err)
+		}
+		study.NumberOfSeries = len(series)
+		if study.NumberOfInstances == 0 {
+			for _, s := range series {
+				study.NumberOfInstances += s.NumberOfInstances
+			}
+		}
+	}
+	return nil
+}
+
+// multipartReader returns a reader over a multipart body. With
+// SniffMultipartBoundary the boundary is taken from the body itself when the
+// header's boundary does not start it, as some archives quote or pad it.
+func (q Quirks) multipartReader(body io.Reader, boundary string) *multipart.Reader {
+	if !q.SniffMultipartBoundary {
+		return multipart.NewReader(body, boundary)
+	}
+
+	buffered := bufio.NewReaderSize(body, 4096)
+	head, _ := buffered.Peek(1024)
+	first := bytes.TrimLeft(head, "\r\n \t")
+	if end := bytes.IndexAny(first, "\r\n"); end > 2 && bytes.HasPrefix(first, []byte("--")) {
+		boundary = string(bytes.TrimSpace(first[2:end]))
+	}
+	return multipart.NewReader(buffered, boundary)
+}
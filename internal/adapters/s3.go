@@ -0,0 +1,497 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/dictionary/tags"
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/media"
+	"github.com/otcheredev/ris-dicom-connector/internal/dicomquery"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	// s3MaxObjectSize bounds objects read into memory for metadata, thumbnails and indexing
+	s3MaxObjectSize = 512 << 20
+
+	// s3IndexPageSize is the number of keys listed per page while indexing
+	s3IndexPageSize = 1000
+)
+
+// S3Adapter implements PACSAdapter for an S3-compatible bucket holding one DICOM
+// Part 10 object per instance under study/series/instance prefixes. Queries are
+// answered from the object index table, which Reindex populates from the bucket;
+// retrieval streams the objects with GetObject.
+type S3Adapter struct {
+	BaseAdapter
+	client *s3Client
+	prefix string
+	index  *repository.ObjectIndexRepository
+}
+
+// NewS3Adapter creates a new S3 object store adapter
+func NewS3Adapter(config models.PACSConfig) (*S3Adapter, error) {
+	if config.S3Bucket == "" {
+		return nil, fmt.Errorf("s3 requires a bucket")
+	}
+
+	region := config.S3Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	endpoint, err := s3EndpointURL(config, region)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := buildTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	prefix := strings.Trim(config.S3Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &S3Adapter{
+		BaseAdapter: BaseAdapter{config: config},
+		client: &s3Client{
+			// No overall timeout: instances are streamed and may be large
+			httpClient: &http.Client{Transport: transport},
+			endpoint:   endpoint,
+			bucket:     config.S3Bucket,
+			region:     region,
+			pathStyle:  config.S3PathStyle,
+			accessKey:  config.S3AccessKeyID,
+			secretKey:  config.S3SecretAccessKey,
+		},
+		prefix: prefix,
+		index:  repository.NewObjectIndexRepository(),
+	}, nil
+}
+
+// s3EndpointURL resolves the S3 API endpoint. Without an endpoint the regional
+// AWS endpoint is used; an endpoint without a scheme uses https on port 443.
+func s3EndpointURL(config models.PACSConfig, region string) (*url.URL, error) {
+	endpoint := config.Endpoint
+	switch {
+	case endpoint == "":
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	case strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://"):
+	case config.Port == 0 || config.Port == 443:
+		endpoint = "https://" + endpoint
+	default:
+		endpoint = fmt.Sprintf("http://%s:%d", endpoint, config.Port)
+	}
+
+	parsed, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint: %s", endpoint)
+	}
+	return &url.URL{Scheme: parsed.Scheme, Host: parsed.Host}, nil
+}
+
+func (s *S3Adapter) Type() models.PACSType {
+	return models.PACSTypeS3
+}
+
+func (s *S3Adapter) Capabilities() []string {
+	return []string{"QIDO-RS", "WADO-RS", "DELETE"}
+}
+
+// FindStudies matches studies in the object index
+func (s *S3Adapter) FindStudies(ctx context.Context, params models.QueryParams) ([]models.Study, error) {
+	for tag := range params.Filters {
+		if _, ok := repository.ObjectIndexColumns[strings.ToUpper(tag)]; !ok {
+			return nil, fmt.Errorf("%w: attribute %s is not indexed", ErrInvalidRequest, tag)
+		}
+	}
+	if params.PatientName != "" {
+		params.PatientName = dicomquery.PersonNameQuery(params.PatientName)
+	}
+
+	return s.index.FindStudies(ctx, s.config.ID, params)
+}
+
+// FindSeries lists the indexed series of a study
+func (s *S3Adapter) FindSeries(ctx context.Context, studyUID string) ([]models.Series, error) {
+	return s.index.FindSeries(ctx, s.config.ID, studyUID)
+}
+
+// FindInstances lists the indexed instances of a series
+func (s *S3Adapter) FindInstances(ctx context.Context, studyUID, seriesUID string) ([]models.Instance, error) {
+	entries, err := s.index.FindInstances(ctx, s.config.ID, studyUID, seriesUID)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]models.Instance, 0, len(entries))
+	for _, entry := range entries {
+		instances = append(instances, models.Instance{
+			SOPInstanceUID:            entry.SOPInstanceUID,
+			SOPClassUID:               entry.SOPClassUID,
+			InstanceNumber:            entry.InstanceNumber,
+			TransferSyntaxUID:         entry.TransferSyntaxUID,
+			Rows:                      entry.Rows,
+			Columns:                   entry.Columns,
+			BitsAllocated:             entry.BitsAllocated,
+			BitsStored:                entry.BitsStored,
+			HighBit:                   entry.HighBit,
+			PixelRepresentation:       entry.PixelRepresentation,
+			PhotometricInterpretation: entry.PhotometricInterpretation,
+			SamplesPerPixel:           entry.SamplesPerPixel,
+			NumberOfFrames:            entry.NumberOfFrames,
+		})
+	}
+	return instances, nil
+}
+
+// ObjectExists checks the object index for a study, series or instance
+func (s *S3Adapter) ObjectExists(ctx context.Context, studyUID, seriesUID, instanceUID string) (bool, error) {
+	count, err := s.index.CountObjects(ctx, s.config.ID, studyUID, seriesUID, instanceUID)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetInstance streams an instance from the bucket in its stored transfer syntax
+func (s *S3Adapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
+	entry, err := s.lookup(ctx, studyUID, seriesUID, instanceUID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := s.client.getObject(ctx, entry.ObjectKey, opts.Range)
+	if err != nil {
+		return nil, "", err
+	}
+
+	contentType := "application/dicom"
+	if entry.TransferSyntaxUID != "" {
+		contentType += "; transfer-syntax=" + entry.TransferSyntaxUID
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return &models.InstanceBody{ReadCloser: resp.Body, ContentLength: resp.ContentLength}, contentType, nil
+	case http.StatusPartialContent:
+		return &models.InstanceBody{
+			ReadCloser:    resp.Body,
+			ContentLength: resp.ContentLength,
+			ContentRange:  resp.Header.Get("Content-Range"),
+		}, contentType, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return nil, "", ErrRangeNotSatisfiable
+	default:
+		err := statusError(resp)
+		resp.Body.Close()
+		return nil, "", err
+	}
+}
+
+// GetInstanceMetadata reads an instance and returns its attributes
+func (s *S3Adapter) GetInstanceMetadata(ctx context.Context, studyUID, seriesUID, instanceUID string) (*models.Metadata, error) {
+	entry, err := s.lookup(ctx, studyUID, seriesUID, instanceUID)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.readMetadata(ctx, *entry)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// GetSeriesMetadata reads every instance of a series and returns their attributes
+func (s *S3Adapter) GetSeriesMetadata(ctx context.Context, studyUID, seriesUID string) ([]models.Metadata, error) {
+	entries, err := s.index.FindInstances(ctx, s.config.ID, studyUID, seriesUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.readAllMetadata(ctx, entries)
+}
+
+// GetStudyMetadata reads every instance of a study and returns their attributes
+func (s *S3Adapter) GetStudyMetadata(ctx context.Context, studyUID string) ([]models.Metadata, error) {
+	entries, err := s.index.FindStudyInstances(ctx, s.config.ID, studyUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.readAllMetadata(ctx, entries)
+}
+
+func (s *S3Adapter) readAllMetadata(ctx context.Context, entries []models.ObjectIndexEntry) ([]models.Metadata, error) {
+	if len(entries) == 0 {
+		return nil, ErrNotFound
+	}
+
+	metadata := make([]models.Metadata, 0, len(entries))
+	for _, entry := range entries {
+		m, err := s.readMetadata(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, m)
+	}
+	return metadata, nil
+}
+
+func (s *S3Adapter) readMetadata(ctx context.Context, entry models.ObjectIndexEntry) (models.Metadata, error) {
+	obj, err := s.readObject(ctx, entry.ObjectKey)
+	if err != nil {
+		return models.Metadata{}, err
+	}
+
+	return models.Metadata{
+		SOPInstanceUID:    entry.SOPInstanceUID,
+		SOPClassUID:       entry.SOPClassUID,
+		TransferSyntaxUID: entry.TransferSyntaxUID,
+		Attributes:        dcmObjToAttributes(obj),
+	}, nil
+}
+
+// GetFrame is not supported; frames would require decoding the whole object
+func (s *S3Adapter) GetFrame(ctx context.Context, studyUID, seriesUID, instanceUID string, frame int) (io.ReadCloser, string, error) {
+	return nil, "", ErrNotSupported
+}
+
+// GetRendered is not supported by object storage
+func (s *S3Adapter) GetRendered(ctx context.Context, studyUID, seriesUID, instanceUID, mediaType string) (io.ReadCloser, string, error) {
+	return nil, "", ErrNotSupported
+}
+
+// GetThumbnail reads the instance and renders a JPEG thumbnail from its pixel data
+func (s *S3Adapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	entry, err := s.lookup(ctx, studyUID, seriesUID, instanceUID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.readObjectBytes(ctx, entry.ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	return thumbnail.Generate(data, opts)
+}
+
+// DeleteStudy deletes the objects of a study and removes them from the index
+func (s *S3Adapter) DeleteStudy(ctx context.Context, studyUID string) error {
+	entries, err := s.index.FindStudyInstances(ctx, s.config.ID, studyUID)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%w: study %s", ErrNotFound, studyUID)
+	}
+
+	for _, entry := range entries {
+		if err := s.client.deleteObject(ctx, entry.ObjectKey); err != nil {
+			return fmt.Errorf("failed to delete object %s: %w", entry.ObjectKey, err)
+		}
+	}
+
+	return s.index.DeleteStudy(ctx, s.config.ID, studyUID)
+}
+
+// Reindex lists the bucket under the configured prefix and indexes every object
+// that is new or changed since it was last indexed
+func (s *S3Adapter) Reindex(ctx context.Context) (*models.ReindexResult, error) {
+	etags, err := s.index.ObjectETags(ctx, s.config.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ReindexResult{}
+	token := ""
+	for {
+		page, err := s.client.listObjects(ctx, s.prefix, token, s3IndexPageSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to list bucket: %w", err)
+		}
+
+		for _, object := range page.Contents {
+			if strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+			result.Scanned++
+
+			if etag, ok := etags[object.Key]; ok && etag == object.ETag {
+				result.Skipped++
+				continue
+			}
+
+			if err := s.indexObject(ctx, object); err != nil {
+				if ctx.Err() != nil {
+					return result, ctx.Err()
+				}
+				log.Warn().Err(err).Str("key", object.Key).Msg("Failed to index object")
+				result.Failed++
+				continue
+			}
+			result.Indexed++
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return result, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// indexObject reads an object and records its attributes in the index
+func (s *S3Adapter) indexObject(ctx context.Context, object s3Object) error {
+	obj, err := s.readObject(ctx, object.Key)
+	if err != nil {
+		return err
+	}
+
+	entry := &models.ObjectIndexEntry{
+		PACSConfigID:      s.config.ID,
+		StudyInstanceUID:  obj.GetString(tags.StudyInstanceUID),
+		SeriesInstanceUID: obj.GetString(tags.SeriesInstanceUID),
+		SOPInstanceUID:    obj.GetString(tags.SOPInstanceUID),
+		SOPClassUID:       obj.GetString(tags.SOPClassUID),
+
+		PatientID:          obj.GetString(tags.PatientID),
+		PatientName:        dicomquery.FormatPersonName(obj.GetString(tags.PatientName)),
+		PatientBirthDate:   obj.GetString(tags.PatientBirthDate),
+		PatientSex:         obj.GetString(tags.PatientSex),
+		StudyDate:          obj.GetString(tags.StudyDate),
+		StudyTime:          obj.GetString(tags.StudyTime),
+		StudyDescription:   obj.GetString(tags.StudyDescription),
+		AccessionNumber:    obj.GetString(tags.AccessionNumber),
+		ReferringPhysician: dicomquery.FormatPersonName(obj.GetString(tags.ReferringPhysicianName)),
+
+		Modality:          obj.GetString(tags.Modality),
+		SeriesNumber:      dcmInt(obj, tags.SeriesNumber),
+		SeriesDescription: obj.GetString(tags.SeriesDescription),
+		SeriesDate:        obj.GetString(tags.SeriesDate),
+		SeriesTime:        obj.GetString(tags.SeriesTime),
+		BodyPartExamined:  obj.GetString(tags.BodyPartExamined),
+
+		InstanceNumber:            dcmInt(obj, tags.InstanceNumber),
+		Rows:                      dcmInt(obj, tags.Rows),
+		Columns:                   dcmInt(obj, tags.Columns),
+		BitsAllocated:             dcmInt(obj, tags.BitsAllocated),
+		BitsStored:                dcmInt(obj, tags.BitsStored),
+		HighBit:                   dcmInt(obj, tags.HighBit),
+		PixelRepresentation:       dcmInt(obj, tags.PixelRepresentation),
+		PhotometricInterpretation: obj.GetString(tags.PhotometricInterpretation),
+		SamplesPerPixel:           dcmInt(obj, tags.SamplesPerPixel),
+		NumberOfFrames:            dcmInt(obj, tags.NumberOfFrames),
+
+		ObjectKey:  object.Key,
+		ObjectSize: object.Size,
+		ObjectETag: object.ETag,
+	}
+	if ts := obj.GetTransferSyntax(); ts != nil {
+		entry.TransferSyntaxUID = ts.UID
+	}
+
+	if entry.StudyInstanceUID == "" || entry.SeriesInstanceUID == "" || entry.SOPInstanceUID == "" {
+		return fmt.Errorf("object is missing study, series or SOP instance UID")
+	}
+
+	return s.index.Upsert(ctx, entry)
+}
+
+// lookup returns the index entry of an instance
+func (s *S3Adapter) lookup(ctx context.Context, studyUID, seriesUID, instanceUID string) (*models.ObjectIndexEntry, error) {
+	entry, err := s.index.GetInstance(ctx, s.config.ID, studyUID, seriesUID, instanceUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: instance %s", ErrNotFound, instanceUID)
+	}
+	return entry, err
+}
+
+// readObject reads and parses a DICOM object from the bucket
+func (s *S3Adapter) readObject(ctx context.Context, key string) (media.DcmObj, error) {
+	data, err := s.readObjectBytes(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := media.NewDCMObjFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DICOM object %s: %w", key, err)
+	}
+	return obj, nil
+}
+
+func (s *S3Adapter) readObjectBytes(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.client.getObject(ctx, key, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+	if resp.ContentLength > s3MaxObjectSize {
+		return nil, fmt.Errorf("%w: object %s is %d bytes", ErrTooLarge, key, resp.ContentLength)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s3MaxObjectSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", key, err)
+	}
+	if len(data) > s3MaxObjectSize {
+		return nil, fmt.Errorf("%w: object %s exceeds %d bytes", ErrTooLarge, key, s3MaxObjectSize)
+	}
+	return data, nil
+}
+
+// TestConnection lists a single key of the bucket
+func (s *S3Adapter) TestConnection(ctx context.Context) (*models.ConnectionStatus, error) {
+	start := time.Now()
+	status := &models.ConnectionStatus{
+		LastChecked: start,
+	}
+
+	_, err := s.client.listObjects(ctx, s.prefix, "", 1)
+	status.ResponseTime = time.Since(start).Milliseconds()
+
+	if err != nil {
+		status.IsConnected = false
+		status.ErrorMessage = err.Error()
+		return status, err
+	}
+
+	status.IsConnected = true
+	status.Capabilities = s.Capabilities()
+	return status, nil
+}
+
+// Close closes the adapter
+func (s *S3Adapter) Close() error {
+	s.client.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// dcmInt returns an integer attribute, or 0 when it is absent or malformed
+func dcmInt(obj media.DcmObj, tag *tags.Tag) int {
+	value, err := strconv.Atoi(strings.TrimSpace(obj.GetString(tag)))
+	if err != nil {
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,310 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	s3DefaultRegion   = "us-east-1"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// s3Client is a minimal S3 API client signing requests with AWS Signature Version 4.
+// It only covers the calls the object store adapter needs.
+type s3Client struct {
+	httpClient *http.Client
+	endpoint   *url.URL // scheme and host of the S3 API
+	bucket     string
+	region     string
+	pathStyle  bool
+	accessKey  string
+	secretKey  string
+}
+
+// s3Object is an entry of a ListObjectsV2 page
+type s3Object struct {
+	Key  string `xml:"Key"`
+	Size int64  `xml:"Size"`
+	ETag string `xml:"ETag"`
+}
+
+// s3ListResult is a ListObjectsV2 response page
+type s3ListResult struct {
+	Contents              []s3Object `xml:"Contents"`
+	IsTruncated           bool       `xml:"IsTruncated"`
+	NextContinuationToken string     `xml:"NextContinuationToken"`
+}
+
+// s3InitiateMultipartUploadResult is a CreateMultipartUpload response
+type s3InitiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+// s3CompletedPart is a part listed in a CompleteMultipartUpload request
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// s3CompleteMultipartUpload is a CompleteMultipartUpload request body
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+// objectURL returns the URL of an object key (or the bucket root for an empty key)
+func (c *s3Client) objectURL(key string) *url.URL {
+	u := *c.endpoint
+	path := "/" + key
+	if c.pathStyle {
+		path = "/" + c.bucket + path
+	} else {
+		u.Host = c.bucket + "." + u.Host
+	}
+	u.Path = path
+	u.RawPath = sigV4URIEncode(path, false)
+	return &u
+}
+
+// newRequest creates a request for an object key
+func (c *s3Client) newRequest(ctx context.Context, method, key string, query url.Values) (*http.Request, error) {
+	u := c.objectURL(key)
+	u.RawQuery = sigV4CanonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return req, nil
+}
+
+// do signs and executes a request
+func (c *s3Client) do(req *http.Request) (*http.Response, error) {
+	c.sign(req, time.Now().UTC())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return resp, nil
+}
+
+// getObject streams an object, optionally restricted to a byte range
+func (c *s3Client) getObject(ctx context.Context, key, byteRange string) (*http.Response, error) {
+	req, err := c.newRequest(ctx, "GET", key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+
+	return c.do(req)
+}
+
+// deleteObject removes an object; deleting a missing key is not an error in S3
+func (c *s3Client) deleteObject(ctx context.Context, key string) error {
+	req, err := c.newRequest(ctx, "DELETE", key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	return nil
+}
+
+// listObjects returns one ListObjectsV2 page of up to maxKeys keys under a prefix
+func (c *s3Client) listObjects(ctx context.Context, prefix, continuationToken string, maxKeys int) (*s3ListResult, error) {
+	query := url.Values{}
+	query.Set("list-type", "2")
+	query.Set("max-keys", strconv.Itoa(maxKeys))
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if continuationToken != "" {
+		query.Set("continuation-token", continuationToken)
+	}
+
+	req, err := c.newRequest(ctx, "GET", "", query)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var result s3ListResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode object listing: %w", err)
+	}
+	return &result, nil
+}
+
+// putObject uploads an object of up to 5 GiB in a single request
+func (c *s3Client) putObject(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := c.newRequest(ctx, "PUT", key, nil)
+	if err != nil {
+		return err
+	}
+	setRequestBody(req, body, size)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return destinationStatusError(resp)
+	}
+	return nil
+}
+
+// createMultipartUpload starts a multipart upload and returns its upload ID
+func (c *s3Client) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	req, err := c.newRequest(ctx, "POST", key, url.Values{"uploads": {""}})
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", destinationStatusError(resp)
+	}
+
+	var result s3InitiateMultipartUploadResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode multipart upload: %w", err)
+	}
+	if result.UploadID == "" {
+		return "", fmt.Errorf("S3 returned no multipart upload ID")
+	}
+	return result.UploadID, nil
+}
+
+// uploadPart uploads one part of a multipart upload and returns its ETag
+func (c *s3Client) uploadPart(ctx context.Context, key, uploadID string, partNumber int, body io.Reader, size int64) (string, error) {
+	query := url.Values{}
+	query.Set("partNumber", strconv.Itoa(partNumber))
+	query.Set("uploadId", uploadID)
+
+	req, err := c.newRequest(ctx, "PUT", key, query)
+	if err != nil {
+		return "", err
+	}
+	setRequestBody(req, body, size)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", destinationStatusError(resp)
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// completeMultipartUpload assembles the uploaded parts into the object
+func (c *s3Client) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []s3CompletedPart) error {
+	body, err := xml.Marshal(s3CompleteMultipartUpload{Parts: parts})
+	if err != nil {
+		return fmt.Errorf("failed to encode multipart upload parts: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, "POST", key, url.Values{"uploadId": {uploadID}})
+	if err != nil {
+		return err
+	}
+	setRequestBody(req, bytes.NewReader(body), int64(len(body)))
+	req.Header.Set("Content-Type", "application/xml")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return destinationStatusError(resp)
+	}
+	// S3 reports failures that happen after the response started in a 200 body
+	result, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return fmt.Errorf("failed to read multipart upload result: %w", err)
+	}
+	if bytes.Contains(result, []byte("<Error>")) {
+		return fmt.Errorf("failed to complete multipart upload: %s", string(result))
+	}
+	return nil
+}
+
+// abortMultipartUpload discards the parts of an unfinished multipart upload
+func (c *s3Client) abortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	req, err := c.newRequest(ctx, "DELETE", key, url.Values{"uploadId": {uploadID}})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return destinationStatusError(resp)
+	}
+	return nil
+}
+
+// sign adds AWS Signature Version 4 headers to a request. The payload is left
+// unsigned, which S3 and MinIO accept, so uploads are streamed without hashing
+// them first.
+func (c *s3Client) sign(req *http.Request, now time.Time) {
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+	if c.accessKey == "" {
+		// Anonymous access to a public bucket
+		req.Header.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+		return
+	}
+
+	signer := sigV4Signer{
+		accessKey: c.accessKey,
+		secretKey: c.secretKey,
+		region:    c.region,
+		service:   "s3",
+	}
+	signer.sign(req, s3UnsignedPayload, now)
+}
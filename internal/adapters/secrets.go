@@ -0,0 +1,350 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// ErrInvalidSecretReference is returned for a PACS credential referencing a
+// secret store that is unknown or not configured, or without a secret path
+var ErrInvalidSecretReference = errors.New("invalid secret reference")
+
+// Secret store schemes of credential references
+const (
+	SecretStoreVault             = "vault"
+	SecretStoreAWSSecretsManager = "aws-sm"
+)
+
+// secretFetchTimeout bounds the fetch of a secret
+const secretFetchTimeout = 10 * time.Second
+
+// SecretStore reads secrets from an external secrets manager
+type SecretStore interface {
+	// GetSecret returns the fields of the secret at a path
+	GetSecret(ctx context.Context, path string) (map[string]string, error)
+}
+
+// secretReference is a PACS credential kept in a secret store, written as
+// <scheme>://<path>#<field>
+type secretReference struct {
+	scheme string
+	path   string
+	field  string
+}
+
+// parseSecretReference parses a credential that references a secret store;
+// ok is false for a credential held in the config itself
+func parseSecretReference(value string) (ref secretReference, ok bool) {
+	for _, scheme := range []string{SecretStoreVault, SecretStoreAWSSecretsManager} {
+		rest, found := strings.CutPrefix(value, scheme+"://")
+		if !found {
+			continue
+		}
+		ref = secretReference{scheme: scheme, path: rest}
+		if i := strings.LastIndex(rest, "#"); i >= 0 {
+			ref.path, ref.field = rest[:i], rest[i+1:]
+		}
+		return ref, true
+	}
+	return ref, false
+}
+
+// SecretResolver replaces the PACS credentials that reference a secret store
+// with the secret they reference. Secrets are cached for the refresh interval;
+// the adapter factory fetches them again each interval and recreates the
+// adapters whose secrets were rotated.
+type SecretResolver struct {
+	stores map[string]SecretStore // by scheme
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	secrets map[string]cachedSecret // by scheme and path
+}
+
+// cachedSecret is a secret as last fetched
+type cachedSecret struct {
+	fields  map[string]string
+	fetched time.Time
+}
+
+// NewSecretResolver creates a resolver over the configured secret stores by
+// scheme, caching secrets for ttl
+func NewSecretResolver(stores map[string]SecretStore, ttl time.Duration) *SecretResolver {
+	return &SecretResolver{
+		stores:  stores,
+		ttl:     ttl,
+		secrets: make(map[string]cachedSecret),
+	}
+}
+
+// checkPACSCredentials validates the secret references of a PACS config
+func (r *SecretResolver) checkPACSCredentials(config *models.PACSConfig) error {
+	for field, value := range config.Credentials() {
+		ref, ok := parseSecretReference(*value)
+		if !ok {
+			continue
+		}
+		switch {
+		case r == nil || r.stores[ref.scheme] == nil:
+			return fmt.Errorf("%w: %s references the %s secret store, which is not configured", ErrInvalidSecretReference, field, ref.scheme)
+		case ref.path == "":
+			return fmt.Errorf("%w: %s references no secret path", ErrInvalidSecretReference, field)
+		case ref.field == "" && ref.scheme == SecretStoreVault:
+			return fmt.Errorf("%w: %s references no field of the vault secret; write vault://<mount>/<path>#<field>", ErrInvalidSecretReference, field)
+		}
+	}
+	return nil
+}
+
+// resolvePACSCredentials returns a copy of a PACS config with the credentials
+// that reference a secret store replaced by the secret, and a fingerprint of
+// those secrets, empty when there are none
+func (r *SecretResolver) resolvePACSCredentials(ctx context.Context, config models.PACSConfig) (models.PACSConfig, string, error) {
+	if err := r.checkPACSCredentials(&config); err != nil {
+		return config, "", err
+	}
+
+	credentials := config.Credentials()
+	fields := make([]string, 0, len(credentials))
+	for field, value := range credentials {
+		if _, ok := parseSecretReference(*value); ok {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return config, "", nil
+	}
+	slices.Sort(fields)
+
+	hash := sha256.New()
+	for _, field := range fields {
+		value := credentials[field]
+		ref, _ := parseSecretReference(*value)
+		secret, err := r.getSecret(ctx, ref)
+		if err != nil {
+			return config, "", fmt.Errorf("failed to get %s from the %s secret store: %w", field, ref.scheme, err)
+		}
+		resolved, ok := secret[ref.field]
+		if !ok {
+			return config, "", fmt.Errorf("%w: the secret of %s has no field %q", ErrInvalidSecretReference, field, ref.field)
+		}
+		*value = resolved
+		hash.Write([]byte(field + "=" + resolved + "\n"))
+	}
+	return config, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// getSecret returns the fields of a referenced secret, from the cache unless
+// it was fetched a refresh interval ago
+func (r *SecretResolver) getSecret(ctx context.Context, ref secretReference) (map[string]string, error) {
+	key := ref.scheme + "://" + ref.path
+
+	r.mu.Lock()
+	cached, ok := r.secrets[key]
+	r.mu.Unlock()
+	if ok && time.Since(cached.fetched) < r.ttl {
+		return cached.fields, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, secretFetchTimeout)
+	defer cancel()
+	fields, err := r.stores[ref.scheme].GetSecret(ctx, ref.path)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.secrets[key] = cachedSecret{fields: fields, fetched: time.Now()}
+	r.mu.Unlock()
+	return fields, nil
+}
+
+// VaultConfig configures access to a HashiCorp Vault server
+type VaultConfig struct {
+	Address   string
+	Token     string
+	Namespace string // Vault Enterprise namespace; empty for the root
+}
+
+// VaultSecretStore reads secrets from the KV version 2 secrets engine of a
+// HashiCorp Vault server. A secret path starts with the engine's mount, e.g.
+// secret/pacs/orthanc for the secret pacs/orthanc of the engine mounted at secret.
+type VaultSecretStore struct {
+	httpClient *http.Client
+	config     VaultConfig
+}
+
+// NewVaultSecretStore creates a Vault secret store
+func NewVaultSecretStore(config VaultConfig) (*VaultSecretStore, error) {
+	if config.Address == "" || config.Token == "" {
+		return nil, fmt.Errorf("vault secret store requires an address and a token")
+	}
+	if _, err := url.Parse(config.Address); err != nil {
+		return nil, fmt.Errorf("invalid vault address: %w", err)
+	}
+	config.Address = strings.TrimSuffix(config.Address, "/")
+
+	return &VaultSecretStore{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		config:     config,
+	}, nil
+}
+
+// GetSecret returns the fields of the latest version of a KV secret
+func (s *VaultSecretStore) GetSecret(ctx context.Context, path string) (map[string]string, error) {
+	mount, name, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("%w: vault secret path %q does not start with a mount", ErrInvalidSecretReference, path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", s.config.Address+"/v1/"+mount+"/data/"+name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", s.config.Token)
+	if s.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.config.Namespace)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("vault returned status %d for %s: %s", resp.StatusCode, path, string(msg))
+	}
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode vault secret %s: %w", path, err)
+	}
+
+	fields := make(map[string]string, len(body.Data.Data))
+	for field, value := range body.Data.Data {
+		if s, ok := value.(string); ok {
+			fields[field] = s
+		} else {
+			fields[field] = fmt.Sprint(value)
+		}
+	}
+	return fields, nil
+}
+
+// AWSSecretsManagerConfig configures access to AWS Secrets Manager
+type AWSSecretsManagerConfig struct {
+	Region          string
+	Endpoint        string // Secrets Manager-compatible endpoint; empty for AWS
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// AWSSecretsManagerStore reads secrets from AWS Secrets Manager. A secret path
+// is the secret's name or ARN; a secret string holding a JSON object has its
+// keys as fields, any other secret string is the field named by an empty
+// reference field.
+type AWSSecretsManagerStore struct {
+	httpClient *http.Client
+	endpoint   string
+	signer     *sigV4Signer
+}
+
+// NewAWSSecretsManagerStore creates an AWS Secrets Manager secret store
+func NewAWSSecretsManagerStore(config AWSSecretsManagerConfig) (*AWSSecretsManagerStore, error) {
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("secrets manager store requires an access key ID and secret access key")
+	}
+
+	region := config.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid secrets manager endpoint: %w", err)
+	}
+
+	return &AWSSecretsManagerStore{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		endpoint:   endpoint,
+		signer: &sigV4Signer{
+			accessKey: config.AccessKeyID,
+			secretKey: config.SecretAccessKey,
+			region:    region,
+			service:   "secretsmanager",
+		},
+	}, nil
+}
+
+// GetSecret returns the fields of the current version of a secret
+func (s *AWSSecretsManagerStore) GetSecret(ctx context.Context, path string) (map[string]string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": path})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode secrets manager request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secrets manager request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	hash := sha256.Sum256(body)
+	s.signer.sign(req, hex.EncodeToString(hash[:]), time.Now().UTC())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("secrets manager returned status %d for %s: %s", resp.StatusCode, path, string(msg))
+	}
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, fmt.Errorf("failed to decode secret %s: %w", path, err)
+	}
+	if secret.SecretString == nil {
+		return nil, fmt.Errorf("secret %s has no secret string", path)
+	}
+
+	var object map[string]any
+	if err := json.Unmarshal([]byte(*secret.SecretString), &object); err != nil {
+		return map[string]string{"": *secret.SecretString}, nil
+	}
+	fields := make(map[string]string, len(object))
+	for field, value := range object {
+		if s, ok := value.(string); ok {
+			fields[field] = s
+		} else {
+			fields[field] = fmt.Sprint(value)
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,260 @@
+package adapters
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+)
+
+// WrappedAdapter is implemented by adapters wrapping another one
+type WrappedAdapter interface {
+	Unwrap() PACSAdapter
+}
+
+// Unwrap returns the adapter behind any wrappers, for the optional interfaces
+// a wrapper hides
+func Unwrap(adapter PACSAdapter) PACSAdapter {
+	for {
+		wrapped, ok := adapter.(WrappedAdapter)
+		if !ok {
+			return adapter
+		}
+		adapter = wrapped.Unwrap()
+	}
+}
+
+// Hold takes a reference to a factory adapter for work done through one of its
+// optional interfaces, which bypass the reference counting of its calls; the
+// returned function releases it. Other adapters need no reference.
+func Hold(adapter PACSAdapter) (release func()) {
+	for {
+		if shared, ok := adapter.(*sharedAdapter); ok {
+			shared.acquire()
+			var once sync.Once
+			return func() { once.Do(shared.release) }
+		}
+		wrapped, ok := adapter.(WrappedAdapter)
+		if !ok {
+			return func() {}
+		}
+		adapter = wrapped.Unwrap()
+	}
+}
+
+// sharedAdapter is an adapter cached by the factory. Each call holds a
+// reference to it until it returns, or until the body it returned is closed,
+// so an adapter removed or evicted while in use is closed only when its last
+// call is done. Its users do not close it; the factory does.
+type sharedAdapter struct {
+	PACSAdapter
+	configID uuid.UUID
+	tenantID uuid.UUID
+
+	// Configs the credentials come from, and the fingerprint of the secrets
+	// they reference when the adapter was created; empty when they reference none
+	sources []models.PACSConfig
+	secrets string
+
+	mu       sync.Mutex
+	refs     int
+	lastUsed time.Time
+	retired  bool // removed from the factory
+	closed   bool
+	done     chan struct{} // closed with the adapter
+}
+
+func newSharedAdapter(adapter PACSAdapter, config models.PACSConfig) *sharedAdapter {
+	return &sharedAdapter{
+		PACSAdapter: adapter,
+		configID:    config.ID,
+		tenantID:    config.TenantID,
+		lastUsed:    time.Now(),
+		done:        make(chan struct{}),
+	}
+}
+
+func (a *sharedAdapter) Unwrap() PACSAdapter {
+	return a.PACSAdapter
+}
+
+// Close does nothing: the adapter is shared, and closed by the factory once it
+// is removed and no longer in use
+func (a *sharedAdapter) Close() error {
+	return nil
+}
+
+// touch marks the adapter used
+func (a *sharedAdapter) touch() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.lastUsed = time.Now()
+}
+
+// acquire takes a reference for a call
+func (a *sharedAdapter) acquire() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.refs++
+	a.lastUsed = time.Now()
+}
+
+// release drops the reference of a finished call, closing a retired adapter
+// when it was the last one
+func (a *sharedAdapter) release() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.refs--
+	a.lastUsed = time.Now()
+	if a.retired && a.refs == 0 {
+		a.closeLocked()
+	}
+}
+
+// retire marks the adapter removed from the factory and closes it unless calls
+// still use it
+func (a *sharedAdapter) retire() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.retired = true
+	if a.refs > 0 {
+		return nil
+	}
+	return a.closeLocked()
+}
+
+func (a *sharedAdapter) closeLocked() error {
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+	close(a.done)
+	return a.PACSAdapter.Close()
+}
+
+// idleSince reports whether no call has used the adapter since a time
+func (a *sharedAdapter) idleSince(since time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.refs == 0 && a.lastUsed.Before(since)
+}
+
+// inUse reports whether calls are using the adapter
+func (a *sharedAdapter) inUse() bool {
+	return a.calls() > 0
+}
+
+// calls returns the number of calls using the adapter
+func (a *sharedAdapter) calls() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.refs
+}
+
+// heldBody releases its adapter reference when the body is closed
+type heldBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *heldBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// holdBody keeps the reference of a call returning a body until the body is
+// closed, or releases it at once when the call failed
+func (a *sharedAdapter) holdBody(body io.ReadCloser, contentType string, err error) (io.ReadCloser, string, error) {
+	if err != nil || body == nil {
+		a.release()
+		return body, contentType, err
+	}
+	return &heldBody{ReadCloser: body, release: a.release}, contentType, nil
+}
+
+func (a *sharedAdapter) FindStudies(ctx context.Context, params models.QueryParams) ([]models.Study, error) {
+	a.acquire()
+	defer a.release()
+	return a.PACSAdapter.FindStudies(ctx, params)
+}
+
+func (a *sharedAdapter) FindSeries(ctx context.Context, studyUID string) ([]models.Series, error) {
+	a.acquire()
+	defer a.release()
+	return a.PACSAdapter.FindSeries(ctx, studyUID)
+}
+
+func (a *sharedAdapter) FindInstances(ctx context.Context, studyUID, seriesUID string) ([]models.Instance, error) {
+	a.acquire()
+	defer a.release()
+	return a.PACSAdapter.FindInstances(ctx, studyUID, seriesUID)
+}
+
+func (a *sharedAdapter) ObjectExists(ctx context.Context, studyUID, seriesUID, instanceUID string) (bool, error) {
+	a.acquire()
+	defer a.release()
+	return a.PACSAdapter.ObjectExists(ctx, studyUID, seriesUID, instanceUID)
+}
+
+func (a *sharedAdapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
+	a.acquire()
+	return a.holdBody(a.PACSAdapter.GetInstance(ctx, studyUID, seriesUID, instanceUID, opts))
+}
+
+func (a *sharedAdapter) GetInstanceMetadata(ctx context.Context, studyUID, seriesUID, instanceUID string) (*models.Metadata, error) {
+	a.acquire()
+	defer a.release()
+	return a.PACSAdapter.GetInstanceMetadata(ctx, studyUID, seriesUID, instanceUID)
+}
+
+func (a *sharedAdapter) GetSeriesMetadata(ctx context.Context, studyUID, seriesUID string) ([]models.Metadata, error) {
+	a.acquire()
+	defer a.release()
+	return a.PACSAdapter.GetSeriesMetadata(ctx, studyUID, seriesUID)
+}
+
+func (a *sharedAdapter) GetStudyMetadata(ctx context.Context, studyUID string) ([]models.Metadata, error) {
+	a.acquire()
+	defer a.release()
+	return a.PACSAdapter.GetStudyMetadata(ctx, studyUID)
+}
+
+func (a *sharedAdapter) GetFrame(ctx context.Context, studyUID, seriesUID, instanceUID string, frame int) (io.ReadCloser, string, error) {
+	a.acquire()
+	return a.holdBody(a.PACSAdapter.GetFrame(ctx, studyUID, seriesUID, instanceUID, frame))
+}
+
+func (a *sharedAdapter) GetRendered(ctx context.Context, studyUID, seriesUID, instanceUID, mediaType string) (io.ReadCloser, string, error) {
+	a.acquire()
+	return a.holdBody(a.PACSAdapter.GetRendered(ctx, studyUID, seriesUID, instanceUID, mediaType))
+}
+
+func (a *sharedAdapter) DeleteStudy(ctx context.Context, studyUID string) error {
+	a.acquire()
+	defer a.release()
+	return a.PACSAdapter.DeleteStudy(ctx, studyUID)
+}
+
+func (a *sharedAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	a.acquire()
+	defer a.release()
+	return a.PACSAdapter.GetThumbnail(ctx, studyUID, seriesUID, instanceUID, opts)
+}
+
+func (a *sharedAdapter) TestConnection(ctx context.Context) (*models.ConnectionStatus, error) {
+	a.acquire()
+	defer a.release()
+	return a.PACSAdapter.TestConnection(ctx)
+}
@@ -0,0 +1,47 @@
+package adapters
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// buildTLSConfig creates the TLS configuration for upstream connections from the
+// PACS config: custom CA bundle, optional client certificate and skip-verify for test systems
+func buildTLSConfig(config models.PACSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if config.TLSCACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(config.TLSCACert)) {
+			return nil, fmt.Errorf("failed to parse TLS CA certificate bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.TLSClientCert != "" || config.TLSClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(config.TLSClientCert), []byte(config.TLSClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if config.TLSInsecureSkipVerify {
+		log.Warn().
+			Str("tenant_id", config.TenantID.String()).
+			Str("endpoint", config.Endpoint).
+			Msg("TLS certificate verification disabled for PACS connection")
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,93 @@
+package adapters
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+)
+
+// Bounds of the metadata an adapter keeps to revalidate with the PACS
+const (
+	validatorMaxEntries = 256
+	validatorMaxBytes   = 64 << 20
+)
+
+// validatorCache keeps the last metadata responses the PACS tagged with an
+// ETag or Last-Modified, so they can be fetched again conditionally and an
+// unchanged resource costs a 304 instead of its whole body. The least recently
+// used responses are dropped beyond validatorMaxEntries or validatorMaxBytes.
+type validatorCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element // of *validatedResponse
+	lru     *list.List               // most recently used first
+	size    int64
+}
+
+type validatedResponse struct {
+	url          string
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+func newValidatorCache() *validatorCache {
+	return &validatorCache{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// condition adds the validators of a response kept for requestURL to the
+// request and returns that response, or nil when none is kept
+func (c *validatorCache) condition(requestURL string, req *http.Request) *validatedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[requestURL]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	kept := elem.Value.(*validatedResponse)
+	if kept.etag != "" {
+		req.Header.Set("If-None-Match", kept.etag)
+	}
+	if kept.lastModified != "" {
+		req.Header.Set("If-Modified-Since", kept.lastModified)
+	}
+	return kept
+}
+
+// store keeps a response body when the PACS sent validators for it, and
+// forgets the URL's previous response otherwise
+func (c *validatorCache) store(requestURL string, header http.Header, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[requestURL]; ok {
+		c.remove(elem)
+	}
+
+	etag, lastModified := header.Get("ETag"), header.Get("Last-Modified")
+	if (etag == "" && lastModified == "") || int64(len(body)) > validatorMaxBytes/4 {
+		return
+	}
+
+	c.entries[requestURL] = c.lru.PushFront(&validatedResponse{
+		url:          requestURL,
+		etag:         etag,
+		lastModified: lastModified,
+		body:         body,
+	})
+	c.size += int64(len(body))
+
+	for c.lru.Len() > validatorMaxEntries || c.size > validatorMaxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *validatorCache) remove(elem *list.Element) {
+	kept := c.lru.Remove(elem).(*validatedResponse)
+	delete(c.entries, kept.url)
+	c.size -= int64(len(kept.body))
+}
@@ -0,0 +1,540 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	rad69Action = "urn:ihe:rad:2009:RetrieveImagingDocumentSet"
+
+	soapNamespace = "http://www.w3.org/2003/05/soap-envelope"
+	wsaNamespace  = "http://www.w3.org/2005/08/addressing"
+	xdsiNamespace = "urn:ihe:rad:xdsi-b:2009"
+	xdsbNamespace = "urn:ihe:iti:xds-b:2007"
+
+	registryStatusFailure = "urn:oasis:names:tc:ebxml-regrep:ResponseStatusType:Failure"
+	registryStatusPartial = "urn:ihe:iti:2007:ResponseStatusType:PartialSuccess"
+
+	// xdsMaxEnvelopeSize bounds the SOAP envelope, which may carry base64 documents inline
+	xdsMaxEnvelopeSize = 64 << 20
+	// xdsMaxDocumentSize bounds documents read into memory to render thumbnails
+	xdsMaxDocumentSize = 512 << 20
+
+	// xdsDefaultTransferSyntax is requested when the client accepts any transfer
+	// syntax; every Imaging Document Source supports Explicit VR Little Endian
+	xdsDefaultTransferSyntax = "1.2.840.10008.1.2.1"
+
+	// xdsRootContentID identifies the SOAP envelope part of MTOM requests
+	xdsRootContentID = "root.message@ris-dicom-connector"
+)
+
+// XDSIAdapter implements PACSAdapter for an IHE XDS-I.b Imaging Document Source.
+// Only the RAD-69 Retrieve Imaging Document Set transaction is available: instances
+// are retrieved by UID, as listed in the KOS manifests published to the registry,
+// and queries are not supported.
+type XDSIAdapter struct {
+	BaseAdapter
+	transport          *DICOMWebAdapter // authenticated HTTP client for the RAD-69 endpoint
+	serviceURL         string
+	repositoryUniqueID string
+	homeCommunityID    string
+}
+
+// NewXDSIAdapter creates a new XDS-I.b adapter. The RAD-69 service URL is taken
+// from BaseURL or an endpoint with a scheme.
+func NewXDSIAdapter(config models.PACSConfig) (*XDSIAdapter, error) {
+	if config.BaseURL == "" && !strings.HasPrefix(config.Endpoint, "http://") && !strings.HasPrefix(config.Endpoint, "https://") {
+		return nil, fmt.Errorf("xds-i requires the RAD-69 service URL in base_url")
+	}
+	if config.XDSRepositoryUniqueID == "" {
+		return nil, fmt.Errorf("xds-i requires a repository unique ID")
+	}
+
+	transport, err := NewDICOMWebAdapter(config)
+	if err != nil {
+		return nil, err
+	}
+	// Document sets are streamed; requests are bounded by their context instead
+	transport.client.Timeout = 0
+
+	return &XDSIAdapter{
+		BaseAdapter:        BaseAdapter{config: config},
+		transport:          transport,
+		serviceURL:         transport.baseURL,
+		repositoryUniqueID: config.XDSRepositoryUniqueID,
+		homeCommunityID:    config.XDSHomeCommunityID,
+	}, nil
+}
+
+func (x *XDSIAdapter) Type() models.PACSType {
+	return models.PACSTypeXDSI
+}
+
+func (x *XDSIAdapter) Capabilities() []string {
+	return []string{"RAD-69"}
+}
+
+// FindStudies is not supported; studies are found through the XDS registry
+func (x *XDSIAdapter) FindStudies(ctx context.Context, params models.QueryParams) ([]models.Study, error) {
+	return nil, ErrNotSupported
+}
+
+// FindSeries is not supported; series are listed in the study's KOS manifest
+func (x *XDSIAdapter) FindSeries(ctx context.Context, studyUID string) ([]models.Series, error) {
+	return nil, ErrNotSupported
+}
+
+// FindInstances is not supported; instances are listed in the study's KOS manifest
+func (x *XDSIAdapter) FindInstances(ctx context.Context, studyUID, seriesUID string) ([]models.Instance, error) {
+	return nil, ErrNotSupported
+}
+
+// ObjectExists is not supported by RAD-69
+func (x *XDSIAdapter) ObjectExists(ctx context.Context, studyUID, seriesUID, instanceUID string) (bool, error) {
+	return false, ErrNotSupported
+}
+
+// GetInstance retrieves a single instance with RAD-69, streaming it as it arrives
+func (x *XDSIAdapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
+	req := models.ImagingDocumentSetRequest{
+		StudyUID: studyUID,
+		Series:   []models.ImagingSeriesRequest{{SeriesUID: seriesUID, InstanceUIDs: []string{instanceUID}}},
+	}
+	if opts.TransferSyntax != "" && opts.TransferSyntax != "*" {
+		req.TransferSyntaxes = []string{opts.TransferSyntax}
+	}
+
+	type started struct {
+		mimeType string
+		err      error
+	}
+	reader, writer := io.Pipe()
+	first := make(chan started, 1)
+
+	go func() {
+		found := false
+		err := x.RetrieveImagingDocumentSet(ctx, req, func(doc models.ImagingDocument, data io.Reader) error {
+			if found || doc.InstanceUID != instanceUID {
+				return nil
+			}
+			found = true
+			first <- started{mimeType: doc.MimeType}
+			_, err := io.Copy(writer, data)
+			return err
+		})
+		if !found {
+			if err == nil {
+				err = fmt.Errorf("%w: instance %s", ErrNotFound, instanceUID)
+			}
+			first <- started{err: err}
+		}
+		writer.CloseWithError(err)
+	}()
+
+	result := <-first
+	if result.err != nil {
+		return nil, "", result.err
+	}
+
+	contentType := result.mimeType
+	if contentType == "" {
+		contentType = "application/dicom"
+	}
+	return reader, contentType, nil
+}
+
+// GetInstanceMetadata is not supported by RAD-69
+func (x *XDSIAdapter) GetInstanceMetadata(ctx context.Context, studyUID, seriesUID, instanceUID string) (*models.Metadata, error) {
+	return nil, ErrNotSupported
+}
+
+// GetSeriesMetadata is not supported by RAD-69
+func (x *XDSIAdapter) GetSeriesMetadata(ctx context.Context, studyUID, seriesUID string) ([]models.Metadata, error) {
+	return nil, ErrNotSupported
+}
+
+// GetStudyMetadata is not supported by RAD-69
+func (x *XDSIAdapter) GetStudyMetadata(ctx context.Context, studyUID string) ([]models.Metadata, error) {
+	return nil, ErrNotSupported
+}
+
+// GetFrame is not supported by RAD-69
+func (x *XDSIAdapter) GetFrame(ctx context.Context, studyUID, seriesUID, instanceUID string, frame int) (io.ReadCloser, string, error) {
+	return nil, "", ErrNotSupported
+}
+
+// GetRendered is not supported by RAD-69
+func (x *XDSIAdapter) GetRendered(ctx context.Context, studyUID, seriesUID, instanceUID, mediaType string) (io.ReadCloser, string, error) {
+	return nil, "", ErrNotSupported
+}
+
+// GetThumbnail retrieves the instance and renders a JPEG thumbnail from its pixel data
+func (x *XDSIAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	body, _, err := x.GetInstance(ctx, studyUID, seriesUID, instanceUID, models.RetrieveOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, xdsMaxDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance: %w", err)
+	}
+	if len(data) > xdsMaxDocumentSize {
+		return nil, fmt.Errorf("%w: instance exceeds %d bytes", ErrTooLarge, xdsMaxDocumentSize)
+	}
+	return thumbnail.Generate(data, opts)
+}
+
+// DeleteStudy is not supported by RAD-69
+func (x *XDSIAdapter) DeleteStudy(ctx context.Context, studyUID string) error {
+	return ErrNotSupported
+}
+
+// RetrieveImagingDocumentSet performs a RAD-69 retrieve and hands each returned
+// document to visit, in the order the Imaging Document Source sends them
+func (x *XDSIAdapter) RetrieveImagingDocumentSet(ctx context.Context, req models.ImagingDocumentSetRequest, visit func(doc models.ImagingDocument, data io.Reader) error) error {
+	if req.RepositoryUniqueID == "" {
+		req.RepositoryUniqueID = x.repositoryUniqueID
+	}
+	if req.HomeCommunityID == "" {
+		req.HomeCommunityID = x.homeCommunityID
+	}
+
+	// Documents are returned by UID only, so remember where each was requested
+	requested := make(map[string]models.ImagingDocument)
+	for _, series := range req.Series {
+		for _, instanceUID := range series.InstanceUIDs {
+			requested[instanceUID] = models.ImagingDocument{
+				StudyUID:    req.StudyUID,
+				SeriesUID:   series.SeriesUID,
+				InstanceUID: instanceUID,
+			}
+		}
+	}
+	if req.StudyUID == "" || len(requested) == 0 {
+		return fmt.Errorf("%w: a study UID and at least one instance UID are required", ErrInvalidRequest)
+	}
+
+	body, contentType, err := rad69Request(req, x.serviceURL)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := x.transport.newRequest(ctx, "POST", x.serviceURL, body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.Header.Set("Accept", "multipart/related, application/soap+xml")
+
+	resp, err := x.transport.do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	mediaType, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	isMultipart := strings.HasPrefix(mediaType, "multipart/")
+	if resp.StatusCode >= 400 && !isMultipart && !strings.Contains(mediaType, "xml") {
+		return statusError(resp)
+	}
+
+	var envelope io.Reader = resp.Body
+	var parts *multipart.Reader
+	if isMultipart {
+		parts = x.transport.quirks.multipartReader(resp.Body, params["boundary"])
+		root, err := parts.NextPart()
+		if err != nil {
+			return fmt.Errorf("failed to read RAD-69 response: %w", err)
+		}
+		if start := params["start"]; start != "" && xopContentID(root.Header.Get("Content-ID")) != xopContentID(start) {
+			return fmt.Errorf("RAD-69 response does not start with the SOAP envelope")
+		}
+		envelope = root
+	}
+
+	documents, err := decodeRAD69Response(io.LimitReader(envelope, xdsMaxEnvelopeSize))
+	if err != nil {
+		return err
+	}
+
+	// Inline documents are visited straight away; MTOM attachments as their parts arrive
+	attachments := make(map[string]models.ImagingDocument)
+	for _, document := range documents {
+		doc, ok := requested[document.DocumentUniqueID]
+		if !ok {
+			log.Warn().Str("document_uid", document.DocumentUniqueID).Msg("Ignoring unrequested RAD-69 document")
+			continue
+		}
+		doc.RepositoryUniqueID = document.RepositoryUniqueID
+		doc.HomeCommunityID = document.HomeCommunityID
+		doc.MimeType = document.MimeType
+
+		if document.Document.Include != nil {
+			attachments[xopContentID(document.Document.Include.Href)] = doc
+			continue
+		}
+		data := base64.NewDecoder(base64.StdEncoding, strings.NewReader(strings.TrimSpace(document.Document.Data)))
+		if err := visit(doc, data); err != nil {
+			return err
+		}
+	}
+
+	for len(attachments) > 0 {
+		if parts == nil {
+			return fmt.Errorf("RAD-69 response references attachments but is not multipart")
+		}
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			return fmt.Errorf("RAD-69 response is missing %d attachments", len(attachments))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read RAD-69 attachment: %w", err)
+		}
+
+		cid := xopContentID(part.Header.Get("Content-ID"))
+		doc, ok := attachments[cid]
+		if !ok {
+			continue
+		}
+		delete(attachments, cid)
+
+		var data io.Reader = part
+		if strings.EqualFold(part.Header.Get("Content-Transfer-Encoding"), "base64") {
+			data = base64.NewDecoder(base64.StdEncoding, part)
+		}
+		if err := visit(doc, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rad69Request builds an MTOM-packaged RetrieveImagingDocumentSetRequest and returns
+// it with its Content-Type
+func rad69Request(req models.ImagingDocumentSetRequest, serviceURL string) (io.Reader, string, error) {
+	var envelope strings.Builder
+	envelope.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	envelope.WriteString(`<s:Envelope xmlns:s="` + soapNamespace + `" xmlns:a="` + wsaNamespace + `">`)
+	envelope.WriteString(`<s:Header>`)
+	envelope.WriteString(`<a:Action s:mustUnderstand="1">` + rad69Action + `</a:Action>`)
+	envelope.WriteString(`<a:MessageID>urn:uuid:` + uuid.NewString() + `</a:MessageID>`)
+	envelope.WriteString(`<a:ReplyTo><a:Address>http://www.w3.org/2005/08/addressing/anonymous</a:Address></a:ReplyTo>`)
+	envelope.WriteString(`<a:To s:mustUnderstand="1">` + xmlText(serviceURL) + `</a:To>`)
+	envelope.WriteString(`</s:Header><s:Body>`)
+	envelope.WriteString(`<iherad:RetrieveImagingDocumentSetRequest xmlns:iherad="` + xdsiNamespace + `" xmlns:ihe="` + xdsbNamespace + `">`)
+	envelope.WriteString(`<iherad:StudyRequest studyInstanceUID="` + xmlText(req.StudyUID) + `">`)
+	for _, series := range req.Series {
+		envelope.WriteString(`<iherad:SeriesRequest seriesInstanceUID="` + xmlText(series.SeriesUID) + `">`)
+		for _, instanceUID := range series.InstanceUIDs {
+			envelope.WriteString(`<ihe:DocumentRequest>`)
+			if req.HomeCommunityID != "" {
+				envelope.WriteString(`<ihe:HomeCommunityId>` + xmlText(req.HomeCommunityID) + `</ihe:HomeCommunityId>`)
+			}
+			envelope.WriteString(`<ihe:RepositoryUniqueId>` + xmlText(req.RepositoryUniqueID) + `</ihe:RepositoryUniqueId>`)
+			envelope.WriteString(`<ihe:DocumentUniqueId>` + xmlText(instanceUID) + `</ihe:DocumentUniqueId>`)
+			envelope.WriteString(`</ihe:DocumentRequest>`)
+		}
+		envelope.WriteString(`</iherad:SeriesRequest>`)
+	}
+	envelope.WriteString(`</iherad:StudyRequest>`)
+
+	transferSyntaxes := req.TransferSyntaxes
+	if len(transferSyntaxes) == 0 {
+		transferSyntaxes = []string{xdsDefaultTransferSyntax}
+	}
+	envelope.WriteString(`<iherad:TransferSyntaxUIDList>`)
+	for _, ts := range transferSyntaxes {
+		envelope.WriteString(`<iherad:TransferSyntaxUID>` + xmlText(ts) + `</iherad:TransferSyntaxUID>`)
+	}
+	envelope.WriteString(`</iherad:TransferSyntaxUIDList>`)
+	envelope.WriteString(`</iherad:RetrieveImagingDocumentSetRequest></s:Body></s:Envelope>`)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", `application/xop+xml; charset=UTF-8; type="application/soap+xml"`)
+	header.Set("Content-Transfer-Encoding", "8bit")
+	header.Set("Content-ID", "<"+xdsRootContentID+">")
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build RAD-69 request: %w", err)
+	}
+	part.Write([]byte(envelope.String()))
+	if err := mw.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to build RAD-69 request: %w", err)
+	}
+
+	contentType := mime.FormatMediaType("multipart/related", map[string]string{
+		"type":       "application/xop+xml",
+		"boundary":   mw.Boundary(),
+		"start":      "<" + xdsRootContentID + ">",
+		"start-info": "application/soap+xml",
+		"action":     rad69Action,
+	})
+	return &body, contentType, nil
+}
+
+// rad69Envelope is the part of a RAD-69 response envelope the adapter reads.
+// Elements are matched by local name so namespace prefixes do not matter.
+type rad69Envelope struct {
+	Body struct {
+		Fault *struct {
+			Reason      string `xml:"Reason>Text"`
+			FaultString string `xml:"faultstring"` // SOAP 1.1
+		} `xml:"Fault"`
+		Response *struct {
+			RegistryResponse struct {
+				Status string `xml:"status,attr"`
+				Errors []struct {
+					ErrorCode   string `xml:"errorCode,attr"`
+					CodeContext string `xml:"codeContext,attr"`
+					Severity    string `xml:"severity,attr"`
+				} `xml:"RegistryErrorList>RegistryError"`
+			} `xml:"RegistryResponse"`
+			Documents []rad69Document `xml:"DocumentResponse"`
+		} `xml:"RetrieveDocumentSetResponse"`
+	} `xml:"Body"`
+}
+
+// rad69Document is a DocumentResponse, carrying the document inline in base64
+// or as a reference to an MTOM attachment
+type rad69Document struct {
+	HomeCommunityID    string `xml:"HomeCommunityId"`
+	RepositoryUniqueID string `xml:"RepositoryUniqueId"`
+	DocumentUniqueID   string `xml:"DocumentUniqueId"`
+	MimeType           string `xml:"mimeType"`
+	Document           struct {
+		Include *struct {
+			Href string `xml:"href,attr"`
+		} `xml:"Include"`
+		Data string `xml:",chardata"`
+	} `xml:"Document"`
+}
+
+// decodeRAD69Response parses a response envelope, converting SOAP faults and
+// registry failures into adapter errors
+func decodeRAD69Response(r io.Reader) ([]rad69Document, error) {
+	var envelope rad69Envelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode RAD-69 response: %w", err)
+	}
+
+	if fault := envelope.Body.Fault; fault != nil {
+		reason := fault.Reason
+		if reason == "" {
+			reason = fault.FaultString
+		}
+		return nil, fmt.Errorf("%w: SOAP fault: %s", ErrInvalidRequest, strings.TrimSpace(reason))
+	}
+
+	response := envelope.Body.Response
+	if response == nil {
+		return nil, fmt.Errorf("RAD-69 response has no RetrieveDocumentSetResponse")
+	}
+
+	registry := response.RegistryResponse
+	if registry.Status == registryStatusFailure || (registry.Status == registryStatusPartial && len(registry.Errors) > 0) {
+		// Unknown documents are reported as XDSDocumentUniqueIdError
+		notFound := len(registry.Errors) > 0
+		var messages []string
+		for _, e := range registry.Errors {
+			if e.ErrorCode != "XDSDocumentUniqueIdError" {
+				notFound = false
+			}
+			messages = append(messages, strings.TrimSpace(e.ErrorCode+" "+e.CodeContext))
+		}
+
+		if registry.Status == registryStatusPartial {
+			log.Warn().Strs("errors", messages).Msg("RAD-69 retrieve partially succeeded")
+		} else if notFound {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, strings.Join(messages, "; "))
+		} else {
+			return nil, fmt.Errorf("%w: RAD-69 retrieve failed: %s", ErrInvalidRequest, strings.Join(messages, "; "))
+		}
+	}
+
+	return response.Documents, nil
+}
+
+// xopContentID normalizes a Content-ID header or cid: URL for comparison
+func xopContentID(id string) string {
+	id = strings.TrimSpace(id)
+	if strings.HasPrefix(id, "cid:") {
+		id = strings.TrimPrefix(id, "cid:")
+		if unescaped, err := url.PathUnescape(id); err == nil {
+			id = unescaped
+		}
+	}
+	return strings.Trim(id, "<>")
+}
+
+// xmlText escapes a value for use in XML text or attribute content
+func xmlText(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+// TestConnection fetches the service WSDL to check that the endpoint is reachable
+func (x *XDSIAdapter) TestConnection(ctx context.Context) (*models.ConnectionStatus, error) {
+	start := time.Now()
+	status := &models.ConnectionStatus{
+		LastChecked: start,
+	}
+
+	err := x.fetchWSDL(ctx)
+	status.ResponseTime = time.Since(start).Milliseconds()
+
+	if err != nil {
+		status.IsConnected = false
+		status.ErrorMessage = err.Error()
+		return status, err
+	}
+
+	status.IsConnected = true
+	status.Capabilities = x.Capabilities()
+	return status, nil
+}
+
+func (x *XDSIAdapter) fetchWSDL(ctx context.Context) error {
+	req, err := x.transport.newRequest(ctx, "GET", x.serviceURL+"?wsdl", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := x.transport.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	return nil
+}
+
+// Close closes the adapter
+func (x *XDSIAdapter) Close() error {
+	return x.transport.Close()
+}
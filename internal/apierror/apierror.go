@@ -0,0 +1,58 @@
+// Package apierror writes machine-readable error responses (RFC 9457 problem
+// details) for the DICOMweb and management APIs
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ContentType is the media type of error response bodies
+const ContentType = "application/problem+json"
+
+// Error codes returned in the "code" member of a problem response
+const (
+	CodeInvalidRequest      = "invalid_request"
+	CodeTenantRequired      = "tenant_required"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeNotAcceptable       = "not_acceptable"
+	CodeConflict            = "conflict"
+	CodePayloadTooLarge     = "payload_too_large"
+	CodeRangeNotSatisfiable = "range_not_satisfiable"
+	CodeInternal            = "internal_error"
+	CodeNotSupported        = "not_supported"
+	CodeUpstreamError       = "upstream_error"
+	CodePACSUnavailable     = "pacs_unavailable"
+	CodePACSNotConfigured   = "pacs_not_configured"
+	CodeUpstreamTimeout     = "upstream_timeout"
+	CodeServiceNotReady     = "service_not_ready"
+	CodeRateLimited         = "rate_limited"
+)
+
+// Problem is an RFC 9457 problem details body extended with an error code
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+}
+
+// Write sends a problem details response with the given status, code and detail
+func Write(w http.ResponseWriter, status int, code, detail string) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", ContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	})
+}
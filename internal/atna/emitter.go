@@ -0,0 +1,251 @@
+package atna
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// queueSize bounds the entries waiting to be sent; entries emitted while
+	// the queue is full are dropped and counted
+	queueSize = 10000
+	// dialTimeout and writeTimeout bound connecting to and writing a message
+	// to the Audit Record Repository
+	dialTimeout  = 10 * time.Second
+	writeTimeout = 10 * time.Second
+	// maxRetryDelay caps the wait between attempts to reach the repository
+	maxRetryDelay = time.Minute
+	// syslogPriority is facility authpriv (10) at severity notice (5), as ATNA
+	// (ITI-20) recommends
+	syslogPriority = 10*8 + 5
+	// syslogMsgID marks messages in the DICOM audit message format
+	syslogMsgID = "IHE+DICOM"
+)
+
+// Config configures the Audit Record Repository audit messages are sent to
+type Config struct {
+	Address  string // host:port of the repository's syslog-TLS listener
+	CAFile   string // PEM bundle of the CAs the repository's certificate is verified against; empty for the system roots
+	CertFile string // PEM certificate the connector authenticates with (ATNA node authentication)
+	KeyFile  string // PEM private key of the certificate
+	SourceID string // AuditSourceID of the messages; empty for the hostname
+	SiteID   string // AuditEnterpriseSiteID of the messages
+	AppName  string // names the connector as a participant and in the syslog header
+}
+
+// Emitter sends the audit log entries of queries, retrievals and exports to
+// the Audit Record Repository in the background, so reporting never holds up a
+// request. While the repository cannot be reached, entries wait in a bounded
+// queue.
+type Emitter struct {
+	config    Config
+	tlsConfig *tls.Config
+	hostname  string
+	sourceID  string
+	pid       string
+
+	entries chan models.AuditLog
+	dropped atomic.Int64 // entries dropped since the last warning
+	conn    net.Conn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewEmitter creates an emitter; call Start to run its sender
+func NewEmitter(config Config) (*Emitter, error) {
+	host, _, err := net.SplitHostPort(config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Audit Record Repository address %q: %w", config.Address, err)
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: host}
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ATNA CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ATNA CA file %s holds no PEM certificates", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ATNA client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	sourceID := config.SourceID
+	if sourceID == "" {
+		sourceID = hostname
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Emitter{
+		config:    config,
+		tlsConfig: tlsConfig,
+		hostname:  hostname,
+		sourceID:  sourceID,
+		pid:       strconv.Itoa(os.Getpid()),
+		entries:   make(chan models.AuditLog, queueSize),
+		ctx:       ctx,
+		cancel:    cancel,
+	}, nil
+}
+
+// Start launches the sender
+func (e *Emitter) Start() {
+	e.wg.Add(1)
+	go e.run()
+}
+
+// Stop waits for the sender to exit after sending the entries still queued,
+// giving up on them when the repository cannot be reached
+func (e *Emitter) Stop() {
+	e.cancel()
+	e.wg.Wait()
+}
+
+// Emit queues an audit log entry without blocking. Entries of actions that are
+// not reported are ignored when they are sent.
+func (e *Emitter) Emit(entry models.AuditLog) {
+	select {
+	case e.entries <- entry:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+func (e *Emitter) run() {
+	defer e.wg.Done()
+	defer e.close()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			// Send what was emitted before shutdown while the repository answers
+			for {
+				select {
+				case entry := <-e.entries:
+					if !e.send(&entry) {
+						if lost := len(e.entries); lost > 0 {
+							log.Error().Int("entries", lost).Msg("Audit Record Repository unreachable at shutdown, audit messages were not sent")
+						}
+						return
+					}
+				default:
+					return
+				}
+			}
+		case entry := <-e.entries:
+			e.send(&entry)
+		}
+	}
+}
+
+// send reports an entry to the repository, retrying with a growing delay until
+// it is sent or the emitter stops. It reports whether the entry was sent or
+// needed no message.
+func (e *Emitter) send(entry *models.AuditLog) bool {
+	if dropped := e.dropped.Swap(0); dropped > 0 {
+		log.Error().Int64("dropped", dropped).Msg("ATNA queue full, audit messages were not sent")
+	}
+
+	msg, ok, err := e.message(entry)
+	if err != nil {
+		log.Error().Err(err).Str("action", entry.Action).Msg("Failed to build audit message")
+		return true
+	}
+	if !ok {
+		return true
+	}
+	frame := e.frame(msg, entry.CreatedAt)
+
+	delay := time.Second
+	for attempt := 0; ; attempt++ {
+		err := e.write(frame)
+		if err == nil {
+			return true
+		}
+		e.close()
+		// A connection the repository closed while idle is reopened at once
+		if attempt == 0 {
+			continue
+		}
+		log.Warn().Err(err).Str("address", e.config.Address).Dur("retry_in", delay).Msg("Failed to send audit message to the Audit Record Repository")
+
+		select {
+		case <-e.ctx.Done():
+			log.Error().
+				Str("tenant_id", entry.TenantID.String()).
+				Str("action", entry.Action).
+				Str("resource_uid", entry.ResourceUID).
+				Msg("Audit message not sent")
+			return false
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// frame wraps an audit message in an RFC 5424 syslog message, its UTF-8 body
+// marked with a BOM, framed with its length for syslog over TLS (RFC 5425)
+func (e *Emitter) frame(msg []byte, at time.Time) []byte {
+	header := fmt.Sprintf("<%d>1 %s %s %s %s %s - \ufeff",
+		syslogPriority,
+		at.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		e.hostname,
+		e.config.AppName,
+		e.pid,
+		syslogMsgID)
+	length := len(header) + len(msg)
+	frame := make([]byte, 0, len(strconv.Itoa(length))+1+length)
+	frame = strconv.AppendInt(frame, int64(length), 10)
+	frame = append(frame, ' ')
+	frame = append(frame, header...)
+	return append(frame, msg...)
+}
+
+// write sends a frame, connecting to the repository first when not connected
+func (e *Emitter) write(frame []byte) error {
+	if e.conn == nil {
+		dialer := &net.Dialer{Timeout: dialTimeout}
+		conn, err := tls.DialWithDialer(dialer, "tcp", e.config.Address, e.tlsConfig)
+		if err != nil {
+			return err
+		}
+		e.conn = conn
+	}
+	if err := e.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	_, err := e.conn.Write(frame)
+	return err
+}
+
+// close drops the connection to the repository
+func (e *Emitter) close() {
+	if e.conn != nil {
+		e.conn.Close()
+		e.conn = nil
+	}
+}
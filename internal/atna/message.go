@@ -0,0 +1,231 @@
+// Package atna emits the audit log as IHE ATNA audit messages: DICOM PS3.15
+// Annex A.5 XML sent over syslog-TLS (RFC 5425) to a hospital's central Audit
+// Record Repository, alongside the connector's own audit_logs table.
+package atna
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"strings"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// codedValue is a code of an audit message, such as an event or role ID
+type codedValue struct {
+	Code   string `xml:"csd-code,attr"`
+	System string `xml:"codeSystemName,attr"`
+	Text   string `xml:"originalText,attr"`
+}
+
+// Event IDs of the DICOM audit messages emitted (PS3.15 A.5.3)
+var (
+	eventQuery                = codedValue{"110112", "DCM", "Query"}
+	eventInstancesTransferred = codedValue{"110104", "DCM", "DICOM Instances Transferred"}
+	eventExport               = codedValue{"110106", "DCM", "Export"}
+)
+
+// Codes of the participants and objects of the messages
+var (
+	roleSource           = codedValue{"110153", "DCM", "Source Role ID"}
+	roleDestination      = codedValue{"110152", "DCM", "Destination Role ID"}
+	roleDestinationMedia = codedValue{"110154", "DCM", "Destination Media"}
+	idStudyInstanceUID   = codedValue{"110180", "DCM", "Study Instance UID"}
+	idSOPClassUID        = codedValue{"110181", "DCM", "SOP Class UID"}
+	sourceApplication    = codedValue{"4", "DCM", "Application Server Process"}
+)
+
+// studyRootFind is the SOP class a query is reported as: DICOMweb, FHIR and
+// GraphQL searches all query the PACS's studies
+const studyRootFind = "1.2.840.10008.5.1.4.1.2.2.1"
+
+// Event outcome indicators
+const (
+	outcomeSuccess        = 0
+	outcomeMinorFailure   = 4 // the request was refused, e.g. not found or not authorized
+	outcomeSeriousFailure = 8 // the connector or the PACS failed
+)
+
+type auditMessage struct {
+	XMLName      xml.Name            `xml:"AuditMessage"`
+	Event        eventIdentification `xml:"EventIdentification"`
+	Participants []activeParticipant `xml:"ActiveParticipant"`
+	Source       auditSource         `xml:"AuditSourceIdentification"`
+	Objects      []participantObject `xml:"ParticipantObjectIdentification"`
+}
+
+type eventIdentification struct {
+	ActionCode         string     `xml:"EventActionCode,attr"`
+	DateTime           string     `xml:"EventDateTime,attr"`
+	Outcome            int        `xml:"EventOutcomeIndicator,attr"`
+	EventID            codedValue `xml:"EventID"`
+	OutcomeDescription string     `xml:"EventOutcomeDescription,omitempty"`
+}
+
+type activeParticipant struct {
+	UserID                 string      `xml:"UserID,attr"`
+	AlternativeUserID      string      `xml:"AlternativeUserID,attr,omitempty"`
+	UserIsRequestor        bool        `xml:"UserIsRequestor,attr"`
+	NetworkAccessPointID   string      `xml:"NetworkAccessPointID,attr,omitempty"`
+	NetworkAccessPointType string      `xml:"NetworkAccessPointTypeCode,attr,omitempty"` // 1 machine name, 2 IP address
+	RoleIDCode             *codedValue `xml:"RoleIDCode,omitempty"`
+}
+
+type auditSource struct {
+	EnterpriseSiteID string     `xml:"AuditEnterpriseSiteID,attr,omitempty"`
+	SourceID         string     `xml:"AuditSourceID,attr"`
+	TypeCode         codedValue `xml:"AuditSourceTypeCode"`
+}
+
+type participantObject struct {
+	ID           string         `xml:"ParticipantObjectID,attr"`
+	TypeCode     int            `xml:"ParticipantObjectTypeCode,attr"`     // 2 system object
+	TypeCodeRole int            `xml:"ParticipantObjectTypeCodeRole,attr"` // 3 report
+	IDTypeCode   codedValue     `xml:"ParticipantObjectIDTypeCode"`
+	Query        string         `xml:"ParticipantObjectQuery,omitempty"` // base64
+	Details      []objectDetail `xml:"ParticipantObjectDetail"`
+}
+
+type objectDetail struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"` // base64
+}
+
+// eventOf returns the DICOM audit event an audit log action is reported as
+// and its event action code; ok is false for actions that are not reported
+func eventOf(action string) (event codedValue, actionCode string, ok bool) {
+	switch {
+	case action == "dicomweb.qido", action == "fhir.get", strings.HasPrefix(action, "graphql."):
+		return eventQuery, "E", true
+	case action == "dicomweb.wado", action == "study.research_metadata", action == "study.research_retrieve":
+		return eventInstancesTransferred, "R", true
+	case action == "study.export", action == "study.export.download", action == "study.export.deliver":
+		return eventExport, "R", true
+	}
+	return codedValue{}, "", false
+}
+
+// outcomeOf returns the event outcome indicator of an audit log entry
+func outcomeOf(entry *models.AuditLog) int {
+	switch {
+	case entry.Status != "failure":
+		return outcomeSuccess
+	case entry.StatusCode >= 400 && entry.StatusCode < 500:
+		return outcomeMinorFailure
+	default:
+		return outcomeSeriousFailure
+	}
+}
+
+// message builds the audit message reporting an audit log entry; ok is false
+// for entries whose action is not reported
+func (e *Emitter) message(entry *models.AuditLog) (data []byte, ok bool, err error) {
+	event, actionCode, ok := eventOf(entry.Action)
+	if !ok {
+		return nil, false, nil
+	}
+
+	msg := auditMessage{
+		Event: eventIdentification{
+			ActionCode:         actionCode,
+			DateTime:           entry.CreatedAt.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+			Outcome:            outcomeOf(entry),
+			EventID:            event,
+			OutcomeDescription: entry.ErrorMessage,
+		},
+		Source: auditSource{
+			EnterpriseSiteID: e.config.SiteID,
+			SourceID:         e.sourceID,
+			TypeCode:         sourceApplication,
+		},
+	}
+
+	// The connector serves queries and sends the instances; the user is the
+	// requestor, unless a background job acted without one
+	connector := activeParticipant{
+		UserID:                 e.config.AppName,
+		AlternativeUserID:      e.pid,
+		NetworkAccessPointID:   e.hostname,
+		NetworkAccessPointType: "1",
+	}
+	userID := entry.User
+	if userID == "" {
+		userID = entry.IPAddress
+	}
+	var requestor *activeParticipant
+	if userID != "" {
+		requestor = &activeParticipant{UserID: userID, UserIsRequestor: true}
+		if entry.IPAddress != "" {
+			requestor.NetworkAccessPointID = entry.IPAddress
+			requestor.NetworkAccessPointType = "2"
+		}
+	} else {
+		connector.UserIsRequestor = true
+	}
+	switch event {
+	case eventQuery:
+		connector.RoleIDCode = &roleDestination
+		if requestor != nil {
+			requestor.RoleIDCode = &roleSource
+		}
+	case eventInstancesTransferred:
+		connector.RoleIDCode = &roleSource
+		if requestor != nil {
+			requestor.RoleIDCode = &roleDestination
+		}
+	case eventExport:
+		connector.RoleIDCode = &roleSource
+		if requestor != nil {
+			requestor.RoleIDCode = &roleDestinationMedia
+		}
+	}
+	if requestor != nil {
+		msg.Participants = append(msg.Participants, *requestor)
+	}
+	msg.Participants = append(msg.Participants, connector)
+
+	if event == eventQuery {
+		msg.Objects = append(msg.Objects, participantObject{
+			ID:           studyRootFind,
+			TypeCode:     2,
+			TypeCodeRole: 3,
+			IDTypeCode:   idSOPClassUID,
+			Query:        base64.StdEncoding.EncodeToString([]byte(entry.Query)),
+		})
+	}
+	if study := studyObject(entry); study != nil {
+		msg.Objects = append(msg.Objects, *study)
+	}
+
+	data, err = xml.Marshal(msg)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// studyObject returns the study an audit log entry is about, naming the series
+// or instance when the entry is about one, or nil when it names no study
+func studyObject(entry *models.AuditLog) *participantObject {
+	studyUID := entry.StudyUID
+	if entry.ResourceType == "study" {
+		studyUID = entry.ResourceUID
+	}
+	if studyUID == "" {
+		return nil
+	}
+
+	study := &participantObject{
+		ID:           studyUID,
+		TypeCode:     2,
+		TypeCodeRole: 3,
+		IDTypeCode:   idStudyInstanceUID,
+	}
+	switch entry.ResourceType {
+	case "series":
+		study.Details = append(study.Details, objectDetail{"SeriesInstanceUID", base64.StdEncoding.EncodeToString([]byte(entry.ResourceUID))})
+	case "instance":
+		study.Details = append(study.Details, objectDetail{"SOPInstanceUID", base64.StdEncoding.EncodeToString([]byte(entry.ResourceUID))})
+	}
+	return study
+}
@@ -0,0 +1,51 @@
+// Package audit carries what the audit log records about a request through its
+// context: the user making it, and whether a service already wrote an entry
+// for it that replaces the request's own.
+package audit
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	requestKey
+)
+
+// WithUser returns a context naming the user a request is made for
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// User returns the user a request is made for, or "" when unknown
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(userKey).(string)
+	return user
+}
+
+// Request tracks the audit entries written while a request is served
+type Request struct {
+	recorded atomic.Bool
+}
+
+// WithRequest returns a context tracking the audit entries written for a request
+func WithRequest(ctx context.Context) (context.Context, *Request) {
+	req := &Request{}
+	return context.WithValue(ctx, requestKey, req), req
+}
+
+// MarkRecorded notes that an audit entry was written for the request of ctx.
+// Contexts outside a tracked request are ignored.
+func MarkRecorded(ctx context.Context) {
+	if req, ok := ctx.Value(requestKey).(*Request); ok {
+		req.recorded.Store(true)
+	}
+}
+
+// Recorded reports whether an audit entry was written for the request
+func (r *Request) Recorded() bool {
+	return r.recorded.Load()
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TokenUser returns the user named by a claim of a JWT. With a secret the
+// token must carry a valid HS256 signature; without one the claims are read
+// from any well-formed token, for deployments where a gateway in front of the
+// connector already verified it. The expiry is not checked, since the token
+// only identifies the caller for the audit log.
+func TokenUser(token string, secret []byte, claim string) (string, error) {
+	var claims map[string]any
+	if err := ParseClaims(token, secret, &claims); err != nil {
+		return "", err
+	}
+	user, ok := claims[claim].(string)
+	if !ok || user == "" {
+		return "", fmt.Errorf("token has no %s claim", claim)
+	}
+	return user, nil
+}
+
+// ParseClaims decodes the claims of a JWT into claims. With a secret the token
+// must carry a valid HS256 signature; without one any well-formed token is
+// read. Registered claims such as the expiry are left to the caller.
+func ParseClaims(token string, secret []byte, claims any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("token is not a JWT")
+	}
+
+	if len(secret) > 0 {
+		header, err := decodeSegment(parts[0])
+		if err != nil {
+			return fmt.Errorf("invalid token header: %w", err)
+		}
+		var alg struct {
+			Alg string `json:"alg"`
+		}
+		if err := json.Unmarshal(header, &alg); err != nil {
+			return fmt.Errorf("invalid token header: %w", err)
+		}
+		if alg.Alg != "HS256" {
+			return fmt.Errorf("unsupported token algorithm %q", alg.Alg)
+		}
+
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			return fmt.Errorf("invalid token signature: %w", err)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(parts[0] + "." + parts[1]))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return errors.New("token signature mismatch")
+		}
+	}
+
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return fmt.Errorf("invalid token claims: %w", err)
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return fmt.Errorf("invalid token claims: %w", err)
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url segment of a JWT, with or without padding
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"strconv"
 	"time"
 )
 
@@ -14,13 +15,21 @@ type Cache interface {
 	Clear(ctx context.Context, pattern string) error
 }
 
-// CacheKey generates a cache key
-func CacheKey(tenantID, studyUID, seriesUID, instanceUID, suffix string) string {
+// CacheKey generates a cache key. Keys carry the generation of the tenant's PACS
+// config, so entries cached from a PACS the tenant has since moved away from are
+// not served.
+func CacheKey(tenantID string, generation int64, studyUID, seriesUID, instanceUID, suffix string) string {
+	prefix := TenantPrefix(tenantID, generation)
 	if instanceUID != "" {
-		return tenantID + ":" + studyUID + ":" + seriesUID + ":" + instanceUID + ":" + suffix
+		return prefix + ":" + studyUID + ":" + seriesUID + ":" + instanceUID + ":" + suffix
 	}
 	if seriesUID != "" {
-		return tenantID + ":" + studyUID + ":" + seriesUID + ":" + suffix
+		return prefix + ":" + studyUID + ":" + seriesUID + ":" + suffix
 	}
-	return tenantID + ":" + studyUID + ":" + suffix
+	return prefix + ":" + studyUID + ":" + suffix
+}
+
+// TenantPrefix returns the prefix of the keys of a tenant at a config generation
+func TenantPrefix(tenantID string, generation int64) string {
+	return tenantID + ":g" + strconv.FormatInt(generation, 36)
 }
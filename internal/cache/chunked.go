@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultChunkSize is the chunk size used when none is configured
+const DefaultChunkSize = 4 << 20
+
+// chunkManifestHeader starts the manifest stored in place of a chunked value
+const chunkManifestHeader = "cache-chunks:v1\n"
+
+// chunkManifest describes how a value was split into chunks
+type chunkManifest struct {
+	Size     int64  `json:"size"`
+	Chunks   int    `json:"chunks"`
+	Checksum string `json:"sha256"` // of the whole value, so chunks of concurrent writes are not mixed up
+}
+
+// ChunkedCache splits values larger than its chunk size into chunks stored under
+// <key>:chunk:<n>, with a manifest under the key itself. Large objects can so be
+// kept in stores with value size limits, such as Redis, and spread across the
+// tiers of a TieredCache like any other entry.
+type ChunkedCache struct {
+	Cache
+	chunkSize int
+}
+
+// NewChunkedCache wraps a cache so values above chunkSize bytes are stored in chunks
+func NewChunkedCache(c Cache, chunkSize int) *ChunkedCache {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &ChunkedCache{Cache: c, chunkSize: chunkSize}
+}
+
+// Get retrieves a value from cache, reassembling it from its chunks. A value
+// with a missing or inconsistent chunk is a miss.
+func (c *ChunkedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.Cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, ok := parseChunkManifest(value)
+	if !ok {
+		return value, nil
+	}
+
+	assembled := make([]byte, 0, manifest.Size)
+	for i := 0; i < manifest.Chunks; i++ {
+		chunk, err := c.Cache.Get(ctx, chunkKey(key, i))
+		if err != nil {
+			return nil, err
+		}
+		assembled = append(assembled, chunk...)
+	}
+
+	sum := sha256.Sum256(assembled)
+	if int64(len(assembled)) != manifest.Size || hex.EncodeToString(sum[:]) != manifest.Checksum {
+		log.Warn().Str("key", key).Msg("Discarding cache entry with inconsistent chunks")
+		return nil, ErrCacheMiss
+	}
+	return assembled, nil
+}
+
+// Set stores a value in cache, in chunks when it exceeds the chunk size. The
+// chunks are written before the manifest, so readers never see a manifest
+// without its chunks.
+func (c *ChunkedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if len(value) <= c.chunkSize {
+		return c.Cache.Set(ctx, key, value, ttl)
+	}
+
+	chunks := 0
+	for offset := 0; offset < len(value); offset += c.chunkSize {
+		end := min(offset+c.chunkSize, len(value))
+		if err := c.Cache.Set(ctx, chunkKey(key, chunks), value[offset:end], ttl); err != nil {
+			return fmt.Errorf("failed to store chunk %d: %w", chunks, err)
+		}
+		chunks++
+	}
+
+	sum := sha256.Sum256(value)
+	manifest, err := json.Marshal(chunkManifest{
+		Size:     int64(len(value)),
+		Chunks:   chunks,
+		Checksum: hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode chunk manifest: %w", err)
+	}
+	return c.Cache.Set(ctx, key, append([]byte(chunkManifestHeader), manifest...), ttl)
+}
+
+// Delete removes a value and its chunks from cache
+func (c *ChunkedCache) Delete(ctx context.Context, key string) error {
+	if value, err := c.Cache.Get(ctx, key); err == nil {
+		if manifest, ok := parseChunkManifest(value); ok {
+			for i := 0; i < manifest.Chunks; i++ {
+				if err := c.Cache.Delete(ctx, chunkKey(key, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return c.Cache.Delete(ctx, key)
+}
+
+// Clear removes all keys matching pattern along with their chunks
+func (c *ChunkedCache) Clear(ctx context.Context, pattern string) error {
+	if err := c.Cache.Clear(ctx, pattern); err != nil {
+		return err
+	}
+	return c.Cache.Clear(ctx, pattern+":chunk:*")
+}
+
+// Close closes the wrapped cache if it holds resources
+func (c *ChunkedCache) Close() error {
+	if closer, ok := c.Cache.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func chunkKey(key string, n int) string {
+	return key + ":chunk:" + strconv.Itoa(n)
+}
+
+// parseChunkManifest decodes a manifest, reporting false for plain values
+func parseChunkManifest(value []byte) (chunkManifest, bool) {
+	var manifest chunkManifest
+	body, ok := bytes.CutPrefix(value, []byte(chunkManifestHeader))
+	if !ok {
+		return manifest, false
+	}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return manifest, false
+	}
+	return manifest, true
+}
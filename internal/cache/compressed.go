@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/rs/zerolog/log"
+)
+
+// compressedHeader starts a value stored compressed; the zstd frame follows
+const compressedHeader = "cache-zstd:v1\n"
+
+const (
+	// compressMinSize is the smallest value worth compressing
+	compressMinSize = 1 << 10
+	// maxDecompressedSize bounds what a compressed value may expand to
+	maxDecompressedSize = 1 << 30
+)
+
+// CompressedCache compresses values with zstd before they are stored and
+// decompresses them when read. Values that do not shrink by at least an
+// eighth, such as JPEG or JPEG 2000 encoded instances, are stored as they are,
+// so only metadata, query results and losslessly compressible instances pay
+// for compression. Values stored before compression was enabled are read
+// unchanged.
+type CompressedCache struct {
+	Cache
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+// NewCompressedCache wraps a cache so its values are stored compressed
+func NewCompressedCache(c Cache) (*CompressedCache, error) {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecompressedSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+	}
+	return &CompressedCache{Cache: c, encoder: encoder, decoder: decoder}, nil
+}
+
+// Get retrieves a value from cache, decompressing it. A value that fails to
+// decompress is a miss.
+func (c *CompressedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.Cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	frame, ok := bytes.CutPrefix(value, []byte(compressedHeader))
+	if !ok {
+		return value, nil
+	}
+	decompressed, err := c.decoder.DecodeAll(frame, nil)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed to decompress cache entry")
+		return nil, ErrCacheMiss
+	}
+	return decompressed, nil
+}
+
+// Set stores a value in cache, compressed when that makes it notably smaller
+func (c *CompressedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if len(value) < compressMinSize {
+		return c.Cache.Set(ctx, key, value, ttl)
+	}
+
+	compressed := c.encoder.EncodeAll(value, []byte(compressedHeader))
+	if len(compressed) > len(value)-len(value)/8 {
+		return c.Cache.Set(ctx, key, value, ttl)
+	}
+	return c.Cache.Set(ctx, key, compressed, ttl)
+}
+
+// Close releases the decoder and closes the wrapped cache if it holds resources
+func (c *CompressedCache) Close() error {
+	c.decoder.Close()
+	if closer, ok := c.Cache.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// encryptedHeader starts a value stored encrypted; the wrapped data key, the
+// nonce and the sealed value follow
+const encryptedHeader = "cache-aes:v1\n"
+
+const (
+	// dataKeySize is the length of the AES-256 data keys values are sealed with
+	dataKeySize = 32
+	// dataKeyLifetime is how long one data key seals new values before it is replaced
+	dataKeyLifetime = 24 * time.Hour
+	// keyWrapTimeout bounds a call to wrap or unwrap a data key
+	keyWrapTimeout = 10 * time.Second
+)
+
+// KeyWrapper encrypts the data keys of an EncryptedCache with a master key
+// that never leaves it, such as a locally configured key or a KMS key
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// EncryptedCache seals values with AES-256-GCM before they are stored and
+// opens them when read, so a tier kept on disk, in Redis dumps or in S3 never
+// holds PHI in plaintext. Values are encrypted under a data key that is itself
+// stored wrapped by the master key alongside each value (envelope
+// encryption); the master key is only used when a data key is created or
+// first read, and data keys are replaced daily. A value is bound to its key,
+// so it cannot be moved to another entry. Values that are not encrypted, such
+// as entries cached before encryption was enabled, or that fail to open, are
+// misses.
+type EncryptedCache struct {
+	Cache
+	wrapper KeyWrapper
+
+	mu        sync.Mutex
+	current   *dataKey
+	unwrapped map[string]cipher.AEAD // wrapped data key to its cipher
+}
+
+// dataKey is the data key new values are sealed with
+type dataKey struct {
+	wrapped []byte
+	aead    cipher.AEAD
+	created time.Time
+}
+
+// NewEncryptedCache wraps a cache so its values are stored encrypted
+func NewEncryptedCache(c Cache, wrapper KeyWrapper) *EncryptedCache {
+	return &EncryptedCache{
+		Cache:     c,
+		wrapper:   wrapper,
+		unwrapped: make(map[string]cipher.AEAD),
+	}
+}
+
+// Get retrieves a value from cache, decrypting it
+func (c *EncryptedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.Cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, ok := bytes.CutPrefix(value, []byte(encryptedHeader))
+	if !ok || len(sealed) < 2 {
+		return nil, ErrCacheMiss
+	}
+	wrappedLen := int(binary.BigEndian.Uint16(sealed))
+	sealed = sealed[2:]
+	if len(sealed) < wrappedLen {
+		return nil, ErrCacheMiss
+	}
+	wrapped, sealed := sealed[:wrappedLen], sealed[wrappedLen:]
+
+	aead, err := c.cipherFor(ctx, wrapped)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed to unwrap cache data key")
+		return nil, ErrCacheMiss
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrCacheMiss
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(key))
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed to decrypt cache entry")
+		return nil, ErrCacheMiss
+	}
+	return plaintext, nil
+}
+
+// Set stores a value in cache, encrypted under the current data key
+func (c *EncryptedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	dk, err := c.dataKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, dk.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := make([]byte, 0, len(encryptedHeader)+2+len(dk.wrapped)+len(nonce)+len(value)+dk.aead.Overhead())
+	sealed = append(sealed, encryptedHeader...)
+	sealed = binary.BigEndian.AppendUint16(sealed, uint16(len(dk.wrapped)))
+	sealed = append(sealed, dk.wrapped...)
+	sealed = append(sealed, nonce...)
+	sealed = dk.aead.Seal(sealed, nonce, value, []byte(key))
+	return c.Cache.Set(ctx, key, sealed, ttl)
+}
+
+// Close closes the wrapped cache if it holds resources
+func (c *EncryptedCache) Close() error {
+	if closer, ok := c.Cache.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// dataKey returns the data key new values are sealed with, creating a new one
+// when there is none yet or the current one is due for replacement
+func (c *EncryptedCache) dataKey(ctx context.Context) (*dataKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.current != nil && time.Since(c.current.created) < dataKeyLifetime {
+		return c.current, nil
+	}
+
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, keyWrapTimeout)
+	defer cancel()
+	wrapped, err := c.wrapper.WrapKey(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	if len(wrapped) > 0xFFFF {
+		return nil, fmt.Errorf("wrapped data key is too long: %d bytes", len(wrapped))
+	}
+
+	c.current = &dataKey{wrapped: wrapped, aead: aead, created: time.Now()}
+	c.unwrapped[string(wrapped)] = aead
+	return c.current, nil
+}
+
+// cipherFor returns the cipher of a wrapped data key, unwrapping it with the
+// master key the first time it is seen
+func (c *EncryptedCache) cipherFor(ctx context.Context, wrapped []byte) (cipher.AEAD, error) {
+	c.mu.Lock()
+	aead, ok := c.unwrapped[string(wrapped)]
+	c.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, keyWrapTimeout)
+	defer cancel()
+	key, err := c.wrapper.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	aead, err = newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.unwrapped[string(wrapped)] = aead
+	c.mu.Unlock()
+	return aead, nil
+}
+
+// LocalKeyWrapper wraps data keys with an AES-256 master key held in memory
+type LocalKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+// NewLocalKeyWrapper creates a key wrapper for a 32-byte master key
+func NewLocalKeyWrapper(masterKey []byte) (*LocalKeyWrapper, error) {
+	if len(masterKey) != dataKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", dataKeySize, len(masterKey))
+	}
+	aead, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalKeyWrapper{aead: aead}, nil
+}
+
+// WrapKey encrypts a data key with the master key
+func (w *LocalKeyWrapper) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	nonce := make([]byte, w.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return w.aead.Seal(nonce, nonce, key, nil), nil
+}
+
+// UnwrapKey decrypts a data key wrapped with the master key
+func (w *LocalKeyWrapper) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < w.aead.NonceSize() {
+		return nil, fmt.Errorf("wrapped data key is truncated")
+	}
+	nonce, sealed := wrapped[:w.aead.NonceSize()], wrapped[w.aead.NonceSize():]
+	key, err := w.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return aead, nil
+}
@@ -1,29 +1,49 @@
 package cache
 
 import (
+	"container/list"
 	"context"
-	"strings"
+	"path"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// MemoryCache implements Cache interface using in-memory storage
+// MemoryCache implements Cache interface using in-memory storage. When it
+// holds more than its maximum number of entries or bytes, the least recently
+// used entries are evicted, except those of pinned studies.
 type MemoryCache struct {
-	mu   sync.RWMutex
-	data map[string]*cacheItem
-	done chan struct{}
+	mu         sync.Mutex
+	data       map[string]*list.Element // of *cacheItem
+	lru        *list.List               // most recently used first
+	size       int64                    // bytes of keys and values held
+	maxEntries int                      // 0 for no limit
+	maxBytes   int64                    // 0 for no limit
+	evictions  atomic.Uint64
+	onEvict    func(key string)
+	pins       *Pins
+	done       chan struct{}
 }
 
 type cacheItem struct {
+	key        string
 	value      []byte
 	expiration time.Time
 }
 
-// NewMemoryCache creates a new in-memory cache
-func NewMemoryCache() *MemoryCache {
+func (i *cacheItem) size() int64 {
+	return int64(len(i.key) + len(i.value))
+}
+
+// NewMemoryCache creates a new in-memory cache bounded to maxEntries entries
+// and maxBytes bytes; 0 leaves a bound unlimited
+func NewMemoryCache(maxEntries int, maxBytes int64) *MemoryCache {
 	mc := &MemoryCache{
-		data: make(map[string]*cacheItem),
-		done: make(chan struct{}),
+		data:       make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		done:       make(chan struct{}),
 	}
 
 	// Start cleanup goroutine
@@ -34,30 +54,57 @@ func NewMemoryCache() *MemoryCache {
 
 // Get retrieves a value from cache
 func (m *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	item, exists := m.data[key]
+	elem, exists := m.data[key]
 	if !exists {
 		return nil, ErrCacheMiss
 	}
 
+	item := elem.Value.(*cacheItem)
 	if time.Now().After(item.expiration) {
 		return nil, ErrCacheMiss
 	}
 
+	m.lru.MoveToFront(elem)
 	return item.value, nil
 }
 
-// Set stores a value in cache
+// Set stores a value in cache, evicting the least recently used entries when
+// the cache is full. Values larger than the whole cache are not stored.
 func (m *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.data[key] = &cacheItem{
+	m.removeLocked(key)
+
+	item := &cacheItem{
+		key:        key,
 		value:      value,
 		expiration: time.Now().Add(ttl),
 	}
+	if m.maxBytes > 0 && item.size() > m.maxBytes {
+		return nil
+	}
+
+	m.data[key] = m.lru.PushFront(item)
+	m.size += item.size()
+
+	// When only pinned entries are left the cache stays over its limits
+	victim := m.lru.Back()
+	for victim != nil && ((m.maxEntries > 0 && len(m.data) > m.maxEntries) || (m.maxBytes > 0 && m.size > m.maxBytes)) {
+		oldest := victim.Value.(*cacheItem)
+		victim = victim.Prev()
+		if oldest.key == key || m.pins.Pinned(oldest.key) {
+			continue
+		}
+		m.removeLocked(oldest.key)
+		m.evictions.Add(1)
+		if m.onEvict != nil {
+			m.onEvict(oldest.key)
+		}
+	}
 
 	return nil
 }
@@ -67,21 +114,21 @@ func (m *MemoryCache) Delete(ctx context.Context, key string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	delete(m.data, key)
+	m.removeLocked(key)
 	return nil
 }
 
 // Exists checks if a key exists
 func (m *MemoryCache) Exists(ctx context.Context, key string) (bool, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	item, exists := m.data[key]
+	elem, exists := m.data[key]
 	if !exists {
 		return false, nil
 	}
 
-	if time.Now().After(item.expiration) {
+	if time.Now().After(elem.Value.(*cacheItem).expiration) {
 		return false, nil
 	}
 
@@ -93,16 +140,76 @@ func (m *MemoryCache) Clear(ctx context.Context, pattern string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Simple pattern matching (only supports * wildcard)
 	for key := range m.data {
 		if matchPattern(key, pattern) {
-			delete(m.data, key)
+			m.removeLocked(key)
 		}
 	}
 
 	return nil
 }
 
+// Len returns the number of entries held, including expired ones not yet cleaned up
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.data)
+}
+
+// Size returns the bytes of keys and values held
+func (m *MemoryCache) Size() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.size
+}
+
+// Evictions returns how many entries were evicted to stay within the limits
+func (m *MemoryCache) Evictions() uint64 {
+	return m.evictions.Load()
+}
+
+// OnEvict registers a function called with the key of every evicted entry. It
+// is called with the cache locked and must not use the cache.
+func (m *MemoryCache) OnEvict(fn func(key string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.onEvict = fn
+}
+
+// KeepPinned exempts the entries of the studies in pins from eviction
+func (m *MemoryCache) KeepPinned(pins *Pins) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pins = pins
+}
+
+// TenantSizes returns the bytes held per tenant, the part of the keys before
+// the first colon
+func (m *MemoryCache) TenantSizes() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sizes := make(map[string]int64)
+	for key, elem := range m.data {
+		sizes[keyTenant(key)] += elem.Value.(*cacheItem).size()
+	}
+	return sizes
+}
+
+func (m *MemoryCache) removeLocked(key string) {
+	elem, exists := m.data[key]
+	if !exists {
+		return
+	}
+	m.size -= elem.Value.(*cacheItem).size()
+	m.lru.Remove(elem)
+	delete(m.data, key)
+}
+
 // cleanup periodically removes expired items
 func (m *MemoryCache) cleanup() {
 	ticker := time.NewTicker(1 * time.Minute)
@@ -113,9 +220,9 @@ func (m *MemoryCache) cleanup() {
 		case <-ticker.C:
 			m.mu.Lock()
 			now := time.Now()
-			for key, item := range m.data {
-				if now.After(item.expiration) {
-					delete(m.data, key)
+			for key, elem := range m.data {
+				if now.After(elem.Value.(*cacheItem).expiration) {
+					m.removeLocked(key)
 				}
 			}
 			m.mu.Unlock()
@@ -131,17 +238,10 @@ func (m *MemoryCache) Close() error {
 	return nil
 }
 
-// matchPattern performs simple pattern matching
+// matchPattern matches a key against a glob pattern, where * matches any run
+// of characters as in Redis SCAN
 func matchPattern(s, pattern string) bool {
-	// Simple wildcard matching
-	if pattern == "*" {
-		return true
-	}
-
-	if strings.HasSuffix(pattern, "*") {
-		prefix := strings.TrimSuffix(pattern, "*")
-		return strings.HasPrefix(s, prefix)
-	}
-
-	return s == pattern
+	// Keys contain no slashes, so path.Match's * spans the whole key
+	ok, err := path.Match(pattern, s)
+	return err == nil && ok
 }
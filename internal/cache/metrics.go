@@ -0,0 +1,28 @@
+package cache
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// RegisterMemoryCacheMetrics exposes the size and evictions of a memory cache
+// on the default Prometheus registry
+func RegisterMemoryCacheMetrics(m *MemoryCache) {
+	prometheus.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "risconnector_memory_cache_entries",
+			Help: "Entries held in the in-memory cache.",
+		}, func() float64 { return float64(m.Len()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "risconnector_memory_cache_bytes",
+			Help: "Bytes of keys and values held in the in-memory cache.",
+		}, func() float64 { return float64(m.Size()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "risconnector_memory_cache_evictions_total",
+			Help: "Entries evicted from the in-memory cache to stay within its limits.",
+		}, func() float64 { return float64(m.Evictions()) }),
+	)
+}
+
+// RegisterStatsMetrics exposes per-tenant and per-tier cache statistics on the
+// default Prometheus registry
+func RegisterStatsMetrics(stats *Stats) {
+	prometheus.MustRegister(stats)
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+)
+
+// Pins is the set of pinned studies. Entries of a pinned study are exempt from
+// the evictions a MemoryCache, TieredCache or QuotaCache makes to stay within
+// its limits; they still expire. A nil Pins pins nothing.
+type Pins struct {
+	mu      sync.RWMutex
+	studies map[string]bool // by tenant:studyUID
+}
+
+// NewPins creates an empty set of pinned studies
+func NewPins() *Pins {
+	return &Pins{studies: make(map[string]bool)}
+}
+
+// Pin pins a study of a tenant
+func (p *Pins) Pin(tenant, studyUID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.studies[tenant+":"+studyUID] = true
+}
+
+// Unpin unpins a study of a tenant
+func (p *Pins) Unpin(tenant, studyUID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.studies, tenant+":"+studyUID)
+}
+
+// Replace replaces the pinned studies with the given study UIDs per tenant
+func (p *Pins) Replace(studies map[string][]string) {
+	pinned := make(map[string]bool)
+	for tenant, uids := range studies {
+		for _, studyUID := range uids {
+			pinned[tenant+":"+studyUID] = true
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.studies = pinned
+}
+
+// Pinned reports whether a key belongs to a pinned study. Keys are laid out as
+// tenant:generation:study:... (see CacheKey).
+func (p *Pins) Pinned(key string) bool {
+	if p == nil {
+		return false
+	}
+	parts := strings.SplitN(key, ":", 4)
+	if len(parts) < 3 {
+		return false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.studies[parts[0]+":"+parts[2]]
+}
@@ -0,0 +1,244 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Quota limits what one tenant may keep in a cache; zero fields do not limit
+type Quota struct {
+	MaxBytes   int64
+	MaxObjects int
+}
+
+// limited reports whether the quota limits anything
+func (q Quota) limited() bool {
+	return q.MaxBytes > 0 || q.MaxObjects > 0
+}
+
+// quotaSweepInterval is how often the entries that expired are dropped from
+// the tenants' usage
+const quotaSweepInterval = time.Minute
+
+// QuotaFunc returns the quota of a tenant
+type QuotaFunc func(ctx context.Context, tenant string) Quota
+
+// QuotaCache enforces per-tenant quotas on a cache. When a write takes a tenant
+// past its quota, that tenant's oldest entries are evicted until it fits, so one
+// tenant's large studies cannot push everyone else's entries out of the cache.
+// Entries of pinned studies count against the quota but are not evicted, so a
+// tenant pinning more than its quota holds stays over it. Values larger than
+// the tenant's byte quota are not cached.
+//
+// Like the tiers of a TieredCache, usage is tracked in process and covers the
+// entries this instance has written while the tenant had a quota; entries are
+// dropped from it once they expire.
+type QuotaCache struct {
+	Cache
+	quotas QuotaFunc
+
+	mu      sync.Mutex
+	tenants map[string]*tenantUsage
+	entries map[string]*list.Element // by key
+	swept   time.Time                // when expired entries were last dropped
+	onEvict func(key string)
+	pins    *Pins
+}
+
+// tenantUsage tracks the entries of a tenant in the order they were written
+type tenantUsage struct {
+	bytes int64
+	order *list.List // of *quotaEntry, oldest first
+}
+
+type quotaEntry struct {
+	key     string
+	tenant  string
+	size    int64
+	expires time.Time // zero when the entry does not expire
+}
+
+// NewQuotaCache wraps a cache so the quotas returned by quotas are enforced;
+// a nil quotas enforces none until SetQuotas is called
+func NewQuotaCache(c Cache, quotas QuotaFunc) *QuotaCache {
+	return &QuotaCache{
+		Cache:   c,
+		quotas:  quotas,
+		tenants: make(map[string]*tenantUsage),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// SetQuotas sets the function returning the tenants' quotas; it is not safe to
+// call while the cache is in use
+func (c *QuotaCache) SetQuotas(quotas QuotaFunc) {
+	c.quotas = quotas
+}
+
+// OnEvict registers a function called with the key of every entry evicted to
+// keep a tenant within its quota; it is not safe to call while the cache is in use
+func (c *QuotaCache) OnEvict(fn func(key string)) {
+	c.onEvict = fn
+}
+
+// KeepPinned exempts the entries of the studies in pins from eviction; it is
+// not safe to call while the cache is in use
+func (c *QuotaCache) KeepPinned(pins *Pins) {
+	c.pins = pins
+}
+
+// Usage returns the bytes and entries a tenant holds
+func (c *QuotaCache) Usage(tenant string) (int64, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	usage, ok := c.tenants[tenant]
+	if !ok {
+		return 0, 0
+	}
+	return usage.bytes, usage.order.Len()
+}
+
+// Get retrieves a value from cache
+func (c *QuotaCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.Cache.Get(ctx, key)
+	if errors.Is(err, ErrCacheMiss) {
+		c.forget(key)
+	}
+	return value, err
+}
+
+// Set stores a value in cache, evicting the tenant's oldest entries when it
+// exceeds its quota
+func (c *QuotaCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	tenant := keyTenant(key)
+	var quota Quota
+	if c.quotas != nil {
+		quota = c.quotas(ctx, tenant)
+	}
+
+	size := int64(len(value))
+	if quota.MaxBytes > 0 && size > quota.MaxBytes {
+		// Drop the previous value rather than leave it to be served
+		c.forget(key)
+		return c.Cache.Delete(ctx, key)
+	}
+
+	if err := c.Cache.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	// Entries of tenants without a quota are not tracked
+	if !quota.limited() {
+		c.forget(key)
+		return nil
+	}
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	for _, victim := range c.add(tenant, key, size, expires, quota) {
+		if err := c.Cache.Delete(ctx, victim.key); err != nil {
+			log.Warn().Err(err).Str("key", victim.key).Msg("Failed to evict cache entry over tenant quota")
+			continue
+		}
+		if c.onEvict != nil && (victim.expires.IsZero() || time.Now().Before(victim.expires)) {
+			c.onEvict(victim.key)
+		}
+	}
+	return nil
+}
+
+// Delete removes a value from cache
+func (c *QuotaCache) Delete(ctx context.Context, key string) error {
+	c.forget(key)
+	return c.Cache.Delete(ctx, key)
+}
+
+// Clear removes all keys matching a glob pattern
+func (c *QuotaCache) Clear(ctx context.Context, pattern string) error {
+	c.mu.Lock()
+	for key := range c.entries {
+		if matchPattern(key, pattern) {
+			c.forgetLocked(key)
+		}
+	}
+	c.mu.Unlock()
+
+	return c.Cache.Clear(ctx, pattern)
+}
+
+// add records an entry written for a tenant and returns the tenant's oldest
+// entries that no longer fit its quota
+func (c *QuotaCache) add(tenant, key string, size int64, expires time.Time, quota Quota) []*quotaEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.swept) >= quotaSweepInterval {
+		c.sweepLocked(now)
+	}
+
+	c.forgetLocked(key)
+	usage, ok := c.tenants[tenant]
+	if !ok {
+		usage = &tenantUsage{order: list.New()}
+		c.tenants[tenant] = usage
+	}
+	c.entries[key] = usage.order.PushBack(&quotaEntry{key: key, tenant: tenant, size: size, expires: expires})
+	usage.bytes += size
+
+	var evicted []*quotaEntry
+	oldest := usage.order.Front()
+	for (quota.MaxBytes > 0 && usage.bytes > quota.MaxBytes) ||
+		(quota.MaxObjects > 0 && usage.order.Len() > quota.MaxObjects) {
+		entry := oldest.Value.(*quotaEntry)
+		if entry.key == key {
+			break
+		}
+		oldest = oldest.Next()
+		if c.pins.Pinned(entry.key) {
+			continue
+		}
+		c.forgetLocked(entry.key)
+		evicted = append(evicted, entry)
+	}
+	return evicted
+}
+
+// sweepLocked drops the entries that expired from the tenants' usage
+func (c *QuotaCache) sweepLocked(now time.Time) {
+	c.swept = now
+	for key, elem := range c.entries {
+		if expires := elem.Value.(*quotaEntry).expires; !expires.IsZero() && !now.Before(expires) {
+			c.forgetLocked(key)
+		}
+	}
+}
+
+func (c *QuotaCache) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.forgetLocked(key)
+}
+
+func (c *QuotaCache) forgetLocked(key string) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	entry := elem.Value.(*quotaEntry)
+	usage := c.tenants[entry.tenant]
+	usage.bytes -= entry.size
+	usage.order.Remove(elem)
+	if usage.order.Len() == 0 {
+		delete(c.tenants, entry.tenant)
+	}
+	delete(c.entries, key)
+}
@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Recorder receives every read of an instrumented cache. RecordRead must not
+// block the reader.
+type Recorder interface {
+	RecordRead(key, tier string, hit bool, size int64, duration time.Duration)
+}
+
+// servedTierKey is the context key of the slot a tiered cache reports the
+// tier serving a read in
+type servedTierKey struct{}
+
+// withServedTier returns a context in which the tier serving a read is reported
+func withServedTier(ctx context.Context) (context.Context, *string) {
+	tier := new(string)
+	return context.WithValue(ctx, servedTierKey{}, tier), tier
+}
+
+// setServedTier reports the tier serving a read to the caller that asked for it
+func setServedTier(ctx context.Context, tier string) {
+	if slot, ok := ctx.Value(servedTierKey{}).(*string); ok {
+		*slot = tier
+	}
+}
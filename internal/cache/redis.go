@@ -2,39 +2,117 @@ package cache
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// Redis deployment modes
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeCluster    = "cluster"
+	RedisModeSentinel   = "sentinel"
+)
+
+// RedisConfig locates a standalone Redis node, a Redis Cluster or the
+// sentinels of a Redis master
+type RedisConfig struct {
+	Mode       string   // standalone, cluster or sentinel; empty for standalone
+	Addrs      []string // the node, cluster seed nodes or sentinels as host:port
+	MasterName string   // sentinel: name of the monitored master
+	Username   string   // ACL user; empty for the default user
+	Password   string
+	DB         int // standalone and sentinel only
+
+	SentinelUsername string // sentinel: credentials of the sentinels, when they differ
+	SentinelPassword string
+
+	TLS       bool
+	TLSCAFile string // PEM bundle of a private CA; empty for the system roots
+}
+
 // RedisCache implements Cache interface using Redis
 type RedisCache struct {
-	client *redis.Client
+	client redis.UniversalClient
 }
 
 // NewRedisCache creates a new Redis cache
-func NewRedisCache(addr, password string, db int) (*RedisCache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:         addr,
-		Password:     password,
-		DB:           db,
+func NewRedisCache(config RedisConfig) (*RedisCache, error) {
+	client, err := NewRedisClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisCache{client: client}, nil
+}
+
+// NewRedisClient connects to Redis in the configured mode, for the cache and
+// other state shared by connector instances
+func NewRedisClient(config RedisConfig) (redis.UniversalClient, error) {
+	if len(config.Addrs) == 0 {
+		return nil, fmt.Errorf("redis requires at least one address")
+	}
+
+	var tlsConfig *tls.Config
+	if config.TLS {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if config.TLSCAFile != "" {
+			pem, err := os.ReadFile(config.TLSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read Redis CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("redis CA file %s holds no PEM certificates", config.TLSCAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+
+	options := &redis.UniversalOptions{
+		Addrs:        config.Addrs,
+		Username:     config.Username,
+		Password:     config.Password,
 		DialTimeout:  5 * time.Second,
 		ReadTimeout:  3 * time.Second,
 		WriteTimeout: 3 * time.Second,
 		PoolSize:     10,
 		MinIdleConns: 5,
-	})
+		TLSConfig:    tlsConfig,
+	}
+	var client redis.UniversalClient
+	switch config.Mode {
+	case "", RedisModeStandalone:
+		options.DB = config.DB
+		client = redis.NewClient(options.Simple())
+	case RedisModeCluster:
+		client = redis.NewClusterClient(options.Cluster())
+	case RedisModeSentinel:
+		if config.MasterName == "" {
+			return nil, fmt.Errorf("redis sentinel mode requires a master name")
+		}
+		options.DB = config.DB
+		options.MasterName = config.MasterName
+		options.SentinelUsername = config.SentinelUsername
+		options.SentinelPassword = config.SentinelPassword
+		client = redis.NewFailoverClient(options.Failover())
+	default:
+		return nil, fmt.Errorf("invalid Redis mode: %s", config.Mode)
+	}
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &RedisCache{client: client}, nil
+	return client, nil
 }
 
 // Get retrieves a value from cache
@@ -74,9 +152,20 @@ func (r *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
 	return count > 0, nil
 }
 
-// Clear removes all keys matching pattern
+// Clear removes all keys matching pattern. A cluster is scanned on every master,
+// as each holds its own share of the keys.
 func (r *RedisCache) Clear(ctx context.Context, pattern string) error {
-	iter := r.client.Scan(ctx, 0, pattern, 0).Iterator()
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return r.clearNode(ctx, node, pattern)
+		})
+	}
+	return r.clearNode(ctx, r.client, pattern)
+}
+
+// clearNode deletes the keys matching pattern found by scanning one node
+func (r *RedisCache) clearNode(ctx context.Context, node redis.Cmdable, pattern string) error {
+	iter := node.Scan(ctx, 0, pattern, 0).Iterator()
 	for iter.Next(ctx) {
 		if err := r.client.Del(ctx, iter.Val()).Err(); err != nil {
 			return fmt.Errorf("failed to delete key %s: %w", iter.Val(), err)
@@ -0,0 +1,314 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TierStats are the cache statistics of one tenant in one tier, counted since
+// the process started
+type TierStats struct {
+	Tier      string
+	Hits      uint64
+	Misses    uint64
+	Sets      uint64
+	Evictions uint64
+	// BytesStored is what the tier holds for the tenant, for tiers whose
+	// contents are tracked in process; -1 when not known
+	BytesStored int64
+}
+
+// HitRatio returns the share of reads that were hits, or 0 without reads
+func (t TierStats) HitRatio() float64 {
+	if t.Hits+t.Misses == 0 {
+		return 0
+	}
+	return float64(t.Hits) / float64(t.Hits+t.Misses)
+}
+
+// Stats counts cache hits, misses, sets and evictions per tenant and tier. The
+// tenant of an entry is the part of its key before the first colon, as laid
+// out by CacheKey. Stats is a prometheus.Collector.
+type Stats struct {
+	mu       sync.Mutex
+	counters map[statsKey]*statsCounters
+	sizes    map[string]func() map[string]int64 // bytes per tenant, by tier
+}
+
+type statsKey struct {
+	tenant string
+	tier   string
+}
+
+type statsCounters struct {
+	hits      uint64
+	misses    uint64
+	sets      uint64
+	evictions uint64
+}
+
+// NewStats creates an empty set of cache statistics
+func NewStats() *Stats {
+	return &Stats{
+		counters: make(map[statsKey]*statsCounters),
+		sizes:    make(map[string]func() map[string]int64),
+	}
+}
+
+// Hit records a read of key served by tier
+func (s *Stats) Hit(tier, key string) {
+	s.record(tier, key, func(c *statsCounters) { c.hits++ })
+}
+
+// Miss records a read of key that tier could not serve
+func (s *Stats) Miss(tier, key string) {
+	s.record(tier, key, func(c *statsCounters) { c.misses++ })
+}
+
+// Set records a write of key to tier
+func (s *Stats) Set(tier, key string) {
+	s.record(tier, key, func(c *statsCounters) { c.sets++ })
+}
+
+// Evicted records key being evicted from tier to make room
+func (s *Stats) Evicted(tier, key string) {
+	s.record(tier, key, func(c *statsCounters) { c.evictions++ })
+}
+
+// TrackSize reports the bytes tier holds per tenant through sizes, which is
+// called whenever statistics are read
+func (s *Stats) TrackSize(tier string, sizes func() map[string]int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sizes[tier] = sizes
+}
+
+func (s *Stats) record(tier, key string, update func(*statsCounters)) {
+	k := statsKey{tenant: keyTenant(key), tier: tier}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[k]
+	if !ok {
+		c = &statsCounters{}
+		s.counters[k] = c
+	}
+	update(c)
+}
+
+// Tenant returns the statistics of a tenant, one entry per tier it was seen in
+func (s *Stats) Tenant(tenant string) []TierStats {
+	var tiers []TierStats
+	for _, t := range s.snapshot() {
+		if t.tenant == tenant {
+			tiers = append(tiers, t.TierStats)
+		}
+	}
+	return tiers
+}
+
+type tenantTierStats struct {
+	TierStats
+	tenant string
+}
+
+// snapshot returns the statistics of every tenant and tier, sorted
+func (s *Stats) snapshot() []tenantTierStats {
+	s.mu.Lock()
+	sizeFuncs := make(map[string]func() map[string]int64, len(s.sizes))
+	for tier, fn := range s.sizes {
+		sizeFuncs[tier] = fn
+	}
+	stats := make(map[statsKey]*tenantTierStats, len(s.counters))
+	for k, c := range s.counters {
+		stats[k] = &tenantTierStats{
+			TierStats: TierStats{
+				Tier:        k.tier,
+				Hits:        c.hits,
+				Misses:      c.misses,
+				Sets:        c.sets,
+				Evictions:   c.evictions,
+				BytesStored: -1,
+			},
+			tenant: k.tenant,
+		}
+	}
+	s.mu.Unlock()
+
+	// Sizes are computed outside the lock, as they scan the tiers
+	for tier, fn := range sizeFuncs {
+		for tenant, size := range fn() {
+			k := statsKey{tenant: tenant, tier: tier}
+			t, ok := stats[k]
+			if !ok {
+				t = &tenantTierStats{TierStats: TierStats{Tier: tier}, tenant: tenant}
+				stats[k] = t
+			}
+			t.BytesStored = size
+		}
+		for k, t := range stats {
+			if k.tier == tier && t.BytesStored < 0 {
+				t.BytesStored = 0
+			}
+		}
+	}
+
+	result := make([]tenantTierStats, 0, len(stats))
+	for _, t := range stats {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].tenant != result[j].tenant {
+			return result[i].tenant < result[j].tenant
+		}
+		return result[i].Tier < result[j].Tier
+	})
+	return result
+}
+
+var (
+	statsHitsDesc = prometheus.NewDesc("risconnector_cache_hits_total",
+		"Cache reads served, by tenant and tier.", []string{"tenant", "tier"}, nil)
+	statsMissesDesc = prometheus.NewDesc("risconnector_cache_misses_total",
+		"Cache reads not served, by tenant and tier.", []string{"tenant", "tier"}, nil)
+	statsHitRatioDesc = prometheus.NewDesc("risconnector_cache_hit_ratio",
+		"Share of cache reads served since start, by tenant and tier.", []string{"tenant", "tier"}, nil)
+	statsEvictionsDesc = prometheus.NewDesc("risconnector_cache_evictions_total",
+		"Cache entries evicted to make room, by tenant and tier.", []string{"tenant", "tier"}, nil)
+	statsBytesDesc = prometheus.NewDesc("risconnector_cache_bytes",
+		"Bytes held in the cache, by tenant and tier; only for tiers tracked in process.", []string{"tenant", "tier"}, nil)
+)
+
+// Describe implements prometheus.Collector
+func (s *Stats) Describe(ch chan<- *prometheus.Desc) {
+	ch <- statsHitsDesc
+	ch <- statsMissesDesc
+	ch <- statsHitRatioDesc
+	ch <- statsEvictionsDesc
+	ch <- statsBytesDesc
+}
+
+// Collect implements prometheus.Collector
+func (s *Stats) Collect(ch chan<- prometheus.Metric) {
+	for _, t := range s.snapshot() {
+		ch <- prometheus.MustNewConstMetric(statsHitsDesc, prometheus.CounterValue, float64(t.Hits), t.tenant, t.Tier)
+		ch <- prometheus.MustNewConstMetric(statsMissesDesc, prometheus.CounterValue, float64(t.Misses), t.tenant, t.Tier)
+		ch <- prometheus.MustNewConstMetric(statsHitRatioDesc, prometheus.GaugeValue, t.HitRatio(), t.tenant, t.Tier)
+		ch <- prometheus.MustNewConstMetric(statsEvictionsDesc, prometheus.CounterValue, float64(t.Evictions), t.tenant, t.Tier)
+		if t.BytesStored >= 0 {
+			ch <- prometheus.MustNewConstMetric(statsBytesDesc, prometheus.GaugeValue, float64(t.BytesStored), t.tenant, t.Tier)
+		}
+	}
+}
+
+// keyTenant returns the tenant part of a cache key
+func keyTenant(key string) string {
+	tenant, _, _ := strings.Cut(key, ":")
+	return tenant
+}
+
+// InstrumentedCache records the reads and writes of a cache in Stats, and its
+// reads with the Recorders added
+type InstrumentedCache struct {
+	Cache
+	tier      string
+	stats     *Stats
+	recorders []Recorder
+}
+
+// Instrument wraps a cache so its hits, misses and sets are counted in stats
+// under tier. Memory and tiered caches also report their evictions and the
+// bytes they hold; a tiered cache counts each of its tiers separately. Entries
+// evicted to keep a tenant within its quota count as evictions of tier.
+func Instrument(c Cache, tier string, stats *Stats) *InstrumentedCache {
+	inner := c
+unwrap:
+	for {
+		switch wrapper := inner.(type) {
+		case *QuotaCache:
+			wrapper.OnEvict(func(key string) { stats.Evicted(tier, key) })
+			inner = wrapper.Cache
+		case *ChunkedCache:
+			inner = wrapper.Cache
+		case *CompressedCache:
+			inner = wrapper.Cache
+		case *EncryptedCache:
+			inner = wrapper.Cache
+		default:
+			break unwrap
+		}
+	}
+	switch impl := inner.(type) {
+	case *MemoryCache:
+		impl.OnEvict(func(key string) { stats.Evicted(tier, key) })
+		stats.TrackSize(tier, impl.TenantSizes)
+	case *TieredCache:
+		impl.RecordStats(stats)
+	}
+	return &InstrumentedCache{Cache: c, tier: tier, stats: stats}
+}
+
+// Stats returns the statistics the cache records into
+func (c *InstrumentedCache) Stats() *Stats {
+	return c.stats
+}
+
+// RecordTo adds a recorder that receives every read; it is not safe to call
+// while the cache is in use
+func (c *InstrumentedCache) RecordTo(recorder Recorder) {
+	c.recorders = append(c.recorders, recorder)
+}
+
+// Get retrieves a value from cache
+func (c *InstrumentedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	start := time.Now()
+	ctx, servedBy := withServedTier(ctx)
+
+	value, err := c.Cache.Get(ctx, key)
+	switch {
+	case err == nil:
+		c.stats.Hit(c.tier, key)
+	case errors.Is(err, ErrCacheMiss):
+		c.stats.Miss(c.tier, key)
+	default:
+		return value, err
+	}
+
+	if len(c.recorders) > 0 {
+		tier := c.tier
+		if *servedBy != "" {
+			tier = *servedBy
+		}
+		duration := time.Since(start)
+		for _, recorder := range c.recorders {
+			recorder.RecordRead(key, tier, err == nil, int64(len(value)), duration)
+		}
+	}
+	return value, err
+}
+
+// Set stores a value in cache
+func (c *InstrumentedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.Cache.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	c.stats.Set(c.tier, key)
+	return nil
+}
+
+// Close closes the wrapped cache if it holds resources
+func (c *InstrumentedCache) Close() error {
+	if closer, ok := c.Cache.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultPromoteHits is how often an entry is read from a slower tier before it is promoted
+const DefaultPromoteHits = 2
+
+// Tier is one level of a TieredCache
+type Tier struct {
+	Name     string // for logging, e.g. "memory"
+	Cache    Cache
+	MaxBytes int64 // capacity of the tier; 0 for no limit
+}
+
+// TieredCache composes caches of decreasing speed, e.g. memory, Redis and S3.
+// Reads are served from the fastest tier holding a key, and keys read often
+// from a slower tier are promoted to the tier above. New entries go to the
+// fastest tier that can hold them; when a tier is full its least recently used
+// entries are demoted to the next tier, or evicted from the last one. Entries
+// of pinned studies may be demoted but are never evicted from the last tier.
+//
+// Sizes and recency are tracked in process, so the tier limits account for
+// the entries this instance has written or read.
+type TieredCache struct {
+	tiers       []*tierState
+	promoteHits int
+	promoteTTL  time.Duration // for promoted entries whose expiry is not known
+	stats       *Stats        // per-tier statistics; nil when not recorded
+	pins        *Pins
+}
+
+// tierState tracks the entries of a tier in least recently used order
+type tierState struct {
+	Tier
+
+	mu      sync.Mutex
+	used    int64
+	lru     *list.List               // of *tierEntry, most recently used first
+	entries map[string]*list.Element // by key
+}
+
+type tierEntry struct {
+	key     string
+	size    int64
+	expires time.Time // zero when not known
+	hits    int       // reads since the entry entered the tier
+}
+
+// NewTieredCache creates a tiered cache from tiers ordered fastest first.
+// Entries read promoteHits times from a slower tier move up one tier; entries
+// of unknown expiry keep promoteTTL when they do.
+func NewTieredCache(tiers []Tier, promoteHits int, promoteTTL time.Duration) *TieredCache {
+	if promoteHits <= 0 {
+		promoteHits = DefaultPromoteHits
+	}
+
+	states := make([]*tierState, len(tiers))
+	for i, tier := range tiers {
+		states[i] = &tierState{
+			Tier:    tier,
+			lru:     list.New(),
+			entries: make(map[string]*list.Element),
+		}
+	}
+
+	return &TieredCache{
+		tiers:       states,
+		promoteHits: promoteHits,
+		promoteTTL:  promoteTTL,
+	}
+}
+
+// RecordStats counts the hits, misses and evictions of each tier in stats under
+// the tier's name, along with the bytes each tier holds
+func (c *TieredCache) RecordStats(stats *Stats) {
+	c.stats = stats
+	for _, t := range c.tiers {
+		stats.TrackSize(t.Name, t.tenantSizes)
+	}
+}
+
+// KeepPinned exempts the entries of the studies in pins from eviction from the
+// last tier; it is not safe to call while the cache is in use
+func (c *TieredCache) KeepPinned(pins *Pins) {
+	c.pins = pins
+}
+
+// Get retrieves a value from the fastest tier holding it. A tier that fails is
+// skipped, so an unavailable slow tier degrades to a miss.
+func (c *TieredCache) Get(ctx context.Context, key string) ([]byte, error) {
+	var firstErr error
+	for i, t := range c.tiers {
+		value, err := t.Cache.Get(ctx, key)
+		if errors.Is(err, ErrCacheMiss) {
+			t.forget(key)
+			if c.stats != nil {
+				c.stats.Miss(t.Name, key)
+			}
+			continue
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		if c.stats != nil {
+			c.stats.Hit(t.Name, key)
+		}
+		setServedTier(ctx, t.Name)
+		entry := t.touch(key, int64(len(value)))
+		if i > 0 && entry.hits >= c.promoteHits {
+			c.promote(ctx, i, key, value, entry.expires)
+		}
+		return value, nil
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, ErrCacheMiss
+}
+
+// Set stores a value in the fastest tier that can hold it, demoting that tier's
+// coldest entries when it overflows
+func (c *TieredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	stored, err := c.store(ctx, 0, key, value, ttl)
+	if err != nil {
+		return err
+	}
+
+	// Drop copies left in other tiers so they are neither served nor counted
+	for i, t := range c.tiers {
+		if i != stored && t.tracks(key) {
+			t.remove(ctx, key)
+		}
+	}
+	return nil
+}
+
+// Delete removes a value from every tier
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	var firstErr error
+	for _, t := range c.tiers {
+		t.forget(key)
+		if err := t.Cache.Delete(ctx, key); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Exists checks if any tier holds a key
+func (c *TieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	var firstErr error
+	for _, t := range c.tiers {
+		ok, err := t.Cache.Exists(ctx, key)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, firstErr
+}
+
+// Clear removes all keys matching pattern from every tier
+func (c *TieredCache) Clear(ctx context.Context, pattern string) error {
+	var firstErr error
+	for _, t := range c.tiers {
+		t.forgetMatching(pattern)
+		if err := t.Cache.Clear(ctx, pattern); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close closes the tiers that hold resources
+func (c *TieredCache) Close() error {
+	var firstErr error
+	for _, t := range c.tiers {
+		if closer, ok := t.Cache.(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// store writes a value to the first tier from the given one that can hold it
+// and returns that tier's index
+func (c *TieredCache) store(ctx context.Context, from int, key string, value []byte, ttl time.Duration) (int, error) {
+	size := int64(len(value))
+	for i := from; i < len(c.tiers); i++ {
+		t := c.tiers[i]
+		if t.MaxBytes > 0 && size > t.MaxBytes {
+			continue
+		}
+
+		if err := t.Cache.Set(ctx, key, value, ttl); err != nil {
+			return i, err
+		}
+		if c.stats != nil {
+			c.stats.Set(t.Name, key)
+		}
+
+		var expires time.Time
+		if ttl > 0 {
+			expires = time.Now().Add(ttl)
+		}
+		var pins *Pins
+		if i == len(c.tiers)-1 {
+			pins = c.pins
+		}
+		for _, cold := range t.add(key, size, expires, pins) {
+			c.demote(ctx, i, cold)
+		}
+		return i, nil
+	}
+
+	// Too large for every tier; not caching it is not an error
+	return -1, nil
+}
+
+// promote moves an entry read from a slower tier to the tier above it
+func (c *TieredCache) promote(ctx context.Context, from int, key string, value []byte, expires time.Time) {
+	ttl := c.promoteTTL
+	if !expires.IsZero() {
+		ttl = time.Until(expires)
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	to, err := c.store(ctx, from-1, key, value, ttl)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Str("tier", c.tiers[from-1].Name).Msg("Failed to promote cache entry")
+		return
+	}
+	if to < from {
+		c.tiers[from].remove(ctx, key)
+	}
+}
+
+// demote moves an entry evicted from a full tier to the next one, or drops it
+// from the last tier
+func (c *TieredCache) demote(ctx context.Context, from int, entry *tierEntry) {
+	t := c.tiers[from]
+	if c.stats != nil {
+		c.stats.Evicted(t.Name, entry.key)
+	}
+	value, err := t.Cache.Get(ctx, entry.key)
+	if err := t.Cache.Delete(ctx, entry.key); err != nil {
+		log.Warn().Err(err).Str("key", entry.key).Str("tier", t.Name).Msg("Failed to evict cache entry")
+	}
+	if err != nil || from+1 >= len(c.tiers) {
+		return
+	}
+
+	ttl := c.promoteTTL
+	if !entry.expires.IsZero() {
+		ttl = time.Until(entry.expires)
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	if _, err := c.store(ctx, from+1, entry.key, value, ttl); err != nil {
+		log.Warn().Err(err).Str("key", entry.key).Str("tier", c.tiers[from+1].Name).Msg("Failed to demote cache entry")
+	}
+}
+
+// add records an entry written to the tier and returns the least recently
+// used entries that no longer fit, leaving out those of studies in pins
+func (t *tierState) add(key string, size int64, expires time.Time, pins *Pins) []*tierEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.forgetLocked(key)
+	t.entries[key] = t.lru.PushFront(&tierEntry{key: key, size: size, expires: expires})
+	t.used += size
+
+	var evicted []*tierEntry
+	oldest := t.lru.Back()
+	for t.MaxBytes > 0 && t.used > t.MaxBytes {
+		entry := oldest.Value.(*tierEntry)
+		if entry.key == key {
+			break
+		}
+		oldest = oldest.Prev()
+		if pins.Pinned(entry.key) {
+			continue
+		}
+		t.forgetLocked(entry.key)
+		evicted = append(evicted, entry)
+	}
+	return evicted
+}
+
+// touch records a read of an entry, tracking entries the tier held before
+// this instance saw them
+func (t *tierState) touch(key string, size int64) tierEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elem, ok := t.entries[key]
+	if !ok {
+		elem = t.lru.PushFront(&tierEntry{key: key, size: size})
+		t.entries[key] = elem
+		t.used += size
+	} else {
+		t.lru.MoveToFront(elem)
+	}
+
+	entry := elem.Value.(*tierEntry)
+	entry.hits++
+	return *entry
+}
+
+// tenantSizes returns the bytes the tier holds per tenant
+func (t *tierState) tenantSizes() map[string]int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sizes := make(map[string]int64)
+	for key, elem := range t.entries {
+		sizes[keyTenant(key)] += elem.Value.(*tierEntry).size
+	}
+	return sizes
+}
+
+func (t *tierState) tracks(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.entries[key]
+	return ok
+}
+
+// remove deletes an entry from the tier
+func (t *tierState) remove(ctx context.Context, key string) {
+	t.forget(key)
+	if err := t.Cache.Delete(ctx, key); err != nil {
+		log.Warn().Err(err).Str("key", key).Str("tier", t.Name).Msg("Failed to remove cache entry")
+	}
+}
+
+func (t *tierState) forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.forgetLocked(key)
+}
+
+func (t *tierState) forgetMatching(pattern string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key := range t.entries {
+		if matchPattern(key, pattern) {
+			t.forgetLocked(key)
+		}
+	}
+}
+
+func (t *tierState) forgetLocked(key string) {
+	elem, ok := t.entries[key]
+	if !ok {
+		return
+	}
+	t.used -= elem.Value.(*tierEntry).size
+	t.lru.Remove(elem)
+	delete(t.entries, key)
+}
@@ -1,23 +1,46 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	Cache    CacheConfig
-	CORS     CORSConfig
-	Metrics  MetricsConfig
-	Log      LogConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Redis     RedisConfig
+	Cache     CacheConfig
+	CORS      CORSConfig
+	Metrics   MetricsConfig
+	Log       LogConfig
+	Auth      AuthConfig
+	Audit     AuditConfig
+	Usage     UsageConfig
+	HL7       HL7Config
+	Viewer    ViewerConfig
+	SMART     SMARTConfig
+	Webhook   WebhookConfig
+	EventBus  EventBusConfig
+	GRPC      GRPCConfig
+	Export    ExportConfig
+	Deident   DeidentConfig
+	Failover  FailoverConfig
+	Sync      SyncConfig
+	Jobs      JobsConfig
+	RateLimit RateLimitConfig
+	Breaker   CircuitBreakerConfig
+	Adapters  AdapterConfig
 }
 
 type ServerConfig struct {
@@ -25,8 +48,20 @@ type ServerConfig struct {
 	Port         int
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	PublicURL    string // externally visible base URL for links in responses; derived from the request when empty
+
+	TLSCertFile     string // PEM certificate the listener serves HTTPS with; empty serves plain HTTP
+	TLSKeyFile      string // PEM private key of the certificate
+	TLSClientCAFile string // PEM bundle of the CAs client certificates are verified against; empty disables mutual TLS
+	TLSClientAuth   string // "require" or "optional" client certificates with a client CA bundle
 }
 
+// Client certificate verification modes of mutual TLS
+const (
+	TLSClientAuthRequire  = "require"
+	TLSClientAuthOptional = "optional"
+)
+
 type DatabaseConfig struct {
 	Host     string
 	Port     int
@@ -38,17 +73,89 @@ type DatabaseConfig struct {
 }
 
 type RedisConfig struct {
-	Host     string
-	Port     int
-	Password string
-	DB       int
-	TTL      time.Duration
+	Mode       string // standalone, cluster or sentinel
+	Host       string // standalone node when Addrs is empty
+	Port       int
+	Addrs      []string // cluster seed nodes or sentinels as host:port
+	MasterName string   // sentinel: name of the monitored master
+	Username   string
+	Password   string
+	DB         int
+	TTL        time.Duration
+
+	SentinelUsername string
+	SentinelPassword string
+
+	TLS       bool
+	TLSCAFile string // PEM bundle of a private CA; empty for the system roots
+}
+
+// Addresses returns the Redis nodes or sentinels to connect to
+func (r RedisConfig) Addresses() []string {
+	if len(r.Addrs) > 0 {
+		return r.Addrs
+	}
+	return []string{fmt.Sprintf("%s:%d", r.Host, r.Port)}
 }
 
 type CacheConfig struct {
-	Enabled    bool
-	Type       string // redis or memory
-	DefaultTTL time.Duration
+	Enabled          bool
+	Type             string // redis, memory or tiered
+	DefaultTTL       time.Duration
+	MetadataTTL      time.Duration // study, series and instance metadata; tenants may override
+	QueryTTL         time.Duration // QIDO result sets; tenants may override
+	QueryStaleTTL    time.Duration // how long QIDO result sets are served past QueryTTL while refreshed; 0 disables
+	ThumbnailTTL     time.Duration // rendered thumbnails; tenants may override
+	ExistsTTL        time.Duration // positive existence probes; 0 disables
+	NotFoundTTL      time.Duration // not-found answers of the PACS; 0 disables
+	MaxInstanceMB    int           // largest instance body cached
+	ChunkSizeMB      int           // redis and tiered: values above are stored in chunks; 0 disables
+	CompressTiers    []string      // memory, redis and s3 tiers whose values are stored zstd-compressed, or none
+	Tiers            []string      // tiered: memory, redis and s3, fastest first
+	MemoryMaxMB      int           // capacity of the memory cache or tier; 0 for no limit
+	MemoryMaxEntries int           // entries held by the memory cache; 0 for no limit
+	RedisMaxMB       int           // per-tier capacity of a tiered cache; 0 for no limit
+	S3MaxMB          int
+	PromoteHits      int           // reads from a slower tier before an entry moves up
+	TenantMaxMB      int           // default per-tenant quota; tenants may override; 0 for no limit
+	TenantMaxObjects int           // default per-tenant entry quota; 0 for no limit
+	RefreshEnabled   bool          // refresh hot metadata before it expires
+	RefreshInterval  time.Duration // how often hot metadata is looked for
+	RefreshAhead     time.Duration // how long before expiry hot metadata is refreshed
+	RefreshMinHits   int           // cache reads within a TTL that make metadata hot
+	RefreshMaxKeys   int           // metadata entries whose reads are tracked
+	MetricsEnabled   bool          // record every cache read in the cache_metrics table
+	MetricsRetention time.Duration // how long recorded reads are kept; 0 keeps them
+	PinRefresh       time.Duration // how often pinned studies are checked for instances missing from the cache
+	PinTTL           time.Duration // how long instances fetched for pinned studies stay cached
+	VerifyInterval   time.Duration // how often every tenant's cache is compared with the PACS; 0 only on request
+	VerifyLookback   int           // recent days whose studies a verification checks
+	VerifyRepair     bool          // scheduled verifications refetch missing instances and drop stale metadata
+	S3               CacheS3Config
+	Encryption       EncryptionConfig
+}
+
+// CacheS3Config locates the bucket of the s3 cache tier
+type CacheS3Config struct {
+	Bucket          string
+	Region          string
+	Endpoint        string
+	PathStyle       bool
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// EncryptionConfig selects a master key data is encrypted under, such as the
+// redis and s3 cache tiers or stored PACS credentials
+type EncryptionConfig struct {
+	Provider        string // local or aws-kms; empty disables encryption
+	Key             string // local: base64 of a 32-byte master key
+	KMSKeyID        string // aws-kms: key ID, ARN or alias
+	KMSRegion       string
+	KMSEndpoint     string
+	AccessKeyID     string
+	SecretAccessKey string
 }
 
 type CORSConfig struct {
@@ -62,6 +169,152 @@ type MetricsConfig struct {
 	Port    int
 }
 
+type AuthConfig struct {
+	AdminToken           string           // bearer token for admin-scope operations; empty disables them
+	RBACEnabled          bool             // require every API request to carry a credential granting the route's scope
+	JWTSecret            string           // HS256 secret verifying the bearer JWTs whose role and permissions grant scopes
+	APIKeySigningSecret  string           // derives the secrets of API keys that sign requests; empty disables request signing
+	StudyAuthzURL        string           // policy endpoint (OPA or webhook) deciding on every study served; empty serves all
+	StudyAuthzToken      string           // bearer token sent to the policy endpoint
+	StudyAuthzCacheTTL   time.Duration    // how long a policy decision is reused; 0 asks on every read
+	CredentialEncryption EncryptionConfig // master key PACS credentials are stored encrypted under
+}
+
+type AuditConfig struct {
+	Enabled   bool          // record every data-access request in the audit_logs table
+	JWTSecret string        // HS256 secret verifying the bearer JWT the user is taken from; empty trusts any well-formed token
+	UserClaim string        // JWT claim naming the user
+	Retention time.Duration // how long audit entries are kept; 0 keeps them
+
+	ATNAAddress  string // host:port of the Audit Record Repository's syslog-TLS listener; empty sends no ATNA audit messages
+	ATNACAFile   string // PEM bundle of the CAs the repository's certificate is verified against; empty for the system roots
+	ATNACertFile string // PEM client certificate the connector authenticates to the repository with
+	ATNAKeyFile  string // PEM private key of the client certificate
+	ATNASourceID string // AuditSourceID of the audit messages; empty for the hostname
+	ATNASiteID   string // AuditEnterpriseSiteID of the audit messages
+	ATNAAppName  string // application name of the connector in the audit messages
+}
+
+type UsageConfig struct {
+	Enabled bool // meter searches, retrievals, bytes served and cache reads per tenant and day
+}
+
+type HL7Config struct {
+	Enabled              bool
+	Port                 int
+	TenantID             string            // tenant for messages from facilities without a mapping
+	FacilityTenants      map[string]string // sending facility (MSH-4) to tenant ID
+	PrefetchPriors       int               // most recent prior studies warmed per order
+	PrefetchLookback     time.Duration     // how far back prior studies are considered
+	PrefetchSameModality bool              // only priors sharing the current study's modality
+	PrefetchSameBodyPart bool              // only priors of the current study's body part
+	PrefetchOnOpen       bool              // also prefetch priors when a study's metadata is first loaded
+	PrefetchWorkers      int               // also run prefetch jobs requested through the API
+	PrefetchTTL          time.Duration     // how long prefetched instances stay cached
+}
+
+type ViewerConfig struct {
+	LaunchURL   string        // viewer URL template for IHE Invoke Image Display; empty disables it
+	TokenSecret string        // HMAC key for launch tokens
+	TokenTTL    time.Duration // lifetime of launch tokens
+}
+
+type SMARTConfig struct {
+	IntrospectionURL string // token introspection endpoint of the SMART authorization server; empty disables launch hand-off
+	ClientID         string // connector credentials for introspection
+	ClientSecret     string
+	GrantTTL         time.Duration // lifetime of viewer grants
+}
+
+type WebhookConfig struct {
+	MaxAttempts       int           // delivery attempts before an event is dead-lettered
+	Timeout           time.Duration // per-attempt request timeout
+	PollInterval      time.Duration // how often due retries are looked for
+	PACSCheckInterval time.Duration // PACS health check interval for pacs.down/pacs.up; 0 disables
+	PACSDegradeAfter  int           // failed health checks in a row that mark a PACS config degraded; 0 never does
+	PACSTestRetention time.Duration // how long recorded PACS connection tests are kept; 0 keeps them
+}
+
+type FailoverConfig struct {
+	Enabled        bool          // move a tenant to its next active PACS config while the primary is down
+	ErrorThreshold int           // consecutive unavailable or timed out requests that fail the primary over; 0 leaves it to health checks
+	RetryInterval  time.Duration // how long requests stay with the secondary before the primary is tried again
+}
+
+type SyncConfig struct {
+	Enabled     bool          // index the studies of each tenant's primary PACS in the study_index table
+	Interval    time.Duration // how often each tenant's primary PACS is searched
+	InitialDays int           // days of studies a tenant's first run indexes
+	OverlapDays int           // days before the last run's day searched again, for studies sent late
+	IndexMode   string        // off, populate to index search results too, or prefer to answer searches from the index
+	IndexMaxAge time.Duration // how long after the PACS was searched the index may answer a search
+}
+
+type RateLimitConfig struct {
+	Enabled     bool
+	Store       string        // memory, or redis to share the limits between instances
+	TenantRate  int           // DICOMweb requests per second of each tenant; 0 for no limit
+	TenantBurst int           // requests a tenant may make at once above its rate
+	PACSRate    int           // calls per second to each PACS; 0 for no limit
+	PACSBurst   int           // calls made at once above the rate
+	PACSMaxWait time.Duration // how long a PACS call waits for its turn before the request fails
+}
+
+type AdapterConfig struct {
+	IdleTimeout time.Duration // how long an unused PACS adapter is kept open; 0 keeps it until its config changes
+
+	// Secret stores PACS credentials may reference instead of being stored
+	VaultAddress                  string // HashiCorp Vault server; empty disables vault:// references
+	VaultToken                    string
+	VaultNamespace                string
+	SecretsManagerRegion          string
+	SecretsManagerEndpoint        string // Secrets Manager-compatible endpoint; empty for AWS
+	SecretsManagerAccessKeyID     string // empty disables aws-sm:// references
+	SecretsManagerSecretAccessKey string
+	SecretRefreshInterval         time.Duration // how long fetched secrets are used before they are fetched again
+}
+
+type CircuitBreakerConfig struct {
+	Enabled          bool          // fail calls to a PACS fast while too many of them fail
+	Window           time.Duration // period over which the error rate is measured
+	MinRequests      int           // calls in a window before its error rate can open the circuit
+	ErrorRate        int           // percentage of failed calls that opens the circuit
+	OpenDuration     time.Duration // how long calls fail fast before the PACS is probed again
+	HalfOpenRequests int           // probe calls let through at once while half-open
+}
+
+type EventBusConfig struct {
+	Driver      string   // kafka or nats; empty disables the event bus
+	Brokers     []string // Kafka bootstrap brokers or NATS server URLs
+	TopicPrefix string   // events go to <prefix>.<event type>
+	Username    string   // SASL PLAIN (Kafka) or NATS user
+	Password    string
+	TLS         bool
+}
+
+type GRPCConfig struct {
+	Enabled bool
+	Port    int // gRPC API for internal services, served on the server host
+}
+
+type JobsConfig struct {
+	Workers          int           // concurrent retrieve jobs per instance
+	PollInterval     time.Duration // how often queued jobs are looked for
+	InstanceAttempts int           // attempts at each instance of a job before it is recorded as failed
+}
+
+type ExportConfig struct {
+	Dir        string        // where export archives are kept until their link expires; shared storage when running several instances
+	Workers    int           // concurrent study exports
+	LinkTTL    time.Duration // lifetime of download links
+	LinkSecret string        // HMAC key for download links; must match across instances
+}
+
+type DeidentConfig struct {
+	Secret    string // key de-identified UIDs and pseudonyms are derived from; must match across instances and stay fixed for UIDs to remain consistent
+	Overrides string // site overrides of the PS3.15 Basic Profile as comma-separated TAG=ACTION pairs, e.g. 00080080=K
+}
+
 type LogConfig struct {
 	Level  string
 	Format string
@@ -78,6 +331,12 @@ func Load() (*Config, error) {
 			Port:         getEnvAsInt("SERVER_PORT", 8080),
 			ReadTimeout:  getEnvAsDuration("SERVER_READ_TIMEOUT", 30*time.Second),
 			WriteTimeout: getEnvAsDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+			PublicURL:    getEnv("PUBLIC_BASE_URL", ""),
+
+			TLSCertFile:     getEnv("SERVER_TLS_CERT_FILE", ""),
+			TLSKeyFile:      getEnv("SERVER_TLS_KEY_FILE", ""),
+			TLSClientCAFile: getEnv("SERVER_TLS_CLIENT_CA_FILE", ""),
+			TLSClientAuth:   getEnv("SERVER_TLS_CLIENT_AUTH", TLSClientAuthRequire),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -89,21 +348,78 @@ func Load() (*Config, error) {
 			LogLevel: getEnv("DB_LOG_LEVEL", "error"),
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
-			Port:     getEnvAsInt("REDIS_PORT", 6379),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       getEnvAsInt("REDIS_DB", 0),
-			TTL:      getEnvAsDuration("REDIS_TTL", 24*time.Hour),
+			Mode:       getEnv("REDIS_MODE", "standalone"),
+			Host:       getEnv("REDIS_HOST", "localhost"),
+			Port:       getEnvAsInt("REDIS_PORT", 6379),
+			Addrs:      getEnvAsSlice("REDIS_ADDRS", nil),
+			MasterName: getEnv("REDIS_MASTER_NAME", ""),
+			Username:   getEnv("REDIS_USERNAME", ""),
+			Password:   getEnv("REDIS_PASSWORD", ""),
+			DB:         getEnvAsInt("REDIS_DB", 0),
+			TTL:        getEnvAsDuration("REDIS_TTL", 24*time.Hour),
+
+			SentinelUsername: getEnv("REDIS_SENTINEL_USERNAME", ""),
+			SentinelPassword: getEnv("REDIS_SENTINEL_PASSWORD", ""),
+
+			TLS:       getEnvAsBool("REDIS_TLS", false),
+			TLSCAFile: getEnv("REDIS_TLS_CA_FILE", ""),
 		},
 		Cache: CacheConfig{
-			Enabled:    getEnvAsBool("CACHE_ENABLED", true),
-			Type:       getEnv("CACHE_TYPE", "redis"),
-			DefaultTTL: getEnvAsDuration("CACHE_DEFAULT_TTL", 1*time.Hour),
+			Enabled:          getEnvAsBool("CACHE_ENABLED", true),
+			Type:             getEnv("CACHE_TYPE", "redis"),
+			DefaultTTL:       getEnvAsDuration("CACHE_DEFAULT_TTL", 1*time.Hour),
+			MetadataTTL:      getEnvAsDuration("CACHE_METADATA_TTL", 10*time.Minute),
+			QueryTTL:         getEnvAsDuration("CACHE_QUERY_TTL", 30*time.Second),
+			QueryStaleTTL:    getEnvAsDuration("CACHE_QUERY_STALE_TTL", 2*time.Minute),
+			ThumbnailTTL:     getEnvAsDuration("CACHE_THUMBNAIL_TTL", 24*time.Hour),
+			ExistsTTL:        getEnvAsDuration("CACHE_EXISTS_TTL", 5*time.Minute),
+			NotFoundTTL:      getEnvAsDuration("CACHE_NOT_FOUND_TTL", 30*time.Second),
+			MaxInstanceMB:    getEnvAsInt("CACHE_MAX_INSTANCE_MB", 64),
+			ChunkSizeMB:      getEnvAsInt("CACHE_CHUNK_SIZE_MB", 4),
+			CompressTiers:    getEnvAsSlice("CACHE_COMPRESS_TIERS", []string{"redis", "s3"}),
+			Tiers:            getEnvAsSlice("CACHE_TIERS", []string{"memory", "redis"}),
+			MemoryMaxMB:      getEnvAsInt("CACHE_MEMORY_MAX_MB", 512),
+			MemoryMaxEntries: getEnvAsInt("CACHE_MEMORY_MAX_ENTRIES", 100000),
+			RedisMaxMB:       getEnvAsInt("CACHE_REDIS_MAX_MB", 4096),
+			S3MaxMB:          getEnvAsInt("CACHE_S3_MAX_MB", 0),
+			PromoteHits:      getEnvAsInt("CACHE_PROMOTE_HITS", 2),
+			TenantMaxMB:      getEnvAsInt("CACHE_TENANT_MAX_MB", 0),
+			TenantMaxObjects: getEnvAsInt("CACHE_TENANT_MAX_OBJECTS", 0),
+			RefreshEnabled:   getEnvAsBool("CACHE_REFRESH_ENABLED", true),
+			RefreshInterval:  getEnvAsDuration("CACHE_REFRESH_INTERVAL", 15*time.Second),
+			RefreshAhead:     getEnvAsDuration("CACHE_REFRESH_AHEAD", time.Minute),
+			RefreshMinHits:   getEnvAsInt("CACHE_REFRESH_MIN_HITS", 5),
+			RefreshMaxKeys:   getEnvAsInt("CACHE_REFRESH_MAX_KEYS", 10000),
+			MetricsEnabled:   getEnvAsBool("CACHE_METRICS_ENABLED", true),
+			MetricsRetention: getEnvAsDuration("CACHE_METRICS_RETENTION", 7*24*time.Hour),
+			PinRefresh:       getEnvAsDuration("CACHE_PIN_REFRESH_INTERVAL", 15*time.Minute),
+			PinTTL:           getEnvAsDuration("CACHE_PIN_TTL", 7*24*time.Hour),
+			VerifyInterval:   getEnvAsDuration("CACHE_VERIFY_INTERVAL", 0),
+			VerifyLookback:   getEnvAsInt("CACHE_VERIFY_LOOKBACK_DAYS", 1),
+			VerifyRepair:     getEnvAsBool("CACHE_VERIFY_REPAIR", false),
+			S3: CacheS3Config{
+				Bucket:          getEnv("CACHE_S3_BUCKET", ""),
+				Region:          getEnv("CACHE_S3_REGION", ""),
+				Endpoint:        getEnv("CACHE_S3_ENDPOINT", ""),
+				PathStyle:       getEnvAsBool("CACHE_S3_PATH_STYLE", false),
+				Prefix:          getEnv("CACHE_S3_PREFIX", "cache/"),
+				AccessKeyID:     getEnv("CACHE_S3_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnv("CACHE_S3_SECRET_ACCESS_KEY", ""),
+			},
+			Encryption: EncryptionConfig{
+				Provider:        getEnv("CACHE_ENCRYPTION", ""),
+				Key:             getEnv("CACHE_ENCRYPTION_KEY", ""),
+				KMSKeyID:        getEnv("CACHE_KMS_KEY_ID", ""),
+				KMSRegion:       getEnv("CACHE_KMS_REGION", ""),
+				KMSEndpoint:     getEnv("CACHE_KMS_ENDPOINT", ""),
+				AccessKeyID:     getEnv("CACHE_KMS_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnv("CACHE_KMS_SECRET_ACCESS_KEY", ""),
+			},
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"*"}),
 			AllowedMethods: getEnvAsSlice("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-			AllowedHeaders: getEnvAsSlice("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "X-Tenant-ID"}),
+			AllowedHeaders: getEnvAsSlice("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "X-Tenant-ID", "X-Department", "X-Calling-AE-Title", "Range", "If-None-Match"}),
 		},
 		Metrics: MetricsConfig{
 			Enabled: getEnvAsBool("METRICS_ENABLED", true),
@@ -113,6 +429,142 @@ func Load() (*Config, error) {
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
+		Auth: AuthConfig{
+			AdminToken:          getEnv("ADMIN_API_TOKEN", ""),
+			RBACEnabled:         getEnvAsBool("AUTH_RBAC_ENABLED", false),
+			JWTSecret:           getEnv("AUTH_JWT_SECRET", ""),
+			APIKeySigningSecret: getEnv("API_KEY_SIGNING_SECRET", ""),
+			StudyAuthzURL:       getEnv("STUDY_AUTHZ_URL", ""),
+			StudyAuthzToken:     getEnv("STUDY_AUTHZ_TOKEN", ""),
+			StudyAuthzCacheTTL:  getEnvAsDuration("STUDY_AUTHZ_CACHE_TTL", time.Minute),
+			CredentialEncryption: EncryptionConfig{
+				Provider:        getEnv("CREDENTIAL_ENCRYPTION", ""),
+				Key:             getEnv("CREDENTIAL_ENCRYPTION_KEY", ""),
+				KMSKeyID:        getEnv("CREDENTIAL_KMS_KEY_ID", ""),
+				KMSRegion:       getEnv("CREDENTIAL_KMS_REGION", ""),
+				KMSEndpoint:     getEnv("CREDENTIAL_KMS_ENDPOINT", ""),
+				AccessKeyID:     getEnv("CREDENTIAL_KMS_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnv("CREDENTIAL_KMS_SECRET_ACCESS_KEY", ""),
+			},
+		},
+		Audit: AuditConfig{
+			Enabled:   getEnvAsBool("AUDIT_ENABLED", true),
+			JWTSecret: getEnv("AUDIT_JWT_SECRET", ""),
+			UserClaim: getEnv("AUDIT_JWT_USER_CLAIM", "sub"),
+			Retention: getEnvAsDuration("AUDIT_RETENTION", 0),
+
+			ATNAAddress:  getEnv("AUDIT_ATNA_ADDRESS", ""),
+			ATNACAFile:   getEnv("AUDIT_ATNA_TLS_CA_FILE", ""),
+			ATNACertFile: getEnv("AUDIT_ATNA_TLS_CERT_FILE", ""),
+			ATNAKeyFile:  getEnv("AUDIT_ATNA_TLS_KEY_FILE", ""),
+			ATNASourceID: getEnv("AUDIT_ATNA_SOURCE_ID", ""),
+			ATNASiteID:   getEnv("AUDIT_ATNA_SITE_ID", ""),
+			ATNAAppName:  getEnv("AUDIT_ATNA_APP_NAME", "ris-dicom-connector"),
+		},
+		Usage: UsageConfig{
+			Enabled: getEnvAsBool("USAGE_METERING_ENABLED", true),
+		},
+		HL7: HL7Config{
+			Enabled:              getEnvAsBool("HL7_ENABLED", false),
+			Port:                 getEnvAsInt("HL7_PORT", 2575),
+			TenantID:             getEnv("HL7_TENANT_ID", ""),
+			FacilityTenants:      getEnvAsMap("HL7_FACILITY_TENANTS"),
+			PrefetchPriors:       getEnvAsInt("PREFETCH_PRIORS", 3),
+			PrefetchLookback:     getEnvAsDuration("PREFETCH_LOOKBACK", 5*365*24*time.Hour),
+			PrefetchSameModality: getEnvAsBool("PREFETCH_SAME_MODALITY", true),
+			PrefetchSameBodyPart: getEnvAsBool("PREFETCH_SAME_BODY_PART", false),
+			PrefetchOnOpen:       getEnvAsBool("PREFETCH_ON_STUDY_OPEN", true),
+			PrefetchWorkers:      getEnvAsInt("PREFETCH_WORKERS", 2),
+			PrefetchTTL:          getEnvAsDuration("PREFETCH_TTL", 12*time.Hour),
+		},
+		Viewer: ViewerConfig{
+			LaunchURL:   getEnv("VIEWER_LAUNCH_URL", ""),
+			TokenSecret: getEnv("VIEWER_TOKEN_SECRET", ""),
+			TokenTTL:    getEnvAsDuration("VIEWER_TOKEN_TTL", 15*time.Minute),
+		},
+		SMART: SMARTConfig{
+			IntrospectionURL: getEnv("SMART_INTROSPECTION_URL", ""),
+			ClientID:         getEnv("SMART_CLIENT_ID", ""),
+			ClientSecret:     getEnv("SMART_CLIENT_SECRET", ""),
+			GrantTTL:         getEnvAsDuration("VIEWER_GRANT_TTL", 15*time.Minute),
+		},
+		Webhook: WebhookConfig{
+			MaxAttempts:       getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			Timeout:           getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			PollInterval:      getEnvAsDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			PACSCheckInterval: getEnvAsDuration("PACS_HEALTH_CHECK_INTERVAL", time.Minute),
+			PACSDegradeAfter:  getEnvAsInt("PACS_DEGRADED_AFTER", 3),
+			PACSTestRetention: getEnvAsDuration("PACS_TEST_HISTORY_RETENTION", 30*24*time.Hour),
+		},
+		Failover: FailoverConfig{
+			Enabled:        getEnvAsBool("PACS_FAILOVER_ENABLED", true),
+			ErrorThreshold: getEnvAsInt("PACS_FAILOVER_ERROR_THRESHOLD", 5),
+			RetryInterval:  getEnvAsDuration("PACS_FAILOVER_RETRY_INTERVAL", time.Minute),
+		},
+		Sync: SyncConfig{
+			Enabled:     getEnvAsBool("PACS_SYNC_ENABLED", false),
+			Interval:    getEnvAsDuration("PACS_SYNC_INTERVAL", 5*time.Minute),
+			InitialDays: getEnvAsInt("PACS_SYNC_INITIAL_DAYS", 7),
+			OverlapDays: getEnvAsInt("PACS_SYNC_OVERLAP_DAYS", 1),
+			IndexMode:   getEnv("STUDY_INDEX_MODE", "off"),
+			IndexMaxAge: getEnvAsDuration("STUDY_INDEX_MAX_AGE", 15*time.Minute),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:     getEnvAsBool("RATE_LIMIT_ENABLED", false),
+			Store:       getEnv("RATE_LIMIT_STORE", "memory"),
+			TenantRate:  getEnvAsInt("TENANT_RATE_LIMIT", 50),
+			TenantBurst: getEnvAsInt("TENANT_RATE_BURST", 100),
+			PACSRate:    getEnvAsInt("PACS_RATE_LIMIT", 0),
+			PACSBurst:   getEnvAsInt("PACS_RATE_BURST", 0),
+			PACSMaxWait: getEnvAsDuration("PACS_RATE_LIMIT_MAX_WAIT", 10*time.Second),
+		},
+		Adapters: AdapterConfig{
+			IdleTimeout: getEnvAsDuration("PACS_ADAPTER_IDLE_TIMEOUT", 10*time.Minute),
+
+			VaultAddress:                  getEnv("VAULT_ADDR", ""),
+			VaultToken:                    getEnv("VAULT_TOKEN", ""),
+			VaultNamespace:                getEnv("VAULT_NAMESPACE", ""),
+			SecretsManagerRegion:          getEnv("SECRETS_MANAGER_REGION", ""),
+			SecretsManagerEndpoint:        getEnv("SECRETS_MANAGER_ENDPOINT", ""),
+			SecretsManagerAccessKeyID:     getEnv("SECRETS_MANAGER_ACCESS_KEY_ID", ""),
+			SecretsManagerSecretAccessKey: getEnv("SECRETS_MANAGER_SECRET_ACCESS_KEY", ""),
+			SecretRefreshInterval:         getEnvAsDuration("SECRETS_REFRESH_INTERVAL", 5*time.Minute),
+		},
+		Breaker: CircuitBreakerConfig{
+			Enabled:          getEnvAsBool("CIRCUIT_BREAKER_ENABLED", true),
+			Window:           getEnvAsDuration("CIRCUIT_BREAKER_WINDOW", time.Minute),
+			MinRequests:      getEnvAsInt("CIRCUIT_BREAKER_MIN_REQUESTS", 5),
+			ErrorRate:        getEnvAsInt("CIRCUIT_BREAKER_ERROR_RATE", 50),
+			OpenDuration:     getEnvAsDuration("CIRCUIT_BREAKER_OPEN_DURATION", 30*time.Second),
+			HalfOpenRequests: getEnvAsInt("CIRCUIT_BREAKER_HALF_OPEN_REQUESTS", 1),
+		},
+		EventBus: EventBusConfig{
+			Driver:      getEnv("EVENT_BUS_DRIVER", ""),
+			Brokers:     getEnvAsSlice("EVENT_BUS_BROKERS", nil),
+			TopicPrefix: getEnv("EVENT_BUS_TOPIC_PREFIX", "risconnector"),
+			Username:    getEnv("EVENT_BUS_USERNAME", ""),
+			Password:    getEnv("EVENT_BUS_PASSWORD", ""),
+			TLS:         getEnvAsBool("EVENT_BUS_TLS", false),
+		},
+		GRPC: GRPCConfig{
+			Enabled: getEnvAsBool("GRPC_ENABLED", false),
+			Port:    getEnvAsInt("GRPC_PORT", 9091),
+		},
+		Export: ExportConfig{
+			Dir:        getEnv("EXPORT_DIR", filepath.Join(os.TempDir(), "dicom-connector-exports")),
+			Workers:    getEnvAsInt("EXPORT_WORKERS", 2),
+			LinkTTL:    getEnvAsDuration("EXPORT_LINK_TTL", 24*time.Hour),
+			LinkSecret: getEnv("EXPORT_LINK_SECRET", ""),
+		},
+		Deident: DeidentConfig{
+			Secret:    getEnv("DEIDENT_SECRET", ""),
+			Overrides: getEnv("DEIDENT_OVERRIDES", ""),
+		},
+		Jobs: JobsConfig{
+			Workers:          getEnvAsInt("RETRIEVE_JOB_WORKERS", 2),
+			PollInterval:     getEnvAsDuration("RETRIEVE_JOB_POLL_INTERVAL", 5*time.Second),
+			InstanceAttempts: getEnvAsInt("RETRIEVE_JOB_INSTANCE_ATTEMPTS", 3),
+		},
 	}
 
 	return config, nil
@@ -164,6 +616,17 @@ func getEnvAsSlice(key string, defaultValue []string) []string {
 	return result
 }
 
+// getEnvAsMap parses comma-separated key=value pairs
+func getEnvAsMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range splitCSV(getEnv(key, "")) {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return result
+}
+
 func splitCSV(s string) []string {
 	var result []string
 	current := ""
@@ -187,8 +650,277 @@ func (c *Config) Validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		return fmt.Errorf("SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE must be set together")
+	}
+	if c.Server.TLSClientCAFile != "" && c.Server.TLSCertFile == "" {
+		return fmt.Errorf("SERVER_TLS_CLIENT_CA_FILE requires SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE")
+	}
+	if c.Server.TLSClientAuth != TLSClientAuthRequire && c.Server.TLSClientAuth != TLSClientAuthOptional {
+		return fmt.Errorf("invalid SERVER_TLS_CLIENT_AUTH: %s", c.Server.TLSClientAuth)
+	}
 	if c.Database.Host == "" {
 		return fmt.Errorf("database host is required")
 	}
+	if c.HL7.Enabled {
+		if c.HL7.Port <= 0 || c.HL7.Port > 65535 {
+			return fmt.Errorf("invalid HL7 port: %d", c.HL7.Port)
+		}
+		if c.HL7.TenantID == "" && len(c.HL7.FacilityTenants) == 0 {
+			return fmt.Errorf("HL7 listener requires HL7_TENANT_ID or HL7_FACILITY_TENANTS")
+		}
+		if c.HL7.TenantID != "" {
+			if _, err := uuid.Parse(c.HL7.TenantID); err != nil {
+				return fmt.Errorf("invalid HL7 tenant ID: %w", err)
+			}
+		}
+		for facility, tenantID := range c.HL7.FacilityTenants {
+			if _, err := uuid.Parse(tenantID); err != nil {
+				return fmt.Errorf("invalid tenant ID for HL7 facility %s: %w", facility, err)
+			}
+		}
+	}
+	if c.Cache.Enabled && c.Cache.Type == "tiered" {
+		if len(c.Cache.Tiers) == 0 {
+			return fmt.Errorf("CACHE_TIERS is required for the tiered cache")
+		}
+		for _, tier := range c.Cache.Tiers {
+			switch tier {
+			case "memory", "redis":
+			case "s3":
+				if c.Cache.S3.Bucket == "" {
+					return fmt.Errorf("CACHE_S3_BUCKET is required for the s3 cache tier")
+				}
+			default:
+				return fmt.Errorf("invalid cache tier: %s", tier)
+			}
+		}
+		if c.Cache.RedisMaxMB < 0 || c.Cache.S3MaxMB < 0 {
+			return fmt.Errorf("cache tier sizes must not be negative")
+		}
+	}
+	if c.usesRedis() {
+		switch c.Redis.Mode {
+		case "standalone", "cluster":
+		case "sentinel":
+			if c.Redis.MasterName == "" {
+				return fmt.Errorf("REDIS_MASTER_NAME is required in Redis sentinel mode")
+			}
+			if len(c.Redis.Addrs) == 0 {
+				return fmt.Errorf("REDIS_ADDRS must list the sentinels in Redis sentinel mode")
+			}
+		default:
+			return fmt.Errorf("invalid Redis mode: %s", c.Redis.Mode)
+		}
+	}
+	if c.Cache.RefreshEnabled {
+		if c.Cache.RefreshInterval <= 0 || c.Cache.RefreshAhead <= 0 {
+			return fmt.Errorf("CACHE_REFRESH_INTERVAL and CACHE_REFRESH_AHEAD must be positive")
+		}
+		if c.Cache.RefreshMinHits <= 0 || c.Cache.RefreshMaxKeys < 0 {
+			return fmt.Errorf("CACHE_REFRESH_MIN_HITS must be positive and CACHE_REFRESH_MAX_KEYS not negative")
+		}
+	}
+	if c.Audit.UserClaim == "" {
+		return fmt.Errorf("AUDIT_JWT_USER_CLAIM is required")
+	}
+	if c.Audit.Retention < 0 {
+		return fmt.Errorf("AUDIT_RETENTION must not be negative")
+	}
+	if c.Audit.ATNAAddress != "" {
+		if !c.Audit.Enabled {
+			return fmt.Errorf("AUDIT_ATNA_ADDRESS requires AUDIT_ENABLED")
+		}
+		if _, _, err := net.SplitHostPort(c.Audit.ATNAAddress); err != nil {
+			return fmt.Errorf("invalid AUDIT_ATNA_ADDRESS %q: must be host:port", c.Audit.ATNAAddress)
+		}
+		if c.Audit.ATNAAppName == "" || len(c.Audit.ATNAAppName) > 48 || strings.ContainsAny(c.Audit.ATNAAppName, " \t\n") {
+			return fmt.Errorf("AUDIT_ATNA_APP_NAME must be 1 to 48 characters without spaces")
+		}
+	}
+	if (c.Audit.ATNACertFile == "") != (c.Audit.ATNAKeyFile == "") {
+		return fmt.Errorf("AUDIT_ATNA_TLS_CERT_FILE and AUDIT_ATNA_TLS_KEY_FILE must be set together")
+	}
+	if c.Cache.MetricsRetention < 0 {
+		return fmt.Errorf("CACHE_METRICS_RETENTION must not be negative")
+	}
+	if c.Cache.PinRefresh <= 0 || c.Cache.PinTTL <= 0 {
+		return fmt.Errorf("CACHE_PIN_REFRESH_INTERVAL and CACHE_PIN_TTL must be positive")
+	}
+	if c.Sync.Enabled {
+		if c.Sync.Interval <= 0 {
+			return fmt.Errorf("PACS_SYNC_INTERVAL must be positive")
+		}
+		if c.Sync.InitialDays < 1 || c.Sync.OverlapDays < 0 {
+			return fmt.Errorf("PACS_SYNC_INITIAL_DAYS must be at least 1 and PACS_SYNC_OVERLAP_DAYS must not be negative")
+		}
+	}
+	switch c.Sync.IndexMode {
+	case "off", "populate":
+	case "prefer":
+		if c.Sync.IndexMaxAge <= 0 {
+			return fmt.Errorf("STUDY_INDEX_MAX_AGE must be positive")
+		}
+	default:
+		return fmt.Errorf("STUDY_INDEX_MODE must be off, populate or prefer")
+	}
+	if c.Cache.VerifyInterval < 0 {
+		return fmt.Errorf("CACHE_VERIFY_INTERVAL must not be negative")
+	}
+	if c.Cache.VerifyLookback < 1 || c.Cache.VerifyLookback > 31 {
+		return fmt.Errorf("CACHE_VERIFY_LOOKBACK_DAYS must be between 1 and 31")
+	}
+	if c.Cache.DefaultTTL < 0 || c.Cache.MetadataTTL < 0 || c.Cache.QueryTTL < 0 || c.Cache.QueryStaleTTL < 0 || c.Cache.ThumbnailTTL < 0 || c.Cache.ExistsTTL < 0 {
+		return fmt.Errorf("cache TTLs must not be negative")
+	}
+	if c.Cache.NotFoundTTL < 0 {
+		return fmt.Errorf("CACHE_NOT_FOUND_TTL must not be negative")
+	}
+	if c.Cache.MaxInstanceMB <= 0 {
+		return fmt.Errorf("CACHE_MAX_INSTANCE_MB must be positive")
+	}
+	if c.Cache.ChunkSizeMB < 0 {
+		return fmt.Errorf("CACHE_CHUNK_SIZE_MB must not be negative")
+	}
+	if c.Cache.MemoryMaxMB < 0 || c.Cache.MemoryMaxEntries < 0 {
+		return fmt.Errorf("memory cache limits must not be negative")
+	}
+	for _, tier := range c.Cache.CompressTiers {
+		switch tier {
+		case "memory", "redis", "s3", "none":
+		default:
+			return fmt.Errorf("invalid compressed cache tier: %s", tier)
+		}
+	}
+	switch c.Cache.Encryption.Provider {
+	case "":
+	case "local":
+		key, err := base64.StdEncoding.DecodeString(c.Cache.Encryption.Key)
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("CACHE_ENCRYPTION_KEY must be the base64 encoding of 32 bytes")
+		}
+	case "aws-kms":
+		if c.Cache.Encryption.KMSKeyID == "" {
+			return fmt.Errorf("CACHE_KMS_KEY_ID is required for aws-kms cache encryption")
+		}
+	default:
+		return fmt.Errorf("invalid CACHE_ENCRYPTION: %s", c.Cache.Encryption.Provider)
+	}
+	if c.Auth.RBACEnabled && c.Auth.JWTSecret == "" {
+		return fmt.Errorf("AUTH_RBAC_ENABLED requires AUTH_JWT_SECRET")
+	}
+	if c.Auth.APIKeySigningSecret != "" && len(c.Auth.APIKeySigningSecret) < 32 {
+		return fmt.Errorf("API_KEY_SIGNING_SECRET must be at least 32 characters")
+	}
+	if c.Auth.StudyAuthzCacheTTL < 0 {
+		return fmt.Errorf("STUDY_AUTHZ_CACHE_TTL must not be negative")
+	}
+	switch c.Auth.CredentialEncryption.Provider {
+	case "":
+	case "local":
+		key, err := base64.StdEncoding.DecodeString(c.Auth.CredentialEncryption.Key)
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("CREDENTIAL_ENCRYPTION_KEY must be the base64 encoding of 32 bytes")
+		}
+	case "aws-kms":
+		if c.Auth.CredentialEncryption.KMSKeyID == "" {
+			return fmt.Errorf("CREDENTIAL_KMS_KEY_ID is required for aws-kms credential encryption")
+		}
+	default:
+		return fmt.Errorf("invalid CREDENTIAL_ENCRYPTION: %s", c.Auth.CredentialEncryption.Provider)
+	}
+	if c.Cache.TenantMaxMB < 0 || c.Cache.TenantMaxObjects < 0 {
+		return fmt.Errorf("tenant cache quotas must not be negative")
+	}
+	if c.Webhook.MaxAttempts <= 0 {
+		return fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be positive")
+	}
+	if c.Webhook.PACSCheckInterval < 0 {
+		return fmt.Errorf("PACS_HEALTH_CHECK_INTERVAL must not be negative")
+	}
+	if c.Webhook.PACSDegradeAfter < 0 {
+		return fmt.Errorf("PACS_DEGRADED_AFTER must not be negative")
+	}
+	if c.Webhook.PACSTestRetention < 0 {
+		return fmt.Errorf("PACS_TEST_HISTORY_RETENTION must not be negative")
+	}
+	if c.Jobs.Workers <= 0 || c.Jobs.InstanceAttempts <= 0 {
+		return fmt.Errorf("RETRIEVE_JOB_WORKERS and RETRIEVE_JOB_INSTANCE_ATTEMPTS must be positive")
+	}
+	if c.Failover.ErrorThreshold < 0 {
+		return fmt.Errorf("PACS_FAILOVER_ERROR_THRESHOLD must not be negative")
+	}
+	if c.Failover.Enabled && c.Failover.RetryInterval <= 0 {
+		return fmt.Errorf("PACS_FAILOVER_RETRY_INTERVAL must be positive")
+	}
+	switch c.EventBus.Driver {
+	case "":
+	case "kafka", "nats":
+		if len(c.EventBus.Brokers) == 0 {
+			return fmt.Errorf("EVENT_BUS_BROKERS is required for the %s event bus", c.EventBus.Driver)
+		}
+	default:
+		return fmt.Errorf("invalid EVENT_BUS_DRIVER: %s", c.EventBus.Driver)
+	}
+	if c.GRPC.Enabled && (c.GRPC.Port <= 0 || c.GRPC.Port > 65535) {
+		return fmt.Errorf("invalid gRPC port: %d", c.GRPC.Port)
+	}
+	if c.Export.LinkTTL <= 0 {
+		return fmt.Errorf("EXPORT_LINK_TTL must be positive")
+	}
+	if c.Export.LinkSecret != "" && len(c.Export.LinkSecret) < 32 {
+		return fmt.Errorf("EXPORT_LINK_SECRET must be at least 32 characters")
+	}
+	if c.Deident.Secret != "" && len(c.Deident.Secret) < 32 {
+		return fmt.Errorf("DEIDENT_SECRET must be at least 32 characters")
+	}
+	if c.RateLimit.Enabled {
+		if c.RateLimit.Store != "memory" && c.RateLimit.Store != "redis" {
+			return fmt.Errorf("invalid RATE_LIMIT_STORE: %s", c.RateLimit.Store)
+		}
+		if c.RateLimit.TenantRate < 0 || c.RateLimit.TenantBurst < 0 || c.RateLimit.PACSRate < 0 || c.RateLimit.PACSBurst < 0 {
+			return fmt.Errorf("rate limits must not be negative")
+		}
+		if c.RateLimit.PACSMaxWait < 0 {
+			return fmt.Errorf("PACS_RATE_LIMIT_MAX_WAIT must not be negative")
+		}
+	}
+	if c.Adapters.IdleTimeout < 0 {
+		return fmt.Errorf("PACS_ADAPTER_IDLE_TIMEOUT must not be negative")
+	}
+	if c.Adapters.VaultAddress != "" && c.Adapters.VaultToken == "" {
+		return fmt.Errorf("VAULT_TOKEN is required with VAULT_ADDR")
+	}
+	if c.Adapters.SecretsManagerAccessKeyID != "" && c.Adapters.SecretsManagerSecretAccessKey == "" {
+		return fmt.Errorf("SECRETS_MANAGER_SECRET_ACCESS_KEY is required with SECRETS_MANAGER_ACCESS_KEY_ID")
+	}
+	if c.Adapters.SecretRefreshInterval <= 0 {
+		return fmt.Errorf("SECRETS_REFRESH_INTERVAL must be positive")
+	}
+	if c.Breaker.Enabled {
+		if c.Breaker.Window <= 0 || c.Breaker.OpenDuration <= 0 {
+			return fmt.Errorf("CIRCUIT_BREAKER_WINDOW and CIRCUIT_BREAKER_OPEN_DURATION must be positive")
+		}
+		if c.Breaker.MinRequests <= 0 || c.Breaker.HalfOpenRequests <= 0 {
+			return fmt.Errorf("CIRCUIT_BREAKER_MIN_REQUESTS and CIRCUIT_BREAKER_HALF_OPEN_REQUESTS must be positive")
+		}
+		if c.Breaker.ErrorRate <= 0 || c.Breaker.ErrorRate > 100 {
+			return fmt.Errorf("CIRCUIT_BREAKER_ERROR_RATE must be a percentage between 1 and 100")
+		}
+	}
+	if c.SMART.GrantTTL <= 0 {
+		return fmt.Errorf("VIEWER_GRANT_TTL must be positive")
+	}
+	if strings.Contains(c.Viewer.LaunchURL, "{token}") && len(c.Viewer.TokenSecret) < 32 {
+		return fmt.Errorf("VIEWER_TOKEN_SECRET must be at least 32 characters when the viewer URL uses {token}")
+	}
 	return nil
 }
+
+// usesRedis reports whether the cache is kept in Redis, alone or as a tier
+func (c *Config) usesRedis() bool {
+	if !c.Cache.Enabled {
+		return false
+	}
+	return c.Cache.Type == "redis" || (c.Cache.Type == "tiered" && slices.Contains(c.Cache.Tiers, "redis"))
+}
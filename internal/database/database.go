@@ -80,9 +80,34 @@ func Connect(cfg Config) error {
 // AutoMigrate runs automatic migrations for all models
 func AutoMigrate() error {
 	return DB.AutoMigrate(
+		&models.Tenant{},
 		&models.PACSConfig{},
 		&models.AuditLog{},
+		&models.AuditChainHead{},
 		&models.CacheMetrics{},
+		&models.Workitem{},
+		&models.ObjectIndexEntry{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.ExportJob{},
+		&models.MetadataExportJob{},
+		&models.ExportDestination{},
+		&models.ViewerGrant{},
+		&models.APIKey{},
+		&models.APIKeyNonce{},
+		&models.PrefetchJob{},
+		&models.PinnedStudy{},
+		&models.PACSRoutingRule{},
+		&models.RetrieveJob{},
+		&models.TenantSettings{},
+		&models.ConnectionTest{},
+		&models.TenantUsage{},
+		&models.CacheVerification{},
+		&models.CacheDiscrepancy{},
+		&models.StudyIndexEntry{},
+		&models.SeriesIndexEntry{},
+		&models.InstanceIndexEntry{},
+		&models.StudySyncState{},
 	)
 }
 
@@ -0,0 +1,334 @@
+// Package deident de-identifies DICOM metadata and Part 10 objects following
+// the PS3.15 Basic Application Level Confidentiality Profile with site
+// overrides. UIDs and patient identifiers are replaced consistently within a
+// de-identification context, so studies of one patient stay linked for a
+// research project without revealing who the patient is.
+package deident
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/media"
+)
+
+const (
+	tagPatientIdentityRemoved = 0x00120062
+	tagDeidentificationMethod = 0x00120063
+
+	undefinedLength = 0xFFFFFFFF
+)
+
+// ErrInvalidObject is returned when a Part 10 object cannot be parsed
+var ErrInvalidObject = errors.New("invalid DICOM object")
+
+// Deidentifier de-identifies objects of one context. The same input always
+// yields the same UIDs and dummies for the same key and context.
+type Deidentifier struct {
+	profile *Profile
+	key     []byte
+}
+
+// New creates a de-identifier for a context; key is the deployment secret the
+// context's replacements are derived from
+func New(profile *Profile, key []byte, context string) *Deidentifier {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("deident:" + context))
+	return &Deidentifier{profile: profile, key: mac.Sum(nil)}
+}
+
+// UID returns the replacement of a UID, a 2.25 UID derived from it
+func (d *Deidentifier) UID(uid string) string {
+	uid = strings.TrimRight(uid, "\x00 ")
+	if uid == "" {
+		return ""
+	}
+	sum := d.sum("uid", uid)
+	return "2.25." + new(big.Int).SetBytes(sum[:16]).String()
+}
+
+// Dummy returns the replacement of a value of the given VR. Names and
+// identifiers get a pseudonym derived from the value; other values a fixed dummy.
+func (d *Deidentifier) Dummy(vr, value string) string {
+	switch vr {
+	case "DA":
+		return "19000101"
+	case "TM":
+		return "000000"
+	case "DT":
+		return "19000101000000"
+	case "UI":
+		return d.UID(value)
+	case "AS":
+		return "000Y"
+	case "DS", "IS":
+		return "0"
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	sum := d.sum(vr, value)
+	pseudonym := "ANON" + strings.ToUpper(hex.EncodeToString(sum[:6]))
+	if vr == "PN" {
+		return pseudonym + "^ANON"
+	}
+	return pseudonym
+}
+
+func (d *Deidentifier) sum(kind, value string) []byte {
+	mac := hmac.New(sha256.New, d.key)
+	mac.Write([]byte(kind + ":" + value))
+	return mac.Sum(nil)
+}
+
+// Attributes returns a de-identified copy of a DICOM JSON dataset, including
+// the items of its sequences
+func (d *Deidentifier) Attributes(attrs map[string]interface{}) map[string]interface{} {
+	out := d.attributes(attrs)
+	out[fmt.Sprintf("%08X", tagPatientIdentityRemoved)] = map[string]interface{}{"vr": "CS", "Value": []interface{}{"YES"}}
+	out[fmt.Sprintf("%08X", tagDeidentificationMethod)] = map[string]interface{}{"vr": "LO", "Value": []interface{}{Method}}
+	return out
+}
+
+func (d *Deidentifier) attributes(attrs map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(attrs))
+	for key, attr := range attrs {
+		tag, err := parseTag(strings.ToUpper(key))
+		if err != nil {
+			continue
+		}
+		element, ok := attr.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		action := d.profile.action(tag)
+		if action == ActionRemove {
+			continue
+		}
+
+		vr, _ := element["vr"].(string)
+		copied := make(map[string]interface{}, len(element))
+		for k, v := range element {
+			if k != "Value" {
+				copied[k] = v
+			}
+		}
+		values, _ := element["Value"].([]interface{})
+
+		switch {
+		case action == ActionEmpty:
+		case action == ActionUID || action == ActionDummy:
+			replaced := make([]interface{}, 0, len(values))
+			for _, value := range values {
+				replaced = append(replaced, d.jsonValue(action, vr, value))
+			}
+			copied["Value"] = replaced
+		case vr == "SQ":
+			items := make([]interface{}, 0, len(values))
+			for _, item := range values {
+				if nested, ok := item.(map[string]interface{}); ok {
+					items = append(items, d.attributes(nested))
+				}
+			}
+			copied["Value"] = items
+		case values != nil:
+			copied["Value"] = values
+		}
+		out[key] = copied
+	}
+	return out
+}
+
+// jsonValue replaces one value of a DICOM JSON attribute
+func (d *Deidentifier) jsonValue(action Action, vr string, value interface{}) interface{} {
+	if vr == "PN" {
+		name, _ := value.(map[string]interface{})
+		alphabetic, _ := name["Alphabetic"].(string)
+		return map[string]interface{}{"Alphabetic": d.Dummy(vr, alphabetic)}
+	}
+	text, _ := value.(string)
+	if action == ActionUID {
+		return d.UID(text)
+	}
+	return d.Dummy(vr, text)
+}
+
+// Instance de-identifies a DICOM Part 10 object. The file meta information is
+// rewritten with the replaced SOP Instance UID.
+func (d *Deidentifier) Instance(data []byte) ([]byte, error) {
+	obj, err := media.NewDCMObjFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidObject, err)
+	}
+	if obj.GetTransferSyntax() == nil {
+		return nil, fmt.Errorf("%w: unknown transfer syntax", ErrInvalidObject)
+	}
+
+	d.dataset(obj, obj.IsExplicitVR())
+	setTopLevel(obj, tagPatientIdentityRemoved, "CS", "YES")
+	setTopLevel(obj, tagDeidentificationMethod, "LO", Method)
+	return obj.WriteToBytes(), nil
+}
+
+// dataset de-identifies the elements of a parsed dataset. The parser keeps
+// the contents of sequences and items of undefined length inline, so those are
+// handled in this list; sequences and items of defined length are decoded,
+// de-identified and encoded again.
+func (d *Deidentifier) dataset(obj media.DcmObj, explicitVR bool) {
+	fragments := false // inside encapsulated pixel data
+	for i := 0; i < obj.TagCount(); i++ {
+		tag := obj.GetTagAt(i)
+
+		if tag.Group == 0xFFFE {
+			switch {
+			case tag.Element == 0xE0DD:
+				fragments = false
+			case tag.Element == 0xE000 && !fragments && tag.Length != 0 && tag.Length != undefinedLength:
+				d.sequence(tag, 0xFFFE, 0xE000, explicitVR)
+			}
+			continue
+		}
+		if fragments {
+			continue
+		}
+
+		vr := tag.VR
+		if !explicitVR {
+			vr = media.GetDictionaryVR(tag.Group, tag.Element)
+		}
+
+		// Group lengths would no longer match the rewritten groups
+		if tag.Element == 0x0000 {
+			obj.DelTag(i)
+			i--
+			continue
+		}
+
+		action := d.profile.action(uint32(tag.Group)<<16 | uint32(tag.Element))
+		switch action {
+		case ActionRemove:
+			end := i + 1
+			if tag.Length == undefinedLength {
+				end = delimiterAfter(obj, i)
+			}
+			for j := end - 1; j >= i; j-- {
+				obj.DelTag(j)
+			}
+			i--
+		case ActionEmpty:
+			if tag.Length == undefinedLength {
+				// An emptied sequence loses its items up to its delimiter
+				for j := delimiterAfter(obj, i) - 2; j > i; j-- {
+					obj.DelTag(j)
+				}
+			} else {
+				tag.Data, tag.Length = nil, 0
+			}
+		case ActionUID, ActionDummy:
+			if vr == "SQ" || tag.Length == undefinedLength {
+				continue
+			}
+			values := strings.Split(strings.TrimRight(string(tag.Data), "\x00 "), "\\")
+			for j, value := range values {
+				if action == ActionUID {
+					values[j] = d.UID(value)
+				} else {
+					values[j] = d.Dummy(vr, value)
+				}
+			}
+			setString(tag, vr, strings.Join(values, "\\"))
+		default:
+			switch {
+			case tag.Length != undefinedLength:
+				if vr == "SQ" && tag.Length > 0 {
+					d.sequence(tag, tag.Group, tag.Element, explicitVR)
+				}
+			case vr != "SQ":
+				// Encapsulated pixel data is followed by its fragments
+				fragments = true
+			}
+		}
+	}
+}
+
+// sequence de-identifies a sequence or item of defined length in place
+func (d *Deidentifier) sequence(tag *media.DcmTag, group, element uint16, explicitVR bool) {
+	seq := tag.ReadSeq(explicitVR)
+	seq.SetExplicitVR(explicitVR)
+	seq.SetBigEndian(tag.BigEndian)
+	d.dataset(seq, explicitVR)
+	tag.WriteSeq(group, element, seq)
+}
+
+// delimiterAfter returns the index after the Sequence Delimitation Item that
+// closes the sequence of undefined length at index i
+func delimiterAfter(obj media.DcmObj, i int) int {
+	depth := 0
+	for j := i + 1; j < obj.TagCount(); j++ {
+		tag := obj.GetTagAt(j)
+		switch {
+		case tag.Group == 0xFFFE && tag.Element == 0xE0DD:
+			if depth == 0 {
+				return j + 1
+			}
+			depth--
+		case tag.Group != 0xFFFE && tag.Length == undefinedLength:
+			depth++
+		}
+	}
+	return obj.TagCount()
+}
+
+// setTopLevel sets a string element of the dataset itself, keeping the
+// elements in ascending tag order
+func setTopLevel(obj media.DcmObj, key uint32, vr, value string) {
+	tag := &media.DcmTag{Group: uint16(key >> 16), Element: uint16(key), VR: vr}
+	setString(tag, vr, value)
+
+	depth := 0
+	for i := 0; i < obj.TagCount(); i++ {
+		existing := obj.GetTagAt(i)
+		if existing.Group == 0xFFFE {
+			if existing.Element == 0xE0DD {
+				depth--
+			}
+			continue
+		}
+		if depth == 0 {
+			current := uint32(existing.Group)<<16 | uint32(existing.Element)
+			if current == key {
+				obj.SetTag(i, tag)
+				return
+			}
+			if current > key {
+				obj.InsertTag(i, tag)
+				return
+			}
+		}
+		if existing.Length == undefinedLength {
+			depth++
+		}
+	}
+	obj.Add(tag)
+}
+
+// setString replaces the value of a string element, padded to an even length
+func setString(tag *media.DcmTag, vr, value string) {
+	data := []byte(value)
+	if len(data)%2 == 1 {
+		if vr == "UI" {
+			data = append(data, 0x00)
+		} else {
+			data = append(data, ' ')
+		}
+	}
+	tag.Data = data
+	tag.Length = uint32(len(data))
+}
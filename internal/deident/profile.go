@@ -0,0 +1,223 @@
+package deident
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Action is what de-identification does to an attribute, named after the
+// action codes of PS3.15 Table E.1-1
+type Action string
+
+const (
+	ActionRemove Action = "X" // remove the attribute
+	ActionEmpty  Action = "Z" // keep the attribute with an empty value
+	ActionDummy  Action = "D" // replace the value with a dummy, consistent per context
+	ActionUID    Action = "U" // replace the UID with one consistent per context
+	ActionKeep   Action = "K" // keep the attribute unchanged
+)
+
+// Method is recorded in De-identification Method (0012,0063) of de-identified objects
+const Method = "PS3.15 Basic Application Level Confidentiality Profile"
+
+// basicProfile is the subset of the PS3.15 Basic Application Level
+// Confidentiality Profile applied to the attributes connector clients meet.
+// Where the standard allows several actions the one keeping the attribute
+// present is used for Type 2 attributes, and the patient's name and ID get
+// dummies so studies of a patient stay linked within a context.
+var basicProfile = map[uint32]Action{
+	// UIDs
+	0x00080014: ActionUID, // Instance Creator UID
+	0x00080018: ActionUID, // SOP Instance UID
+	0x00081155: ActionUID, // Referenced SOP Instance UID
+	0x00081195: ActionUID, // Transaction UID
+	0x00181002: ActionUID, // Device UID
+	0x0020000D: ActionUID, // Study Instance UID
+	0x0020000E: ActionUID, // Series Instance UID
+	0x00200052: ActionUID, // Frame of Reference UID
+	0x00200200: ActionUID, // Synchronization Frame of Reference UID
+	0x00209161: ActionUID, // Concatenation UID
+	0x00209164: ActionUID, // Dimension Organization UID
+	0x00620021: ActionUID, // Tracking UID
+	0x00880140: ActionUID, // Storage Media File-set UID
+	0x30060024: ActionUID, // Referenced Frame of Reference UID
+	0x300600C2: ActionUID, // Related Frame of Reference UID
+	0x0040A124: ActionUID, // UID
+	0x0040A171: ActionUID, // Observation UID
+
+	// Patient
+	0x00100010: ActionDummy,  // Patient's Name
+	0x00100020: ActionDummy,  // Patient ID
+	0x00100021: ActionRemove, // Issuer of Patient ID
+	0x00100030: ActionEmpty,  // Patient's Birth Date
+	0x00100032: ActionRemove, // Patient's Birth Time
+	0x00100040: ActionEmpty,  // Patient's Sex
+	0x00101000: ActionRemove, // Other Patient IDs
+	0x00101001: ActionRemove, // Other Patient Names
+	0x00101002: ActionRemove, // Other Patient IDs Sequence
+	0x00101005: ActionRemove, // Patient's Birth Name
+	0x00101010: ActionRemove, // Patient's Age
+	0x00101020: ActionRemove, // Patient's Size
+	0x00101030: ActionRemove, // Patient's Weight
+	0x00101040: ActionRemove, // Patient's Address
+	0x00101060: ActionRemove, // Patient's Mother's Birth Name
+	0x00101080: ActionRemove, // Military Rank
+	0x00101081: ActionRemove, // Branch of Service
+	0x00101090: ActionRemove, // Medical Record Locator
+	0x00102000: ActionRemove, // Medical Alerts
+	0x00102110: ActionRemove, // Allergies
+	0x00102154: ActionRemove, // Patient's Telephone Numbers
+	0x00102160: ActionRemove, // Ethnic Group
+	0x00102180: ActionRemove, // Occupation
+	0x001021A0: ActionRemove, // Smoking Status
+	0x001021B0: ActionRemove, // Additional Patient History
+	0x001021C0: ActionRemove, // Pregnancy Status
+	0x001021D0: ActionRemove, // Last Menstrual Date
+	0x00104000: ActionRemove, // Patient Comments
+	0x00380010: ActionRemove, // Admission ID
+	0x00380300: ActionRemove, // Current Patient Location
+	0x00380500: ActionRemove, // Patient State
+
+	// Study, series and instance
+	0x00080012: ActionRemove, // Instance Creation Date
+	0x00080013: ActionRemove, // Instance Creation Time
+	0x00080020: ActionEmpty,  // Study Date
+	0x00080021: ActionRemove, // Series Date
+	0x00080022: ActionRemove, // Acquisition Date
+	0x00080023: ActionEmpty,  // Content Date
+	0x0008002A: ActionRemove, // Acquisition DateTime
+	0x00080030: ActionEmpty,  // Study Time
+	0x00080031: ActionRemove, // Series Time
+	0x00080032: ActionRemove, // Acquisition Time
+	0x00080033: ActionEmpty,  // Content Time
+	0x00080050: ActionEmpty,  // Accession Number
+	0x00080080: ActionRemove, // Institution Name
+	0x00080081: ActionRemove, // Institution Address
+	0x00080090: ActionEmpty,  // Referring Physician's Name
+	0x00080092: ActionRemove, // Referring Physician's Address
+	0x00080094: ActionRemove, // Referring Physician's Telephone Numbers
+	0x00080096: ActionRemove, // Referring Physician Identification Sequence
+	0x00080201: ActionRemove, // Timezone Offset From UTC
+	0x00081010: ActionRemove, // Station Name
+	0x00081030: ActionRemove, // Study Description
+	0x0008103E: ActionRemove, // Series Description
+	0x00081040: ActionRemove, // Institutional Department Name
+	0x00081048: ActionRemove, // Physician(s) of Record
+	0x00081049: ActionRemove, // Physician(s) of Record Identification Sequence
+	0x00081050: ActionRemove, // Performing Physician's Name
+	0x00081052: ActionRemove, // Performing Physician Identification Sequence
+	0x00081060: ActionRemove, // Name of Physician(s) Reading Study
+	0x00081070: ActionRemove, // Operators' Name
+	0x00081072: ActionRemove, // Operator Identification Sequence
+	0x00081080: ActionRemove, // Admitting Diagnoses Description
+	0x00081110: ActionRemove, // Referenced Study Sequence
+	0x00081111: ActionRemove, // Referenced Performed Procedure Step Sequence
+	0x00081120: ActionRemove, // Referenced Patient Sequence
+	0x00082111: ActionRemove, // Derivation Description
+	0x00181000: ActionRemove, // Device Serial Number
+	0x00181004: ActionRemove, // Plate ID
+	0x00181005: ActionRemove, // Generator ID
+	0x00181007: ActionRemove, // Cassette ID
+	0x00181008: ActionRemove, // Gantry ID
+	0x00181030: ActionRemove, // Protocol Name
+	0x00181400: ActionRemove, // Acquisition Device Processing Description
+	0x00200010: ActionEmpty,  // Study ID
+	0x00204000: ActionRemove, // Image Comments
+	0x00321032: ActionRemove, // Requesting Physician
+	0x00321033: ActionRemove, // Requesting Service
+	0x00321060: ActionRemove, // Requested Procedure Description
+	0x00321070: ActionRemove, // Requested Contrast Agent
+	0x00324000: ActionRemove, // Study Comments
+	0x00400241: ActionRemove, // Performed Station AE Title
+	0x00400242: ActionRemove, // Performed Station Name
+	0x00400243: ActionRemove, // Performed Location
+	0x00400244: ActionRemove, // Performed Procedure Step Start Date
+	0x00400245: ActionRemove, // Performed Procedure Step Start Time
+	0x00400253: ActionRemove, // Performed Procedure Step ID
+	0x00400254: ActionRemove, // Performed Procedure Step Description
+	0x00400275: ActionRemove, // Request Attributes Sequence
+	0x00400280: ActionRemove, // Comments on the Performed Procedure Step
+	0x00401001: ActionRemove, // Requested Procedure ID
+	0x0040A730: ActionRemove, // Content Sequence
+}
+
+// Profile is the Basic Profile with a site's overrides
+type Profile struct {
+	overrides map[uint32]Action
+}
+
+// NewProfile returns the Basic Profile with the given overrides, as tag
+// (GGGGEEEE) to action
+func NewProfile(overrides map[string]Action) (*Profile, error) {
+	p := &Profile{overrides: make(map[uint32]Action, len(overrides))}
+	for key, action := range overrides {
+		tag, err := parseTag(key)
+		if err != nil {
+			return nil, err
+		}
+		switch action {
+		case ActionRemove, ActionEmpty, ActionDummy, ActionUID, ActionKeep:
+		default:
+			return nil, fmt.Errorf("invalid de-identification action %q for %s", action, key)
+		}
+		p.overrides[tag] = action
+	}
+	return p, nil
+}
+
+// ParseOverrides parses site overrides written as comma-separated TAG=ACTION
+// pairs, e.g. "00080080=K,00181030=K,00204000=X"
+func ParseOverrides(value string) (map[string]Action, error) {
+	overrides := make(map[string]Action)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, action, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid de-identification override %q", pair)
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if _, err := parseTag(key); err != nil {
+			return nil, err
+		}
+		overrides[key] = Action(strings.ToUpper(strings.TrimSpace(action)))
+	}
+	return overrides, nil
+}
+
+// action returns what happens to an attribute. Attributes the profile does not
+// name are kept, except private attributes and overlay and curve data, which
+// are removed.
+func (p *Profile) action(tag uint32) Action {
+	if action, ok := p.overrides[tag]; ok {
+		return action
+	}
+	if action, ok := basicProfile[tag]; ok {
+		return action
+	}
+
+	group, element := uint16(tag>>16), uint16(tag)
+	switch {
+	case group%2 == 1:
+		return ActionRemove
+	case group&0xFF00 == 0x5000:
+		return ActionRemove
+	case group&0xFF00 == 0x6000 && (element == 0x3000 || element == 0x4000):
+		return ActionRemove
+	}
+	return ActionKeep
+}
+
+func parseTag(key string) (uint32, error) {
+	if len(key) != 8 {
+		return 0, fmt.Errorf("invalid tag %q: expected GGGGEEEE", key)
+	}
+	tag, err := strconv.ParseUint(key, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tag %q: expected GGGGEEEE", key)
+	}
+	return uint32(tag), nil
+}
@@ -0,0 +1,351 @@
+// Package dicomdir writes the DICOMDIR of a DICOM File-set (PS3.10, PS3.3
+// F.3 Basic Directory IOD) for media exports such as patient CD replacements.
+// The directory is encoded in Explicit VR Little Endian with a
+// PATIENT/STUDY/SERIES/IMAGE record hierarchy.
+package dicomdir
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MediaStorageDirectoryStorage is the SOP class of a DICOMDIR
+	MediaStorageDirectoryStorage = "1.2.840.10008.1.3.10"
+	// ExplicitVRLittleEndian is the transfer syntax the DICOMDIR is written in
+	ExplicitVRLittleEndian = "1.2.840.10008.1.2.1"
+
+	implementationClassUID    = "2.25.179349743863566132743692342662904249395"
+	implementationVersionName = "RISCONNECTOR"
+
+	preambleSize = 128
+	// maxFileIDComponent is the longest component of a Referenced File ID
+	maxFileIDComponent = 8
+)
+
+// FileSet describes the content of a File-set
+type FileSet struct {
+	ID          string // File-set ID; up to 16 characters
+	SOPInstance string // Media Storage SOP Instance UID of the DICOMDIR
+	Patients    []*Patient
+}
+
+// Patient is a PATIENT directory record
+type Patient struct {
+	PatientID   string
+	PatientName string
+	Studies     []*Study
+}
+
+// Study is a STUDY directory record
+type Study struct {
+	StudyInstanceUID string
+	StudyDate        string
+	StudyTime        string
+	StudyDescription string
+	StudyID          string
+	AccessionNumber  string
+	Series           []*Series
+}
+
+// Series is a SERIES directory record
+type Series struct {
+	SeriesInstanceUID string
+	Modality          string
+	SeriesNumber      int
+	Images            []*Image
+}
+
+// Image is an IMAGE directory record referencing one file of the File-set
+type Image struct {
+	FileID            []string // path components relative to the DICOMDIR, e.g. DICOM, S0001, I0001
+	SOPClassUID       string
+	SOPInstanceUID    string
+	TransferSyntaxUID string
+	InstanceNumber    int
+}
+
+// ValidFileID reports whether path components form a valid Referenced File ID:
+// one to eight components of at most eight uppercase letters, digits or underscores
+func ValidFileID(components []string) bool {
+	if len(components) == 0 || len(components) > 8 {
+		return false
+	}
+	for _, c := range components {
+		if c == "" || len(c) > maxFileIDComponent {
+			return false
+		}
+		for _, r := range c {
+			if !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '_' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// record is a directory record with its encoded attributes and its position in the file
+type record struct {
+	recordType string
+	attributes []byte
+	children   []*record
+	offset     uint32
+}
+
+// size is the encoded size of the record item
+func (r *record) size() int {
+	// Item tag and length, the two offsets, the in-use flag and the record type
+	return 8 + 12 + 10 + 12 + len(encodeElement(0x0004, 0x1430, "CS", r.recordType)) + len(r.attributes)
+}
+
+// Encode returns the DICOMDIR as a DICOM Part 10 file
+func (fs *FileSet) Encode() ([]byte, error) {
+	roots, err := fs.records()
+	if err != nil {
+		return nil, err
+	}
+
+	meta := encodeMeta(fs.SOPInstance)
+
+	// Everything before the first record has a fixed size, so the record
+	// positions are known before any offset is written
+	var header bytes.Buffer
+	header.Write(encodeElement(0x0004, 0x1130, "CS", fs.ID))
+	header.Write(encodeUL(0x0004, 0x1200, 0))
+	header.Write(encodeUL(0x0004, 0x1202, 0))
+	header.Write(encodeUS(0x0004, 0x1212, 0))
+	header.Write(sequenceHeader(0x0004, 0x1220))
+
+	position := uint32(preambleSize + 4 + len(meta) + header.Len())
+	var ordered []*record
+	var place func(records []*record)
+	place = func(records []*record) {
+		for _, r := range records {
+			r.offset = position
+			position += uint32(r.size())
+			ordered = append(ordered, r)
+			place(r.children)
+		}
+	}
+	place(roots)
+
+	var first, last uint32
+	if len(roots) > 0 {
+		first, last = roots[0].offset, roots[len(roots)-1].offset
+	}
+
+	var out bytes.Buffer
+	out.Write(make([]byte, preambleSize))
+	out.WriteString("DICM")
+	out.Write(meta)
+	out.Write(encodeElement(0x0004, 0x1130, "CS", fs.ID))
+	out.Write(encodeUL(0x0004, 0x1200, first))
+	out.Write(encodeUL(0x0004, 0x1202, last))
+	out.Write(encodeUS(0x0004, 0x1212, 0))
+	out.Write(sequenceHeader(0x0004, 0x1220))
+
+	next := nextSiblings(roots, nil)
+	for _, r := range ordered {
+		var lower uint32
+		if len(r.children) > 0 {
+			lower = r.children[0].offset
+		}
+
+		var item bytes.Buffer
+		item.Write(encodeUL(0x0004, 0x1400, next[r]))
+		item.Write(encodeUS(0x0004, 0x1410, 0xFFFF))
+		item.Write(encodeUL(0x0004, 0x1420, lower))
+		item.Write(encodeElement(0x0004, 0x1430, "CS", r.recordType))
+		item.Write(r.attributes)
+
+		writeTag(&out, 0xFFFE, 0xE000)
+		binary.Write(&out, binary.LittleEndian, uint32(item.Len()))
+		out.Write(item.Bytes())
+	}
+
+	// Sequence delimitation item
+	writeTag(&out, 0xFFFE, 0xE0DD)
+	binary.Write(&out, binary.LittleEndian, uint32(0))
+
+	return out.Bytes(), nil
+}
+
+// nextSiblings maps each record to the offset of the next record at its level, 0 for the last
+func nextSiblings(records []*record, next map[*record]uint32) map[*record]uint32 {
+	if next == nil {
+		next = make(map[*record]uint32)
+	}
+	for i, r := range records {
+		if i+1 < len(records) {
+			next[r] = records[i+1].offset
+		}
+		nextSiblings(r.children, next)
+	}
+	return next
+}
+
+// records builds the record hierarchy with the attributes of each record encoded
+func (fs *FileSet) records() ([]*record, error) {
+	var patients []*record
+	for _, p := range fs.Patients {
+		patient := &record{recordType: "PATIENT", attributes: encodeAttributes([]element{
+			textElement(0x0010, 0x0010, "PN", p.PatientName),
+			textElement(0x0010, 0x0020, "LO", p.PatientID),
+		})}
+
+		for _, st := range p.Studies {
+			study := &record{recordType: "STUDY", attributes: encodeAttributes([]element{
+				textElement(0x0008, 0x0020, "DA", st.StudyDate),
+				textElement(0x0008, 0x0030, "TM", st.StudyTime),
+				textElement(0x0008, 0x0050, "SH", st.AccessionNumber),
+				textElement(0x0008, 0x1030, "LO", st.StudyDescription),
+				textElement(0x0020, 0x000D, "UI", st.StudyInstanceUID),
+				textElement(0x0020, 0x0010, "SH", st.StudyID),
+			})}
+
+			for _, se := range st.Series {
+				series := &record{recordType: "SERIES", attributes: encodeAttributes([]element{
+					textElement(0x0008, 0x0060, "CS", se.Modality),
+					textElement(0x0020, 0x000E, "UI", se.SeriesInstanceUID),
+					textElement(0x0020, 0x0011, "IS", strconv.Itoa(se.SeriesNumber)),
+				})}
+
+				for _, img := range se.Images {
+					if !ValidFileID(img.FileID) {
+						return nil, fmt.Errorf("invalid referenced file ID %q", strings.Join(img.FileID, `\`))
+					}
+					series.children = append(series.children, &record{recordType: "IMAGE", attributes: encodeAttributes([]element{
+						textElement(0x0004, 0x1500, "CS", strings.Join(img.FileID, `\`)),
+						textElement(0x0004, 0x1510, "UI", img.SOPClassUID),
+						textElement(0x0004, 0x1511, "UI", img.SOPInstanceUID),
+						textElement(0x0004, 0x1512, "UI", img.TransferSyntaxUID),
+						textElement(0x0020, 0x0013, "IS", strconv.Itoa(img.InstanceNumber)),
+					})})
+				}
+				study.children = append(study.children, series)
+			}
+			patient.children = append(patient.children, study)
+		}
+		patients = append(patients, patient)
+	}
+	return patients, nil
+}
+
+// element is a record attribute to encode
+type element struct {
+	group, elem uint16
+	vr          string
+	value       string
+}
+
+func textElement(group, elem uint16, vr, value string) element {
+	return element{group: group, elem: elem, vr: vr, value: value}
+}
+
+// encodeAttributes encodes the attributes of a record in ascending tag order,
+// declaring UTF-8 when a value is not plain ASCII
+func encodeAttributes(elements []element) []byte {
+	for _, e := range elements {
+		if !isASCII(e.value) {
+			elements = append(elements, textElement(0x0008, 0x0005, "CS", "ISO_IR 192"))
+			break
+		}
+	}
+	sort.SliceStable(elements, func(i, j int) bool {
+		if elements[i].group != elements[j].group {
+			return elements[i].group < elements[j].group
+		}
+		return elements[i].elem < elements[j].elem
+	})
+
+	var buf bytes.Buffer
+	for _, e := range elements {
+		buf.Write(encodeElement(e.group, e.elem, e.vr, e.value))
+	}
+	return buf.Bytes()
+}
+
+// encodeMeta encodes the File Meta Information group with its group length
+func encodeMeta(sopInstanceUID string) []byte {
+	var group bytes.Buffer
+	writeTag(&group, 0x0002, 0x0001)
+	group.WriteString("OB")
+	group.Write([]byte{0, 0})
+	binary.Write(&group, binary.LittleEndian, uint32(2))
+	group.Write([]byte{0x00, 0x01})
+	group.Write(encodeElement(0x0002, 0x0002, "UI", MediaStorageDirectoryStorage))
+	group.Write(encodeElement(0x0002, 0x0003, "UI", sopInstanceUID))
+	group.Write(encodeElement(0x0002, 0x0010, "UI", ExplicitVRLittleEndian))
+	group.Write(encodeElement(0x0002, 0x0012, "UI", implementationClassUID))
+	group.Write(encodeElement(0x0002, 0x0013, "SH", implementationVersionName))
+
+	var meta bytes.Buffer
+	meta.Write(encodeUL(0x0002, 0x0000, uint32(group.Len())))
+	meta.Write(group.Bytes())
+	return meta.Bytes()
+}
+
+// encodeElement encodes a string value with a short explicit VR header, padded
+// to an even length with NUL for UIDs and spaces otherwise
+func encodeElement(group, elem uint16, vr, value string) []byte {
+	if len(value)%2 != 0 {
+		if vr == "UI" {
+			value += "\x00"
+		} else {
+			value += " "
+		}
+	}
+
+	var buf bytes.Buffer
+	writeTag(&buf, group, elem)
+	buf.WriteString(vr)
+	binary.Write(&buf, binary.LittleEndian, uint16(len(value)))
+	buf.WriteString(value)
+	return buf.Bytes()
+}
+
+func encodeUL(group, elem uint16, value uint32) []byte {
+	var buf bytes.Buffer
+	writeTag(&buf, group, elem)
+	buf.WriteString("UL")
+	binary.Write(&buf, binary.LittleEndian, uint16(4))
+	binary.Write(&buf, binary.LittleEndian, value)
+	return buf.Bytes()
+}
+
+func encodeUS(group, elem uint16, value uint16) []byte {
+	var buf bytes.Buffer
+	writeTag(&buf, group, elem)
+	buf.WriteString("US")
+	binary.Write(&buf, binary.LittleEndian, uint16(2))
+	binary.Write(&buf, binary.LittleEndian, value)
+	return buf.Bytes()
+}
+
+// sequenceHeader starts a sequence of undefined length
+func sequenceHeader(group, elem uint16) []byte {
+	var buf bytes.Buffer
+	writeTag(&buf, group, elem)
+	buf.WriteString("SQ")
+	buf.Write([]byte{0, 0})
+	binary.Write(&buf, binary.LittleEndian, uint32(0xFFFFFFFF))
+	return buf.Bytes()
+}
+
+func writeTag(buf *bytes.Buffer, group, elem uint16) {
+	binary.Write(buf, binary.LittleEndian, group)
+	binary.Write(buf, binary.LittleEndian, elem)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
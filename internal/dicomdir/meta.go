@@ -0,0 +1,68 @@
+package dicomdir
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// MetaPeekSize is enough of a Part 10 file to read its File Meta Information
+const MetaPeekSize = 16 << 10
+
+// ErrNotPart10 is returned for data without a DICOM Part 10 preamble and prefix
+var ErrNotPart10 = errors.New("not a DICOM Part 10 file")
+
+// FileMeta holds the identifying attributes of a Part 10 file's meta information
+type FileMeta struct {
+	SOPClassUID       string
+	SOPInstanceUID    string
+	TransferSyntaxUID string
+}
+
+// ReadFileMeta reads the File Meta Information group (always Explicit VR
+// Little Endian) from the start of a Part 10 file. Attributes beyond the end of
+// data are left empty.
+func ReadFileMeta(data []byte) (FileMeta, error) {
+	var meta FileMeta
+	if len(data) < preambleSize+4 || string(data[preambleSize:preambleSize+4]) != "DICM" {
+		return meta, ErrNotPart10
+	}
+
+	pos := preambleSize + 4
+	for pos+8 <= len(data) {
+		group := binary.LittleEndian.Uint16(data[pos:])
+		elem := binary.LittleEndian.Uint16(data[pos+2:])
+		if group != 0x0002 {
+			break
+		}
+
+		vr := string(data[pos+4 : pos+6])
+		var length, header int
+		switch vr {
+		case "OB", "OW", "OF", "SQ", "UT", "UN":
+			if pos+12 > len(data) {
+				return meta, nil
+			}
+			length = int(binary.LittleEndian.Uint32(data[pos+8:]))
+			header = 12
+		default:
+			length = int(binary.LittleEndian.Uint16(data[pos+6:]))
+			header = 8
+		}
+		if length < 0 || pos+header+length > len(data) {
+			return meta, nil
+		}
+
+		value := strings.TrimRight(string(data[pos+header:pos+header+length]), "\x00 ")
+		switch elem {
+		case 0x0002:
+			meta.SOPClassUID = value
+		case 0x0003:
+			meta.SOPInstanceUID = value
+		case 0x0010:
+			meta.TransferSyntaxUID = value
+		}
+		pos += header + length
+	}
+	return meta, nil
+}
@@ -0,0 +1,266 @@
+// Package dicomquery normalizes query matching values into DICOM syntax so that
+// every adapter receives the same matching keys regardless of how the client
+// wrote them.
+package dicomquery
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	dicomDateLayout = "20060102"
+	isoDateLayout   = "2006-01-02"
+)
+
+// NormalizeDate converts a date matching value into DA syntax. It accepts single
+// dates and ranges in DICOM form (20240501, 20240501-20240502, 20240501-, -20240502)
+// or ISO 8601 form, where ranges are separated by a slash (2024-05-01/2024-05-02).
+func NormalizeDate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	var start, end string
+	isRange := false
+	switch {
+	case strings.Contains(value, "/"):
+		start, end, _ = strings.Cut(value, "/")
+		isRange = true
+	case strings.Count(value, "-") == 1 && !strings.Contains(value, ":") && len(value) != len(isoDateLayout):
+		start, end, _ = strings.Cut(value, "-")
+		isRange = true
+	default:
+		start = value
+	}
+
+	startDA, err := normalizeDateValue(start)
+	if err != nil {
+		return "", err
+	}
+	if !isRange {
+		if startDA == "" {
+			return "", fmt.Errorf("invalid date: %s", value)
+		}
+		return startDA, nil
+	}
+
+	endDA, err := normalizeDateValue(end)
+	if err != nil {
+		return "", err
+	}
+	if startDA == "" && endDA == "" {
+		return "", fmt.Errorf("invalid date range: %s", value)
+	}
+	if startDA != "" && endDA != "" && startDA > endDA {
+		return "", fmt.Errorf("date range start is after its end: %s", value)
+	}
+
+	return startDA + "-" + endDA, nil
+}
+
+func normalizeDateValue(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	for _, layout := range []string{dicomDateLayout, isoDateLayout} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(dicomDateLayout), nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid date: %s", value)
+}
+
+// NormalizeTime converts a time matching value into TM syntax. It accepts single
+// times and ranges in DICOM form (0800, 080000, 0800-1200) or with colons
+// (08:00, 08:00:00-12:00).
+func NormalizeTime(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	start, end, isRange := strings.Cut(value, "-")
+
+	startTM, err := normalizeTimeValue(start)
+	if err != nil {
+		return "", err
+	}
+	if !isRange {
+		if startTM == "" {
+			return "", fmt.Errorf("invalid time: %s", value)
+		}
+		return startTM, nil
+	}
+
+	endTM, err := normalizeTimeValue(end)
+	if err != nil {
+		return "", err
+	}
+	if startTM == "" && endTM == "" {
+		return "", fmt.Errorf("invalid time range: %s", value)
+	}
+
+	return startTM + "-" + endTM, nil
+}
+
+func normalizeTimeValue(value string) (string, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ":", "")
+	if value == "" {
+		return "", nil
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if len(whole) != 2 && len(whole) != 4 && len(whole) != 6 || !isDigits(whole) || !isDigits(fraction) || len(fraction) > 6 {
+		return "", fmt.Errorf("invalid time: %s", value)
+	}
+
+	hour, minute, second := atoi2(whole[0:2]), 0, 0
+	if len(whole) >= 4 {
+		minute = atoi2(whole[2:4])
+	}
+	if len(whole) == 6 {
+		second = atoi2(whole[4:6])
+	}
+	// TM allows a leap second (60)
+	if hour > 23 || minute > 59 || second > 60 {
+		return "", fmt.Errorf("invalid time: %s", value)
+	}
+
+	return value, nil
+}
+
+// ISODate formats a returned DA value as an ISO 8601 date (2024-05-01). Values
+// that are not a complete date give an empty string.
+func ISODate(da string) string {
+	t, err := time.Parse(dicomDateLayout, strings.TrimSpace(da))
+	if err != nil {
+		return ""
+	}
+	return t.Format(isoDateLayout)
+}
+
+// ISOTime formats a returned TM value as an ISO 8601 time of the same precision,
+// so 0830 becomes 08:30 and 083015.25 becomes 08:30:15.25. Values that are not a
+// valid time give an empty string.
+func ISOTime(tm string) string {
+	value, err := normalizeTimeValue(strings.TrimSpace(tm))
+	if err != nil || value == "" {
+		return ""
+	}
+
+	whole, fraction, hasFraction := strings.Cut(value, ".")
+	parts := []string{whole[0:2]}
+	for i := 2; i < len(whole); i += 2 {
+		parts = append(parts, whole[i:i+2])
+	}
+	iso := strings.Join(parts, ":")
+	if hasFraction && fraction != "" && len(whole) == 6 {
+		iso += "." + fraction
+	}
+	return iso
+}
+
+// DateTimeRange is a combined StudyDate/StudyTime window. A zero bound is open.
+type DateTimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// CombinedRange interprets normalized DA and TM range values as a single datetime
+// window, as defined for combined date and time range matching in PS3.4 C.2.2.2.5.
+// It returns false when the values do not form a window spanning several days,
+// in which case independent date and time matching already gives the same result.
+func CombinedRange(date, tm string) (DateTimeRange, bool) {
+	startDate, endDate, dateRange := strings.Cut(date, "-")
+	startTime, endTime, timeRange := strings.Cut(tm, "-")
+	if !dateRange || !timeRange || (startDate != "" && startDate == endDate) {
+		return DateTimeRange{}, false
+	}
+
+	var r DateTimeRange
+	if startDate != "" {
+		r.Start = parseDateTime(startDate, startTime, false)
+	}
+	if endDate != "" {
+		r.End = parseDateTime(endDate, endTime, true)
+	}
+	return r, true
+}
+
+// Contains reports whether a DA/TM pair falls within the window. Objects without
+// a time are matched on their date alone.
+func (r DateTimeRange) Contains(date, tm string) bool {
+	if date == "" {
+		return false
+	}
+
+	start := parseDateTime(date, tm, false)
+	end := start
+	if tm == "" {
+		end = parseDateTime(date, "", true)
+	}
+	if start.IsZero() {
+		return false
+	}
+
+	if !r.Start.IsZero() && end.Before(r.Start) {
+		return false
+	}
+	if !r.End.IsZero() && start.After(r.End) {
+		return false
+	}
+	return true
+}
+
+// parseDateTime combines a DA and a TM value. A missing or partial time is
+// completed to the start of the period, or to its end when upper is set.
+func parseDateTime(date, tm string, upper bool) time.Time {
+	day, err := time.Parse(dicomDateLayout, date)
+	if err != nil {
+		return time.Time{}
+	}
+
+	whole, _, _ := strings.Cut(strings.TrimSpace(tm), ".")
+	hour, minute, second := 0, 0, 0
+	switch {
+	case len(whole) >= 2 && isDigits(whole):
+		hour = atoi2(whole[0:2])
+		if len(whole) >= 4 {
+			minute = atoi2(whole[2:4])
+		} else if upper {
+			minute = 59
+		}
+		if len(whole) >= 6 {
+			second = atoi2(whole[4:6])
+		} else if upper {
+			second = 59
+		}
+	case upper:
+		hour, minute, second = 23, 59, 59
+	}
+
+	t := day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second)
+	if upper {
+		t = t.Add(time.Second - time.Nanosecond)
+	}
+	return t
+}
+
+func isDigits(s string) bool {
+	for _, char := range s {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func atoi2(s string) int {
+	return int(s[0]-'0')*10 + int(s[1]-'0')
+}
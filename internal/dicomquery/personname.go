@@ -0,0 +1,138 @@
+package dicomquery
+
+import (
+	"strings"
+)
+
+// PN component groups are separated by '=' (alphabetic, ideographic, phonetic)
+// and components within a group by '^' (family, given, middle, prefix, suffix)
+const (
+	pnGroupSeparator     = "="
+	pnComponentSeparator = "^"
+	pnMaxComponents      = 5
+)
+
+// PersonNameQuery builds a PN matching value from a user supplied name. It accepts
+// caret separated components (Smith^John) or the "Family, Given Middle" form,
+// trims whitespace and, unless the value already contains wildcards, appends '*'
+// to the last component so that partial names match (Smith^Jo matches Smith^John^A).
+func PersonNameQuery(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "*" {
+		return value
+	}
+
+	var components []string
+	if !strings.Contains(value, pnComponentSeparator) && strings.Contains(value, ",") {
+		family, rest, _ := strings.Cut(value, ",")
+		components = append([]string{family}, strings.Fields(rest)...)
+	} else {
+		// Only the alphabetic group is used for matching
+		alphabetic, _, _ := strings.Cut(value, pnGroupSeparator)
+		components = strings.Split(alphabetic, pnComponentSeparator)
+	}
+
+	components = trimComponents(components)
+	if len(components) == 0 {
+		return ""
+	}
+
+	if !strings.ContainsAny(value, "*?") {
+		components[len(components)-1] += "*"
+	}
+
+	return strings.Join(components, pnComponentSeparator)
+}
+
+// MatchPersonName reports whether a PN value matches a PersonNameQuery pattern.
+// Matching is case-insensitive, applied per component against the alphabetic
+// group, and a pattern with fewer components leaves the remaining ones unconstrained.
+func MatchPersonName(pattern, name string) bool {
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+
+	alphabetic, _, _ := strings.Cut(name, pnGroupSeparator)
+	nameComponents := strings.Split(strings.ToUpper(alphabetic), pnComponentSeparator)
+	patternComponents := strings.Split(strings.ToUpper(pattern), pnComponentSeparator)
+
+	for i, p := range patternComponents {
+		component := ""
+		if i < len(nameComponents) {
+			component = strings.TrimSpace(nameComponents[i])
+		}
+		if !matchWildcard(p, component) {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatPersonName normalizes a returned PN value for display: whitespace around
+// components is trimmed and empty trailing components and groups are dropped,
+// so "SMITH ^JOHN^^^" becomes "SMITH^JOHN".
+func FormatPersonName(name string) string {
+	groups := strings.Split(name, pnGroupSeparator)
+	for i, group := range groups {
+		groups[i] = strings.Join(trimComponents(strings.Split(group, pnComponentSeparator)), pnComponentSeparator)
+	}
+	for len(groups) > 0 && groups[len(groups)-1] == "" {
+		groups = groups[:len(groups)-1]
+	}
+	return strings.Join(groups, pnGroupSeparator)
+}
+
+// PersonNameComponents splits the alphabetic group of a returned PN value into
+// its family, given, middle, prefix and suffix components, trimmed. Components
+// the value does not have are empty.
+func PersonNameComponents(name string) [pnMaxComponents]string {
+	var components [pnMaxComponents]string
+	alphabetic, _, _ := strings.Cut(name, pnGroupSeparator)
+	copy(components[:], trimComponents(strings.Split(alphabetic, pnComponentSeparator)))
+	return components
+}
+
+// trimComponents trims each component, drops empty trailing components and
+// limits the result to the five PN components
+func trimComponents(components []string) []string {
+	for i := range components {
+		components[i] = strings.TrimSpace(components[i])
+	}
+	if len(components) > pnMaxComponents {
+		components = components[:pnMaxComponents]
+	}
+	for len(components) > 0 && components[len(components)-1] == "" {
+		components = components[:len(components)-1]
+	}
+	return components
+}
+
+// matchWildcard matches a value against a DICOM wildcard pattern where '*'
+// matches any sequence of characters and '?' a single character
+func matchWildcard(pattern, value string) bool {
+	p, v := []rune(pattern), []rune(value)
+	star, match := -1, 0
+	i, j := 0, 0
+
+	for j < len(v) {
+		switch {
+		case i < len(p) && (p[i] == '?' || p[i] == v[j]):
+			i++
+			j++
+		case i < len(p) && p[i] == '*':
+			star, match = i, j
+			i++
+		case star >= 0:
+			i = star + 1
+			match++
+			j = match
+		default:
+			return false
+		}
+	}
+
+	for i < len(p) && p[i] == '*' {
+		i++
+	}
+	return i == len(p)
+}
@@ -0,0 +1,183 @@
+// Package events publishes connector events to a message bus (Kafka or NATS)
+// for downstream analytics and workflow engines.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Supported bus drivers
+const (
+	DriverKafka = "kafka"
+	DriverNATS  = "nats"
+)
+
+const (
+	// queueSize bounds the events waiting to be sent
+	queueSize = 4096
+	// batchSize is the most events sent in one write
+	batchSize = 100
+	// publishTimeout bounds one write to the bus
+	publishTimeout = 10 * time.Second
+)
+
+// Config selects and addresses the message bus
+type Config struct {
+	Driver      string   // kafka or nats
+	Brokers     []string // Kafka bootstrap brokers or NATS server URLs
+	TopicPrefix string   // events go to <prefix>.<event type>
+	Username    string   // SASL PLAIN (Kafka) or user credentials (NATS)
+	Password    string
+	TLS         bool
+}
+
+// Event is the envelope of every published event
+type Event struct {
+	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// message is an encoded event bound for a topic
+type message struct {
+	topic   string
+	key     []byte
+	payload []byte
+}
+
+// sink writes batches of messages to a bus
+type sink interface {
+	write(ctx context.Context, messages []message) error
+	close() error
+}
+
+// Bus publishes events asynchronously. Publish never blocks: events are queued
+// and sent in batches by a background worker, and dropped with a warning when
+// the bus cannot keep up.
+type Bus struct {
+	sink   sink
+	prefix string
+	queue  chan message
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// New connects to the configured bus and starts publishing
+func New(config Config) (*Bus, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("event bus requires at least one broker")
+	}
+
+	var s sink
+	var err error
+	switch config.Driver {
+	case DriverKafka:
+		s, err = newKafkaSink(config)
+	case DriverNATS:
+		s, err = newNATSSink(config)
+	default:
+		return nil, fmt.Errorf("unsupported event bus driver: %s", config.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bus{
+		sink:   s,
+		prefix: strings.TrimSuffix(config.TopicPrefix, "."),
+		queue:  make(chan message, queueSize),
+		done:   make(chan struct{}),
+	}
+	go b.run()
+	return b, nil
+}
+
+// Publish queues an event. Events of a tenant share a partition key, so
+// consumers see them in order.
+func (b *Bus) Publish(tenantID uuid.UUID, eventType string, data any) {
+	payload, err := json.Marshal(Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		TenantID:  tenantID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("event_type", eventType).Msg("Failed to encode event")
+		return
+	}
+
+	topic := eventType
+	if b.prefix != "" {
+		topic = b.prefix + "." + eventType
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+
+	select {
+	case b.queue <- message{topic: topic, key: []byte(tenantID.String()), payload: payload}:
+	default:
+		log.Warn().
+			Str("tenant_id", tenantID.String()).
+			Str("event_type", eventType).
+			Msg("Event bus queue full, dropping event")
+	}
+}
+
+// Close sends the queued events and disconnects from the bus
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.queue)
+	b.mu.Unlock()
+
+	<-b.done
+	return b.sink.close()
+}
+
+// run sends queued events in batches of whatever has accumulated
+func (b *Bus) run() {
+	defer close(b.done)
+
+	for first := range b.queue {
+		batch := []message{first}
+	collect:
+		for len(batch) < batchSize {
+			select {
+			case m, ok := <-b.queue:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, m)
+			default:
+				break collect
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		if err := b.sink.write(ctx, batch); err != nil {
+			log.Error().Err(err).Int("events", len(batch)).Msg("Failed to publish events")
+		}
+		cancel()
+	}
+}
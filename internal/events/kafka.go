@@ -0,0 +1,52 @@
+package events
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// kafkaSink writes events to Kafka topics
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+func newKafkaSink(config Config) (*kafkaSink, error) {
+	transport := &kafka.Transport{}
+	if config.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if config.Username != "" {
+		transport.SASL = plain.Mechanism{Username: config.Username, Password: config.Password}
+	}
+
+	return &kafkaSink{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(config.Brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireOne,
+			BatchTimeout:           10 * time.Millisecond,
+			AllowAutoTopicCreation: true,
+			Transport:              transport,
+		},
+	}, nil
+}
+
+func (k *kafkaSink) write(ctx context.Context, messages []message) error {
+	batch := make([]kafka.Message, len(messages))
+	for i, m := range messages {
+		batch[i] = kafka.Message{Topic: m.topic, Key: m.key, Value: m.payload}
+	}
+	if err := k.writer.WriteMessages(ctx, batch...); err != nil {
+		return fmt.Errorf("failed to write to Kafka: %w", err)
+	}
+	return nil
+}
+
+func (k *kafkaSink) close() error {
+	return k.writer.Close()
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsSink publishes events on NATS subjects
+type natsSink struct {
+	conn *nats.Conn
+}
+
+func newNATSSink(config Config) (*natsSink, error) {
+	options := []nats.Option{
+		nats.Name("ris-dicom-connector"),
+		nats.MaxReconnects(-1),
+	}
+	if config.Username != "" {
+		options = append(options, nats.UserInfo(config.Username, config.Password))
+	}
+	if config.TLS {
+		options = append(options, nats.Secure(&tls.Config{MinVersion: tls.VersionTLS12}))
+	}
+
+	conn, err := nats.Connect(strings.Join(config.Brokers, ","), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	return &natsSink{conn: conn}, nil
+}
+
+func (n *natsSink) write(ctx context.Context, messages []message) error {
+	for _, m := range messages {
+		if err := n.conn.Publish(m.topic, m.payload); err != nil {
+			return fmt.Errorf("failed to publish to NATS: %w", err)
+		}
+	}
+	// Publishes are buffered; flushing surfaces a lost connection
+	if err := n.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush to NATS: %w", err)
+	}
+	return nil
+}
+
+func (n *natsSink) close() error {
+	return n.conn.Drain()
+}
@@ -0,0 +1,71 @@
+package fhir
+
+// Bundle is a FHIR R4 searchset Bundle
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type"`
+	Total        *int          `json:"total,omitempty"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry"`
+}
+
+// BundleLink is a Bundle.link element (self, next)
+type BundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+// BundleEntry is a Bundle.entry element
+type BundleEntry struct {
+	FullURL  string       `json:"fullUrl"`
+	Resource interface{}  `json:"resource"`
+	Search   *EntrySearch `json:"search,omitempty"`
+}
+
+// EntrySearch is a Bundle.entry.search element
+type EntrySearch struct {
+	Mode string `json:"mode"`
+}
+
+// NewSearchBundle creates an empty searchset Bundle
+func NewSearchBundle() *Bundle {
+	return &Bundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Entry:        []BundleEntry{},
+	}
+}
+
+// AddMatch appends a resource matched by the search
+func (b *Bundle) AddMatch(fullURL string, resource interface{}) {
+	b.Entry = append(b.Entry, BundleEntry{
+		FullURL:  fullURL,
+		Resource: resource,
+		Search:   &EntrySearch{Mode: "match"},
+	})
+}
+
+// OperationOutcome is a FHIR R4 OperationOutcome, returned for failed requests
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
+
+// OperationOutcomeIssue is an OperationOutcome.issue element
+type OperationOutcomeIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+// NewOperationOutcome creates an OperationOutcome with a single error issue
+func NewOperationOutcome(code, diagnostics string) OperationOutcome {
+	return OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue: []OperationOutcomeIssue{{
+			Severity:    "error",
+			Code:        code,
+			Diagnostics: diagnostics,
+		}},
+	}
+}
@@ -0,0 +1,188 @@
+// Package fhir maps DICOM query results to FHIR R4 resources for clients that
+// consume imaging metadata through FHIR instead of DICOMweb.
+package fhir
+
+import (
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// ContentType is the media type of FHIR JSON responses
+const ContentType = "application/fhir+json"
+
+// Code systems and identifier systems used in ImagingStudy resources
+const (
+	SystemDICOMUID       = "urn:dicom:uid"
+	SystemDICOMModality  = "http://dicom.nema.org/resources/ontology/DCM"
+	SystemIdentifierType = "http://terminology.hl7.org/CodeSystem/v2-0203"
+	SystemConnectionType = "http://terminology.hl7.org/CodeSystem/endpoint-connection-type"
+	SystemRFC3986        = "urn:ietf:rfc:3986"
+)
+
+// wadoEndpointID is the id of the contained WADO-RS Endpoint of every ImagingStudy
+const wadoEndpointID = "wado-rs"
+
+// Coding is a FHIR Coding
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// CodeableConcept is a FHIR CodeableConcept
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+// Identifier is a FHIR Identifier
+type Identifier struct {
+	Type   *CodeableConcept `json:"type,omitempty"`
+	System string           `json:"system,omitempty"`
+	Value  string           `json:"value"`
+}
+
+// Reference is a FHIR Reference
+type Reference struct {
+	Reference  string      `json:"reference,omitempty"`
+	Identifier *Identifier `json:"identifier,omitempty"`
+	Display    string      `json:"display,omitempty"`
+}
+
+// Endpoint is a FHIR R4 Endpoint, contained in ImagingStudy resources to point at our WADO-RS service
+type Endpoint struct {
+	ResourceType    string            `json:"resourceType"`
+	ID              string            `json:"id"`
+	Status          string            `json:"status"`
+	ConnectionType  Coding            `json:"connectionType"`
+	PayloadType     []CodeableConcept `json:"payloadType"`
+	PayloadMimeType []string          `json:"payloadMimeType,omitempty"`
+	Address         string            `json:"address"`
+}
+
+// ImagingStudy is a FHIR R4 ImagingStudy
+type ImagingStudy struct {
+	ResourceType      string               `json:"resourceType"`
+	ID                string               `json:"id"`
+	Contained         []Endpoint           `json:"contained,omitempty"`
+	Identifier        []Identifier         `json:"identifier"`
+	Status            string               `json:"status"`
+	Modality          []Coding             `json:"modality,omitempty"`
+	Subject           Reference            `json:"subject"`
+	Started           string               `json:"started,omitempty"`
+	Referrer          *Reference           `json:"referrer,omitempty"`
+	Endpoint          []Reference          `json:"endpoint,omitempty"`
+	NumberOfSeries    int                  `json:"numberOfSeries,omitempty"`
+	NumberOfInstances int                  `json:"numberOfInstances,omitempty"`
+	Description       string               `json:"description,omitempty"`
+	Series            []ImagingStudySeries `json:"series,omitempty"`
+}
+
+// ImagingStudySeries is an ImagingStudy.series element
+type ImagingStudySeries struct {
+	UID               string                 `json:"uid"`
+	Number            int                    `json:"number,omitempty"`
+	Modality          Coding                 `json:"modality"`
+	Description       string                 `json:"description,omitempty"`
+	NumberOfInstances int                    `json:"numberOfInstances,omitempty"`
+	BodySite          *Coding                `json:"bodySite,omitempty"`
+	Started           string                 `json:"started,omitempty"`
+	Instance          []ImagingStudyInstance `json:"instance,omitempty"`
+}
+
+// ImagingStudyInstance is an ImagingStudy.series.instance element
+type ImagingStudyInstance struct {
+	UID      string `json:"uid"`
+	SopClass Coding `json:"sopClass"`
+	Number   int    `json:"number,omitempty"`
+}
+
+// SeriesDetail is a series with its instances, used to populate ImagingStudy.series
+type SeriesDetail struct {
+	models.Series
+	Instances []models.Instance
+}
+
+// NewImagingStudy converts a study into an ImagingStudy whose endpoint is the
+// WADO-RS service at wadoURL. Series are only included when given.
+func NewImagingStudy(study models.Study, series []SeriesDetail, wadoURL string) ImagingStudy {
+	resource := ImagingStudy{
+		ResourceType: "ImagingStudy",
+		ID:           study.StudyInstanceUID,
+		Contained: []Endpoint{{
+			ResourceType:    "Endpoint",
+			ID:              wadoEndpointID,
+			Status:          "active",
+			ConnectionType:  Coding{System: SystemConnectionType, Code: "dicom-wado-rs"},
+			PayloadType:     []CodeableConcept{{Text: "DICOM WADO-RS"}},
+			PayloadMimeType: []string{"application/dicom"},
+			Address:         wadoURL,
+		}},
+		Identifier: []Identifier{{
+			System: SystemDICOMUID,
+			Value:  "urn:oid:" + study.StudyInstanceUID,
+		}},
+		Status:            "available",
+		Subject:           Reference{Display: study.PatientName},
+		Started:           Date(study.StudyDate),
+		Endpoint:          []Reference{{Reference: "#" + wadoEndpointID}},
+		NumberOfSeries:    study.NumberOfSeries,
+		NumberOfInstances: study.NumberOfInstances,
+		Description:       study.StudyDescription,
+	}
+
+	if study.PatientID != "" {
+		resource.Subject.Identifier = &Identifier{Value: study.PatientID}
+	}
+	if study.AccessionNumber != "" {
+		resource.Identifier = append(resource.Identifier, Identifier{
+			Type:  &CodeableConcept{Coding: []Coding{{System: SystemIdentifierType, Code: "ACSN"}}},
+			Value: study.AccessionNumber,
+		})
+	}
+	if study.ReferringPhysician != "" {
+		resource.Referrer = &Reference{Display: study.ReferringPhysician}
+	}
+	for _, modality := range study.ModalitiesInStudy {
+		resource.Modality = append(resource.Modality, Coding{System: SystemDICOMModality, Code: modality})
+	}
+
+	for _, s := range series {
+		element := ImagingStudySeries{
+			UID:               s.SeriesInstanceUID,
+			Number:            s.SeriesNumber,
+			Modality:          Coding{System: SystemDICOMModality, Code: s.Modality},
+			Description:       s.SeriesDescription,
+			NumberOfInstances: s.NumberOfInstances,
+			Started:           Date(s.SeriesDate),
+		}
+		if s.BodyPartExamined != "" {
+			element.BodySite = &Coding{Display: s.BodyPartExamined}
+		}
+		for _, instance := range s.Instances {
+			element.Instance = append(element.Instance, ImagingStudyInstance{
+				UID:      instance.SOPInstanceUID,
+				SopClass: Coding{System: SystemRFC3986, Code: "urn:oid:" + instance.SOPClassUID},
+				Number:   instance.InstanceNumber,
+			})
+		}
+		if element.NumberOfInstances == 0 {
+			element.NumberOfInstances = len(s.Instances)
+		}
+		resource.Series = append(resource.Series, element)
+	}
+
+	return resource
+}
+
+// Date converts a DICOM DA value to a FHIR date. Times are not carried over:
+// FHIR requires a time zone on dateTime values with a time, which DICOM TM
+// values do not have.
+func Date(da string) string {
+	d, err := time.Parse("20060102", da)
+	if err != nil {
+		return ""
+	}
+	return d.Format("2006-01-02")
+}
@@ -0,0 +1,157 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	connectorapiv1 "github.com/otcheredev/ris-dicom-connector/pkg/connectorapi/v1"
+)
+
+func queryParams(req *connectorapiv1.SearchStudiesRequest) models.QueryParams {
+	return models.QueryParams{
+		PatientID:        req.GetPatientId(),
+		PatientName:      req.GetPatientName(),
+		StudyDate:        req.GetStudyDate(),
+		StudyTime:        req.GetStudyTime(),
+		AccessionNumber:  req.GetAccessionNumber(),
+		Modalities:       req.GetModalities(),
+		StudyDescription: req.GetStudyDescription(),
+		Filters:          req.GetFilters(),
+		Limit:            int(req.GetLimit()),
+		Offset:           int(req.GetOffset()),
+	}
+}
+
+func toStudy(s models.Study) *connectorapiv1.Study {
+	return &connectorapiv1.Study{
+		StudyInstanceUid:   s.StudyInstanceUID,
+		PatientId:          s.PatientID,
+		PatientName:        s.PatientName,
+		PatientBirthDate:   s.PatientBirthDate,
+		PatientSex:         s.PatientSex,
+		StudyDate:          s.StudyDate,
+		StudyTime:          s.StudyTime,
+		StudyDescription:   s.StudyDescription,
+		AccessionNumber:    s.AccessionNumber,
+		ReferringPhysician: s.ReferringPhysician,
+		NumberOfSeries:     int32(s.NumberOfSeries),
+		NumberOfInstances:  int32(s.NumberOfInstances),
+		ModalitiesInStudy:  s.ModalitiesInStudy,
+	}
+}
+
+func toSeries(s models.Series) *connectorapiv1.Series {
+	return &connectorapiv1.Series{
+		SeriesInstanceUid:  s.SeriesInstanceUID,
+		SeriesNumber:       int32(s.SeriesNumber),
+		Modality:           s.Modality,
+		SeriesDescription:  s.SeriesDescription,
+		SeriesDate:         s.SeriesDate,
+		SeriesTime:         s.SeriesTime,
+		BodyPartExamined:   s.BodyPartExamined,
+		NumberOfInstances:  int32(s.NumberOfInstances),
+		ProtocolName:       s.ProtocolName,
+		PerformedProcedure: s.PerformedProcedure,
+	}
+}
+
+func toInstance(i models.Instance) *connectorapiv1.Instance {
+	return &connectorapiv1.Instance{
+		SopInstanceUid:            i.SOPInstanceUID,
+		SopClassUid:               i.SOPClassUID,
+		InstanceNumber:            int32(i.InstanceNumber),
+		TransferSyntaxUid:         i.TransferSyntaxUID,
+		Rows:                      int32(i.Rows),
+		Columns:                   int32(i.Columns),
+		BitsAllocated:             int32(i.BitsAllocated),
+		BitsStored:                int32(i.BitsStored),
+		HighBit:                   int32(i.HighBit),
+		PixelRepresentation:       int32(i.PixelRepresentation),
+		PhotometricInterpretation: i.PhotometricInterpretation,
+		SamplesPerPixel:           int32(i.SamplesPerPixel),
+		NumberOfFrames:            int32(i.NumberOfFrames),
+	}
+}
+
+// toMetadata encodes the attributes as a DICOM JSON model object
+func toMetadata(m models.Metadata) (*connectorapiv1.Metadata, error) {
+	attributes := m.Attributes
+	if attributes == nil {
+		attributes = map[string]interface{}{}
+	}
+	dicomJSON, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata of %s: %w", m.SOPInstanceUID, err)
+	}
+	return &connectorapiv1.Metadata{
+		SopInstanceUid:    m.SOPInstanceUID,
+		SopClassUid:       m.SOPClassUID,
+		TransferSyntaxUid: m.TransferSyntaxUID,
+		DicomJson:         dicomJSON,
+	}, nil
+}
+
+func toMetadataList(metadata []models.Metadata) (*connectorapiv1.MetadataList, error) {
+	list := &connectorapiv1.MetadataList{Metadata: make([]*connectorapiv1.Metadata, 0, len(metadata))}
+	for _, m := range metadata {
+		converted, err := toMetadata(m)
+		if err != nil {
+			return nil, err
+		}
+		list.Metadata = append(list.Metadata, converted)
+	}
+	return list, nil
+}
+
+func toCapabilities(c *models.CapabilitiesResponse) *connectorapiv1.Capabilities {
+	return &connectorapiv1.Capabilities{
+		PacsType:         string(c.PACSType),
+		Services:         c.Services,
+		Resources:        c.Resources,
+		SopClasses:       c.SOPClasses,
+		TransferSyntaxes: c.TransferSyntaxes,
+		MediaTypes:       c.MediaTypes,
+	}
+}
+
+// toPACSConfig converts a PACS configuration, leaving out its credentials
+func toPACSConfig(c *models.PACSConfig) *connectorapiv1.PACSConfig {
+	config := &connectorapiv1.PACSConfig{
+		Id:                   c.ID.String(),
+		Name:                 c.Name,
+		Type:                 string(c.Type),
+		Endpoint:             c.Endpoint,
+		Port:                 int32(c.Port),
+		AeTitle:              c.AETitle,
+		BaseUrl:              c.BaseURL,
+		IsPrimary:            c.IsPrimary,
+		IsActive:             c.IsActive,
+		MaxResults:           int32(c.MaxResults),
+		Quirks:               c.Quirks,
+		LastConnectionStatus: c.LastConnectionStatus,
+		LastError:            c.LastError,
+	}
+	if !c.LastConnectionTest.IsZero() {
+		config.LastConnectionTestUnix = c.LastConnectionTest.Unix()
+	}
+	return config
+}
+
+func pacsConfigRequest(req *connectorapiv1.CreatePACSConfigRequest) *models.PACSConfigRequest {
+	return &models.PACSConfigRequest{
+		Name:       req.GetName(),
+		Type:       models.PACSType(req.GetType()),
+		Endpoint:   req.GetEndpoint(),
+		Port:       int(req.GetPort()),
+		AETitle:    req.GetAeTitle(),
+		BaseURL:    req.GetBaseUrl(),
+		BasePath:   req.GetBasePath(),
+		Username:   req.GetUsername(),
+		Password:   req.GetPassword(),
+		APIKey:     req.GetApiKey(),
+		IsPrimary:  req.GetIsPrimary(),
+		MaxResults: int(req.GetMaxResults()),
+		Quirks:     req.GetQuirks(),
+	}
+}
@@ -0,0 +1,226 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/audit"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	connectorapiv1 "github.com/otcheredev/ris-dicom-connector/pkg/connectorapi/v1"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tenantMetadataKey carries the tenant ID, like the X-Tenant-ID header of the REST API
+const tenantMetadataKey = "x-tenant-id"
+
+// methodScopes are the scopes the RPCs need, those of the matching REST routes;
+// RPCs not listed need the admin scope
+var methodScopes = map[string]string{
+	connectorapiv1.ConnectorService_SearchStudies_FullMethodName:       models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_SearchSeries_FullMethodName:        models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_SearchInstances_FullMethodName:     models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_GetStudyMetadata_FullMethodName:    models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_GetSeriesMetadata_FullMethodName:   models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_GetInstanceMetadata_FullMethodName: models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_RetrieveInstance_FullMethodName:    models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_RetrieveSeries_FullMethodName:      models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_RetrieveStudy_FullMethodName:       models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_RetrieveRendered_FullMethodName:    models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_GetThumbnail_FullMethodName:        models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_GetCapabilities_FullMethodName:     models.ScopeDICOMWebRead,
+	connectorapiv1.ConnectorService_ListPACSConfigs_FullMethodName:     models.ScopeManagementRead,
+	connectorapiv1.ConnectorService_GetPACSConfig_FullMethodName:       models.ScopeManagementRead,
+	connectorapiv1.ConnectorService_CreatePACSConfig_FullMethodName:    models.ScopeManagementWrite,
+	connectorapiv1.ConnectorService_DeleteStudy_FullMethodName:         models.ScopeAdmin,
+}
+
+// callAuth authenticates the calls of the API like the REST API authenticates
+// requests: API keys, the admin token and bearer JWTs in the authorization
+// metadata grant scopes, which each RPC checks, and the tenant must be
+// provisioned and active
+type callAuth struct {
+	authz   *middleware.Authorizer
+	keys    *services.APIKeyService
+	tenants middleware.TenantLookup
+}
+
+// UnaryAuthInterceptor authenticates every unary call and resolves its tenant
+// into the context, where the handlers read it with middleware.GetTenantID
+func UnaryAuthInterceptor(authz *middleware.Authorizer, keys *services.APIKeyService, tenants middleware.TenantLookup) grpc.UnaryServerInterceptor {
+	auth := &callAuth{authz: authz, keys: keys, tenants: tenants}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := auth.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor authenticates every streaming call and resolves its tenant
+func StreamAuthInterceptor(authz *middleware.Authorizer, keys *services.APIKeyService, tenants middleware.TenantLookup) grpc.StreamServerInterceptor {
+	auth := &callAuth{authz: authz, keys: keys, tenants: tenants}
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := auth.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize authenticates a call's credential, checks its tenant and the
+// scope of its method, and adds them to the context, whose study reads are put
+// to the study authorizer
+func (a *callAuth) authorize(ctx context.Context, method string) (context.Context, error) {
+	credential, err := a.credential(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := callTenant(ctx, credential)
+	if err != nil {
+		return nil, err
+	}
+
+	err = middleware.ValidateTenant(ctx, a.tenants, tenantID)
+	switch {
+	case errors.Is(err, middleware.ErrTenantNotProvisioned):
+		log.Warn().Str("tenant_id", tenantID.String()).Msg("Rejected call of unknown tenant")
+		return nil, status.Error(codes.PermissionDenied, "The tenant is not provisioned")
+	case errors.Is(err, middleware.ErrTenantDisabled):
+		return nil, status.Error(codes.PermissionDenied, "The tenant is disabled")
+	case err != nil:
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to validate tenant")
+		return nil, status.Error(codes.Unavailable, "The tenant could not be verified")
+	}
+
+	ctx = context.WithValue(ctx, middleware.TenantIDKey, tenantID)
+	if credential != nil {
+		ctx = middleware.WithScopes(ctx, credential.Scopes)
+		if credential.Subject != "" {
+			ctx = audit.WithUser(ctx, credential.Subject)
+		}
+	}
+
+	scope, ok := methodScopes[method]
+	if !ok {
+		scope = models.ScopeAdmin
+	}
+	switch err := a.authz.CheckScope(ctx, scope); {
+	case errors.Is(err, middleware.ErrAuthorizationRequired):
+		return nil, status.Error(codes.Unauthenticated, "Authorization required")
+	case errors.Is(err, middleware.ErrInsufficientScope):
+		return nil, status.Error(codes.PermissionDenied, "The credential lacks the "+scope+" scope")
+	}
+	return services.WithStudyAuthorization(ctx), nil
+}
+
+// credential authenticates the API key, admin token or bearer JWT of a call,
+// as the APIKeys and Authenticate middleware do; it is nil for a call without
+// one, and bearer JWTs and the admin token are ignored while access control is
+// disabled
+func (a *callAuth) credential(ctx context.Context) (*middleware.Credential, error) {
+	token, ok := strings.CutPrefix(firstMetadata(ctx, "authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(token, models.APIKeyTokenPrefix) {
+		key, err := a.keys.ValidateKey(ctx, token)
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			return nil, status.Error(codes.Unauthenticated, "The API key is invalid, expired or revoked")
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to validate API key")
+			return nil, status.Error(codes.Internal, "Failed to validate API key")
+		}
+		return &middleware.Credential{
+			Scopes:   key.Scopes,
+			TenantID: key.TenantID,
+			Subject:  "api-key:" + key.ID.String(),
+		}, nil
+	}
+
+	if !a.authz.Enabled() {
+		return nil, nil
+	}
+	credential, err := a.authz.VerifyBearer(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return credential, nil
+}
+
+// callTenant returns the tenant of a call: that of its credential, which the
+// x-tenant-id metadata cannot change, or otherwise the one the metadata names
+func callTenant(ctx context.Context, credential *middleware.Credential) (uuid.UUID, error) {
+	tenantIDStr := firstMetadata(ctx, tenantMetadataKey)
+	if credential != nil && credential.TenantID != uuid.Nil {
+		if tenantIDStr != "" && tenantIDStr != credential.TenantID.String() {
+			return uuid.Nil, status.Error(codes.PermissionDenied, "The credential belongs to another tenant")
+		}
+		return credential.TenantID, nil
+	}
+
+	if tenantIDStr == "" {
+		log.Warn().Msg("Missing tenant ID")
+		return uuid.Nil, status.Error(codes.InvalidArgument, "x-tenant-id metadata is required")
+	}
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantIDStr).Msg("Invalid tenant ID")
+		return uuid.Nil, status.Error(codes.InvalidArgument, "Invalid x-tenant-id metadata format")
+	}
+	return tenantID, nil
+}
+
+// tenantStream overrides the context of a server stream
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantStream) Context() context.Context {
+	return s.ctx
+}
+
+// requireAdmin checks the admin bearer token in the authorization metadata,
+// mirroring middleware.RequireAdmin. When no token is configured, admin calls
+// are disabled entirely.
+func requireAdmin(ctx context.Context, token, method string) error {
+	if token == "" {
+		return status.Error(codes.PermissionDenied, "Admin operations are disabled")
+	}
+
+	provided, ok := strings.CutPrefix(firstMetadata(ctx, "authorization"), "Bearer ")
+	if !ok || provided == "" {
+		return status.Error(codes.Unauthenticated, "Admin authorization required")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		log.Warn().Str("method", method).Msg("Rejected admin call with invalid token")
+		return status.Error(codes.PermissionDenied, "Forbidden")
+	}
+	return nil
+}
+
+// firstMetadata returns the first value of an incoming metadata key
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
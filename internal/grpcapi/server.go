@@ -0,0 +1,537 @@
+// Package grpcapi serves the connector's query, retrieve and management
+// operations over gRPC (risconnector.api.v1.ConnectorService) for internal
+// services, with the same tenant isolation as the REST API.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+	connectorapiv1 "github.com/otcheredev/ris-dicom-connector/pkg/connectorapi/v1"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// chunkSize is the size of the data messages of streamed objects
+const chunkSize = 64 << 10
+
+// Server implements ConnectorService on top of the PACS service
+type Server struct {
+	connectorapiv1.UnimplementedConnectorServiceServer
+
+	pacsService *services.PACSService
+	adminToken  string
+	authz       *middleware.Authorizer
+	keys        *services.APIKeyService
+	tenants     middleware.TenantLookup
+}
+
+// NewServer creates the gRPC API; adminToken guards DeleteStudy like the REST
+// admin routes, authz and keys authenticate calls and check their scopes, and
+// tenants tells the provisioned, active tenants it serves
+func NewServer(pacsService *services.PACSService, adminToken string, authz *middleware.Authorizer, keys *services.APIKeyService, tenants middleware.TenantLookup) *Server {
+	return &Server{
+		pacsService: pacsService,
+		adminToken:  adminToken,
+		authz:       authz,
+		keys:        keys,
+		tenants:     tenants,
+	}
+}
+
+// Register creates a gRPC server with the auth interceptors and registers the API on it
+func Register(s *Server, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(UnaryAuthInterceptor(s.authz, s.keys, s.tenants)),
+		grpc.ChainStreamInterceptor(StreamAuthInterceptor(s.authz, s.keys, s.tenants)),
+	)
+	server := grpc.NewServer(opts...)
+	connectorapiv1.RegisterConnectorServiceServer(server, s)
+	return server
+}
+
+// SearchStudies searches for studies
+func (s *Server) SearchStudies(ctx context.Context, req *connectorapiv1.SearchStudiesRequest) (*connectorapiv1.SearchStudiesResponse, error) {
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	studies, truncated, err := s.pacsService.FindStudies(ctx, tenantID, queryParams(req))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search studies")
+		return nil, statusError(err, "Failed to search studies")
+	}
+
+	resp := &connectorapiv1.SearchStudiesResponse{
+		Studies:   make([]*connectorapiv1.Study, 0, len(studies)),
+		Truncated: truncated,
+	}
+	for _, study := range studies {
+		resp.Studies = append(resp.Studies, toStudy(study))
+	}
+	return resp, nil
+}
+
+// SearchSeries searches for the series of a study
+func (s *Server) SearchSeries(ctx context.Context, req *connectorapiv1.SearchSeriesRequest) (*connectorapiv1.SearchSeriesResponse, error) {
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetStudyUid() == "" {
+		return nil, status.Error(codes.InvalidArgument, "study_uid is required")
+	}
+
+	series, err := s.pacsService.FindSeries(ctx, tenantID, req.GetStudyUid())
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", req.GetStudyUid()).Msg("Failed to search series")
+		return nil, statusError(err, "Failed to search series")
+	}
+
+	resp := &connectorapiv1.SearchSeriesResponse{Series: make([]*connectorapiv1.Series, 0, len(series))}
+	for _, se := range series {
+		resp.Series = append(resp.Series, toSeries(se))
+	}
+	return resp, nil
+}
+
+// SearchInstances searches for the instances of a series
+func (s *Server) SearchInstances(ctx context.Context, req *connectorapiv1.SearchInstancesRequest) (*connectorapiv1.SearchInstancesResponse, error) {
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetStudyUid() == "" || req.GetSeriesUid() == "" {
+		return nil, status.Error(codes.InvalidArgument, "study_uid and series_uid are required")
+	}
+
+	instances, err := s.pacsService.FindInstances(ctx, tenantID, req.GetStudyUid(), req.GetSeriesUid())
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", req.GetStudyUid()).Str("series_uid", req.GetSeriesUid()).Msg("Failed to search instances")
+		return nil, statusError(err, "Failed to search instances")
+	}
+
+	resp := &connectorapiv1.SearchInstancesResponse{Instances: make([]*connectorapiv1.Instance, 0, len(instances))}
+	for _, instance := range instances {
+		resp.Instances = append(resp.Instances, toInstance(instance))
+	}
+	return resp, nil
+}
+
+// GetStudyMetadata retrieves the metadata of every instance of a study
+func (s *Server) GetStudyMetadata(ctx context.Context, ref *connectorapiv1.ObjectRef) (*connectorapiv1.MetadataList, error) {
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRef(ref, false, false); err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.pacsService.GetStudyMetadata(ctx, tenantID, ref.GetStudyUid())
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", ref.GetStudyUid()).Msg("Failed to get study metadata")
+		return nil, statusError(err, "Failed to get study metadata")
+	}
+	return metadataList(metadata)
+}
+
+// GetSeriesMetadata retrieves the metadata of every instance of a series
+func (s *Server) GetSeriesMetadata(ctx context.Context, ref *connectorapiv1.ObjectRef) (*connectorapiv1.MetadataList, error) {
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRef(ref, true, false); err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.pacsService.GetSeriesMetadata(ctx, tenantID, ref.GetStudyUid(), ref.GetSeriesUid())
+	if err != nil {
+		log.Error().Err(err).Str("series_uid", ref.GetSeriesUid()).Msg("Failed to get series metadata")
+		return nil, statusError(err, "Failed to get series metadata")
+	}
+	return metadataList(metadata)
+}
+
+// GetInstanceMetadata retrieves the metadata of an instance
+func (s *Server) GetInstanceMetadata(ctx context.Context, ref *connectorapiv1.ObjectRef) (*connectorapiv1.Metadata, error) {
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRef(ref, true, true); err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.pacsService.GetInstanceMetadata(ctx, tenantID, ref.GetStudyUid(), ref.GetSeriesUid(), ref.GetInstanceUid())
+	if err != nil {
+		log.Error().Err(err).Str("instance_uid", ref.GetInstanceUid()).Msg("Failed to get instance metadata")
+		return nil, statusError(err, "Failed to get instance metadata")
+	}
+
+	converted, err := toMetadata(*metadata)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return converted, nil
+}
+
+// RetrieveInstance streams an instance; the first chunk carries its content type
+func (s *Server) RetrieveInstance(req *connectorapiv1.RetrieveInstanceRequest, stream grpc.ServerStreamingServer[connectorapiv1.DataChunk]) error {
+	ctx := stream.Context()
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return err
+	}
+	ref := req.GetObject()
+	if err := requireRef(ref, true, true); err != nil {
+		return err
+	}
+
+	opts := models.RetrieveOptions{TransferSyntax: req.GetTransferSyntax()}
+	data, contentType, err := s.pacsService.GetInstance(ctx, tenantID, ref.GetStudyUid(), ref.GetSeriesUid(), ref.GetInstanceUid(), opts)
+	if err != nil {
+		log.Error().Err(err).Str("instance_uid", ref.GetInstanceUid()).Msg("Failed to retrieve instance")
+		return statusError(err, "Failed to retrieve instance")
+	}
+	defer data.Close()
+
+	return sendObject(stream, data, contentType)
+}
+
+// RetrieveSeries streams every instance of a series
+func (s *Server) RetrieveSeries(req *connectorapiv1.RetrieveRequest, stream grpc.ServerStreamingServer[connectorapiv1.InstancePart]) error {
+	ctx := stream.Context()
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return err
+	}
+	ref := req.GetObject()
+	if err := requireRef(ref, true, false); err != nil {
+		return err
+	}
+
+	opts := models.RetrieveOptions{TransferSyntax: req.GetTransferSyntax()}
+	if err := s.pacsService.RetrieveSeries(ctx, tenantID, ref.GetStudyUid(), ref.GetSeriesUid(), opts, instanceSender(stream)); err != nil {
+		log.Error().Err(err).Str("series_uid", ref.GetSeriesUid()).Msg("Failed to retrieve series")
+		return statusError(err, "Failed to retrieve series")
+	}
+	return nil
+}
+
+// RetrieveStudy streams every instance of a study
+func (s *Server) RetrieveStudy(req *connectorapiv1.RetrieveRequest, stream grpc.ServerStreamingServer[connectorapiv1.InstancePart]) error {
+	ctx := stream.Context()
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return err
+	}
+	ref := req.GetObject()
+	if err := requireRef(ref, false, false); err != nil {
+		return err
+	}
+
+	opts := models.RetrieveOptions{TransferSyntax: req.GetTransferSyntax()}
+	if err := s.pacsService.RetrieveStudy(ctx, tenantID, ref.GetStudyUid(), opts, instanceSender(stream)); err != nil {
+		log.Error().Err(err).Str("study_uid", ref.GetStudyUid()).Msg("Failed to retrieve study")
+		return statusError(err, "Failed to retrieve study")
+	}
+	return nil
+}
+
+// RetrieveRendered streams a consumer-format rendering of an instance
+func (s *Server) RetrieveRendered(req *connectorapiv1.RetrieveRenderedRequest, stream grpc.ServerStreamingServer[connectorapiv1.DataChunk]) error {
+	ctx := stream.Context()
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return err
+	}
+	ref := req.GetObject()
+	if err := requireRef(ref, true, true); err != nil {
+		return err
+	}
+
+	data, contentType, err := s.pacsService.GetRendered(ctx, tenantID, ref.GetStudyUid(), ref.GetSeriesUid(), ref.GetInstanceUid(), req.GetMediaType())
+	if err != nil {
+		log.Error().Err(err).Str("instance_uid", ref.GetInstanceUid()).Msg("Failed to retrieve rendered instance")
+		return statusError(err, "Failed to retrieve rendered instance")
+	}
+	defer data.Close()
+
+	return sendObject(stream, data, contentType)
+}
+
+// GetThumbnail renders a JPEG thumbnail of a study, series or instance
+func (s *Server) GetThumbnail(ctx context.Context, req *connectorapiv1.GetThumbnailRequest) (*connectorapiv1.Thumbnail, error) {
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ref := req.GetObject()
+	if err := requireRef(ref, false, false); err != nil {
+		return nil, err
+	}
+	if ref.GetInstanceUid() != "" && ref.GetSeriesUid() == "" {
+		return nil, status.Error(codes.InvalidArgument, "series_uid is required with instance_uid")
+	}
+
+	opts := thumbnail.Options{
+		Size:    thumbnail.DefaultSize,
+		Quality: thumbnail.DefaultQuality,
+	}
+	if size := int(req.GetSize()); size > 0 {
+		opts.Size = min(size, thumbnail.MaxSize)
+	}
+	if quality := int(req.GetQuality()); quality != 0 {
+		if quality < 1 || quality > 100 {
+			return nil, status.Error(codes.InvalidArgument, "quality must be between 1 and 100")
+		}
+		opts.Quality = quality
+	}
+
+	var data []byte
+	switch {
+	case ref.GetInstanceUid() != "":
+		data, err = s.pacsService.GetThumbnail(ctx, tenantID, ref.GetStudyUid(), ref.GetSeriesUid(), ref.GetInstanceUid(), opts)
+	case ref.GetSeriesUid() != "":
+		data, err = s.pacsService.GetSeriesThumbnail(ctx, tenantID, ref.GetStudyUid(), ref.GetSeriesUid(), opts)
+	default:
+		data, err = s.pacsService.GetStudyThumbnail(ctx, tenantID, ref.GetStudyUid(), opts)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", ref.GetStudyUid()).Msg("Failed to render thumbnail")
+		return nil, statusError(err, "Failed to render thumbnail")
+	}
+	return &connectorapiv1.Thumbnail{Jpeg: data}, nil
+}
+
+// GetCapabilities describes the services available to the tenant
+func (s *Server) GetCapabilities(ctx context.Context, _ *connectorapiv1.GetCapabilitiesRequest) (*connectorapiv1.Capabilities, error) {
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	capabilities, err := s.pacsService.GetCapabilities(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get capabilities")
+		return nil, statusError(err, "Failed to get capabilities")
+	}
+	return toCapabilities(capabilities), nil
+}
+
+// ListPACSConfigs lists the tenant's PACS configurations
+func (s *Server) ListPACSConfigs(ctx context.Context, _ *connectorapiv1.ListPACSConfigsRequest) (*connectorapiv1.ListPACSConfigsResponse, error) {
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := s.pacsService.GetPACSConfigs(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get PACS configs")
+		return nil, statusError(err, "Failed to get PACS configs")
+	}
+
+	resp := &connectorapiv1.ListPACSConfigsResponse{Configs: make([]*connectorapiv1.PACSConfig, 0, len(configs))}
+	for i := range configs {
+		resp.Configs = append(resp.Configs, toPACSConfig(&configs[i]))
+	}
+	return resp, nil
+}
+
+// GetPACSConfig retrieves one of the tenant's PACS configurations
+func (s *Server) GetPACSConfig(ctx context.Context, req *connectorapiv1.GetPACSConfigRequest) (*connectorapiv1.PACSConfig, error) {
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	configID, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid config ID")
+	}
+
+	config, err := s.pacsService.GetPACSConfig(ctx, tenantID, configID)
+	if errors.Is(err, services.ErrPACSConfigNotFound) {
+		return nil, status.Error(codes.NotFound, "PACS config not found")
+	}
+	if err != nil {
+		log.Error().Err(err).Str("config_id", req.GetId()).Msg("Failed to get PACS config")
+		return nil, statusError(err, "Failed to get PACS config")
+	}
+	return toPACSConfig(config), nil
+}
+
+// CreatePACSConfig creates a PACS configuration for the tenant
+func (s *Server) CreatePACSConfig(ctx context.Context, req *connectorapiv1.CreatePACSConfigRequest) (*connectorapiv1.PACSConfig, error) {
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.pacsService.CreatePACSConfig(ctx, tenantID, pacsConfigRequest(req))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create PACS config")
+		return nil, statusError(err, "Failed to create PACS config")
+	}
+	return toPACSConfig(config), nil
+}
+
+// DeleteStudy deletes a study from the tenant's PACS; it requires the admin token
+func (s *Server) DeleteStudy(ctx context.Context, ref *connectorapiv1.ObjectRef) (*connectorapiv1.DeleteStudyResponse, error) {
+	if err := requireAdmin(ctx, s.adminToken, "DeleteStudy"); err != nil {
+		return nil, err
+	}
+	tenantID, err := tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRef(ref, false, false); err != nil {
+		return nil, err
+	}
+
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	if err := s.pacsService.DeleteStudy(ctx, tenantID, ref.GetStudyUid(), remoteAddr, firstMetadata(ctx, "user-agent")); err != nil {
+		log.Error().Err(err).Str("study_uid", ref.GetStudyUid()).Msg("Failed to delete study")
+		return nil, statusError(err, "Failed to delete study")
+	}
+	return &connectorapiv1.DeleteStudyResponse{}, nil
+}
+
+// tenant returns the tenant resolved by the interceptors
+func tenant(ctx context.Context) (uuid.UUID, error) {
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "Tenant ID not found")
+	}
+	return tenantID, nil
+}
+
+// requireRef checks that an object reference names the UIDs of its level
+func requireRef(ref *connectorapiv1.ObjectRef, series, instance bool) error {
+	switch {
+	case ref.GetStudyUid() == "":
+		return status.Error(codes.InvalidArgument, "study_uid is required")
+	case series && ref.GetSeriesUid() == "":
+		return status.Error(codes.InvalidArgument, "series_uid is required")
+	case instance && ref.GetInstanceUid() == "":
+		return status.Error(codes.InvalidArgument, "instance_uid is required")
+	}
+	return nil
+}
+
+func metadataList(metadata []models.Metadata) (*connectorapiv1.MetadataList, error) {
+	list, err := toMetadataList(metadata)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return list, nil
+}
+
+// sendObject streams an object in chunks, the first carrying its content type and length
+func sendObject(stream grpc.ServerStreamingServer[connectorapiv1.DataChunk], data io.Reader, contentType string) error {
+	var contentLength int64 = -1
+	if body, ok := data.(*models.InstanceBody); ok {
+		contentLength = body.ContentLength
+	}
+
+	first := true
+	return copyChunks(data, func(chunk []byte) error {
+		msg := &connectorapiv1.DataChunk{Data: chunk}
+		if first {
+			msg.ContentType = contentType
+			msg.ContentLength = contentLength
+			first = false
+		}
+		return stream.Send(msg)
+	})
+}
+
+// instanceSender streams each retrieved instance as a header part followed by data parts
+func instanceSender(stream grpc.ServerStreamingServer[connectorapiv1.InstancePart]) services.InstanceVisitor {
+	return func(instance models.Instance, data io.ReadCloser, contentType string) error {
+		header := &connectorapiv1.InstancePart{Part: &connectorapiv1.InstancePart_Header{Header: &connectorapiv1.InstanceHeader{
+			SopInstanceUid: instance.SOPInstanceUID,
+			SopClassUid:    instance.SOPClassUID,
+			InstanceNumber: int32(instance.InstanceNumber),
+			ContentType:    contentType,
+		}}}
+		if err := stream.Send(header); err != nil {
+			return err
+		}
+		return copyChunks(data, func(chunk []byte) error {
+			return stream.Send(&connectorapiv1.InstancePart{Part: &connectorapiv1.InstancePart_Data{Data: chunk}})
+		})
+	}
+}
+
+// copyChunks reads data in chunkSize pieces and hands each to send. An empty
+// object is still sent as one empty chunk so the first-chunk fields arrive.
+func copyChunks(data io.Reader, send func([]byte) error) error {
+	buf := make([]byte, chunkSize)
+	sent := false
+	for {
+		n, err := io.ReadFull(data, buf)
+		if n > 0 || !sent {
+			// The message is marshalled by Send, so the buffer can be reused
+			if sendErr := send(buf[:n]); sendErr != nil {
+				return sendErr
+			}
+			sent = true
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// statusError maps a service error onto a gRPC status, the counterpart of the
+// REST API's problem responses. Unclassified errors become Internal with the given message.
+func statusError(err error, message string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "The PACS did not respond in time")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "The call was canceled")
+	case errors.Is(err, services.ErrNoPACSConfigured):
+		return status.Error(codes.FailedPrecondition, "No PACS is configured for this tenant")
+	case errors.Is(err, services.ErrUnavailable):
+		return status.Error(codes.Unavailable, "The PACS is unavailable")
+	case errors.Is(err, services.ErrStudyAccessDenied):
+		return status.Error(codes.PermissionDenied, "Access to the study is denied")
+	case errors.Is(err, services.ErrNotFound):
+		return status.Error(codes.NotFound, "The requested resource was not found")
+	case errors.Is(err, services.ErrRangeNotSatisfiable):
+		return status.Error(codes.OutOfRange, "Requested range not satisfiable")
+	case errors.Is(err, services.ErrTooLarge):
+		return status.Error(codes.ResourceExhausted, "The result is too large; narrow the query")
+	case errors.Is(err, services.ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, "The PACS rejected the request")
+	case errors.Is(err, services.ErrNotSupported):
+		return status.Error(codes.Unimplemented, "The operation is not supported by the configured PACS")
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+const (
+	mediaTypeDICOM            = "application/dicom"
+	mediaTypeMultipartRelated = "multipart/related"
+)
+
+// mediaRange is a single entry of an Accept header
+type mediaRange struct {
+	mediaType string
+	params    map[string]string
+	q         float64
+}
+
+// retrieveRepresentation describes how a retrieved instance is returned to the client
+type retrieveRepresentation struct {
+	multipart      bool
+	transferSyntax string // empty or "*" means the transfer syntax stored by the PACS
+}
+
+// parseAccept parses an Accept header into media ranges ordered by preference
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, item := range splitHeaderList(header) {
+		mediaType, params, err := mime.ParseMediaType(item)
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if qStr, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(qStr, 64); err == nil {
+				q = parsed
+			}
+			delete(params, "q")
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mediaType, params: params, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	return ranges
+}
+
+// splitHeaderList splits a comma separated header value, ignoring commas inside quoted strings
+func splitHeaderList(header string) []string {
+	var items []string
+	var current strings.Builder
+	inQuotes := false
+
+	for _, char := range header {
+		switch {
+		case char == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(char)
+		case char == ',' && !inQuotes:
+			if item := strings.TrimSpace(current.String()); item != "" {
+				items = append(items, item)
+			}
+			current.Reset()
+		default:
+			current.WriteRune(char)
+		}
+	}
+	if item := strings.TrimSpace(current.String()); item != "" {
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// negotiateRetrieve picks the instance representation to return for an Accept header.
+// It returns false when none of the acceptable media types can be satisfied.
+func negotiateRetrieve(accept string) (retrieveRepresentation, bool) {
+	// PS3.18 default for instance resources is multipart/related; type="application/dicom"
+	if strings.TrimSpace(accept) == "" {
+		return retrieveRepresentation{multipart: true}, true
+	}
+
+	for _, r := range parseAccept(accept) {
+		if r.q <= 0 {
+			continue
+		}
+
+		transferSyntax := r.params["transfer-syntax"]
+		if !isValidTransferSyntaxParam(transferSyntax) {
+			continue
+		}
+
+		switch r.mediaType {
+		case "*/*", "multipart/*", mediaTypeMultipartRelated:
+			partType := r.params["type"]
+			if partType != "" && partType != mediaTypeDICOM && partType != "application/*" {
+				continue
+			}
+			return retrieveRepresentation{multipart: true, transferSyntax: transferSyntax}, true
+		case mediaTypeDICOM, "application/*":
+			return retrieveRepresentation{multipart: false, transferSyntax: transferSyntax}, true
+		}
+	}
+
+	return retrieveRepresentation{}, false
+}
+
+// isValidTransferSyntaxParam reports whether a transfer-syntax parameter is "*", empty or a UID
+func isValidTransferSyntaxParam(ts string) bool {
+	if ts == "" || ts == "*" {
+		return true
+	}
+	for _, char := range ts {
+		if (char < '0' || char > '9') && char != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// writeRetrieveResponse writes a single-part retrieved instance in the negotiated representation,
+// wrapping it in multipart/related when the client asked for it.
+// It responds 406 when the instance is in a different transfer syntax than requested
+// and could not be transcoded.
+func writeRetrieveResponse(w http.ResponseWriter, rep retrieveRepresentation, data io.Reader, contentType string) error {
+	partType, ok := negotiatedPartType(rep, contentType)
+	if !ok {
+		apierror.Write(w, http.StatusNotAcceptable, apierror.CodeNotAcceptable, fmt.Sprintf("Transfer syntax %s is not available", rep.transferSyntax))
+		return nil
+	}
+
+	if !rep.multipart {
+		w.Header().Set("Content-Type", partType)
+		w.Header().Set("Accept-Ranges", "bytes")
+		if body, ok := data.(*models.InstanceBody); ok {
+			if body.ContentLength >= 0 {
+				w.Header().Set("Content-Length", strconv.FormatInt(body.ContentLength, 10))
+			}
+			if body.ContentRange != "" {
+				w.Header().Set("Content-Range", body.ContentRange)
+				w.WriteHeader(http.StatusPartialContent)
+			}
+		}
+		_, err := streamCopy(w, data)
+		return err
+	}
+
+	mw, err := newMultipartStreamWriter(w, mediaTypeDICOM, "")
+	if err != nil {
+		return err
+	}
+	if err := mw.WritePart(partType, data); err != nil {
+		return err
+	}
+
+	return mw.Close()
+}
+
+// negotiatedPartType returns the Content-Type of a retrieved instance in the
+// negotiated representation, or false when the PACS returned a different
+// transfer syntax than the client requested
+func negotiatedPartType(rep retrieveRepresentation, contentType string) (string, bool) {
+	transferSyntax := responseTransferSyntax(contentType)
+
+	wanted := rep.transferSyntax
+	if wanted != "" && wanted != "*" {
+		if transferSyntax != "" && transferSyntax != wanted {
+			return "", false
+		}
+		transferSyntax = wanted
+	}
+
+	if transferSyntax == "" {
+		return mediaTypeDICOM, true
+	}
+	return mime.FormatMediaType(mediaTypeDICOM, map[string]string{"transfer-syntax": transferSyntax}), true
+}
+
+// responseTransferSyntax reports the transfer-syntax parameter of a retrieved instance's content type
+func responseTransferSyntax(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return params["transfer-syntax"]
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/audit"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// APIKeyHandler manages the API keys of machine-to-machine callers and
+// authenticates the requests carrying them
+type APIKeyHandler struct {
+	keyService *services.APIKeyService
+}
+
+// NewAPIKeyHandler creates an API key handler
+func NewAPIKeyHandler(keyService *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{keyService: keyService}
+}
+
+// CreateKey handles POST /api/v1/keys. The key itself is only returned here.
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	key, err := h.keyService.CreateKey(ctx, tenantID, &req, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create API key")
+		writeServiceError(w, err, "Failed to create API key")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+// GetKeys handles GET /api/v1/keys
+func (h *APIKeyHandler) GetKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	keys, err := h.keyService.GetKeys(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get API keys")
+		writeServiceError(w, err, "Failed to get API keys")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// RotateKey handles POST /api/v1/keys/{id}/rotate, issuing a replacement key.
+// The body is optional.
+func (h *APIKeyHandler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	keyID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid key ID")
+		return
+	}
+
+	var req models.APIKeyRotateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+			return
+		}
+	}
+
+	key, err := h.keyService.RotateKey(ctx, tenantID, keyID, &req, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Str("key_id", keyID.String()).Msg("Failed to rotate API key")
+		writeServiceError(w, err, "Failed to rotate API key")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+// RevokeKey handles DELETE /api/v1/keys/{id}
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	keyID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid key ID")
+		return
+	}
+
+	if err := h.keyService.RevokeKey(ctx, tenantID, keyID, r.RemoteAddr, r.UserAgent()); err != nil {
+		log.Error().Err(err).Str("key_id", keyID.String()).Msg("Failed to revoke API key")
+		writeServiceError(w, err, "Failed to revoke API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// APIKeys authenticates requests carrying an API key as a bearer token: the
+// request is scoped to the key's tenant and granted the key's scopes, which the
+// routes check. Requests without an API key pass through.
+func (h *APIKeyHandler) APIKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !strings.HasPrefix(token, models.APIKeyTokenPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := h.keyService.ValidateKey(r.Context(), token)
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, "The API key is invalid, expired or revoked")
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to validate API key")
+			apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to validate API key")
+			return
+		}
+
+		if header := r.Header.Get("X-Tenant-ID"); header != "" && header != key.TenantID.String() {
+			apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The API key belongs to another tenant")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), middleware.TenantIDKey, key.TenantID)
+		ctx = middleware.WithScopes(ctx, key.Scopes)
+		ctx = audit.WithUser(ctx, "api-key:"+key.ID.String())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// maxSignedRequestBody bounds the body of a signed request, read in full to
+// verify its hash
+const maxSignedRequestBody = 10 << 20
+
+// SignedRequests authenticates requests signed with a signing key, carrying
+// its ID in X-API-Key-ID and the signature in X-API-Signature: the request is
+// scoped to the key's tenant and granted the key's scopes. Unsigned requests
+// pass through.
+func (h *APIKeyHandler) SignedRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature := r.Header.Get("X-API-Signature")
+		if signature == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedRequestBody+1))
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Failed to read request body")
+			return
+		}
+		if len(body) > maxSignedRequestBody {
+			apierror.Write(w, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "The body of a signed request is too large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key, err := h.keyService.VerifySignedRequest(r.Context(), r.Header.Get("X-API-Key-ID"), signature, r.Method, r.URL.RequestURI(), body)
+		if errors.Is(err, services.ErrInvalidSignature) {
+			w.Header().Set("WWW-Authenticate", `HMAC-SHA256 realm="api"`)
+			apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, "The request signature is invalid, expired or replayed")
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to verify signed request")
+			apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify signed request")
+			return
+		}
+
+		if header := r.Header.Get("X-Tenant-ID"); header != "" && header != key.TenantID.String() {
+			apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The API key belongs to another tenant")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), middleware.TenantIDKey, key.TenantID)
+		ctx = middleware.WithScopes(ctx, key.Scopes)
+		ctx = audit.WithUser(ctx, "api-key:"+key.ID.String())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
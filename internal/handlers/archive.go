@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// RejectStudy rejects a study, or one of its series or instances, with a rejection note
+func (h *ManagementHandler) RejectStudy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.RejectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+	req.StudyUID = chi.URLParam(r, "studyUID")
+
+	if err := h.pacsService.RejectObjects(ctx, tenantID, req, r.RemoteAddr, r.UserAgent()); err != nil {
+		log.Error().Err(err).Str("study_uid", req.StudyUID).Msg("Failed to reject objects")
+		writeServiceError(w, err, "Failed to reject objects")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ExportStudy schedules a study, or one of its series or instances, on an archive exporter
+func (h *ManagementHandler) ExportStudy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+	req.StudyUID = chi.URLParam(r, "studyUID")
+
+	if err := h.pacsService.ExportObjects(ctx, tenantID, req, r.RemoteAddr, r.UserAgent()); err != nil {
+		log.Error().Err(err).Str("study_uid", req.StudyUID).Msg("Failed to export objects")
+		writeServiceError(w, err, "Failed to export objects")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// MergePatients merges a prior patient into a target patient in the archive
+func (h *ManagementHandler) MergePatients(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.PatientMergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.pacsService.MergePatients(ctx, tenantID, req, r.RemoteAddr, r.UserAgent()); err != nil {
+		log.Error().Err(err).Str("patient_id", req.PatientID).Msg("Failed to merge patients")
+		writeServiceError(w, err, "Failed to merge patients")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReindexObjectStore starts rebuilding the object index of the tenant's object store PACS
+func (h *ManagementHandler) ReindexObjectStore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	if err := h.pacsService.ReindexObjectStore(ctx, tenantID, r.RemoteAddr, r.UserAgent()); err != nil {
+		log.Error().Err(err).Msg("Failed to start object store reindex")
+		writeServiceError(w, err, "Failed to start object store reindex")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/rs/zerolog/log"
+)
+
+// VerifyAuditChain handles GET /api/v1/audit/verify, checking that the
+// tenant's audit log entries have not been altered, removed or reordered since
+// they were recorded
+func (h *ManagementHandler) VerifyAuditChain(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	result, err := h.pacsService.VerifyAuditChain(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to verify audit chain")
+		writeServiceError(w, err, "Failed to verify audit chain")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
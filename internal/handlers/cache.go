@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// PurgeCache handles DELETE /api/v1/cache, clearing cached objects of the tenant
+// selected by the study_uid, series_uid and resource query parameters, or all
+// of them with purge_tenant=true
+func (h *ManagementHandler) PurgeCache(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	query := r.URL.Query()
+	req := models.CachePurgeRequest{
+		StudyUID:  query.Get("study_uid"),
+		SeriesUID: query.Get("series_uid"),
+		Resource:  models.CacheResource(query.Get("resource")),
+	}
+	if value := query.Get("purge_tenant"); value != "" {
+		purge, err := strconv.ParseBool(value)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "purge_tenant must be true or false")
+			return
+		}
+		req.PurgeTenant = purge
+	}
+
+	if err := h.pacsService.PurgeCache(ctx, tenantID, req, r.RemoteAddr, r.UserAgent()); err != nil {
+		log.Error().Err(err).Str("study_uid", req.StudyUID).Msg("Failed to purge cache")
+		writeServiceError(w, err, "Failed to purge cache")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCacheStats handles GET /api/v1/cache/stats, reporting the tenant's cache
+// hits, misses, hit ratio, evictions and stored bytes per tier
+func (h *ManagementHandler) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.pacsService.CacheStats(ctx, tenantID))
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultCacheMetricsPeriod is the period reported when no from is given
+const defaultCacheMetricsPeriod = 24 * time.Hour
+
+// CacheMetricsHandler reports the recorded cache reads of a tenant
+type CacheMetricsHandler struct {
+	metricsService *services.CacheMetricsService
+}
+
+// NewCacheMetricsHandler creates a cache metrics handler
+func NewCacheMetricsHandler(metricsService *services.CacheMetricsService) *CacheMetricsHandler {
+	return &CacheMetricsHandler{metricsService: metricsService}
+}
+
+// GetCacheMetrics handles GET /api/v1/cache/metrics, aggregating the tenant's
+// cache reads per tier between the RFC 3339 from and to query parameters,
+// which default to the last 24 hours
+func (h *CacheMetricsHandler) GetCacheMetrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	query := r.URL.Query()
+	to := time.Now()
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "to must be an RFC 3339 time")
+			return
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultCacheMetricsPeriod)
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "from must be an RFC 3339 time")
+			return
+		}
+		from = parsed
+	}
+
+	summary, err := h.metricsService.GetCacheMetrics(ctx, tenantID, from, to)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get cache metrics")
+		writeServiceError(w, err, "Failed to get cache metrics")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// VerificationHandler starts cache verifications and reports what they found
+type VerificationHandler struct {
+	verificationService *services.VerificationService
+}
+
+// NewVerificationHandler creates a cache verification handler
+func NewVerificationHandler(verificationService *services.VerificationService) *VerificationHandler {
+	return &VerificationHandler{verificationService: verificationService}
+}
+
+// VerifyCache handles POST /api/v1/cache/verifications, queueing a comparison
+// of the cache with the PACS for the studies of the tenant's recent days. An
+// empty body uses the deployment's lookback and doesn't repair.
+func (h *VerificationHandler) VerifyCache(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.CacheVerificationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+			return
+		}
+	}
+
+	verification, err := h.verificationService.VerifyCache(ctx, tenantID, &req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to start cache verification")
+		writeServiceError(w, err, "Failed to start cache verification")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/cache/verifications/"+verification.ID.String())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(verification)
+}
+
+// GetVerification handles GET /api/v1/cache/verifications/{id}
+func (h *VerificationHandler) GetVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	verificationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid cache verification ID")
+		return
+	}
+
+	verification, err := h.verificationService.GetVerification(ctx, tenantID, verificationID)
+	if err != nil {
+		log.Error().Err(err).Str("verification_id", verificationID.String()).Msg("Failed to get cache verification")
+		writeServiceError(w, err, "Failed to get cache verification")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(verification)
+}
@@ -2,14 +2,20 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
 	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
 	"github.com/otcheredev/ris-dicom-connector/internal/models"
 	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
 	"github.com/rs/zerolog/log"
 )
 
@@ -28,63 +34,166 @@ func (h *DICOMWebHandler) SearchStudies(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 	tenantID, ok := middleware.GetTenantID(ctx)
 	if !ok {
-		http.Error(w, "Tenant ID not found", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
 		return
 	}
 
+	ctx, answer := services.WithIndexAnswer(ctx)
+
 	// Parse query parameters
-	params := models.QueryParams{
-		PatientID:        r.URL.Query().Get("PatientID"),
-		PatientName:      r.URL.Query().Get("PatientName"),
-		StudyDate:        r.URL.Query().Get("StudyDate"),
-		AccessionNumber:  r.URL.Query().Get("AccessionNumber"),
-		Modality:         r.URL.Query().Get("ModalitiesInStudy"),
-		StudyDescription: r.URL.Query().Get("StudyDescription"),
+	params, err := parseStudyQuery(r.URL.Query())
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
 	}
 
-	if limit := r.URL.Query().Get("limit"); limit != "" {
-		params.Limit, _ = strconv.Atoi(limit)
-	}
-	if offset := r.URL.Query().Get("offset"); offset != "" {
-		params.Offset, _ = strconv.Atoi(offset)
+	// fanout=true searches every active PACS of the tenant instead of the one
+	// the request is routed to
+	find := h.pacsService.FindStudies
+	if value := r.URL.Query().Get("fanout"); value != "" {
+		fanOut, err := strconv.ParseBool(value)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "fanout must be true or false")
+			return
+		}
+		if fanOut {
+			find = h.pacsService.FindStudiesAllPACS
+		}
 	}
 
-	studies, err := h.pacsService.FindStudies(ctx, tenantID, params)
+	studies, truncated, err := find(ctx, tenantID, params)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to search studies")
-		http.Error(w, "Failed to search studies", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to search studies")
 		return
 	}
 
+	if truncated {
+		w.Header().Set("Warning", fmt.Sprintf("299 %s %q", r.Host,
+			"The number of results exceeded the maximum supported by the server. Additional results can be requested."))
+	}
+
+	writeIndexAnswer(w, answer)
 	w.Header().Set("Content-Type", "application/dicom+json")
 	json.NewEncoder(w).Encode(studies)
 }
 
+// writeIndexAnswer tells the client a search was answered from the connector's
+// study index and when the PACS last listed the results
+func writeIndexAnswer(w http.ResponseWriter, answer *services.IndexAnswer) {
+	if answer.IndexedAt == nil {
+		return
+	}
+	w.Header().Set("X-Query-Source", "index")
+	w.Header().Set("X-Index-Updated-At", answer.IndexedAt.UTC().Format(time.RFC3339))
+}
+
 // GetStudyMetadata handles WADO-RS metadata retrieval
 func (h *DICOMWebHandler) GetStudyMetadata(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	tenantID, ok := middleware.GetTenantID(ctx)
 	if !ok {
-		http.Error(w, "Tenant ID not found", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
 		return
 	}
 
 	studyUID := chi.URLParam(r, "studyUID")
 	if studyUID == "" {
-		http.Error(w, "Study UID is required", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID is required")
 		return
 	}
 
-	// For now, return series instead of full metadata
-	series, err := h.pacsService.FindSeries(ctx, tenantID, studyUID)
+	metadata, err := h.pacsService.GetStudyMetadata(ctx, tenantID, studyUID)
 	if err != nil {
 		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to get study metadata")
-		http.Error(w, "Failed to get study metadata", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to get study metadata")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/dicom+json")
-	json.NewEncoder(w).Encode(series)
+	writeJSONWithETag(w, r, "application/dicom+json", metadataDatasets(metadata))
+}
+
+// GetSeriesMetadata handles WADO-RS series metadata retrieval
+func (h *DICOMWebHandler) GetSeriesMetadata(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	seriesUID := chi.URLParam(r, "seriesUID")
+	if studyUID == "" || seriesUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID and Series UID are required")
+		return
+	}
+
+	metadata, err := h.pacsService.GetSeriesMetadata(ctx, tenantID, studyUID, seriesUID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("study_uid", studyUID).
+			Str("series_uid", seriesUID).
+			Msg("Failed to get series metadata")
+		writeServiceError(w, err, "Failed to get series metadata")
+		return
+	}
+
+	writeJSONWithETag(w, r, "application/dicom+json", metadataDatasets(metadata))
+}
+
+// GetInstanceMetadata handles WADO-RS instance metadata retrieval
+func (h *DICOMWebHandler) GetInstanceMetadata(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	seriesUID := chi.URLParam(r, "seriesUID")
+	instanceUID := chi.URLParam(r, "instanceUID")
+	if studyUID == "" || seriesUID == "" || instanceUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID, Series UID, and Instance UID are required")
+		return
+	}
+
+	metadata, err := h.pacsService.GetInstanceMetadata(ctx, tenantID, studyUID, seriesUID, instanceUID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("study_uid", studyUID).
+			Str("series_uid", seriesUID).
+			Str("instance_uid", instanceUID).
+			Msg("Failed to get instance metadata")
+		writeServiceError(w, err, "Failed to get instance metadata")
+		return
+	}
+
+	writeJSONWithETag(w, r, "application/dicom+json", metadataDatasets([]models.Metadata{*metadata}))
+}
+
+// metadataDatasets converts adapter metadata into DICOM JSON datasets, making sure
+// the SOP identifiers are present even when the PACS omitted them
+func metadataDatasets(metadata []models.Metadata) []map[string]interface{} {
+	datasets := make([]map[string]interface{}, 0, len(metadata))
+	for _, m := range metadata {
+		dataset := make(map[string]interface{}, len(m.Attributes)+3)
+		for tag, attr := range m.Attributes {
+			dataset[tag] = attr
+		}
+		if _, ok := dataset["00080016"]; !ok && m.SOPClassUID != "" {
+			dataset["00080016"] = map[string]interface{}{"vr": "UI", "Value": []string{m.SOPClassUID}}
+		}
+		if _, ok := dataset["00080018"]; !ok && m.SOPInstanceUID != "" {
+			dataset["00080018"] = map[string]interface{}{"vr": "UI", "Value": []string{m.SOPInstanceUID}}
+		}
+		if _, ok := dataset["00083002"]; !ok && m.TransferSyntaxUID != "" {
+			dataset["00083002"] = map[string]interface{}{"vr": "UI", "Value": []string{m.TransferSyntaxUID}}
+		}
+		datasets = append(datasets, dataset)
+	}
+	return datasets
 }
 
 // SearchSeries handles QIDO-RS series search
@@ -92,23 +201,25 @@ func (h *DICOMWebHandler) SearchSeries(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	tenantID, ok := middleware.GetTenantID(ctx)
 	if !ok {
-		http.Error(w, "Tenant ID not found", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
 		return
 	}
 
 	studyUID := chi.URLParam(r, "studyUID")
 	if studyUID == "" {
-		http.Error(w, "Study UID is required", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID is required")
 		return
 	}
 
+	ctx, answer := services.WithIndexAnswer(ctx)
 	series, err := h.pacsService.FindSeries(ctx, tenantID, studyUID)
 	if err != nil {
 		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to search series")
-		http.Error(w, "Failed to search series", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to search series")
 		return
 	}
 
+	writeIndexAnswer(w, answer)
 	w.Header().Set("Content-Type", "application/dicom+json")
 	json.NewEncoder(w).Encode(series)
 }
@@ -118,7 +229,7 @@ func (h *DICOMWebHandler) SearchInstances(w http.ResponseWriter, r *http.Request
 	ctx := r.Context()
 	tenantID, ok := middleware.GetTenantID(ctx)
 	if !ok {
-		http.Error(w, "Tenant ID not found", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
 		return
 	}
 
@@ -126,20 +237,22 @@ func (h *DICOMWebHandler) SearchInstances(w http.ResponseWriter, r *http.Request
 	seriesUID := chi.URLParam(r, "seriesUID")
 
 	if studyUID == "" || seriesUID == "" {
-		http.Error(w, "Study UID and Series UID are required", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID and Series UID are required")
 		return
 	}
 
+	ctx, answer := services.WithIndexAnswer(ctx)
 	instances, err := h.pacsService.FindInstances(ctx, tenantID, studyUID, seriesUID)
 	if err != nil {
 		log.Error().Err(err).
 			Str("study_uid", studyUID).
 			Str("series_uid", seriesUID).
 			Msg("Failed to search instances")
-		http.Error(w, "Failed to search instances", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to search instances")
 		return
 	}
 
+	writeIndexAnswer(w, answer)
 	w.Header().Set("Content-Type", "application/dicom+json")
 	json.NewEncoder(w).Encode(instances)
 }
@@ -149,7 +262,7 @@ func (h *DICOMWebHandler) RetrieveInstance(w http.ResponseWriter, r *http.Reques
 	ctx := r.Context()
 	tenantID, ok := middleware.GetTenantID(ctx)
 	if !ok {
-		http.Error(w, "Tenant ID not found", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
 		return
 	}
 
@@ -158,22 +271,355 @@ func (h *DICOMWebHandler) RetrieveInstance(w http.ResponseWriter, r *http.Reques
 	instanceUID := chi.URLParam(r, "instanceUID")
 
 	if studyUID == "" || seriesUID == "" || instanceUID == "" {
-		http.Error(w, "Study UID, Series UID, and Instance UID are required", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID, Series UID, and Instance UID are required")
+		return
+	}
+
+	rep, ok := negotiateRetrieve(r.Header.Get("Accept"))
+	if !ok {
+		apierror.Write(w, http.StatusNotAcceptable, apierror.CodeNotAcceptable, "Requested representation is not supported")
 		return
 	}
 
-	data, contentType, err := h.pacsService.GetInstance(ctx, tenantID, studyUID, seriesUID, instanceUID)
+	opts := models.RetrieveOptions{TransferSyntax: rep.transferSyntax}
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" && !rep.multipart {
+		opts.Range = rangeHeader
+	}
+	data, contentType, err := h.pacsService.GetInstance(ctx, tenantID, studyUID, seriesUID, instanceUID, opts)
 	if err != nil {
 		log.Error().Err(err).
 			Str("study_uid", studyUID).
 			Str("series_uid", seriesUID).
 			Str("instance_uid", instanceUID).
 			Msg("Failed to retrieve instance")
-		http.Error(w, "Failed to retrieve instance", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to retrieve instance")
 		return
 	}
 	defer data.Close()
 
-	w.Header().Set("Content-Type", contentType)
-	io.Copy(w, data)
+	if err := writeRetrieveResponse(w, rep, data, contentType); err != nil {
+		log.Error().Err(err).
+			Str("instance_uid", instanceUID).
+			Msg("Failed to write instance response")
+	}
+}
+
+// RetrieveSeries handles WADO-RS series retrieval as a streamed multipart/related response
+func (h *DICOMWebHandler) RetrieveSeries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	seriesUID := chi.URLParam(r, "seriesUID")
+	if studyUID == "" || seriesUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID and Series UID are required")
+		return
+	}
+
+	h.streamInstances(w, r, func(opts models.RetrieveOptions, visit services.InstanceVisitor) error {
+		return h.pacsService.RetrieveSeries(ctx, tenantID, studyUID, seriesUID, opts, visit)
+	})
+}
+
+// RetrieveStudy handles WADO-RS study retrieval as a streamed multipart/related response
+func (h *DICOMWebHandler) RetrieveStudy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	if studyUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID is required")
+		return
+	}
+
+	h.streamInstances(w, r, func(opts models.RetrieveOptions, visit services.InstanceVisitor) error {
+		return h.pacsService.RetrieveStudy(ctx, tenantID, studyUID, opts, visit)
+	})
+}
+
+// streamInstances negotiates a multipart representation and writes every
+// retrieved instance as its own part as soon as it arrives
+func (h *DICOMWebHandler) streamInstances(w http.ResponseWriter, r *http.Request, retrieve func(models.RetrieveOptions, services.InstanceVisitor) error) {
+	rep, ok := negotiateRetrieve(r.Header.Get("Accept"))
+	if !ok || !rep.multipart {
+		apierror.Write(w, http.StatusNotAcceptable, apierror.CodeNotAcceptable, "Multi-instance resources are only available as multipart/related")
+		return
+	}
+
+	mw, err := newMultipartStreamWriter(w, mediaTypeDICOM, "")
+	if err != nil {
+		writeServiceError(w, err, "Failed to prepare response")
+		return
+	}
+
+	skipped := 0
+	err = retrieve(models.RetrieveOptions{TransferSyntax: rep.transferSyntax}, func(instance models.Instance, data io.ReadCloser, contentType string) error {
+		partType, ok := negotiatedPartType(rep, contentType)
+		if !ok {
+			skipped++
+			log.Warn().
+				Str("instance_uid", instance.SOPInstanceUID).
+				Str("content_type", contentType).
+				Msg("Skipping instance not available in requested transfer syntax")
+			return nil
+		}
+		return mw.WritePart(partType, data)
+	})
+
+	if err != nil {
+		log.Error().Err(err).Int("parts", mw.Parts()).Msg("Failed to retrieve instances")
+		if mw.Parts() == 0 {
+			writeServiceError(w, err, "Failed to retrieve instances")
+		}
+		// Once parts have been sent the status is committed; the missing closing
+		// boundary tells the client the response is incomplete
+		return
+	}
+
+	if mw.Parts() == 0 && skipped > 0 {
+		apierror.Write(w, http.StatusNotAcceptable, apierror.CodeNotAcceptable, fmt.Sprintf("Transfer syntax %s is not available", rep.transferSyntax))
+		return
+	}
+
+	if err := mw.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to finish multipart response")
+	}
+}
+
+// Capabilities describes the DICOMweb services available for the tenant's PACS
+func (h *DICOMWebHandler) Capabilities(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	capabilities, err := h.pacsService.GetCapabilities(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get capabilities")
+		writeServiceError(w, err, "Failed to get capabilities")
+		return
+	}
+
+	w.Header().Set("Allow", "GET, OPTIONS")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(capabilities)
+}
+
+// RetrieveInstanceThumbnail handles WADO-RS instance thumbnail retrieval
+func (h *DICOMWebHandler) RetrieveInstanceThumbnail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	seriesUID := chi.URLParam(r, "seriesUID")
+	instanceUID := chi.URLParam(r, "instanceUID")
+
+	if studyUID == "" || seriesUID == "" || instanceUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID, Series UID, and Instance UID are required")
+		return
+	}
+
+	opts, err := parseThumbnailOptions(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	data, err := h.pacsService.GetThumbnail(ctx, tenantID, studyUID, seriesUID, instanceUID, opts)
+	if err != nil {
+		log.Error().Err(err).
+			Str("study_uid", studyUID).
+			Str("series_uid", seriesUID).
+			Str("instance_uid", instanceUID).
+			Msg("Failed to retrieve instance thumbnail")
+		writeServiceError(w, err, "Failed to retrieve thumbnail")
+		return
+	}
+
+	writeThumbnail(w, data)
+}
+
+// RetrieveSeriesThumbnail handles WADO-RS series thumbnail retrieval
+func (h *DICOMWebHandler) RetrieveSeriesThumbnail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	seriesUID := chi.URLParam(r, "seriesUID")
+
+	if studyUID == "" || seriesUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID and Series UID are required")
+		return
+	}
+
+	opts, err := parseThumbnailOptions(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	data, err := h.pacsService.GetSeriesThumbnail(ctx, tenantID, studyUID, seriesUID, opts)
+	if err != nil {
+		log.Error().Err(err).
+			Str("study_uid", studyUID).
+			Str("series_uid", seriesUID).
+			Msg("Failed to retrieve series thumbnail")
+		writeServiceError(w, err, "Failed to retrieve thumbnail")
+		return
+	}
+
+	writeThumbnail(w, data)
+}
+
+// RetrieveStudyThumbnail handles WADO-RS study thumbnail retrieval
+func (h *DICOMWebHandler) RetrieveStudyThumbnail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	if studyUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID is required")
+		return
+	}
+
+	opts, err := parseThumbnailOptions(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	data, err := h.pacsService.GetStudyThumbnail(ctx, tenantID, studyUID, opts)
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to retrieve study thumbnail")
+		writeServiceError(w, err, "Failed to retrieve thumbnail")
+		return
+	}
+
+	writeThumbnail(w, data)
+}
+
+// parseThumbnailOptions reads the PS3.18 viewport (vw,vh) and quality query parameters
+func parseThumbnailOptions(r *http.Request) (thumbnail.Options, error) {
+	opts := thumbnail.Options{
+		Size:    thumbnail.DefaultSize,
+		Quality: thumbnail.DefaultQuality,
+	}
+
+	if viewport := r.URL.Query().Get("viewport"); viewport != "" {
+		parts := strings.Split(viewport, ",")
+		if len(parts) < 2 {
+			return opts, fmt.Errorf("viewport must be vw,vh")
+		}
+		vw, errW := strconv.Atoi(strings.TrimSpace(parts[0]))
+		vh, errH := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if errW != nil || errH != nil || vw <= 0 || vh <= 0 {
+			return opts, fmt.Errorf("invalid viewport: %s", viewport)
+		}
+		opts.Size = vw
+		if vh > vw {
+			opts.Size = vh
+		}
+		if opts.Size > thumbnail.MaxSize {
+			opts.Size = thumbnail.MaxSize
+		}
+	}
+
+	if quality := r.URL.Query().Get("quality"); quality != "" {
+		q, err := strconv.Atoi(quality)
+		if err != nil || q < 1 || q > 100 {
+			return opts, fmt.Errorf("quality must be between 1 and 100")
+		}
+		opts.Quality = q
+	}
+
+	return opts, nil
+}
+
+func writeThumbnail(w http.ResponseWriter, data []byte) {
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+// DeleteStudy removes a study from the tenant's PACS
+func (h *DICOMWebHandler) DeleteStudy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	if studyUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID is required")
+		return
+	}
+
+	err := h.pacsService.DeleteStudy(ctx, tenantID, studyUID, r.RemoteAddr, r.UserAgent())
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "Study not found")
+		return
+	case errors.Is(err, services.ErrNotSupported):
+		apierror.Write(w, http.StatusNotImplemented, apierror.CodeNotSupported, "Study deletion is not supported by the configured PACS")
+		return
+	case err != nil:
+		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to delete study")
+		writeServiceError(w, err, "Failed to delete study")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ObjectExists answers HEAD requests on study, series and instance resources
+// without transferring a body
+func (h *DICOMWebHandler) ObjectExists(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	seriesUID := chi.URLParam(r, "seriesUID")
+	instanceUID := chi.URLParam(r, "instanceUID")
+
+	exists, err := h.pacsService.ObjectExists(ctx, tenantID, studyUID, seriesUID, instanceUID)
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to probe object")
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+)
+
+// writeServiceError maps a service error to a DICOMweb-conformant status code and
+// problem response. Unclassified errors become 500 with the given message.
+func writeServiceError(w http.ResponseWriter, err error, message string) {
+	status, code, detail := serviceErrorStatus(err, message)
+	if seconds := retryAfter(err); seconds != "" {
+		w.Header().Set("Retry-After", seconds)
+	}
+	apierror.Write(w, status, code, detail)
+}
+
+// retryAfter returns the Retry-After seconds for errors that clear by
+// themselves, or an empty string
+func retryAfter(err error) string {
+	switch {
+	case errors.Is(err, services.ErrUnavailable):
+		return "30"
+	case errors.Is(err, services.ErrRateLimited):
+		return "5"
+	}
+	return ""
+}
+
+// serviceErrorStatus classifies a service error into a status code, problem code
+// and detail message, so facades other than DICOMweb can report it in their own format
+func serviceErrorStatus(err error, message string) (int, string, string) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, apierror.CodeUpstreamTimeout, "The PACS did not respond in time"
+	case errors.Is(err, services.ErrNoPACSConfigured):
+		return http.StatusServiceUnavailable, apierror.CodePACSNotConfigured, "No PACS is configured for this tenant"
+	case errors.Is(err, services.ErrCircuitOpen):
+		return http.StatusServiceUnavailable, apierror.CodePACSUnavailable, "The PACS is failing; requests to it are paused"
+	case errors.Is(err, services.ErrUnavailable):
+		return http.StatusServiceUnavailable, apierror.CodePACSUnavailable, "The PACS is unavailable"
+	case errors.Is(err, services.ErrRateLimited):
+		return http.StatusTooManyRequests, apierror.CodeRateLimited, "The PACS is receiving too many requests; retry later"
+	case errors.Is(err, services.ErrStudyAccessDenied):
+		return http.StatusForbidden, apierror.CodeForbidden, "Access to the study is denied"
+	case errors.Is(err, services.ErrNotFound),
+		errors.Is(err, services.ErrWorkitemNotFound),
+		errors.Is(err, services.ErrWebhookNotFound),
+		errors.Is(err, services.ErrExportNotFound),
+		errors.Is(err, services.ErrPrefetchJobNotFound),
+		errors.Is(err, services.ErrDestinationNotFound),
+		errors.Is(err, services.ErrRoutingRuleNotFound),
+		errors.Is(err, services.ErrPACSConfigNotFound),
+		errors.Is(err, services.ErrStudyNotPinned),
+		errors.Is(err, services.ErrRetrieveJobNotFound),
+		errors.Is(err, services.ErrVerificationNotFound),
+		errors.Is(err, services.ErrSyncStateNotFound),
+		errors.Is(err, services.ErrAPIKeyNotFound),
+		errors.Is(err, services.ErrTenantNotFound):
+		return http.StatusNotFound, apierror.CodeNotFound, "The requested resource was not found"
+	case errors.Is(err, services.ErrRangeNotSatisfiable):
+		return http.StatusRequestedRangeNotSatisfiable, apierror.CodeRangeNotSatisfiable, "Requested range not satisfiable"
+	case errors.Is(err, services.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "The result is too large; narrow the query"
+	case errors.Is(err, services.ErrInvalidRequest):
+		return http.StatusBadRequest, apierror.CodeInvalidRequest, "The PACS rejected the request"
+	case errors.Is(err, services.ErrInvalidWorkitem),
+		errors.Is(err, services.ErrInvalidArchiveRequest),
+		errors.Is(err, services.ErrInvalidDocumentSetRequest),
+		errors.Is(err, services.ErrTransactionUIDMismatch),
+		errors.Is(err, services.ErrInvalidWebhook),
+		errors.Is(err, services.ErrInvalidDestination),
+		errors.Is(err, services.ErrInvalidViewerGrantRequest),
+		errors.Is(err, services.ErrInvalidPrefetchRequest),
+		errors.Is(err, services.ErrInvalidCachePurge),
+		errors.Is(err, services.ErrInvalidMetricsPeriod),
+		errors.Is(err, services.ErrInvalidUsagePeriod),
+		errors.Is(err, services.ErrInvalidRoutingRule),
+		errors.Is(err, services.ErrInvalidPACSConfig),
+		errors.Is(err, services.ErrInvalidTestHistoryQuery),
+		errors.Is(err, services.ErrInvalidTenantSettings),
+		errors.Is(err, services.ErrInvalidDeidentContext),
+		errors.Is(err, services.ErrInvalidPin),
+		errors.Is(err, services.ErrInvalidRetrieveJob),
+		errors.Is(err, services.ErrInvalidVerificationRequest),
+		errors.Is(err, services.ErrInvalidMetadataExport),
+		errors.Is(err, services.ErrInvalidAPIKeyRequest),
+		errors.Is(err, services.ErrInvalidTenantRequest):
+		return http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error()
+	case errors.Is(err, services.ErrWorkitemExists),
+		errors.Is(err, services.ErrInvalidStateTransition),
+		errors.Is(err, services.ErrWorkitemStateConflict),
+		errors.Is(err, services.ErrRetrieveJobState),
+		errors.Is(err, services.ErrVerificationBusy),
+		errors.Is(err, services.ErrPACSConfigState),
+		errors.Is(err, services.ErrAPIKeyRevoked),
+		errors.Is(err, services.ErrTenantState):
+		return http.StatusConflict, apierror.CodeConflict, err.Error()
+	case errors.Is(err, services.ErrNotSupported):
+		return http.StatusNotImplemented, apierror.CodeNotSupported, "The operation is not supported by the configured PACS"
+	default:
+		return http.StatusInternalServerError, apierror.CodeInternal, message
+	}
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// ExportHandler starts study exports and serves the finished archives
+type ExportHandler struct {
+	exportService *services.ExportService
+	publicURL     string
+}
+
+// NewExportHandler creates an export handler. Download links are built from
+// publicURL, or from the request host when it is empty.
+func NewExportHandler(exportService *services.ExportService, publicURL string) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+		publicURL:     publicURL,
+	}
+}
+
+// ExportStudy handles POST /api/v1/export/studies/{studyUID}, queueing the
+// export of a study as a ZIP archive with a DICOMDIR. An optional body
+// {"destination_id": ...} pushes the archive to an export destination, and
+// {"deidentify": "<context>"} de-identifies the study for a research context.
+func (h *ExportHandler) ExportStudy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.StudyExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	job, err := h.exportService.CreateStudyExport(ctx, tenantID, studyUID, req.DestinationID, req.Deidentify, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to start study export")
+		writeServiceError(w, err, "Failed to start study export")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/export/jobs/"+job.ID.String())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetExportJob handles GET /api/v1/export/jobs/{id}; completed jobs include
+// their time-limited download link
+func (h *ExportHandler) GetExportJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid export job ID")
+		return
+	}
+
+	job, err := h.exportService.GetExport(ctx, tenantID, jobID)
+	if err != nil {
+		log.Error().Err(err).Str("export_id", jobID.String()).Msg("Failed to get export job")
+		writeServiceError(w, err, "Failed to get export job")
+		return
+	}
+	if job.DownloadURL != "" {
+		job.DownloadURL = externalBaseURL(r, h.publicURL) + job.DownloadURL
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// DownloadExport handles GET /api/v1/export/downloads/{id}. The signed link
+// authorizes the download, so it can be opened without a tenant header.
+func (h *ExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid export job ID")
+		return
+	}
+
+	query := r.URL.Query()
+	file, job, err := h.exportService.OpenDownload(ctx, jobID, query.Get("expires"), query.Get("signature"), r.RemoteAddr, r.UserAgent())
+	switch {
+	case errors.Is(err, services.ErrInvalidDownloadLink):
+		apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The download link is invalid or has expired")
+		return
+	case err != nil:
+		log.Error().Err(err).Str("export_id", jobID.String()).Msg("Failed to open export archive")
+		writeServiceError(w, err, "Failed to open export archive")
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="study-%s.zip"`, job.ID))
+	http.ServeContent(deadlineResponseWriter{w}, r, "", *job.CompletedAt, file)
+}
+
+// ExportMetadata handles POST /api/v1/export/metadata, queueing the export of
+// the study and series metadata of the tenant's studies dated from {"from"} to
+// {"to"} (YYYY-MM-DD) as NDJSON. An optional "destination_id" pushes the file
+// to an export destination.
+func (h *ExportHandler) ExportMetadata(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.MetadataExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	job, err := h.exportService.CreateMetadataExport(ctx, tenantID, &req, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to start metadata export")
+		writeServiceError(w, err, "Failed to start metadata export")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/export/metadata/"+job.ID.String())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetMetadataExportJob handles GET /api/v1/export/metadata/{id}, reporting the
+// days and studies exported so far; completed jobs include their time-limited
+// download link
+func (h *ExportHandler) GetMetadataExportJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid export job ID")
+		return
+	}
+
+	job, err := h.exportService.GetMetadataExport(ctx, tenantID, jobID)
+	if err != nil {
+		log.Error().Err(err).Str("export_id", jobID.String()).Msg("Failed to get metadata export job")
+		writeServiceError(w, err, "Failed to get metadata export job")
+		return
+	}
+	if job.DownloadURL != "" {
+		job.DownloadURL = externalBaseURL(r, h.publicURL) + job.DownloadURL
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// DownloadMetadataExport handles GET /api/v1/export/metadata/downloads/{id}.
+// The signed link authorizes the download, so it can be opened without a tenant header.
+func (h *ExportHandler) DownloadMetadataExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid export job ID")
+		return
+	}
+
+	query := r.URL.Query()
+	file, job, err := h.exportService.OpenMetadataDownload(ctx, jobID, query.Get("expires"), query.Get("signature"), r.RemoteAddr, r.UserAgent())
+	switch {
+	case errors.Is(err, services.ErrInvalidDownloadLink):
+		apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The download link is invalid or has expired")
+		return
+	case err != nil:
+		log.Error().Err(err).Str("export_id", jobID.String()).Msg("Failed to open metadata export")
+		writeServiceError(w, err, "Failed to open metadata export")
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="metadata-%s-%s.ndjson"`, job.From, job.To))
+	http.ServeContent(deadlineResponseWriter{w}, r, "", *job.CompletedAt, file)
+}
+
+// CreateDestination handles POST /api/v1/export/destinations, registering
+// external storage that exports can be pushed to. Credentials are never returned.
+func (h *ExportHandler) CreateDestination(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.ExportDestinationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	destination, err := h.exportService.CreateDestination(ctx, tenantID, &req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create export destination")
+		writeServiceError(w, err, "Failed to create export destination")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(destination)
+}
+
+// GetDestinations handles GET /api/v1/export/destinations
+func (h *ExportHandler) GetDestinations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	destinations, err := h.exportService.GetDestinations(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get export destinations")
+		writeServiceError(w, err, "Failed to get export destinations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(destinations)
+}
+
+// DeleteDestination handles DELETE /api/v1/export/destinations/{id}
+func (h *ExportHandler) DeleteDestination(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	destinationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid export destination ID")
+		return
+	}
+
+	if err := h.exportService.DeleteDestination(ctx, tenantID, destinationID); err != nil {
+		log.Error().Err(err).Str("destination_id", destinationID.String()).Msg("Failed to delete export destination")
+		writeServiceError(w, err, "Failed to delete export destination")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otcheredev/ris-dicom-connector/internal/fhir"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// FHIRHandler serves FHIR R4 ImagingStudy resources built from QIDO results
+type FHIRHandler struct {
+	pacsService *services.PACSService
+	publicURL   string
+}
+
+// NewFHIRHandler creates a FHIR handler. Links in responses are built from
+// publicURL, or from the request host when it is empty.
+func NewFHIRHandler(pacsService *services.PACSService, publicURL string) *FHIRHandler {
+	return &FHIRHandler{
+		pacsService: pacsService,
+		publicURL:   strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+// SearchImagingStudy handles GET /fhir/ImagingStudy
+func (h *FHIRHandler) SearchImagingStudy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		writeOperationOutcome(w, http.StatusBadRequest, "Tenant ID not found")
+		return
+	}
+
+	params, err := parseImagingStudySearch(r.URL.Query())
+	if err != nil {
+		writeOperationOutcome(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	studies, truncated, err := h.pacsService.FindStudies(ctx, tenantID, params)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search imaging studies")
+		writeFHIRServiceError(w, err, "Failed to search imaging studies")
+		return
+	}
+
+	base := h.baseURL(r)
+	wadoURL := base + "/dicom-web"
+
+	bundle := fhir.NewSearchBundle()
+	bundle.Link = append(bundle.Link, fhir.BundleLink{Relation: "self", URL: base + r.URL.RequestURI()})
+	for _, study := range studies {
+		bundle.AddMatch(base+"/fhir/ImagingStudy/"+study.StudyInstanceUID, fhir.NewImagingStudy(study, nil, wadoURL))
+	}
+
+	// A full page may be followed by more matches; a short one is the last
+	if truncated || (params.Limit > 0 && len(studies) == params.Limit) {
+		next := r.URL.Query()
+		next.Set("_offset", strconv.Itoa(params.Offset+len(studies)))
+		if params.Limit <= 0 {
+			next.Set("_count", strconv.Itoa(len(studies)))
+		}
+		bundle.Link = append(bundle.Link, fhir.BundleLink{Relation: "next", URL: base + r.URL.Path + "?" + next.Encode()})
+	} else {
+		total := params.Offset + len(studies)
+		bundle.Total = &total
+	}
+
+	w.Header().Set("Content-Type", fhir.ContentType)
+	json.NewEncoder(w).Encode(bundle)
+}
+
+// ReadImagingStudy handles GET /fhir/ImagingStudy/{id}, where the id is the Study Instance UID
+func (h *FHIRHandler) ReadImagingStudy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		writeOperationOutcome(w, http.StatusBadRequest, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "id")
+	if studyUID == "" {
+		writeOperationOutcome(w, http.StatusBadRequest, "ImagingStudy id is required")
+		return
+	}
+
+	studies, _, err := h.pacsService.FindStudies(ctx, tenantID, models.QueryParams{
+		Filters: map[string]string{"0020000D": studyUID},
+		Limit:   1,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to read imaging study")
+		writeFHIRServiceError(w, err, "Failed to read imaging study")
+		return
+	}
+	if len(studies) == 0 {
+		writeOperationOutcome(w, http.StatusNotFound, fmt.Sprintf("ImagingStudy/%s is not known", studyUID))
+		return
+	}
+
+	series, err := h.pacsService.FindSeries(ctx, tenantID, studyUID)
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to find imaging study series")
+		writeFHIRServiceError(w, err, "Failed to read imaging study")
+		return
+	}
+
+	details := make([]fhir.SeriesDetail, 0, len(series))
+	for _, s := range series {
+		instances, err := h.pacsService.FindInstances(ctx, tenantID, studyUID, s.SeriesInstanceUID)
+		if err != nil {
+			log.Error().Err(err).
+				Str("study_uid", studyUID).
+				Str("series_uid", s.SeriesInstanceUID).
+				Msg("Failed to find imaging study instances")
+			writeFHIRServiceError(w, err, "Failed to read imaging study")
+			return
+		}
+		details = append(details, fhir.SeriesDetail{Series: s, Instances: instances})
+	}
+
+	resource := fhir.NewImagingStudy(studies[0], details, h.baseURL(r)+"/dicom-web")
+	writeJSONWithETag(w, r, fhir.ContentType, resource)
+}
+
+// baseURL returns the externally visible root URL of the service
+func (h *FHIRHandler) baseURL(r *http.Request) string {
+	return externalBaseURL(r, h.publicURL)
+}
+
+// externalBaseURL returns publicURL when configured, or the root URL the
+// request was addressed to, honoring X-Forwarded-Proto from a proxy
+func externalBaseURL(r *http.Request, publicURL string) string {
+	if publicURL != "" {
+		return publicURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// parseImagingStudySearch maps FHIR ImagingStudy search parameters onto a study
+// query. Parameters that have no QIDO equivalent are ignored, as FHIR servers may
+// do with unsupported parameters under the default lenient handling.
+func parseImagingStudySearch(query url.Values) (models.QueryParams, error) {
+	var params models.QueryParams
+	filters := make(map[string]string)
+
+	for key, values := range query {
+		if len(values) == 0 {
+			continue
+		}
+		value := values[0]
+
+		switch key {
+		case "_id":
+			filters["0020000D"] = value
+		case "identifier":
+			// Study Instance UIDs use the urn:dicom:uid system; anything else is
+			// taken to be an accession number
+			system, code := splitToken(value)
+			if system == fhir.SystemDICOMUID || strings.HasPrefix(code, "urn:oid:") {
+				filters["0020000D"] = strings.TrimPrefix(code, "urn:oid:")
+			} else {
+				params.AccessionNumber = code
+			}
+		case "patient", "subject":
+			// Patient references resolve to the DICOM Patient ID; absolute
+			// references keep only their trailing id
+			if idx := strings.LastIndex(value, "Patient/"); idx >= 0 {
+				value = value[idx+len("Patient/"):]
+			}
+			params.PatientID = value
+		case "patient.identifier", "subject.identifier":
+			_, params.PatientID = splitToken(value)
+		case "started":
+			dateRange, err := fhirDateRange(values)
+			if err != nil {
+				return params, fmt.Errorf("started: %w", err)
+			}
+			params.StudyDate = dateRange
+		case "modality":
+			for _, token := range strings.Split(strings.Join(values, ","), ",") {
+				if _, code := splitToken(token); code != "" {
+					params.Modalities = append(params.Modalities, code)
+				}
+			}
+		case "_count":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 0 {
+				return params, fmt.Errorf("_count must be a non-negative integer")
+			}
+			params.Limit = count
+		case "_offset":
+			offset, err := strconv.Atoi(value)
+			if err != nil || offset < 0 {
+				return params, fmt.Errorf("_offset must be a non-negative integer")
+			}
+			params.Offset = offset
+		}
+	}
+
+	if len(filters) > 0 {
+		params.Filters = filters
+	}
+	return params, nil
+}
+
+// splitToken splits a FHIR token search value ("system|code" or "code")
+func splitToken(value string) (string, string) {
+	if system, code, ok := strings.Cut(value, "|"); ok {
+		return system, code
+	}
+	return "", value
+}
+
+// fhirDateRange converts FHIR date search values with eq, ge, gt, le and lt
+// prefixes into a DICOM date range. Only the date part of each value is used.
+func fhirDateRange(values []string) (string, error) {
+	var from, to time.Time
+
+	for _, value := range values {
+		prefix := "eq"
+		if len(value) > 2 && value[0] >= 'a' && value[0] <= 'z' {
+			prefix, value = value[:2], value[2:]
+		}
+		if len(value) > 10 {
+			value = value[:10]
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return "", fmt.Errorf("invalid date %q", value)
+		}
+
+		switch prefix {
+		case "eq":
+			from, to = date, date
+		case "ge":
+			from = date
+		case "gt":
+			from = date.AddDate(0, 0, 1)
+		case "le":
+			to = date
+		case "lt":
+			to = date.AddDate(0, 0, -1)
+		default:
+			return "", fmt.Errorf("unsupported prefix %q", prefix)
+		}
+	}
+
+	if !from.IsZero() && !to.IsZero() && from.Equal(to) {
+		return from.Format("20060102"), nil
+	}
+	var dateRange string
+	if !from.IsZero() {
+		dateRange = from.Format("20060102")
+	}
+	dateRange += "-"
+	if !to.IsZero() {
+		dateRange += to.Format("20060102")
+	}
+	return dateRange, nil
+}
+
+// writeFHIRServiceError reports a service error as an OperationOutcome
+func writeFHIRServiceError(w http.ResponseWriter, err error, message string) {
+	status, _, detail := serviceErrorStatus(err, message)
+	if seconds := retryAfter(err); seconds != "" {
+		w.Header().Set("Retry-After", seconds)
+	}
+	writeOperationOutcome(w, status, detail)
+}
+
+// writeOperationOutcome writes an OperationOutcome with an issue code matching the status
+func writeOperationOutcome(w http.ResponseWriter, status int, diagnostics string) {
+	code := "exception"
+	switch status {
+	case http.StatusBadRequest:
+		code = "invalid"
+	case http.StatusNotFound:
+		code = "not-found"
+	case http.StatusRequestEntityTooLarge:
+		code = "too-costly"
+	case http.StatusNotImplemented:
+		code = "not-supported"
+	case http.StatusTooManyRequests:
+		code = "throttled"
+	case http.StatusServiceUnavailable:
+		code = "transient"
+	case http.StatusGatewayTimeout:
+		code = "timeout"
+	}
+
+	w.Header().Set("Content-Type", fhir.ContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(fhir.NewOperationOutcome(code, diagnostics))
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	mediaTypeOctetStream = "application/octet-stream"
+	mediaTypeMP4         = "video/mp4"
+
+	// maxFramesPerRequest bounds the frame list of a single frame retrieval
+	maxFramesPerRequest = 5000
+)
+
+// RetrieveFrames handles WADO-RS frame retrieval. Frames are streamed as a
+// multipart/related response, each part flushed as soon as it is fetched.
+func (h *DICOMWebHandler) RetrieveFrames(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	seriesUID := chi.URLParam(r, "seriesUID")
+	instanceUID := chi.URLParam(r, "instanceUID")
+	if studyUID == "" || seriesUID == "" || instanceUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID, Series UID, and Instance UID are required")
+		return
+	}
+
+	frames, err := parseFrameList(chi.URLParam(r, "frameList"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	mw, err := newMultipartStreamWriter(w, mediaTypeOctetStream, "")
+	if err != nil {
+		writeServiceError(w, err, "Failed to prepare response")
+		return
+	}
+
+	err = h.pacsService.RetrieveFrames(ctx, tenantID, studyUID, seriesUID, instanceUID, frames, func(frame int, data io.ReadCloser, contentType string) error {
+		if contentType == "" {
+			contentType = mediaTypeOctetStream
+		}
+		return mw.WritePart(contentType, data)
+	})
+	if err != nil {
+		log.Error().Err(err).
+			Str("instance_uid", instanceUID).
+			Int("parts", mw.Parts()).
+			Msg("Failed to retrieve frames")
+		if mw.Parts() == 0 {
+			writeServiceError(w, err, "Failed to retrieve frames")
+		}
+		return
+	}
+
+	if err := mw.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to finish multipart response")
+	}
+}
+
+// RetrieveRendered handles WADO-RS rendered retrieval of video instances as MP4,
+// relayed as it arrives so playback can start before the transfer completes
+func (h *DICOMWebHandler) RetrieveRendered(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	seriesUID := chi.URLParam(r, "seriesUID")
+	instanceUID := chi.URLParam(r, "instanceUID")
+	if studyUID == "" || seriesUID == "" || instanceUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID, Series UID, and Instance UID are required")
+		return
+	}
+
+	if !acceptsMediaType(r.Header.Get("Accept"), mediaTypeMP4) {
+		apierror.Write(w, http.StatusNotAcceptable, apierror.CodeNotAcceptable, "Rendered instances are only available as video/mp4")
+		return
+	}
+
+	data, contentType, err := h.pacsService.GetRendered(ctx, tenantID, studyUID, seriesUID, instanceUID, mediaTypeMP4)
+	if err != nil {
+		log.Error().Err(err).
+			Str("instance_uid", instanceUID).
+			Msg("Failed to retrieve rendered instance")
+		writeServiceError(w, err, "Failed to retrieve rendered instance")
+		return
+	}
+	defer data.Close()
+
+	if contentType == "" {
+		contentType = mediaTypeMP4
+	}
+	w.Header().Set("Content-Type", contentType)
+	if _, err := streamCopy(w, data); err != nil {
+		log.Error().Err(err).
+			Str("instance_uid", instanceUID).
+			Msg("Failed to write rendered response")
+	}
+}
+
+// parseFrameList parses a WADO-RS frame list such as "1,2,3". Ranges ("1-30")
+// are accepted as a shorthand for cine loops.
+func parseFrameList(list string) ([]int, error) {
+	var frames []int
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+
+		first, last, isRange := strings.Cut(item, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("invalid frame number: %s", item)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil || end < start {
+				return nil, fmt.Errorf("invalid frame range: %s", item)
+			}
+		}
+
+		if len(frames)+end-start+1 > maxFramesPerRequest {
+			return nil, fmt.Errorf("at most %d frames can be retrieved per request", maxFramesPerRequest)
+		}
+		for frame := start; frame <= end; frame++ {
+			frames = append(frames, frame)
+		}
+	}
+	return frames, nil
+}
+
+// acceptsMediaType reports whether an Accept header allows the given media type
+func acceptsMediaType(accept, mediaType string) bool {
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
+
+	majorType, _, _ := strings.Cut(mediaType, "/")
+	for _, r := range parseAccept(accept) {
+		if r.q <= 0 {
+			continue
+		}
+		if r.mediaType == mediaType || r.mediaType == "*/*" || r.mediaType == majorType+"/*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/graphql-go/graphql"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// maxGraphQLQuerySize bounds the query document of a GraphQL request
+const maxGraphQLQuerySize = 64 << 10
+
+// GraphQLHandler serves the imaging hierarchy as a GraphQL schema, so clients
+// fetch studies with exactly the nested series and instances they need in one
+// round trip. Nested fields are resolved only when selected.
+type GraphQLHandler struct {
+	pacsService *services.PACSService
+	schema      graphql.Schema
+}
+
+// graphQLRequest is a GraphQL request body, or the equivalent GET query parameters
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphQLError reports a resolver failure with a problem code in its extensions
+type graphQLError struct {
+	message string
+	code    string
+}
+
+func (e *graphQLError) Error() string { return e.message }
+
+func (e *graphQLError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// NewGraphQLHandler creates a GraphQL handler
+func NewGraphQLHandler(pacsService *services.PACSService) (*GraphQLHandler, error) {
+	h := &GraphQLHandler{pacsService: pacsService}
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: h.queryType()})
+	if err != nil {
+		return nil, err
+	}
+	h.schema = schema
+	return h, nil
+}
+
+// Query handles GET and POST /graphql
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if _, ok := middleware.GetTenantID(ctx); !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req graphQLRequest
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid variables parameter")
+				return
+			}
+		}
+	} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLQuerySize)).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	if req.Query == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "query is required")
+		return
+	}
+	if len(req.Query) > maxGraphQLQuerySize {
+		apierror.Write(w, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "query is too large")
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// queryType builds the root query: studies and study, with series and
+// instances resolved on demand below them
+func (h *GraphQLHandler) queryType() *graphql.Object {
+	instanceType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Instance",
+		Fields: graphql.Fields{
+			"sopInstanceUID":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"sopClassUID":               &graphql.Field{Type: graphql.String},
+			"instanceNumber":            &graphql.Field{Type: graphql.Int},
+			"transferSyntaxUID":         &graphql.Field{Type: graphql.String},
+			"rows":                      &graphql.Field{Type: graphql.Int},
+			"columns":                   &graphql.Field{Type: graphql.Int},
+			"bitsAllocated":             &graphql.Field{Type: graphql.Int},
+			"photometricInterpretation": &graphql.Field{Type: graphql.String},
+			"numberOfFrames":            &graphql.Field{Type: graphql.Int},
+		},
+	})
+
+	seriesType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Series",
+		Fields: graphql.Fields{
+			"seriesInstanceUID":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"seriesNumber":       &graphql.Field{Type: graphql.Int},
+			"modality":           &graphql.Field{Type: graphql.String},
+			"seriesDescription":  &graphql.Field{Type: graphql.String},
+			"seriesDate":         &graphql.Field{Type: graphql.String},
+			"seriesTime":         &graphql.Field{Type: graphql.String},
+			"bodyPartExamined":   &graphql.Field{Type: graphql.String},
+			"numberOfInstances":  &graphql.Field{Type: graphql.Int},
+			"protocolName":       &graphql.Field{Type: graphql.String},
+			"performedProcedure": &graphql.Field{Type: graphql.String},
+			"instances": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(instanceType)),
+				Description: "Instances of the series in instance number order; first limits them, e.g. to fetch a representative instance",
+				Args: graphql.FieldConfigArgument{
+					"first": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: h.resolveInstances,
+			},
+		},
+	})
+
+	studyType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Study",
+		Fields: graphql.Fields{
+			"studyInstanceUID":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"patientID":          &graphql.Field{Type: graphql.String},
+			"patientName":        &graphql.Field{Type: graphql.String},
+			"patientBirthDate":   &graphql.Field{Type: graphql.String},
+			"patientSex":         &graphql.Field{Type: graphql.String},
+			"studyDate":          &graphql.Field{Type: graphql.String},
+			"studyTime":          &graphql.Field{Type: graphql.String},
+			"studyDescription":   &graphql.Field{Type: graphql.String},
+			"accessionNumber":    &graphql.Field{Type: graphql.String},
+			"referringPhysician": &graphql.Field{Type: graphql.String},
+			"numberOfSeries":     &graphql.Field{Type: graphql.Int},
+			"numberOfInstances":  &graphql.Field{Type: graphql.Int},
+			"modalitiesInStudy":  &graphql.Field{Type: graphql.NewList(graphql.String)},
+			"series": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(seriesType)),
+				Description: "Series of the study, optionally of one modality",
+				Args: graphql.FieldConfigArgument{
+					"modality": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: h.resolveSeries,
+			},
+		},
+	})
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"studies": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(studyType)),
+				Description: "Searches for studies with the QIDO-RS matching rules",
+				Args: graphql.FieldConfigArgument{
+					"patientID":        &graphql.ArgumentConfig{Type: graphql.String},
+					"patientName":      &graphql.ArgumentConfig{Type: graphql.String},
+					"studyDate":        &graphql.ArgumentConfig{Type: graphql.String, Description: "DICOM date or range, e.g. 20240101-20240131"},
+					"studyTime":        &graphql.ArgumentConfig{Type: graphql.String},
+					"accessionNumber":  &graphql.ArgumentConfig{Type: graphql.String},
+					"modalities":       &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					"studyDescription": &graphql.ArgumentConfig{Type: graphql.String},
+					"limit":            &graphql.ArgumentConfig{Type: graphql.Int},
+					"offset":           &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: h.resolveStudies,
+			},
+			"study": &graphql.Field{
+				Type:        studyType,
+				Description: "Looks up a study by its UID; null when it is not known",
+				Args: graphql.FieldConfigArgument{
+					"studyInstanceUID": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: h.resolveStudy,
+			},
+		},
+	})
+}
+
+func (h *GraphQLHandler) resolveStudies(p graphql.ResolveParams) (interface{}, error) {
+	tenantID, _ := middleware.GetTenantID(p.Context)
+
+	params := models.QueryParams{
+		PatientID:        stringArg(p.Args, "patientID"),
+		PatientName:      stringArg(p.Args, "patientName"),
+		StudyDate:        stringArg(p.Args, "studyDate"),
+		StudyTime:        stringArg(p.Args, "studyTime"),
+		AccessionNumber:  stringArg(p.Args, "accessionNumber"),
+		StudyDescription: stringArg(p.Args, "studyDescription"),
+		Limit:            intArg(p.Args, "limit"),
+		Offset:           intArg(p.Args, "offset"),
+	}
+	if modalities, ok := p.Args["modalities"].([]interface{}); ok {
+		for _, modality := range modalities {
+			if m, ok := modality.(string); ok && m != "" {
+				params.Modalities = append(params.Modalities, m)
+			}
+		}
+	}
+	if params.Limit < 0 || params.Offset < 0 {
+		return nil, &graphQLError{message: "limit and offset must not be negative", code: apierror.CodeInvalidRequest}
+	}
+
+	studies, _, err := h.pacsService.FindStudies(p.Context, tenantID, params)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search studies")
+		return nil, resolverError(err, "Failed to search studies")
+	}
+
+	nodes := make([]map[string]interface{}, 0, len(studies))
+	for _, study := range studies {
+		nodes = append(nodes, studyNode(study))
+	}
+	return nodes, nil
+}
+
+func (h *GraphQLHandler) resolveStudy(p graphql.ResolveParams) (interface{}, error) {
+	tenantID, _ := middleware.GetTenantID(p.Context)
+	studyUID := stringArg(p.Args, "studyInstanceUID")
+
+	studies, _, err := h.pacsService.FindStudies(p.Context, tenantID, models.QueryParams{
+		Filters: map[string]string{"0020000D": studyUID},
+		Limit:   1,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to look up study")
+		return nil, resolverError(err, "Failed to look up study")
+	}
+	if len(studies) == 0 {
+		return nil, nil
+	}
+	return studyNode(studies[0]), nil
+}
+
+func (h *GraphQLHandler) resolveSeries(p graphql.ResolveParams) (interface{}, error) {
+	tenantID, _ := middleware.GetTenantID(p.Context)
+	study, _ := p.Source.(map[string]interface{})
+	studyUID, _ := study["studyInstanceUID"].(string)
+
+	series, err := h.pacsService.FindSeries(p.Context, tenantID, studyUID)
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to find series")
+		return nil, resolverError(err, "Failed to find series")
+	}
+
+	modality := stringArg(p.Args, "modality")
+	nodes := make([]map[string]interface{}, 0, len(series))
+	for _, s := range series {
+		if modality != "" && s.Modality != modality {
+			continue
+		}
+		nodes = append(nodes, seriesNode(studyUID, s))
+	}
+	return nodes, nil
+}
+
+func (h *GraphQLHandler) resolveInstances(p graphql.ResolveParams) (interface{}, error) {
+	tenantID, _ := middleware.GetTenantID(p.Context)
+	series, _ := p.Source.(map[string]interface{})
+	studyUID, _ := series["studyInstanceUID"].(string)
+	seriesUID, _ := series["seriesInstanceUID"].(string)
+
+	first := intArg(p.Args, "first")
+	if first < 0 {
+		return nil, &graphQLError{message: "first must not be negative", code: apierror.CodeInvalidRequest}
+	}
+
+	instances, err := h.pacsService.FindInstances(p.Context, tenantID, studyUID, seriesUID)
+	if err != nil {
+		log.Error().Err(err).Str("series_uid", seriesUID).Msg("Failed to find instances")
+		return nil, resolverError(err, "Failed to find instances")
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool {
+		return instances[i].InstanceNumber < instances[j].InstanceNumber
+	})
+	if first > 0 && len(instances) > first {
+		instances = instances[:first]
+	}
+
+	nodes := make([]map[string]interface{}, 0, len(instances))
+	for _, instance := range instances {
+		nodes = append(nodes, instanceNode(instance))
+	}
+	return nodes, nil
+}
+
+// resolverError classifies a service error like the REST API and reports its problem code
+func resolverError(err error, message string) error {
+	_, code, detail := serviceErrorStatus(err, message)
+	return &graphQLError{message: detail, code: code}
+}
+
+func studyNode(s models.Study) map[string]interface{} {
+	return map[string]interface{}{
+		"studyInstanceUID":   s.StudyInstanceUID,
+		"patientID":          s.PatientID,
+		"patientName":        s.PatientName,
+		"patientBirthDate":   s.PatientBirthDate,
+		"patientSex":         s.PatientSex,
+		"studyDate":          s.StudyDate,
+		"studyTime":          s.StudyTime,
+		"studyDescription":   s.StudyDescription,
+		"accessionNumber":    s.AccessionNumber,
+		"referringPhysician": s.ReferringPhysician,
+		"numberOfSeries":     s.NumberOfSeries,
+		"numberOfInstances":  s.NumberOfInstances,
+		"modalitiesInStudy":  s.ModalitiesInStudy,
+	}
+}
+
+// seriesNode carries the study UID along so the instances of the series can be resolved
+func seriesNode(studyUID string, s models.Series) map[string]interface{} {
+	return map[string]interface{}{
+		"studyInstanceUID":   studyUID,
+		"seriesInstanceUID":  s.SeriesInstanceUID,
+		"seriesNumber":       s.SeriesNumber,
+		"modality":           s.Modality,
+		"seriesDescription":  s.SeriesDescription,
+		"seriesDate":         s.SeriesDate,
+		"seriesTime":         s.SeriesTime,
+		"bodyPartExamined":   s.BodyPartExamined,
+		"numberOfInstances":  s.NumberOfInstances,
+		"protocolName":       s.ProtocolName,
+		"performedProcedure": s.PerformedProcedure,
+	}
+}
+
+func instanceNode(i models.Instance) map[string]interface{} {
+	return map[string]interface{}{
+		"sopInstanceUID":            i.SOPInstanceUID,
+		"sopClassUID":               i.SOPClassUID,
+		"instanceNumber":            i.InstanceNumber,
+		"transferSyntaxUID":         i.TransferSyntaxUID,
+		"rows":                      i.Rows,
+		"columns":                   i.Columns,
+		"bitsAllocated":             i.BitsAllocated,
+		"photometricInterpretation": i.PhotometricInterpretation,
+		"numberOfFrames":            i.NumberOfFrames,
+	}
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+func intArg(args map[string]interface{}, name string) int {
+	n, _ := args[name].(int)
+	return n
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
 	"github.com/otcheredev/ris-dicom-connector/internal/database"
 )
 
@@ -47,7 +48,7 @@ func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
 	// Check if service is ready to accept requests
 	sqlDB, err := database.DB.DB()
 	if err != nil || sqlDB.Ping() != nil {
-		http.Error(w, "Service not ready", http.StatusServiceUnavailable)
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.CodeServiceNotReady, "Service not ready")
 		return
 	}
 
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/otcheredev/ris-dicom-connector/internal/viewer"
+	"github.com/rs/zerolog/log"
+)
+
+// IID request types
+const (
+	iidRequestStudy   = "STUDY"
+	iidRequestPatient = "PATIENT"
+)
+
+// errNoStudies is reported when an IID request matches nothing in the PACS
+var errNoStudies = errors.New("no matching studies")
+
+// IIDHandler implements the Image Display actor of IHE Invoke Image Display
+// (RAD-106), launching the configured viewer for studies found in the tenant's PACS
+type IIDHandler struct {
+	pacsService *services.PACSService
+	launcher    *viewer.Launcher
+	publicURL   string
+}
+
+// NewIIDHandler creates an IID handler. The DICOMweb root handed to the viewer
+// is built from publicURL, or from the request host when it is empty.
+func NewIIDHandler(pacsService *services.PACSService, launcher *viewer.Launcher, publicURL string) *IIDHandler {
+	return &IIDHandler{
+		pacsService: pacsService,
+		launcher:    launcher,
+		publicURL:   strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+// iidLaunchResponse is returned instead of a redirect to clients asking for JSON
+type iidLaunchResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	StudyUIDs []string  `json:"study_uids"`
+}
+
+// InvokeImageDisplay handles GET /IHEInvokeImageDisplay. It resolves the requested
+// studies and redirects to the viewer, or returns the launch URL as JSON when the
+// client accepts application/json.
+func (h *IIDHandler) InvokeImageDisplay(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	if !h.launcher.Enabled() {
+		apierror.Write(w, http.StatusNotImplemented, apierror.CodeNotSupported, "No image viewer is configured")
+		return
+	}
+
+	query := r.URL.Query()
+	params, patientID, maxResults, err := parseIIDRequest(query)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	studyUIDs, err := h.resolveStudies(ctx, tenantID, params, maxResults)
+	if err != nil {
+		if errors.Is(err, errNoStudies) {
+			apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "No studies match the display request")
+			return
+		}
+		log.Error().Err(err).Str("request_type", query.Get("requestType")).Msg("Failed to resolve studies for image display")
+		writeServiceError(w, err, "Failed to resolve studies for image display")
+		return
+	}
+
+	launchURL, expires, err := h.launcher.URL(viewer.Launch{
+		TenantID:    tenantID,
+		StudyUIDs:   studyUIDs,
+		PatientID:   patientID,
+		DICOMWebURL: externalBaseURL(r, h.publicURL) + "/dicom-web",
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to build viewer launch URL")
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build viewer launch URL")
+		return
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Strs("study_uids", studyUIDs).
+		Msg("Image display invoked")
+
+	// The launch URL carries a bearer token and must not be cached
+	w.Header().Set("Cache-Control", "no-store")
+	if prefersJSON(r.Header.Get("Accept")) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(iidLaunchResponse{URL: launchURL, ExpiresAt: expires, StudyUIDs: studyUIDs})
+		return
+	}
+	http.Redirect(w, r, launchURL, http.StatusFound)
+}
+
+// resolveStudies returns the UIDs of the studies an IID request refers to. STUDY
+// requests query each listed study or accession number; PATIENT requests return
+// the patient's most recent studies first.
+func (h *IIDHandler) resolveStudies(ctx context.Context, tenantID uuid.UUID, queries []models.QueryParams, maxResults int) ([]string, error) {
+	var studies []models.Study
+	seen := make(map[string]bool)
+	for _, params := range queries {
+		found, _, err := h.pacsService.FindStudies(ctx, tenantID, params)
+		if err != nil {
+			return nil, err
+		}
+		for _, study := range found {
+			if study.StudyInstanceUID == "" || seen[study.StudyInstanceUID] {
+				continue
+			}
+			seen[study.StudyInstanceUID] = true
+			studies = append(studies, study)
+		}
+	}
+	if len(studies) == 0 {
+		return nil, errNoStudies
+	}
+
+	if maxResults > 0 {
+		sort.SliceStable(studies, func(i, j int) bool {
+			return studies[i].StudyDate+studies[i].StudyTime > studies[j].StudyDate+studies[j].StudyTime
+		})
+		if len(studies) > maxResults {
+			studies = studies[:maxResults]
+		}
+	}
+
+	uids := make([]string, len(studies))
+	for i, study := range studies {
+		uids[i] = study.StudyInstanceUID
+	}
+	return uids, nil
+}
+
+// parseIIDRequest maps the RAD-106 query parameters onto study queries. It
+// returns the queries, the patient ID for PATIENT requests and the
+// mostRecentResults limit. Parameters that only tune the viewer, such as
+// viewerType and diagnosticQuality, are ignored.
+func parseIIDRequest(query url.Values) ([]models.QueryParams, string, int, error) {
+	switch strings.ToUpper(query.Get("requestType")) {
+	case iidRequestStudy:
+		studyUIDs := splitIIDList(query.Get("studyUID"))
+		accessionNumbers := splitIIDList(query.Get("accessionNumber"))
+		if len(studyUIDs) == 0 && len(accessionNumbers) == 0 {
+			return nil, "", 0, fmt.Errorf("STUDY requests require studyUID or accessionNumber")
+		}
+		if len(studyUIDs) > 0 && len(accessionNumbers) > 0 {
+			return nil, "", 0, fmt.Errorf("studyUID and accessionNumber cannot be combined")
+		}
+
+		var queries []models.QueryParams
+		for _, uid := range studyUIDs {
+			queries = append(queries, models.QueryParams{Filters: map[string]string{"0020000D": uid}, Limit: 1})
+		}
+		for _, accession := range accessionNumbers {
+			queries = append(queries, models.QueryParams{AccessionNumber: accession})
+		}
+		return queries, "", 0, nil
+
+	case iidRequestPatient:
+		// The patient is sent as an HL7 CX value (id^^^&issuer&ISO); only the id is queried
+		patientID, _, _ := strings.Cut(query.Get("patientID"), "^")
+		if patientID == "" {
+			return nil, "", 0, fmt.Errorf("PATIENT requests require patientID")
+		}
+		params := models.QueryParams{PatientID: patientID}
+
+		from, err := iidDate(query.Get("lowerDateTime"))
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("lowerDateTime: %w", err)
+		}
+		to, err := iidDate(query.Get("upperDateTime"))
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("upperDateTime: %w", err)
+		}
+		if from != "" || to != "" {
+			params.StudyDate = from + "-" + to
+		}
+		params.Modalities = splitIIDList(query.Get("modalitiesInStudy"))
+
+		maxResults := 0
+		if value := query.Get("mostRecentResults"); value != "" {
+			maxResults, err = strconv.Atoi(value)
+			if err != nil || maxResults < 0 {
+				return nil, "", 0, fmt.Errorf("mostRecentResults must be a non-negative integer")
+			}
+		}
+		return []models.QueryParams{params}, patientID, maxResults, nil
+
+	default:
+		return nil, "", 0, fmt.Errorf("requestType must be STUDY or PATIENT")
+	}
+}
+
+// prefersJSON reports whether the client explicitly accepts JSON; browsers,
+// which send wildcards, are redirected instead
+func prefersJSON(accept string) bool {
+	for _, r := range parseAccept(accept) {
+		if r.q > 0 && r.mediaType == "application/json" {
+			return true
+		}
+	}
+	return false
+}
+
+// splitIIDList splits a comma-separated parameter, dropping empty values
+func splitIIDList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// iidDate converts the date part of an ISO 8601 date-time to a DICOM date
+func iidDate(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if len(value) > 10 {
+		value = value[:10]
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return "", fmt.Errorf("invalid date-time %q", value)
+	}
+	return date.Format("20060102"), nil
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// JobHandler queues background retrievals and reports their progress
+type JobHandler struct {
+	retrieveJobService *services.RetrieveJobService
+}
+
+// NewJobHandler creates a job handler
+func NewJobHandler(retrieveJobService *services.RetrieveJobService) *JobHandler {
+	return &JobHandler{retrieveJobService: retrieveJobService}
+}
+
+// CreateJob handles POST /api/v1/jobs, queueing the retrieval of
+// {"study_uid": "...", "series_uid": "..."} into the cache
+func (h *JobHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.RetrieveJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	job, err := h.retrieveJobService.CreateJob(ctx, tenantID, &req, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", req.StudyUID).Msg("Failed to create retrieve job")
+		writeServiceError(w, err, "Failed to create retrieve job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/jobs/"+job.ID.String())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetJob handles GET /api/v1/jobs/{id}
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	h.serveJob(w, r, "get", h.retrieveJobService.GetJob)
+}
+
+// CancelJob handles POST /api/v1/jobs/{id}/cancel
+func (h *JobHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	h.serveJob(w, r, "cancel", h.retrieveJobService.CancelJob)
+}
+
+// RetryJob handles POST /api/v1/jobs/{id}/retry
+func (h *JobHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	h.serveJob(w, r, "retry", h.retrieveJobService.RetryJob)
+}
+
+// serveJob applies an operation to the job in the URL and responds with the job
+func (h *JobHandler) serveJob(w http.ResponseWriter, r *http.Request, operation string, apply func(ctx context.Context, tenantID, id uuid.UUID) (*models.RetrieveJob, error)) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := apply(ctx, tenantID, jobID)
+	if err != nil {
+		log.Error().Err(err).Str("retrieve_job_id", jobID.String()).Msg("Failed to " + operation + " retrieve job")
+		writeServiceError(w, err, "Failed to "+operation+" retrieve job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
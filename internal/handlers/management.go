@@ -1,17 +1,31 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
 	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
 	"github.com/otcheredev/ris-dicom-connector/internal/models"
 	"github.com/otcheredev/ris-dicom-connector/internal/services"
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	// defaultConnectionTestPeriod is the period of connection tests reported when no from is given
+	defaultConnectionTestPeriod = 24 * time.Hour
+	// defaultConnectionTestInterval is the length of a connection test trend interval
+	defaultConnectionTestInterval = time.Hour
+	// defaultConnectionTestLimit and maxConnectionTestLimit bound the connection tests listed
+	defaultConnectionTestLimit = 100
+	maxConnectionTestLimit     = 1000
+)
+
 type ManagementHandler struct {
 	pacsService *services.PACSService
 }
@@ -27,20 +41,20 @@ func (h *ManagementHandler) CreatePACSConfig(w http.ResponseWriter, r *http.Requ
 	ctx := r.Context()
 	tenantID, ok := middleware.GetTenantID(ctx)
 	if !ok {
-		http.Error(w, "Tenant ID not found", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
 		return
 	}
 
 	var req models.PACSConfigRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
 		return
 	}
 
 	config, err := h.pacsService.CreatePACSConfig(ctx, tenantID, &req)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create PACS config")
-		http.Error(w, "Failed to create PACS config", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to create PACS config")
 		return
 	}
 
@@ -55,7 +69,7 @@ func (h *ManagementHandler) TestConnection(w http.ResponseWriter, r *http.Reques
 
 	var req models.ConnectionTestRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
 		return
 	}
 
@@ -73,19 +87,32 @@ func (h *ManagementHandler) TestConnection(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(status)
 }
 
-// GetPACSConfigs retrieves all PACS configurations for a tenant
+// GetPACSConfigs retrieves the active PACS configurations for a tenant, or with
+// include_inactive=true every configuration including inactive and deleted ones
 func (h *ManagementHandler) GetPACSConfigs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	tenantID, ok := middleware.GetTenantID(ctx)
 	if !ok {
-		http.Error(w, "Tenant ID not found", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
 		return
 	}
 
-	configs, err := h.pacsService.GetPACSConfigs(ctx, tenantID)
+	list := h.pacsService.GetPACSConfigs
+	if value := r.URL.Query().Get("include_inactive"); value != "" {
+		all, err := strconv.ParseBool(value)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "include_inactive must be true or false")
+			return
+		}
+		if all {
+			list = h.pacsService.GetPACSConfigHistory
+		}
+	}
+
+	configs, err := list(ctx, tenantID)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get PACS configs")
-		http.Error(w, "Failed to get PACS configs", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to get PACS configs")
 		return
 	}
 
@@ -96,21 +123,194 @@ func (h *ManagementHandler) GetPACSConfigs(w http.ResponseWriter, r *http.Reques
 // GetPACSConfig retrieves a specific PACS configuration
 func (h *ManagementHandler) GetPACSConfig(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
 
 	configIDStr := chi.URLParam(r, "id")
 	configID, err := uuid.Parse(configIDStr)
 	if err != nil {
-		http.Error(w, "Invalid config ID", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid config ID")
 		return
 	}
 
-	config, err := h.pacsService.GetPACSConfig(ctx, configID)
+	config, err := h.pacsService.GetPACSConfig(ctx, tenantID, configID)
 	if err != nil {
 		log.Error().Err(err).Str("config_id", configIDStr).Msg("Failed to get PACS config")
-		http.Error(w, "Failed to get PACS config", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to get PACS config")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(config)
+}
+
+// UpdatePACSConfig replaces the settings of a PACS configuration and tests its
+// connection again
+func (h *ManagementHandler) UpdatePACSConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	configID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid config ID")
+		return
+	}
+
+	var req models.PACSConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	config, err := h.pacsService.UpdatePACSConfig(ctx, tenantID, configID, &req)
+	if err != nil {
+		log.Error().Err(err).Str("config_id", configID.String()).Msg("Failed to update PACS config")
+		writeServiceError(w, err, "Failed to update PACS config")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(config)
+}
+
+// DeletePACSConfig removes a PACS configuration
+func (h *ManagementHandler) DeletePACSConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	configID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid config ID")
+		return
+	}
+
+	if err := h.pacsService.DeletePACSConfig(ctx, tenantID, configID); err != nil {
+		log.Error().Err(err).Str("config_id", configID.String()).Msg("Failed to delete PACS config")
+		writeServiceError(w, err, "Failed to delete PACS config")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeactivatePACSConfig handles POST /api/v1/pacs/config/{id}/deactivate,
+// taking a PACS configuration out of service after the calls using it are done
+func (h *ManagementHandler) DeactivatePACSConfig(w http.ResponseWriter, r *http.Request) {
+	h.changePACSConfigState(w, r, "deactivate", h.pacsService.DeactivatePACSConfig)
+}
+
+// ActivatePACSConfig handles POST /api/v1/pacs/config/{id}/activate, putting
+// an inactive PACS configuration back in service
+func (h *ManagementHandler) ActivatePACSConfig(w http.ResponseWriter, r *http.Request) {
+	h.changePACSConfigState(w, r, "activate", h.pacsService.ActivatePACSConfig)
+}
+
+// RestorePACSConfig handles POST /api/v1/pacs/config/{id}/restore, undeleting
+// a PACS configuration, which comes back inactive
+func (h *ManagementHandler) RestorePACSConfig(w http.ResponseWriter, r *http.Request) {
+	h.changePACSConfigState(w, r, "restore", h.pacsService.RestorePACSConfig)
+}
+
+// changePACSConfigState applies a lifecycle change to the PACS configuration of
+// the request and writes the configuration as changed
+func (h *ManagementHandler) changePACSConfigState(w http.ResponseWriter, r *http.Request, action string, change func(ctx context.Context, tenantID, configID uuid.UUID) (*models.PACSConfig, error)) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	configID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid config ID")
+		return
+	}
+
+	config, err := change(ctx, tenantID, configID)
+	if err != nil {
+		log.Error().Err(err).Str("config_id", configID.String()).Msgf("Failed to %s PACS config", action)
+		writeServiceError(w, err, "Failed to "+action+" PACS config")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(config)
 }
+
+// GetConnectionTests handles GET /api/v1/pacs/config/{id}/tests, reporting the
+// connection tests of a PACS configuration between the RFC 3339 from and to
+// query parameters, which default to the last 24 hours, with a trend per
+// interval (a duration, 1h by default) and the latest limit tests
+func (h *ManagementHandler) GetConnectionTests(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	configID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid config ID")
+		return
+	}
+
+	query := r.URL.Query()
+	to := time.Now()
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "to must be an RFC 3339 time")
+			return
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultConnectionTestPeriod)
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "from must be an RFC 3339 time")
+			return
+		}
+		from = parsed
+	}
+	interval := defaultConnectionTestInterval
+	if value := query.Get("interval"); value != "" {
+		interval, err = time.ParseDuration(value)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "interval must be a duration, e.g. 1h")
+			return
+		}
+	}
+	limit := defaultConnectionTestLimit
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "limit must be a number")
+			return
+		}
+		limit = min(limit, maxConnectionTestLimit)
+	}
+
+	history, err := h.pacsService.GetConnectionTests(ctx, tenantID, configID, from, to, interval, limit)
+	if err != nil {
+		log.Error().Err(err).Str("config_id", configID.String()).Msg("Failed to get PACS connection tests")
+		writeServiceError(w, err, "Failed to get PACS connection tests")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
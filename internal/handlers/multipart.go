@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+)
+
+// multipartStreamWriter emits a multipart/related response one part at a time,
+// flushing each part to the client as soon as it has been written
+type multipartStreamWriter struct {
+	w        http.ResponseWriter
+	mw       *multipart.Writer
+	partType string
+	parts    int
+}
+
+// newMultipartStreamWriter prepares a multipart/related response whose root type
+// is partType. An empty boundary selects a random one.
+func newMultipartStreamWriter(w http.ResponseWriter, partType, boundary string) (*multipartStreamWriter, error) {
+	mw := multipart.NewWriter(w)
+	if boundary != "" {
+		if err := mw.SetBoundary(boundary); err != nil {
+			return nil, err
+		}
+	}
+
+	return &multipartStreamWriter{w: w, mw: mw, partType: partType}, nil
+}
+
+// contentType returns the Content-Type header value of the multipart response
+func (m *multipartStreamWriter) contentType() string {
+	return mime.FormatMediaType(mediaTypeMultipartRelated, map[string]string{
+		"type":     m.partType,
+		"boundary": m.mw.Boundary(),
+	})
+}
+
+// WritePart streams one part with the given Content-Type. Response headers are
+// committed with the first part.
+func (m *multipartStreamWriter) WritePart(contentType string, body io.Reader) error {
+	if m.parts == 0 {
+		m.w.Header().Set("Content-Type", m.contentType())
+	}
+	// Parts may be written long after the response started
+	extendWriteDeadline(m.w)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+
+	part, err := m.mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	m.parts++
+
+	_, err = streamCopy(&flushingWriter{Writer: part, flush: m.w}, body)
+	return err
+}
+
+// Parts reports how many parts have been written
+func (m *multipartStreamWriter) Parts() int {
+	return m.parts
+}
+
+// Close writes the closing boundary
+func (m *multipartStreamWriter) Close() error {
+	if m.parts == 0 {
+		m.w.Header().Set("Content-Type", m.contentType())
+	}
+	extendWriteDeadline(m.w)
+	return m.mw.Close()
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// SyncHandler reports the PACS synchronization of a tenant
+type SyncHandler struct {
+	syncService *services.SyncService
+}
+
+// NewSyncHandler creates a PACS synchronization handler
+func NewSyncHandler(syncService *services.SyncService) *SyncHandler {
+	return &SyncHandler{syncService: syncService}
+}
+
+// GetSyncState handles GET /api/v1/pacs/sync, reporting the outcome of the
+// tenant's last synchronization run and how many of its studies are indexed
+func (h *SyncHandler) GetSyncState(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	state, err := h.syncService.GetSyncState(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get PACS synchronization state")
+		writeServiceError(w, err, "Failed to get PACS synchronization state")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
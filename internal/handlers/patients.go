@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// PatientHandler serves patient-centred views over the tenant's PACS for the RIS
+type PatientHandler struct {
+	pacsService *services.PACSService
+}
+
+// NewPatientHandler creates a new patient handler
+func NewPatientHandler(pacsService *services.PACSService) *PatientHandler {
+	return &PatientHandler{pacsService: pacsService}
+}
+
+// GetStudies handles GET /api/v1/patients/{patientID}/studies, returning the
+// patient's imaging history from every PACS of the tenant, newest first
+func (h *PatientHandler) GetStudies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	patientID := chi.URLParam(r, "patientID")
+	if patientID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Patient ID is required")
+		return
+	}
+
+	timeline, err := h.pacsService.PatientTimeline(ctx, tenantID, patientID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get patient studies")
+		writeServiceError(w, err, "Failed to get patient studies")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timeline)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// PinHandler pins studies in the cache for teaching files and tumor boards
+type PinHandler struct {
+	pinService *services.PinService
+}
+
+// NewPinHandler creates a pin handler
+func NewPinHandler(pinService *services.PinService) *PinHandler {
+	return &PinHandler{pinService: pinService}
+}
+
+// PinStudy handles PUT /api/v1/pinned-studies/{studyUID} with an optional
+// {"reason": "..."}
+func (h *PinHandler) PinStudy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	if studyUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID is required")
+		return
+	}
+
+	var req models.PinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	pin, err := h.pinService.PinStudy(ctx, tenantID, studyUID, &req)
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to pin study")
+		writeServiceError(w, err, "Failed to pin study")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pin)
+}
+
+// GetPinnedStudies handles GET /api/v1/pinned-studies
+func (h *PinHandler) GetPinnedStudies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	pins, err := h.pinService.GetPinnedStudies(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get pinned studies")
+		writeServiceError(w, err, "Failed to get pinned studies")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pins)
+}
+
+// UnpinStudy handles DELETE /api/v1/pinned-studies/{studyUID}
+func (h *PinHandler) UnpinStudy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	if err := h.pinService.UnpinStudy(ctx, tenantID, studyUID); err != nil {
+		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to unpin study")
+		writeServiceError(w, err, "Failed to unpin study")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
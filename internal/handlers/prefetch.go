@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// PrefetchHandler starts cache warm-up jobs and reports their progress
+type PrefetchHandler struct {
+	prefetchService *services.PrefetchService
+}
+
+// NewPrefetchHandler creates a prefetch handler
+func NewPrefetchHandler(prefetchService *services.PrefetchService) *PrefetchHandler {
+	return &PrefetchHandler{prefetchService: prefetchService}
+}
+
+// PrefetchStudies handles POST /api/v1/prefetch, queueing the retrieval of every
+// instance of {"study_uids": [...]} or {"accession_number": "..."} into the cache
+func (h *PrefetchHandler) PrefetchStudies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.PrefetchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	job, err := h.prefetchService.PrefetchStudies(ctx, tenantID, &req, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to start prefetch")
+		writeServiceError(w, err, "Failed to start prefetch")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/prefetch/jobs/"+job.ID.String())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetPrefetchJob handles GET /api/v1/prefetch/jobs/{id}
+func (h *PrefetchHandler) GetPrefetchJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid prefetch job ID")
+		return
+	}
+
+	job, err := h.prefetchService.GetPrefetchJob(ctx, tenantID, jobID)
+	if err != nil {
+		log.Error().Err(err).Str("prefetch_id", jobID.String()).Msg("Failed to get prefetch job")
+		writeServiceError(w, err, "Failed to get prefetch job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/dictionary/tags"
+	"github.com/otcheredev/ris-dicom-connector/internal/dicomquery"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// qidoControlParams are QIDO-RS query parameters that are not attribute matching keys
+var qidoControlParams = map[string]bool{
+	"limit":         true,
+	"offset":        true,
+	"fuzzymatching": true,
+	"includefield":  true,
+	"fanout":        true, // search every PACS of the tenant, see SearchStudies
+}
+
+// qidoNonMatchableVRs are value representations that cannot be used as matching keys
+var qidoNonMatchableVRs = map[string]bool{
+	"SQ": true,
+	"OB": true,
+	"OD": true,
+	"OF": true,
+	"OL": true,
+	"OV": true,
+	"OW": true,
+	"UN": true,
+}
+
+// parseStudyQuery builds study-level query parameters from a QIDO-RS query string.
+// Well-known attributes populate the typed fields; any other attribute given by
+// keyword or {group}{element} tag is forwarded as an additional matching key.
+func parseStudyQuery(query url.Values) (models.QueryParams, error) {
+	var params models.QueryParams
+
+	for key, values := range query {
+		if len(values) == 0 {
+			continue
+		}
+		value := values[0]
+
+		if qidoControlParams[strings.ToLower(key)] {
+			continue
+		}
+
+		tag, err := resolveQueryAttribute(key)
+		if err != nil {
+			return params, err
+		}
+
+		switch tag.Name {
+		case tags.PatientID.Name:
+			params.PatientID = value
+		case tags.PatientName.Name:
+			params.PatientName = value
+		case tags.StudyDate.Name:
+			if params.StudyDate, err = dicomquery.NormalizeDate(value); err != nil {
+				return params, fmt.Errorf("StudyDate: %w", err)
+			}
+		case tags.StudyTime.Name:
+			if params.StudyTime, err = dicomquery.NormalizeTime(value); err != nil {
+				return params, fmt.Errorf("StudyTime: %w", err)
+			}
+		case tags.AccessionNumber.Name:
+			params.AccessionNumber = value
+		case tags.ModalitiesInStudy.Name:
+			params.Modalities = splitModalities(values)
+		case tags.StudyDescription.Name:
+			params.StudyDescription = value
+		default:
+			if params.Filters == nil {
+				params.Filters = make(map[string]string)
+			}
+			params.Filters[fmt.Sprintf("%04X%04X", tag.Group, tag.Element)] = value
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		params.Limit, _ = strconv.Atoi(limit)
+	}
+	if offset := query.Get("offset"); offset != "" {
+		params.Offset, _ = strconv.Atoi(offset)
+	}
+
+	return params, nil
+}
+
+// splitModalities collects the modality codes of repeated and comma separated
+// ModalitiesInStudy parameters (e.g. ModalitiesInStudy=CT,MR&ModalitiesInStudy=US)
+func splitModalities(values []string) []string {
+	var modalities []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, modality := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\\' }) {
+			modality = strings.ToUpper(strings.TrimSpace(modality))
+			if modality == "" || seen[modality] {
+				continue
+			}
+			seen[modality] = true
+			modalities = append(modalities, modality)
+		}
+	}
+	return modalities
+}
+
+// resolveQueryAttribute resolves a QIDO-RS attribute given as a keyword or an
+// eight digit hexadecimal tag against the DICOM dictionary
+func resolveQueryAttribute(key string) (*tags.Tag, error) {
+	var tag *tags.Tag
+
+	if len(key) == 8 && isHex(key) {
+		tagValue, _ := strconv.ParseUint(key, 16, 32)
+		tag = tags.GetTag(uint16(tagValue>>16), uint16(tagValue))
+	} else {
+		tag = tags.GetTagFromName(key)
+	}
+
+	if tag == nil || tag.Name == "" {
+		return nil, fmt.Errorf("unknown query attribute: %s", key)
+	}
+	if tag.Group <= 0x0002 {
+		return nil, fmt.Errorf("query attribute %s is not a dataset attribute", key)
+	}
+	if qidoNonMatchableVRs[tag.VR] {
+		return nil, fmt.Errorf("query attribute %s (VR %s) cannot be used for matching", key, tag.VR)
+	}
+
+	return tag, nil
+}
+
+func isHex(s string) bool {
+	for _, char := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", char) {
+			return false
+		}
+	}
+	return true
+}
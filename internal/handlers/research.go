@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// ResearchHandler serves studies de-identified for a research context. UIDs
+// are replaced consistently within a context, so the responses of one context
+// can be linked to each other but not to the original studies.
+type ResearchHandler struct {
+	pacsService *services.PACSService
+}
+
+// NewResearchHandler creates a new research handler
+func NewResearchHandler(pacsService *services.PACSService) *ResearchHandler {
+	return &ResearchHandler{pacsService: pacsService}
+}
+
+// GetStudyMetadata handles GET /api/v1/research/{context}/studies/{studyUID}/metadata
+func (h *ResearchHandler) GetStudyMetadata(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	if studyUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study UID is required")
+		return
+	}
+
+	metadata, err := h.pacsService.GetResearchStudyMetadata(ctx, tenantID, chi.URLParam(r, "context"), studyUID, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to get research study metadata")
+		writeServiceError(w, err, "Failed to get study metadata")
+		return
+	}
+
+	writeJSONWithETag(w, r, "application/dicom+json", metadataDatasets(metadata))
+}
+
+// RetrieveInstance handles GET /api/v1/research/{context}/studies/{studyUID}/series/{seriesUID}/instances/{instanceUID},
+// returning the de-identified instance as application/dicom
+func (h *ResearchHandler) RetrieveInstance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	studyUID := chi.URLParam(r, "studyUID")
+	seriesUID := chi.URLParam(r, "seriesUID")
+	instanceUID := chi.URLParam(r, "instanceUID")
+	if studyUID == "" || seriesUID == "" || instanceUID == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Study, Series and Instance UIDs are required")
+		return
+	}
+
+	data, err := h.pacsService.GetResearchInstance(ctx, tenantID, chi.URLParam(r, "context"), studyUID, seriesUID, instanceUID, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).
+			Str("study_uid", studyUID).
+			Str("instance_uid", instanceUID).
+			Msg("Failed to retrieve research instance")
+		writeServiceError(w, err, "Failed to retrieve instance")
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeDICOM)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
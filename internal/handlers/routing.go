@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// RouteHints adds the caller's X-Department and X-Calling-AE-Title headers to
+// the request context, for the tenant's routing rules to choose a PACS by
+func RouteHints(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		department := r.Header.Get("X-Department")
+		callingAE := r.Header.Get("X-Calling-AE-Title")
+		if department == "" && callingAE == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := services.WithRouteHints(r.Context(), services.RouteHints{
+			Department:     department,
+			CallingAETitle: callingAE,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// CreateRoutingRule adds a rule routing matching requests to one of the tenant's PACS configs
+func (h *ManagementHandler) CreateRoutingRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.RoutingRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	rule, err := h.pacsService.CreateRoutingRule(ctx, tenantID, &req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create routing rule")
+		writeServiceError(w, err, "Failed to create routing rule")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// GetRoutingRules lists the tenant's routing rules in evaluation order
+func (h *ManagementHandler) GetRoutingRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	rules, err := h.pacsService.GetRoutingRules(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get routing rules")
+		writeServiceError(w, err, "Failed to get routing rules")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// DeleteRoutingRule removes a routing rule
+func (h *ManagementHandler) DeleteRoutingRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	ruleID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid routing rule ID")
+		return
+	}
+
+	if err := h.pacsService.DeleteRoutingRule(ctx, tenantID, ruleID); err != nil {
+		log.Error().Err(err).Str("rule_id", ruleID.String()).Msg("Failed to delete routing rule")
+		writeServiceError(w, err, "Failed to delete routing rule")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/dictionary/tags"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/audit"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// SMARTHandler hands a SMART on FHIR launch over to an image viewer by minting
+// study-scoped viewer grants, and enforces their scope on DICOMweb requests
+type SMARTHandler struct {
+	grantService *services.ViewerGrantService
+	publicURL    string
+}
+
+// NewSMARTHandler creates a SMART handler. The DICOMweb root returned with a
+// grant is built from publicURL, or from the request host when it is empty.
+func NewSMARTHandler(grantService *services.ViewerGrantService, publicURL string) *SMARTHandler {
+	return &SMARTHandler{
+		grantService: grantService,
+		publicURL:    publicURL,
+	}
+}
+
+// CreateViewerGrant handles POST /api/v1/smart/viewer-grants, exchanging a SMART
+// launch token and a study UID or accession number for a viewer grant
+func (h *SMARTHandler) CreateViewerGrant(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.ViewerGrantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	grant, err := h.grantService.CreateGrant(ctx, tenantID, &req, r.RemoteAddr, r.UserAgent())
+	switch {
+	case errors.Is(err, services.ErrSMARTNotConfigured):
+		apierror.Write(w, http.StatusNotImplemented, apierror.CodeNotSupported, "SMART launch is not configured")
+		return
+	case errors.Is(err, services.ErrInvalidLaunchToken):
+		apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, "The launch token is invalid or no longer active")
+		return
+	case errors.Is(err, services.ErrLaunchNotAuthorized):
+		apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The launch does not grant read access to this study")
+		return
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to create viewer grant")
+		writeServiceError(w, err, "Failed to create viewer grant")
+		return
+	}
+	grant.DICOMWebURL = externalBaseURL(r, h.publicURL) + "/dicom-web"
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(grant)
+}
+
+// ViewerGrants authorizes DICOMweb requests that carry a viewer grant as their
+// bearer token. The grant supplies the tenant, grants the DICOMweb read scope
+// and limits the request to reading its study; requests without a grant pass
+// through unchanged.
+func (h *SMARTHandler) ViewerGrants(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !strings.HasPrefix(token, models.ViewerGrantTokenPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		grant, err := h.grantService.ValidateGrant(r.Context(), token)
+		if errors.Is(err, services.ErrInvalidViewerGrant) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dicom-web", error="invalid_token"`)
+			apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, "The viewer grant is invalid or has expired")
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to validate viewer grant")
+			apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to validate viewer grant")
+			return
+		}
+
+		if header := r.Header.Get("X-Tenant-ID"); header != "" && header != grant.TenantID.String() {
+			apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The viewer grant belongs to another tenant")
+			return
+		}
+		if !viewerGrantCovers(r, grant.StudyUID) {
+			apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The viewer grant does not cover this request")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), middleware.TenantIDKey, grant.TenantID)
+		ctx = middleware.WithScopes(ctx, []string{models.ScopeDICOMWebRead})
+		if grant.Subject != "" {
+			ctx = audit.WithUser(ctx, grant.Subject)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// viewerGrantCovers reports whether a DICOMweb request only reads the granted
+// study: its WADO-RS resources, or a QIDO-RS study search restricted to it
+func viewerGrantCovers(r *http.Request, studyUID string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	i := strings.Index(r.URL.Path, "/studies")
+	if i < 0 {
+		return false
+	}
+	segments := strings.Split(strings.Trim(r.URL.Path[i:], "/"), "/")
+	if segments[0] != "studies" {
+		return false
+	}
+	if len(segments) == 1 {
+		return searchesOnlyStudy(r.URL.Query(), studyUID)
+	}
+	return segments[1] == studyUID
+}
+
+// searchesOnlyStudy reports whether a QIDO-RS study search matches the study
+// alone: it has to give StudyInstanceUID exactly once, under any of its keys,
+// with the study's UID as the value
+func searchesOnlyStudy(query url.Values, studyUID string) bool {
+	filters := 0
+	for key, values := range query {
+		if qidoControlParams[strings.ToLower(key)] {
+			continue
+		}
+		tag, err := resolveQueryAttribute(key)
+		if err != nil {
+			return false
+		}
+		if tag.Name == tags.StudyInstanceUID.Name {
+			filters += len(values)
+		}
+	}
+	if filters != 1 {
+		return false
+	}
+
+	params, err := parseStudyQuery(query)
+	if err != nil {
+		return false
+	}
+	return params.Filters["0020000D"] == studyUID
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// streamBufferSize is the read size used when relaying instance bodies
+	streamBufferSize = 64 * 1024
+	// streamFlushBytes is how much data is written between flushes to the client
+	streamFlushBytes = 256 * 1024
+	// streamWriteTimeout bounds each write of a streamed response, in place of
+	// the server's write timeout that would cut off transfers outlasting it
+	streamWriteTimeout = 30 * time.Second
+)
+
+// flushingWriter writes to an inner writer (e.g. a multipart part) but flushes the outer response
+type flushingWriter struct {
+	io.Writer
+	flush http.ResponseWriter
+}
+
+// deadlineResponseWriter extends the response's write deadline before every
+// write, for responses streamed by the standard library such as http.ServeContent
+type deadlineResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w deadlineResponseWriter) Write(p []byte) (int, error) {
+	extendWriteDeadline(w.ResponseWriter)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w deadlineResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// extendWriteDeadline lets the next write of a response take up to
+// streamWriteTimeout; writers that cannot set deadlines keep the server's
+func extendWriteDeadline(w http.ResponseWriter) {
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+}
+
+// streamCopy relays src to dst without buffering the whole body, flushing the
+// response periodically so viewers can start rendering before the transfer
+// ends. Each write gets streamWriteTimeout, however long the whole transfer takes.
+func streamCopy(dst io.Writer, src io.Reader) (int64, error) {
+	var response http.ResponseWriter
+	switch w := dst.(type) {
+	case *flushingWriter:
+		response = w.flush
+	case http.ResponseWriter:
+		response = w
+	}
+	flusher, _ := response.(http.Flusher)
+
+	buf := make([]byte, streamBufferSize)
+	var written, sinceFlush int64
+
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			if response != nil {
+				extendWriteDeadline(response)
+			}
+			m, writeErr := dst.Write(buf[:n])
+			written += int64(m)
+			sinceFlush += int64(m)
+			if writeErr != nil {
+				return written, writeErr
+			}
+			if flusher != nil && sinceFlush >= streamFlushBytes {
+				flusher.Flush()
+				sinceFlush = 0
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return written, nil
+}
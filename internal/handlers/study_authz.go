@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+)
+
+// StudyAuthorization has the studies, series and instances a request reads put
+// to the study authorizer, when one is enabled
+func StudyAuthorization(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(services.WithStudyAuthorization(r.Context())))
+	})
+}
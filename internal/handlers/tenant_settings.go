@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// GetTenantSettings returns the tenant's overrides of the deployment configuration
+func (h *ManagementHandler) GetTenantSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	settings, err := h.pacsService.GetTenantSettings(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get tenant settings")
+		writeServiceError(w, err, "Failed to get tenant settings")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateTenantSettings replaces the tenant's settings
+func (h *ManagementHandler) UpdateTenantSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.TenantSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	settings, err := h.pacsService.UpdateTenantSettings(ctx, tenantID, &req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to update tenant settings")
+		writeServiceError(w, err, "Failed to update tenant settings")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// ResetTenantSettings removes the tenant's settings so the deployment defaults apply
+func (h *ManagementHandler) ResetTenantSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	if err := h.pacsService.ResetTenantSettings(ctx, tenantID); err != nil {
+		log.Error().Err(err).Msg("Failed to reset tenant settings")
+		writeServiceError(w, err, "Failed to reset tenant settings")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
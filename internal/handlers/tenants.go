@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// TenantHandler provisions tenants and manages their lifecycle. Its routes
+// span tenants, so they name the tenant in the path instead of the
+// X-Tenant-ID header.
+type TenantHandler struct {
+	tenantService *services.TenantService
+}
+
+// NewTenantHandler creates a tenant handler
+func NewTenantHandler(tenantService *services.TenantService) *TenantHandler {
+	return &TenantHandler{tenantService: tenantService}
+}
+
+// CreateTenant handles POST /api/v1/tenants
+func (h *TenantHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	var req models.TenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	tenant, err := h.tenantService.CreateTenant(r.Context(), &req, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create tenant")
+		writeServiceError(w, err, "Failed to create tenant")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/tenants/"+tenant.ID.String())
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// GetTenants handles GET /api/v1/tenants, optionally filtered by status
+func (h *TenantHandler) GetTenants(w http.ResponseWriter, r *http.Request) {
+	tenants, err := h.tenantService.GetTenants(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get tenants")
+		writeServiceError(w, err, "Failed to get tenants")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenants)
+}
+
+// GetTenant handles GET /api/v1/tenants/{id}
+func (h *TenantHandler) GetTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+
+	tenant, err := h.tenantService.GetTenant(r.Context(), tenantID)
+	if err != nil {
+		writeServiceError(w, err, "Failed to get tenant")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// UpdateTenant handles PUT /api/v1/tenants/{id}, renaming the tenant
+func (h *TenantHandler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.TenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	tenant, err := h.tenantService.RenameTenant(r.Context(), tenantID, &req, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to update tenant")
+		writeServiceError(w, err, "Failed to update tenant")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// DisableTenant handles POST /api/v1/tenants/{id}/disable
+func (h *TenantHandler) DisableTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+
+	tenant, err := h.tenantService.DisableTenant(r.Context(), tenantID, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to disable tenant")
+		writeServiceError(w, err, "Failed to disable tenant")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// EnableTenant handles POST /api/v1/tenants/{id}/enable
+func (h *TenantHandler) EnableTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+
+	tenant, err := h.tenantService.EnableTenant(r.Context(), tenantID, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to enable tenant")
+		writeServiceError(w, err, "Failed to enable tenant")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// DeleteTenant handles DELETE /api/v1/tenants/{id}; only disabled tenants can
+// be deleted
+func (h *TenantHandler) DeleteTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.tenantService.DeleteTenant(r.Context(), tenantID, r.RemoteAddr, r.UserAgent()); err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to delete tenant")
+		writeServiceError(w, err, "Failed to delete tenant")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RotateTenantKeys handles POST /api/v1/tenants/{id}/keys/rotate, replacing
+// every valid API key of the tenant. The body is optional; the new keys are
+// only returned here.
+func (h *TenantHandler) RotateTenantKeys(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.APIKeyRotateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+			return
+		}
+	}
+
+	rotation, err := h.tenantService.RotateTenantKeys(r.Context(), tenantID, &req, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to rotate tenant API keys")
+		writeServiceError(w, err, "Failed to rotate tenant API keys")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(rotation)
+}
+
+// tenantParam parses the tenant ID in the path, writing the error response
+// when it is invalid
+func tenantParam(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid tenant ID")
+		return uuid.Nil, false
+	}
+	return tenantID, true
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// UsageHandler reports the metered usage of a tenant for billing
+type UsageHandler struct {
+	usageService *services.UsageService
+}
+
+// NewUsageHandler creates a usage handler
+func NewUsageHandler(usageService *services.UsageService) *UsageHandler {
+	return &UsageHandler{usageService: usageService}
+}
+
+// GetUsage handles GET /api/v1/usage, reporting the tenant's usage per UTC day
+// from the from to the to query parameter (YYYY-MM-DD, inclusive), by default
+// the 30 days up to today
+func (h *UsageHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	query := r.URL.Query()
+	report, err := h.usageService.GetUsage(ctx, tenantID, query.Get("from"), query.Get("to"))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get usage")
+		writeServiceError(w, err, "Failed to get usage")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultDeliveryLimit caps a delivery listing without an explicit limit
+const defaultDeliveryLimit = 100
+
+// WebhookHandler manages tenant webhooks and their deliveries
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook registers a webhook. The response is the only one that includes the signing secret.
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	registration, err := h.webhookService.CreateWebhook(ctx, tenantID, &req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create webhook")
+		writeServiceError(w, err, "Failed to create webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(registration)
+}
+
+// GetWebhooks lists the tenant's webhooks
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	webhooks, err := h.webhookService.GetWebhooks(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get webhooks")
+		writeServiceError(w, err, "Failed to get webhooks")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// DeleteWebhook removes a webhook
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	webhookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid webhook ID")
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(ctx, tenantID, webhookID); err != nil {
+		log.Error().Err(err).Str("webhook_id", webhookID.String()).Msg("Failed to delete webhook")
+		writeServiceError(w, err, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveries lists the tenant's webhook deliveries, newest first; ?status=dead
+// lists the dead letters
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	query := r.URL.Query()
+	limit := defaultDeliveryLimit
+	if l := query.Get("limit"); l != "" {
+		limit, _ = strconv.Atoi(l)
+	}
+	offset, _ := strconv.Atoi(query.Get("offset"))
+
+	deliveries, err := h.webhookService.GetDeliveries(ctx, tenantID, query.Get("status"), limit, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get webhook deliveries")
+		writeServiceError(w, err, "Failed to get webhook deliveries")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// RedeliverDelivery requeues a dead-lettered delivery
+func (h *WebhookHandler) RedeliverDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid delivery ID")
+		return
+	}
+
+	if err := h.webhookService.Redeliver(ctx, tenantID, deliveryID); err != nil {
+		log.Error().Err(err).Str("delivery_id", deliveryID.String()).Msg("Failed to redeliver webhook")
+		writeServiceError(w, err, "Failed to redeliver webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
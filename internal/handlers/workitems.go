@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/dictionary/tags"
+	"github.com/go-chi/chi/v5"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// maxWorkitemBodySize bounds UPS-RS request payloads
+const maxWorkitemBodySize = 1 << 20
+
+// WorkitemHandler serves the UPS-RS worklist service
+type WorkitemHandler struct {
+	worklistService *services.WorklistService
+}
+
+func NewWorkitemHandler(worklistService *services.WorklistService) *WorkitemHandler {
+	return &WorkitemHandler{
+		worklistService: worklistService,
+	}
+}
+
+// CreateWorkitem handles UPS-RS create (POST /workitems{?workitem})
+func (h *WorkitemHandler) CreateWorkitem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	dataset, err := decodeWorkitemDataset(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	workitem, err := h.worklistService.CreateWorkitem(ctx, tenantID, r.URL.Query().Get("workitem"), dataset)
+	if err != nil {
+		writeWorkitemError(w, err, "Failed to create workitem")
+		return
+	}
+
+	w.Header().Set("Location", workitemLocation(r, workitem.SOPInstanceUID))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// SearchWorkitems handles UPS-RS search (GET /workitems)
+func (h *WorkitemHandler) SearchWorkitems(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	query, err := parseWorkitemQuery(r.URL.Query())
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	workitems, err := h.worklistService.SearchWorkitems(ctx, tenantID, query)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search workitems")
+		writeServiceError(w, err, "Failed to search workitems")
+		return
+	}
+
+	if len(workitems) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	datasets := make([]models.DICOMJSONDataset, 0, len(workitems))
+	for i := range workitems {
+		dataset, err := h.worklistService.WorkitemDataset(&workitems[i])
+		if err != nil {
+			log.Error().Err(err).Str("workitem_uid", workitems[i].SOPInstanceUID).Msg("Failed to decode workitem")
+			continue
+		}
+		datasets = append(datasets, dataset)
+	}
+
+	w.Header().Set("Content-Type", "application/dicom+json")
+	json.NewEncoder(w).Encode(datasets)
+}
+
+// RetrieveWorkitem handles UPS-RS retrieve (GET /workitems/{uid})
+func (h *WorkitemHandler) RetrieveWorkitem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	workitem, err := h.worklistService.GetWorkitem(ctx, tenantID, chi.URLParam(r, "workitemUID"))
+	if err != nil {
+		writeWorkitemError(w, err, "Failed to retrieve workitem")
+		return
+	}
+
+	dataset, err := h.worklistService.WorkitemDataset(workitem)
+	if err != nil {
+		writeWorkitemError(w, err, "Failed to retrieve workitem")
+		return
+	}
+
+	writeJSONWithETag(w, r, "application/dicom+json", []models.DICOMJSONDataset{dataset})
+}
+
+// UpdateWorkitem handles UPS-RS update (POST /workitems/{uid}{?transaction-uid})
+func (h *WorkitemHandler) UpdateWorkitem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	dataset, err := decodeWorkitemDataset(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	uid := chi.URLParam(r, "workitemUID")
+	if _, err := h.worklistService.UpdateWorkitem(ctx, tenantID, uid, r.URL.Query().Get("transaction-uid"), dataset); err != nil {
+		writeWorkitemError(w, err, "Failed to update workitem")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ChangeWorkitemState handles UPS-RS change state (PUT /workitems/{uid}/state).
+// Claiming a workitem is a change to IN PROGRESS with a new transaction UID.
+func (h *WorkitemHandler) ChangeWorkitemState(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	dataset, err := decodeWorkitemDataset(r)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	state := models.ProcedureStepState(dataset.String("00741000")) // Procedure Step State
+	transactionUID := dataset.String("00081195")                   // Transaction UID
+	if state == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Procedure Step State (00741000) is required")
+		return
+	}
+
+	uid := chi.URLParam(r, "workitemUID")
+	if _, err := h.worklistService.ChangeState(ctx, tenantID, uid, state, transactionUID); err != nil {
+		writeWorkitemError(w, err, "Failed to change workitem state")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// decodeWorkitemDataset reads a DICOM JSON request body, which may be a single
+// dataset or an array holding one
+func decodeWorkitemDataset(r *http.Request) (models.DICOMJSONDataset, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWorkitemBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body")
+	}
+	body = bytes.TrimSpace(body)
+
+	if len(body) > 0 && body[0] == '[' {
+		var datasets []models.DICOMJSONDataset
+		if err := json.Unmarshal(body, &datasets); err != nil {
+			return nil, fmt.Errorf("invalid DICOM JSON body")
+		}
+		if len(datasets) != 1 {
+			return nil, fmt.Errorf("request body must contain exactly one dataset")
+		}
+		return datasets[0], nil
+	}
+
+	var dataset models.DICOMJSONDataset
+	if err := json.Unmarshal(body, &dataset); err != nil {
+		return nil, fmt.Errorf("invalid DICOM JSON body")
+	}
+	return dataset, nil
+}
+
+// parseWorkitemQuery builds UPS-RS search parameters from a query string
+func parseWorkitemQuery(query url.Values) (models.WorkitemQuery, error) {
+	var q models.WorkitemQuery
+
+	for key := range query {
+		value := query.Get(key)
+
+		if qidoControlParams[strings.ToLower(key)] {
+			continue
+		}
+
+		tag, err := resolveQueryAttribute(key)
+		if err != nil {
+			return q, err
+		}
+
+		switch tag.Name {
+		case tags.PatientID.Name:
+			q.PatientID = value
+		case tags.AccessionNumber.Name:
+			q.AccessionNumber = value
+		case tags.StudyInstanceUID.Name:
+			q.StudyInstanceUID = value
+		case tags.WorklistLabel.Name:
+			q.WorklistLabel = value
+		case tags.ProcedureStepState.Name:
+			q.State = models.ProcedureStepState(value)
+		case tags.ScheduledProcedureStepStartDateTime.Name:
+			q.ScheduledStart = value
+		default:
+			return q, fmt.Errorf("workitem search does not support attribute %s", key)
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		q.Limit, _ = strconv.Atoi(limit)
+	}
+	if offset := query.Get("offset"); offset != "" {
+		q.Offset, _ = strconv.Atoi(offset)
+	}
+
+	return q, nil
+}
+
+// workitemLocation returns the URL of a workitem resource relative to the request
+func workitemLocation(r *http.Request, uid string) string {
+	return strings.TrimSuffix(r.URL.Path, "/") + "/" + url.PathEscape(uid)
+}
+
+// writeWorkitemError logs unexpected worklist failures and writes the error response
+func writeWorkitemError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrWorkitemNotFound),
+		errors.Is(err, services.ErrWorkitemExists),
+		errors.Is(err, services.ErrInvalidWorkitem),
+		errors.Is(err, services.ErrTransactionUIDMismatch),
+		errors.Is(err, services.ErrInvalidStateTransition),
+		errors.Is(err, services.ErrWorkitemStateConflict):
+	default:
+		log.Error().Err(err).Msg(message)
+	}
+
+	writeServiceError(w, err, message)
+}
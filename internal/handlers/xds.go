@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/middleware"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// RetrieveImagingDocumentSet pulls instances from the tenant's XDS-I.b Imaging
+// Document Source (RAD-69) and streams them as multipart/related DICOM
+func (h *ManagementHandler) RetrieveImagingDocumentSet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID, ok := middleware.GetTenantID(ctx)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Tenant ID not found")
+		return
+	}
+
+	var req models.ImagingDocumentSetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	mw, err := newMultipartStreamWriter(w, mediaTypeDICOM, "")
+	if err != nil {
+		writeServiceError(w, err, "Failed to prepare response")
+		return
+	}
+
+	err = h.pacsService.RetrieveImagingDocumentSet(ctx, tenantID, req, func(instance models.Instance, data io.ReadCloser, contentType string) error {
+		return mw.WritePart(contentType, data)
+	}, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		log.Error().Err(err).Str("study_uid", req.StudyUID).Int("parts", mw.Parts()).Msg("Failed to retrieve imaging document set")
+		if mw.Parts() == 0 {
+			writeServiceError(w, err, "Failed to retrieve imaging document set")
+		}
+		// Once parts have been sent the status is committed; the missing closing
+		// boundary tells the client the response is incomplete
+		return
+	}
+
+	mw.Close()
+}
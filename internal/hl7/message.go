@@ -0,0 +1,164 @@
+// Package hl7 implements the parts of HL7 v2 needed to receive order messages
+// over MLLP: segment/field parsing, acknowledgements and the listener itself.
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrMalformed is returned for data that is not an HL7 v2 message
+var ErrMalformed = errors.New("malformed HL7 message")
+
+// Message is a parsed HL7 v2 message. Fields are kept as raw strings and split
+// into components on access.
+type Message struct {
+	Segments []Segment
+
+	fieldSep     byte
+	componentSep byte
+	encoding     string // MSH-2, e.g. ^~\&
+}
+
+// Segment is an HL7 segment; Fields[0] is the segment ID and Fields[n] is field n.
+// For MSH, Fields[1] is the field separator so numbering matches the standard.
+type Segment struct {
+	Fields []string
+}
+
+// ID returns the segment ID, e.g. "PID"
+func (s Segment) ID() string {
+	if len(s.Fields) == 0 {
+		return ""
+	}
+	return s.Fields[0]
+}
+
+// Parse parses an HL7 v2 message with segments separated by carriage returns
+func Parse(data []byte) (*Message, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\r")
+	text = strings.ReplaceAll(text, "\n", "\r")
+	text = strings.Trim(text, "\r")
+
+	if len(text) < 8 || !strings.HasPrefix(text, "MSH") {
+		return nil, fmt.Errorf("%w: missing MSH segment", ErrMalformed)
+	}
+
+	msg := &Message{fieldSep: text[3]}
+	encodingEnd := strings.IndexByte(text[4:], msg.fieldSep)
+	if encodingEnd < 1 {
+		return nil, fmt.Errorf("%w: missing encoding characters", ErrMalformed)
+	}
+	msg.encoding = text[4 : 4+encodingEnd]
+	msg.componentSep = msg.encoding[0]
+
+	for _, line := range strings.Split(text, "\r") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, string(msg.fieldSep))
+		if fields[0] == "MSH" {
+			// MSH-1 is the field separator itself
+			fields = append([]string{"MSH", string(msg.fieldSep)}, fields[1:]...)
+		}
+		msg.Segments = append(msg.Segments, Segment{Fields: fields})
+	}
+
+	return msg, nil
+}
+
+// Segment returns the first segment with the given ID
+func (m *Message) Segment(id string) (Segment, bool) {
+	for _, segment := range m.Segments {
+		if segment.ID() == id {
+			return segment, true
+		}
+	}
+	return Segment{}, false
+}
+
+// Field returns field n of the first segment with the given ID, or "" when absent.
+// Repetitions other than the first are dropped.
+func (m *Message) Field(segmentID string, n int) string {
+	segment, ok := m.Segment(segmentID)
+	if !ok || n >= len(segment.Fields) {
+		return ""
+	}
+	value := segment.Fields[n]
+	if segmentID == "MSH" && n <= 2 {
+		return value
+	}
+	if len(m.encoding) > 1 {
+		value, _, _ = strings.Cut(value, string(m.encoding[1]))
+	}
+	return value
+}
+
+// Component returns component c (1-based) of field n of a segment
+func (m *Message) Component(segmentID string, n, c int) string {
+	components := strings.Split(m.Field(segmentID, n), string(m.componentSep))
+	if c < 1 || c > len(components) {
+		return ""
+	}
+	return components[c-1]
+}
+
+// Type returns the message type and trigger event from MSH-9, e.g. "ORM", "O01"
+func (m *Message) Type() (string, string) {
+	return m.Component("MSH", 9, 1), m.Component("MSH", 9, 2)
+}
+
+// ControlID returns the message control ID (MSH-10)
+func (m *Message) ControlID() string {
+	return m.Field("MSH", 10)
+}
+
+// SendingFacility returns the namespace ID of the sending facility (MSH-4)
+func (m *Message) SendingFacility() string {
+	return m.Component("MSH", 4, 1)
+}
+
+// Acknowledgment codes (MSA-1)
+const (
+	AckAccept = "AA"
+	AckError  = "AE"
+	AckReject = "AR"
+)
+
+// Ack builds an ACK for the message. The text, if any, is returned in MSA-3.
+func (m *Message) Ack(code, text string) []byte {
+	sep := string(m.fieldSep)
+	_, trigger := m.Type()
+
+	msh := []string{
+		"MSH", m.encoding,
+		m.Field("MSH", 5), m.Field("MSH", 6), // receiver becomes sender
+		m.Field("MSH", 3), m.Field("MSH", 4),
+		time.Now().Format("20060102150405"),
+		"",
+		"ACK" + string(m.componentSep) + trigger + string(m.componentSep) + "ACK",
+		"ACK" + m.ControlID(),
+		m.Field("MSH", 11),
+		m.Field("MSH", 12),
+	}
+	msa := []string{"MSA", code, m.ControlID(), plainText(text, sep+m.encoding)}
+
+	return []byte(strings.Join(msh, sep) + "\r" + strings.TrimRight(strings.Join(msa, sep), sep) + "\r")
+}
+
+// RejectAck builds an AR acknowledgement for data that could not be parsed
+func RejectAck(text string) []byte {
+	return []byte("MSH|^~\\&|||||" + time.Now().Format("20060102150405") + "||ACK|||2.5\rMSA|AR||" + plainText(text, `|^~\&`) + "\r")
+}
+
+// plainText replaces delimiter characters so free text cannot break the message structure
+func plainText(text, delimiters string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(delimiters, r) {
+			return ' '
+		}
+		return r
+	}, text)
+}
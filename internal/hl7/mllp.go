@@ -0,0 +1,230 @@
+package hl7
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// MLLP framing bytes
+const (
+	startBlock     = 0x0b
+	endBlock       = 0x1c
+	carriageReturn = 0x0d
+)
+
+const (
+	// maxMessageSize bounds a single framed message; order messages are a few KB
+	maxMessageSize = 1 << 20
+	// idleTimeout closes connections that send nothing for this long
+	idleTimeout = 5 * time.Minute
+	// handleTimeout bounds the handling of a single message before it is acknowledged
+	handleTimeout = 10 * time.Second
+)
+
+// ErrServerClosed is returned by ListenAndServe after Close
+var ErrServerClosed = errors.New("hl7: server closed")
+
+// Handler processes received messages. A nil error is acknowledged with AA and
+// an error with AE, so handlers should hand long-running work off and return quickly.
+type Handler interface {
+	HandleMessage(ctx context.Context, msg *Message) error
+}
+
+// Server is an MLLP listener that acknowledges each message after handling it
+type Server struct {
+	addr    string
+	handler Handler
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer creates an MLLP server listening on addr
+func NewServer(addr string, handler Handler) *Server {
+	return &Server{
+		addr:    addr,
+		handler: handler,
+		conns:   make(map[net.Conn]struct{}),
+	}
+}
+
+// ListenAndServe accepts connections until Close is called
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return ErrServerClosed
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serve(conn)
+	}
+}
+
+// Close stops the listener, closes open connections and waits for in-flight messages
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// serve reads framed messages from a connection and writes an acknowledgement for each
+func (s *Server) serve(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+		s.wg.Done()
+	}()
+
+	remote := conn.RemoteAddr().String()
+	reader := bufio.NewReader(conn)
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
+
+		data, err := readFrame(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) && !errors.Is(err, os.ErrDeadlineExceeded) {
+				log.Warn().Err(err).Str("remote_addr", remote).Msg("Closing HL7 connection")
+			}
+			return
+		}
+
+		ack := s.handle(data, remote)
+		if err := writeFrame(conn, ack); err != nil {
+			log.Warn().Err(err).Str("remote_addr", remote).Msg("Failed to send HL7 acknowledgement")
+			return
+		}
+	}
+}
+
+// handle parses and dispatches a message and returns its acknowledgement
+func (s *Server) handle(data []byte, remote string) []byte {
+	msg, err := Parse(data)
+	if err != nil {
+		log.Warn().Err(err).Str("remote_addr", remote).Msg("Rejected HL7 message")
+		return RejectAck(err.Error())
+	}
+
+	msgType, trigger := msg.Type()
+	logger := log.With().
+		Str("remote_addr", remote).
+		Str("message_type", msgType+"^"+trigger).
+		Str("control_id", msg.ControlID()).
+		Logger()
+
+	ctx, cancel := context.WithTimeout(context.Background(), handleTimeout)
+	defer cancel()
+
+	if err := s.handler.HandleMessage(ctx, msg); err != nil {
+		logger.Warn().Err(err).Msg("Failed to handle HL7 message")
+		return msg.Ack(AckError, err.Error())
+	}
+
+	logger.Debug().Msg("HL7 message accepted")
+	return msg.Ack(AckAccept, "")
+}
+
+// readFrame reads one MLLP frame, discarding any bytes before the start block
+func readFrame(reader *bufio.Reader) ([]byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == startBlock {
+			break
+		}
+	}
+
+	var frame bytes.Buffer
+	for {
+		chunk, err := reader.ReadSlice(endBlock)
+		if frame.Len()+len(chunk) > maxMessageSize {
+			return nil, fmt.Errorf("message exceeds %d bytes", maxMessageSize)
+		}
+		frame.Write(chunk)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+	}
+
+	if b, err := reader.ReadByte(); err != nil {
+		return nil, err
+	} else if b != carriageReturn {
+		return nil, fmt.Errorf("missing carriage return after end block")
+	}
+
+	return bytes.TrimSuffix(frame.Bytes(), []byte{endBlock}), nil
+}
+
+// writeFrame writes a message in an MLLP frame
+func writeFrame(w io.Writer, data []byte) error {
+	frame := make([]byte, 0, len(data)+3)
+	frame = append(frame, startBlock)
+	frame = append(frame, data...)
+	frame = append(frame, endBlock, carriageReturn)
+	_, err := w.Write(frame)
+	return err
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// RequireAdmin restricts a route to callers presenting the admin bearer token,
+// or a credential granted the admin scope. When neither is configured, admin
+// routes are disabled entirely.
+func RequireAdmin(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if scopes, _ := GrantedScopes(r.Context()); slices.Contains(scopes, models.ScopeAdmin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if token == "" {
+				apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "Admin operations are disabled")
+				return
+			}
+
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || provided == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, "Admin authorization required")
+				return
+			}
+
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				log.Warn().Str("path", r.URL.Path).Msg("Rejected admin request with invalid token")
+				apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "Forbidden")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/audit"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// AuditConfig configures how the Audit middleware identifies the user
+type AuditConfig struct {
+	JWTSecret []byte // verifies HS256 bearer tokens; empty trusts any well-formed token
+	UserClaim string // JWT claim naming the user
+}
+
+const (
+	// maxAuditUserLength is the length of the audit log's user column
+	maxAuditUserLength = 255
+	// maxAuditUserAgentLength bounds the user agent recorded per request
+	maxAuditUserAgentLength = 512
+)
+
+// Audit records an audit entry for every request of a tenant with record,
+// after the request was served: the user from the bearer JWT or an earlier
+// credential such as a viewer grant, the action derived from category and the
+// route, the resource UIDs in the path, the outcome, duration and client.
+// Requests a service already wrote a more specific entry for are not recorded
+// twice. record must not block; it runs on the request's goroutine.
+func Audit(category string, config AuditConfig, record func(entry models.AuditLog)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID, ok := GetTenantID(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+
+			ctx := r.Context()
+			user := audit.User(ctx)
+			if user == "" {
+				user = bearerUser(r, config)
+				ctx = audit.WithUser(ctx, user)
+			}
+			ctx, req := audit.WithRequest(ctx)
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+			if req.Recorded() {
+				return
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			entry := models.AuditLog{
+				TenantID:   tenantID,
+				User:       user,
+				Action:     requestAction(category, r),
+				IPAddress:  clientAddress(r),
+				UserAgent:  r.UserAgent(),
+				Status:     "success",
+				StatusCode: status,
+				Duration:   time.Since(start).Milliseconds(),
+				CreatedAt:  time.Now(),
+			}
+			if userID, err := uuid.Parse(user); err == nil {
+				entry.UserID = userID
+			}
+			if len(entry.UserAgent) > maxAuditUserAgentLength {
+				entry.UserAgent = entry.UserAgent[:maxAuditUserAgentLength]
+			}
+			entry.ResourceType, entry.ResourceUID = auditResource(r)
+			entry.StudyUID = chi.URLParam(r, "studyUID")
+			entry.Query = r.URL.RawQuery
+			if status >= http.StatusBadRequest {
+				entry.Status = "failure"
+				entry.ErrorMessage = http.StatusText(status)
+			}
+			record(entry)
+		})
+	}
+}
+
+// bearerUser returns the user named by the request's bearer JWT, or "" when
+// it carries none
+func bearerUser(r *http.Request, config AuditConfig) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || strings.Count(token, ".") != 2 {
+		return ""
+	}
+	user, err := audit.TokenUser(token, config.JWTSecret, config.UserClaim)
+	if err != nil {
+		log.Debug().Err(err).Msg("Bearer token does not identify a user for the audit log")
+		return ""
+	}
+	if len(user) > maxAuditUserLength {
+		user = user[:maxAuditUserLength]
+	}
+	return user
+}
+
+// clientAddress returns the client IP of a request, without the port
+// RemoteAddr carries when no proxy header set it
+func clientAddress(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// requestAction names what a request did. DICOMweb requests are told apart by
+// service (qido, wado, stow, delete, ups); other requests are named after the
+// HTTP method.
+func requestAction(category string, r *http.Request) string {
+	if category != "dicomweb" {
+		return category + "." + strings.ToLower(r.Method)
+	}
+
+	pattern := chi.RouteContext(r.Context()).RoutePattern()
+	switch {
+	case strings.Contains(pattern, "/workitems"):
+		return "dicomweb.ups"
+	case r.Method == http.MethodDelete:
+		return "dicomweb.delete"
+	case r.Method == http.MethodPost:
+		return "dicomweb.stow"
+	case strings.HasSuffix(pattern, "/studies"), strings.HasSuffix(pattern, "/series"), strings.HasSuffix(pattern, "/instances"):
+		return "dicomweb.qido"
+	case strings.HasSuffix(pattern, "/capabilities"), r.Method == http.MethodOptions:
+		return "dicomweb.capabilities"
+	}
+	return "dicomweb.wado"
+}
+
+// auditResource returns the most specific resource named in the request path
+func auditResource(r *http.Request) (string, string) {
+	rctx := chi.RouteContext(r.Context())
+	for _, param := range []struct{ name, resourceType string }{
+		{"instanceUID", "instance"},
+		{"seriesUID", "series"},
+		{"studyUID", "study"},
+		{"workitemUID", "workitem"},
+		{"patientID", "patient"},
+		{"id", "resource"},
+	} {
+		if value := rctx.URLParam(param.name); value != "" {
+			return param.resourceType, value
+		}
+	}
+	return "", ""
+}
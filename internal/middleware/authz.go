@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/audit"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const scopesKey contextKey = "scopes"
+
+var (
+	ErrAuthorizationRequired = errors.New("authorization required")
+	ErrInsufficientScope     = errors.New("the credential lacks the scope")
+)
+
+// TokenError rejects a bearer token; its message is told to the client
+type TokenError string
+
+func (e TokenError) Error() string {
+	return string(e)
+}
+
+// WithScopes returns a context granting scopes, set by the middleware that
+// authenticated the request's credential
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// GrantedScopes returns the scopes the request's credential grants; ok is false
+// when no credential was authenticated
+func GrantedScopes(ctx context.Context) (scopes []string, ok bool) {
+	scopes, ok = ctx.Value(scopesKey).([]string)
+	return scopes, ok
+}
+
+// JWTClaims are the claims of the bearer JWTs users and service accounts
+// present: the role grants its scopes, and permissions grant further scopes
+type JWTClaims struct {
+	Subject     string   `json:"sub"`
+	TenantID    string   `json:"tenant_id"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+	ExpiresAt   int64    `json:"exp"` // Unix time; 0 for a token that does not expire
+}
+
+// Scopes returns the scopes the claims grant
+func (c *JWTClaims) Scopes() []string {
+	scopes := slices.Clone(models.RoleScopes[c.Role])
+	for _, permission := range c.Permissions {
+		if !slices.Contains(scopes, permission) {
+			scopes = append(scopes, permission)
+		}
+	}
+	return scopes
+}
+
+// AuthzConfig configures role-based access control
+type AuthzConfig struct {
+	Enabled    bool   // every request needs a credential granting the route's scope
+	JWTSecret  []byte // verifies the HS256 bearer JWTs carrying a role
+	AdminToken string // bearer token granted every scope
+}
+
+// Authorizer enforces the scopes route groups declare. Credentials grant
+// scopes: API keys and viewer grants through their own middleware, and, with
+// access control enabled, the admin token and bearer JWTs through
+// Authenticate.
+type Authorizer struct {
+	config AuthzConfig
+}
+
+// NewAuthorizer creates an authorizer
+func NewAuthorizer(config AuthzConfig) *Authorizer {
+	return &Authorizer{config: config}
+}
+
+// Credential is what an authenticated bearer token grants
+type Credential struct {
+	Scopes   []string
+	TenantID uuid.UUID // uuid.Nil for an admin, who may act for any tenant
+	Subject  string
+}
+
+// Enabled reports whether every request needs a credential
+func (a *Authorizer) Enabled() bool {
+	return a.config.Enabled
+}
+
+// VerifyBearer authenticates the admin token or a bearer JWT. A JWT must carry a
+// valid signature and must not have expired, and names its tenant unless its
+// role is admin. A rejected token returns a TokenError.
+func (a *Authorizer) VerifyBearer(token string) (*Credential, error) {
+	if a.config.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.config.AdminToken)) == 1 {
+		return &Credential{Scopes: models.RoleScopes[models.RoleAdmin]}, nil
+	}
+
+	var claims JWTClaims
+	if err := audit.ParseClaims(token, a.config.JWTSecret, &claims); err != nil {
+		log.Debug().Err(err).Msg("Rejected invalid bearer token")
+		return nil, TokenError("The bearer token is invalid")
+	}
+	if claims.ExpiresAt != 0 && !time.Now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, TokenError("The bearer token has expired")
+	}
+
+	// Only admins may act for any tenant; everyone else is held to the
+	// tenant their token names
+	credential := &Credential{Scopes: claims.Scopes(), Subject: claims.Subject}
+	switch {
+	case claims.TenantID != "":
+		tenantID, err := uuid.Parse(claims.TenantID)
+		if err != nil {
+			return nil, TokenError("The bearer token names an invalid tenant")
+		}
+		credential.TenantID = tenantID
+	case claims.Role != models.RoleAdmin:
+		return nil, TokenError("The bearer token names no tenant")
+	}
+	return credential, nil
+}
+
+// CheckScope returns ErrAuthorizationRequired when access control is enabled
+// and ctx carries no credential, or ErrInsufficientScope when its credential
+// does not grant scope
+func (a *Authorizer) CheckScope(ctx context.Context, scope string) error {
+	granted, ok := GrantedScopes(ctx)
+	switch {
+	case !ok && a.config.Enabled:
+		return ErrAuthorizationRequired
+	case ok && !slices.Contains(granted, scope):
+		return ErrInsufficientScope
+	}
+	return nil
+}
+
+// Authenticate grants the scopes of the admin token or of a bearer JWT's role
+// and permissions, as VerifyBearer authenticates them. A JWT's tenant_id claim
+// supplies the tenant, which an X-Tenant-ID header cannot change. Only admin
+// tokens may leave it out and name the tenant in the header. Requests whose
+// credential was authenticated before, and every request while access control
+// is disabled, pass through.
+func (a *Authorizer) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := GrantedScopes(r.Context()); ok || !a.config.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		credential, err := a.VerifyBearer(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, err.Error())
+			return
+		}
+
+		ctx := WithScopes(r.Context(), credential.Scopes)
+		if credential.TenantID != uuid.Nil {
+			if header := r.Header.Get("X-Tenant-ID"); header != "" && header != credential.TenantID.String() {
+				apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The bearer token belongs to another tenant")
+				return
+			}
+			ctx = context.WithValue(ctx, TenantIDKey, credential.TenantID)
+		}
+		if credential.Subject != "" {
+			ctx = audit.WithUser(ctx, credential.Subject)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Require restricts a route to credentials granting scope, whatever the method
+func (a *Authorizer) Require(scope string) func(http.Handler) http.Handler {
+	return a.RequireReadWrite(scope, scope)
+}
+
+// RequireReadWrite restricts a route to credentials granting its scope: read
+// for GET and HEAD, write for other methods. Without access control enabled,
+// requests without a credential pass through, as before API keys and roles
+// existed.
+func (a *Authorizer) RequireReadWrite(read, write string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope := write
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				scope = read
+			}
+
+			switch err := a.CheckScope(r.Context(), scope); {
+			case errors.Is(err, ErrAuthorizationRequired):
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api", scope="`+scope+`"`)
+				apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authorization required")
+				return
+			case errors.Is(err, ErrInsufficientScope):
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="insufficient_scope", scope="`+scope+`"`)
+				apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The credential lacks the "+scope+" scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/ratelimit"
+	"github.com/rs/zerolog/log"
+)
+
+// RateLimit limits the requests of each tenant to the limit limitFor returns
+// for it, answering 429 with a Retry-After header once the tenant's bucket is
+// empty. It must run after the tenant is known. Requests are let through when
+// the limiter fails, so an unreachable Redis does not take the API down.
+func RateLimit(limiter ratelimit.Limiter, limitFor func(ctx context.Context, tenantID uuid.UUID) ratelimit.Limit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID, ok := GetTenantID(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, retryAfter, err := limiter.Allow(r.Context(), "tenant:"+tenantID.String(), limitFor(r.Context(), tenantID))
+			if err != nil {
+				log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("Rate limiter failed; letting the request through")
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				apierror.Write(w, http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many requests for this tenant; retry later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
 	"github.com/rs/zerolog/log"
 )
 
@@ -16,7 +17,7 @@ func Recovery(next http.Handler) http.Handler {
 					Str("path", r.URL.Path).
 					Msg("Panic recovered")
 
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Internal Server Error")
 			}
 		}()
 
@@ -2,9 +2,13 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/apierror"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
 	"github.com/rs/zerolog/log"
 )
 
@@ -12,27 +16,116 @@ type contextKey string
 
 const TenantIDKey contextKey = "tenant_id"
 
-// TenantID middleware extracts tenant ID from header
-func TenantID(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tenantIDStr := r.Header.Get("X-Tenant-ID")
-		if tenantIDStr == "" {
-			log.Warn().Msg("Missing X-Tenant-ID header")
-			http.Error(w, "X-Tenant-ID header is required", http.StatusBadRequest)
-			return
-		}
-
-		tenantID, err := uuid.Parse(tenantIDStr)
-		if err != nil {
-			log.Warn().Err(err).Str("tenant_id", tenantIDStr).Msg("Invalid tenant ID")
-			http.Error(w, "Invalid X-Tenant-ID format", http.StatusBadRequest)
-			return
-		}
-
-		// Add tenant ID to context
-		ctx := context.WithValue(r.Context(), TenantIDKey, tenantID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// Tenant validation errors
+var (
+	ErrTenantNotProvisioned = errors.New("tenant is not provisioned")
+	ErrTenantDisabled       = errors.New("tenant is disabled")
+)
+
+// TenantLookup returns a tenant, or nil when it is not provisioned
+type TenantLookup func(ctx context.Context, tenantID uuid.UUID) (*models.Tenant, error)
+
+// ValidateTenant returns ErrTenantNotProvisioned or ErrTenantDisabled unless
+// the tenant is provisioned and active
+func ValidateTenant(ctx context.Context, lookup TenantLookup, tenantID uuid.UUID) error {
+	tenant, err := lookup(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to look up tenant: %w", err)
+	}
+	if tenant == nil {
+		return ErrTenantNotProvisioned
+	}
+	if !tenant.Active() {
+		return ErrTenantDisabled
+	}
+	return nil
+}
+
+// TenantID middleware extracts tenant ID from header. A tenant already set by an
+// earlier credential, such as a viewer grant, is kept. Either way the tenant
+// must be provisioned and active.
+func TenantID(lookup TenantLookup) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tenantID, ok := GetTenantID(r.Context()); ok {
+				if validTenant(w, r, lookup, tenantID) {
+					next.ServeHTTP(w, r)
+				}
+				return
+			}
+			serveWithTenant(w, r, next, lookup, r.Header.Get("X-Tenant-ID"), "X-Tenant-ID header")
+		})
+	}
+}
+
+// TenantIDOrQuery extracts the tenant ID from the X-Tenant-ID header or, for
+// links opened in a browser that cannot set headers, from the named query
+// parameter. A tenant set by the request's credential is kept, and the
+// parameter may not name another one.
+func TenantIDOrQuery(param string, lookup TenantLookup) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tenantID, ok := GetTenantID(r.Context()); ok {
+				if query := r.URL.Query().Get(param); query != "" && query != tenantID.String() {
+					apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The credential belongs to another tenant")
+					return
+				}
+				if validTenant(w, r, lookup, tenantID) {
+					next.ServeHTTP(w, r)
+				}
+				return
+			}
+			if tenantIDStr := r.Header.Get("X-Tenant-ID"); tenantIDStr != "" {
+				serveWithTenant(w, r, next, lookup, tenantIDStr, "X-Tenant-ID header")
+				return
+			}
+			serveWithTenant(w, r, next, lookup, r.URL.Query().Get(param), "X-Tenant-ID header or "+param+" parameter")
+		})
+	}
+}
+
+// serveWithTenant parses the tenant ID, checks the tenant and adds its ID to
+// the request context; source names where the ID came from in error responses
+func serveWithTenant(w http.ResponseWriter, r *http.Request, next http.Handler, lookup TenantLookup, tenantIDStr, source string) {
+	if tenantIDStr == "" {
+		log.Warn().Msg("Missing tenant ID")
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, source+" is required")
+		return
+	}
+
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantIDStr).Msg("Invalid tenant ID")
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeTenantRequired, "Invalid "+source+" format")
+		return
+	}
+	if !validTenant(w, r, lookup, tenantID) {
+		return
+	}
+
+	// Add tenant ID to context
+	ctx := context.WithValue(r.Context(), TenantIDKey, tenantID)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// validTenant writes the error response and returns false unless the tenant
+// is provisioned and active
+func validTenant(w http.ResponseWriter, r *http.Request, lookup TenantLookup, tenantID uuid.UUID) bool {
+	err := ValidateTenant(r.Context(), lookup, tenantID)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrTenantNotProvisioned):
+		log.Warn().Str("tenant_id", tenantID.String()).Msg("Rejected request of unknown tenant")
+		apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The tenant is not provisioned")
+	case errors.Is(err, ErrTenantDisabled):
+		apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "The tenant is disabled")
+	default:
+		log.Error().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to validate tenant")
+		w.Header().Set("Retry-After", "30")
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.CodeServiceNotReady, "The tenant could not be verified")
+	}
+	return false
 }
 
 // GetTenantID extracts tenant ID from context
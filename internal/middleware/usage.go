@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// Usage meters every request of a tenant with record once it was served: the
+// bytes of its response and, for a DICOMweb request of category dicomweb
+// answered with a success, whether it was a WADO-RS retrieval. record must
+// not block; it runs on the request's goroutine.
+func Usage(category string, record func(tenantID uuid.UUID, retrieval bool, bytes int64)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID, ok := GetTenantID(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			// Existence checks (HEAD) are not retrievals
+			retrieval := category == "dicomweb" && r.Method == http.MethodGet &&
+				ww.Status() < http.StatusBadRequest && requestAction(category, r) == "dicomweb.wado"
+			record(tenantID, retrieval, int64(ww.BytesWritten()))
+		})
+	}
+}
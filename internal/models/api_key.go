@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKeyTokenPrefix marks API keys, so they are told apart from other bearer
+// tokens
+const APIKeyTokenPrefix = "ak_"
+
+// APIKeyScopes lists the scopes an API key can be given; keys never reach the
+// admin routes
+var APIKeyScopes = []string{
+	ScopeDICOMWebRead,
+	ScopeDICOMWebWrite,
+	ScopeFHIRRead,
+	ScopeManagementRead,
+	ScopeManagementWrite,
+	ScopePrefetch,
+	ScopeExport,
+}
+
+// APIKey authenticates a machine-to-machine caller of one tenant, limited to
+// its scopes. Only a hash of the key is stored. A signing key is not sent as a
+// bearer token but signs each request with a secret derived from its ID.
+type APIKey struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID  uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	KeyPrefix string    `gorm:"type:varchar(16);not null" json:"key_prefix"` // first characters of the key, to recognize it
+	KeyHash   string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	Scopes    []string  `gorm:"type:text[];default:'{}'" json:"scopes"`
+	Signing   bool      `gorm:"not null;default:false" json:"signing"` // only authenticates signed requests
+
+	RotatedFromID *uuid.UUID `gorm:"type:uuid" json:"rotated_from_id,omitempty"` // key this one replaced
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`                       // nil never expires
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"` // updated at most every half minute per connector instance
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// BeforeCreate hook
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// APIKeyRequest represents a request to issue an API key
+type APIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty"` // 0 never expires
+	Signing       bool     `json:"signing,omitempty"`         // issue a signing secret for signed requests instead of a bearer key
+}
+
+// APIKeyRotateRequest represents a request to replace an API key by a new one
+// with the same name and scopes
+type APIKeyRotateRequest struct {
+	ExpiresInDays int `json:"expires_in_days,omitempty"` // of the new key; 0 never expires
+	GracePeriod   int `json:"grace_period,omitempty"`    // seconds the old key stays valid, so callers can switch; 0 revokes it at once
+}
+
+// IssuedAPIKey carries a newly issued API key; it is the only time the key, or
+// the signing secret of a signing key, is shown
+type IssuedAPIKey struct {
+	APIKey
+	Key           string `json:"key,omitempty"`
+	SigningSecret string `json:"signing_secret,omitempty"`
+}
+
+// APIKeyNonce is a nonce of a signed request, remembered until the request's
+// timestamp is no longer accepted so the request cannot be replayed
+type APIKeyNonce struct {
+	KeyID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Nonce     string    `gorm:"type:varchar(128);primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// TableName overrides the table name
+func (APIKeyNonce) TableName() string {
+	return "api_key_nonces"
+}
@@ -0,0 +1,33 @@
+package models
+
+// Rejection note reasons understood by archives that support IHE IOCM (dcm4chee-arc)
+const (
+	RejectionQuality          = "quality"            // 113001^DCM Rejected for Quality Reasons
+	RejectionPatientSafety    = "patient-safety"     // 113037^DCM Rejected for Patient Safety Reasons
+	RejectionIncorrectMWL     = "incorrect-worklist" // 113038^DCM Incorrect Modality Worklist Entry
+	RejectionRetentionExpired = "retention-expired"  // 113039^DCM Data Retention Policy Expired
+)
+
+// RejectionRequest rejects a study, series or instance with a rejection note
+type RejectionRequest struct {
+	StudyUID    string `json:"-"`
+	SeriesUID   string `json:"series_uid,omitempty"`   // empty rejects the whole study
+	InstanceUID string `json:"instance_uid,omitempty"` // requires SeriesUID
+	Reason      string `json:"reason"`                 // one of the Rejection* reasons or CODE^SCHEME
+}
+
+// ExportRequest sends a study, series or instance to an exporter configured in the archive
+type ExportRequest struct {
+	StudyUID    string `json:"-"`
+	SeriesUID   string `json:"series_uid,omitempty"`
+	InstanceUID string `json:"instance_uid,omitempty"`
+	ExporterID  string `json:"exporter_id"`
+}
+
+// PatientMergeRequest merges the studies of a prior patient into a target patient
+type PatientMergeRequest struct {
+	PatientID       string `json:"patient_id"`
+	IssuerOfPatient string `json:"issuer_of_patient_id,omitempty"`
+	PriorPatientID  string `json:"prior_patient_id"`
+	PriorIssuer     string `json:"prior_issuer_of_patient_id,omitempty"`
+}
@@ -1,26 +1,41 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-// AuditLog represents an audit log entry
+// AuditLog represents an audit log entry. The entries of a tenant form a hash
+// chain: each one's hash covers its fields and the hash of the entry before
+// it, so altering, removing or reordering an entry breaks every hash after it.
 type AuditLog struct {
 	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	TenantID     uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;index;index:idx_audit_logs_chain,priority:1" json:"tenant_id"`
+	Sequence     int64     `gorm:"index:idx_audit_logs_chain,priority:2" json:"sequence"` // position in the tenant's chain, from 1; 0 for entries recorded before chaining
+	PrevHash     string    `gorm:"type:varchar(64)" json:"prev_hash,omitempty"`           // hash of the tenant's previous entry; empty for the first
+	Hash         string    `gorm:"type:varchar(64)" json:"hash,omitempty"`                // hex SHA-256 of PrevHash and the entry's fields
 	UserID       uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
+	User         string    `gorm:"type:varchar(255);index" json:"user,omitempty"` // user named by the caller's credentials; UserID is set when it is a UUID
 	Action       string    `gorm:"type:varchar(100);not null;index" json:"action"`
 	ResourceType string    `gorm:"type:varchar(50);index" json:"resource_type"`
 	ResourceUID  string    `gorm:"type:varchar(255);index" json:"resource_uid"`
 	IPAddress    string    `gorm:"type:varchar(45)" json:"ip_address"`
 	UserAgent    string    `gorm:"type:text" json:"user_agent"`
 	Status       string    `gorm:"type:varchar(20);index" json:"status"` // success, failure
+	StatusCode   int       `json:"status_code,omitempty"`                // HTTP status of a request recorded by the audit middleware
 	ErrorMessage string    `gorm:"type:text" json:"error_message,omitempty"`
 	Duration     int64     `json:"duration_ms"` // milliseconds
 	CreatedAt    time.Time `gorm:"index" json:"timestamp"`
+
+	// Not stored: forwarded to the Audit Record Repository with the entry
+	StudyUID string `gorm:"-" json:"-"` // study of a series or instance resource
+	Query    string `gorm:"-" json:"-"` // query string of a search
 }
 
 // TableName overrides the table name
@@ -36,13 +51,76 @@ func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// ChainHash returns the hash of the entry: the SHA-256 of its previous hash
+// and its fields, encoded as a JSON array so no field can spill into another.
+// CreatedAt is hashed in UTC at the microsecond precision it is stored with.
+func (a *AuditLog) ChainHash() string {
+	fields, _ := json.Marshal([]string{
+		a.PrevHash,
+		a.ID.String(),
+		a.TenantID.String(),
+		strconv.FormatInt(a.Sequence, 10),
+		a.UserID.String(),
+		a.User,
+		a.Action,
+		a.ResourceType,
+		a.ResourceUID,
+		a.IPAddress,
+		a.UserAgent,
+		a.Status,
+		strconv.Itoa(a.StatusCode),
+		a.ErrorMessage,
+		strconv.FormatInt(a.Duration, 10),
+		a.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(fields)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditChainHead is the last entry of a tenant's audit chain. Appending to the
+// chain locks it, so entries written by several connector instances are
+// chained one after another, and comparing it with the last stored entry
+// reveals entries removed from the end of the chain. The last entry the
+// retention removed does the same for the start of the chain.
+type AuditChainHead struct {
+	TenantID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"tenant_id"`
+	Sequence       int64     `gorm:"not null" json:"sequence"`
+	Hash           string    `gorm:"type:varchar(64)" json:"hash"`
+	PrunedSequence int64     `gorm:"not null;default:0" json:"pruned_sequence,omitempty"` // last entry removed by the retention; 0 when none was
+	PrunedHash     string    `gorm:"type:varchar(64)" json:"pruned_hash,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (AuditChainHead) TableName() string {
+	return "audit_chain_heads"
+}
+
+// AuditChainVerification reports whether a tenant's audit chain is intact.
+// Entries removed by the audit retention are gone from the start of the
+// chain, so verification starts after the last one removed, which the chain
+// head records.
+type AuditChainVerification struct {
+	TenantID       uuid.UUID `json:"tenant_id"`
+	Valid          bool      `json:"valid"`
+	Entries        int64     `json:"entries"`                   // entries verified
+	FirstSequence  int64     `json:"first_sequence,omitempty"`  // oldest entry kept
+	PrunedSequence int64     `json:"pruned_sequence,omitempty"` // last entry removed by the retention
+	LastSequence   int64     `json:"last_sequence,omitempty"`   // last entry verified
+	HeadSequence   int64     `json:"head_sequence"`
+	HeadHash       string    `json:"head_hash,omitempty"`
+	BrokenAt       int64     `json:"broken_at,omitempty"` // sequence where the chain first breaks
+	Reason         string    `json:"reason,omitempty"`    // why it breaks there
+	VerifiedAt     time.Time `json:"verified_at"`
+}
+
 // CacheMetrics tracks cache performance metrics
 type CacheMetrics struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
 	TenantID  uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
 	CacheKey  string    `gorm:"type:varchar(500);not null" json:"cache_key"`
 	CacheHit  bool      `gorm:"not null;index" json:"cache_hit"`
-	CacheTier string    `gorm:"type:varchar(20)" json:"cache_tier"` // redis, s3, pacs
+	CacheTier string    `gorm:"type:varchar(20)" json:"cache_tier"` // tier serving a hit, e.g. memory, redis or s3; the cache type for misses
 	Size      int64     `json:"size_bytes"`
 	Duration  int64     `json:"duration_ms"`
 	CreatedAt time.Time `gorm:"index" json:"timestamp"`
@@ -0,0 +1,48 @@
+package models
+
+// Scopes granted to callers, each allowing an API area. An area with separate
+// read and write scopes needs the read scope for GET and HEAD and the write
+// scope otherwise.
+const (
+	ScopeDICOMWebRead    = "dicomweb:read"
+	ScopeDICOMWebWrite   = "dicomweb:write"
+	ScopeFHIRRead        = "fhir:read"
+	ScopeManagementRead  = "management:read"
+	ScopeManagementWrite = "management:write"
+	ScopePrefetch        = "prefetch"
+	ScopeExport          = "export"
+	ScopeAdmin           = "admin"
+)
+
+// Roles named by the role claim of a bearer JWT
+const (
+	RoleViewer  = "viewer"
+	RoleService = "service"
+	RoleAdmin   = "admin"
+)
+
+// RoleScopes lists the scopes each role grants: viewers read studies through
+// QIDO-RS, WADO-RS and FHIR, service accounts also prefetch and export them,
+// and admins reach every API, management and cache administration included
+var RoleScopes = map[string][]string{
+	RoleViewer: {
+		ScopeDICOMWebRead,
+		ScopeFHIRRead,
+	},
+	RoleService: {
+		ScopeDICOMWebRead,
+		ScopeFHIRRead,
+		ScopePrefetch,
+		ScopeExport,
+	},
+	RoleAdmin: {
+		ScopeDICOMWebRead,
+		ScopeDICOMWebWrite,
+		ScopeFHIRRead,
+		ScopeManagementRead,
+		ScopeManagementWrite,
+		ScopePrefetch,
+		ScopeExport,
+		ScopeAdmin,
+	},
+}
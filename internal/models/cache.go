@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// CacheResource is a type of cached entry
+type CacheResource string
+
+const (
+	CacheResourceInstance  CacheResource = "instance"  // instance bodies
+	CacheResourceMetadata  CacheResource = "metadata"  // study, series and instance metadata
+	CacheResourceQuery     CacheResource = "query"     // QIDO result sets
+	CacheResourceThumbnail CacheResource = "thumbnail" // rendered thumbnails
+	CacheResourceExists    CacheResource = "exists"    // existence probes
+	CacheResourceMissing   CacheResource = "missing"   // not-found answers
+)
+
+// CachePurgeRequest selects the cached entries of a tenant to clear. Without a
+// study the whole tenant is purged, which has to be asked for explicitly.
+type CachePurgeRequest struct {
+	StudyUID    string        `json:"study_uid,omitempty"`
+	SeriesUID   string        `json:"series_uid,omitempty"`
+	Resource    CacheResource `json:"resource,omitempty"` // empty clears every type
+	PurgeTenant bool          `json:"purge_tenant,omitempty"`
+}
+
+// CacheStats are a tenant's cache statistics since the connector started
+type CacheStats struct {
+	TenantID string           `json:"tenant_id"`
+	Tiers    []CacheTierStats `json:"tiers"`
+}
+
+// CacheTierStats are the cache statistics of a tenant in one tier. A tiered
+// cache reports each tier and, as "tiered", the cache as a whole.
+type CacheTierStats struct {
+	Tier        string  `json:"tier"`
+	Hits        uint64  `json:"hits"`
+	Misses      uint64  `json:"misses"`
+	HitRatio    float64 `json:"hit_ratio"`
+	Sets        uint64  `json:"sets"`
+	Evictions   uint64  `json:"evictions"`
+	BytesStored *int64  `json:"bytes_stored,omitempty"` // only for tiers tracked in process
+}
+
+// CacheMetricsSummary aggregates a tenant's recorded cache reads over a period
+type CacheMetricsSummary struct {
+	TenantID string             `json:"tenant_id"`
+	From     time.Time          `json:"from"`
+	To       time.Time          `json:"to"`
+	Tiers    []CacheTierMetrics `json:"tiers"`
+}
+
+// CacheTierMetrics aggregates the recorded cache reads of one tier
+type CacheTierMetrics struct {
+	Tier          string  `json:"tier"`
+	Reads         int64   `json:"reads"`
+	Hits          int64   `json:"hits"`
+	HitRatio      float64 `json:"hit_ratio"`
+	BytesServed   int64   `json:"bytes_served"`
+	AvgDurationMs float64 `json:"avg_duration_ms"`
+	MaxDurationMs int64   `json:"max_duration_ms"`
+}
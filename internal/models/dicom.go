@@ -1,16 +1,32 @@
 package models
 
+import "io"
+
 // QueryParams represents DICOM query parameters
 type QueryParams struct {
-	PatientID        string `json:"patient_id,omitempty"`
-	PatientName      string `json:"patient_name,omitempty"`
-	StudyDate        string `json:"study_date,omitempty"`
-	StudyTime        string `json:"study_time,omitempty"`
-	AccessionNumber  string `json:"accession_number,omitempty"`
-	Modality         string `json:"modality,omitempty"`
-	StudyDescription string `json:"study_description,omitempty"`
-	Limit            int    `json:"limit,omitempty"`
-	Offset           int    `json:"offset,omitempty"`
+	PatientID        string            `json:"patient_id,omitempty"`
+	PatientName      string            `json:"patient_name,omitempty"`
+	StudyDate        string            `json:"study_date,omitempty"`
+	StudyTime        string            `json:"study_time,omitempty"`
+	AccessionNumber  string            `json:"accession_number,omitempty"`
+	Modalities       []string          `json:"modalities,omitempty"` // matches studies containing any of the modalities
+	StudyDescription string            `json:"study_description,omitempty"`
+	Filters          map[string]string `json:"filters,omitempty"` // additional matching keys by tag (GGGGEEEE)
+	Limit            int               `json:"limit,omitempty"`
+	Offset           int               `json:"offset,omitempty"`
+}
+
+// RetrieveOptions represents the representation requested for a WADO-RS retrieve
+type RetrieveOptions struct {
+	TransferSyntax string `json:"transfer_syntax,omitempty"` // empty or "*" accepts any transfer syntax
+	Range          string `json:"range,omitempty"`           // HTTP Range header for partial retrieval
+}
+
+// InstanceBody wraps a retrieved instance stream with the transport metadata known about it
+type InstanceBody struct {
+	io.ReadCloser
+	ContentLength int64  // -1 when unknown
+	ContentRange  string // set when only the requested byte range was retrieved, e.g. "bytes 0-1023/4096"
 }
 
 // Study represents a DICOM study
@@ -29,6 +45,53 @@ type Study struct {
 	NumberOfInstances  int      `json:"00201208" dicom:"00201208"`
 	ModalitiesInStudy  []string `json:"00080061" dicom:"00080061"`
 	RetrieveURL        string   `json:"00081190,omitempty"`
+
+	Source *StudySource `json:"source,omitempty"` // archive the study was found in by a search across all PACS
+
+	Normalized *NormalizedStudy `json:"normalized,omitempty"`
+}
+
+// NormalizedStudy holds the dates, times and names of a study in the forms a
+// RIS frontend uses, next to the DICOM values
+type NormalizedStudy struct {
+	StudyDate          string      `json:"study_date,omitempty"`         // YYYY-MM-DD
+	StudyTime          string      `json:"study_time,omitempty"`         // HH:MM:SS, with the precision of the DICOM value
+	PatientBirthDate   string      `json:"patient_birth_date,omitempty"` // YYYY-MM-DD
+	PatientName        *PersonName `json:"patient_name,omitempty"`
+	ReferringPhysician *PersonName `json:"referring_physician,omitempty"`
+}
+
+// PersonName is the alphabetic group of a DICOM PN value split into its components
+type PersonName struct {
+	Family string `json:"family,omitempty"`
+	Given  string `json:"given,omitempty"`
+	Middle string `json:"middle,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// StudySource identifies the PACS config a study was found in
+type StudySource struct {
+	PACSConfigID string `json:"pacs_config_id"`
+	Name         string `json:"name"`
+}
+
+// PatientTimeline is a patient's imaging history across every PACS of the
+// tenant, newest study first
+type PatientTimeline struct {
+	PatientID   string          `json:"patient_id"`
+	Studies     []TimelineStudy `json:"studies"`
+	Truncated   bool            `json:"truncated"`             // a PACS returned no more than its study search cap
+	Unavailable []StudySource   `json:"unavailable,omitempty"` // PACS that could not be searched; their studies are missing
+}
+
+// TimelineStudy is a study of a patient timeline with the archives holding it
+// and whether it can be opened
+type TimelineStudy struct {
+	Study
+	Sources   []StudySource `json:"sources"`
+	Cached    bool          `json:"cached"`    // the study's metadata is in the connector's cache
+	Available bool          `json:"available"` // at least one archive holding the study is not degraded
 }
 
 // Series represents a DICOM series
@@ -44,6 +107,14 @@ type Series struct {
 	ProtocolName       string `json:"00181030" dicom:"00181030"`
 	PerformedProcedure string `json:"00400254" dicom:"00400254"`
 	RetrieveURL        string `json:"00081190,omitempty"`
+
+	Normalized *NormalizedSeries `json:"normalized,omitempty"`
+}
+
+// NormalizedSeries holds the date and time of a series in ISO 8601 form
+type NormalizedSeries struct {
+	SeriesDate string `json:"series_date,omitempty"` // YYYY-MM-DD
+	SeriesTime string `json:"series_time,omitempty"` // HH:MM:SS, with the precision of the DICOM value
 }
 
 // Instance represents a DICOM instance
@@ -71,3 +142,15 @@ type Metadata struct {
 	TransferSyntaxUID string                 `json:"transfer_syntax_uid"`
 	Attributes        map[string]interface{} `json:"attributes"`
 }
+
+// CapabilitiesResponse describes the DICOMweb services available to a tenant
+type CapabilitiesResponse struct {
+	PACSType         PACSType `json:"pacs_type"`
+	Services         []string `json:"services"`
+	Resources        []string `json:"resources"`
+	HeadResources    []string `json:"head_resources"`                  // resources that also answer HEAD
+	Unsupported      []string `json:"unsupported_resources,omitempty"` // resources the PACS cannot serve
+	SOPClasses       []string `json:"sop_classes,omitempty"`
+	TransferSyntaxes []string `json:"transfer_syntaxes"`
+	MediaTypes       []string `json:"media_types"`
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Export job states
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+	ExportExpired   = "expired" // the archive was deleted after its download link expired
+)
+
+// ExportJob is a background export of a study to a ZIP archive of a DICOM
+// File-set with a DICOMDIR, as used for patient CD replacement
+type ExportJob struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	StudyUID    string     `gorm:"type:varchar(255);not null" json:"study_uid"`
+	Status      string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	Instances   int        `gorm:"default:0" json:"instances"`
+	SizeBytes   int64      `gorm:"default:0" json:"size_bytes,omitempty"`
+	FilePath    string     `gorm:"type:text" json:"-"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at,omitempty"` // when the download link expires and the archive is deleted
+
+	DestinationID *uuid.UUID `gorm:"type:uuid" json:"destination_id,omitempty"` // where the archive is pushed once built
+	DeliveredTo   string     `gorm:"type:text" json:"delivered_to,omitempty"`   // location of the pushed archive
+
+	DeidentContext string `gorm:"type:varchar(64)" json:"deident_context,omitempty"` // de-identification context of a de-identified export
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (ExportJob) TableName() string {
+	return "export_jobs"
+}
+
+// BeforeCreate hook
+func (j *ExportJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// ExportJobStatus is an export job with the download link of a completed archive
+type ExportJobStatus struct {
+	ExportJob
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// StudyExportRequest represents the optional body of a study export request
+type StudyExportRequest struct {
+	DestinationID *uuid.UUID `json:"destination_id,omitempty"`
+	Deidentify    string     `json:"deidentify,omitempty"` // de-identification context; the study is exported as is when empty
+}
+
+// MetadataExportJob is a background export of the study and series metadata
+// of a tenant's studies dated in a range of days, written as NDJSON with one
+// study and its series per line, for data warehouse loads and migration audits
+type MetadataExportJob struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	From     string    `gorm:"type:varchar(10);not null" json:"from"` // first study date, YYYY-MM-DD
+	To       string    `gorm:"type:varchar(10);not null" json:"to"`   // last study date, YYYY-MM-DD
+	Status   string    `gorm:"type:varchar(20);not null;index" json:"status"`
+
+	// Progress; days are exported oldest first
+	TotalDays    int   `gorm:"default:0" json:"total_days"`
+	ExportedDays int   `gorm:"default:0" json:"exported_days"`
+	Studies      int   `gorm:"default:0" json:"studies"`
+	Series       int   `gorm:"default:0" json:"series"`
+	SizeBytes    int64 `gorm:"default:0" json:"size_bytes,omitempty"`
+
+	FilePath    string     `gorm:"type:text" json:"-"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at,omitempty"` // when the download link expires and the file is deleted
+
+	DestinationID *uuid.UUID `gorm:"type:uuid" json:"destination_id,omitempty"` // where the file is pushed once written
+	DeliveredTo   string     `gorm:"type:text" json:"delivered_to,omitempty"`   // location of the pushed file
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (MetadataExportJob) TableName() string {
+	return "metadata_export_jobs"
+}
+
+// BeforeCreate hook
+func (j *MetadataExportJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// MetadataExportJobStatus is a metadata export job with the download link of a completed file
+type MetadataExportJobStatus struct {
+	MetadataExportJob
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// MetadataExportRequest represents a request to export the metadata of a tenant's studies
+type MetadataExportRequest struct {
+	From          string     `json:"from"` // YYYY-MM-DD
+	To            string     `json:"to"`   // YYYY-MM-DD, inclusive
+	DestinationID *uuid.UUID `json:"destination_id,omitempty"`
+}
+
+// MetadataExportRecord is one line of a metadata export: a study with its series
+type MetadataExportRecord struct {
+	Study
+	Series []Series `json:"series"`
+}
+
+// DestinationType is the kind of external storage an export is pushed to
+type DestinationType string
+
+const (
+	DestinationTypeS3        DestinationType = "s3"         // Amazon S3 or an S3-compatible store such as MinIO
+	DestinationTypeGCS       DestinationType = "gcs"        // Google Cloud Storage
+	DestinationTypeAzureBlob DestinationType = "azure-blob" // Azure Blob Storage container
+	DestinationTypeSFTP      DestinationType = "sftp"       // SFTP server
+)
+
+// ExportDestination is a tenant's external storage that finished exports can be
+// pushed to, e.g. for research hand-offs or legal requests
+type ExportDestination struct {
+	ID       uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID uuid.UUID       `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	Name     string          `gorm:"type:varchar(255);not null" json:"name"`
+	Type     DestinationType `gorm:"type:varchar(20);not null" json:"type"`
+	Prefix   string          `gorm:"type:varchar(500)" json:"prefix,omitempty"` // key prefix or remote directory of the archives
+	IsActive bool            `gorm:"default:true" json:"is_active"`
+
+	// S3 and GCS
+	Bucket string `gorm:"type:varchar(255)" json:"bucket,omitempty"`
+
+	// S3; Endpoint addresses an S3-compatible API instead of AWS
+	Region          string `gorm:"type:varchar(100)" json:"region,omitempty"`
+	Endpoint        string `gorm:"type:varchar(500)" json:"endpoint,omitempty"`
+	PathStyle       bool   `gorm:"default:false" json:"path_style,omitempty"`
+	AccessKeyID     string `gorm:"type:varchar(255)" json:"access_key_id,omitempty"`
+	SecretAccessKey string `gorm:"type:text" json:"-"` // Encrypted secret access key
+
+	// GCS
+	ServiceAccountKey string `gorm:"type:text" json:"-"` // Encrypted service account JSON key
+
+	// Azure Blob Storage
+	ContainerURL string `gorm:"type:varchar(500)" json:"container_url,omitempty"` // https://<account>.blob.core.windows.net/<container>
+	SASToken     string `gorm:"type:text" json:"-"`                               // Encrypted SAS token with create and write permission
+
+	// SFTP
+	Host       string `gorm:"type:varchar(255)" json:"host,omitempty"`
+	Port       int    `json:"port,omitempty"`
+	Username   string `gorm:"type:varchar(255)" json:"username,omitempty"`
+	Password   string `gorm:"type:text" json:"-"`                  // Encrypted password
+	PrivateKey string `gorm:"type:text" json:"-"`                  // Encrypted PEM private key
+	HostKey    string `gorm:"type:text" json:"host_key,omitempty"` // expected server key, in authorized_keys format
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName overrides the table name
+func (ExportDestination) TableName() string {
+	return "export_destinations"
+}
+
+// BeforeCreate hook
+func (d *ExportDestination) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// ExportDestinationRequest represents a request to register an export destination
+type ExportDestinationRequest struct {
+	Name   string          `json:"name"`
+	Type   DestinationType `json:"type"`
+	Prefix string          `json:"prefix,omitempty"`
+
+	Bucket string `json:"bucket,omitempty"`
+
+	Region          string `json:"region,omitempty"`
+	Endpoint        string `json:"endpoint,omitempty"`
+	PathStyle       bool   `json:"path_style,omitempty"`
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+
+	ServiceAccountKey string `json:"service_account_key,omitempty"` // service account JSON key
+
+	ContainerURL string `json:"container_url,omitempty"`
+	SASToken     string `json:"sas_token,omitempty"`
+
+	Host       string `json:"host,omitempty"`
+	Port       int    `json:"port,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+	PrivateKey string `json:"private_key,omitempty"`
+	HostKey    string `json:"host_key,omitempty"`
+}
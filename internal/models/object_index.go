@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ObjectIndexEntry indexes one DICOM instance stored in an object store bucket so
+// that QIDO queries can be answered without reading the objects
+type ObjectIndexEntry struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	PACSConfigID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_object_index_instance,priority:1;index:idx_object_index_study,priority:1" json:"pacs_config_id"`
+
+	StudyInstanceUID  string `gorm:"type:varchar(64);not null;index:idx_object_index_study,priority:2" json:"study_instance_uid"`
+	SeriesInstanceUID string `gorm:"type:varchar(64);not null" json:"series_instance_uid"`
+	SOPInstanceUID    string `gorm:"type:varchar(64);not null;uniqueIndex:idx_object_index_instance,priority:2" json:"sop_instance_uid"`
+	SOPClassUID       string `gorm:"type:varchar(64)" json:"sop_class_uid"`
+
+	// Patient and study attributes
+	PatientID          string `gorm:"type:varchar(64);index" json:"patient_id"`
+	PatientName        string `gorm:"type:varchar(255)" json:"patient_name"`
+	PatientBirthDate   string `gorm:"type:varchar(8)" json:"patient_birth_date"`
+	PatientSex         string `gorm:"type:varchar(16)" json:"patient_sex"`
+	StudyDate          string `gorm:"type:varchar(8);index" json:"study_date"`
+	StudyTime          string `gorm:"type:varchar(16)" json:"study_time"`
+	StudyDescription   string `gorm:"type:varchar(255)" json:"study_description"`
+	AccessionNumber    string `gorm:"type:varchar(64);index" json:"accession_number"`
+	ReferringPhysician string `gorm:"type:varchar(255)" json:"referring_physician"`
+
+	// Series attributes
+	Modality          string `gorm:"type:varchar(16)" json:"modality"`
+	SeriesNumber      int    `json:"series_number"`
+	SeriesDescription string `gorm:"type:varchar(255)" json:"series_description"`
+	SeriesDate        string `gorm:"type:varchar(8)" json:"series_date"`
+	SeriesTime        string `gorm:"type:varchar(16)" json:"series_time"`
+	BodyPartExamined  string `gorm:"type:varchar(64)" json:"body_part_examined"`
+
+	// Instance attributes
+	InstanceNumber            int    `json:"instance_number"`
+	TransferSyntaxUID         string `gorm:"type:varchar(64)" json:"transfer_syntax_uid"`
+	Rows                      int    `json:"rows"`
+	Columns                   int    `json:"columns"`
+	BitsAllocated             int    `json:"bits_allocated"`
+	BitsStored                int    `json:"bits_stored"`
+	HighBit                   int    `json:"high_bit"`
+	PixelRepresentation       int    `json:"pixel_representation"`
+	PhotometricInterpretation string `gorm:"type:varchar(32)" json:"photometric_interpretation"`
+	SamplesPerPixel           int    `json:"samples_per_pixel"`
+	NumberOfFrames            int    `json:"number_of_frames"`
+
+	// Object location
+	ObjectKey  string `gorm:"type:varchar(1024);not null" json:"object_key"`
+	ObjectSize int64  `json:"object_size"`
+	ObjectETag string `gorm:"type:varchar(255)" json:"object_etag"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (ObjectIndexEntry) TableName() string {
+	return "object_index"
+}
+
+// BeforeCreate hook
+func (e *ObjectIndexEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// ReindexResult summarizes an object store indexing run
+type ReindexResult struct {
+	Scanned int `json:"scanned"`
+	Indexed int `json:"indexed"`
+	Skipped int `json:"skipped"` // already indexed with the same ETag
+	Failed  int `json:"failed"`  // objects that could not be read or parsed
+}
@@ -14,6 +14,29 @@ const (
 	PACSTypeDICOMWeb PACSType = "dicomweb"
 	PACSTypeDIMSE    PACSType = "dimse"
 	PACSTypeOrthanc  PACSType = "orthanc"
+	PACSTypeDcm4chee PACSType = "dcm4chee"
+	PACSTypeS3       PACSType = "s3"    // S3/MinIO bucket of DICOM objects indexed in the database
+	PACSTypeXDSI     PACSType = "xds-i" // IHE XDS-I.b Imaging Document Source (RAD-69 retrieve only)
+	PACSTypeGRPC     PACSType = "grpc"  // out-of-process adapter plugin serving pacsplugin.v1
+
+	// PACSTypeConnector proxies the DICOMweb API of another ris-dicom-connector
+	PACSTypeConnector PACSType = "connector"
+
+	// PACSTypeFederated queries the tenant's other PACS configs as one archive
+	PACSTypeFederated PACSType = "federated"
+
+	PACSTypeGoogleHealthcare PACSType = "google-healthcare"
+)
+
+// AuthMode represents how the connector authenticates against a PACS
+type AuthMode string
+
+const (
+	AuthModeNone   AuthMode = "none"
+	AuthModeBasic  AuthMode = "basic"
+	AuthModeAPIKey AuthMode = "api_key"
+	AuthModeOAuth2 AuthMode = "oauth2" // OAuth2 client-credentials grant
+	AuthModeSigV4  AuthMode = "sigv4"  // AWS Signature Version 4, e.g. behind API Gateway
 )
 
 // PACSConfig represents a tenant's PACS configuration
@@ -25,21 +48,104 @@ type PACSConfig struct {
 	Endpoint     string    `gorm:"type:varchar(500);not null" json:"endpoint"`
 	Port         int       `gorm:"not null" json:"port"`
 	AETitle      string    `gorm:"type:varchar(50)" json:"ae_title"`
+	BaseURL      string    `gorm:"type:varchar(1000)" json:"base_url,omitempty"` // DICOMweb root URL or template, e.g. https://{host}:{port}/dcm4chee-arc/aets/{ae_title}/rs
+	BasePath     string    `gorm:"type:varchar(500)" json:"base_path,omitempty"` // DICOMweb path appended to endpoint when BaseURL is empty (default /dicom-web)
 	Username     string    `gorm:"type:varchar(255)" json:"username,omitempty"`
 	PasswordHash string    `gorm:"type:text" json:"-"` // Encrypted password
 	APIKey       string    `gorm:"type:text" json:"-"` // Encrypted API key
 	Capabilities []string  `gorm:"type:text[];default:'{}'" json:"capabilities"`
-	IsActive     bool      `gorm:"default:true" json:"is_active"`
-	IsPrimary    bool      `gorm:"default:false" json:"is_primary"`
+
+	// OAuth2 client-credentials settings (AuthMode oauth2)
+	AuthMode          AuthMode `gorm:"type:varchar(20)" json:"auth_mode,omitempty"`
+	OAuthTokenURL     string   `gorm:"type:varchar(1000)" json:"oauth_token_url,omitempty"`
+	OAuthClientID     string   `gorm:"type:varchar(255)" json:"oauth_client_id,omitempty"`
+	OAuthClientSecret string   `gorm:"type:text" json:"-"`                      // Encrypted client secret
+	OAuthScopes       string   `gorm:"type:text" json:"oauth_scopes,omitempty"` // space separated
+
+	// AWS Signature Version 4 settings (AuthMode sigv4)
+	SigV4Region          string `gorm:"type:varchar(100)" json:"sigv4_region,omitempty"`
+	SigV4Service         string `gorm:"type:varchar(100)" json:"sigv4_service,omitempty"` // signing name; execute-api when empty
+	SigV4AccessKeyID     string `gorm:"type:varchar(255)" json:"sigv4_access_key_id,omitempty"`
+	SigV4SecretAccessKey string `gorm:"type:text" json:"-"` // Encrypted secret access key
+	SigV4SessionToken    string `gorm:"type:text" json:"-"` // Encrypted session token of temporary credentials
+
+	// Google Cloud Healthcare API DICOM store (PACSType google-healthcare)
+	GCPProject           string `gorm:"type:varchar(255)" json:"gcp_project,omitempty"`
+	GCPLocation          string `gorm:"type:varchar(100)" json:"gcp_location,omitempty"`
+	GCPDataset           string `gorm:"type:varchar(255)" json:"gcp_dataset,omitempty"`
+	GCPDICOMStore        string `gorm:"type:varchar(255)" json:"gcp_dicom_store,omitempty"`
+	GCPServiceAccountKey string `gorm:"type:text" json:"-"` // Encrypted service account JSON key
+
+	// S3-compatible object store (PACSType s3); Endpoint and Port address the S3 API
+	S3Bucket          string `gorm:"type:varchar(255)" json:"s3_bucket,omitempty"`
+	S3Region          string `gorm:"type:varchar(100)" json:"s3_region,omitempty"`
+	S3Prefix          string `gorm:"type:varchar(500)" json:"s3_prefix,omitempty"` // key prefix of the study/series/instance layout
+	S3PathStyle       bool   `gorm:"default:false" json:"s3_path_style,omitempty"` // bucket in the path, as MinIO expects
+	S3AccessKeyID     string `gorm:"type:varchar(255)" json:"s3_access_key_id,omitempty"`
+	S3SecretAccessKey string `gorm:"type:text" json:"-"` // Encrypted secret access key
+
+	// XDS-I.b Imaging Document Source (PACSType xds-i); BaseURL is the RAD-69 service URL
+	XDSRepositoryUniqueID string `gorm:"type:varchar(255)" json:"xds_repository_unique_id,omitempty"`
+	XDSHomeCommunityID    string `gorm:"type:varchar(255)" json:"xds_home_community_id,omitempty"`
+
+	// Tenant sent to an upstream connector (PACSType connector); empty sends this config's tenant
+	RemoteTenantID string `gorm:"type:varchar(36)" json:"remote_tenant_id,omitempty"`
+
+	// Member config IDs of a federated PACS in priority order; empty federates
+	// every other active config of the tenant
+	FederatedSources []string `gorm:"type:text[];default:'{}'" json:"federated_sources,omitempty"`
+
+	// Vendor quirk profile, e.g. sectra, ge-centricity or agfa; empty for a standard-conformant PACS
+	Quirks string `gorm:"type:varchar(50)" json:"quirks,omitempty"`
+
+	IsActive      bool       `gorm:"default:true" json:"is_active"`
+	IsPrimary     bool       `gorm:"default:false" json:"is_primary"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"` // when the config was last deactivated or deleted
+
+	// Maximum number of results returned by a study search (0 uses the service default)
+	MaxResults int `gorm:"default:0" json:"max_results,omitempty"`
+
+	// Calls per second the connector makes to the PACS and the burst allowed
+	// above it (0 uses the deployment default, -1 for no limit)
+	RateLimit int `gorm:"default:0" json:"rate_limit,omitempty"`
+	RateBurst int `gorm:"default:0" json:"rate_burst,omitempty"`
+
+	// Lifetimes in seconds of cached instances, metadata, QIDO results and thumbnails
+	// (0 uses the deployment default, -1 disables caching)
+	InstanceCacheTTL  int `gorm:"default:0" json:"instance_cache_ttl,omitempty"`
+	MetadataCacheTTL  int `gorm:"default:0" json:"metadata_cache_ttl,omitempty"`
+	QueryCacheTTL     int `gorm:"default:0" json:"query_cache_ttl,omitempty"`
+	ThumbnailCacheTTL int `gorm:"default:0" json:"thumbnail_cache_ttl,omitempty"`
+
+	// Cache quota of the tenant in MB and entries (0 uses the deployment default, -1 for no limit)
+	CacheQuotaMB      int `gorm:"default:0" json:"cache_quota_mb,omitempty"`
+	CacheQuotaObjects int `gorm:"default:0" json:"cache_quota_objects,omitempty"`
+
+	// Response filtering policy applied to QIDO and metadata responses
+	StripPrivateTags   bool     `gorm:"default:false" json:"strip_private_tags"`
+	RedactedAttributes []string `gorm:"type:text[];default:'{}'" json:"redacted_attributes,omitempty"` // tags (GGGGEEEE) removed from responses
+
+	// TLS settings for upstream DICOMweb connections (PEM encoded)
+	TLSCACert             string `gorm:"type:text" json:"tls_ca_cert,omitempty"`
+	TLSClientCert         string `gorm:"type:text" json:"tls_client_cert,omitempty"`
+	TLSClientKey          string `gorm:"type:text" json:"-"`
+	TLSInsecureSkipVerify bool   `gorm:"default:false" json:"tls_insecure_skip_verify"`
 
 	// Connection status tracking
 	LastConnectionTest   time.Time `gorm:"index" json:"last_connection_test,omitempty"`
 	LastConnectionStatus bool      `json:"last_connection_status,omitempty"`
 	LastError            string    `gorm:"type:text" json:"last_error,omitempty"`
+	LastResponseTime     int64     `gorm:"default:0" json:"last_response_time_ms,omitempty"`
+	ConsecutiveFailures  int       `gorm:"default:0" json:"consecutive_failures"` // failed connection tests in a row
+	Degraded             bool      `gorm:"default:false;index" json:"degraded"`   // failed too many connection tests in a row
+
+	// Calls to the PACS still running when a deactivation or deletion stopped
+	// waiting for them; they finish on their own
+	UnfinishedCalls int `gorm:"-" json:"unfinished_calls,omitempty"`
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitzero"` // set on deleted configs, which can be restored
 }
 
 // TableName overrides the table name
@@ -55,6 +161,21 @@ func (p *PACSConfig) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// Credentials returns the secret fields of the config by field name, to be
+// encrypted before they are stored
+func (p *PACSConfig) Credentials() map[string]*string {
+	return map[string]*string{
+		"PasswordHash":         &p.PasswordHash,
+		"APIKey":               &p.APIKey,
+		"TLSClientKey":         &p.TLSClientKey,
+		"OAuthClientSecret":    &p.OAuthClientSecret,
+		"SigV4SecretAccessKey": &p.SigV4SecretAccessKey,
+		"SigV4SessionToken":    &p.SigV4SessionToken,
+		"GCPServiceAccountKey": &p.GCPServiceAccountKey,
+		"S3SecretAccessKey":    &p.S3SecretAccessKey,
+	}
+}
+
 // ConnectionStatus represents the status of a PACS connection
 type ConnectionStatus struct {
 	IsConnected  bool      `json:"is_connected"`
@@ -64,15 +185,108 @@ type ConnectionStatus struct {
 	Capabilities []string  `json:"capabilities,omitempty"`
 }
 
+// ConnectionTest is the recorded result of one connection test of a PACS config
+type ConnectionTest struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	PACSConfigID uuid.UUID `gorm:"type:uuid;not null;index:idx_connection_tests_config,priority:1" json:"pacs_config_id"`
+	TestedAt     time.Time `gorm:"not null;index:idx_connection_tests_config,priority:2;index" json:"tested_at"`
+	Success      bool      `gorm:"not null" json:"success"`
+	ResponseTime int64     `gorm:"default:0" json:"response_time_ms"`
+	Error        string    `gorm:"type:text" json:"error,omitempty"`
+}
+
+// TableName overrides the table name
+func (ConnectionTest) TableName() string {
+	return "pacs_connection_tests"
+}
+
+// BeforeCreate hook
+func (t *ConnectionTest) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// ConnectionTestHistory reports the connection tests of a PACS config over a
+// period: totals, a trend per interval and the latest tests
+type ConnectionTestHistory struct {
+	PACSConfigID uuid.UUID              `json:"pacs_config_id"`
+	From         time.Time              `json:"from"`
+	To           time.Time              `json:"to"`
+	Summary      ConnectionTestSummary  `json:"summary"`
+	Trend        []ConnectionTestBucket `json:"trend"`
+	Tests        []ConnectionTest       `json:"tests"` // newest first
+}
+
+// ConnectionTestSummary aggregates the connection tests of a period
+type ConnectionTestSummary struct {
+	Tests             int        `json:"tests"`
+	Failures          int        `json:"failures"`
+	SuccessRate       float64    `json:"success_rate"`
+	AvgResponseTimeMs float64    `json:"avg_response_time_ms"`
+	MaxResponseTimeMs int64      `json:"max_response_time_ms"`
+	StatusChanges     int        `json:"status_changes"` // switches between up and down; many of them mean a flapping connection
+	LastFailure       *time.Time `json:"last_failure,omitempty"`
+}
+
+// ConnectionTestBucket aggregates the connection tests of one trend interval
+type ConnectionTestBucket struct {
+	Start             time.Time `json:"start"`
+	Tests             int       `json:"tests"`
+	Failures          int       `json:"failures"`
+	AvgResponseTimeMs float64   `json:"avg_response_time_ms"`
+}
+
 // ConnectionTestRequest represents a request to test PACS connection
 type ConnectionTestRequest struct {
 	Type     PACSType `json:"type" binding:"required"`
 	Endpoint string   `json:"endpoint" binding:"required"`
 	Port     int      `json:"port" binding:"required"`
 	AETitle  string   `json:"ae_title,omitempty"`
+	BaseURL  string   `json:"base_url,omitempty"`
+	BasePath string   `json:"base_path,omitempty"`
 	Username string   `json:"username,omitempty"`
 	Password string   `json:"password,omitempty"`
 	APIKey   string   `json:"api_key,omitempty"`
+
+	TLSCACert             string `json:"tls_ca_cert,omitempty"`
+	TLSClientCert         string `json:"tls_client_cert,omitempty"`
+	TLSClientKey          string `json:"tls_client_key,omitempty"`
+	TLSInsecureSkipVerify bool   `json:"tls_insecure_skip_verify,omitempty"`
+
+	AuthMode          AuthMode `json:"auth_mode,omitempty"`
+	OAuthTokenURL     string   `json:"oauth_token_url,omitempty"`
+	OAuthClientID     string   `json:"oauth_client_id,omitempty"`
+	OAuthClientSecret string   `json:"oauth_client_secret,omitempty"`
+	OAuthScopes       string   `json:"oauth_scopes,omitempty"`
+
+	SigV4Region          string `json:"sigv4_region,omitempty"`
+	SigV4Service         string `json:"sigv4_service,omitempty"`
+	SigV4AccessKeyID     string `json:"sigv4_access_key_id,omitempty"`
+	SigV4SecretAccessKey string `json:"sigv4_secret_access_key,omitempty"`
+	SigV4SessionToken    string `json:"sigv4_session_token,omitempty"`
+
+	GCPProject           string `json:"gcp_project,omitempty"`
+	GCPLocation          string `json:"gcp_location,omitempty"`
+	GCPDataset           string `json:"gcp_dataset,omitempty"`
+	GCPDICOMStore        string `json:"gcp_dicom_store,omitempty"`
+	GCPServiceAccountKey string `json:"gcp_service_account_key,omitempty"` // service account JSON key
+
+	S3Bucket          string `json:"s3_bucket,omitempty"`
+	S3Region          string `json:"s3_region,omitempty"`
+	S3Prefix          string `json:"s3_prefix,omitempty"`
+	S3PathStyle       bool   `json:"s3_path_style,omitempty"`
+	S3AccessKeyID     string `json:"s3_access_key_id,omitempty"`
+	S3SecretAccessKey string `json:"s3_secret_access_key,omitempty"`
+
+	XDSRepositoryUniqueID string `json:"xds_repository_unique_id,omitempty"`
+	XDSHomeCommunityID    string `json:"xds_home_community_id,omitempty"`
+
+	RemoteTenantID string `json:"remote_tenant_id,omitempty"`
+
+	Quirks string `json:"quirks,omitempty"`
 }
 
 // PACSConfigRequest represents a request to create/update PACS config
@@ -82,8 +296,68 @@ type PACSConfigRequest struct {
 	Endpoint  string   `json:"endpoint" binding:"required"`
 	Port      int      `json:"port" binding:"required"`
 	AETitle   string   `json:"ae_title,omitempty"`
+	BaseURL   string   `json:"base_url,omitempty"`
+	BasePath  string   `json:"base_path,omitempty"`
 	Username  string   `json:"username,omitempty"`
 	Password  string   `json:"password,omitempty"`
 	APIKey    string   `json:"api_key,omitempty"`
 	IsPrimary bool     `json:"is_primary"`
+
+	MaxResults int `json:"max_results,omitempty"`
+
+	RateLimit int `json:"rate_limit,omitempty"` // calls per second; -1 for no limit
+	RateBurst int `json:"rate_burst,omitempty"`
+
+	InstanceCacheTTL  int `json:"instance_cache_ttl,omitempty"` // seconds; -1 disables caching
+	MetadataCacheTTL  int `json:"metadata_cache_ttl,omitempty"`
+	QueryCacheTTL     int `json:"query_cache_ttl,omitempty"`
+	ThumbnailCacheTTL int `json:"thumbnail_cache_ttl,omitempty"`
+
+	CacheQuotaMB      int `json:"cache_quota_mb,omitempty"` // -1 for no limit
+	CacheQuotaObjects int `json:"cache_quota_objects,omitempty"`
+
+	StripPrivateTags   bool     `json:"strip_private_tags,omitempty"`
+	RedactedAttributes []string `json:"redacted_attributes,omitempty"` // keywords or GGGGEEEE tags, e.g. InstitutionName
+
+	TLSCACert             string `json:"tls_ca_cert,omitempty"`
+	TLSClientCert         string `json:"tls_client_cert,omitempty"`
+	TLSClientKey          string `json:"tls_client_key,omitempty"`
+	TLSInsecureSkipVerify bool   `json:"tls_insecure_skip_verify,omitempty"`
+
+	AuthMode          AuthMode `json:"auth_mode,omitempty"`
+	OAuthTokenURL     string   `json:"oauth_token_url,omitempty"`
+	OAuthClientID     string   `json:"oauth_client_id,omitempty"`
+	OAuthClientSecret string   `json:"oauth_client_secret,omitempty"`
+	OAuthScopes       string   `json:"oauth_scopes,omitempty"`
+
+	SigV4Region          string `json:"sigv4_region,omitempty"`
+	SigV4Service         string `json:"sigv4_service,omitempty"`
+	SigV4AccessKeyID     string `json:"sigv4_access_key_id,omitempty"`
+	SigV4SecretAccessKey string `json:"sigv4_secret_access_key,omitempty"`
+	SigV4SessionToken    string `json:"sigv4_session_token,omitempty"`
+
+	GCPProject           string `json:"gcp_project,omitempty"`
+	GCPLocation          string `json:"gcp_location,omitempty"`
+	GCPDataset           string `json:"gcp_dataset,omitempty"`
+	GCPDICOMStore        string `json:"gcp_dicom_store,omitempty"`
+	GCPServiceAccountKey string `json:"gcp_service_account_key,omitempty"` // service account JSON key
+
+	S3Bucket          string `json:"s3_bucket,omitempty"`
+	S3Region          string `json:"s3_region,omitempty"`
+	S3Prefix          string `json:"s3_prefix,omitempty"`
+	S3PathStyle       bool   `json:"s3_path_style,omitempty"`
+	S3AccessKeyID     string `json:"s3_access_key_id,omitempty"`
+	S3SecretAccessKey string `json:"s3_secret_access_key,omitempty"`
+
+	XDSRepositoryUniqueID string `json:"xds_repository_unique_id,omitempty"`
+	XDSHomeCommunityID    string `json:"xds_home_community_id,omitempty"`
+
+	RemoteTenantID string `json:"remote_tenant_id,omitempty"`
+
+	Quirks string `json:"quirks,omitempty"`
+
+	FederatedSources []string `json:"federated_sources,omitempty"` // member config IDs of a federated PACS
+
+	// Secrets to remove from the config by request field, e.g. "password"
+	ClearSecrets []string `json:"clear_secrets,omitempty"`
 }
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PinnedStudy is a study kept in the cache for teaching files and tumor boards.
+// Its cached instances are not evicted, and instances missing from the cache
+// are fetched again by the pin refresher.
+type PinnedStudy struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_pinned_studies_tenant_study" json:"tenant_id"`
+	StudyUID  string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_pinned_studies_tenant_study" json:"study_uid"`
+	Reason    string    `gorm:"type:text" json:"reason,omitempty"` // e.g. the teaching file or tumor board it is pinned for
+	CreatedAt time.Time `json:"created_at"`
+
+	// Outcome of the last refresh
+	RefreshedAt      *time.Time `json:"refreshed_at,omitempty"`
+	TotalInstances   int        `gorm:"default:0" json:"total_instances"`
+	FetchedInstances int        `gorm:"default:0" json:"fetched_instances"` // missing from the cache and fetched again
+	Error            string     `gorm:"type:text" json:"error,omitempty"`
+}
+
+// TableName overrides the table name
+func (PinnedStudy) TableName() string {
+	return "pinned_studies"
+}
+
+// BeforeCreate hook
+func (p *PinnedStudy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// PinRequest represents a request to pin a study
+type PinRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Prefetch job states
+const (
+	PrefetchPending   = "pending"
+	PrefetchRunning   = "running"
+	PrefetchCompleted = "completed"
+	PrefetchFailed    = "failed"
+)
+
+// PrefetchJob is a background warm-up of the cache with every instance of
+// one or more studies
+type PrefetchJob struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID        uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	StudyUIDs       []string  `gorm:"type:text[];default:'{}'" json:"study_uids"`
+	AccessionNumber string    `gorm:"type:varchar(255)" json:"accession_number,omitempty"` // when the studies were resolved from one
+	Status          string    `gorm:"type:varchar(20);not null;index" json:"status"`
+	Priority        string    `gorm:"type:varchar(10);not null;default:'routine'" json:"priority"`
+
+	// Progress; the total is known once the studies' instances have been listed
+	TotalInstances     int `gorm:"default:0" json:"total_instances"`
+	ProcessedInstances int `gorm:"default:0" json:"processed_instances"`
+	CachedInstances    int `gorm:"default:0" json:"cached_instances"` // newly added; instances already cached or too large are only processed
+
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (PrefetchJob) TableName() string {
+	return "prefetch_jobs"
+}
+
+// BeforeCreate hook
+func (j *PrefetchJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// PrefetchRequest represents a request to warm the cache with studies, given
+// by their UIDs or an accession number
+type PrefetchRequest struct {
+	StudyUIDs       []string `json:"study_uids,omitempty"`
+	AccessionNumber string   `json:"accession_number,omitempty"`
+	Priority        string   `json:"priority,omitempty"` // stat or routine; routine when empty
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Retrieve job states
+const (
+	RetrieveJobPending   = "pending"
+	RetrieveJobRunning   = "running"
+	RetrieveJobCompleted = "completed"
+	RetrieveJobFailed    = "failed"
+	RetrieveJobCancelled = "cancelled"
+)
+
+// Job priorities of retrieve and prefetch jobs. STAT jobs are always started
+// before routine ones; a job without a priority is routine.
+const (
+	JobPriorityStat    = "stat"
+	JobPriorityRoutine = "routine"
+)
+
+// JobPriorities lists the job priorities, highest first
+var JobPriorities = []string{JobPriorityStat, JobPriorityRoutine}
+
+// RetrieveJob is a long-running retrieval of a study or series from the PACS
+// into the cache. Jobs are queued in the database and claimed by the workers of
+// any connector instance, so they survive restarts.
+type RetrieveJob struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID  uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	StudyUID  string    `gorm:"type:varchar(255);not null" json:"study_uid"`
+	SeriesUID string    `gorm:"type:varchar(255)" json:"series_uid,omitempty"` // empty retrieves the whole study
+	Status    string    `gorm:"type:varchar(20);not null;index" json:"status"`
+	Priority  string    `gorm:"type:varchar(10);not null;default:'routine'" json:"priority"`
+
+	// Progress; the total is known once the instances have been listed
+	TotalInstances     int   `gorm:"default:0" json:"total_instances"`
+	CompletedInstances int   `gorm:"default:0" json:"completed_instances"`
+	FailedInstances    int   `gorm:"default:0" json:"failed_instances"`
+	Bytes              int64 `gorm:"default:0" json:"bytes"` // retrieved from the PACS
+
+	// Instances that could not be retrieved as <series UID>/<SOP instance UID>;
+	// a retry of the job retrieves only these
+	FailedInstanceUIDs []string `gorm:"type:text[];default:'{}'" json:"failed_instance_uids,omitempty"`
+
+	Runs       int        `gorm:"default:0" json:"runs"` // times the job was started, including retries
+	LeaseUntil *time.Time `gorm:"index" json:"-"`        // a running job not renewed by then is taken over by another worker
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (RetrieveJob) TableName() string {
+	return "retrieve_jobs"
+}
+
+// BeforeCreate hook
+func (j *RetrieveJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// RetrieveJobRequest represents a request to retrieve a study, or one of its series
+type RetrieveJobRequest struct {
+	StudyUID  string `json:"study_uid"`
+	SeriesUID string `json:"series_uid,omitempty"`
+	Priority  string `json:"priority,omitempty"` // stat or routine; routine when empty
+}
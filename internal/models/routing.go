@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PACSRoutingRule sends a tenant's requests matching its criteria to one of
+// the tenant's PACS configs instead of the primary. Rules are evaluated in
+// priority order and the first match wins; a criterion left empty matches
+// every request, so a rule without criteria catches all of them.
+type PACSRoutingRule struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	Name         string    `gorm:"type:varchar(255);not null" json:"name"`
+	Priority     int       `gorm:"not null;default:0" json:"priority"` // lower runs first
+	PACSConfigID uuid.UUID `gorm:"type:uuid;not null" json:"pacs_config_id"`
+
+	// Criteria; every one that is set must match
+	Modalities    []string `gorm:"type:text[];default:'{}'" json:"modalities,omitempty"`        // any of the queried modalities
+	StudyDateFrom string   `gorm:"type:varchar(8)" json:"study_date_from,omitempty"`            // DA; queried dates must not start before it
+	StudyDateTo   string   `gorm:"type:varchar(8)" json:"study_date_to,omitempty"`              // DA; queried dates must not end after it
+	Departments   []string `gorm:"type:text[];default:'{}'" json:"departments,omitempty"`       // X-Department of the request
+	AETitles      []string `gorm:"type:text[];default:'{}'" json:"calling_ae_titles,omitempty"` // X-Calling-AE-Title of the request
+
+	IsActive bool `gorm:"default:true" json:"is_active"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName overrides the table name
+func (PACSRoutingRule) TableName() string {
+	return "pacs_routing_rules"
+}
+
+// BeforeCreate hook
+func (r *PACSRoutingRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// RoutingRuleRequest represents a request to create a routing rule
+type RoutingRuleRequest struct {
+	Name          string    `json:"name"`
+	Priority      int       `json:"priority"`
+	PACSConfigID  uuid.UUID `json:"pacs_config_id"`
+	Modalities    []string  `json:"modalities,omitempty"`
+	StudyDateFrom string    `json:"study_date_from,omitempty"` // DICOM or ISO 8601 date
+	StudyDateTo   string    `json:"study_date_to,omitempty"`
+	Departments   []string  `json:"departments,omitempty"`
+	AETitles      []string  `json:"calling_ae_titles,omitempty"`
+}
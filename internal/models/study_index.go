@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StudyIndexEntry is a study of a tenant found by the PACS synchronization, a
+// search or a prefetch, so new studies are noticed without waiting for client
+// queries and searches can be answered without the PACS
+type StudyIndexEntry struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_study_index_study,priority:1;index:idx_study_index_date,priority:1" json:"tenant_id"`
+	PACSConfigID uuid.UUID `gorm:"type:uuid;not null" json:"pacs_config_id"` // the PACS the study was last found on
+
+	StudyInstanceUID   string   `gorm:"type:varchar(64);not null;uniqueIndex:idx_study_index_study,priority:2" json:"study_instance_uid"`
+	AccessionNumber    string   `gorm:"type:varchar(64);index" json:"accession_number,omitempty"`
+	PatientID          string   `gorm:"type:varchar(64);index" json:"patient_id,omitempty"`
+	PatientName        string   `gorm:"type:varchar(255)" json:"patient_name,omitempty"`
+	PatientBirthDate   string   `gorm:"type:varchar(8)" json:"patient_birth_date,omitempty"`
+	PatientSex         string   `gorm:"type:varchar(16)" json:"patient_sex,omitempty"`
+	ReferringPhysician string   `gorm:"type:varchar(255)" json:"referring_physician,omitempty"`
+	StudyDate          string   `gorm:"type:varchar(8);index:idx_study_index_date,priority:2" json:"study_date,omitempty"`
+	StudyTime          string   `gorm:"type:varchar(16)" json:"study_time,omitempty"`
+	StudyDescription   string   `gorm:"type:varchar(255)" json:"study_description,omitempty"`
+	ModalitiesInStudy  []string `gorm:"type:text[];default:'{}'" json:"modalities_in_study,omitempty"`
+	NumberOfSeries     int      `json:"number_of_series"`
+	NumberOfInstances  int      `json:"number_of_instances"`
+
+	FirstSeenAt     time.Time  `gorm:"not null" json:"first_seen_at"`
+	LastSeenAt      time.Time  `gorm:"not null" json:"last_seen_at"`
+	SeriesIndexedAt *time.Time `json:"series_indexed_at,omitempty"` // when the PACS last listed all the study's series
+}
+
+// TableName overrides the table name
+func (StudyIndexEntry) TableName() string {
+	return "study_index"
+}
+
+// BeforeCreate hook
+func (e *StudyIndexEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// SeriesIndexEntry is a series of an indexed study as the PACS last listed it
+type SeriesIndexEntry struct {
+	ID                 uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID           uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_series_index_series,priority:1;index:idx_series_index_study,priority:1" json:"tenant_id"`
+	StudyInstanceUID   string     `gorm:"type:varchar(64);not null;index:idx_series_index_study,priority:2" json:"study_instance_uid"`
+	SeriesInstanceUID  string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_series_index_series,priority:2" json:"series_instance_uid"`
+	Dataset            string     `gorm:"type:jsonb;not null" json:"-"` // the series as returned by searches
+	IndexedAt          time.Time  `gorm:"not null" json:"indexed_at"`
+	InstancesIndexedAt *time.Time `json:"instances_indexed_at,omitempty"` // when the PACS last listed all the series' instances
+}
+
+// TableName overrides the table name
+func (SeriesIndexEntry) TableName() string {
+	return "series_index"
+}
+
+// BeforeCreate hook
+func (e *SeriesIndexEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// InstanceIndexEntry is an instance of an indexed series as the PACS last listed it
+type InstanceIndexEntry struct {
+	ID                uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID          uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_instance_index_instance,priority:1;index:idx_instance_index_series,priority:1" json:"tenant_id"`
+	StudyInstanceUID  string    `gorm:"type:varchar(64);not null" json:"study_instance_uid"`
+	SeriesInstanceUID string    `gorm:"type:varchar(64);not null;index:idx_instance_index_series,priority:2" json:"series_instance_uid"`
+	SOPInstanceUID    string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_instance_index_instance,priority:2" json:"sop_instance_uid"`
+	Dataset           string    `gorm:"type:jsonb;not null" json:"-"` // the instance as returned by searches
+	IndexedAt         time.Time `gorm:"not null" json:"indexed_at"`
+}
+
+// TableName overrides the table name
+func (InstanceIndexEntry) TableName() string {
+	return "instance_index"
+}
+
+// BeforeCreate hook
+func (e *InstanceIndexEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// StudySyncState is the progress of the PACS synchronization of a tenant
+type StudySyncState struct {
+	TenantID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"tenant_id"`
+	StartedAt        *time.Time `json:"started_at,omitempty"`                         // when the last run was claimed by a connector instance
+	SyncedAt         *time.Time `json:"synced_at,omitempty"`                          // when the last successful run started; the next run searches from its day
+	SyncedFrom       string     `gorm:"type:varchar(8)" json:"synced_from,omitempty"` // study dates the last successful run searched, YYYYMMDD
+	SyncedTo         string     `gorm:"type:varchar(8)" json:"synced_to,omitempty"`
+	IndexedFrom      string     `gorm:"type:varchar(8)" json:"indexed_from,omitempty"` // study dates from this day to synced_to are all indexed, YYYYMMDD
+	Studies          int        `gorm:"default:0" json:"studies"`                      // studies found by the last successful run
+	NewStudies       int        `gorm:"default:0" json:"new_studies"`                  // of which were not indexed before
+	TruncatedWindows int        `gorm:"default:0" json:"truncated_windows"`            // searches of the last run cut at the tenant's result cap
+	Error            string     `gorm:"type:text" json:"error,omitempty"`              // why the last run failed
+	Indexed          int64      `gorm:"-" json:"indexed"`                              // studies of the tenant in the index
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (StudySyncState) TableName() string {
+	return "study_sync_states"
+}
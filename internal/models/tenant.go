@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tenant statuses
+const (
+	TenantStatusActive   = "active"
+	TenantStatusDisabled = "disabled"
+)
+
+// Tenant is an organization served by the connector. Requests are only served
+// for tenants that are provisioned and active; a disabled tenant keeps its
+// PACS configs, keys and settings until it is enabled again.
+type Tenant struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name       string     `gorm:"type:varchar(255);not null" json:"name"`
+	Status     string     `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+
+	// Generation of the tenant's cache keys; a new generation on every change of
+	// its PACS configs keeps entries of a previous PACS from being served
+	CacheGeneration int64 `gorm:"not null;default:0" json:"-"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // set on deleted tenants, whose ID is not given out again
+}
+
+// TableName overrides the table name
+func (Tenant) TableName() string {
+	return "tenants"
+}
+
+// BeforeCreate hook
+func (t *Tenant) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// Active reports whether the tenant's requests are served
+func (t *Tenant) Active() bool {
+	return t.Status == TenantStatusActive
+}
+
+// TenantRequest represents a request to provision or rename a tenant
+type TenantRequest struct {
+	ID   *uuid.UUID `json:"id,omitempty"` // on creation, the ID to provision, e.g. one already used by the RIS; generated when left out
+	Name string     `json:"name"`
+}
+
+// TenantKeyRotation carries the API keys issued by rotating every key of a
+// tenant; it is the only time they are shown
+type TenantKeyRotation struct {
+	Keys   []IssuedAPIKey `json:"keys"`
+	Failed []uuid.UUID    `json:"failed_key_ids,omitempty"` // keys that could not be rotated and were left as they were
+}
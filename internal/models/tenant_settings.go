@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantSettings are a tenant's overrides of the deployment configuration. A
+// setting left unset, zero or null, falls back to the deployment default, and
+// a PACS config's own cache lifetimes and result limit take precedence over
+// the tenant's.
+type TenantSettings struct {
+	TenantID uuid.UUID `gorm:"type:uuid;primaryKey" json:"tenant_id"`
+
+	// Lifetimes in seconds of cached instances, metadata, QIDO results and thumbnails
+	// (0 uses the deployment default, -1 disables caching)
+	InstanceCacheTTL  int `gorm:"default:0" json:"instance_cache_ttl,omitempty"`
+	MetadataCacheTTL  int `gorm:"default:0" json:"metadata_cache_ttl,omitempty"`
+	QueryCacheTTL     int `gorm:"default:0" json:"query_cache_ttl,omitempty"`
+	ThumbnailCacheTTL int `gorm:"default:0" json:"thumbnail_cache_ttl,omitempty"`
+
+	// Maximum number of results returned by a study search (0 uses the service default)
+	MaxResults int `gorm:"default:0" json:"max_results,omitempty"`
+
+	// DICOMweb requests per second of the tenant and the burst allowed above it
+	// (0 uses the deployment default, -1 for no limit)
+	RateLimit int `gorm:"default:0" json:"rate_limit,omitempty"`
+	RateBurst int `gorm:"default:0" json:"rate_burst,omitempty"`
+
+	// Modalities the tenant's study and series searches return; empty allows all
+	AllowedModalities []string `gorm:"type:text[];default:'{}'" json:"allowed_modalities,omitempty"`
+
+	// Prior prefetch rules
+	PrefetchPriors       *int  `json:"prefetch_priors,omitempty"`
+	PrefetchLookbackDays *int  `json:"prefetch_lookback_days,omitempty"` // 0 for no limit
+	PrefetchSameModality *bool `json:"prefetch_same_modality,omitempty"`
+	PrefetchSameBodyPart *bool `json:"prefetch_same_body_part,omitempty"`
+
+	// Feature toggles; a feature disabled for the deployment cannot be enabled here
+	FanOutEnabled   *bool `json:"fanout_enabled,omitempty"`   // fanout=true study searches
+	PrefetchEnabled *bool `json:"prefetch_enabled,omitempty"` // prior prefetch for HL7 orders and opened studies
+	FailoverEnabled *bool `json:"failover_enabled,omitempty"` // failover to a secondary PACS
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (TenantSettings) TableName() string {
+	return "tenant_settings"
+}
+
+// TenantSettingsRequest represents a request to replace a tenant's settings;
+// settings left out are reset to the deployment default
+type TenantSettingsRequest struct {
+	InstanceCacheTTL     int      `json:"instance_cache_ttl,omitempty"`
+	MetadataCacheTTL     int      `json:"metadata_cache_ttl,omitempty"`
+	QueryCacheTTL        int      `json:"query_cache_ttl,omitempty"`
+	ThumbnailCacheTTL    int      `json:"thumbnail_cache_ttl,omitempty"`
+	MaxResults           int      `json:"max_results,omitempty"`
+	RateLimit            int      `json:"rate_limit,omitempty"`
+	RateBurst            int      `json:"rate_burst,omitempty"`
+	AllowedModalities    []string `json:"allowed_modalities,omitempty"`
+	PrefetchPriors       *int     `json:"prefetch_priors,omitempty"`
+	PrefetchLookbackDays *int     `json:"prefetch_lookback_days,omitempty"`
+	PrefetchSameModality *bool    `json:"prefetch_same_modality,omitempty"`
+	PrefetchSameBodyPart *bool    `json:"prefetch_same_body_part,omitempty"`
+	FanOutEnabled        *bool    `json:"fanout_enabled,omitempty"`
+	PrefetchEnabled      *bool    `json:"prefetch_enabled,omitempty"`
+	FailoverEnabled      *bool    `json:"failover_enabled,omitempty"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UsageCounts are the metered uses of the connector
+type UsageCounts struct {
+	Queries     int64 `gorm:"not null;default:0" json:"queries"`      // QIDO-RS, FHIR and GraphQL searches
+	Retrievals  int64 `gorm:"not null;default:0" json:"retrievals"`   // WADO-RS requests answered
+	BytesServed int64 `gorm:"not null;default:0" json:"bytes_served"` // response bytes of DICOMweb, FHIR and GraphQL requests
+	CacheHits   int64 `gorm:"not null;default:0" json:"cache_hits"`
+	CacheMisses int64 `gorm:"not null;default:0" json:"cache_misses"`
+}
+
+// Add adds other to the counts
+func (c *UsageCounts) Add(other UsageCounts) {
+	c.Queries += other.Queries
+	c.Retrievals += other.Retrievals
+	c.BytesServed += other.BytesServed
+	c.CacheHits += other.CacheHits
+	c.CacheMisses += other.CacheMisses
+}
+
+// TenantUsage is a tenant's metered usage on one UTC day
+type TenantUsage struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"-"`
+	TenantID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_tenant_usage_day" json:"-"`
+	Day         time.Time `gorm:"type:date;not null;uniqueIndex:idx_tenant_usage_day" json:"-"`
+	UsageCounts `gorm:"embedded"`
+	UpdatedAt   time.Time `json:"-"`
+}
+
+// TableName overrides the table name
+func (TenantUsage) TableName() string {
+	return "tenant_usage"
+}
+
+// BeforeCreate hook
+func (u *TenantUsage) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
+
+// UsageReport is a tenant's metered usage per day over a period of days
+type UsageReport struct {
+	TenantID string      `json:"tenant_id"`
+	From     string      `json:"from"` // first day, YYYY-MM-DD
+	To       string      `json:"to"`   // last day, YYYY-MM-DD
+	Days     []UsageDay  `json:"days"`
+	Total    UsageCounts `json:"total"`
+}
+
+// UsageDay is the metered usage of one day of a usage report
+type UsageDay struct {
+	Day string `json:"day"` // YYYY-MM-DD
+	UsageCounts
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Cache verification states
+const (
+	VerificationPending   = "pending"
+	VerificationRunning   = "running"
+	VerificationCompleted = "completed"
+	VerificationFailed    = "failed"
+)
+
+// Kinds of cache discrepancies
+const (
+	DiscrepancyMissingInstances = "missing_instances" // instances on the PACS missing from a study's cached instances
+	DiscrepancyStaleMetadata    = "stale_metadata"    // cached metadata listing other instances than the PACS
+)
+
+// CacheVerification compares the cache with the PACS for the studies of a
+// tenant's recent days, and optionally repairs the discrepancies found
+type CacheVerification struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	Status       string    `gorm:"type:varchar(20);not null;index" json:"status"`
+	LookbackDays int       `gorm:"not null" json:"lookback_days"` // studies dated in the last days are checked
+	Repair       bool      `gorm:"not null;default:false" json:"repair"`
+	Scheduled    bool      `gorm:"not null;default:false" json:"scheduled"` // started by the connector rather than through the API
+
+	// Progress; the total is known once the PACS listed the studies
+	TotalStudies        int `gorm:"default:0" json:"total_studies"`
+	CheckedStudies      int `gorm:"default:0" json:"checked_studies"`
+	CachedStudies       int `gorm:"default:0" json:"cached_studies"` // checked studies with any instance or metadata cached
+	InconsistentStudies int `gorm:"default:0" json:"inconsistent_studies"`
+	MissingInstances    int `gorm:"default:0" json:"missing_instances"`
+	RefetchedInstances  int `gorm:"default:0" json:"refetched_instances"`
+
+	Discrepancies []CacheDiscrepancy `gorm:"foreignKey:VerificationID;constraint:OnDelete:CASCADE" json:"discrepancies,omitempty"`
+
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (CacheVerification) TableName() string {
+	return "cache_verifications"
+}
+
+// BeforeCreate hook
+func (v *CacheVerification) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+// CacheDiscrepancy is a difference between the cache and the PACS found for a
+// study by a cache verification
+type CacheDiscrepancy struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"-"`
+	VerificationID  uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	StudyUID        string    `gorm:"type:varchar(64);not null" json:"study_uid"`
+	Kind            string    `gorm:"type:varchar(30);not null" json:"kind"`
+	PACSInstances   int       `json:"pacs_instances"`
+	CachedInstances int       `json:"cached_instances"`                                          // instances cached, or listed by the cached metadata for stale_metadata
+	MissingUIDs     []string  `gorm:"type:text[];default:'{}'" json:"missing_uids,omitempty"`    // SOP Instance UIDs on the PACS only; the first 100
+	UnexpectedUIDs  []string  `gorm:"type:text[];default:'{}'" json:"unexpected_uids,omitempty"` // SOP Instance UIDs cached only; the first 100
+	Repaired        bool      `json:"repaired"`
+	Error           string    `gorm:"type:text" json:"error,omitempty"` // why the repair failed
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// TableName overrides the table name
+func (CacheDiscrepancy) TableName() string {
+	return "cache_discrepancies"
+}
+
+// BeforeCreate hook
+func (d *CacheDiscrepancy) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// CacheVerificationRequest represents a request to verify the cache of a tenant
+type CacheVerificationRequest struct {
+	LookbackDays int  `json:"lookback_days,omitempty"` // the deployment default when 0
+	Repair       bool `json:"repair,omitempty"`        // fetch missing instances again and drop stale metadata
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ViewerGrantTokenPrefix marks viewer grant tokens, so they are told apart from
+// other bearer tokens sent to the DICOMweb endpoints
+const ViewerGrantTokenPrefix = "vg_"
+
+// ViewerGrant is a short-lived access grant minted for a viewer from a SMART on
+// FHIR launch. It limits DICOMweb retrieval to one study of one tenant. Only a
+// hash of the token is stored.
+type ViewerGrant struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID  uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	StudyUID  string    `gorm:"type:varchar(255);not null" json:"study_uid"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+
+	// Launch context reported by the SMART authorization server
+	Subject   string `gorm:"type:varchar(255)" json:"subject,omitempty"`
+	PatientID string `gorm:"type:varchar(255)" json:"patient_id,omitempty"` // FHIR Patient in the launch context
+	ClientID  string `gorm:"type:varchar(255)" json:"client_id,omitempty"`
+
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName overrides the table name
+func (ViewerGrant) TableName() string {
+	return "viewer_grants"
+}
+
+// BeforeCreate hook
+func (g *ViewerGrant) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	return nil
+}
+
+// ViewerGrantRequest represents a request to exchange a SMART launch token for a
+// viewer grant; the study is given by its UID or accession number
+type ViewerGrantRequest struct {
+	LaunchToken      string `json:"launch_token"`
+	StudyInstanceUID string `json:"study_instance_uid,omitempty"`
+	AccessionNumber  string `json:"accession_number,omitempty"`
+}
+
+// ViewerGrantResponse carries a minted grant token; it is the only time the token is shown
+type ViewerGrantResponse struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int64     `json:"expires_in"` // seconds
+	ExpiresAt        time.Time `json:"expires_at"`
+	StudyInstanceUID string    `json:"study_instance_uid"`
+	DICOMWebURL      string    `json:"dicomweb_url,omitempty"`
+}
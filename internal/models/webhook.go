@@ -0,0 +1,129 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Event types published to webhooks and the event bus
+const (
+	EventStudyRetrieved       = "study.retrieved"        // a WADO-RS study retrieve completed
+	EventRetrieveJobCompleted = "retrieve_job.completed" // a background prefetch or export job finished
+	EventPACSDown             = "pacs.down"              // the primary PACS failed its health check
+	EventPACSUp               = "pacs.up"                // the primary PACS recovered
+	EventPACSFailover         = "pacs.failover"          // requests moved from the primary PACS to a secondary
+	EventPACSFailback         = "pacs.failback"          // requests returned to the recovered primary PACS
+	EventStudyDiscovered      = "study.discovered"       // the PACS synchronization found a study not indexed before
+
+	// High-volume events, published to the event bus only
+	EventQueryExecuted  = "query.executed"  // a study, series or instance query was answered
+	EventInstanceServed = "instance.served" // an instance was retrieved from cache or the PACS
+	EventAuditRecorded  = "audit.recorded"  // an audit log entry was written
+)
+
+// WebhookEventTypes lists the events a webhook can subscribe to
+var WebhookEventTypes = []string{EventStudyRetrieved, EventRetrieveJobCompleted, EventPACSDown, EventPACSUp, EventPACSFailover, EventPACSFailback, EventStudyDiscovered}
+
+// Webhook delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // retries exhausted; kept for inspection and redelivery
+)
+
+// Webhook is a tenant's subscription to connector events
+type Webhook struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID uuid.UUID `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	URL      string    `gorm:"type:text;not null" json:"url"`
+	Secret   string    `gorm:"type:text;not null" json:"-"`            // HMAC key for payload signatures
+	Events   []string  `gorm:"type:text[];default:'{}'" json:"events"` // empty subscribes to every event
+	IsActive bool      `gorm:"default:true" json:"is_active"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName overrides the table name
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// BeforeCreate hook
+func (w *Webhook) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// Subscribes reports whether the webhook receives an event type
+func (w *Webhook) Subscribes(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, event := range w.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookRequest represents a request to register a webhook
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"` // generated when empty
+	Events []string `json:"events,omitempty"`
+}
+
+// WebhookRegistration is returned once on registration, the only time the secret is shown
+type WebhookRegistration struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookEvent is the signed payload POSTed to webhooks
+type WebhookEvent struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	TenantID  uuid.UUID       `json:"tenant_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookDelivery tracks the delivery of one event to one webhook
+type WebhookDelivery struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	WebhookID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"webhook_id"`
+	EventID        uuid.UUID  `gorm:"type:uuid;not null" json:"event_id"`
+	EventType      string     `gorm:"type:varchar(100);not null" json:"event_type"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `gorm:"index" json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// BeforeCreate hook
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
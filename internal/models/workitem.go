@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProcedureStepState is the UPS state (0074,1000)
+type ProcedureStepState string
+
+const (
+	ProcedureStepScheduled  ProcedureStepState = "SCHEDULED"
+	ProcedureStepInProgress ProcedureStepState = "IN PROGRESS"
+	ProcedureStepCanceled   ProcedureStepState = "CANCELED"
+	ProcedureStepCompleted  ProcedureStepState = "COMPLETED"
+)
+
+// IsFinal reports whether no further changes are allowed in this state
+func (s ProcedureStepState) IsFinal() bool {
+	return s == ProcedureStepCanceled || s == ProcedureStepCompleted
+}
+
+// Workitem is a Unified Procedure Step brokered by the connector. The full DICOM
+// JSON dataset is stored as-is; frequently searched attributes are copied into
+// indexed columns.
+type Workitem struct {
+	ID             uuid.UUID          `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID       uuid.UUID          `gorm:"type:uuid;not null;uniqueIndex:idx_workitems_tenant_uid" json:"tenant_id"`
+	SOPInstanceUID string             `gorm:"type:varchar(64);not null;uniqueIndex:idx_workitems_tenant_uid" json:"sop_instance_uid"`
+	State          ProcedureStepState `gorm:"type:varchar(20);not null;index" json:"state"`
+	TransactionUID string             `gorm:"type:varchar(64)" json:"-"` // Locking UID of the performer that claimed the workitem
+
+	PatientID          string `gorm:"type:varchar(64);index" json:"patient_id,omitempty"`
+	PatientName        string `gorm:"type:varchar(255)" json:"patient_name,omitempty"`
+	AccessionNumber    string `gorm:"type:varchar(64);index" json:"accession_number,omitempty"`
+	StudyInstanceUID   string `gorm:"type:varchar(64);index" json:"study_instance_uid,omitempty"`
+	ProcedureStepLabel string `gorm:"type:varchar(255)" json:"procedure_step_label,omitempty"`
+	WorklistLabel      string `gorm:"type:varchar(255);index" json:"worklist_label,omitempty"`
+	Priority           string `gorm:"type:varchar(10)" json:"priority,omitempty"`
+	ScheduledStart     string `gorm:"type:varchar(26);index" json:"scheduled_start,omitempty"` // DT
+
+	Dataset string `gorm:"type:jsonb;not null" json:"-"` // DICOM JSON
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName overrides the table name
+func (Workitem) TableName() string {
+	return "workitems"
+}
+
+// BeforeCreate hook
+func (w *Workitem) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// WorkitemQuery represents UPS-RS search parameters
+type WorkitemQuery struct {
+	PatientID        string             `json:"patient_id,omitempty"`
+	AccessionNumber  string             `json:"accession_number,omitempty"`
+	StudyInstanceUID string             `json:"study_instance_uid,omitempty"`
+	WorklistLabel    string             `json:"worklist_label,omitempty"`
+	State            ProcedureStepState `json:"state,omitempty"`
+	ScheduledStart   string             `json:"scheduled_start,omitempty"` // DT or DT range (from-to)
+	Limit            int                `json:"limit,omitempty"`
+	Offset           int                `json:"offset,omitempty"`
+}
+
+// DICOMJSONAttribute is a single attribute of a PS3.18 DICOM JSON dataset
+type DICOMJSONAttribute struct {
+	VR           string        `json:"vr"`
+	Value        []interface{} `json:"Value,omitempty"`
+	InlineBinary string        `json:"InlineBinary,omitempty"`
+	BulkDataURI  string        `json:"BulkDataURI,omitempty"`
+}
+
+// DICOMJSONDataset is a PS3.18 DICOM JSON dataset keyed by GGGGEEEE tag
+type DICOMJSONDataset map[string]DICOMJSONAttribute
+
+// String returns the first value of an attribute as a string, using the
+// alphabetic representation for person names
+func (d DICOMJSONDataset) String(tag string) string {
+	attr, ok := d[tag]
+	if !ok || len(attr.Value) == 0 {
+		return ""
+	}
+
+	switch v := attr.Value[0].(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if alphabetic, ok := v["Alphabetic"].(string); ok {
+			return alphabetic
+		}
+	}
+	return ""
+}
+
+// SetString sets a single-valued string attribute
+func (d DICOMJSONDataset) SetString(tag, vr, value string) {
+	d[tag] = DICOMJSONAttribute{VR: vr, Value: []interface{}{value}}
+}
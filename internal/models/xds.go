@@ -0,0 +1,28 @@
+package models
+
+// ImagingDocumentSetRequest selects the instances to pull from an XDS-I.b Imaging
+// Document Source with the RAD-69 Retrieve Imaging Document Set transaction. The
+// instance UIDs are the document unique IDs listed in the study's KOS manifest.
+type ImagingDocumentSetRequest struct {
+	StudyUID           string                 `json:"study_uid"`
+	Series             []ImagingSeriesRequest `json:"series"`
+	RepositoryUniqueID string                 `json:"repository_unique_id,omitempty"` // defaults to the PACS config's repository
+	HomeCommunityID    string                 `json:"home_community_id,omitempty"`    // defaults to the PACS config's community
+	TransferSyntaxes   []string               `json:"transfer_syntaxes,omitempty"`    // acceptable transfer syntaxes in order of preference
+}
+
+// ImagingSeriesRequest lists the instances requested from one series
+type ImagingSeriesRequest struct {
+	SeriesUID    string   `json:"series_uid"`
+	InstanceUIDs []string `json:"instance_uids"`
+}
+
+// ImagingDocument describes a document returned by a RAD-69 retrieve
+type ImagingDocument struct {
+	StudyUID           string
+	SeriesUID          string
+	InstanceUID        string
+	RepositoryUniqueID string
+	HomeCommunityID    string
+	MimeType           string
+}
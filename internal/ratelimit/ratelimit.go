@@ -0,0 +1,76 @@
+// Package ratelimit limits request rates with token buckets, kept in process
+// memory for a single connector instance or in Redis so that every instance
+// draws from the same buckets.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLimited is returned by Wait when no token becomes available in time
+var ErrLimited = errors.New("rate limit exceeded")
+
+// Limit is a token bucket of Burst tokens refilled at Rate tokens per second.
+// A limit without a rate is unlimited.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerSecond returns a limit of rate requests per second with bursts of up to
+// burst requests; burst is raised to one second's worth when it is smaller
+func PerSecond(rate, burst int) Limit {
+	if burst < rate {
+		burst = rate
+	}
+	return Limit{Rate: float64(rate), Burst: burst}
+}
+
+// Unlimited reports whether the limit lets every request through
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// burst is the bucket size, at least one token
+func (l Limit) burst() float64 {
+	if l.Burst < 1 {
+		return 1
+	}
+	return float64(l.Burst)
+}
+
+// Limiter takes tokens from buckets identified by key
+type Limiter interface {
+	// Allow takes a token from the bucket of key. When the bucket is empty it
+	// reports false and how long until the next token is available.
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// Wait takes a token from the bucket of key, waiting for one for up to
+// maxWait. It returns ErrLimited when no token becomes available in time.
+func Wait(ctx context.Context, limiter Limiter, key string, limit Limit, maxWait time.Duration) error {
+	if limit.Unlimited() {
+		return nil
+	}
+
+	deadline := time.Now().Add(maxWait)
+	for {
+		ok, retryAfter, err := limiter.Allow(ctx, key, limit)
+		if err != nil || ok {
+			return err
+		}
+		if time.Now().Add(retryAfter).After(deadline) {
+			return ErrLimited
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
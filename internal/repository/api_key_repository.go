@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// APIKeyRepository handles API key database operations
+type APIKeyRepository struct{}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{}
+}
+
+// Create creates a new API key
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	if err := database.DB.WithContext(ctx).Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an API key of a tenant
+func (r *APIKeyRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := database.DB.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&key).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return &key, nil
+}
+
+// GetValidByID retrieves the API key with an ID that is neither revoked nor
+// expired at now
+func (r *APIKeyRepository) GetValidByID(ctx context.Context, id uuid.UUID, now time.Time) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := database.DB.WithContext(ctx).
+		Where("id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", id, now).
+		First(&key).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return &key, nil
+}
+
+// GetByTenantID retrieves the API keys of a tenant, revoked ones included, newest first
+func (r *APIKeyRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// GetValidByHash retrieves the API key with a hash that is neither revoked nor
+// expired at now
+func (r *APIKeyRepository) GetValidByHash(ctx context.Context, keyHash string, now time.Time) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := database.DB.WithContext(ctx).
+		Where("key_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", keyHash, now).
+		First(&key).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return &key, nil
+}
+
+// Rotate creates the replacement of an API key and has the old one expire at
+// retireAt, or revokes it when retireAt is nil
+func (r *APIKeyRepository) Rotate(ctx context.Context, old, replacement *models.APIKey, retireAt *time.Time) error {
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"revoked_at": time.Now()}
+		if retireAt != nil {
+			updates = map[string]interface{}{"expires_at": *retireAt}
+		}
+		if err := tx.Model(old).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(replacement).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rotate API key: %w", err)
+	}
+	return nil
+}
+
+// Revoke revokes an API key
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, now time.Time) error {
+	if err := database.DB.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", now).Error; err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return nil
+}
+
+// Touch records that an API key was used
+func (r *APIKeyRepository) Touch(ctx context.Context, id uuid.UUID, now time.Time) error {
+	if err := database.DB.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", now).Error; err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+	return nil
+}
+
+// UseNonce records the nonce of a signed request until expiresAt; fresh is
+// false when the key's nonce was recorded before
+func (r *APIKeyRepository) UseNonce(ctx context.Context, keyID uuid.UUID, nonce string, expiresAt time.Time) (fresh bool, err error) {
+	result := database.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.APIKeyNonce{KeyID: keyID, Nonce: nonce, ExpiresAt: expiresAt})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record request nonce: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// PruneNonces removes the nonces that expired before a time
+func (r *APIKeyRepository) PruneNonces(ctx context.Context, before time.Time) (int64, error) {
+	result := database.DB.WithContext(ctx).
+		Where("expires_at < ?", before).
+		Delete(&models.APIKeyNonce{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune request nonces: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -2,11 +2,16 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/otcheredev/ris-dicom-connector/internal/database"
 	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // AuditRepository handles audit log database operations
@@ -17,11 +22,16 @@ func NewAuditRepository() *AuditRepository {
 	return &AuditRepository{}
 }
 
-// Create creates a new audit log entry
+// Create creates a new audit log entry, appending it to its tenant's chain
 func (r *AuditRepository) Create(ctx context.Context, log *models.AuditLog) error {
-	if err := database.DB.WithContext(ctx).Create(log).Error; err != nil {
+	logs := []models.AuditLog{*log}
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return appendToChains(tx, logs)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to create audit log: %w", err)
 	}
+	*log = logs[0]
 	return nil
 }
 
@@ -57,3 +67,143 @@ func (r *AuditRepository) GetByResourceUID(ctx context.Context, tenantID uuid.UU
 	}
 	return logs, nil
 }
+
+// CreateBatch stores audit log entries in one statement, appending each to its
+// tenant's chain in their order
+func (r *AuditRepository) CreateBatch(ctx context.Context, logs []models.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return appendToChains(tx, logs)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create audit logs: %w", err)
+	}
+	return nil
+}
+
+// appendToChains chains entries after the heads of their tenants' chains and
+// inserts them. The heads stay locked until the transaction ends; they are
+// locked in tenant order so concurrent batches cannot deadlock.
+func appendToChains(tx *gorm.DB, logs []models.AuditLog) error {
+	byTenant := make(map[uuid.UUID][]int)
+	var tenants []uuid.UUID
+	for i := range logs {
+		tenantID := logs[i].TenantID
+		if _, ok := byTenant[tenantID]; !ok {
+			tenants = append(tenants, tenantID)
+		}
+		byTenant[tenantID] = append(byTenant[tenantID], i)
+	}
+	slices.SortFunc(tenants, func(a, b uuid.UUID) int {
+		return slices.Compare(a[:], b[:])
+	})
+
+	now := time.Now()
+	for _, tenantID := range tenants {
+		head := models.AuditChainHead{TenantID: tenantID, UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&head).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("tenant_id = ?", tenantID).
+			First(&head).Error; err != nil {
+			return err
+		}
+
+		for _, i := range byTenant[tenantID] {
+			entry := &logs[i]
+			if entry.ID == uuid.Nil {
+				entry.ID = uuid.New()
+			}
+			if entry.CreatedAt.IsZero() {
+				entry.CreatedAt = now
+			}
+			// Stored at microsecond precision, so hashed as it reads back
+			entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+			head.Sequence++
+			entry.Sequence = head.Sequence
+			entry.PrevHash = head.Hash
+			entry.Hash = entry.ChainHash()
+			head.Hash = entry.Hash
+		}
+
+		if err := tx.Model(&models.AuditChainHead{}).
+			Where("tenant_id = ?", tenantID).
+			Updates(map[string]interface{}{"sequence": head.Sequence, "hash": head.Hash, "updated_at": now}).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Create(&logs).Error
+}
+
+// GetChainHead returns the head of a tenant's audit chain, or nil when the
+// tenant has no chained entries
+func (r *AuditRepository) GetChainHead(ctx context.Context, tenantID uuid.UUID) (*models.AuditChainHead, error) {
+	var head models.AuditChainHead
+	err := database.DB.WithContext(ctx).Where("tenant_id = ?", tenantID).First(&head).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+	return &head, nil
+}
+
+// GetChainRange returns up to limit of a tenant's chained entries after a
+// sequence and up to another, in chain order
+func (r *AuditRepository) GetChainRange(ctx context.Context, tenantID uuid.UUID, after, upTo int64, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND sequence > ? AND sequence <= ?", tenantID, after, upTo).
+		Order("sequence").
+		Limit(limit).
+		Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get audit chain: %w", err)
+	}
+	return logs, nil
+}
+
+// DeleteBefore removes audit log entries recorded before a time. A tenant's
+// chained entries are removed from the start of its chain up to the last one
+// recorded before then, whose sequence and hash the chain head keeps, so
+// verification can tell entries the retention removed from tampering.
+func (r *AuditRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var last []models.AuditLog
+		if err := tx.Raw(`SELECT DISTINCT ON (tenant_id) tenant_id, sequence, hash FROM audit_logs
+			WHERE created_at < ? AND sequence > 0 ORDER BY tenant_id, sequence DESC`, before).
+			Scan(&last).Error; err != nil {
+			return err
+		}
+
+		for _, entry := range last {
+			if err := tx.Model(&models.AuditChainHead{}).
+				Where("tenant_id = ? AND pruned_sequence < ?", entry.TenantID, entry.Sequence).
+				Updates(map[string]interface{}{"pruned_sequence": entry.Sequence, "pruned_hash": entry.Hash}).Error; err != nil {
+				return err
+			}
+			result := tx.Where("tenant_id = ? AND sequence > 0 AND sequence <= ?", entry.TenantID, entry.Sequence).
+				Delete(&models.AuditLog{})
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted += result.RowsAffected
+		}
+
+		// Entries recorded before chaining
+		result := tx.Where("created_at < ? AND COALESCE(sequence, 0) = 0", before).Delete(&models.AuditLog{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit logs: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// CacheMetricsRepository handles cache metrics database operations
+type CacheMetricsRepository struct{}
+
+// NewCacheMetricsRepository creates a new cache metrics repository
+func NewCacheMetricsRepository() *CacheMetricsRepository {
+	return &CacheMetricsRepository{}
+}
+
+// CreateBatch stores cache metrics records in one statement
+func (r *CacheMetricsRepository) CreateBatch(ctx context.Context, records []models.CacheMetrics) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := database.DB.WithContext(ctx).Create(&records).Error; err != nil {
+		return fmt.Errorf("failed to create cache metrics: %w", err)
+	}
+	return nil
+}
+
+// AggregateByTier summarizes a tenant's cache metrics recorded in [from, to) per tier
+func (r *CacheMetricsRepository) AggregateByTier(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.CacheTierMetrics, error) {
+	var tiers []models.CacheTierMetrics
+	if err := database.DB.WithContext(ctx).
+		Model(&models.CacheMetrics{}).
+		Select("cache_tier AS tier, COUNT(*) AS reads, "+
+			"COUNT(*) FILTER (WHERE cache_hit) AS hits, "+
+			"COALESCE(SUM(size), 0) AS bytes_served, "+
+			"COALESCE(AVG(duration), 0) AS avg_duration_ms, "+
+			"COALESCE(MAX(duration), 0) AS max_duration_ms").
+		Where("tenant_id = ? AND created_at >= ? AND created_at < ?", tenantID, from, to).
+		Group("cache_tier").
+		Order("cache_tier").
+		Scan(&tiers).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate cache metrics: %w", err)
+	}
+	return tiers, nil
+}
+
+// DeleteBefore removes cache metrics recorded before a time
+func (r *CacheMetricsRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := database.DB.WithContext(ctx).
+		Where("created_at < ?", before).
+		Delete(&models.CacheMetrics{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete cache metrics: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// ExportRepository handles export job database operations
+type ExportRepository struct{}
+
+// NewExportRepository creates a new export repository
+func NewExportRepository() *ExportRepository {
+	return &ExportRepository{}
+}
+
+// Create creates a new export job
+func (r *ExportRepository) Create(ctx context.Context, job *models.ExportJob) error {
+	if err := database.DB.WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create export job: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an export job by ID
+func (r *ExportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ExportJob, error) {
+	var job models.ExportJob
+	if err := database.DB.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+	return &job, nil
+}
+
+// Update saves an export job
+func (r *ExportRepository) Update(ctx context.Context, job *models.ExportJob) error {
+	if err := database.DB.WithContext(ctx).Save(job).Error; err != nil {
+		return fmt.Errorf("failed to update export job: %w", err)
+	}
+	return nil
+}
+
+// FailUnfinished marks the given jobs failed unless they have finished
+func (r *ExportRepository) FailUnfinished(ctx context.Context, ids []uuid.UUID, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := database.DB.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("id IN ? AND status IN ?", ids, []string{models.ExportPending, models.ExportRunning}).
+		Updates(map[string]interface{}{"status": models.ExportFailed, "error": reason}).Error; err != nil {
+		return fmt.Errorf("failed to update export jobs: %w", err)
+	}
+	return nil
+}
+
+// GetExpired retrieves completed jobs whose download link expired before now
+func (r *ExportRepository) GetExpired(ctx context.Context, now time.Time) ([]models.ExportJob, error) {
+	var jobs []models.ExportJob
+	if err := database.DB.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", models.ExportCompleted, now).
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expired export jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// CreateMetadataExport creates a new metadata export job
+func (r *ExportRepository) CreateMetadataExport(ctx context.Context, job *models.MetadataExportJob) error {
+	if err := database.DB.WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create metadata export job: %w", err)
+	}
+	return nil
+}
+
+// GetMetadataExportByID retrieves a metadata export job by ID
+func (r *ExportRepository) GetMetadataExportByID(ctx context.Context, id uuid.UUID) (*models.MetadataExportJob, error) {
+	var job models.MetadataExportJob
+	if err := database.DB.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to get metadata export job: %w", err)
+	}
+	return &job, nil
+}
+
+// UpdateMetadataExport saves a metadata export job
+func (r *ExportRepository) UpdateMetadataExport(ctx context.Context, job *models.MetadataExportJob) error {
+	if err := database.DB.WithContext(ctx).Save(job).Error; err != nil {
+		return fmt.Errorf("failed to update metadata export job: %w", err)
+	}
+	return nil
+}
+
+// FailUnfinishedMetadataExports marks the given metadata export jobs failed unless they have finished
+func (r *ExportRepository) FailUnfinishedMetadataExports(ctx context.Context, ids []uuid.UUID, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := database.DB.WithContext(ctx).
+		Model(&models.MetadataExportJob{}).
+		Where("id IN ? AND status IN ?", ids, []string{models.ExportPending, models.ExportRunning}).
+		Updates(map[string]interface{}{"status": models.ExportFailed, "error": reason}).Error; err != nil {
+		return fmt.Errorf("failed to update metadata export jobs: %w", err)
+	}
+	return nil
+}
+
+// GetExpiredMetadataExports retrieves completed metadata export jobs whose download link expired before now
+func (r *ExportRepository) GetExpiredMetadataExports(ctx context.Context, now time.Time) ([]models.MetadataExportJob, error) {
+	var jobs []models.MetadataExportJob
+	if err := database.DB.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", models.ExportCompleted, now).
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expired metadata export jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// CreateDestination creates a new export destination
+func (r *ExportRepository) CreateDestination(ctx context.Context, destination *models.ExportDestination) error {
+	if err := database.DB.WithContext(ctx).Create(destination).Error; err != nil {
+		return fmt.Errorf("failed to create export destination: %w", err)
+	}
+	return nil
+}
+
+// GetDestination retrieves an export destination of a tenant by ID
+func (r *ExportRepository) GetDestination(ctx context.Context, tenantID, id uuid.UUID) (*models.ExportDestination, error) {
+	var destination models.ExportDestination
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		First(&destination).Error; err != nil {
+		return nil, fmt.Errorf("failed to get export destination: %w", err)
+	}
+	return &destination, nil
+}
+
+// GetDestinations retrieves all export destinations of a tenant
+func (r *ExportRepository) GetDestinations(ctx context.Context, tenantID uuid.UUID) ([]models.ExportDestination, error) {
+	var destinations []models.ExportDestination
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at ASC").
+		Find(&destinations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get export destinations: %w", err)
+	}
+	return destinations, nil
+}
+
+// DeleteDestination soft deletes an export destination of a tenant
+func (r *ExportRepository) DeleteDestination(ctx context.Context, tenantID, id uuid.UUID) (bool, error) {
+	result := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Delete(&models.ExportDestination{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete export destination: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
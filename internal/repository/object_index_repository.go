@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ObjectIndexColumns maps the QIDO matching attributes (GGGGEEEE) answered from
+// the object index to their columns
+var ObjectIndexColumns = map[string]string{
+	"00100020": "patient_id",
+	"00100010": "patient_name",
+	"00100030": "patient_birth_date",
+	"00100040": "patient_sex",
+	"00080020": "study_date",
+	"00080030": "study_time",
+	"00081030": "study_description",
+	"00080050": "accession_number",
+	"00080090": "referring_physician",
+	"0020000D": "study_instance_uid",
+}
+
+// ObjectIndexRepository handles the object store instance index
+type ObjectIndexRepository struct{}
+
+// NewObjectIndexRepository creates a new object index repository
+func NewObjectIndexRepository() *ObjectIndexRepository {
+	return &ObjectIndexRepository{}
+}
+
+// Upsert creates or replaces the index entry of an instance
+func (r *ObjectIndexRepository) Upsert(ctx context.Context, entry *models.ObjectIndexEntry) error {
+	if err := database.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "pacs_config_id"}, {Name: "sop_instance_uid"}},
+			DoUpdates: clause.AssignmentColumns(objectIndexUpdateColumns),
+		}).
+		Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to index instance: %w", err)
+	}
+	return nil
+}
+
+// objectIndexUpdateColumns are replaced when an already indexed instance is re-indexed
+var objectIndexUpdateColumns = []string{
+	"study_instance_uid", "series_instance_uid", "sop_class_uid",
+	"patient_id", "patient_name", "patient_birth_date", "patient_sex",
+	"study_date", "study_time", "study_description", "accession_number", "referring_physician",
+	"modality", "series_number", "series_description", "series_date", "series_time", "body_part_examined",
+	"instance_number", "transfer_syntax_uid", "rows", "columns", "bits_allocated", "bits_stored",
+	"high_bit", "pixel_representation", "photometric_interpretation", "samples_per_pixel", "number_of_frames",
+	"object_key", "object_size", "object_etag", "updated_at",
+}
+
+// ObjectETags returns the ETag of every indexed object of a PACS config, keyed by object key
+func (r *ObjectIndexRepository) ObjectETags(ctx context.Context, configID uuid.UUID) (map[string]string, error) {
+	var rows []struct {
+		ObjectKey  string
+		ObjectETag string
+	}
+	if err := database.DB.WithContext(ctx).
+		Model(&models.ObjectIndexEntry{}).
+		Select("object_key, object_etag").
+		Where("pacs_config_id = ?", configID).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list indexed objects: %w", err)
+	}
+
+	etags := make(map[string]string, len(rows))
+	for _, row := range rows {
+		etags[row.ObjectKey] = row.ObjectETag
+	}
+	return etags, nil
+}
+
+// studyRow is a study aggregated from its instances
+type studyRow struct {
+	StudyInstanceUID   string
+	PatientID          string
+	PatientName        string
+	PatientBirthDate   string
+	PatientSex         string
+	StudyDate          string
+	StudyTime          string
+	StudyDescription   string
+	AccessionNumber    string
+	ReferringPhysician string
+	NumberOfSeries     int
+	NumberOfInstances  int
+	Modalities         string
+}
+
+// FindStudies matches studies against a QIDO query. Filters must be keyed by
+// attributes listed in ObjectIndexColumns.
+func (r *ObjectIndexRepository) FindStudies(ctx context.Context, configID uuid.UUID, params models.QueryParams) ([]models.Study, error) {
+	query := database.DB.WithContext(ctx).
+		Model(&models.ObjectIndexEntry{}).
+		Select(`study_instance_uid,
+			MAX(patient_id) AS patient_id, MAX(patient_name) AS patient_name,
+			MAX(patient_birth_date) AS patient_birth_date, MAX(patient_sex) AS patient_sex,
+			MAX(study_date) AS study_date, MAX(study_time) AS study_time,
+			MAX(study_description) AS study_description, MAX(accession_number) AS accession_number,
+			MAX(referring_physician) AS referring_physician,
+			COUNT(DISTINCT series_instance_uid) AS number_of_series, COUNT(*) AS number_of_instances,
+			STRING_AGG(DISTINCT modality, '\') AS modalities`).
+		Where("pacs_config_id = ?", configID).
+		Group("study_instance_uid").
+		Order("MAX(study_date) DESC, MAX(study_time) DESC, study_instance_uid")
+
+	query = matchValue(query, "patient_id", params.PatientID)
+	query = matchValue(query, "patient_name", params.PatientName)
+	query = matchRange(query, "study_date", params.StudyDate)
+	query = matchRange(query, "study_time", params.StudyTime)
+	query = matchValue(query, "accession_number", params.AccessionNumber)
+	query = matchValue(query, "study_description", params.StudyDescription)
+	for tag, value := range params.Filters {
+		column, ok := ObjectIndexColumns[strings.ToUpper(tag)]
+		if !ok {
+			return nil, fmt.Errorf("attribute %s is not indexed", tag)
+		}
+		if column == "study_date" || column == "study_time" || column == "patient_birth_date" {
+			query = matchRange(query, column, value)
+		} else {
+			query = matchValue(query, column, value)
+		}
+	}
+	if len(params.Modalities) > 0 {
+		query = query.Where("study_instance_uid IN (?)", database.DB.
+			Model(&models.ObjectIndexEntry{}).
+			Select("study_instance_uid").
+			Where("pacs_config_id = ? AND modality IN ?", configID, params.Modalities))
+	}
+
+	if params.Limit > 0 {
+		query = query.Limit(params.Limit)
+	}
+	if params.Offset > 0 {
+		query = query.Offset(params.Offset)
+	}
+
+	var rows []studyRow
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to search studies: %w", err)
+	}
+
+	studies := make([]models.Study, 0, len(rows))
+	for _, row := range rows {
+		study := models.Study{
+			StudyInstanceUID:   row.StudyInstanceUID,
+			PatientID:          row.PatientID,
+			PatientName:        row.PatientName,
+			PatientBirthDate:   row.PatientBirthDate,
+			PatientSex:         row.PatientSex,
+			StudyDate:          row.StudyDate,
+			StudyTime:          row.StudyTime,
+			StudyDescription:   row.StudyDescription,
+			AccessionNumber:    row.AccessionNumber,
+			ReferringPhysician: row.ReferringPhysician,
+			NumberOfSeries:     row.NumberOfSeries,
+			NumberOfInstances:  row.NumberOfInstances,
+		}
+		if row.Modalities != "" {
+			study.ModalitiesInStudy = strings.Split(row.Modalities, `\`)
+		}
+		studies = append(studies, study)
+	}
+
+	return studies, nil
+}
+
+// FindSeries returns the series of a study aggregated from their instances
+func (r *ObjectIndexRepository) FindSeries(ctx context.Context, configID uuid.UUID, studyUID string) ([]models.Series, error) {
+	var series []models.Series
+	if err := database.DB.WithContext(ctx).
+		Model(&models.ObjectIndexEntry{}).
+		Select(`series_instance_uid, MAX(series_number) AS series_number, MAX(modality) AS modality,
+			MAX(series_description) AS series_description, MAX(series_date) AS series_date,
+			MAX(series_time) AS series_time, MAX(body_part_examined) AS body_part_examined,
+			COUNT(*) AS number_of_instances`).
+		Where("pacs_config_id = ? AND study_instance_uid = ?", configID, studyUID).
+		Group("series_instance_uid").
+		Order("MAX(series_number), series_instance_uid").
+		Scan(&series).Error; err != nil {
+		return nil, fmt.Errorf("failed to search series: %w", err)
+	}
+	return series, nil
+}
+
+// FindInstances returns the index entries of a series ordered by instance number
+func (r *ObjectIndexRepository) FindInstances(ctx context.Context, configID uuid.UUID, studyUID, seriesUID string) ([]models.ObjectIndexEntry, error) {
+	var entries []models.ObjectIndexEntry
+	if err := database.DB.WithContext(ctx).
+		Where("pacs_config_id = ? AND study_instance_uid = ? AND series_instance_uid = ?", configID, studyUID, seriesUID).
+		Order("instance_number, sop_instance_uid").
+		Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to search instances: %w", err)
+	}
+	return entries, nil
+}
+
+// FindStudyInstances returns the index entries of a study ordered by series and instance number
+func (r *ObjectIndexRepository) FindStudyInstances(ctx context.Context, configID uuid.UUID, studyUID string) ([]models.ObjectIndexEntry, error) {
+	var entries []models.ObjectIndexEntry
+	if err := database.DB.WithContext(ctx).
+		Where("pacs_config_id = ? AND study_instance_uid = ?", configID, studyUID).
+		Order("series_number, series_instance_uid, instance_number, sop_instance_uid").
+		Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to search instances: %w", err)
+	}
+	return entries, nil
+}
+
+// GetInstance retrieves the index entry of an instance
+func (r *ObjectIndexRepository) GetInstance(ctx context.Context, configID uuid.UUID, studyUID, seriesUID, instanceUID string) (*models.ObjectIndexEntry, error) {
+	var entry models.ObjectIndexEntry
+	if err := database.DB.WithContext(ctx).
+		Where("pacs_config_id = ? AND study_instance_uid = ? AND series_instance_uid = ? AND sop_instance_uid = ?",
+			configID, studyUID, seriesUID, instanceUID).
+		First(&entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to get indexed instance: %w", err)
+	}
+	return &entry, nil
+}
+
+// CountObjects counts the indexed instances under a study, series or instance
+func (r *ObjectIndexRepository) CountObjects(ctx context.Context, configID uuid.UUID, studyUID, seriesUID, instanceUID string) (int64, error) {
+	query := database.DB.WithContext(ctx).
+		Model(&models.ObjectIndexEntry{}).
+		Where("pacs_config_id = ? AND study_instance_uid = ?", configID, studyUID)
+	if seriesUID != "" {
+		query = query.Where("series_instance_uid = ?", seriesUID)
+	}
+	if instanceUID != "" {
+		query = query.Where("sop_instance_uid = ?", instanceUID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count indexed instances: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteStudy removes the index entries of a study
+func (r *ObjectIndexRepository) DeleteStudy(ctx context.Context, configID uuid.UUID, studyUID string) error {
+	if err := database.DB.WithContext(ctx).
+		Where("pacs_config_id = ? AND study_instance_uid = ?", configID, studyUID).
+		Delete(&models.ObjectIndexEntry{}).Error; err != nil {
+		return fmt.Errorf("failed to delete indexed study: %w", err)
+	}
+	return nil
+}
+
+// matchValue adds single value matching on a column, translating DICOM
+// wildcards (* and ?) to a case-insensitive LIKE
+func matchValue(query *gorm.DB, column, value string) *gorm.DB {
+	if value == "" || value == "*" {
+		return query
+	}
+	if !strings.ContainsAny(value, "*?") {
+		return query.Where(column+" = ?", value)
+	}
+
+	pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`, "*", "%", "?", "_").Replace(value)
+	return query.Where(column+" ILIKE ?", pattern)
+}
+
+// matchRange adds DICOM range matching ("from-to", "from-", "-to") on a date or time column
+func matchRange(query *gorm.DB, column, value string) *gorm.DB {
+	if value == "" {
+		return query
+	}
+
+	from, to, isRange := strings.Cut(value, "-")
+	if !isRange {
+		return query.Where(column+" = ?", value)
+	}
+	if from != "" {
+		query = query.Where(column+" >= ?", from)
+	}
+	if to != "" {
+		query = query.Where(column+" <= ?", to)
+	}
+	return query
+}
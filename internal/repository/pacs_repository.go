@@ -3,10 +3,13 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/otcheredev/ris-dicom-connector/internal/database"
 	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PACSRepository handles PACS configuration database operations
@@ -46,6 +49,61 @@ func (r *PACSRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID)
 	return configs, nil
 }
 
+// GetHistoryByTenantID retrieves every PACS configuration of a tenant,
+// including inactive and deleted ones
+func (r *PACSRepository) GetHistoryByTenantID(ctx context.Context, tenantID uuid.UUID) ([]models.PACSConfig, error) {
+	var configs []models.PACSConfig
+	if err := database.DB.WithContext(ctx).
+		Unscoped().
+		Where("tenant_id = ?", tenantID).
+		Order("is_primary DESC, created_at ASC").
+		Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get PACS configs: %w", err)
+	}
+	return configs, nil
+}
+
+// GetAllWithDeleted retrieves the PACS configurations of every tenant,
+// including inactive and deleted ones
+func (r *PACSRepository) GetAllWithDeleted(ctx context.Context) ([]models.PACSConfig, error) {
+	var configs []models.PACSConfig
+	if err := database.DB.WithContext(ctx).
+		Unscoped().
+		Order("tenant_id ASC, created_at ASC").
+		Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get PACS configs: %w", err)
+	}
+	return configs, nil
+}
+
+// UpdateCredentials stores the credentials of a PACS configuration unless it
+// was updated since it was read, and reports whether they were stored
+func (r *PACSRepository) UpdateCredentials(ctx context.Context, config *models.PACSConfig) (bool, error) {
+	fields := make([]string, 0, len(config.Credentials()))
+	for field := range config.Credentials() {
+		fields = append(fields, field)
+	}
+	result := database.DB.WithContext(ctx).
+		Unscoped().
+		Model(config).
+		Where("updated_at = ?", config.UpdatedAt).
+		Select(fields).
+		UpdateColumns(config)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update PACS credentials: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetByIDWithDeleted retrieves a PACS configuration by ID, deleted or not
+func (r *PACSRepository) GetByIDWithDeleted(ctx context.Context, id uuid.UUID) (*models.PACSConfig, error) {
+	var config models.PACSConfig
+	if err := database.DB.WithContext(ctx).Unscoped().Where("id = ?", id).First(&config).Error; err != nil {
+		return nil, fmt.Errorf("failed to get PACS config: %w", err)
+	}
+	return &config, nil
+}
+
 // GetPrimaryByTenantID retrieves the primary PACS configuration for a tenant
 func (r *PACSRepository) GetPrimaryByTenantID(ctx context.Context, tenantID uuid.UUID) (*models.PACSConfig, error) {
 	var config models.PACSConfig
@@ -57,6 +115,18 @@ func (r *PACSRepository) GetPrimaryByTenantID(ctx context.Context, tenantID uuid
 	return &config, nil
 }
 
+// GetActivePrimaries retrieves the active primary PACS configuration of every tenant
+func (r *PACSRepository) GetActivePrimaries(ctx context.Context) ([]models.PACSConfig, error) {
+	var configs []models.PACSConfig
+	if err := database.DB.WithContext(ctx).
+		Where("is_primary = ? AND is_active = ?", true, true).
+		Order("tenant_id ASC").
+		Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get primary PACS configs: %w", err)
+	}
+	return configs, nil
+}
+
 // Update updates a PACS configuration
 func (r *PACSRepository) Update(ctx context.Context, config *models.PACSConfig) error {
 	if err := database.DB.WithContext(ctx).Save(config).Error; err != nil {
@@ -73,6 +143,33 @@ func (r *PACSRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// SetActive activates or deactivates a PACS configuration, recording when it was deactivated
+func (r *PACSRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	updates := map[string]interface{}{"is_active": active}
+	if !active {
+		updates["deactivated_at"] = time.Now()
+	}
+	if err := database.DB.WithContext(ctx).
+		Model(&models.PACSConfig{}).
+		Where("id = ?", id).
+		Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update PACS config: %w", err)
+	}
+	return nil
+}
+
+// Restore undeletes a PACS configuration, inactive and not primary
+func (r *PACSRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	if err := database.DB.WithContext(ctx).
+		Unscoped().
+		Model(&models.PACSConfig{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "is_active": false, "is_primary": false}).Error; err != nil {
+		return fmt.Errorf("failed to restore PACS config: %w", err)
+	}
+	return nil
+}
+
 // SetPrimary sets a PACS configuration as primary (and unsets others)
 func (r *PACSRepository) SetPrimary(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) error {
 	// Start transaction
@@ -102,20 +199,86 @@ func (r *PACSRepository) SetPrimary(ctx context.Context, id uuid.UUID, tenantID
 	return tx.Commit().Error
 }
 
-// UpdateConnectionStatus updates the connection status of a PACS configuration
-func (r *PACSRepository) UpdateConnectionStatus(ctx context.Context, id uuid.UUID, status *models.ConnectionStatus) error {
+// GetAllActive retrieves the active PACS configurations of every tenant
+func (r *PACSRepository) GetAllActive(ctx context.Context) ([]models.PACSConfig, error) {
+	var configs []models.PACSConfig
+	if err := database.DB.WithContext(ctx).
+		Where("is_active = ?", true).
+		Order("tenant_id ASC, is_primary DESC, created_at ASC").
+		Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get PACS configs: %w", err)
+	}
+	return configs, nil
+}
+
+// UpdateConnectionStatus records a connection test of a PACS configuration, on
+// the configuration and in its test history. A failed test extends its run of
+// consecutive failures and one that succeeds ends it; the configuration is
+// degraded while the run is at least degradeAfter long (0 never degrades it).
+// It returns the updated configuration.
+func (r *PACSRepository) UpdateConnectionStatus(ctx context.Context, id uuid.UUID, status *models.ConnectionStatus, degradeAfter int) (*models.PACSConfig, error) {
 	updates := map[string]interface{}{
 		"last_connection_test":   status.LastChecked,
 		"last_connection_status": status.IsConnected,
 		"last_error":             status.ErrorMessage,
+		"last_response_time":     status.ResponseTime,
+	}
+	if status.IsConnected {
+		updates["consecutive_failures"] = 0
+		updates["degraded"] = false
+	} else {
+		updates["consecutive_failures"] = gorm.Expr("consecutive_failures + 1")
+		updates["degraded"] = gorm.Expr("? > 0 AND consecutive_failures + 1 >= ?", degradeAfter, degradeAfter)
+	}
+
+	var config models.PACSConfig
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&config).
+			Clauses(clause.Returning{}).
+			Where("id = ?", id).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Create(&models.ConnectionTest{
+			TenantID:     config.TenantID,
+			PACSConfigID: id,
+			TestedAt:     status.LastChecked,
+			Success:      status.IsConnected,
+			ResponseTime: status.ResponseTime,
+			Error:        status.ErrorMessage,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update connection status: %w", err)
 	}
+	return &config, nil
+}
 
+// GetConnectionTests retrieves the connection tests of a PACS configuration in
+// [from, to), oldest first
+func (r *PACSRepository) GetConnectionTests(ctx context.Context, id uuid.UUID, from, to time.Time) ([]models.ConnectionTest, error) {
+	var tests []models.ConnectionTest
 	if err := database.DB.WithContext(ctx).
-		Model(&models.PACSConfig{}).
-		Where("id = ?", id).
-		Updates(updates).Error; err != nil {
-		return fmt.Errorf("failed to update connection status: %w", err)
+		Where("pacs_config_id = ? AND tested_at >= ? AND tested_at < ?", id, from, to).
+		Order("tested_at ASC").
+		Find(&tests).Error; err != nil {
+		return nil, fmt.Errorf("failed to get connection tests: %w", err)
 	}
+	return tests, nil
+}
 
-	return nil
+// DeleteConnectionTestsBefore removes the connection tests recorded before a time
+func (r *PACSRepository) DeleteConnectionTestsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := database.DB.WithContext(ctx).
+		Where("tested_at < ?", before).
+		Delete(&models.ConnectionTest{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete connection tests: %w", result.Error)
+	}
+	return result.RowsAffected, nil
 }
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"gorm.io/gorm/clause"
+)
+
+// PinRepository handles pinned study database operations
+type PinRepository struct{}
+
+// NewPinRepository creates a new pinned study repository
+func NewPinRepository() *PinRepository {
+	return &PinRepository{}
+}
+
+// Save pins a study, replacing the reason of a study already pinned
+func (r *PinRepository) Save(ctx context.Context, pin *models.PinnedStudy) error {
+	if err := database.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "study_uid"}},
+			DoUpdates: clause.AssignmentColumns([]string{"reason"}),
+		}).
+		Create(pin).Error; err != nil {
+		return fmt.Errorf("failed to save pinned study: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a pinned study of a tenant
+func (r *PinRepository) Get(ctx context.Context, tenantID uuid.UUID, studyUID string) (*models.PinnedStudy, error) {
+	var pin models.PinnedStudy
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND study_uid = ?", tenantID, studyUID).
+		First(&pin).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pinned study: %w", err)
+	}
+	return &pin, nil
+}
+
+// GetByTenantID retrieves the pinned studies of a tenant, most recently pinned first
+func (r *PinRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID) ([]models.PinnedStudy, error) {
+	var pins []models.PinnedStudy
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&pins).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pinned studies: %w", err)
+	}
+	return pins, nil
+}
+
+// GetAll retrieves the pinned studies of every tenant
+func (r *PinRepository) GetAll(ctx context.Context) ([]models.PinnedStudy, error) {
+	var pins []models.PinnedStudy
+	if err := database.DB.WithContext(ctx).Order("created_at ASC").Find(&pins).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pinned studies: %w", err)
+	}
+	return pins, nil
+}
+
+// UpdateRefresh records the outcome of a pinned study's refresh
+func (r *PinRepository) UpdateRefresh(ctx context.Context, pin *models.PinnedStudy) error {
+	if err := database.DB.WithContext(ctx).
+		Model(&models.PinnedStudy{}).
+		Where("id = ?", pin.ID).
+		Updates(map[string]interface{}{
+			"refreshed_at":      pin.RefreshedAt,
+			"total_instances":   pin.TotalInstances,
+			"fetched_instances": pin.FetchedInstances,
+			"error":             pin.Error,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to update pinned study: %w", err)
+	}
+	return nil
+}
+
+// Delete unpins a study of a tenant
+func (r *PinRepository) Delete(ctx context.Context, tenantID uuid.UUID, studyUID string) (bool, error) {
+	result := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND study_uid = ?", tenantID, studyUID).
+		Delete(&models.PinnedStudy{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete pinned study: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// PrefetchRepository handles prefetch job database operations
+type PrefetchRepository struct{}
+
+// NewPrefetchRepository creates a new prefetch repository
+func NewPrefetchRepository() *PrefetchRepository {
+	return &PrefetchRepository{}
+}
+
+// Create creates a new prefetch job
+func (r *PrefetchRepository) Create(ctx context.Context, job *models.PrefetchJob) error {
+	if err := database.DB.WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create prefetch job: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a prefetch job by ID
+func (r *PrefetchRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PrefetchJob, error) {
+	var job models.PrefetchJob
+	if err := database.DB.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to get prefetch job: %w", err)
+	}
+	return &job, nil
+}
+
+// Update saves a prefetch job
+func (r *PrefetchRepository) Update(ctx context.Context, job *models.PrefetchJob) error {
+	if err := database.DB.WithContext(ctx).Save(job).Error; err != nil {
+		return fmt.Errorf("failed to update prefetch job: %w", err)
+	}
+	return nil
+}
+
+// FailUnfinished marks the given jobs failed unless they have finished
+func (r *PrefetchRepository) FailUnfinished(ctx context.Context, ids []uuid.UUID, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := database.DB.WithContext(ctx).
+		Model(&models.PrefetchJob{}).
+		Where("id IN ? AND status IN ?", ids, []string{models.PrefetchPending, models.PrefetchRunning}).
+		Updates(map[string]interface{}{"status": models.PrefetchFailed, "error": reason}).Error; err != nil {
+		return fmt.Errorf("failed to update prefetch jobs: %w", err)
+	}
+	return nil
+}
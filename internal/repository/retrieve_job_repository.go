@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// RetrieveJobRepository handles retrieve job database operations
+type RetrieveJobRepository struct{}
+
+// NewRetrieveJobRepository creates a new retrieve job repository
+func NewRetrieveJobRepository() *RetrieveJobRepository {
+	return &RetrieveJobRepository{}
+}
+
+// Create creates a new retrieve job
+func (r *RetrieveJobRepository) Create(ctx context.Context, job *models.RetrieveJob) error {
+	if err := database.DB.WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create retrieve job: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a tenant's retrieve job by ID
+func (r *RetrieveJobRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.RetrieveJob, error) {
+	var job models.RetrieveJob
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		First(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to get retrieve job: %w", err)
+	}
+	return &job, nil
+}
+
+// ClaimNext starts the oldest pending job of the highest priority, or takes over
+// a running job whose lease expired because its worker stopped, and leases it
+// until now+lease so workers on other instances skip it. It returns nil when no
+// job is waiting.
+func (r *RetrieveJobRepository) ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*models.RetrieveJob, error) {
+	var jobs []models.RetrieveJob
+	if err := database.DB.WithContext(ctx).Raw(`
+		UPDATE retrieve_jobs SET status = ?, lease_until = ?, runs = runs + 1,
+			started_at = COALESCE(started_at, ?), updated_at = ?
+		WHERE id = (
+			SELECT id FROM retrieve_jobs
+			WHERE status = ? OR (status = ? AND lease_until < ?)
+			ORDER BY CASE priority WHEN ? THEN 0 ELSE 1 END, created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.RetrieveJobRunning, now.Add(lease), now, now,
+		models.RetrieveJobPending, models.RetrieveJobRunning, now, models.JobPriorityStat).
+		Scan(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to claim retrieve job: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// CountPending counts the pending jobs of every tenant per priority
+func (r *RetrieveJobRepository) CountPending(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Priority string
+		Count    int64
+	}
+	if err := database.DB.WithContext(ctx).
+		Model(&models.RetrieveJob{}).
+		Select("priority, COUNT(*) AS count").
+		Where("status = ?", models.RetrieveJobPending).
+		Group("priority").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count pending retrieve jobs: %w", err)
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Priority] = row.Count
+	}
+	return counts, nil
+}
+
+// SaveProgress saves a running job's progress and renews its lease. It reports
+// false when the job is no longer running, e.g. because it was cancelled.
+func (r *RetrieveJobRepository) SaveProgress(ctx context.Context, job *models.RetrieveJob, leaseUntil time.Time) (bool, error) {
+	result := database.DB.WithContext(ctx).
+		Model(&models.RetrieveJob{}).
+		Where("id = ? AND status = ?", job.ID, models.RetrieveJobRunning).
+		Updates(map[string]interface{}{
+			"total_instances":      job.TotalInstances,
+			"completed_instances":  job.CompletedInstances,
+			"failed_instances":     job.FailedInstances,
+			"bytes":                job.Bytes,
+			"failed_instance_uids": job.FailedInstanceUIDs,
+			"lease_until":          leaseUntil,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to save retrieve job progress: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Finish records the outcome of a running job. It reports false when the job
+// is no longer running, e.g. because it was cancelled.
+func (r *RetrieveJobRepository) Finish(ctx context.Context, job *models.RetrieveJob) (bool, error) {
+	result := database.DB.WithContext(ctx).
+		Model(&models.RetrieveJob{}).
+		Where("id = ? AND status = ?", job.ID, models.RetrieveJobRunning).
+		Updates(map[string]interface{}{
+			"status":               job.Status,
+			"total_instances":      job.TotalInstances,
+			"completed_instances":  job.CompletedInstances,
+			"failed_instances":     job.FailedInstances,
+			"bytes":                job.Bytes,
+			"failed_instance_uids": job.FailedInstanceUIDs,
+			"error":                job.Error,
+			"lease_until":          nil,
+			"completed_at":         job.CompletedAt,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to finish retrieve job: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Cancel cancels a tenant's pending or running job. It reports false when the
+// job has already finished.
+func (r *RetrieveJobRepository) Cancel(ctx context.Context, tenantID, id uuid.UUID) (bool, error) {
+	now := time.Now()
+	result := database.DB.WithContext(ctx).
+		Model(&models.RetrieveJob{}).
+		Where("tenant_id = ? AND id = ? AND status IN ?", tenantID, id, []string{models.RetrieveJobPending, models.RetrieveJobRunning}).
+		Updates(map[string]interface{}{
+			"status":       models.RetrieveJobCancelled,
+			"lease_until":  nil,
+			"completed_at": now,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to cancel retrieve job: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Release returns a running job to the queue, for a worker that stops before
+// finishing it
+func (r *RetrieveJobRepository) Release(ctx context.Context, id uuid.UUID) error {
+	if err := database.DB.WithContext(ctx).
+		Model(&models.RetrieveJob{}).
+		Where("id = ? AND status = ?", id, models.RetrieveJobRunning).
+		Updates(map[string]interface{}{
+			"status":      models.RetrieveJobPending,
+			"lease_until": nil,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to release retrieve job: %w", err)
+	}
+	return nil
+}
+
+// Requeue returns a tenant's failed or cancelled job to the queue. It reports
+// false when the job is pending, running or completed.
+func (r *RetrieveJobRepository) Requeue(ctx context.Context, tenantID, id uuid.UUID) (bool, error) {
+	result := database.DB.WithContext(ctx).
+		Model(&models.RetrieveJob{}).
+		Where("tenant_id = ? AND id = ? AND status IN ?", tenantID, id, []string{models.RetrieveJobFailed, models.RetrieveJobCancelled}).
+		Updates(map[string]interface{}{
+			"status":       models.RetrieveJobPending,
+			"error":        "",
+			"completed_at": nil,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to requeue retrieve job: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
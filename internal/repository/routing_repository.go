@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// RoutingRepository handles PACS routing rule database operations
+type RoutingRepository struct{}
+
+// NewRoutingRepository creates a new routing rule repository
+func NewRoutingRepository() *RoutingRepository {
+	return &RoutingRepository{}
+}
+
+// Create creates a new routing rule
+func (r *RoutingRepository) Create(ctx context.Context, rule *models.PACSRoutingRule) error {
+	if err := database.DB.WithContext(ctx).Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to create routing rule: %w", err)
+	}
+	return nil
+}
+
+// GetByTenantID retrieves the routing rules of a tenant in evaluation order
+func (r *RoutingRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID) ([]models.PACSRoutingRule, error) {
+	var rules []models.PACSRoutingRule
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("priority ASC, created_at ASC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get routing rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Delete soft deletes a routing rule of a tenant
+func (r *RoutingRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) (bool, error) {
+	result := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Delete(&models.PACSRoutingRule{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete routing rule: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StudyIndexRepository handles study index and PACS synchronization database operations
+type StudyIndexRepository struct{}
+
+// NewStudyIndexRepository creates a new study index repository
+func NewStudyIndexRepository() *StudyIndexRepository {
+	return &StudyIndexRepository{}
+}
+
+// Upsert indexes studies, updating the attributes of studies already indexed
+// but keeping when they were first seen
+func (r *StudyIndexRepository) Upsert(ctx context.Context, entries []models.StudyIndexEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := database.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "tenant_id"}, {Name: "study_instance_uid"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"pacs_config_id", "accession_number", "patient_id", "patient_name", "patient_birth_date", "patient_sex",
+				"referring_physician", "study_date", "study_time", "study_description", "modalities_in_study",
+				"number_of_series", "number_of_instances", "last_seen_at",
+			}),
+		}).
+		CreateInBatches(&entries, 500).Error; err != nil {
+		return fmt.Errorf("failed to index studies: %w", err)
+	}
+	return nil
+}
+
+// SearchStudies retrieves the studies of a tenant found on a PACS config that
+// match a study search, newest first. Patient ID, accession number and study
+// UID (filter 0020000D) are matched exactly, the study date as a single date or
+// a range (YYYYMMDD-YYYYMMDD) and modalities by any of them.
+func (r *StudyIndexRepository) SearchStudies(ctx context.Context, tenantID, pacsConfigID uuid.UUID, params models.QueryParams) ([]models.StudyIndexEntry, error) {
+	query := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND pacs_config_id = ?", tenantID, pacsConfigID).
+		Order("study_date DESC, study_time DESC, study_instance_uid ASC")
+
+	if params.PatientID != "" {
+		query = query.Where("patient_id = ?", params.PatientID)
+	}
+	if params.AccessionNumber != "" {
+		query = query.Where("accession_number = ?", params.AccessionNumber)
+	}
+	if studyUID := params.Filters["0020000D"]; studyUID != "" {
+		query = query.Where("study_instance_uid = ?", studyUID)
+	}
+	if params.StudyDate != "" {
+		if from, to, isRange := strings.Cut(params.StudyDate, "-"); isRange {
+			if from != "" {
+				query = query.Where("study_date >= ?", from)
+			}
+			if to != "" {
+				query = query.Where("study_date <= ?", to)
+			}
+		} else {
+			query = query.Where("study_date = ?", params.StudyDate)
+		}
+	}
+	if len(params.Modalities) > 0 {
+		query = query.Where("modalities_in_study && ARRAY[?]::text[]", params.Modalities)
+	}
+
+	if params.Limit > 0 {
+		query = query.Limit(params.Limit)
+	}
+	if params.Offset > 0 {
+		query = query.Offset(params.Offset)
+	}
+
+	var entries []models.StudyIndexEntry
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to search indexed studies: %w", err)
+	}
+	return entries, nil
+}
+
+// GetStudy retrieves an indexed study of a tenant
+func (r *StudyIndexRepository) GetStudy(ctx context.Context, tenantID uuid.UUID, studyUID string) (*models.StudyIndexEntry, error) {
+	var entry models.StudyIndexEntry
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND study_instance_uid = ?", tenantID, studyUID).
+		First(&entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to get indexed study: %w", err)
+	}
+	return &entry, nil
+}
+
+// ReplaceSeries indexes the series of a study as the PACS listed them,
+// removing series (and their instances) it no longer lists, and records when
+// the study's series were indexed
+func (r *StudyIndexRepository) ReplaceSeries(ctx context.Context, tenantID uuid.UUID, studyUID string, entries []models.SeriesIndexEntry, now time.Time) error {
+	uids := make([]string, len(entries))
+	for i, entry := range entries {
+		uids[i] = entry.SeriesInstanceUID
+	}
+
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		removed := tx.Where("tenant_id = ? AND study_instance_uid = ?", tenantID, studyUID)
+		if len(uids) > 0 {
+			removed = removed.Where("series_instance_uid NOT IN ?", uids)
+		}
+		if err := removed.Delete(&models.InstanceIndexEntry{}).Error; err != nil {
+			return err
+		}
+		removed = tx.Where("tenant_id = ? AND study_instance_uid = ?", tenantID, studyUID)
+		if len(uids) > 0 {
+			removed = removed.Where("series_instance_uid NOT IN ?", uids)
+		}
+		if err := removed.Delete(&models.SeriesIndexEntry{}).Error; err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "series_instance_uid"}},
+				DoUpdates: clause.AssignmentColumns([]string{"study_instance_uid", "dataset", "indexed_at"}),
+			}).CreateInBatches(&entries, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.StudyIndexEntry{}).
+			Where("tenant_id = ? AND study_instance_uid = ?", tenantID, studyUID).
+			Update("series_indexed_at", now).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index series: %w", err)
+	}
+	return nil
+}
+
+// GetSeries retrieves the indexed series of a study
+func (r *StudyIndexRepository) GetSeries(ctx context.Context, tenantID uuid.UUID, studyUID string) ([]models.SeriesIndexEntry, error) {
+	var entries []models.SeriesIndexEntry
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND study_instance_uid = ?", tenantID, studyUID).
+		Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get indexed series: %w", err)
+	}
+	return entries, nil
+}
+
+// GetSeriesEntry retrieves an indexed series of a tenant
+func (r *StudyIndexRepository) GetSeriesEntry(ctx context.Context, tenantID uuid.UUID, seriesUID string) (*models.SeriesIndexEntry, error) {
+	var entry models.SeriesIndexEntry
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND series_instance_uid = ?", tenantID, seriesUID).
+		First(&entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to get indexed series: %w", err)
+	}
+	return &entry, nil
+}
+
+// ReplaceInstances indexes the instances of a series as the PACS listed them,
+// removing instances it no longer lists, and records when the series'
+// instances were indexed
+func (r *StudyIndexRepository) ReplaceInstances(ctx context.Context, tenantID uuid.UUID, seriesUID string, entries []models.InstanceIndexEntry, now time.Time) error {
+	uids := make([]string, len(entries))
+	for i, entry := range entries {
+		uids[i] = entry.SOPInstanceUID
+	}
+
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		removed := tx.Where("tenant_id = ? AND series_instance_uid = ?", tenantID, seriesUID)
+		if len(uids) > 0 {
+			removed = removed.Where("sop_instance_uid NOT IN ?", uids)
+		}
+		if err := removed.Delete(&models.InstanceIndexEntry{}).Error; err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "sop_instance_uid"}},
+				DoUpdates: clause.AssignmentColumns([]string{"study_instance_uid", "series_instance_uid", "dataset", "indexed_at"}),
+			}).CreateInBatches(&entries, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.SeriesIndexEntry{}).
+			Where("tenant_id = ? AND series_instance_uid = ?", tenantID, seriesUID).
+			Update("instances_indexed_at", now).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index instances: %w", err)
+	}
+	return nil
+}
+
+// GetInstances retrieves the indexed instances of a series
+func (r *StudyIndexRepository) GetInstances(ctx context.Context, tenantID uuid.UUID, seriesUID string) ([]models.InstanceIndexEntry, error) {
+	var entries []models.InstanceIndexEntry
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND series_instance_uid = ?", tenantID, seriesUID).
+		Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get indexed instances: %w", err)
+	}
+	return entries, nil
+}
+
+// DeleteStudy removes a study with its series and instances from the index
+func (r *StudyIndexRepository) DeleteStudy(ctx context.Context, tenantID uuid.UUID, studyUID string) error {
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.InstanceIndexEntry{}, &models.SeriesIndexEntry{}, &models.StudyIndexEntry{}} {
+			if err := tx.Where("tenant_id = ? AND study_instance_uid = ?", tenantID, studyUID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove study from the index: %w", err)
+	}
+	return nil
+}
+
+// ForgetStudyContents removes the series and instances of a study from the
+// index, keeping the study, so they are listed by the PACS again
+func (r *StudyIndexRepository) ForgetStudyContents(ctx context.Context, tenantID uuid.UUID, studyUID string) error {
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.InstanceIndexEntry{}, &models.SeriesIndexEntry{}} {
+			if err := tx.Where("tenant_id = ? AND study_instance_uid = ?", tenantID, studyUID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.StudyIndexEntry{}).
+			Where("tenant_id = ? AND study_instance_uid = ?", tenantID, studyUID).
+			Update("series_indexed_at", nil).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clear indexed series: %w", err)
+	}
+	return nil
+}
+
+// IndexedUIDs returns which of the given studies of a tenant are indexed
+func (r *StudyIndexRepository) IndexedUIDs(ctx context.Context, tenantID uuid.UUID, studyUIDs []string) (map[string]bool, error) {
+	indexed := make(map[string]bool)
+	if len(studyUIDs) == 0 {
+		return indexed, nil
+	}
+
+	var uids []string
+	if err := database.DB.WithContext(ctx).
+		Model(&models.StudyIndexEntry{}).
+		Where("tenant_id = ? AND study_instance_uid IN ?", tenantID, studyUIDs).
+		Pluck("study_instance_uid", &uids).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up indexed studies: %w", err)
+	}
+	for _, uid := range uids {
+		indexed[uid] = true
+	}
+	return indexed, nil
+}
+
+// ClaimSync starts a synchronization run of a tenant unless one started less
+// than interval ago, so connector instances sharing the database take turns.
+// It returns the tenant's state and whether the run was claimed.
+func (r *StudyIndexRepository) ClaimSync(ctx context.Context, tenantID uuid.UUID, now time.Time, interval time.Duration) (*models.StudySyncState, bool, error) {
+	db := database.DB.WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.StudySyncState{TenantID: tenantID}).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to create sync state: %w", err)
+	}
+
+	result := db.Model(&models.StudySyncState{}).
+		Where("tenant_id = ? AND (started_at IS NULL OR started_at <= ?)", tenantID, now.Add(-interval)).
+		Updates(map[string]interface{}{"started_at": now, "updated_at": now})
+	if result.Error != nil {
+		return nil, false, fmt.Errorf("failed to claim sync: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, false, nil
+	}
+
+	var state models.StudySyncState
+	if err := db.Where("tenant_id = ?", tenantID).First(&state).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to get sync state: %w", err)
+	}
+	return &state, true, nil
+}
+
+// UpdateSyncState saves the outcome of a synchronization run
+func (r *StudyIndexRepository) UpdateSyncState(ctx context.Context, state *models.StudySyncState) error {
+	if err := database.DB.WithContext(ctx).Save(state).Error; err != nil {
+		return fmt.Errorf("failed to update sync state: %w", err)
+	}
+	return nil
+}
+
+// GetSyncState retrieves a tenant's synchronization state
+func (r *StudyIndexRepository) GetSyncState(ctx context.Context, tenantID uuid.UUID) (*models.StudySyncState, error) {
+	var state models.StudySyncState
+	if err := database.DB.WithContext(ctx).Where("tenant_id = ?", tenantID).First(&state).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sync state: %w", err)
+	}
+	return &state, nil
+}
+
+// CountStudies counts the indexed studies of a tenant
+func (r *StudyIndexRepository) CountStudies(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	var count int64
+	if err := database.DB.WithContext(ctx).Model(&models.StudyIndexEntry{}).Where("tenant_id = ?", tenantID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count indexed studies: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// TenantRepository handles tenant database operations
+type TenantRepository struct{}
+
+// NewTenantRepository creates a new tenant repository
+func NewTenantRepository() *TenantRepository {
+	return &TenantRepository{}
+}
+
+// Create creates a new tenant
+func (r *TenantRepository) Create(ctx context.Context, tenant *models.Tenant) error {
+	if err := database.DB.WithContext(ctx).Create(tenant).Error; err != nil {
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a tenant
+func (r *TenantRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Tenant, error) {
+	var tenant models.Tenant
+	if err := database.DB.WithContext(ctx).Where("id = ?", id).First(&tenant).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	return &tenant, nil
+}
+
+// ExistsWithDeleted reports whether a tenant ID was ever provisioned, deleted
+// tenants included
+func (r *TenantRepository) ExistsWithDeleted(ctx context.Context, id uuid.UUID) (bool, error) {
+	var count int64
+	if err := database.DB.WithContext(ctx).
+		Unscoped().
+		Model(&models.Tenant{}).
+		Where("id = ?", id).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to look up tenant: %w", err)
+	}
+	return count > 0, nil
+}
+
+// List retrieves the tenants, of one status when status is not empty
+func (r *TenantRepository) List(ctx context.Context, status string) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	query := database.DB.WithContext(ctx).Order("name")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&tenants).Error; err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	return tenants, nil
+}
+
+// Rename changes a tenant's name
+func (r *TenantRepository) Rename(ctx context.Context, id uuid.UUID, name string) error {
+	if err := database.DB.WithContext(ctx).
+		Model(&models.Tenant{}).
+		Where("id = ?", id).
+		Update("name", name).Error; err != nil {
+		return fmt.Errorf("failed to rename tenant: %w", err)
+	}
+	return nil
+}
+
+// SetStatus enables or disables a tenant
+func (r *TenantRepository) SetStatus(ctx context.Context, id uuid.UUID, status string, disabledAt *time.Time) error {
+	if err := database.DB.WithContext(ctx).
+		Model(&models.Tenant{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "disabled_at": disabledAt}).Error; err != nil {
+		return fmt.Errorf("failed to update tenant status: %w", err)
+	}
+	return nil
+}
+
+// SetCacheGeneration starts a new generation of the tenant's cache keys
+func (r *TenantRepository) SetCacheGeneration(ctx context.Context, id uuid.UUID, generation int64) error {
+	if err := database.DB.WithContext(ctx).
+		Model(&models.Tenant{}).
+		Where("id = ?", id).
+		Update("cache_generation", generation).Error; err != nil {
+		return fmt.Errorf("failed to update tenant cache generation: %w", err)
+	}
+	return nil
+}
+
+// Delete soft-deletes a tenant
+func (r *TenantRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := database.DB.WithContext(ctx).Where("id = ?", id).Delete(&models.Tenant{}).Error; err != nil {
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+	return nil
+}
+
+// ImportExisting provisions, as active tenants named after their ID, the
+// tenants that have PACS configs, settings or API keys but no tenant record,
+// as left by connector versions that did not keep tenants. It returns how
+// many it provisioned.
+func (r *TenantRepository) ImportExisting(ctx context.Context) (int64, error) {
+	now := time.Now()
+	result := database.DB.WithContext(ctx).Exec(`
+		INSERT INTO tenants (id, name, status, created_at, updated_at)
+		SELECT tenant_id, tenant_id::text, ?, ?, ?
+		FROM (
+			SELECT tenant_id FROM pacs_configs
+			UNION SELECT tenant_id FROM tenant_settings
+			UNION SELECT tenant_id FROM api_keys
+		) existing
+		ON CONFLICT (id) DO NOTHING`,
+		models.TenantStatusActive, now, now)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to import existing tenants: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"gorm.io/gorm/clause"
+)
+
+// TenantSettingsRepository handles tenant settings database operations
+type TenantSettingsRepository struct{}
+
+// NewTenantSettingsRepository creates a new tenant settings repository
+func NewTenantSettingsRepository() *TenantSettingsRepository {
+	return &TenantSettingsRepository{}
+}
+
+// Get retrieves a tenant's settings
+func (r *TenantSettingsRepository) Get(ctx context.Context, tenantID uuid.UUID) (*models.TenantSettings, error) {
+	var settings models.TenantSettings
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		First(&settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tenant settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// Save creates or replaces a tenant's settings
+func (r *TenantSettingsRepository) Save(ctx context.Context, settings *models.TenantSettings) error {
+	if err := database.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}},
+			DoUpdates: clause.AssignmentColumns(tenantSettingsColumns),
+		}).
+		Create(settings).Error; err != nil {
+		return fmt.Errorf("failed to save tenant settings: %w", err)
+	}
+	return nil
+}
+
+// tenantSettingsColumns are the columns replaced when settings are saved again
+var tenantSettingsColumns = []string{
+	"instance_cache_ttl", "metadata_cache_ttl", "query_cache_ttl", "thumbnail_cache_ttl",
+	"max_results", "allowed_modalities",
+	"prefetch_priors", "prefetch_lookback_days", "prefetch_same_modality", "prefetch_same_body_part",
+	"fan_out_enabled", "prefetch_enabled", "failover_enabled",
+	"updated_at",
+}
+
+// Delete removes a tenant's settings. It reports false when the tenant had none.
+func (r *TenantSettingsRepository) Delete(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	result := database.DB.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Delete(&models.TenantSettings{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete tenant settings: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UsageRepository handles tenant usage database operations
+type UsageRepository struct{}
+
+// NewUsageRepository creates a new usage repository
+func NewUsageRepository() *UsageRepository {
+	return &UsageRepository{}
+}
+
+// Add adds the counts of each record to the tenant's stored usage of its day,
+// so every connector instance can add what it metered
+func (r *UsageRepository) Add(ctx context.Context, records []models.TenantUsage) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	increment := func(column string) clause.Assignment {
+		return clause.Assignment{
+			Column: clause.Column{Name: column},
+			Value:  gorm.Expr(fmt.Sprintf("tenant_usage.%s + excluded.%s", column, column)),
+		}
+	}
+	if err := database.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "tenant_id"}, {Name: "day"}},
+			DoUpdates: clause.Set{
+				increment("queries"),
+				increment("retrievals"),
+				increment("bytes_served"),
+				increment("cache_hits"),
+				increment("cache_misses"),
+				{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+			},
+		}).
+		Create(&records).Error; err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// GetByTenantID retrieves a tenant's usage on the days from to to, inclusive, oldest first
+func (r *UsageRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.TenantUsage, error) {
+	var usage []models.TenantUsage
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND day >= ? AND day <= ?", tenantID, from, to).
+		Order("day ASC").
+		Find(&usage).Error; err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return usage, nil
+}
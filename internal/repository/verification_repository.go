@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"gorm.io/gorm"
+)
+
+// VerificationRepository handles cache verification database operations
+type VerificationRepository struct{}
+
+// NewVerificationRepository creates a new cache verification repository
+func NewVerificationRepository() *VerificationRepository {
+	return &VerificationRepository{}
+}
+
+// Create creates a new cache verification
+func (r *VerificationRepository) Create(ctx context.Context, verification *models.CacheVerification) error {
+	if err := database.DB.WithContext(ctx).Create(verification).Error; err != nil {
+		return fmt.Errorf("failed to create cache verification: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a cache verification with its discrepancies
+func (r *VerificationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.CacheVerification, error) {
+	var verification models.CacheVerification
+	if err := database.DB.WithContext(ctx).
+		Preload("Discrepancies", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Where("id = ?", id).
+		First(&verification).Error; err != nil {
+		return nil, fmt.Errorf("failed to get cache verification: %w", err)
+	}
+	return &verification, nil
+}
+
+// Update saves the progress of a cache verification, without its discrepancies
+func (r *VerificationRepository) Update(ctx context.Context, verification *models.CacheVerification) error {
+	if err := database.DB.WithContext(ctx).Omit("Discrepancies").Save(verification).Error; err != nil {
+		return fmt.Errorf("failed to update cache verification: %w", err)
+	}
+	return nil
+}
+
+// AddDiscrepancy records a discrepancy found by a cache verification
+func (r *VerificationRepository) AddDiscrepancy(ctx context.Context, discrepancy *models.CacheDiscrepancy) error {
+	if err := database.DB.WithContext(ctx).Create(discrepancy).Error; err != nil {
+		return fmt.Errorf("failed to create cache discrepancy: %w", err)
+	}
+	return nil
+}
+
+// FailUnfinished marks the given verifications failed unless they have finished
+func (r *VerificationRepository) FailUnfinished(ctx context.Context, ids []uuid.UUID, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := database.DB.WithContext(ctx).
+		Model(&models.CacheVerification{}).
+		Where("id IN ? AND status IN ?", ids, []string{models.VerificationPending, models.VerificationRunning}).
+		Updates(map[string]interface{}{"status": models.VerificationFailed, "error": reason}).Error; err != nil {
+		return fmt.Errorf("failed to update cache verifications: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// ViewerGrantRepository handles viewer grant database operations
+type ViewerGrantRepository struct{}
+
+// NewViewerGrantRepository creates a new viewer grant repository
+func NewViewerGrantRepository() *ViewerGrantRepository {
+	return &ViewerGrantRepository{}
+}
+
+// Create creates a new viewer grant
+func (r *ViewerGrantRepository) Create(ctx context.Context, grant *models.ViewerGrant) error {
+	if err := database.DB.WithContext(ctx).Create(grant).Error; err != nil {
+		return fmt.Errorf("failed to create viewer grant: %w", err)
+	}
+	return nil
+}
+
+// GetByTokenHash retrieves the grant with a token hash that has not expired at now
+func (r *ViewerGrantRepository) GetByTokenHash(ctx context.Context, tokenHash string, now time.Time) (*models.ViewerGrant, error) {
+	var grant models.ViewerGrant
+	if err := database.DB.WithContext(ctx).
+		Where("token_hash = ? AND expires_at > ?", tokenHash, now).
+		First(&grant).Error; err != nil {
+		return nil, fmt.Errorf("failed to get viewer grant: %w", err)
+	}
+	return &grant, nil
+}
+
+// DeleteExpired deletes the grants that expired before now
+func (r *ViewerGrantRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := database.DB.WithContext(ctx).
+		Where("expires_at < ?", now).
+		Delete(&models.ViewerGrant{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired viewer grants: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// WebhookRepository handles webhook and delivery database operations
+type WebhookRepository struct{}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{}
+}
+
+// Create creates a new webhook
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	if err := database.DB.WithContext(ctx).Create(webhook).Error; err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a webhook of a tenant by ID
+func (r *WebhookRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		First(&webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return &webhook, nil
+}
+
+// GetByTenantID retrieves all webhooks of a tenant
+func (r *WebhookRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at ASC").
+		Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// GetAllWithDeleted retrieves the webhooks of every tenant, including deleted ones
+func (r *WebhookRepository) GetAllWithDeleted(ctx context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := database.DB.WithContext(ctx).
+		Unscoped().
+		Order("tenant_id ASC, created_at ASC").
+		Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// UpdateSecret replaces a webhook's signing secret unless it changed since it
+// was read, and reports whether it was replaced
+func (r *WebhookRepository) UpdateSecret(ctx context.Context, id uuid.UUID, old, secret string) (bool, error) {
+	result := database.DB.WithContext(ctx).
+		Unscoped().
+		Model(&models.Webhook{}).
+		Where("id = ? AND secret = ?", id, old).
+		UpdateColumn("secret", secret)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update webhook secret: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetActiveByTenantID retrieves the active webhooks of a tenant
+func (r *WebhookRepository) GetActiveByTenantID(ctx context.Context, tenantID uuid.UUID) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// Delete soft deletes a webhook of a tenant
+func (r *WebhookRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) (bool, error) {
+	result := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Delete(&models.Webhook{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete webhook: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CreateDeliveries queues event deliveries
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := database.DB.WithContext(ctx).Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueDeliveries returns pending deliveries whose next attempt is due and
+// pushes their next attempt out by lease, so concurrent dispatchers on other
+// instances skip them while they are in flight
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	if err := database.DB.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(lease), now, models.DeliveryPending, now, limit).
+		Scan(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery saves the outcome of a delivery attempt
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if err := database.DB.WithContext(ctx).Save(delivery).Error; err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveries retrieves a tenant's deliveries, newest first, optionally by status
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, tenantID uuid.UUID, status string, limit, offset int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	query := database.DB.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC")
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver requeues a dead-lettered delivery of a tenant for immediate delivery
+func (r *WebhookRepository) Redeliver(ctx context.Context, tenantID, id uuid.UUID) (bool, error) {
+	result := database.DB.WithContext(ctx).
+		Model(&models.WebhookDelivery{}).
+		Where("tenant_id = ? AND id = ? AND status = ?", tenantID, id, models.DeliveryDead).
+		Updates(map[string]interface{}{
+			"status":          models.DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to requeue webhook delivery: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
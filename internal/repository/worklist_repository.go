@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/database"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// WorklistRepository handles UPS workitem database operations
+type WorklistRepository struct{}
+
+// NewWorklistRepository creates a new worklist repository
+func NewWorklistRepository() *WorklistRepository {
+	return &WorklistRepository{}
+}
+
+// Create creates a new workitem
+func (r *WorklistRepository) Create(ctx context.Context, workitem *models.Workitem) error {
+	if err := database.DB.WithContext(ctx).Create(workitem).Error; err != nil {
+		return fmt.Errorf("failed to create workitem: %w", err)
+	}
+	return nil
+}
+
+// GetByUID retrieves a workitem by its SOP Instance UID
+func (r *WorklistRepository) GetByUID(ctx context.Context, tenantID uuid.UUID, uid string) (*models.Workitem, error) {
+	var workitem models.Workitem
+	if err := database.DB.WithContext(ctx).
+		Where("tenant_id = ? AND sop_instance_uid = ?", tenantID, uid).
+		First(&workitem).Error; err != nil {
+		return nil, fmt.Errorf("failed to get workitem: %w", err)
+	}
+	return &workitem, nil
+}
+
+// Search retrieves workitems matching a query, ordered by scheduled start
+func (r *WorklistRepository) Search(ctx context.Context, tenantID uuid.UUID, q models.WorkitemQuery) ([]models.Workitem, error) {
+	var workitems []models.Workitem
+	query := database.DB.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("scheduled_start ASC, created_at ASC")
+
+	if q.PatientID != "" {
+		query = query.Where("patient_id = ?", q.PatientID)
+	}
+	if q.AccessionNumber != "" {
+		query = query.Where("accession_number = ?", q.AccessionNumber)
+	}
+	if q.StudyInstanceUID != "" {
+		query = query.Where("study_instance_uid = ?", q.StudyInstanceUID)
+	}
+	if q.WorklistLabel != "" {
+		query = query.Where("worklist_label = ?", q.WorklistLabel)
+	}
+	if q.State != "" {
+		query = query.Where("state = ?", q.State)
+	}
+	if q.ScheduledStart != "" {
+		if from, to, isRange := strings.Cut(q.ScheduledStart, "-"); isRange {
+			if from != "" {
+				query = query.Where("scheduled_start >= ?", from)
+			}
+			if to != "" {
+				query = query.Where("scheduled_start <= ?", to)
+			}
+		} else {
+			query = query.Where("scheduled_start LIKE ?", q.ScheduledStart+"%")
+		}
+	}
+
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	if q.Offset > 0 {
+		query = query.Offset(q.Offset)
+	}
+
+	if err := query.Find(&workitems).Error; err != nil {
+		return nil, fmt.Errorf("failed to search workitems: %w", err)
+	}
+
+	return workitems, nil
+}
+
+// Update updates a workitem
+func (r *WorklistRepository) Update(ctx context.Context, workitem *models.Workitem) error {
+	if err := database.DB.WithContext(ctx).Save(workitem).Error; err != nil {
+		return fmt.Errorf("failed to update workitem: %w", err)
+	}
+	return nil
+}
+
+// UpdateIfState updates a workitem only while it is still in the expected state,
+// reporting false when another caller changed the state first
+func (r *WorklistRepository) UpdateIfState(ctx context.Context, workitem *models.Workitem, expected models.ProcedureStepState) (bool, error) {
+	result := database.DB.WithContext(ctx).
+		Model(&models.Workitem{}).
+		Where("id = ? AND state = ?", workitem.ID, expected).
+		Select("*").
+		Omit("id", "tenant_id", "created_at").
+		Updates(workitem)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update workitem: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// API key errors
+var (
+	ErrInvalidAPIKey        = errors.New("invalid, expired or revoked API key")
+	ErrInvalidAPIKeyRequest = errors.New("invalid API key request")
+	ErrAPIKeyNotFound       = errors.New("API key not found")
+	ErrAPIKeyRevoked        = errors.New("API key is revoked")
+)
+
+const (
+	// apiKeyBytes is the entropy of API keys
+	apiKeyBytes = 32
+	// apiKeyPrefixLength is how much of a key is kept to recognize it
+	apiKeyPrefixLength = 11
+	// apiKeyCacheTTL is how long a validated key is kept before it is read
+	// again; a key revoked through another connector instance is accepted here
+	// for up to this long
+	apiKeyCacheTTL = 30 * time.Second
+	// maxAPIKeyGracePeriod bounds how long a rotated key stays valid
+	maxAPIKeyGracePeriod = 7 * 24 * time.Hour
+)
+
+// cachedAPIKey is a validated API key as last read
+type cachedAPIKey struct {
+	key     models.APIKey
+	expires time.Time
+}
+
+// APIKeyService issues, rotates and revokes the API keys of machine-to-machine
+// callers, and validates the keys presented on requests
+type APIKeyService struct {
+	pacsService *PACSService
+	repo        *repository.APIKeyRepository
+
+	mu    sync.Mutex
+	cache map[string]cachedAPIKey // by key hash
+
+	signing *requestSigning // nil when requests cannot be signed
+}
+
+// NewAPIKeyService creates an API key service
+func NewAPIKeyService(pacsService *PACSService, repo *repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		pacsService: pacsService,
+		repo:        repo,
+		cache:       make(map[string]cachedAPIKey),
+	}
+}
+
+// CreateKey issues an API key for the tenant
+func (s *APIKeyService) CreateKey(ctx context.Context, tenantID uuid.UUID, req *models.APIKeyRequest, ipAddress, userAgent string) (*models.IssuedAPIKey, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKeyRequest)
+	}
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: give at least one scope of %s", ErrInvalidAPIKeyRequest, strings.Join(models.APIKeyScopes, ", "))
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(models.APIKeyScopes, scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKeyRequest, scope)
+		}
+	}
+	if req.Signing && s.signing == nil {
+		return nil, fmt.Errorf("%w: request signing is not configured", ErrInvalidAPIKeyRequest)
+	}
+	expiresAt, err := apiKeyExpiry(req.ExpiresInDays)
+	if err != nil {
+		return nil, err
+	}
+
+	key, token, err := newAPIKey(tenantID, strings.TrimSpace(req.Name), slices.Compact(slices.Sorted(slices.Values(req.Scopes))), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	key.Signing = req.Signing
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	s.recordAudit(ctx, tenantID, "apikey.create", key.ID, ipAddress, userAgent)
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("key_id", key.ID.String()).
+		Str("name", key.Name).
+		Strs("scopes", key.Scopes).
+		Bool("signing", key.Signing).
+		Msg("API key issued")
+	return s.issued(key, token), nil
+}
+
+// GetKeys lists the API keys of the tenant, revoked ones included
+func (s *APIKeyService) GetKeys(ctx context.Context, tenantID uuid.UUID) ([]models.APIKey, error) {
+	return s.repo.GetByTenantID(ctx, tenantID)
+}
+
+// RotateKey issues a replacement of one of the tenant's API keys, with the
+// same name and scopes. The old key is revoked, or expires after the grace
+// period so callers can switch to the new one.
+func (s *APIKeyService) RotateKey(ctx context.Context, tenantID, keyID uuid.UUID, req *models.APIKeyRotateRequest, ipAddress, userAgent string) (*models.IssuedAPIKey, error) {
+	if err := validateKeyRotation(req); err != nil {
+		return nil, err
+	}
+	grace := time.Duration(req.GracePeriod) * time.Second
+	expiresAt, err := apiKeyExpiry(req.ExpiresInDays)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := s.getKey(ctx, tenantID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if old.RevokedAt != nil || (old.ExpiresAt != nil && !now.Before(*old.ExpiresAt)) {
+		return nil, fmt.Errorf("%w: only valid keys can be rotated", ErrAPIKeyRevoked)
+	}
+
+	if old.Signing && s.signing == nil {
+		return nil, fmt.Errorf("%w: request signing is not configured", ErrInvalidAPIKeyRequest)
+	}
+
+	key, token, err := newAPIKey(tenantID, old.Name, old.Scopes, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	key.Signing = old.Signing
+	key.RotatedFromID = &old.ID
+
+	var retireAt *time.Time
+	if grace > 0 {
+		retire := now.Add(grace)
+		if old.ExpiresAt != nil && old.ExpiresAt.Before(retire) {
+			retire = *old.ExpiresAt
+		}
+		retireAt = &retire
+	}
+	if err := s.repo.Rotate(ctx, old, key, retireAt); err != nil {
+		return nil, err
+	}
+	s.forget(old.KeyHash)
+	s.recordAudit(ctx, tenantID, "apikey.rotate", old.ID, ipAddress, userAgent)
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("key_id", key.ID.String()).
+		Str("rotated_from_id", old.ID.String()).
+		Dur("grace_period", grace).
+		Msg("API key rotated")
+	return s.issued(key, token), nil
+}
+
+// RevokeKey revokes one of the tenant's API keys at once
+func (s *APIKeyService) RevokeKey(ctx context.Context, tenantID, keyID uuid.UUID, ipAddress, userAgent string) error {
+	key, err := s.getKey(ctx, tenantID, keyID)
+	if err != nil {
+		return err
+	}
+	if key.RevokedAt != nil {
+		return fmt.Errorf("%w: the key was revoked before", ErrAPIKeyRevoked)
+	}
+
+	if err := s.repo.Revoke(ctx, key.ID, time.Now()); err != nil {
+		return err
+	}
+	s.forget(key.KeyHash)
+	s.recordAudit(ctx, tenantID, "apikey.revoke", key.ID, ipAddress, userAgent)
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("key_id", key.ID.String()).
+		Msg("API key revoked")
+	return nil
+}
+
+// ValidateKey returns the valid API key a bearer token was issued as; signing
+// keys are not accepted as bearer tokens
+func (s *APIKeyService) ValidateKey(ctx context.Context, token string) (*models.APIKey, error) {
+	if !strings.HasPrefix(token, models.APIKeyTokenPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	hash := hashAPIKey(token)
+	now := time.Now()
+
+	s.mu.Lock()
+	cached, ok := s.cache[hash]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) && (cached.key.ExpiresAt == nil || now.Before(*cached.key.ExpiresAt)) {
+		return &cached.key, nil
+	}
+
+	key, err := s.repo.GetValidByHash(ctx, hash, now)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && key.Signing) {
+		s.forget(hash)
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Recorded when the key is read again, so at most once per cache lifetime
+	if err := s.repo.Touch(ctx, key.ID, now); err != nil {
+		log.Warn().Err(err).Str("key_id", key.ID.String()).Msg("Failed to record API key use")
+	}
+
+	s.mu.Lock()
+	s.cache[hash] = cachedAPIKey{key: *key, expires: now.Add(apiKeyCacheTTL)}
+	s.mu.Unlock()
+	return key, nil
+}
+
+// issued returns what is shown of a newly issued key: the key itself, or the
+// signing secret of a signing key
+func (s *APIKeyService) issued(key *models.APIKey, token string) *models.IssuedAPIKey {
+	if key.Signing {
+		return &models.IssuedAPIKey{APIKey: *key, SigningSecret: s.signing.secret(key.ID)}
+	}
+	return &models.IssuedAPIKey{APIKey: *key, Key: token}
+}
+
+// getKey returns one of the tenant's API keys, or ErrAPIKeyNotFound
+func (s *APIKeyService) getKey(ctx context.Context, tenantID, keyID uuid.UUID) (*models.APIKey, error) {
+	key, err := s.repo.GetByID(ctx, tenantID, keyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// forget drops a key from the validation cache
+func (s *APIKeyService) forget(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, hash)
+}
+
+// recordAudit records an API key management action
+func (s *APIKeyService) recordAudit(ctx context.Context, tenantID uuid.UUID, action string, keyID uuid.UUID, ipAddress, userAgent string) {
+	entry := &models.AuditLog{
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "api_key",
+		ResourceUID:  keyID.String(),
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Status:       "success",
+	}
+	if err := s.pacsService.recordAudit(ctx, entry); err != nil {
+		log.Error().Err(err).Str("action", action).Msg("Failed to record API key audit entry")
+	}
+}
+
+// validateKeyRotation checks the grace period and lifetime of a rotation
+func validateKeyRotation(req *models.APIKeyRotateRequest) error {
+	grace := time.Duration(req.GracePeriod) * time.Second
+	if grace < 0 || grace > maxAPIKeyGracePeriod {
+		return fmt.Errorf("%w: grace_period must be between 0 and %d seconds", ErrInvalidAPIKeyRequest, int(maxAPIKeyGracePeriod.Seconds()))
+	}
+	_, err := apiKeyExpiry(req.ExpiresInDays)
+	return err
+}
+
+// apiKeyExpiry returns when a key issued now for a number of days expires; nil
+// for 0 days
+func apiKeyExpiry(days int) (*time.Time, error) {
+	if days < 0 {
+		return nil, fmt.Errorf("%w: expires_in_days must not be negative", ErrInvalidAPIKeyRequest)
+	}
+	if days == 0 {
+		return nil, nil
+	}
+	expires := time.Now().AddDate(0, 0, days)
+	return &expires, nil
+}
+
+// newAPIKey generates a key and the record of it
+func newAPIKey(tenantID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	raw := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	token := models.APIKeyTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	return &models.APIKey{
+		TenantID:  tenantID,
+		Name:      name,
+		KeyPrefix: token[:apiKeyPrefixLength],
+		KeyHash:   hashAPIKey(token),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}, token, nil
+}
+
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
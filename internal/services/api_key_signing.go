@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidSignature is returned for a signed request whose key, timestamp,
+// nonce or signature is not accepted
+var ErrInvalidSignature = errors.New("invalid request signature")
+
+const (
+	// signatureMaxSkew is how far a signed request's timestamp may be from the
+	// connector's clock
+	signatureMaxSkew = 5 * time.Minute
+	// minNonceLength and maxNonceLength bound the nonce of a signed request
+	minNonceLength = 16
+	maxNonceLength = 128
+	// noncePruneInterval is how often expired nonces are removed
+	noncePruneInterval = time.Minute
+)
+
+// requestSigning derives the signing secrets of signing keys and remembers
+// the nonces of signed requests
+type requestSigning struct {
+	secretKey []byte
+
+	mu     sync.Mutex
+	pruned time.Time
+}
+
+// EnableRequestSigning allows API keys issued for signed requests, whose
+// signing secrets are derived from secret. Changing secret invalidates every
+// signing secret issued before.
+func (s *APIKeyService) EnableRequestSigning(secret []byte) {
+	s.signing = &requestSigning{secretKey: secret}
+}
+
+// secret returns the signing secret of a key
+func (r *requestSigning) secret(keyID uuid.UUID) string {
+	mac := hmac.New(sha256.New, r.secretKey)
+	mac.Write([]byte("api-key-signing:" + keyID.String()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignedRequest returns the valid signing key a request was signed
+// with. The signature header is t=<unix time>,n=<nonce>,v1=<hex HMAC-SHA256>
+// over the timestamp, nonce, method, request URI and the hex SHA-256 of the
+// body, one per line, keyed with the key's signing secret. The timestamp must
+// be within five minutes of now and each nonce is accepted once.
+func (s *APIKeyService) VerifySignedRequest(ctx context.Context, keyID, header, method, requestURI string, body []byte) (*models.APIKey, error) {
+	if s.signing == nil {
+		return nil, ErrInvalidSignature
+	}
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	timestamp, nonce, signature, ok := parseRequestSignature(header)
+	if !ok || len(nonce) < minNonceLength || len(nonce) > maxNonceLength {
+		return nil, ErrInvalidSignature
+	}
+	now := time.Now()
+	signedAt := time.Unix(timestamp, 0)
+	if signedAt.Before(now.Add(-signatureMaxSkew)) || signedAt.After(now.Add(signatureMaxSkew)) {
+		return nil, ErrInvalidSignature
+	}
+
+	key, err := s.repo.GetValidByID(ctx, id, now)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !key.Signing) {
+		return nil, ErrInvalidSignature
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(s.signing.secret(key.ID)))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "\n" + nonce + "\n" + method + "\n" + requestURI + "\n" + hex.EncodeToString(bodyHash[:])))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidSignature
+	}
+
+	// The nonce is kept until the timestamp could no longer be accepted
+	fresh, err := s.repo.UseNonce(ctx, key.ID, nonce, signedAt.Add(signatureMaxSkew))
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		log.Warn().Str("key_id", key.ID.String()).Msg("Rejected replayed signed request")
+		return nil, ErrInvalidSignature
+	}
+	s.pruneNonces(ctx, now)
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyCacheTTL {
+		if err := s.repo.Touch(ctx, key.ID, now); err != nil {
+			log.Warn().Err(err).Str("key_id", key.ID.String()).Msg("Failed to record API key use")
+		}
+	}
+	return key, nil
+}
+
+// pruneNonces removes the expired nonces, at most every noncePruneInterval
+func (s *APIKeyService) pruneNonces(ctx context.Context, now time.Time) {
+	s.signing.mu.Lock()
+	if now.Sub(s.signing.pruned) < noncePruneInterval {
+		s.signing.mu.Unlock()
+		return
+	}
+	s.signing.pruned = now
+	s.signing.mu.Unlock()
+
+	if _, err := s.repo.PruneNonces(ctx, now); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune request nonces")
+	}
+}
+
+// parseRequestSignature parses a t=<unix time>,n=<nonce>,v1=<hex> signature header
+func parseRequestSignature(header string) (timestamp int64, nonce string, signature []byte, ok bool) {
+	var err error
+	for _, part := range strings.Split(header, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return 0, "", nil, false
+		}
+		switch name {
+		case "t":
+			if timestamp, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return 0, "", nil, false
+			}
+		case "n":
+			nonce = value
+		case "v1":
+			if signature, err = hex.DecodeString(value); err != nil {
+				return 0, "", nil, false
+			}
+		}
+	}
+	return timestamp, nonce, signature, timestamp != 0 && nonce != "" && len(signature) > 0
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidArchiveRequest is returned when an archive management request is incomplete
+var ErrInvalidArchiveRequest = errors.New("invalid archive request")
+
+// archiveManager returns the archive extensions of the tenant's primary PACS
+func (s *PACSService) archiveManager(ctx context.Context, tenantID uuid.UUID) (adapters.ArchiveManager, error) {
+	adapter, err := s.GetAdapter(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, ok := adapters.Unwrap(adapter).(adapters.ArchiveManager)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return manager, nil
+}
+
+// RejectObjects rejects a study, series or instance with a rejection note and
+// invalidates every cached object belonging to the study
+func (s *PACSService) RejectObjects(ctx context.Context, tenantID uuid.UUID, req models.RejectionRequest, ipAddress, userAgent string) error {
+	if req.StudyUID == "" || (req.InstanceUID != "" && req.SeriesUID == "") {
+		return fmt.Errorf("%w: study UID is required, and series UID when rejecting an instance", ErrInvalidArchiveRequest)
+	}
+	if _, ok := adapters.RejectionCode(req.Reason); !ok {
+		return fmt.Errorf("%w: unknown rejection reason %q", ErrInvalidArchiveRequest, req.Reason)
+	}
+
+	start := time.Now()
+	manager, err := s.archiveManager(ctx, tenantID)
+	if err == nil {
+		err = manager.Reject(ctx, req)
+	}
+	s.recordArchiveAudit(ctx, tenantID, "study.reject", "study", archiveResourceUID(req.StudyUID, req.SeriesUID, req.InstanceUID), ipAddress, userAgent, start, err)
+
+	if err != nil {
+		return fmt.Errorf("failed to reject objects: %w", err)
+	}
+
+	pattern := s.cacheKey(ctx, tenantID, req.StudyUID, "", "", "*")
+	if err := s.cache.Clear(ctx, pattern); err != nil {
+		log.Warn().Err(err).Str("study_uid", req.StudyUID).Msg("Failed to invalidate cache for rejected study")
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("study_uid", req.StudyUID).
+		Str("series_uid", req.SeriesUID).
+		Str("instance_uid", req.InstanceUID).
+		Str("reason", req.Reason).
+		Msg("Objects rejected")
+
+	return nil
+}
+
+// ExportObjects schedules a study, series or instance on one of the archive's exporters
+func (s *PACSService) ExportObjects(ctx context.Context, tenantID uuid.UUID, req models.ExportRequest, ipAddress, userAgent string) error {
+	if req.StudyUID == "" || req.ExporterID == "" || (req.InstanceUID != "" && req.SeriesUID == "") {
+		return fmt.Errorf("%w: study UID and exporter ID are required, and series UID when exporting an instance", ErrInvalidArchiveRequest)
+	}
+
+	start := time.Now()
+	manager, err := s.archiveManager(ctx, tenantID)
+	if err == nil {
+		err = manager.Export(ctx, req)
+	}
+	s.recordArchiveAudit(ctx, tenantID, "study.export", "study", archiveResourceUID(req.StudyUID, req.SeriesUID, req.InstanceUID), ipAddress, userAgent, start, err)
+
+	if err != nil {
+		return fmt.Errorf("failed to export objects: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("study_uid", req.StudyUID).
+		Str("exporter_id", req.ExporterID).
+		Msg("Export scheduled")
+
+	return nil
+}
+
+// MergePatients merges a prior patient into the target patient
+func (s *PACSService) MergePatients(ctx context.Context, tenantID uuid.UUID, req models.PatientMergeRequest, ipAddress, userAgent string) error {
+	if req.PatientID == "" || req.PriorPatientID == "" {
+		return fmt.Errorf("%w: patient ID and prior patient ID are required", ErrInvalidArchiveRequest)
+	}
+	if req.PatientID == req.PriorPatientID && req.IssuerOfPatient == req.PriorIssuer {
+		return fmt.Errorf("%w: a patient cannot be merged into itself", ErrInvalidArchiveRequest)
+	}
+
+	start := time.Now()
+	manager, err := s.archiveManager(ctx, tenantID)
+	if err == nil {
+		err = manager.MergePatients(ctx, req)
+	}
+	s.recordArchiveAudit(ctx, tenantID, "patient.merge", "patient", req.PriorPatientID, ipAddress, userAgent, start, err)
+
+	if err != nil {
+		return fmt.Errorf("failed to merge patients: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("patient_id", req.PatientID).
+		Str("prior_patient_id", req.PriorPatientID).
+		Msg("Patients merged")
+
+	return nil
+}
+
+// recordArchiveAudit records the outcome of an archive management operation
+func (s *PACSService) recordArchiveAudit(ctx context.Context, tenantID uuid.UUID, action, resourceType, resourceUID, ipAddress, userAgent string, start time.Time, err error) {
+	entry := &models.AuditLog{
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceUID:  resourceUID,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Status:       "success",
+		Duration:     time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.Status = "failure"
+		entry.ErrorMessage = err.Error()
+	}
+	if auditErr := s.recordAudit(ctx, entry); auditErr != nil {
+		log.Error().Err(auditErr).Str("action", action).Msg("Failed to record archive audit entry")
+	}
+}
+
+// archiveResourceUID returns the UID of the most specific object addressed
+func archiveResourceUID(studyUID, seriesUID, instanceUID string) string {
+	switch {
+	case instanceUID != "":
+		return instanceUID
+	case seriesUID != "":
+		return seriesUID
+	default:
+		return studyUID
+	}
+}
+
+// reindexTimeout bounds a background object store indexing run
+const reindexTimeout = 6 * time.Hour
+
+// ReindexObjectStore starts indexing the objects of the tenant's primary object
+// store PACS in the background. The outcome is logged and audited.
+func (s *PACSService) ReindexObjectStore(ctx context.Context, tenantID uuid.UUID, ipAddress, userAgent string) error {
+	config, adapter, err := s.getPrimary(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	indexer, ok := adapters.Unwrap(adapter).(adapters.ObjectIndexer)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	// Keep the adapter open while it indexes
+	release := adapters.Hold(adapter)
+	go func() {
+		defer release()
+		ctx, cancel := context.WithTimeout(context.Background(), reindexTimeout)
+		defer cancel()
+
+		start := time.Now()
+		result, err := indexer.Reindex(ctx)
+		s.recordArchiveAudit(ctx, tenantID, "pacs.reindex", "pacs_config", config.ID.String(), ipAddress, userAgent, start, err)
+
+		event := log.Info()
+		if err != nil {
+			event = log.Error().Err(err)
+		}
+		if result != nil {
+			event = event.
+				Int("scanned", result.Scanned).
+				Int("indexed", result.Indexed).
+				Int("skipped", result.Skipped).
+				Int("failed", result.Failed)
+		}
+		event.
+			Str("tenant_id", tenantID.String()).
+			Str("config_id", config.ID.String()).
+			Dur("duration", time.Since(start)).
+			Msg("Object store reindex finished")
+	}()
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/atna"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// auditQueueSize bounds the entries waiting to be written; entries
+	// recorded while the queue is full are dropped and counted
+	auditQueueSize = 10000
+	// auditBatchSize is the most entries written in one insert
+	auditBatchSize = 500
+	// auditFlushInterval is how long entries wait for a batch to fill
+	auditFlushInterval = 2 * time.Second
+	// auditPruneInterval is how often entries past their retention are removed
+	auditPruneInterval = time.Hour
+)
+
+// AuditConfig configures the writing of audit log entries
+type AuditConfig struct {
+	Retention time.Duration // how long entries are kept; 0 keeps them
+}
+
+// AuditService writes audit log entries in the background, so recording the
+// access to a study never holds up the request making it. Entries are queued
+// without blocking and inserted in batches.
+type AuditService struct {
+	repo   *repository.AuditRepository
+	config AuditConfig
+	atna   *atna.Emitter
+
+	entries chan models.AuditLog
+	dropped atomic.Int64 // entries dropped since the last warning
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewAuditService creates an audit service; call Start to run its writer
+func NewAuditService(repo *repository.AuditRepository, config AuditConfig) *AuditService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AuditService{
+		repo:    repo,
+		config:  config,
+		entries: make(chan models.AuditLog, auditQueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start launches the writer
+func (s *AuditService) Start() {
+	s.wg.Add(1)
+	go s.run()
+}
+
+// Stop waits for the writer to exit after writing the entries still queued
+func (s *AuditService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// EnableATNA reports the entries of queries, retrievals and exports to the
+// hospital's Audit Record Repository through emitter as well
+func (s *AuditService) EnableATNA(emitter *atna.Emitter) {
+	s.atna = emitter
+}
+
+// Record queues an audit log entry without blocking
+func (s *AuditService) Record(entry models.AuditLog) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if s.atna != nil {
+		s.atna.Emit(entry)
+	}
+	select {
+	case s.entries <- entry:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+func (s *AuditService) run() {
+	defer s.wg.Done()
+
+	flush := time.NewTicker(auditFlushInterval)
+	defer flush.Stop()
+	prune := time.NewTicker(auditPruneInterval)
+	defer prune.Stop()
+
+	batch := make([]models.AuditLog, 0, auditBatchSize)
+	for {
+		select {
+		case <-s.ctx.Done():
+			// Write what was recorded before shutdown
+			for {
+				select {
+				case entry := <-s.entries:
+					batch = append(batch, entry)
+					if len(batch) == auditBatchSize {
+						batch = s.write(batch)
+					}
+				default:
+					s.write(batch)
+					return
+				}
+			}
+		case entry := <-s.entries:
+			batch = append(batch, entry)
+			if len(batch) == auditBatchSize {
+				batch = s.write(batch)
+			}
+		case <-flush.C:
+			batch = s.write(batch)
+		case <-prune.C:
+			s.prune()
+		}
+	}
+}
+
+// write inserts a batch of entries and returns the emptied batch. A batch that
+// fails to insert is logged entry by entry, so the access it recorded is not
+// lost from the logs as well.
+func (s *AuditService) write(batch []models.AuditLog) []models.AuditLog {
+	if dropped := s.dropped.Swap(0); dropped > 0 {
+		log.Error().Int64("dropped", dropped).Msg("Audit queue full, entries were not recorded")
+	}
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.repo.CreateBatch(ctx, batch); err != nil {
+		log.Error().Err(err).Int("entries", len(batch)).Msg("Failed to write audit log entries")
+		for _, entry := range batch {
+			log.Warn().
+				Str("tenant_id", entry.TenantID.String()).
+				Str("user", entry.User).
+				Str("action", entry.Action).
+				Str("resource_uid", entry.ResourceUID).
+				Str("status", entry.Status).
+				Time("timestamp", entry.CreatedAt).
+				Msg("Unwritten audit log entry")
+		}
+	}
+	return batch[:0]
+}
+
+// prune removes the entries recorded before the retention period
+func (s *AuditService) prune() {
+	if s.config.Retention <= 0 {
+		return
+	}
+
+	deleted, err := s.repo.DeleteBefore(s.ctx, time.Now().Add(-s.config.Retention))
+	if err != nil {
+		if s.ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to prune audit log")
+		}
+		return
+	}
+	if deleted > 0 {
+		log.Debug().Int64("deleted", deleted).Msg("Pruned audit log")
+	}
+}
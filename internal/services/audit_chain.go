@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// auditChainPageSize is how many audit log entries are read at once to verify
+// a chain
+const auditChainPageSize = 1000
+
+// VerifyAuditChain checks a tenant's audit chain up to its current head: each
+// entry must follow the one before it, name that entry's hash as its previous
+// hash and still hash to its own hash, and the last entry must be the head. The
+// first entry follows the last one the retention removed, or starts the chain
+// when none was.
+// Entries recorded while verification runs are left to the next one.
+func (s *PACSService) VerifyAuditChain(ctx context.Context, tenantID uuid.UUID) (*models.AuditChainVerification, error) {
+	result := &models.AuditChainVerification{TenantID: tenantID, Valid: true, VerifiedAt: time.Now()}
+	head, err := s.auditRepo.GetChainHead(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return result, nil
+	}
+	result.HeadSequence = head.Sequence
+	result.HeadHash = head.Hash
+	result.PrunedSequence = head.PrunedSequence
+
+	broken := func(sequence int64, reason string) (*models.AuditChainVerification, error) {
+		result.Valid = false
+		result.BrokenAt = sequence
+		result.Reason = reason
+		log.Warn().
+			Str("tenant_id", tenantID.String()).
+			Int64("sequence", sequence).
+			Str("reason", reason).
+			Msg("Audit chain verification failed")
+		return result, nil
+	}
+
+	prevSequence, prevHash := head.PrunedSequence, head.PrunedHash
+	for {
+		entries, err := s.auditRepo.GetChainRange(ctx, tenantID, prevSequence, head.Sequence, auditChainPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for i := range entries {
+			entry := &entries[i]
+			if result.Entries == 0 {
+				result.FirstSequence = entry.Sequence
+			}
+			switch {
+			case entry.Sequence != prevSequence+1:
+				return broken(prevSequence+1, "the entry is missing")
+			case entry.PrevHash != prevHash:
+				return broken(entry.Sequence, "the previous hash does not match the previous entry")
+			}
+			if entry.ChainHash() != entry.Hash {
+				return broken(entry.Sequence, "the entry does not match its hash")
+			}
+			prevSequence, prevHash = entry.Sequence, entry.Hash
+			result.Entries++
+			result.LastSequence = entry.Sequence
+		}
+		if len(entries) < auditChainPageSize {
+			break
+		}
+	}
+
+	switch {
+	case prevSequence != head.Sequence:
+		return broken(prevSequence+1, "entries are missing from the end of the chain")
+	case prevHash != head.Hash:
+		return broken(head.Sequence, "the last entry does not match the chain head")
+	}
+	return result, nil
+}
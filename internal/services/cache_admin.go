@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/cache"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidCachePurge is returned when a cache purge has no scope or names an unknown resource type
+var ErrInvalidCachePurge = errors.New("invalid cache purge")
+
+// cacheResourcePatterns match the key suffixes of each cached resource type
+// below a tenant, study or series scope
+var cacheResourcePatterns = map[models.CacheResource][]string{
+	models.CacheResourceInstance:  {"*:instance", "*:instance:*"},
+	models.CacheResourceMetadata:  {"*metadata"},
+	models.CacheResourceQuery:     {"*query:*"},
+	models.CacheResourceThumbnail: {"*thumbnail:*"},
+	models.CacheResourceExists:    {"*exists"},
+	models.CacheResourceMissing:   {"*missing"},
+}
+
+// PurgeCache clears cached entries of a tenant, e.g. after a correction on the
+// PACS side. Purges of a study also clear the tenant's cached study searches,
+// which may list it.
+func (s *PACSService) PurgeCache(ctx context.Context, tenantID uuid.UUID, req models.CachePurgeRequest, ipAddress, userAgent string) error {
+	switch {
+	case req.StudyUID == "" && req.SeriesUID != "":
+		return fmt.Errorf("%w: series UID requires a study UID", ErrInvalidCachePurge)
+	case req.StudyUID == "" && !req.PurgeTenant:
+		return fmt.Errorf("%w: give a study UID, or purge_tenant to clear the whole tenant", ErrInvalidCachePurge)
+	}
+
+	suffixes := []string{"*"}
+	if req.Resource != "" {
+		var ok bool
+		if suffixes, ok = cacheResourcePatterns[req.Resource]; !ok {
+			return fmt.Errorf("%w: unknown resource type %q", ErrInvalidCachePurge, req.Resource)
+		}
+	}
+
+	// Keys are laid out as tenant:generation:study:series:... (see cache.CacheKey).
+	// Whole-tenant purges also clear entries of previous generations.
+	prefix := s.cachePrefix(ctx, tenantID)
+	scope := tenantID.String() + ":*"
+	if req.StudyUID != "" {
+		scope = prefix + ":" + req.StudyUID
+	}
+	if req.SeriesUID != "" {
+		scope += ":" + req.SeriesUID
+	}
+
+	patterns := make([]string, 0, len(suffixes)+1)
+	for _, suffix := range suffixes {
+		patterns = append(patterns, scope+":"+suffix)
+	}
+	if req.StudyUID != "" && (req.Resource == "" || req.Resource == models.CacheResourceQuery) {
+		patterns = append(patterns, studySearchCachePattern(prefix))
+	}
+
+	start := time.Now()
+	var err error
+	for _, pattern := range patterns {
+		if clearErr := s.cache.Clear(ctx, pattern); clearErr != nil {
+			err = fmt.Errorf("failed to clear cache entries matching %s: %w", pattern, clearErr)
+			break
+		}
+	}
+	s.recordArchiveAudit(ctx, tenantID, "cache.purge", "cache", archiveResourceUID(req.StudyUID, req.SeriesUID, ""), ipAddress, userAgent, start, err)
+	if err != nil {
+		return err
+	}
+	// Purged query results are not answered from the index either
+	if req.StudyUID != "" && (req.Resource == "" || req.Resource == models.CacheResourceQuery) {
+		s.forgetIndexedStudy(ctx, tenantID, req.StudyUID, true)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("study_uid", req.StudyUID).
+		Str("series_uid", req.SeriesUID).
+		Str("resource", string(req.Resource)).
+		Strs("patterns", patterns).
+		Msg("Cache purged")
+
+	return nil
+}
+
+// CacheStats returns a tenant's cache hit, miss, set and eviction counts per
+// tier. Without statistics recorded on the cache the tier list is empty.
+func (s *PACSService) CacheStats(ctx context.Context, tenantID uuid.UUID) *models.CacheStats {
+	result := &models.CacheStats{TenantID: tenantID.String(), Tiers: []models.CacheTierStats{}}
+
+	instrumented, ok := s.cache.(interface{ Stats() *cache.Stats })
+	if !ok {
+		return result
+	}
+	for _, tier := range instrumented.Stats().Tenant(tenantID.String()) {
+		stats := models.CacheTierStats{
+			Tier:      tier.Tier,
+			Hits:      tier.Hits,
+			Misses:    tier.Misses,
+			HitRatio:  tier.HitRatio(),
+			Sets:      tier.Sets,
+			Evictions: tier.Evictions,
+		}
+		if tier.BytesStored >= 0 {
+			bytes := tier.BytesStored
+			stats.BytesStored = &bytes
+		}
+		result.Tiers = append(result.Tiers, stats)
+	}
+	return result
+}
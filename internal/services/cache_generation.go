@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/cache"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// primaryConfigTTL is how long a tenant's primary PACS config and cache
+// generation are kept for cache keys, lifetimes and quotas before they are
+// read again. A config change made through another connector instance takes
+// effect here within this time.
+const primaryConfigTTL = 30 * time.Second
+
+// cachedPrimaryConfig is a tenant's primary PACS config as last read; config is
+// nil when the tenant has none
+type cachedPrimaryConfig struct {
+	config  *models.PACSConfig
+	expires time.Time
+}
+
+// cachedGeneration is a tenant's cache generation as last read
+type cachedGeneration struct {
+	generation int64
+	expires    time.Time
+}
+
+// newCacheGeneration returns a new cache generation for a tenant
+func newCacheGeneration() int64 {
+	return time.Now().UnixNano()
+}
+
+// cachedPrimary returns the tenant's primary PACS config, read at most every
+// primaryConfigTTL; it is nil when the tenant has none or it cannot be read
+func (s *PACSService) cachedPrimary(ctx context.Context, tenantID uuid.UUID) *models.PACSConfig {
+	now := time.Now()
+	s.primaryMu.Lock()
+	cached, ok := s.primaries[tenantID]
+	s.primaryMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.config
+	}
+
+	config, err := s.pacsRepo.GetPrimaryByTenantID(ctx, tenantID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			// Keep the last known config while the database is unavailable
+			log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to read primary PACS config for cache")
+			return cached.config
+		}
+		config = nil
+	}
+
+	s.primaryMu.Lock()
+	s.primaries[tenantID] = cachedPrimaryConfig{config: config, expires: now.Add(primaryConfigTTL)}
+	s.primaryMu.Unlock()
+	return config
+}
+
+// forgetPrimary drops the kept primary config of a tenant after it changed
+func (s *PACSService) forgetPrimary(tenantID uuid.UUID) {
+	s.primaryMu.Lock()
+	defer s.primaryMu.Unlock()
+
+	delete(s.primaries, tenantID)
+}
+
+// cacheGeneration returns the tenant's cache generation, read at most every
+// primaryConfigTTL; a new generation starts whenever one of the tenant's PACS
+// configs is created, changed, activated, deactivated, deleted or restored
+func (s *PACSService) cacheGeneration(ctx context.Context, tenantID uuid.UUID) int64 {
+	now := time.Now()
+	s.primaryMu.Lock()
+	cached, ok := s.generations[tenantID]
+	s.primaryMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.generation
+	}
+
+	var generation int64
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	switch {
+	case err == nil:
+		generation = tenant.CacheGeneration
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		// Keep the last known generation while the database is unavailable
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to read tenant cache generation")
+		return cached.generation
+	}
+
+	s.primaryMu.Lock()
+	s.generations[tenantID] = cachedGeneration{generation: generation, expires: now.Add(primaryConfigTTL)}
+	s.primaryMu.Unlock()
+	return generation
+}
+
+// startCacheGeneration starts a new generation of the tenant's cache keys after
+// one of its PACS configs changed, so objects cached through the previous
+// configs are no longer served and age out with their TTL
+func (s *PACSService) startCacheGeneration(ctx context.Context, tenantID uuid.UUID) error {
+	if err := s.tenantRepo.SetCacheGeneration(ctx, tenantID, newCacheGeneration()); err != nil {
+		return err
+	}
+
+	s.primaryMu.Lock()
+	defer s.primaryMu.Unlock()
+	delete(s.generations, tenantID)
+	return nil
+}
+
+// cacheKey returns the cache key of a tenant's object at the current generation
+func (s *PACSService) cacheKey(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, instanceUID, suffix string) string {
+	return cache.CacheKey(tenantID.String(), s.cacheGeneration(ctx, tenantID), studyUID, seriesUID, instanceUID, suffix)
+}
+
+// cachePrefix returns the prefix of the tenant's cache keys at the current generation
+func (s *PACSService) cachePrefix(ctx context.Context, tenantID uuid.UUID) string {
+	return cache.TenantPrefix(tenantID.String(), s.cacheGeneration(ctx, tenantID))
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidMetricsPeriod is returned for a cache metrics query whose period ends before it starts
+var ErrInvalidMetricsPeriod = errors.New("invalid cache metrics period")
+
+const (
+	// cacheMetricsQueueSize bounds the reads waiting to be written; reads
+	// recorded while the queue is full are dropped
+	cacheMetricsQueueSize = 10000
+	// cacheMetricsBatchSize is the most reads written in one insert
+	cacheMetricsBatchSize = 500
+	// cacheMetricsFlushInterval is how long reads wait for a batch to fill
+	cacheMetricsFlushInterval = 5 * time.Second
+	// cacheMetricsPruneInterval is how often metrics past their retention are removed
+	cacheMetricsPruneInterval = time.Hour
+	// maxCacheMetricsKeyLength is the length of the cache_key column
+	maxCacheMetricsKeyLength = 500
+)
+
+// CacheMetricsConfig configures the recording of cache reads
+type CacheMetricsConfig struct {
+	Retention time.Duration // how long recorded reads are kept; 0 keeps them
+}
+
+// CacheMetricsService records the reads of the cache in the database and
+// aggregates them per tenant. Reads are queued without blocking the reader and
+// written in batches by a background worker.
+type CacheMetricsService struct {
+	repo   *repository.CacheMetricsRepository
+	config CacheMetricsConfig
+
+	records chan models.CacheMetrics
+	dropped atomic.Int64 // reads dropped since the last warning
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewCacheMetricsService creates a cache metrics service; call Start to run its writer
+func NewCacheMetricsService(repo *repository.CacheMetricsRepository, config CacheMetricsConfig) *CacheMetricsService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &CacheMetricsService{
+		repo:    repo,
+		config:  config,
+		records: make(chan models.CacheMetrics, cacheMetricsQueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start launches the writer
+func (s *CacheMetricsService) Start() {
+	s.wg.Add(1)
+	go s.run()
+}
+
+// Stop waits for the writer to exit after writing the reads still queued
+func (s *CacheMetricsService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// RecordRead queues a cache read. Keys that do not start with a tenant ID are
+// not recorded.
+func (s *CacheMetricsService) RecordRead(key, tier string, hit bool, size int64, duration time.Duration) {
+	prefix, _, _ := strings.Cut(key, ":")
+	tenantID, err := uuid.Parse(prefix)
+	if err != nil {
+		return
+	}
+	if len(key) > maxCacheMetricsKeyLength {
+		key = key[:maxCacheMetricsKeyLength]
+	}
+
+	record := models.CacheMetrics{
+		TenantID:  tenantID,
+		CacheKey:  key,
+		CacheHit:  hit,
+		CacheTier: tier,
+		Size:      size,
+		Duration:  duration.Milliseconds(),
+		CreatedAt: time.Now(),
+	}
+	select {
+	case s.records <- record:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// GetCacheMetrics aggregates the tenant's cache reads recorded in [from, to) per tier
+func (s *CacheMetricsService) GetCacheMetrics(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*models.CacheMetricsSummary, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidMetricsPeriod
+	}
+
+	tiers, err := s.repo.AggregateByTier(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tiers {
+		if tiers[i].Reads > 0 {
+			tiers[i].HitRatio = float64(tiers[i].Hits) / float64(tiers[i].Reads)
+		}
+	}
+	if tiers == nil {
+		tiers = []models.CacheTierMetrics{}
+	}
+
+	return &models.CacheMetricsSummary{
+		TenantID: tenantID.String(),
+		From:     from,
+		To:       to,
+		Tiers:    tiers,
+	}, nil
+}
+
+func (s *CacheMetricsService) run() {
+	defer s.wg.Done()
+
+	flush := time.NewTicker(cacheMetricsFlushInterval)
+	defer flush.Stop()
+	prune := time.NewTicker(cacheMetricsPruneInterval)
+	defer prune.Stop()
+
+	batch := make([]models.CacheMetrics, 0, cacheMetricsBatchSize)
+	for {
+		select {
+		case <-s.ctx.Done():
+			// Write what was recorded before shutdown
+			for {
+				select {
+				case record := <-s.records:
+					batch = append(batch, record)
+					if len(batch) == cacheMetricsBatchSize {
+						batch = s.write(batch)
+					}
+				default:
+					s.write(batch)
+					return
+				}
+			}
+		case record := <-s.records:
+			batch = append(batch, record)
+			if len(batch) == cacheMetricsBatchSize {
+				batch = s.write(batch)
+			}
+		case <-flush.C:
+			batch = s.write(batch)
+		case <-prune.C:
+			s.prune()
+		}
+	}
+}
+
+// write inserts a batch of reads and returns the emptied batch. A batch that
+// fails to insert is dropped, as metrics are not worth holding up the cache for.
+func (s *CacheMetricsService) write(batch []models.CacheMetrics) []models.CacheMetrics {
+	if dropped := s.dropped.Swap(0); dropped > 0 {
+		log.Warn().Int64("dropped", dropped).Msg("Cache metrics queue full, reads were not recorded")
+	}
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.repo.CreateBatch(ctx, batch); err != nil {
+		log.Error().Err(err).Int("reads", len(batch)).Msg("Failed to record cache metrics")
+	}
+	return batch[:0]
+}
+
+// prune removes the reads recorded before the retention period
+func (s *CacheMetricsService) prune() {
+	if s.config.Retention <= 0 {
+		return
+	}
+
+	deleted, err := s.repo.DeleteBefore(s.ctx, time.Now().Add(-s.config.Retention))
+	if err != nil {
+		if s.ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to prune cache metrics")
+		}
+		return
+	}
+	if deleted > 0 {
+		log.Debug().Int64("deleted", deleted).Msg("Pruned cache metrics")
+	}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/cache"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// CacheQuota returns the cache quota of a tenant: the deployment default with
+// the overrides of its primary PACS config. It serves as the cache.QuotaFunc
+// of a cache.QuotaCache; keys that are not tenant IDs have no quota.
+func (s *PACSService) CacheQuota(ctx context.Context, tenant string) cache.Quota {
+	tenantID, err := uuid.Parse(tenant)
+	if err != nil {
+		return cache.Quota{}
+	}
+
+	quota := s.cacheTTLs.TenantQuota
+	if config := s.cachedPrimary(ctx, tenantID); config != nil {
+		quota = tenantCacheQuotaOf(config, quota)
+	}
+	return quota
+}
+
+// tenantCacheQuotaOf applies the quota overrides of a PACS config to the default
+func tenantCacheQuotaOf(config *models.PACSConfig, quota cache.Quota) cache.Quota {
+	switch {
+	case config.CacheQuotaMB < 0:
+		quota.MaxBytes = 0
+	case config.CacheQuotaMB > 0:
+		quota.MaxBytes = int64(config.CacheQuotaMB) << 20
+	}
+	switch {
+	case config.CacheQuotaObjects < 0:
+		quota.MaxObjects = 0
+	case config.CacheQuotaObjects > 0:
+		quota.MaxObjects = config.CacheQuotaObjects
+	}
+	return quota
+}
@@ -0,0 +1,500 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrVerificationNotFound is returned when a cache verification doesn't exist
+	ErrVerificationNotFound = errors.New("cache verification not found")
+	// ErrInvalidVerificationRequest is returned when a verification request is malformed
+	ErrInvalidVerificationRequest = errors.New("invalid cache verification request")
+	// ErrVerificationBusy is returned when a verification cannot be queued yet
+	ErrVerificationBusy = errors.New("cache verification busy")
+)
+
+const (
+	// maxVerificationLookbackDays is the most recent days one verification covers
+	maxVerificationLookbackDays = 31
+	// verificationQueueSize is how many verifications wait for the worker
+	verificationQueueSize = 100
+	// maxDiscrepancyUIDs is how many instance UIDs a discrepancy lists
+	maxDiscrepancyUIDs = 100
+)
+
+// VerificationConfig configures cache verifications
+type VerificationConfig struct {
+	Interval     time.Duration // how often every tenant's cache is verified; 0 only verifies on request
+	LookbackDays int           // default recent days whose studies are checked
+	Repair       bool          // whether scheduled verifications repair what they find
+}
+
+// VerificationService compares the cache with the PACS for the studies of a
+// tenant's recent days. For each study whose instances or metadata are cached
+// it lists the study's instances on the PACS and flags instances missing from
+// the cache and cached metadata listing other instances than the PACS. A
+// repairing verification fetches the missing instances again and drops the
+// stale metadata. Verifications run one at a time in the background.
+type VerificationService struct {
+	pacsService *PACSService
+	pacsRepo    *repository.PACSRepository
+	repo        *repository.VerificationRepository
+	config      VerificationConfig
+
+	queue chan *models.CacheVerification
+
+	mu      sync.Mutex
+	tenants map[uuid.UUID]bool // tenants with a verification queued or running on this instance
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewVerificationService creates a cache verification service; call Start to
+// run its worker and schedule
+func NewVerificationService(pacsService *PACSService, pacsRepo *repository.PACSRepository, repo *repository.VerificationRepository, config VerificationConfig) *VerificationService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &VerificationService{
+		pacsService: pacsService,
+		pacsRepo:    pacsRepo,
+		repo:        repo,
+		config:      config,
+		queue:       make(chan *models.CacheVerification, verificationQueueSize),
+		tenants:     make(map[uuid.UUID]bool),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start launches the worker and, with an interval, the schedule
+func (v *VerificationService) Start() {
+	v.wg.Add(1)
+	go v.work()
+
+	if v.config.Interval > 0 {
+		v.wg.Add(1)
+		go v.schedule()
+	}
+}
+
+// Stop cancels a running verification, waits for the worker to exit and fails
+// the verifications that did not finish, which do not survive a restart
+func (v *VerificationService) Stop() {
+	v.cancel()
+	v.wg.Wait()
+
+	var unfinished []uuid.UUID
+drain:
+	for {
+		select {
+		case verification := <-v.queue:
+			unfinished = append(unfinished, verification.ID)
+		default:
+			break drain
+		}
+	}
+	if err := v.repo.FailUnfinished(context.Background(), unfinished, "the connector shut down before the verification finished"); err != nil {
+		log.Error().Err(err).Msg("Failed to fail queued cache verifications")
+	}
+}
+
+// VerifyCache queues a verification of the tenant's cache. A tenant has one
+// verification queued or running at a time.
+func (v *VerificationService) VerifyCache(ctx context.Context, tenantID uuid.UUID, req *models.CacheVerificationRequest) (*models.CacheVerification, error) {
+	lookback := req.LookbackDays
+	if lookback == 0 {
+		lookback = v.config.LookbackDays
+	}
+	if lookback < 1 || lookback > maxVerificationLookbackDays {
+		return nil, fmt.Errorf("%w: lookback_days must be between 1 and %d", ErrInvalidVerificationRequest, maxVerificationLookbackDays)
+	}
+
+	return v.enqueue(ctx, &models.CacheVerification{
+		TenantID:     tenantID,
+		LookbackDays: lookback,
+		Repair:       req.Repair,
+	})
+}
+
+// GetVerification returns a verification of the tenant with the discrepancies found so far
+func (v *VerificationService) GetVerification(ctx context.Context, tenantID, id uuid.UUID) (*models.CacheVerification, error) {
+	verification, err := v.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && verification.TenantID != tenantID) {
+		return nil, ErrVerificationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return verification, nil
+}
+
+// enqueue records a pending verification and queues it for the worker
+func (v *VerificationService) enqueue(ctx context.Context, verification *models.CacheVerification) (*models.CacheVerification, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.tenants[verification.TenantID] {
+		return nil, fmt.Errorf("%w: a verification of the tenant is already queued or running", ErrVerificationBusy)
+	}
+	if len(v.queue) == cap(v.queue) {
+		return nil, fmt.Errorf("%w: too many verifications are queued; retry later", ErrVerificationBusy)
+	}
+
+	verification.Status = models.VerificationPending
+	if err := v.repo.Create(ctx, verification); err != nil {
+		return nil, err
+	}
+	v.tenants[verification.TenantID] = true
+	v.queue <- verification
+	return verification, nil
+}
+
+// schedule queues a verification of every tenant with an active primary PACS
+// config at each interval. Tenants still being verified are skipped.
+func (v *VerificationService) schedule() {
+	defer v.wg.Done()
+
+	ticker := time.NewTicker(v.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-v.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		primaries, err := v.pacsRepo.GetActivePrimaries(v.ctx)
+		if err != nil {
+			if v.ctx.Err() == nil {
+				log.Error().Err(err).Msg("Failed to list tenants for cache verification")
+			}
+			continue
+		}
+		for _, config := range primaries {
+			_, err := v.enqueue(v.ctx, &models.CacheVerification{
+				TenantID:     config.TenantID,
+				LookbackDays: v.config.LookbackDays,
+				Repair:       v.config.Repair,
+				Scheduled:    true,
+			})
+			if err != nil && !errors.Is(err, ErrVerificationBusy) && v.ctx.Err() == nil {
+				log.Error().Err(err).Str("tenant_id", config.TenantID.String()).Msg("Failed to schedule cache verification")
+			}
+		}
+	}
+}
+
+func (v *VerificationService) work() {
+	defer v.wg.Done()
+
+	for {
+		select {
+		case <-v.ctx.Done():
+			return
+		case verification := <-v.queue:
+			v.run(verification)
+
+			v.mu.Lock()
+			delete(v.tenants, verification.TenantID)
+			v.mu.Unlock()
+		}
+	}
+}
+
+// run verifies the studies of a tenant's recent days one after another,
+// recording the progress and the discrepancies found
+func (v *VerificationService) run(verification *models.CacheVerification) {
+	ctx := v.ctx
+	start := time.Now()
+	logger := log.With().
+		Str("verification_id", verification.ID.String()).
+		Str("tenant_id", verification.TenantID.String()).
+		Logger()
+
+	verification.Status = models.VerificationRunning
+	v.save(verification)
+
+	studies, err := v.pacsService.recentStudies(ctx, verification.TenantID, verification.LookbackDays)
+	if err == nil {
+		verification.TotalStudies = len(studies)
+		v.save(verification)
+
+		for _, studyUID := range studies {
+			if err = v.verifyStudy(ctx, verification, studyUID); err != nil {
+				break
+			}
+			verification.CheckedStudies++
+			v.save(verification)
+		}
+	}
+
+	now := time.Now()
+	verification.CompletedAt = &now
+	verification.Status = models.VerificationCompleted
+	if err != nil {
+		verification.Status = models.VerificationFailed
+		verification.Error = err.Error()
+		if ctx.Err() != nil {
+			verification.Error = "the connector shut down before the verification finished"
+		}
+	}
+	v.save(verification)
+
+	logger = logger.With().
+		Int("studies", verification.CheckedStudies).
+		Int("inconsistent_studies", verification.InconsistentStudies).
+		Int("missing_instances", verification.MissingInstances).
+		Int("refetched_instances", verification.RefetchedInstances).
+		Dur("duration", time.Since(start)).
+		Logger()
+	switch {
+	case err != nil && ctx.Err() == nil:
+		logger.Error().Err(err).Msg("Cache verification failed")
+	case verification.InconsistentStudies > 0:
+		logger.Warn().Msg("Cache verification found discrepancies")
+	default:
+		logger.Info().Msg("Cache verification completed")
+	}
+}
+
+// verifyStudy compares one study's cache with the PACS and records, and with
+// repair repairs, what differs. Failing to reach the PACS fails the
+// verification; a failed repair is recorded with the discrepancy.
+func (v *VerificationService) verifyStudy(ctx context.Context, verification *models.CacheVerification, studyUID string) error {
+	tenantID := verification.TenantID
+	check, err := v.pacsService.checkStudyCache(ctx, tenantID, studyUID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil // deleted since it was listed
+		}
+		return err
+	}
+	if !check.cached() {
+		return nil
+	}
+	verification.CachedStudies++
+
+	var discrepancies []*models.CacheDiscrepancy
+	if len(check.missing) > 0 {
+		missing := &models.CacheDiscrepancy{
+			Kind:            models.DiscrepancyMissingInstances,
+			PACSInstances:   len(check.instances),
+			CachedInstances: len(check.instances) - len(check.missing),
+		}
+		for _, target := range check.missing {
+			missing.MissingUIDs = appendUID(missing.MissingUIDs, target.sopInstanceUID)
+		}
+		if verification.Repair {
+			fetched, err := v.pacsService.refetchInstances(ctx, tenantID, studyUID, check.missing)
+			verification.RefetchedInstances += fetched
+			missing.Repaired = err == nil
+			if err != nil {
+				missing.Error = err.Error()
+			}
+		}
+		verification.MissingInstances += len(check.missing)
+		discrepancies = append(discrepancies, missing)
+	}
+	staleMetadata := check.metadataCached && (len(check.metadataMissing) > 0 || len(check.metadataUnexpected) > 0)
+	if staleMetadata {
+		stale := &models.CacheDiscrepancy{
+			Kind:            models.DiscrepancyStaleMetadata,
+			PACSInstances:   len(check.instances),
+			CachedInstances: check.metadataInstances,
+		}
+		for _, uid := range check.metadataMissing {
+			stale.MissingUIDs = appendUID(stale.MissingUIDs, uid)
+		}
+		for _, uid := range check.metadataUnexpected {
+			stale.UnexpectedUIDs = appendUID(stale.UnexpectedUIDs, uid)
+		}
+		if verification.Repair {
+			err := v.pacsService.dropStudyMetadata(ctx, tenantID, studyUID)
+			stale.Repaired = err == nil
+			if err != nil {
+				stale.Error = err.Error()
+			}
+		}
+		discrepancies = append(discrepancies, stale)
+	}
+	if len(discrepancies) == 0 {
+		return nil
+	}
+
+	verification.InconsistentStudies++
+	for _, discrepancy := range discrepancies {
+		discrepancy.VerificationID = verification.ID
+		discrepancy.StudyUID = studyUID
+		if err := v.repo.AddDiscrepancy(context.Background(), discrepancy); err != nil {
+			log.Error().Err(err).Str("study_uid", studyUID).Msg("Failed to record cache discrepancy")
+		}
+	}
+	log.Warn().
+		Str("tenant_id", tenantID.String()).
+		Str("study_uid", studyUID).
+		Int("pacs_instances", len(check.instances)).
+		Int("missing_instances", len(check.missing)).
+		Bool("stale_metadata", staleMetadata).
+		Msg("Cache differs from the PACS")
+	return nil
+}
+
+// save records a verification's progress
+func (v *VerificationService) save(verification *models.CacheVerification) {
+	if err := v.repo.Update(context.Background(), verification); err != nil {
+		log.Error().Err(err).Str("verification_id", verification.ID.String()).Msg("Failed to update cache verification")
+	}
+}
+
+// appendUID appends a UID to a discrepancy's list unless it is full
+func appendUID(uids []string, uid string) []string {
+	if len(uids) >= maxDiscrepancyUIDs {
+		return uids
+	}
+	return append(uids, uid)
+}
+
+// studyCacheCheck is the state of a study's cache compared with the PACS
+type studyCacheCheck struct {
+	instances        []prefetchTarget // the study's instances on the PACS
+	instancesChecked bool             // whether the tenant caches instances, so they were looked up
+	missing          []prefetchTarget // instances on the PACS that are not cached
+
+	metadataCached     bool     // whether the study's metadata is cached
+	metadataInstances  int      // instances the cached metadata lists
+	metadataMissing    []string // SOP Instance UIDs on the PACS the cached metadata lacks
+	metadataUnexpected []string // SOP Instance UIDs the cached metadata lists that are not on the PACS
+}
+
+// cached reports whether any of the study's instances or its metadata is cached
+func (c *studyCacheCheck) cached() bool {
+	return c.metadataCached || (c.instancesChecked && len(c.missing) < len(c.instances))
+}
+
+// recentStudies lists the UIDs of the tenant's studies dated in the last days
+// on the PACS its requests are routed to, up to the tenant's result cap
+func (s *PACSService) recentStudies(ctx context.Context, tenantID uuid.UUID, days int) ([]string, error) {
+	today := time.Now()
+	params := models.QueryParams{
+		StudyDate: today.AddDate(0, 0, 1-days).Format("20060102") + "-" + today.Format("20060102"),
+	}
+	config, adapter, err := s.route(withQueryHints(ctx, params), tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	studies, _, err := s.findStudiesOn(ctx, config, adapter, params)
+	if err != nil {
+		return nil, err
+	}
+	uids := make([]string, 0, len(studies))
+	for _, study := range studies {
+		if study.StudyInstanceUID != "" {
+			uids = append(uids, study.StudyInstanceUID)
+		}
+	}
+	return uids, nil
+}
+
+// checkStudyCache lists a study's instances on the PACS, bypassing the cached
+// query results, and compares them with the instances and metadata cached
+func (s *PACSService) checkStudyCache(ctx context.Context, tenantID uuid.UUID, studyUID string) (*studyCacheCheck, error) {
+	config, _, err := s.route(withStudyHint(ctx, studyUID), tenantID)
+	if err != nil {
+		return nil, err
+	}
+	series, err := s.searchSeries(ctx, tenantID, studyUID, s.cacheKey(ctx, tenantID, studyUID, "", "", "query:series"))
+	if err != nil {
+		return nil, err
+	}
+
+	check := &studyCacheCheck{instancesChecked: s.ttls(ctx, tenantID).instanceTTL(config) > 0}
+	onPACS := make(map[string]bool)
+	for _, se := range series {
+		instances, err := s.searchInstances(ctx, tenantID, studyUID, se.SeriesInstanceUID,
+			s.cacheKey(ctx, tenantID, studyUID, se.SeriesInstanceUID, "", "query:instances"))
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range instances {
+			target := prefetchTarget{studyUID: studyUID, seriesUID: se.SeriesInstanceUID, sopInstanceUID: instance.SOPInstanceUID}
+			check.instances = append(check.instances, target)
+			onPACS[instance.SOPInstanceUID] = true
+			if !check.instancesChecked {
+				continue
+			}
+
+			key := s.cacheKey(ctx, tenantID, studyUID, se.SeriesInstanceUID, instance.SOPInstanceUID, "instance")
+			cached, err := s.cache.Exists(ctx, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check the cache: %w", err)
+			}
+			if !cached {
+				check.missing = append(check.missing, target)
+			}
+		}
+	}
+
+	var metadata []models.Metadata
+	if s.getCachedJSON(ctx, s.cacheKey(ctx, tenantID, studyUID, "", "", "metadata"), &metadata) {
+		check.metadataCached = true
+		check.metadataInstances = len(metadata)
+		listed := make(map[string]bool, len(metadata))
+		for _, instance := range metadata {
+			listed[instance.SOPInstanceUID] = true
+			if !onPACS[instance.SOPInstanceUID] {
+				check.metadataUnexpected = append(check.metadataUnexpected, instance.SOPInstanceUID)
+			}
+		}
+		for _, target := range check.instances {
+			if !listed[target.sopInstanceUID] {
+				check.metadataMissing = append(check.metadataMissing, target.sopInstanceUID)
+			}
+		}
+	}
+	return check, nil
+}
+
+// refetchInstances caches instances of a study that are missing from the cache
+// and reports how many were fetched
+func (s *PACSService) refetchInstances(ctx context.Context, tenantID uuid.UUID, studyUID string, targets []prefetchTarget) (int, error) {
+	config, adapter, err := s.route(withStudyHint(ctx, studyUID), tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	ttl := s.ttls(ctx, tenantID).instanceTTL(config)
+	fetched := 0
+	for _, target := range targets {
+		added, _, err := s.prefetchInstance(ctx, tenantID, adapter, target, ttl)
+		if err != nil {
+			return fetched, err
+		}
+		if added {
+			fetched++
+		}
+	}
+	return fetched, nil
+}
+
+// dropStudyMetadata removes the cached study, series and instance metadata of
+// a study, so it is loaded from the PACS when next requested
+func (s *PACSService) dropStudyMetadata(ctx context.Context, tenantID uuid.UUID, studyUID string) error {
+	pattern := s.cachePrefix(ctx, tenantID) + ":" + studyUID + ":*metadata"
+	if err := s.cache.Clear(ctx, pattern); err != nil {
+		return fmt.Errorf("failed to clear cached metadata: %w", err)
+	}
+	return nil
+}
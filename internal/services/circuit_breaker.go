@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrCircuitOpen is returned without calling a PACS whose circuit breaker is
+// open. It is an ErrUnavailable, so it is reported and failed over like one.
+var ErrCircuitOpen = fmt.Errorf("%w: circuit breaker open", ErrUnavailable)
+
+// CircuitBreakerConfig controls the circuit breaker around each PACS
+type CircuitBreakerConfig struct {
+	Window           time.Duration // period over which the error rate is measured
+	MinRequests      int           // calls in a window before its error rate can open the circuit
+	ErrorRate        float64       // share of failed calls, 0 to 1, that opens the circuit
+	OpenDuration     time.Duration // how long calls fail fast before the PACS is probed again
+	HalfOpenRequests int           // probe calls let through at once while half-open
+}
+
+// circuitState is the state of one PACS's circuit
+type circuitState int
+
+const (
+	circuitClosed   circuitState = iota // calls go through
+	circuitHalfOpen                     // a few probe calls go through
+	circuitOpen                         // calls fail fast
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitHalfOpen:
+		return "half-open"
+	case circuitOpen:
+		return "open"
+	}
+	return "closed"
+}
+
+// circuit is the breaker state of one PACS config
+type circuit struct {
+	labels []string // tenant_id, pacs_config_id and pacs metric labels
+
+	state       circuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openUntil   time.Time
+	probes      int // probe calls in flight while half-open
+}
+
+// circuitBreakers holds the circuits of the PACS configs. State is kept per
+// connector instance.
+type circuitBreakers struct {
+	config CircuitBreakerConfig
+
+	mu       sync.Mutex
+	circuits map[uuid.UUID]*circuit
+}
+
+var (
+	circuitStateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "risconnector_pacs_circuit_state",
+		Help: "Circuit breaker state of a PACS config: closed (0), half-open (1) or open (2).",
+	}, []string{"tenant_id", "pacs_config_id", "pacs"})
+	circuitTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "risconnector_pacs_circuit_transitions_total",
+		Help: "Circuit breaker state changes of PACS configs by the state entered.",
+	}, []string{"tenant_id", "pacs_config_id", "pacs", "state"})
+	circuitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "risconnector_pacs_circuit_rejections_total",
+		Help: "PACS calls failed fast because the circuit breaker of the PACS config was open.",
+	}, []string{"tenant_id", "pacs_config_id", "pacs"})
+)
+
+// RegisterCircuitBreakerMetrics exposes the circuit breaker states, state
+// changes and fast failures on the default Prometheus registry
+func RegisterCircuitBreakerMetrics() {
+	prometheus.MustRegister(circuitStateGauge, circuitTransitions, circuitRejections)
+}
+
+// EnableCircuitBreakers stops calling a PACS for a while once too many of its
+// calls fail, so requests fail fast instead of waiting out its timeouts
+func (s *PACSService) EnableCircuitBreakers(config CircuitBreakerConfig) {
+	s.breakers = &circuitBreakers{
+		config:   config,
+		circuits: make(map[uuid.UUID]*circuit),
+	}
+}
+
+// breakAdapter wraps the adapter of a PACS config in the config's circuit
+// breaker. Federated adapters are returned as is: they answer from whichever
+// members respond.
+func (s *PACSService) breakAdapter(config *models.PACSConfig, adapter adapters.PACSAdapter) adapters.PACSAdapter {
+	if s.breakers == nil || config.Type == models.PACSTypeFederated {
+		return adapter
+	}
+	return &breakerAdapter{
+		PACSAdapter: adapter,
+		breakers:    s.breakers,
+		configID:    config.ID,
+		labels:      []string{config.TenantID.String(), config.ID.String(), config.Name},
+		name:        config.Name,
+	}
+}
+
+// forgetCircuit drops the circuit of a PACS config after the config changed,
+// so calls with the new settings start from a closed circuit
+func (s *PACSService) forgetCircuit(configID uuid.UUID) {
+	if s.breakers == nil {
+		return
+	}
+	s.breakers.mu.Lock()
+	defer s.breakers.mu.Unlock()
+
+	if c, ok := s.breakers.circuits[configID]; ok {
+		circuitStateGauge.DeleteLabelValues(c.labels...)
+		delete(s.breakers.circuits, configID)
+	}
+}
+
+// circuitFailure reports whether a call's error counts against the PACS. Errors
+// the PACS answered deliberately, and calls that never reached it or that the
+// client gave up on, do not.
+func circuitFailure(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrNotFound),
+		errors.Is(err, ErrNotSupported),
+		errors.Is(err, ErrInvalidRequest),
+		errors.Is(err, ErrTooLarge),
+		errors.Is(err, ErrRangeNotSatisfiable),
+		errors.Is(err, ErrRateLimited),
+		errors.Is(err, context.Canceled):
+		return false
+	}
+	return true
+}
+
+// allow reports whether a call to the PACS may go through, moving an open
+// circuit whose time is up to half-open
+func (b *circuitBreakers) allow(configID uuid.UUID, labels []string) bool {
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[configID]
+	if !ok {
+		c = &circuit{labels: labels, windowStart: now}
+		b.circuits[configID] = c
+	}
+
+	switch c.state {
+	case circuitOpen:
+		if now.Before(c.openUntil) {
+			circuitRejections.WithLabelValues(c.labels...).Inc()
+			return false
+		}
+		b.transition(c, circuitHalfOpen, now)
+		fallthrough
+	case circuitHalfOpen:
+		if c.probes >= b.config.HalfOpenRequests {
+			circuitRejections.WithLabelValues(c.labels...).Inc()
+			return false
+		}
+		c.probes++
+	}
+	return true
+}
+
+// record counts the outcome of a call allowed through. A failed probe opens
+// the circuit again and a successful one closes it; while closed, the circuit
+// opens once the window's error rate reaches the threshold.
+func (b *circuitBreakers) record(configID uuid.UUID, err error) {
+	now := time.Now()
+	failed := circuitFailure(err)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[configID]
+	if !ok {
+		return
+	}
+
+	switch c.state {
+	case circuitHalfOpen:
+		// A call let through before the circuit opened may end here too; its
+		// outcome is as telling as a probe's
+		if c.probes > 0 {
+			c.probes--
+		}
+		if failed {
+			b.transition(c, circuitOpen, now)
+		} else {
+			b.transition(c, circuitClosed, now)
+		}
+	case circuitClosed:
+		if now.Sub(c.windowStart) >= b.config.Window {
+			c.windowStart, c.requests, c.failures = now, 0, 0
+		}
+		c.requests++
+		if failed {
+			c.failures++
+		}
+		if c.requests >= b.config.MinRequests && float64(c.failures) >= b.config.ErrorRate*float64(c.requests) {
+			b.transition(c, circuitOpen, now)
+		}
+	}
+}
+
+// transition moves a circuit to a state; the caller holds b.mu
+func (b *circuitBreakers) transition(c *circuit, state circuitState, now time.Time) {
+	c.state = state
+	c.probes = 0
+	switch state {
+	case circuitOpen:
+		c.openUntil = now.Add(b.config.OpenDuration)
+	case circuitClosed:
+		c.windowStart, c.requests, c.failures = now, 0, 0
+	}
+
+	circuitStateGauge.WithLabelValues(c.labels...).Set(float64(state))
+	circuitTransitions.WithLabelValues(append(c.labels, state.String())...).Inc()
+	event := log.Info()
+	if state == circuitOpen {
+		event = log.Warn()
+	}
+	event.Str("tenant_id", c.labels[0]).Str("pacs", c.labels[2]).Str("state", state.String()).Msg("PACS circuit breaker changed state")
+}
+
+// breakerAdapter fails calls fast while its PACS's circuit is open and records
+// the outcome of those it lets through. Connection tests bypass the breaker, so
+// health checks still reach a PACS whose circuit is open.
+type breakerAdapter struct {
+	adapters.PACSAdapter
+	breakers *circuitBreakers
+	configID uuid.UUID
+	labels   []string
+	name     string
+}
+
+func (a *breakerAdapter) Unwrap() adapters.PACSAdapter {
+	return a.PACSAdapter
+}
+
+// call runs fn unless the circuit is open, recording its outcome
+func (a *breakerAdapter) call(fn func() error) error {
+	if !a.breakers.allow(a.configID, a.labels) {
+		return fmt.Errorf("%w: %s", ErrCircuitOpen, a.name)
+	}
+	err := fn()
+	a.breakers.record(a.configID, err)
+	return err
+}
+
+func (a *breakerAdapter) FindStudies(ctx context.Context, params models.QueryParams) (studies []models.Study, err error) {
+	err = a.call(func() error {
+		studies, err = a.PACSAdapter.FindStudies(ctx, params)
+		return err
+	})
+	return studies, err
+}
+
+func (a *breakerAdapter) FindSeries(ctx context.Context, studyUID string) (series []models.Series, err error) {
+	err = a.call(func() error {
+		series, err = a.PACSAdapter.FindSeries(ctx, studyUID)
+		return err
+	})
+	return series, err
+}
+
+func (a *breakerAdapter) FindInstances(ctx context.Context, studyUID, seriesUID string) (instances []models.Instance, err error) {
+	err = a.call(func() error {
+		instances, err = a.PACSAdapter.FindInstances(ctx, studyUID, seriesUID)
+		return err
+	})
+	return instances, err
+}
+
+func (a *breakerAdapter) ObjectExists(ctx context.Context, studyUID, seriesUID, instanceUID string) (exists bool, err error) {
+	err = a.call(func() error {
+		exists, err = a.PACSAdapter.ObjectExists(ctx, studyUID, seriesUID, instanceUID)
+		return err
+	})
+	return exists, err
+}
+
+func (a *breakerAdapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (body io.ReadCloser, contentType string, err error) {
+	err = a.call(func() error {
+		body, contentType, err = a.PACSAdapter.GetInstance(ctx, studyUID, seriesUID, instanceUID, opts)
+		return err
+	})
+	return body, contentType, err
+}
+
+func (a *breakerAdapter) GetInstanceMetadata(ctx context.Context, studyUID, seriesUID, instanceUID string) (metadata *models.Metadata, err error) {
+	err = a.call(func() error {
+		metadata, err = a.PACSAdapter.GetInstanceMetadata(ctx, studyUID, seriesUID, instanceUID)
+		return err
+	})
+	return metadata, err
+}
+
+func (a *breakerAdapter) GetSeriesMetadata(ctx context.Context, studyUID, seriesUID string) (metadata []models.Metadata, err error) {
+	err = a.call(func() error {
+		metadata, err = a.PACSAdapter.GetSeriesMetadata(ctx, studyUID, seriesUID)
+		return err
+	})
+	return metadata, err
+}
+
+func (a *breakerAdapter) GetStudyMetadata(ctx context.Context, studyUID string) (metadata []models.Metadata, err error) {
+	err = a.call(func() error {
+		metadata, err = a.PACSAdapter.GetStudyMetadata(ctx, studyUID)
+		return err
+	})
+	return metadata, err
+}
+
+func (a *breakerAdapter) GetFrame(ctx context.Context, studyUID, seriesUID, instanceUID string, frame int) (body io.ReadCloser, contentType string, err error) {
+	err = a.call(func() error {
+		body, contentType, err = a.PACSAdapter.GetFrame(ctx, studyUID, seriesUID, instanceUID, frame)
+		return err
+	})
+	return body, contentType, err
+}
+
+func (a *breakerAdapter) GetRendered(ctx context.Context, studyUID, seriesUID, instanceUID, mediaType string) (body io.ReadCloser, contentType string, err error) {
+	err = a.call(func() error {
+		body, contentType, err = a.PACSAdapter.GetRendered(ctx, studyUID, seriesUID, instanceUID, mediaType)
+		return err
+	})
+	return body, contentType, err
+}
+
+func (a *breakerAdapter) DeleteStudy(ctx context.Context, studyUID string) error {
+	return a.call(func() error {
+		return a.PACSAdapter.DeleteStudy(ctx, studyUID)
+	})
+}
+
+func (a *breakerAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) (thumb []byte, err error) {
+	err = a.call(func() error {
+		thumb, err = a.PACSAdapter.GetThumbnail(ctx, studyUID, seriesUID, instanceUID, opts)
+		return err
+	})
+	return thumb, err
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// coalesce runs fetch once for concurrent callers with the same key, so viewers
+// opening the same study together cause one upstream request. The fetch is
+// detached from the caller's cancellation, so one viewer going away does not
+// fail the others; a cancelled caller stops waiting for it. Callers share the
+// result and must not modify it.
+func (s *PACSService) coalesce(ctx context.Context, key string, fetch func(ctx context.Context) (any, error)) (any, error) {
+	result := s.inflight.DoChan(key, func() (any, error) {
+		return fetch(context.WithoutCancel(ctx))
+	})
+
+	select {
+	case res := <-result:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// instanceStreamWindow bounds how far the fastest caller of a shared instance
+// stream reads ahead of the slowest once the stream takes no new callers
+const instanceStreamWindow = 4 << 20
+
+// instanceStreamChunk is the size of each read from the PACS
+const instanceStreamChunk = 32 << 10
+
+// instanceStream is an instance retrieved once from the PACS and streamed to
+// every caller that asked for it in the meantime, so viewers opening the same
+// study together cause one upstream request without waiting for the whole
+// instance. Callers join while the stream is buffered from its start, which it
+// is until more than the cache size limit was read; after that only the bytes
+// between the slowest and the fastest caller are kept, and the fastest waits
+// for the others at instanceStreamWindow.
+type instanceStream struct {
+	ready         chan struct{} // closed once the PACS answered
+	err           error         // the PACS's error, set before ready is closed
+	contentType   string
+	contentLength int64 // -1 when unknown
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	started   bool // the PACS's response is being streamed
+	src       io.ReadCloser
+	cancel    context.CancelFunc // cancels the upstream request
+	buf       []byte             // bytes read from src, from offset base on
+	base      int64
+	maxSize   int64
+	filling   bool  // a caller is reading src
+	done      bool  // src was read to its end or failed
+	readErr   error // io.EOF once src was read to its end
+	closed    bool  // the stream takes no new callers
+	cacheable bool
+	store     func(data []byte) // caches the instance once read whole
+	readers   map[*instanceStreamReader]struct{}
+	release   func() // drops the stream once its last caller is done
+}
+
+// instanceStreamReader is a caller's view of an instance stream
+type instanceStreamReader struct {
+	stream *instanceStream
+	off    int64
+	closed bool
+}
+
+// streamInstanceShared retrieves an instance from the PACS, transcoded as
+// requested, sharing the upstream response with concurrent callers of the same
+// representation, and caches it when it was read whole within the size limit
+func (s *PACSService) streamInstanceShared(ctx context.Context, tenantID uuid.UUID, cacheKey, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
+	s.streamsMu.Lock()
+	stream := s.streams[cacheKey]
+	var reader *instanceStreamReader
+	if stream != nil {
+		reader = stream.join()
+	}
+	if reader == nil {
+		stream = &instanceStream{
+			ready:   make(chan struct{}),
+			maxSize: s.cacheTTLs.maxInstanceSize(),
+			readers: make(map[*instanceStreamReader]struct{}),
+		}
+		stream.cond = sync.NewCond(&stream.mu)
+		stream.release = func() { s.forgetStream(cacheKey, stream) }
+		reader = stream.join()
+		s.streams[cacheKey] = stream
+		// The retrieval is detached from the caller's cancellation, so one
+		// viewer going away does not fail the others
+		go s.openInstanceStream(context.WithoutCancel(ctx), stream, tenantID, cacheKey, studyUID, seriesUID, instanceUID, opts)
+	}
+	s.streamsMu.Unlock()
+
+	select {
+	case <-stream.ready:
+	case <-ctx.Done():
+		reader.Close()
+		return nil, "", ctx.Err()
+	}
+	if stream.err != nil {
+		reader.Close()
+		return nil, "", stream.err
+	}
+	return &models.InstanceBody{ReadCloser: reader, ContentLength: stream.contentLength}, stream.contentType, nil
+}
+
+// openInstanceStream requests an instance from the PACS and starts streaming it
+// to the stream's callers
+func (s *PACSService) openInstanceStream(ctx context.Context, stream *instanceStream, tenantID uuid.UUID, cacheKey, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) {
+	ctx, cancel := context.WithCancel(ctx)
+	data, contentType, err := func() (io.ReadCloser, string, error) {
+		config, adapter, err := s.route(withStudyHint(ctx, studyUID), tenantID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		data, contentType, err := adapter.GetInstance(ctx, studyUID, seriesUID, instanceUID, opts)
+		s.observePACS(ctx, config, err)
+		if err != nil {
+			s.rememberMissing(ctx, tenantID, studyUID, seriesUID, instanceUID, err)
+			return nil, "", fmt.Errorf("failed to get instance: %w", err)
+		}
+
+		// Transcode when the archive could only provide a different transfer syntax
+		if needsTranscode(opts, contentType) {
+			return transcodeInstance(data, contentType, opts.TransferSyntax)
+		}
+		return data, contentType, nil
+	}()
+	if err != nil {
+		cancel()
+		stream.fail(err)
+		return
+	}
+
+	contentLength := int64(-1)
+	if body, ok := data.(*models.InstanceBody); ok {
+		contentLength = body.ContentLength
+	}
+	// Don't cache an instance the requested transfer syntax could not be produced for
+	ttl := s.ttls(ctx, tenantID).instanceTTL(s.cachedPrimary(ctx, tenantID))
+	cacheable := ttl > 0 && !needsTranscode(opts, contentType)
+	store := func(raw []byte) {
+		if err := s.cache.Set(ctx, cacheKey, encodeCachedInstance(contentType, raw), ttl); err != nil {
+			log.Warn().Err(err).Str("cache_key", cacheKey).Msg("Failed to cache instance")
+		}
+	}
+	stream.start(data, contentType, contentLength, cancel, cacheable, store)
+}
+
+// forgetStream drops an instance stream, unless it was replaced meanwhile
+func (s *PACSService) forgetStream(cacheKey string, stream *instanceStream) {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	if s.streams[cacheKey] == stream {
+		delete(s.streams, cacheKey)
+	}
+}
+
+// join adds a caller reading from the start, or returns nil when the stream
+// no longer takes callers
+func (st *instanceStream) join() *instanceStreamReader {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.closed || st.base > 0 {
+		return nil
+	}
+	reader := &instanceStreamReader{stream: st}
+	st.readers[reader] = struct{}{}
+	return reader
+}
+
+// fail reports the PACS's error to the callers
+func (st *instanceStream) fail(err error) {
+	st.mu.Lock()
+	st.err = err
+	st.closed = true
+	st.mu.Unlock()
+	close(st.ready)
+	st.release()
+}
+
+// start streams the PACS's response to the callers; it is dropped at once when
+// they all went away while waiting for it
+func (st *instanceStream) start(src io.ReadCloser, contentType string, contentLength int64, cancel context.CancelFunc, cacheable bool, store func([]byte)) {
+	st.mu.Lock()
+	st.started = true
+	st.contentType = contentType
+	st.contentLength = contentLength
+	st.src = src
+	st.cancel = cancel
+	st.cacheable = cacheable && contentLength <= st.maxSize
+	st.store = store
+	if contentLength > st.maxSize {
+		st.closed = true
+	}
+	abandoned := len(st.readers) == 0
+	if abandoned {
+		st.closed = true
+		st.src = nil
+	}
+	st.mu.Unlock()
+	close(st.ready)
+
+	if abandoned {
+		src.Close()
+		cancel()
+		st.release()
+	}
+}
+
+func (r *instanceStreamReader) Read(p []byte) (int, error) {
+	st := r.stream
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for {
+		if r.closed {
+			return 0, io.ErrClosedPipe
+		}
+		if r.off < st.base+int64(len(st.buf)) {
+			n := copy(p, st.buf[r.off-st.base:])
+			r.off += int64(n)
+			st.trim()
+			return n, nil
+		}
+		if st.done {
+			return 0, st.readErr
+		}
+		if st.filling || (st.closed && int64(len(st.buf)) >= instanceStreamWindow) {
+			st.cond.Wait()
+			continue
+		}
+		st.fill()
+	}
+}
+
+// fill reads the next chunk from the PACS; it is called with mu held and
+// releases it while reading
+func (st *instanceStream) fill() {
+	st.filling = true
+	src := st.src
+	st.mu.Unlock()
+	chunk := make([]byte, instanceStreamChunk)
+	n, err := src.Read(chunk)
+	st.mu.Lock()
+	st.filling = false
+	defer st.cond.Broadcast()
+
+	// Every caller went away while the chunk was read
+	if len(st.readers) == 0 {
+		st.src = nil
+		src.Close()
+		return
+	}
+
+	st.buf = append(st.buf, chunk[:n]...)
+	if !st.closed && st.base+int64(len(st.buf)) > st.maxSize {
+		// Too large to cache, so later callers retrieve it themselves
+		st.closed = true
+		st.cacheable = false
+		st.trim()
+	}
+	if err == nil {
+		return
+	}
+
+	st.done = true
+	st.readErr = err
+	st.closed = true
+	st.src = nil
+	src.Close()
+	st.cancel()
+	if err == io.EOF && st.cacheable && st.base == 0 {
+		// Other callers may read the buffer meanwhile; it is no longer written to
+		data := st.buf
+		st.mu.Unlock()
+		st.store(data)
+		st.mu.Lock()
+	}
+}
+
+// trim drops the bytes every caller has read once the stream takes no new
+// callers; it is called with mu held
+func (st *instanceStream) trim() {
+	if !st.closed || len(st.readers) == 0 {
+		return
+	}
+	low := st.base + int64(len(st.buf))
+	for reader := range st.readers {
+		low = min(low, reader.off)
+	}
+	if drop := low - st.base; drop > 0 {
+		st.buf = st.buf[drop:]
+		st.base = low
+		st.cond.Broadcast()
+	}
+}
+
+// Close stops the caller's reading; once the last caller is done the
+// upstream request is cancelled
+func (r *instanceStreamReader) Close() error {
+	st := r.stream
+	st.mu.Lock()
+	if r.closed {
+		st.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	delete(st.readers, r)
+	// A stream still waiting for the PACS is dropped when it answers
+	last := len(st.readers) == 0 && st.started
+	var src io.ReadCloser
+	if last {
+		st.closed = true
+		st.buf = nil
+		if !st.filling {
+			src, st.src = st.src, nil
+		}
+	} else {
+		st.trim()
+	}
+	st.cond.Broadcast()
+	st.mu.Unlock()
+
+	if !last {
+		return nil
+	}
+	if src != nil {
+		src.Close()
+	}
+	st.cancel()
+	st.release()
+	return nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/deident"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/transcode"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidDeidentContext is returned when a de-identification context name is malformed
+var ErrInvalidDeidentContext = errors.New("invalid de-identification context")
+
+// researchUIDTTL is how long the original UIDs behind a de-identified study are
+// remembered, so research clients can retrieve instances by the UIDs they were given
+const researchUIDTTL = 24 * time.Hour
+
+// deidentContextPattern is the form of de-identification context names
+var deidentContextPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// DeidentConfig configures de-identification for exports and research retrieval
+type DeidentConfig struct {
+	Secret    string // key the replacement UIDs and pseudonyms are derived from; random per process when empty
+	Overrides string // site overrides of the Basic Profile as TAG=ACTION pairs
+}
+
+// deidentification is the profile and key de-identifiers are created from
+type deidentification struct {
+	profile *deident.Profile
+	secret  []byte
+}
+
+// researchUIDs are the original UIDs behind a de-identified study
+type researchUIDs struct {
+	StudyUID string            `json:"study_uid"`
+	UIDs     map[string]string `json:"uids"` // de-identified series and SOP instance UIDs to the originals
+}
+
+// EnableDeidentification sets up the de-identification profile used by
+// de-identified exports and research retrieval
+func (s *PACSService) EnableDeidentification(config DeidentConfig) error {
+	overrides, err := deident.ParseOverrides(config.Overrides)
+	if err != nil {
+		return err
+	}
+	profile, err := deident.NewProfile(overrides)
+	if err != nil {
+		return err
+	}
+
+	secret := []byte(config.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return fmt.Errorf("failed to generate de-identification secret: %w", err)
+		}
+		log.Warn().Msg("DEIDENT_SECRET is not set; de-identified UIDs are only consistent on this instance until it restarts")
+	}
+
+	s.deident = &deidentification{profile: profile, secret: secret}
+	return nil
+}
+
+// deidentifier returns the de-identifier of a tenant's context. Contexts are
+// separate per tenant, so two tenants never share replacement UIDs.
+func (s *PACSService) deidentifier(tenantID uuid.UUID, context string) (*deident.Deidentifier, error) {
+	if !deidentContextPattern.MatchString(context) {
+		return nil, fmt.Errorf("%w: %q must be 1 to 64 letters, digits, '.', '_' or '-'", ErrInvalidDeidentContext, context)
+	}
+	if s.deident == nil {
+		return nil, fmt.Errorf("%w: de-identification is not enabled", ErrInvalidDeidentContext)
+	}
+	return deident.New(s.deident.profile, s.deident.secret, tenantID.String()+":"+context), nil
+}
+
+// researchKey is the cache key of the original UIDs behind a de-identified study
+func (s *PACSService) researchKey(ctx context.Context, tenantID uuid.UUID, context, studyUID string) string {
+	return s.cacheKey(ctx, tenantID, studyUID, "", "", "research:"+context)
+}
+
+// GetResearchStudyMetadata returns the metadata of a study de-identified for a
+// context. The study may be given by its original UID or by the de-identified
+// one of an earlier response.
+func (s *PACSService) GetResearchStudyMetadata(ctx context.Context, tenantID uuid.UUID, context, studyUID, ipAddress, userAgent string) ([]models.Metadata, error) {
+	d, err := s.deidentifier(tenantID, context)
+	if err != nil {
+		return nil, err
+	}
+
+	var known researchUIDs
+	if s.getCachedJSON(ctx, s.researchKey(ctx, tenantID, context, studyUID), &known) {
+		studyUID = known.StudyUID
+	}
+
+	metadata, err := s.GetStudyMetadata(ctx, tenantID, studyUID)
+	if err != nil {
+		return nil, err
+	}
+
+	uids := researchUIDs{StudyUID: studyUID, UIDs: make(map[string]string)}
+	result := make([]models.Metadata, 0, len(metadata))
+	for _, m := range metadata {
+		if seriesUID := metadataString(m.Attributes, "0020000E"); seriesUID != "" {
+			uids.UIDs[d.UID(seriesUID)] = seriesUID
+		}
+		uids.UIDs[d.UID(m.SOPInstanceUID)] = m.SOPInstanceUID
+		result = append(result, models.Metadata{
+			SOPInstanceUID:    d.UID(m.SOPInstanceUID),
+			SOPClassUID:       m.SOPClassUID,
+			TransferSyntaxUID: m.TransferSyntaxUID,
+			Attributes:        d.Attributes(m.Attributes),
+		})
+	}
+	s.setCachedJSON(ctx, s.researchKey(ctx, tenantID, context, d.UID(studyUID)), uids, researchUIDTTL)
+
+	s.recordResearchAccess(ctx, tenantID, "study.research_metadata", studyUID, ipAddress, userAgent)
+	return result, nil
+}
+
+// GetResearchInstance returns an instance de-identified for a context as a
+// Part 10 object. UIDs may be the originals or the de-identified ones of the
+// study's research metadata.
+func (s *PACSService) GetResearchInstance(ctx context.Context, tenantID uuid.UUID, context, studyUID, seriesUID, instanceUID, ipAddress, userAgent string) ([]byte, error) {
+	d, err := s.deidentifier(tenantID, context)
+	if err != nil {
+		return nil, err
+	}
+
+	var known researchUIDs
+	if s.getCachedJSON(ctx, s.researchKey(ctx, tenantID, context, studyUID), &known) {
+		studyUID = known.StudyUID
+		if original, ok := known.UIDs[seriesUID]; ok {
+			seriesUID = original
+		}
+		if original, ok := known.UIDs[instanceUID]; ok {
+			instanceUID = original
+		}
+	}
+
+	body, _, err := s.GetInstance(ctx, tenantID, studyUID, seriesUID, instanceUID, models.RetrieveOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	deidentified, err := deidentifyInstance(d, body)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordResearchAccess(ctx, tenantID, "study.research_retrieve", studyUID, ipAddress, userAgent)
+	return deidentified, nil
+}
+
+// readInstance reads an instance to be rewritten in memory
+func readInstance(body io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(body, transcode.MaxInstanceSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance: %w", err)
+	}
+	if n > transcode.MaxInstanceSize {
+		return nil, fmt.Errorf("%w: instance exceeds %d bytes", ErrTooLarge, transcode.MaxInstanceSize)
+	}
+	return buf.Bytes(), nil
+}
+
+// recordResearchAccess audits a de-identified access to a study
+func (s *PACSService) recordResearchAccess(ctx context.Context, tenantID uuid.UUID, action, studyUID, ipAddress, userAgent string) {
+	entry := &models.AuditLog{
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "study",
+		ResourceUID:  studyUID,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Status:       "success",
+	}
+	if err := s.recordAudit(ctx, entry); err != nil {
+		log.Error().Err(err).Str("action", action).Msg("Failed to record research audit entry")
+	}
+}
@@ -0,0 +1,704 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/deident"
+	"github.com/otcheredev/ris-dicom-connector/internal/dicomdir"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Export errors
+var (
+	ErrExportNotFound      = errors.New("export job not found")
+	ErrInvalidDownloadLink = errors.New("invalid or expired download link")
+	ErrDestinationNotFound = errors.New("export destination not found")
+	ErrInvalidDestination  = errors.New("invalid export destination")
+)
+
+const (
+	// exportQueueSize bounds the exports waiting for a worker
+	exportQueueSize = 64
+	// exportJobTimeout bounds the retrieval and packaging of one study
+	exportJobTimeout = 2 * time.Hour
+	// exportCleanupInterval is how often expired archives are deleted
+	exportCleanupInterval = 10 * time.Minute
+	// exportFileSetID is the File-set ID written to the DICOMDIR
+	exportFileSetID = "RISEXPORT"
+)
+
+// ExportConfig configures study exports
+type ExportConfig struct {
+	Dir        string        // where finished archives are kept until their link expires
+	Workers    int           // concurrent exports
+	LinkTTL    time.Duration // lifetime of download links
+	LinkSecret string        // HMAC key for download links; random per process when empty
+}
+
+// ExportService packages studies as ZIP archives of a DICOM File-set with a
+// DICOMDIR in the background and serves them through time-limited signed
+// download links. Archives are deleted when their link expires. An export can
+// also push its archive to one of the tenant's export destinations. The same
+// workers export the metadata of a tenant's studies as NDJSON.
+type ExportService struct {
+	pacsService *PACSService
+	repo        *repository.ExportRepository
+	config      ExportConfig
+	secret      []byte
+
+	jobs         chan uuid.UUID
+	metadataJobs chan uuid.UUID
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewExportService creates an export service; call Start to run its workers
+func NewExportService(pacsService *PACSService, repo *repository.ExportRepository, config ExportConfig) (*ExportService, error) {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if err := os.MkdirAll(config.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	secret := []byte(config.LinkSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate export link secret: %w", err)
+		}
+		log.Warn().Msg("EXPORT_LINK_SECRET is not set; download links are only valid on this instance until it restarts")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ExportService{
+		pacsService:  pacsService,
+		repo:         repo,
+		config:       config,
+		secret:       secret,
+		jobs:         make(chan uuid.UUID, exportQueueSize),
+		metadataJobs: make(chan uuid.UUID, exportQueueSize),
+		ctx:          ctx,
+		cancel:       cancel,
+	}, nil
+}
+
+// Start launches the export workers and the cleanup of expired archives
+func (e *ExportService) Start() {
+	for i := 0; i < e.config.Workers; i++ {
+		e.wg.Add(1)
+		go e.worker()
+	}
+	e.wg.Add(1)
+	go e.cleanup()
+}
+
+// Stop cancels running exports, waits for the workers to exit and fails the
+// exports still queued, which do not survive a restart
+func (e *ExportService) Stop() {
+	e.cancel()
+	e.wg.Wait()
+
+	var queued, queuedMetadata []uuid.UUID
+drain:
+	for {
+		select {
+		case id := <-e.jobs:
+			queued = append(queued, id)
+		case id := <-e.metadataJobs:
+			queuedMetadata = append(queuedMetadata, id)
+		default:
+			break drain
+		}
+	}
+	if err := e.repo.FailUnfinished(context.Background(), queued, "the connector shut down before the export started"); err != nil {
+		log.Error().Err(err).Msg("Failed to fail queued exports")
+	}
+	if err := e.repo.FailUnfinishedMetadataExports(context.Background(), queuedMetadata, "the connector shut down before the export started"); err != nil {
+		log.Error().Err(err).Msg("Failed to fail queued metadata exports")
+	}
+}
+
+// CreateStudyExport queues the export of a study, to be pushed to the given
+// destination when destinationID is set and de-identified for the given
+// context when deidentContext is set
+func (e *ExportService) CreateStudyExport(ctx context.Context, tenantID uuid.UUID, studyUID string, destinationID *uuid.UUID, deidentContext, ipAddress, userAgent string) (*models.ExportJobStatus, error) {
+	if deidentContext != "" {
+		if _, err := e.pacsService.deidentifier(tenantID, deidentContext); err != nil {
+			return nil, err
+		}
+	}
+	if destinationID != nil {
+		destination, err := e.getDestination(ctx, tenantID, *destinationID)
+		if err != nil {
+			return nil, err
+		}
+		if !destination.IsActive {
+			return nil, fmt.Errorf("%w: destination %s is inactive", ErrInvalidDestination, destination.ID)
+		}
+	}
+
+	exists, err := e.pacsService.ObjectExists(ctx, tenantID, studyUID, "", "")
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	job := &models.ExportJob{
+		TenantID:      tenantID,
+		StudyUID:      studyUID,
+		Status:        models.ExportPending,
+		DestinationID: destinationID,
+
+		DeidentContext: deidentContext,
+	}
+	if err := e.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case e.jobs <- job.ID:
+	default:
+		job.Status = models.ExportFailed
+		job.Error = "export queue is full"
+		if err := e.repo.Update(ctx, job); err != nil {
+			log.Error().Err(err).Str("export_id", job.ID.String()).Msg("Failed to fail rejected export")
+		}
+		return nil, fmt.Errorf("%w: export queue is full", ErrUnavailable)
+	}
+
+	e.recordAudit(ctx, job, "study.export", ipAddress, userAgent)
+	return &models.ExportJobStatus{ExportJob: *job}, nil
+}
+
+// CreateDestination registers an export destination after validating its settings
+func (e *ExportService) CreateDestination(ctx context.Context, tenantID uuid.UUID, req *models.ExportDestinationRequest) (*models.ExportDestination, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidDestination)
+	}
+
+	destination := &models.ExportDestination{
+		TenantID:          tenantID,
+		Name:              req.Name,
+		Type:              req.Type,
+		Prefix:            req.Prefix,
+		IsActive:          true,
+		Bucket:            req.Bucket,
+		Region:            req.Region,
+		Endpoint:          req.Endpoint,
+		PathStyle:         req.PathStyle,
+		AccessKeyID:       req.AccessKeyID,
+		SecretAccessKey:   req.SecretAccessKey, // In production, encrypt this
+		ServiceAccountKey: req.ServiceAccountKey,
+		ContainerURL:      req.ContainerURL,
+		SASToken:          req.SASToken,
+		Host:              req.Host,
+		Port:              req.Port,
+		Username:          req.Username,
+		Password:          req.Password,
+		PrivateKey:        req.PrivateKey,
+		HostKey:           req.HostKey,
+	}
+	if _, err := adapters.NewDestinationUploader(*destination); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDestination, err)
+	}
+
+	if err := e.repo.CreateDestination(ctx, destination); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("destination_id", destination.ID.String()).
+		Str("type", string(destination.Type)).
+		Msg("Export destination registered")
+
+	return destination, nil
+}
+
+// GetDestinations lists a tenant's export destinations
+func (e *ExportService) GetDestinations(ctx context.Context, tenantID uuid.UUID) ([]models.ExportDestination, error) {
+	return e.repo.GetDestinations(ctx, tenantID)
+}
+
+// DeleteDestination removes a tenant's export destination; queued exports to it fail
+func (e *ExportService) DeleteDestination(ctx context.Context, tenantID, id uuid.UUID) error {
+	deleted, err := e.repo.DeleteDestination(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrDestinationNotFound
+	}
+	return nil
+}
+
+func (e *ExportService) getDestination(ctx context.Context, tenantID, id uuid.UUID) (*models.ExportDestination, error) {
+	destination, err := e.repo.GetDestination(ctx, tenantID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDestinationNotFound
+	}
+	return destination, err
+}
+
+// GetExport returns a tenant's export job; the download path is set once the archive is ready
+func (e *ExportService) GetExport(ctx context.Context, tenantID, id uuid.UUID) (*models.ExportJobStatus, error) {
+	job, err := e.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && job.TenantID != tenantID) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.ExportJobStatus{ExportJob: *job}
+	if job.Status == models.ExportCompleted && job.ExpiresAt != nil {
+		status.DownloadURL = e.downloadPath(job.ID, *job.ExpiresAt)
+	}
+	return status, nil
+}
+
+// OpenDownload verifies a download link and opens the archive it points to.
+// The caller closes the file.
+func (e *ExportService) OpenDownload(ctx context.Context, id uuid.UUID, expires, signature, ipAddress, userAgent string) (*os.File, *models.ExportJob, error) {
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresUnix {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+	expected := e.sign(id, expiresUnix)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+
+	job, err := e.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != models.ExportCompleted || job.FilePath == "" {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+
+	file, err := os.Open(job.FilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open export archive: %w", err)
+	}
+
+	e.recordAudit(ctx, job, "study.export.download", ipAddress, userAgent)
+	return file, job, nil
+}
+
+// downloadPath returns the signed download path of an archive
+func (e *ExportService) downloadPath(id uuid.UUID, expires time.Time) string {
+	return fmt.Sprintf("/api/v1/export/downloads/%s?expires=%d&signature=%s", id, expires.Unix(), e.sign(id, expires.Unix()))
+}
+
+func (e *ExportService) sign(id uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, e.secret)
+	fmt.Fprintf(mac, "%s.%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// recordAudit records access to a study's images through an export
+func (e *ExportService) recordAudit(ctx context.Context, job *models.ExportJob, action, ipAddress, userAgent string) {
+	entry := &models.AuditLog{
+		TenantID:     job.TenantID,
+		Action:       action,
+		ResourceType: "study",
+		ResourceUID:  job.StudyUID,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Status:       "success",
+	}
+	if err := e.pacsService.recordAudit(ctx, entry); err != nil {
+		log.Error().Err(err).Str("action", action).Msg("Failed to record export audit entry")
+	}
+}
+
+func (e *ExportService) worker() {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case id := <-e.jobs:
+			e.run(id)
+		case id := <-e.metadataJobs:
+			e.runMetadataExport(id)
+		}
+	}
+}
+
+// run exports one study and records the outcome on its job
+func (e *ExportService) run(id uuid.UUID) {
+	job, err := e.repo.GetByID(e.ctx, id)
+	if err != nil {
+		log.Error().Err(err).Str("export_id", id.String()).Msg("Failed to load export job")
+		return
+	}
+
+	job.Status = models.ExportRunning
+	if err := e.repo.Update(e.ctx, job); err != nil {
+		log.Error().Err(err).Str("export_id", id.String()).Msg("Failed to start export job")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(e.ctx, exportJobTimeout)
+	defer cancel()
+
+	start := time.Now()
+	path, instances, err := e.exportStudy(ctx, job)
+	if err == nil && job.DestinationID != nil {
+		job.DeliveredTo, err = e.deliver(ctx, job, path)
+		if err != nil {
+			os.Remove(path)
+		}
+	}
+
+	logger := log.With().
+		Str("tenant_id", job.TenantID.String()).
+		Str("export_id", job.ID.String()).
+		Str("study_uid", job.StudyUID).
+		Int("instances", instances).
+		Dur("duration", time.Since(start)).
+		Logger()
+
+	now := time.Now()
+	job.Instances = instances
+	job.CompletedAt = &now
+	if err != nil {
+		job.Status = models.ExportFailed
+		job.Error = err.Error()
+	} else {
+		expires := now.Add(e.config.LinkTTL)
+		job.Status = models.ExportCompleted
+		job.FilePath = path
+		job.ExpiresAt = &expires
+		if info, statErr := os.Stat(path); statErr == nil {
+			job.SizeBytes = info.Size()
+		}
+	}
+
+	// The job outcome is saved even when the export was cancelled by shutdown
+	if updateErr := e.repo.Update(context.Background(), job); updateErr != nil {
+		logger.Error().Err(updateErr).Msg("Failed to record export outcome")
+	}
+
+	event := map[string]any{
+		"job":       "export",
+		"export_id": job.ID,
+		"study_uid": job.StudyUID,
+		"instances": instances,
+		"status":    "success",
+	}
+	if job.DeliveredTo != "" {
+		event["delivered_to"] = job.DeliveredTo
+	}
+	if err != nil {
+		event["status"] = "failure"
+		event["error"] = err.Error()
+	}
+	e.pacsService.publish(job.TenantID, models.EventRetrieveJobCompleted, event)
+
+	if err != nil {
+		logger.Error().Err(err).Msg("Study export failed")
+		return
+	}
+	logger.Info().Int64("size_bytes", job.SizeBytes).Msg("Study exported")
+}
+
+// deliver pushes a finished archive to the job's export destination and returns
+// where it was stored
+func (e *ExportService) deliver(ctx context.Context, job *models.ExportJob, path string) (string, error) {
+	name := fmt.Sprintf("study-%s-%s.zip", job.StudyUID, job.ID)
+	location, err := e.upload(ctx, job.TenantID, *job.DestinationID, path, name)
+	if err != nil {
+		return "", err
+	}
+
+	e.recordAudit(ctx, job, "study.export.deliver", "", "")
+	return location, nil
+}
+
+// upload pushes a finished file to one of the tenant's export destinations
+// under the given name and returns where it was stored
+func (e *ExportService) upload(ctx context.Context, tenantID, destinationID uuid.UUID, path, name string) (string, error) {
+	destination, err := e.getDestination(ctx, tenantID, destinationID)
+	if err != nil {
+		return "", err
+	}
+	if !destination.IsActive {
+		return "", fmt.Errorf("%w: destination %s is inactive", ErrInvalidDestination, destination.ID)
+	}
+	uploader, err := adapters.NewDestinationUploader(*destination)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidDestination, err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open export file: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to open export file: %w", err)
+	}
+
+	location, err := uploader.Upload(ctx, name, file, info.Size())
+	if err != nil {
+		return "", fmt.Errorf("failed to deliver export to %s: %w", destination.Name, err)
+	}
+	return location, nil
+}
+
+// exportStudy retrieves every instance of the study into a ZIP archive laid
+// out as DICOM/Snnnn/Innnnn with a DICOMDIR at its root. It returns the path of
+// the archive and the number of instances in it.
+func (e *ExportService) exportStudy(ctx context.Context, job *models.ExportJob) (string, int, error) {
+	studies, _, err := e.pacsService.FindStudies(ctx, job.TenantID, models.QueryParams{
+		Filters: map[string]string{"0020000D": job.StudyUID},
+		Limit:   1,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	if len(studies) == 0 {
+		return "", 0, ErrNotFound
+	}
+	study := studies[0]
+
+	var d *deident.Deidentifier
+	if job.DeidentContext != "" {
+		if d, err = e.pacsService.deidentifier(job.TenantID, job.DeidentContext); err != nil {
+			return "", 0, err
+		}
+	}
+
+	series, err := e.pacsService.FindSeries(ctx, job.TenantID, job.StudyUID)
+	if err != nil {
+		return "", 0, err
+	}
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].SeriesNumber < series[j].SeriesNumber
+	})
+
+	tmp, err := os.CreateTemp(e.config.Dir, "export-*.zip.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create export archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	archive := zip.NewWriter(tmp)
+	dirStudy := &dicomdir.Study{
+		StudyInstanceUID: study.StudyInstanceUID,
+		StudyDate:        study.StudyDate,
+		StudyTime:        study.StudyTime,
+		StudyDescription: study.StudyDescription,
+		StudyID:          study.AccessionNumber,
+		AccessionNumber:  study.AccessionNumber,
+	}
+
+	if d != nil {
+		dirStudy = &dicomdir.Study{StudyInstanceUID: d.UID(study.StudyInstanceUID)}
+	}
+
+	instances := 0
+	for _, se := range series {
+		dirSeries := &dicomdir.Series{
+			SeriesInstanceUID: se.SeriesInstanceUID,
+			Modality:          se.Modality,
+			SeriesNumber:      se.SeriesNumber,
+		}
+		if d != nil {
+			dirSeries.SeriesInstanceUID = d.UID(se.SeriesInstanceUID)
+		}
+		seriesDir := fmt.Sprintf("S%04d", len(dirStudy.Series)+1)
+
+		err := e.pacsService.RetrieveSeries(ctx, job.TenantID, job.StudyUID, se.SeriesInstanceUID, models.RetrieveOptions{},
+			func(instance models.Instance, data io.ReadCloser, contentType string) error {
+				fileID := []string{"DICOM", seriesDir, fmt.Sprintf("I%05d", len(dirSeries.Images)+1)}
+				var source io.Reader = data
+				if d != nil {
+					deidentified, err := deidentifyInstance(d, data)
+					if err != nil {
+						return err
+					}
+					instance.SOPInstanceUID = d.UID(instance.SOPInstanceUID)
+					source = bytes.NewReader(deidentified)
+				}
+				image, err := writeArchiveInstance(archive, fileID, instance, source)
+				if err != nil {
+					return err
+				}
+				dirSeries.Images = append(dirSeries.Images, image)
+				instances++
+				return nil
+			})
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return "", instances, err
+		}
+		if len(dirSeries.Images) > 0 {
+			dirStudy.Series = append(dirStudy.Series, dirSeries)
+		}
+	}
+	if instances == 0 {
+		return "", 0, ErrNotFound
+	}
+
+	patient := &dicomdir.Patient{
+		PatientID:   study.PatientID,
+		PatientName: study.PatientName,
+		Studies:     []*dicomdir.Study{dirStudy},
+	}
+	if d != nil {
+		patient.PatientID = d.Dummy("LO", study.PatientID)
+		patient.PatientName = d.Dummy("PN", study.PatientName)
+	}
+	fileSet := &dicomdir.FileSet{
+		ID:          exportFileSetID,
+		SOPInstance: newUID(),
+		Patients:    []*dicomdir.Patient{patient},
+	}
+	directory, err := fileSet.Encode()
+	if err != nil {
+		return "", instances, fmt.Errorf("failed to encode DICOMDIR: %w", err)
+	}
+	w, err := archive.Create("DICOMDIR")
+	if err != nil {
+		return "", instances, fmt.Errorf("failed to write DICOMDIR: %w", err)
+	}
+	if _, err := w.Write(directory); err != nil {
+		return "", instances, fmt.Errorf("failed to write DICOMDIR: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return "", instances, fmt.Errorf("failed to finish export archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", instances, fmt.Errorf("failed to finish export archive: %w", err)
+	}
+
+	path := filepath.Join(e.config.Dir, job.ID.String()+".zip")
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", instances, fmt.Errorf("failed to store export archive: %w", err)
+	}
+	return path, instances, nil
+}
+
+// writeArchiveInstance copies an instance into the archive and describes it for
+// the DICOMDIR, preferring the UIDs in its file meta information
+func writeArchiveInstance(archive *zip.Writer, fileID []string, instance models.Instance, data io.Reader) (*dicomdir.Image, error) {
+	reader := bufio.NewReaderSize(data, dicomdir.MetaPeekSize)
+	head, _ := reader.Peek(dicomdir.MetaPeekSize)
+
+	image := &dicomdir.Image{
+		FileID:            fileID,
+		SOPClassUID:       instance.SOPClassUID,
+		SOPInstanceUID:    instance.SOPInstanceUID,
+		TransferSyntaxUID: instance.TransferSyntaxUID,
+		InstanceNumber:    instance.InstanceNumber,
+	}
+	if meta, err := dicomdir.ReadFileMeta(head); err == nil {
+		if meta.SOPClassUID != "" {
+			image.SOPClassUID = meta.SOPClassUID
+		}
+		if meta.SOPInstanceUID != "" {
+			image.SOPInstanceUID = meta.SOPInstanceUID
+		}
+		if meta.TransferSyntaxUID != "" {
+			image.TransferSyntaxUID = meta.TransferSyntaxUID
+		}
+	}
+
+	name := filepath.ToSlash(filepath.Join(fileID...))
+	w, err := archive.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add %s to export archive: %w", name, err)
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		return nil, fmt.Errorf("failed to write %s to export archive: %w", name, err)
+	}
+	return image, nil
+}
+
+// deidentifyInstance reads an instance and returns it de-identified
+func deidentifyInstance(d *deident.Deidentifier, data io.Reader) ([]byte, error) {
+	raw, err := readInstance(data)
+	if err != nil {
+		return nil, err
+	}
+	deidentified, err := d.Instance(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to de-identify instance: %w", err)
+	}
+	return deidentified, nil
+}
+
+// cleanup periodically deletes the archives and metadata files whose download link expired
+func (e *ExportService) cleanup() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(exportCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+			e.deleteExpired()
+			e.deleteExpiredMetadataExports()
+		}
+	}
+}
+
+func (e *ExportService) deleteExpired() {
+	jobs, err := e.repo.GetExpired(e.ctx, time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to look up expired exports")
+		return
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Error().Err(err).Str("export_id", job.ID.String()).Msg("Failed to delete expired export archive")
+			continue
+		}
+		job.Status = models.ExportExpired
+		job.FilePath = ""
+		if err := e.repo.Update(e.ctx, job); err != nil {
+			log.Error().Err(err).Str("export_id", job.ID.String()).Msg("Failed to expire export job")
+		}
+	}
+}
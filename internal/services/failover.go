@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// FailoverConfig controls automatic failover from a tenant's primary PACS
+type FailoverConfig struct {
+	ErrorThreshold int           // consecutive unavailable or timed out requests that fail the primary over; 0 leaves it to health checks
+	RetryInterval  time.Duration // how long requests stay with the secondary before the primary is tried again
+}
+
+// failoverState is a tenant failed over from its primary PACS to a secondary
+type failoverState struct {
+	primaryID   uuid.UUID
+	secondaryID uuid.UUID
+	until       time.Time // the primary is tried again after this
+}
+
+// failover tracks the tenants failed over to a secondary PACS. State is kept
+// per connector instance.
+type failover struct {
+	config FailoverConfig
+
+	mu       sync.Mutex
+	failures map[uuid.UUID]int // consecutive upstream failures by primary config ID
+	tenants  map[uuid.UUID]*failoverState
+}
+
+var failoverEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "risconnector_pacs_failovers_total",
+	Help: "Switches of tenants from their primary PACS to a secondary (failover) and back (failback).",
+}, []string{"tenant_id", "direction"})
+
+// RegisterFailoverMetrics exposes failover switches and the tenants currently
+// failed over on the default Prometheus registry
+func (s *PACSService) RegisterFailoverMetrics() {
+	prometheus.MustRegister(
+		failoverEvents,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "risconnector_pacs_failed_over_tenants",
+			Help: "Tenants whose requests are served by a secondary PACS.",
+		}, func() float64 {
+			if s.failover == nil {
+				return 0
+			}
+			s.failover.mu.Lock()
+			defer s.failover.mu.Unlock()
+			return float64(len(s.failover.tenants))
+		}),
+	)
+}
+
+// EnableFailover makes requests fail over from a tenant's primary PACS to its
+// next active config when the primary fails a health check or keeps failing
+// requests, and fail back once it recovers
+func (s *PACSService) EnableFailover(config FailoverConfig) {
+	s.failover = &failover{
+		config:   config,
+		failures: make(map[uuid.UUID]int),
+		tenants:  make(map[uuid.UUID]*failoverState),
+	}
+}
+
+// getActive returns the config and adapter serving the tenant in place of its
+// primary: the secondary while the tenant is failed over, otherwise the primary
+func (s *PACSService) getActive(ctx context.Context, tenantID uuid.UUID) (*models.PACSConfig, adapters.PACSAdapter, error) {
+	if s.failover != nil {
+		s.failover.mu.Lock()
+		state, ok := s.failover.tenants[tenantID]
+		s.failover.mu.Unlock()
+		if ok && time.Now().Before(state.until) {
+			config, err := s.pacsRepo.GetByID(ctx, state.secondaryID)
+			if err == nil && config.IsActive {
+				adapter, err := s.adapterFor(ctx, config)
+				if err == nil {
+					return config, adapter, nil
+				}
+			}
+			log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("Secondary PACS is unavailable; using the primary")
+		}
+	}
+	return s.getPrimary(ctx, tenantID)
+}
+
+// forgetFailover drops the failover state involving a PACS config after the
+// config changed; a tenant failed over from or to it goes back to its primary
+func (s *PACSService) forgetFailover(tenantID, configID uuid.UUID) {
+	if s.failover == nil {
+		return
+	}
+	s.failover.mu.Lock()
+	defer s.failover.mu.Unlock()
+
+	delete(s.failover.failures, configID)
+	if state, ok := s.failover.tenants[tenantID]; ok && (state.primaryID == configID || state.secondaryID == configID) {
+		delete(s.failover.tenants, tenantID)
+	}
+}
+
+// observePACS counts a request to a tenant's primary PACS that failed because
+// the PACS was unavailable or timed out, failing the tenant over when too many
+// fail in a row. Any other outcome resets the count, and fails a tenant whose
+// primary is being retried back to it.
+func (s *PACSService) observePACS(ctx context.Context, config *models.PACSConfig, err error) {
+	if s.failover == nil || s.failover.config.ErrorThreshold <= 0 || config == nil || !config.IsPrimary {
+		return
+	}
+
+	if errors.Is(err, ErrRateLimited) {
+		// The call never reached the PACS
+		return
+	}
+
+	upstream := errors.Is(err, ErrUnavailable) || errors.Is(err, context.DeadlineExceeded)
+	s.failover.mu.Lock()
+	if !upstream {
+		delete(s.failover.failures, config.ID)
+		state, ok := s.failover.tenants[config.TenantID]
+		retried := ok && state.primaryID == config.ID && time.Now().After(state.until)
+		s.failover.mu.Unlock()
+		if retried {
+			// The primary answered again after the retry interval
+			s.failBack(context.WithoutCancel(ctx), config)
+		}
+		return
+	}
+	s.failover.failures[config.ID]++
+	failures := s.failover.failures[config.ID]
+	s.failover.mu.Unlock()
+
+	if failures >= s.failover.config.ErrorThreshold {
+		s.failOver(context.WithoutCancel(ctx), config, err.Error())
+	}
+}
+
+// failOver moves the tenant of a primary config to its next active config. A
+// tenant already failed over stays on its secondary for another retry interval.
+func (s *PACSService) failOver(ctx context.Context, primary *models.PACSConfig, reason string) {
+	if s.failover == nil || !s.failoverEnabled(ctx, primary.TenantID) {
+		return
+	}
+
+	s.failover.mu.Lock()
+	delete(s.failover.failures, primary.ID)
+	if state, ok := s.failover.tenants[primary.TenantID]; ok && state.primaryID == primary.ID {
+		state.until = time.Now().Add(s.failover.config.RetryInterval)
+		s.failover.mu.Unlock()
+		return
+	}
+	s.failover.mu.Unlock()
+
+	secondary, err := s.secondaryConfig(ctx, primary)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("tenant_id", primary.TenantID.String()).
+			Str("config_id", primary.ID.String()).
+			Msg("Primary PACS is failing but there is no secondary to fail over to")
+		return
+	}
+
+	s.failover.mu.Lock()
+	s.failover.tenants[primary.TenantID] = &failoverState{
+		primaryID:   primary.ID,
+		secondaryID: secondary.ID,
+		until:       time.Now().Add(s.failover.config.RetryInterval),
+	}
+	s.failover.mu.Unlock()
+
+	log.Warn().
+		Str("tenant_id", primary.TenantID.String()).
+		Str("primary", primary.Name).
+		Str("secondary", secondary.Name).
+		Str("reason", reason).
+		Msg("Failed over to secondary PACS")
+	s.recordFailover(ctx, "pacs.failover", models.EventPACSFailover, primary, secondary, reason)
+}
+
+// failBack returns the tenant of a recovered primary config to it
+func (s *PACSService) failBack(ctx context.Context, primary *models.PACSConfig) {
+	if s.failover == nil {
+		return
+	}
+
+	s.failover.mu.Lock()
+	delete(s.failover.failures, primary.ID)
+	state, ok := s.failover.tenants[primary.TenantID]
+	if ok {
+		delete(s.failover.tenants, primary.TenantID)
+	}
+	s.failover.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	secondary := &models.PACSConfig{ID: state.secondaryID}
+	if config, err := s.pacsRepo.GetByID(ctx, state.secondaryID); err == nil {
+		secondary = config
+	}
+
+	log.Info().
+		Str("tenant_id", primary.TenantID.String()).
+		Str("primary", primary.Name).
+		Msg("Failed back to primary PACS")
+	s.recordFailover(ctx, "pacs.failback", models.EventPACSFailback, primary, secondary, "")
+}
+
+// secondaryConfig returns the tenant's next active config that can stand in for
+// the primary: the first other config that answers queries and did not fail its
+// last connection test
+func (s *PACSService) secondaryConfig(ctx context.Context, primary *models.PACSConfig) (*models.PACSConfig, error) {
+	configs, err := s.pacsRepo.GetByTenantID(ctx, primary.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range configs {
+		config := &configs[i]
+		if config.ID == primary.ID || config.Type == models.PACSTypeFederated || config.Type == models.PACSTypeXDSI {
+			continue
+		}
+		if !config.LastConnectionTest.IsZero() && !config.LastConnectionStatus {
+			continue
+		}
+		return config, nil
+	}
+	return nil, errors.New("no other active PACS config")
+}
+
+// recordFailover audits, publishes and counts a failover or failback
+func (s *PACSService) recordFailover(ctx context.Context, action, eventType string, primary, secondary *models.PACSConfig, reason string) {
+	direction := "failover"
+	if eventType == models.EventPACSFailback {
+		direction = "failback"
+	}
+	failoverEvents.WithLabelValues(primary.TenantID.String(), direction).Inc()
+
+	entry := &models.AuditLog{
+		TenantID:     primary.TenantID,
+		Action:       action,
+		ResourceType: "pacs_config",
+		ResourceUID:  secondary.ID.String(),
+		Status:       "success",
+		ErrorMessage: reason,
+	}
+	if err := s.recordAudit(ctx, entry); err != nil {
+		log.Error().Err(err).Str("tenant_id", primary.TenantID.String()).Msg("Failed to record PACS failover audit entry")
+	}
+
+	event := map[string]any{
+		"primary_config_id":   primary.ID,
+		"primary_name":        primary.Name,
+		"secondary_config_id": secondary.ID,
+		"secondary_name":      secondary.Name,
+	}
+	if reason != "" {
+		event["reason"] = reason
+	}
+	s.publish(primary.TenantID, eventType, event)
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// studySourceTTL is how long the archive a fan-out search found a study in is
+// remembered for retrievals of the study
+const studySourceTTL = 24 * time.Hour
+
+// fanOutResult is the outcome of a study search against one PACS config
+type fanOutResult struct {
+	config    *models.PACSConfig
+	studies   []models.Study
+	truncated bool
+	err       error
+}
+
+// FindStudiesAllPACS runs a study search concurrently against every active PACS
+// config of the tenant and merges the results by Study Instance UID, the
+// primary's copy winning for duplicates. Each study is annotated with the config
+// it came from, and later retrievals of the study go back to that config. A
+// config that fails is logged and skipped; an error is returned only when all
+// of them failed. For a tenant that turned fan-out off it is a regular search.
+func (s *PACSService) FindStudiesAllPACS(ctx context.Context, tenantID uuid.UUID, params models.QueryParams) ([]models.Study, bool, error) {
+	if !s.fanOutEnabled(ctx, tenantID) {
+		return s.FindStudies(ctx, tenantID, params)
+	}
+
+	start := time.Now()
+	cacheKey := studySearchCacheKey(s.cachePrefix(ctx, tenantID), uuid.Nil, params) + ":fanout"
+
+	var cached cachedStudySearch
+	if found, stale := s.getCachedQuery(ctx, cacheKey, &cached); found {
+		if stale {
+			s.revalidate(ctx, cacheKey, func(ctx context.Context) error {
+				_, _, err := s.searchAllPACS(ctx, tenantID, params, cacheKey)
+				return err
+			})
+		}
+		s.publishQuery(tenantID, "STUDY", "", "", len(cached.Studies), start)
+		return cached.Studies, cached.Truncated, nil
+	}
+
+	studies, truncated, err := s.searchAllPACS(ctx, tenantID, params, cacheKey)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.publishQuery(tenantID, "STUDY", "", "", len(studies), start)
+	return studies, truncated, nil
+}
+
+// searchAllPACS runs a study search on every PACS config of the tenant, merges
+// the results and caches them under cacheKey when every config answered
+func (s *PACSService) searchAllPACS(ctx context.Context, tenantID uuid.UUID, params models.QueryParams, cacheKey string) ([]models.Study, bool, error) {
+	configs, err := s.fanOutConfigs(ctx, tenantID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Every config is asked for the whole requested page, which is cut from
+	// the merged results
+	configParams := params
+	configParams.Offset = 0
+	if params.Limit > 0 {
+		configParams.Limit = params.Offset + params.Limit
+	}
+
+	results := make([]fanOutResult, len(configs))
+	var wg sync.WaitGroup
+	for i := range results {
+		results[i].config = &configs[i]
+		wg.Add(1)
+		go func(result *fanOutResult) {
+			defer wg.Done()
+			var adapter adapters.PACSAdapter
+			if adapter, result.err = s.adapterFor(ctx, result.config); result.err != nil {
+				return
+			}
+			result.studies, result.truncated, result.err = s.findStudiesOn(ctx, result.config, adapter, configParams)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	var firstErr error
+	succeeded := 0
+	truncated := false
+	seen := make(map[string]bool)
+	studies := []models.Study{}
+	for _, result := range results {
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			log.Warn().
+				Err(result.err).
+				Str("tenant_id", tenantID.String()).
+				Str("pacs", result.config.Name).
+				Msg("Fan-out study search failed on a PACS, returning partial results")
+			continue
+		}
+		succeeded++
+		truncated = truncated || result.truncated
+
+		source := &models.StudySource{PACSConfigID: result.config.ID.String(), Name: result.config.Name}
+		for _, study := range result.studies {
+			if study.StudyInstanceUID != "" && seen[study.StudyInstanceUID] {
+				continue
+			}
+			seen[study.StudyInstanceUID] = true
+			study.Source = source
+			studies = append(studies, study)
+			s.rememberStudySource(ctx, tenantID, study.StudyInstanceUID, result.config.ID)
+		}
+	}
+	if succeeded == 0 {
+		return nil, false, firstErr
+	}
+
+	if params.Offset > 0 {
+		if params.Offset >= len(studies) {
+			studies = []models.Study{}
+		} else {
+			studies = studies[params.Offset:]
+		}
+	}
+	if params.Limit > 0 && len(studies) > params.Limit {
+		studies = studies[:params.Limit]
+	}
+
+	// A config that failed would make the cached result incomplete
+	if succeeded == len(results) {
+		s.setCachedQuery(ctx, cacheKey, cachedStudySearch{Studies: studies, Truncated: truncated}, s.ttls(ctx, tenantID).queryTTL(&configs[0]))
+	}
+	return studies, truncated, nil
+}
+
+// fanOutConfigs returns the tenant's active configs that answer study searches,
+// primary first. Federated configs are left out as their members are searched
+// directly, and XDS-I sources as they are not archives of their own.
+func (s *PACSService) fanOutConfigs(ctx context.Context, tenantID uuid.UUID) ([]models.PACSConfig, error) {
+	all, err := s.pacsRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := all[:0]
+	for _, config := range all {
+		if config.Type == models.PACSTypeFederated || config.Type == models.PACSTypeXDSI {
+			continue
+		}
+		configs = append(configs, config)
+	}
+	if len(configs) == 0 {
+		return nil, ErrNoPACSConfigured
+	}
+	return configs, nil
+}
+
+// rememberStudySource records the config a fan-out search found a study in
+func (s *PACSService) rememberStudySource(ctx context.Context, tenantID uuid.UUID, studyUID string, configID uuid.UUID) {
+	if studyUID == "" {
+		return
+	}
+	key := s.cacheKey(ctx, tenantID, studyUID, "", "", "source")
+	if err := s.cache.Set(ctx, key, []byte(configID.String()), studySourceTTL); err != nil {
+		log.Warn().Err(err).Str("cache_key", key).Msg("Failed to remember study source")
+	}
+}
+
+// studySource returns the config a fan-out search found a study in, and its
+// adapter, while that config is still an active one of the tenant
+func (s *PACSService) studySource(ctx context.Context, tenantID uuid.UUID, studyUID string) (*models.PACSConfig, adapters.PACSAdapter, bool) {
+	data, err := s.cache.Get(ctx, s.cacheKey(ctx, tenantID, studyUID, "", "", "source"))
+	if err != nil {
+		return nil, nil, false
+	}
+	configID, err := uuid.ParseBytes(data)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	config, err := s.pacsRepo.GetByID(ctx, configID)
+	if err != nil || !config.IsActive || config.TenantID != tenantID {
+		return nil, nil, false
+	}
+	adapter, err := s.adapterFor(ctx, config)
+	if err != nil {
+		log.Warn().Err(err).Str("study_uid", studyUID).Msg("Failed to create adapter for the study's source PACS")
+		return nil, nil, false
+	}
+	return config, adapter, true
+}
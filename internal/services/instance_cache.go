@@ -0,0 +1,131 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/cache"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// maxCachedInstanceSize is the default size limit of cached instances; larger
+// ones, such as long cine loops, are too large to hold in memory
+const maxCachedInstanceSize = 64 << 20
+
+// cachedInstanceHeader starts a cached instance entry; the content type follows
+// up to the first newline, then the instance itself
+const cachedInstanceHeader = "content-type:"
+
+// encodeCachedInstance packs an instance and its content type into one cache entry
+func encodeCachedInstance(contentType string, data []byte) []byte {
+	entry := make([]byte, 0, len(cachedInstanceHeader)+len(contentType)+1+len(data))
+	entry = append(entry, cachedInstanceHeader...)
+	entry = append(entry, contentType...)
+	entry = append(entry, '\n')
+	return append(entry, data...)
+}
+
+// decodeCachedInstance unpacks a cache entry. Entries without a header hold the
+// bare instance and yield an empty content type.
+func decodeCachedInstance(entry []byte) (string, []byte) {
+	rest, ok := bytes.CutPrefix(entry, []byte(cachedInstanceHeader))
+	if !ok {
+		return "", entry
+	}
+	contentType, data, ok := bytes.Cut(rest, []byte{'\n'})
+	if !ok {
+		return "", entry
+	}
+	return strings.TrimSpace(string(contentType)), data
+}
+
+// cacheInstance returns a body that writes the instance through to the cache as
+// it is read. Partial retrievals, instances known to exceed the size limit and
+// instances of tenants that do not cache them are returned unchanged.
+func (s *PACSService) cacheInstance(ctx context.Context, tenantID uuid.UUID, cacheKey string, data io.ReadCloser, contentType string, opts models.RetrieveOptions) io.ReadCloser {
+	ttl := s.ttls(ctx, tenantID).instanceTTL(s.cachedPrimary(ctx, tenantID))
+	if ttl <= 0 {
+		return data
+	}
+
+	body, ok := data.(*models.InstanceBody)
+	if ok {
+		if body.ContentRange != "" || body.ContentLength > s.cacheTTLs.maxInstanceSize() {
+			return data
+		}
+	} else if opts.Range != "" {
+		// Without transport metadata there is no telling whether the range was applied
+		return data
+	}
+
+	reader := &cachingReader{
+		ReadCloser:  data,
+		ctx:         ctx,
+		cache:       s.cache,
+		key:         cacheKey,
+		contentType: contentType,
+		ttl:         ttl,
+		maxSize:     s.cacheTTLs.maxInstanceSize(),
+	}
+	if !ok {
+		return reader
+	}
+	reader.ReadCloser = body.ReadCloser
+	body.ReadCloser = reader
+	return body
+}
+
+// cachingReader tees an instance stream into the cache. The instance is stored
+// once the stream has been read to the end; streams that are closed early, grow
+// past the size limit or whose request is cancelled are not cached.
+type cachingReader struct {
+	io.ReadCloser
+	ctx         context.Context
+	cache       cache.Cache
+	key         string
+	contentType string
+	ttl         time.Duration
+	maxSize     int64
+
+	buf     bytes.Buffer
+	skipped bool
+}
+
+func (c *cachingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if c.skipped {
+		return n, err
+	}
+
+	switch {
+	case c.ctx.Err() != nil, int64(c.buf.Len()+n) > c.maxSize:
+		c.skip()
+	case n > 0:
+		c.buf.Write(p[:n])
+	}
+
+	if err == io.EOF && !c.skipped {
+		c.store()
+	}
+	return n, err
+}
+
+func (c *cachingReader) store() {
+	entry := encodeCachedInstance(c.contentType, c.buf.Bytes())
+	c.skip()
+
+	if err := c.cache.Set(c.ctx, c.key, entry, c.ttl); err != nil {
+		log.Warn().Err(err).Str("cache_key", c.key).Msg("Failed to cache instance")
+	}
+}
+
+// skip stops buffering and releases what was buffered so far
+func (c *cachingReader) skip() {
+	c.skipped = true
+	c.buf = bytes.Buffer{}
+}
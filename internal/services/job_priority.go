@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var jobQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "risconnector_job_queue_depth",
+	Help: "Jobs waiting for a worker by queue and priority. Retrieve jobs are counted across all instances, prefetches per instance.",
+}, []string{"queue", "priority"})
+
+// RegisterJobQueueMetrics exposes the depth of the retrieve and prefetch job
+// queues on the default Prometheus registry
+func RegisterJobQueueMetrics() {
+	prometheus.MustRegister(jobQueueDepth)
+}
+
+// jobPriority checks the priority requested for a job, wrapping invalid in the
+// error for an unknown one; no priority is routine
+func jobPriority(priority string, invalid error) (string, error) {
+	switch priority = strings.ToLower(strings.TrimSpace(priority)); priority {
+	case "":
+		return models.JobPriorityRoutine, nil
+	case models.JobPriorityStat, models.JobPriorityRoutine:
+		return priority, nil
+	}
+	return "", fmt.Errorf("%w: priority must be %s or %s", invalid, models.JobPriorityStat, models.JobPriorityRoutine)
+}
+
+// priorityQueue is a bounded in-process queue per job priority. Workers are
+// handed STAT items whenever any are waiting, routine ones only otherwise.
+type priorityQueue[T any] struct {
+	name   string // queue label of the depth metric
+	queues map[string]chan T
+}
+
+// newPriorityQueue creates a queue holding up to size items of each priority
+func newPriorityQueue[T any](name string, size int) *priorityQueue[T] {
+	q := &priorityQueue[T]{name: name, queues: make(map[string]chan T, len(models.JobPriorities))}
+	for _, priority := range models.JobPriorities {
+		q.queues[priority] = make(chan T, size)
+	}
+	q.observe()
+	return q
+}
+
+// push queues an item without waiting; it reports false when the queue of its
+// priority is full
+func (q *priorityQueue[T]) push(priority string, item T) bool {
+	queue, ok := q.queues[priority]
+	if !ok {
+		queue = q.queues[models.JobPriorityRoutine]
+	}
+	select {
+	case queue <- item:
+		q.observe()
+		return true
+	default:
+		return false
+	}
+}
+
+// pop waits for the next item, highest priority first; it reports false once
+// ctx is done
+func (q *priorityQueue[T]) pop(ctx context.Context) (T, bool) {
+	stat, routine := q.queues[models.JobPriorityStat], q.queues[models.JobPriorityRoutine]
+
+	var item T
+	select {
+	case item = <-stat:
+		q.observe()
+		return item, true
+	default:
+	}
+
+	select {
+	case <-ctx.Done():
+		return item, false
+	case item = <-stat:
+	case item = <-routine:
+	}
+	q.observe()
+	return item, true
+}
+
+// drain removes and returns the items still queued
+func (q *priorityQueue[T]) drain() []T {
+	var items []T
+	for _, priority := range models.JobPriorities {
+	drain:
+		for {
+			select {
+			case item := <-q.queues[priority]:
+				items = append(items, item)
+			default:
+				break drain
+			}
+		}
+	}
+	q.observe()
+	return items
+}
+
+// observe records the depth of each priority's queue
+func (q *priorityQueue[T]) observe() {
+	for priority, queue := range q.queues {
+		jobQueueDepth.WithLabelValues(q.name, priority).Set(float64(len(queue)))
+	}
+}
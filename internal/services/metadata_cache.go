@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/cache"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// CacheTTLs is the TTL policy of cached entries: the deployment-wide lifetime
+// of each resource type, along with the largest instance that is cached and the
+// default tenant quota. Tenants may override the instance, metadata, query and
+// thumbnail lifetimes and the quota in their primary PACS config.
+type CacheTTLs struct {
+	Instance   time.Duration // instance bodies
+	Metadata   time.Duration // study, series and instance metadata
+	Query      time.Duration // QIDO result sets, kept short so new studies show up quickly
+	QueryStale time.Duration // how long QIDO result sets are served past Query while refreshed in the background; 0 disables
+	Thumbnail  time.Duration // rendered thumbnails
+	Exists     time.Duration // positive existence probes
+	NotFound   time.Duration // not-found answers for studies, series and instances; 0 disables
+
+	MaxInstanceSize int64 // bytes; 0 for maxCachedInstanceSize
+
+	TenantQuota cache.Quota // per-tenant cache quota; zero fields do not limit
+}
+
+// maxInstanceSize returns the size of the largest instance body that is cached
+func (t CacheTTLs) maxInstanceSize() int64 {
+	if t.MaxInstanceSize > 0 {
+		return t.MaxInstanceSize
+	}
+	return maxCachedInstanceSize
+}
+
+// instanceTTL returns how long instances of the tenant are cached; 0 disables
+// caching. A nil config applies the deployment default.
+func (t CacheTTLs) instanceTTL(config *models.PACSConfig) time.Duration {
+	if config == nil {
+		return t.Instance
+	}
+	return tenantCacheTTL(config.InstanceCacheTTL, t.Instance)
+}
+
+// metadataTTL returns how long metadata of the tenant is cached; 0 disables caching
+func (t CacheTTLs) metadataTTL(config *models.PACSConfig) time.Duration {
+	return tenantCacheTTL(config.MetadataCacheTTL, t.Metadata)
+}
+
+// queryTTL returns how long QIDO results of the tenant are cached; 0 disables caching
+func (t CacheTTLs) queryTTL(config *models.PACSConfig) time.Duration {
+	return tenantCacheTTL(config.QueryCacheTTL, t.Query)
+}
+
+// thumbnailTTL returns how long thumbnails of the tenant are cached; 0 disables
+// caching. A nil config applies the deployment default.
+func (t CacheTTLs) thumbnailTTL(config *models.PACSConfig) time.Duration {
+	if config == nil {
+		return t.Thumbnail
+	}
+	return tenantCacheTTL(config.ThumbnailCacheTTL, t.Thumbnail)
+}
+
+func tenantCacheTTL(seconds int, fallback time.Duration) time.Duration {
+	switch {
+	case seconds < 0:
+		return 0
+	case seconds > 0:
+		return time.Duration(seconds) * time.Second
+	}
+	return fallback
+}
+
+// cachedStudySearch is a cached study search result
+type cachedStudySearch struct {
+	Studies   []models.Study `json:"studies"`
+	Truncated bool           `json:"truncated"`
+}
+
+// studySearchCacheKey keys a study search below a tenant's key prefix by the
+// PACS config it is routed to and its normalized parameters, so equivalent
+// queries from worklist refreshes share an entry
+func studySearchCacheKey(prefix string, configID uuid.UUID, params models.QueryParams) string {
+	params.PatientID = strings.TrimSpace(params.PatientID)
+	params.PatientName = strings.TrimSpace(params.PatientName)
+	params.StudyDate = strings.TrimSpace(params.StudyDate)
+	params.StudyTime = strings.TrimSpace(params.StudyTime)
+	params.AccessionNumber = strings.TrimSpace(params.AccessionNumber)
+	params.StudyDescription = strings.TrimSpace(params.StudyDescription)
+
+	modalities := make([]string, 0, len(params.Modalities))
+	for _, modality := range params.Modalities {
+		if modality = strings.ToUpper(strings.TrimSpace(modality)); modality != "" {
+			modalities = append(modalities, modality)
+		}
+	}
+	slices.Sort(modalities)
+	params.Modalities = slices.Compact(modalities)
+
+	// Maps are marshalled with sorted keys
+	normalized, _ := json.Marshal(params)
+	sum := sha256.Sum256(normalized)
+	return prefix + ":query:studies:" + configID.String() + ":" + hex.EncodeToString(sum[:])
+}
+
+// studySearchCachePattern matches every cached study search below a tenant's key prefix
+func studySearchCachePattern(prefix string) string {
+	return prefix + ":query:*"
+}
+
+// getCachedJSON decodes a cached entry into v and reports whether it was found.
+// Numbers are kept as json.Number so metadata attributes are served unchanged.
+func (s *PACSService) getCachedJSON(ctx context.Context, key string, v any) bool {
+	data, err := s.cache.Get(ctx, key)
+	if err != nil {
+		return false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		log.Warn().Err(err).Str("cache_key", key).Msg("Failed to decode cache entry")
+		return false
+	}
+	return true
+}
+
+// setCachedJSON caches v as JSON; a zero ttl disables caching
+func (s *PACSService) setCachedJSON(ctx context.Context, key string, v any, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Warn().Err(err).Str("cache_key", key).Msg("Failed to encode cache entry")
+		return
+	}
+	if err := s.cache.Set(ctx, key, data, ttl); err != nil {
+		log.Warn().Err(err).Str("cache_key", key).Msg("Failed to cache entry")
+	}
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidMetadataExport is returned when a metadata export request is malformed
+var ErrInvalidMetadataExport = errors.New("invalid metadata export request")
+
+const (
+	// maxMetadataExportDays is the longest range of study dates one metadata export covers
+	maxMetadataExportDays = 366
+	// metadataExportPageSize is how many studies are asked for per search
+	metadataExportPageSize = 500
+	// metadataExportTimeout bounds the searches and writing of one metadata export
+	metadataExportTimeout = 6 * time.Hour
+	// metadataExportDayLayout is how the days of a metadata export are given
+	metadataExportDayLayout = "2006-01-02"
+)
+
+// CreateMetadataExport queues the export of the study and series metadata of
+// the tenant's studies dated from req.From to req.To, to be pushed to the
+// given destination when req.DestinationID is set
+func (e *ExportService) CreateMetadataExport(ctx context.Context, tenantID uuid.UUID, req *models.MetadataExportRequest, ipAddress, userAgent string) (*models.MetadataExportJobStatus, error) {
+	from, err := time.Parse(metadataExportDayLayout, req.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from must be a YYYY-MM-DD date", ErrInvalidMetadataExport)
+	}
+	to, err := time.Parse(metadataExportDayLayout, req.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: to must be a YYYY-MM-DD date", ErrInvalidMetadataExport)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrInvalidMetadataExport)
+	}
+	days := int(to.Sub(from)/(24*time.Hour)) + 1
+	if days > maxMetadataExportDays {
+		return nil, fmt.Errorf("%w: the range must not exceed %d days", ErrInvalidMetadataExport, maxMetadataExportDays)
+	}
+	if req.DestinationID != nil {
+		destination, err := e.getDestination(ctx, tenantID, *req.DestinationID)
+		if err != nil {
+			return nil, err
+		}
+		if !destination.IsActive {
+			return nil, fmt.Errorf("%w: destination %s is inactive", ErrInvalidDestination, destination.ID)
+		}
+	}
+
+	job := &models.MetadataExportJob{
+		TenantID:      tenantID,
+		From:          req.From,
+		To:            req.To,
+		Status:        models.ExportPending,
+		TotalDays:     days,
+		DestinationID: req.DestinationID,
+	}
+	if err := e.repo.CreateMetadataExport(ctx, job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case e.metadataJobs <- job.ID:
+	default:
+		job.Status = models.ExportFailed
+		job.Error = "export queue is full"
+		if err := e.repo.UpdateMetadataExport(ctx, job); err != nil {
+			log.Error().Err(err).Str("export_id", job.ID.String()).Msg("Failed to fail rejected metadata export")
+		}
+		return nil, fmt.Errorf("%w: export queue is full", ErrUnavailable)
+	}
+
+	e.recordMetadataAudit(ctx, job, "metadata.export", ipAddress, userAgent)
+	return &models.MetadataExportJobStatus{MetadataExportJob: *job}, nil
+}
+
+// GetMetadataExport returns a tenant's metadata export job; the download path
+// is set once the file is ready
+func (e *ExportService) GetMetadataExport(ctx context.Context, tenantID, id uuid.UUID) (*models.MetadataExportJobStatus, error) {
+	job, err := e.repo.GetMetadataExportByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && job.TenantID != tenantID) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.MetadataExportJobStatus{MetadataExportJob: *job}
+	if job.Status == models.ExportCompleted && job.FilePath != "" && job.ExpiresAt != nil {
+		status.DownloadURL = fmt.Sprintf("/api/v1/export/metadata/downloads/%s?expires=%d&signature=%s",
+			job.ID, job.ExpiresAt.Unix(), e.sign(job.ID, job.ExpiresAt.Unix()))
+	}
+	return status, nil
+}
+
+// OpenMetadataDownload verifies a download link and opens the NDJSON file it
+// points to. The caller closes the file.
+func (e *ExportService) OpenMetadataDownload(ctx context.Context, id uuid.UUID, expires, signature, ipAddress, userAgent string) (*os.File, *models.MetadataExportJob, error) {
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresUnix {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+	expected := e.sign(id, expiresUnix)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+
+	job, err := e.repo.GetMetadataExportByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != models.ExportCompleted || job.FilePath == "" {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+
+	file, err := os.Open(job.FilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open metadata export: %w", err)
+	}
+
+	e.recordMetadataAudit(ctx, job, "metadata.export.download", ipAddress, userAgent)
+	return file, job, nil
+}
+
+// recordMetadataAudit records access to a tenant's study metadata through an export
+func (e *ExportService) recordMetadataAudit(ctx context.Context, job *models.MetadataExportJob, action, ipAddress, userAgent string) {
+	entry := &models.AuditLog{
+		TenantID:     job.TenantID,
+		Action:       action,
+		ResourceType: "metadata_export",
+		ResourceUID:  job.ID.String(),
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Status:       "success",
+	}
+	if err := e.pacsService.recordAudit(ctx, entry); err != nil {
+		log.Error().Err(err).Str("action", action).Msg("Failed to record metadata export audit entry")
+	}
+}
+
+// runMetadataExport exports one tenant's metadata and records the outcome on its job
+func (e *ExportService) runMetadataExport(id uuid.UUID) {
+	job, err := e.repo.GetMetadataExportByID(e.ctx, id)
+	if err != nil {
+		log.Error().Err(err).Str("export_id", id.String()).Msg("Failed to load metadata export job")
+		return
+	}
+
+	job.Status = models.ExportRunning
+	if err := e.repo.UpdateMetadataExport(e.ctx, job); err != nil {
+		log.Error().Err(err).Str("export_id", id.String()).Msg("Failed to start metadata export job")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(e.ctx, metadataExportTimeout)
+	defer cancel()
+
+	start := time.Now()
+	path, err := e.exportMetadata(ctx, job)
+	if err == nil {
+		if info, statErr := os.Stat(path); statErr == nil {
+			job.SizeBytes = info.Size()
+		}
+		if job.DestinationID != nil {
+			name := fmt.Sprintf("metadata-%s-%s-%s.ndjson", job.From, job.To, job.ID)
+			job.DeliveredTo, err = e.upload(ctx, job.TenantID, *job.DestinationID, path, name)
+			if err != nil {
+				os.Remove(path)
+			} else {
+				e.recordMetadataAudit(ctx, job, "metadata.export.deliver", "", "")
+			}
+		}
+	}
+
+	logger := log.With().
+		Str("tenant_id", job.TenantID.String()).
+		Str("export_id", job.ID.String()).
+		Str("from", job.From).
+		Str("to", job.To).
+		Int("studies", job.Studies).
+		Int("series", job.Series).
+		Dur("duration", time.Since(start)).
+		Logger()
+
+	now := time.Now()
+	job.CompletedAt = &now
+	if err != nil {
+		job.Status = models.ExportFailed
+		job.Error = err.Error()
+	} else {
+		expires := now.Add(e.config.LinkTTL)
+		job.Status = models.ExportCompleted
+		job.FilePath = path
+		job.ExpiresAt = &expires
+	}
+
+	// The job outcome is saved even when the export was cancelled by shutdown
+	if updateErr := e.repo.UpdateMetadataExport(context.Background(), job); updateErr != nil {
+		logger.Error().Err(updateErr).Msg("Failed to record metadata export outcome")
+	}
+
+	event := map[string]any{
+		"job":       "metadata_export",
+		"export_id": job.ID,
+		"from":      job.From,
+		"to":        job.To,
+		"studies":   job.Studies,
+		"status":    "success",
+	}
+	if job.DeliveredTo != "" {
+		event["delivered_to"] = job.DeliveredTo
+	}
+	if err != nil {
+		event["status"] = "failure"
+		event["error"] = err.Error()
+	}
+	e.pacsService.publish(job.TenantID, models.EventRetrieveJobCompleted, event)
+
+	if err != nil {
+		logger.Error().Err(err).Msg("Metadata export failed")
+		return
+	}
+	logger.Info().Int64("size_bytes", job.SizeBytes).Msg("Metadata exported")
+}
+
+// exportMetadata writes a line for every study dated in the job's range, with
+// its series, one day after another, saving the job's progress after each day.
+// It returns the path of the NDJSON file.
+func (e *ExportService) exportMetadata(ctx context.Context, job *models.MetadataExportJob) (string, error) {
+	from, err := time.Parse(metadataExportDayLayout, job.From)
+	if err != nil {
+		return "", fmt.Errorf("%w: from must be a YYYY-MM-DD date", ErrInvalidMetadataExport)
+	}
+
+	tmp, err := os.CreateTemp(e.config.Dir, "metadata-*.ndjson.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create metadata export: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for day := 0; day < job.TotalDays; day++ {
+		date := from.AddDate(0, 0, day).Format("20060102")
+		studies, err := e.findStudiesOn(ctx, job.TenantID, date)
+		if err != nil {
+			return "", err
+		}
+
+		for _, study := range studies {
+			series, err := e.pacsService.FindSeries(ctx, job.TenantID, study.StudyInstanceUID)
+			if errors.Is(err, ErrNotFound) {
+				continue // deleted since it was listed
+			}
+			if err != nil {
+				return "", err
+			}
+			if err := encoder.Encode(models.MetadataExportRecord{Study: study, Series: series}); err != nil {
+				return "", fmt.Errorf("failed to write metadata export: %w", err)
+			}
+			job.Studies++
+			job.Series += len(series)
+		}
+
+		job.ExportedDays++
+		if err := e.repo.UpdateMetadataExport(ctx, job); err != nil {
+			log.Error().Err(err).Str("export_id", job.ID.String()).Msg("Failed to record metadata export progress")
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "", fmt.Errorf("failed to finish metadata export: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to finish metadata export: %w", err)
+	}
+
+	path := filepath.Join(e.config.Dir, job.ID.String()+".ndjson")
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to store metadata export: %w", err)
+	}
+	return path, nil
+}
+
+// findStudiesOn lists every study of the tenant dated on a day (YYYYMMDD),
+// paging past the tenant's result cap. Paging stops once a page brings no
+// new studies, for archives that ignore the offset.
+func (e *ExportService) findStudiesOn(ctx context.Context, tenantID uuid.UUID, date string) ([]models.Study, error) {
+	var studies []models.Study
+	seen := make(map[string]bool)
+	for offset := 0; ; {
+		page, truncated, err := e.pacsService.FindStudies(ctx, tenantID, models.QueryParams{
+			StudyDate: date,
+			Limit:     metadataExportPageSize,
+			Offset:    offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for _, study := range page {
+			if study.StudyInstanceUID == "" || seen[study.StudyInstanceUID] {
+				continue
+			}
+			seen[study.StudyInstanceUID] = true
+			studies = append(studies, study)
+			added++
+		}
+		if added == 0 || (len(page) < metadataExportPageSize && !truncated) {
+			return studies, nil
+		}
+		offset += len(page)
+	}
+}
+
+// deleteExpiredMetadataExports deletes the metadata files whose download link expired
+func (e *ExportService) deleteExpiredMetadataExports() {
+	jobs, err := e.repo.GetExpiredMetadataExports(e.ctx, time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to look up expired metadata exports")
+		return
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Error().Err(err).Str("export_id", job.ID.String()).Msg("Failed to delete expired metadata export")
+			continue
+		}
+		job.Status = models.ExportExpired
+		job.FilePath = ""
+		if err := e.repo.UpdateMetadataExport(e.ctx, job); err != nil {
+			log.Error().Err(err).Str("export_id", job.ID.String()).Msg("Failed to expire metadata export job")
+		}
+	}
+}
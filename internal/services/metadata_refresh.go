@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// metadataRefreshTimeout bounds the refresh of one metadata entry
+const metadataRefreshTimeout = 30 * time.Second
+
+// MetadataRefreshConfig configures the refresh of hot metadata
+type MetadataRefreshConfig struct {
+	Interval   time.Duration // how often hot entries are looked for
+	Ahead      time.Duration // how long before expiry a hot entry is refreshed
+	MinHits    int           // cache reads since the last fetch that make an entry hot
+	MaxTracked int           // entries whose reads are tracked; 0 for no limit
+}
+
+// metadataRef names the study, series or instance whose metadata is cached
+type metadataRef struct {
+	studyUID    string
+	seriesUID   string
+	instanceUID string
+}
+
+// hotMetadataEntry tracks the reads of one cached metadata entry
+type hotMetadataEntry struct {
+	tenantID uuid.UUID
+	ref      metadataRef
+	hits     int       // cache reads since the entry was last fetched
+	expires  time.Time // zero when the entry was cached by another instance
+	lastRead time.Time
+}
+
+// metadataTracker counts the cache reads of metadata entries by cache key
+type metadataTracker struct {
+	maxTracked int
+
+	mu      sync.Mutex
+	entries map[string]*hotMetadataEntry
+}
+
+// touchMetadata records a cache read of metadata
+func (s *PACSService) touchMetadata(tenantID uuid.UUID, ref metadataRef, cacheKey string) {
+	t := s.hot
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[cacheKey]
+	if !ok {
+		if t.maxTracked > 0 && len(t.entries) >= t.maxTracked {
+			return
+		}
+		entry = &hotMetadataEntry{tenantID: tenantID, ref: ref}
+		t.entries[cacheKey] = entry
+	}
+	entry.hits++
+	entry.lastRead = time.Now()
+}
+
+// metadataCached records that metadata was fetched and cached for ttl
+func (s *PACSService) metadataCached(tenantID uuid.UUID, ref metadataRef, cacheKey string, ttl time.Duration) {
+	t := s.hot
+	if t == nil || ttl <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[cacheKey]
+	if !ok {
+		if t.maxTracked > 0 && len(t.entries) >= t.maxTracked {
+			return
+		}
+		entry = &hotMetadataEntry{tenantID: tenantID, ref: ref, lastRead: time.Now()}
+		t.entries[cacheKey] = entry
+	}
+	entry.hits = 0
+	entry.expires = time.Now().Add(ttl)
+}
+
+// due returns the hot entries about to expire by cache key, and forgets the
+// entries that expired or went unread without becoming hot. Entries cached by
+// another connector instance have no known expiry and are due once hot.
+func (t *metadataTracker) due(now time.Time, config MetadataRefreshConfig) map[string]hotMetadataEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	due := make(map[string]hotMetadataEntry)
+	for key, entry := range t.entries {
+		hot := entry.hits >= config.MinHits
+		switch {
+		case hot && (entry.expires.IsZero() || entry.expires.Sub(now) <= config.Ahead):
+			due[key] = *entry
+		case !entry.expires.IsZero() && now.After(entry.expires):
+			delete(t.entries, key)
+		case entry.expires.IsZero() && now.Sub(entry.lastRead) > time.Hour:
+			delete(t.entries, key)
+		}
+	}
+	return due
+}
+
+// MetadataRefresher re-fetches metadata that is read often shortly before its
+// cache entry expires, so busy worklists are not hit by the latency of a miss
+type MetadataRefresher struct {
+	pacsService *PACSService
+	config      MetadataRefreshConfig
+	tracker     *metadataTracker
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMetadataRefresher creates a refresher and starts tracking the metadata
+// reads of pacsService; call Start to run it
+func NewMetadataRefresher(pacsService *PACSService, config MetadataRefreshConfig) *MetadataRefresher {
+	if config.MinHits <= 0 {
+		config.MinHits = 1
+	}
+	tracker := &metadataTracker{
+		maxTracked: config.MaxTracked,
+		entries:    make(map[string]*hotMetadataEntry),
+	}
+	pacsService.hot = tracker
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &MetadataRefresher{
+		pacsService: pacsService,
+		config:      config,
+		tracker:     tracker,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start launches the refresher
+func (r *MetadataRefresher) Start() {
+	r.wg.Add(1)
+	go r.run()
+}
+
+// Stop cancels a running refresh and waits for the refresher to exit
+func (r *MetadataRefresher) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *MetadataRefresher) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			r.refreshDue()
+		}
+	}
+}
+
+// refreshDue re-fetches the hot entries about to expire, one at a time so the
+// PACS sees no burst
+func (r *MetadataRefresher) refreshDue() {
+	due := r.tracker.due(time.Now(), r.config)
+	if len(due) == 0 {
+		return
+	}
+
+	refreshed := 0
+	for cacheKey, entry := range due {
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.ctx, metadataRefreshTimeout)
+		_, err := r.pacsService.coalesce(ctx, cacheKey, func(ctx context.Context) (any, error) {
+			return r.pacsService.loadMetadata(ctx, entry.tenantID, entry.ref, cacheKey)
+		})
+		cancel()
+		if err != nil {
+			if r.ctx.Err() == nil {
+				log.Warn().Err(err).
+					Str("tenant_id", entry.tenantID.String()).
+					Str("study_uid", entry.ref.studyUID).
+					Str("series_uid", entry.ref.seriesUID).
+					Str("instance_uid", entry.ref.instanceUID).
+					Msg("Failed to refresh hot metadata")
+			}
+			r.tracker.forget(cacheKey)
+			continue
+		}
+		refreshed++
+	}
+
+	log.Debug().Int("due", len(due)).Int("refreshed", refreshed).Msg("Refreshed hot metadata")
+}
+
+// forget stops tracking an entry
+func (t *metadataTracker) forget(cacheKey string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.entries, cacheKey)
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// missingCacheSuffix keys the remembered absence of a study, series or instance
+const missingCacheSuffix = "missing"
+
+// knownMissing reports whether the PACS recently answered that an object does not exist
+func (s *PACSService) knownMissing(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, instanceUID string) bool {
+	if s.cacheTTLs.NotFound <= 0 {
+		return false
+	}
+	ok, _ := s.cache.Exists(ctx, s.cacheKey(ctx, tenantID, studyUID, seriesUID, instanceUID, missingCacheSuffix))
+	return ok
+}
+
+// rememberMissing caches a not-found answer from the PACS, so repeated requests
+// for a deleted or mistyped object are answered without a round trip. Other
+// errors are not remembered.
+func (s *PACSService) rememberMissing(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, instanceUID string, err error) {
+	if s.cacheTTLs.NotFound <= 0 || !errors.Is(err, ErrNotFound) {
+		return
+	}
+	key := s.cacheKey(ctx, tenantID, studyUID, seriesUID, instanceUID, missingCacheSuffix)
+	if err := s.cache.Set(ctx, key, []byte{1}, s.cacheTTLs.NotFound); err != nil {
+		log.Warn().Err(err).Str("cache_key", key).Msg("Failed to cache missing object")
+	}
+}
+
+// forgetMissing drops remembered not-found answers for an instance and the
+// series and study it belongs to, once the instance is known to exist
+func (s *PACSService) forgetMissing(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, instanceUID string) {
+	if s.cacheTTLs.NotFound <= 0 {
+		return
+	}
+	for _, key := range []string{
+		s.cacheKey(ctx, tenantID, studyUID, "", "", missingCacheSuffix),
+		s.cacheKey(ctx, tenantID, studyUID, seriesUID, "", missingCacheSuffix),
+		s.cacheKey(ctx, tenantID, studyUID, seriesUID, instanceUID, missingCacheSuffix),
+	} {
+		if err := s.cache.Delete(ctx, key); err != nil {
+			log.Warn().Err(err).Str("cache_key", key).Msg("Failed to invalidate missing object")
+		}
+	}
+}
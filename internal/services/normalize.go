@@ -0,0 +1,152 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/otcheredev/ris-dicom-connector/internal/dicomquery"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// DICOM JSON value representations whose leading spaces are significant
+var textVRs = map[string]bool{"LT": true, "ST": true, "UT": true}
+
+// trimPadding removes the space and NUL padding DICOM values arrive with
+func trimPadding(value string) string {
+	return strings.Trim(value, " \x00")
+}
+
+// normalizeStudies trims the padding of study results, tidies their person
+// names and adds the ISO dates, times and split names of each study, so clients
+// get the same values whichever adapter answered
+func normalizeStudies(studies []models.Study) {
+	for i := range studies {
+		st := &studies[i]
+		st.StudyInstanceUID = trimPadding(st.StudyInstanceUID)
+		st.PatientID = trimPadding(st.PatientID)
+		st.PatientName = dicomquery.FormatPersonName(trimPadding(st.PatientName))
+		st.PatientBirthDate = trimPadding(st.PatientBirthDate)
+		st.PatientSex = trimPadding(st.PatientSex)
+		st.StudyDate = trimPadding(st.StudyDate)
+		st.StudyTime = trimPadding(st.StudyTime)
+		st.StudyDescription = trimPadding(st.StudyDescription)
+		st.AccessionNumber = trimPadding(st.AccessionNumber)
+		st.ReferringPhysician = dicomquery.FormatPersonName(trimPadding(st.ReferringPhysician))
+		for j := range st.ModalitiesInStudy {
+			st.ModalitiesInStudy[j] = trimPadding(st.ModalitiesInStudy[j])
+		}
+
+		st.Normalized = &models.NormalizedStudy{
+			StudyDate:          dicomquery.ISODate(st.StudyDate),
+			StudyTime:          dicomquery.ISOTime(st.StudyTime),
+			PatientBirthDate:   dicomquery.ISODate(st.PatientBirthDate),
+			PatientName:        splitPersonName(st.PatientName),
+			ReferringPhysician: splitPersonName(st.ReferringPhysician),
+		}
+	}
+}
+
+// normalizeSeries trims the padding of series results and adds the ISO date
+// and time of each series
+func normalizeSeries(series []models.Series) {
+	for i := range series {
+		se := &series[i]
+		se.SeriesInstanceUID = trimPadding(se.SeriesInstanceUID)
+		se.Modality = trimPadding(se.Modality)
+		se.SeriesDescription = trimPadding(se.SeriesDescription)
+		se.SeriesDate = trimPadding(se.SeriesDate)
+		se.SeriesTime = trimPadding(se.SeriesTime)
+		se.BodyPartExamined = trimPadding(se.BodyPartExamined)
+		se.ProtocolName = trimPadding(se.ProtocolName)
+		se.PerformedProcedure = trimPadding(se.PerformedProcedure)
+
+		se.Normalized = &models.NormalizedSeries{
+			SeriesDate: dicomquery.ISODate(se.SeriesDate),
+			SeriesTime: dicomquery.ISOTime(se.SeriesTime),
+		}
+	}
+}
+
+// normalizeInstances trims the padding of instance results
+func normalizeInstances(instances []models.Instance) {
+	for i := range instances {
+		in := &instances[i]
+		in.SOPInstanceUID = trimPadding(in.SOPInstanceUID)
+		in.SOPClassUID = trimPadding(in.SOPClassUID)
+		in.TransferSyntaxUID = trimPadding(in.TransferSyntaxUID)
+		in.PhotometricInterpretation = trimPadding(in.PhotometricInterpretation)
+	}
+}
+
+// splitPersonName splits a PN value into its components, or returns nil for
+// an empty name
+func splitPersonName(name string) *models.PersonName {
+	components := dicomquery.PersonNameComponents(name)
+	if components == [5]string{} {
+		return nil
+	}
+	return &models.PersonName{
+		Family: components[0],
+		Given:  components[1],
+		Middle: components[2],
+		Prefix: components[3],
+		Suffix: components[4],
+	}
+}
+
+// normalizeMetadata trims the padding of the string values of each metadata
+// entry. Metadata stays in DICOM JSON form for viewers, so dates and names
+// keep their DICOM syntax.
+func normalizeMetadata(metadata []models.Metadata) {
+	for i := range metadata {
+		normalizeInstanceMetadata(&metadata[i])
+	}
+}
+
+// normalizeInstanceMetadata trims the padding of the string values of one
+// metadata entry, including those nested in sequence items
+func normalizeInstanceMetadata(m *models.Metadata) {
+	m.SOPInstanceUID = trimPadding(m.SOPInstanceUID)
+	m.SOPClassUID = trimPadding(m.SOPClassUID)
+	m.TransferSyntaxUID = trimPadding(m.TransferSyntaxUID)
+	normalizeAttributes(m.Attributes)
+}
+
+// normalizeAttributes trims the padding of the string values of a DICOM JSON
+// dataset. Text VRs only lose trailing padding, as their leading spaces are
+// part of the value.
+func normalizeAttributes(attrs map[string]interface{}) {
+	for _, attr := range attrs {
+		element, ok := attr.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		values, ok := element["Value"].([]interface{})
+		if !ok {
+			continue
+		}
+
+		vr, _ := element["vr"].(string)
+		trim := trimPadding
+		if textVRs[vr] {
+			trim = func(value string) string { return strings.TrimRight(value, " \x00") }
+		}
+
+		for i, value := range values {
+			switch v := value.(type) {
+			case string:
+				values[i] = trim(v)
+			case map[string]interface{}:
+				if vr == "SQ" {
+					normalizeAttributes(v)
+					continue
+				}
+				// PN values are objects of component groups
+				for group, name := range v {
+					if text, ok := name.(string); ok {
+						v[group] = dicomquery.FormatPersonName(trim(text))
+					}
+				}
+			}
+		}
+	}
+}
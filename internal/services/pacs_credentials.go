@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EncryptPACSCredentials encrypts the credentials every PACS config still
+// holds in plaintext, deleted configs included, such as those stored before
+// credential encryption was enabled. It is safe to run on several connector
+// instances at once and returns the number of configs encrypted.
+func (s *PACSService) EncryptPACSCredentials(ctx context.Context) (int, error) {
+	if !s.adapterFactory.EncryptsCredentials() {
+		return 0, nil
+	}
+
+	configs, err := s.pacsRepo.GetAllWithDeleted(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	encrypted := 0
+	for i := range configs {
+		config := &configs[i]
+		sealed, err := s.adapterFactory.SealCredentials(ctx, config)
+		if err != nil {
+			return encrypted, fmt.Errorf("failed to encrypt the credentials of PACS config %s: %w", config.ID, err)
+		}
+		if sealed == 0 {
+			continue
+		}
+		// A config updated meanwhile was stored with its credentials encrypted
+		stored, err := s.pacsRepo.UpdateCredentials(ctx, config)
+		if err != nil {
+			return encrypted, err
+		}
+		if stored {
+			encrypted++
+			log.Info().
+				Str("tenant_id", config.TenantID.String()).
+				Str("config_id", config.ID.String()).
+				Int("credentials", sealed).
+				Msg("PACS credentials encrypted")
+		}
+	}
+	return encrypted, nil
+}
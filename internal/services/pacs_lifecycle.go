@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// ErrPACSConfigState is returned when a PACS config is not in a state the
+// requested lifecycle change applies to
+var ErrPACSConfigState = errors.New("PACS config state does not allow the change")
+
+// pacsDrainTimeout bounds how long taking a PACS config out of service waits
+// for the calls still using it
+const pacsDrainTimeout = 30 * time.Second
+
+// GetPACSConfigHistory retrieves every PACS config of a tenant, including
+// inactive and deleted ones
+func (s *PACSService) GetPACSConfigHistory(ctx context.Context, tenantID uuid.UUID) ([]models.PACSConfig, error) {
+	configs, err := s.pacsRepo.GetHistoryByTenantID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PACS configs: %w", err)
+	}
+	return configs, nil
+}
+
+// DeactivatePACSConfig takes one of the tenant's PACS configs out of service:
+// requests are no longer routed to it, health checks and synchronization skip
+// it, and its adapter is closed once the calls using it are done. The primary
+// config cannot be deactivated; make another config primary first.
+func (s *PACSService) DeactivatePACSConfig(ctx context.Context, tenantID, configID uuid.UUID) (*models.PACSConfig, error) {
+	config, err := s.tenantPACSConfig(ctx, tenantID, configID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !config.IsActive:
+		return nil, fmt.Errorf("%w: the config is already inactive", ErrPACSConfigState)
+	case config.IsPrimary:
+		return nil, fmt.Errorf("%w: make another config primary before deactivating the primary", ErrPACSConfigState)
+	}
+
+	if err := s.pacsRepo.SetActive(ctx, config.ID, false); err != nil {
+		return nil, err
+	}
+	unfinished := s.retirePACSConfig(ctx, tenantID, config.ID)
+	if err := s.startCacheGeneration(ctx, tenantID); err != nil {
+		return nil, fmt.Errorf("failed to start a new cache generation: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("config_id", config.ID.String()).
+		Str("pacs", config.Name).
+		Int("unfinished_calls", unfinished).
+		Msg("PACS config deactivated")
+
+	if config, err = s.tenantPACSConfig(ctx, tenantID, configID); err != nil {
+		return nil, err
+	}
+	config.UnfinishedCalls = unfinished
+	return config, nil
+}
+
+// ActivatePACSConfig puts an inactive PACS config of the tenant back in
+// service and tests its connection
+func (s *PACSService) ActivatePACSConfig(ctx context.Context, tenantID, configID uuid.UUID) (*models.PACSConfig, error) {
+	config, err := s.tenantPACSConfig(ctx, tenantID, configID)
+	if err != nil {
+		return nil, err
+	}
+	if config.IsActive {
+		return nil, fmt.Errorf("%w: the config is already active", ErrPACSConfigState)
+	}
+
+	if err := s.pacsRepo.SetActive(ctx, config.ID, true); err != nil {
+		return nil, err
+	}
+	config.IsActive = true
+	s.forgetPACSConfig(tenantID, config.ID)
+	if err := s.startCacheGeneration(ctx, tenantID); err != nil {
+		return nil, fmt.Errorf("failed to start a new cache generation: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("config_id", config.ID.String()).
+		Str("pacs", config.Name).
+		Msg("PACS config activated")
+
+	// A single test does not degrade the config
+	s.checkConnection(ctx, *config, 0)
+	return s.tenantPACSConfig(ctx, tenantID, configID)
+}
+
+// RestorePACSConfig undeletes one of the tenant's PACS configs. It comes back
+// inactive and not primary, to be activated when it should serve requests.
+func (s *PACSService) RestorePACSConfig(ctx context.Context, tenantID, configID uuid.UUID) (*models.PACSConfig, error) {
+	config, err := s.tenantPACSConfigWithDeleted(ctx, tenantID, configID)
+	if err != nil {
+		return nil, err
+	}
+	if !config.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w: the config is not deleted", ErrPACSConfigState)
+	}
+
+	if err := s.pacsRepo.Restore(ctx, config.ID); err != nil {
+		return nil, err
+	}
+	if err := s.startCacheGeneration(ctx, tenantID); err != nil {
+		return nil, fmt.Errorf("failed to start a new cache generation: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("config_id", config.ID.String()).
+		Str("pacs", config.Name).
+		Msg("PACS config restored")
+	return s.tenantPACSConfig(ctx, tenantID, configID)
+}
+
+// tenantPACSConfigWithDeleted returns a PACS config of the tenant, deleted or
+// not, or ErrPACSConfigNotFound
+func (s *PACSService) tenantPACSConfigWithDeleted(ctx context.Context, tenantID, configID uuid.UUID) (*models.PACSConfig, error) {
+	config, err := s.pacsRepo.GetByIDWithDeleted(ctx, configID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPACSConfigNotFound
+		}
+		return nil, err
+	}
+	if config.TenantID != tenantID {
+		return nil, ErrPACSConfigNotFound
+	}
+	return config, nil
+}
+
+// retirePACSConfig drops what is kept about a PACS config taken out of
+// service, like forgetPACSConfig, then waits up to the drain timeout for the
+// calls still using its adapter. It returns the number still running.
+func (s *PACSService) retirePACSConfig(ctx context.Context, tenantID, configID uuid.UUID) int {
+	s.forgetPrimary(tenantID)
+	s.forgetCircuit(configID)
+	s.forgetFailover(tenantID, configID)
+
+	ctx, cancel := context.WithTimeout(ctx, pacsDrainTimeout)
+	defer cancel()
+	unfinished, err := s.adapterFactory.DrainAdapter(ctx, configID)
+	if err != nil {
+		// The adapter is dropped even when closing it fails
+		log.Warn().Err(err).Str("config_id", configID.String()).Msg("Failed to close the adapter of a retired PACS config")
+	}
+	return unfinished
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// connectionCheckTimeout bounds the health check of one PACS
+const connectionCheckTimeout = 30 * time.Second
+
+// connectionCheckConcurrency is how many PACS are checked at once
+const connectionCheckConcurrency = 8
+
+// connectionTestPruneInterval is how often tests past their retention are removed
+const connectionTestPruneInterval = time.Hour
+
+// maxConnectionTestBuckets bounds the trend intervals of a test history query
+const maxConnectionTestBuckets = 1000
+
+// ErrInvalidTestHistoryQuery is returned for a connection test history query
+// whose period ends before it starts or whose interval or limit is not positive
+var ErrInvalidTestHistoryQuery = errors.New("invalid connection test history query")
+
+var (
+	pacsUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "risconnector_pacs_up",
+		Help: "Whether the last health check of a PACS config succeeded (1) or failed (0).",
+	}, []string{"tenant_id", "pacs_config_id", "pacs"})
+	pacsDegraded = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "risconnector_pacs_degraded",
+		Help: "Whether a PACS config failed enough health checks in a row to be degraded.",
+	}, []string{"tenant_id", "pacs_config_id", "pacs"})
+	pacsCheckDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "risconnector_pacs_health_check_duration_seconds",
+		Help:    "Duration of PACS health checks by result.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"result"})
+)
+
+// RegisterHealthCheckMetrics exposes the results of PACS health checks on the
+// default Prometheus registry
+func RegisterHealthCheckMetrics() {
+	prometheus.MustRegister(pacsUp, pacsDegraded, pacsCheckDuration)
+}
+
+// CheckConnections tests every active PACS config, records the result on the
+// config and publishes pacs.down or pacs.up when its state changes. A config
+// failing degradeAfter checks in a row is marked degraded until a check passes.
+// With failover enabled, a tenant whose primary is down fails over to its
+// secondary and one whose primary recovered fails back.
+func (s *PACSService) CheckConnections(ctx context.Context, degradeAfter int) error {
+	configs, err := s.pacsRepo.GetAllActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	sem := make(chan struct{}, connectionCheckConcurrency)
+	var wg sync.WaitGroup
+	for _, config := range configs {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(config models.PACSConfig) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.checkConnection(ctx, config, degradeAfter)
+		}(config)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// checkConnection tests one PACS
+func (s *PACSService) checkConnection(ctx context.Context, config models.PACSConfig, degradeAfter int) {
+	ctx, cancel := context.WithTimeout(ctx, connectionCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var status *models.ConnectionStatus
+	adapter, err := s.adapterFor(ctx, &config)
+	if err == nil {
+		status, err = adapter.TestConnection(ctx)
+	}
+	if status == nil {
+		status = &models.ConnectionStatus{LastChecked: start}
+	}
+	status.IsConnected = err == nil
+	if err != nil {
+		status.ErrorMessage = err.Error()
+	}
+	if status.ResponseTime == 0 {
+		status.ResponseTime = time.Since(start).Milliseconds()
+	}
+
+	result := "failure"
+	if status.IsConnected {
+		result = "success"
+	}
+	pacsCheckDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+
+	logger := log.With().
+		Str("tenant_id", config.TenantID.String()).
+		Str("config_id", config.ID.String()).
+		Str("pacs", config.Name).
+		Logger()
+
+	updated, err := s.pacsRepo.UpdateConnectionStatus(ctx, config.ID, status, degradeAfter)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to record PACS connection status")
+		updated = &config
+	}
+	labels := []string{config.TenantID.String(), config.ID.String(), config.Name}
+	pacsUp.WithLabelValues(labels...).Set(boolGauge(status.IsConnected))
+	pacsDegraded.WithLabelValues(labels...).Set(boolGauge(updated.Degraded))
+	if updated.Degraded && !config.Degraded {
+		logger.Warn().Int("consecutive_failures", updated.ConsecutiveFailures).Msg("PACS is degraded")
+	}
+
+	if config.IsPrimary {
+		if status.IsConnected {
+			s.failBack(ctx, &config)
+		} else {
+			s.failOver(ctx, &config, status.ErrorMessage)
+		}
+	}
+
+	// A PACS seen for the first time is only reported when it is down
+	firstCheck := config.LastConnectionTest.IsZero()
+	if (firstCheck && status.IsConnected) || (!firstCheck && config.LastConnectionStatus == status.IsConnected) {
+		return
+	}
+
+	event := map[string]any{
+		"pacs_config_id": config.ID,
+		"pacs_name":      config.Name,
+		"is_primary":     config.IsPrimary,
+		"checked_at":     status.LastChecked,
+	}
+	if status.IsConnected {
+		logger.Info().Msg("PACS is reachable again")
+		event["response_time_ms"] = status.ResponseTime
+		s.publish(config.TenantID, models.EventPACSUp, event)
+	} else {
+		logger.Warn().Str("error", status.ErrorMessage).Msg("PACS is down")
+		event["error"] = status.ErrorMessage
+		s.publish(config.TenantID, models.EventPACSDown, event)
+	}
+}
+
+// GetConnectionTests reports the connection tests of one of the tenant's PACS
+// configs in [from, to): totals, the trend per interval from from on, and the
+// latest limit tests
+func (s *PACSService) GetConnectionTests(ctx context.Context, tenantID, configID uuid.UUID, from, to time.Time, interval time.Duration, limit int) (*models.ConnectionTestHistory, error) {
+	if !from.Before(to) || interval <= 0 || limit <= 0 {
+		return nil, ErrInvalidTestHistoryQuery
+	}
+	if to.Sub(from)/interval > maxConnectionTestBuckets {
+		return nil, fmt.Errorf("%w: the period spans more than %d intervals", ErrInvalidTestHistoryQuery, maxConnectionTestBuckets)
+	}
+	// Tests of deleted configs stay available for audit
+	if _, err := s.tenantPACSConfigWithDeleted(ctx, tenantID, configID); err != nil {
+		return nil, err
+	}
+
+	tests, err := s.pacsRepo.GetConnectionTests(ctx, configID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &models.ConnectionTestHistory{
+		PACSConfigID: configID,
+		From:         from,
+		To:           to,
+		Trend:        []models.ConnectionTestBucket{},
+	}
+	var totalTime int64
+	var bucketTime int64
+	var bucket *models.ConnectionTestBucket
+	for i, test := range tests {
+		summary := &history.Summary
+		summary.Tests++
+		totalTime += test.ResponseTime
+		summary.MaxResponseTimeMs = max(summary.MaxResponseTimeMs, test.ResponseTime)
+		if !test.Success {
+			summary.Failures++
+			testedAt := test.TestedAt
+			summary.LastFailure = &testedAt
+		}
+		if i > 0 && test.Success != tests[i-1].Success {
+			summary.StatusChanges++
+		}
+
+		start := from.Add(test.TestedAt.Sub(from).Truncate(interval))
+		if bucket == nil || !bucket.Start.Equal(start) {
+			if bucket != nil {
+				bucket.AvgResponseTimeMs = float64(bucketTime) / float64(bucket.Tests)
+			}
+			history.Trend = append(history.Trend, models.ConnectionTestBucket{Start: start})
+			bucket = &history.Trend[len(history.Trend)-1]
+			bucketTime = 0
+		}
+		bucket.Tests++
+		bucketTime += test.ResponseTime
+		if !test.Success {
+			bucket.Failures++
+		}
+	}
+	if bucket != nil {
+		bucket.AvgResponseTimeMs = float64(bucketTime) / float64(bucket.Tests)
+	}
+	if history.Summary.Tests > 0 {
+		history.Summary.SuccessRate = float64(history.Summary.Tests-history.Summary.Failures) / float64(history.Summary.Tests)
+		history.Summary.AvgResponseTimeMs = float64(totalTime) / float64(history.Summary.Tests)
+	}
+
+	latest := tests[max(0, len(tests)-limit):]
+	history.Tests = make([]models.ConnectionTest, 0, len(latest))
+	for i := len(latest) - 1; i >= 0; i-- {
+		history.Tests = append(history.Tests, latest[i])
+	}
+	return history, nil
+}
+
+// PruneConnectionTests removes the connection tests recorded before a time
+func (s *PACSService) PruneConnectionTests(ctx context.Context, before time.Time) (int64, error) {
+	return s.pacsRepo.DeleteConnectionTestsBefore(ctx, before)
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ConnectionMonitor periodically checks the tenants' PACS
+type ConnectionMonitor struct {
+	pacsService  *PACSService
+	interval     time.Duration
+	degradeAfter int
+	retention    time.Duration // how long recorded tests are kept; 0 keeps them
+	pruned       time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewConnectionMonitor creates a monitor checking every interval, degrading
+// configs after degradeAfter failed checks in a row and removing recorded tests
+// older than retention; call Start to run it
+func NewConnectionMonitor(pacsService *PACSService, interval time.Duration, degradeAfter int, retention time.Duration) *ConnectionMonitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ConnectionMonitor{
+		pacsService:  pacsService,
+		interval:     interval,
+		degradeAfter: degradeAfter,
+		retention:    retention,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start launches the monitor
+func (m *ConnectionMonitor) Start() {
+	m.wg.Add(1)
+	go m.run()
+}
+
+// Stop cancels a running check and waits for the monitor to exit
+func (m *ConnectionMonitor) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// prune removes the tests past their retention, at most every
+// connectionTestPruneInterval
+func (m *ConnectionMonitor) prune() {
+	if m.retention <= 0 || time.Since(m.pruned) < connectionTestPruneInterval {
+		return
+	}
+	m.pruned = time.Now()
+
+	deleted, err := m.pacsService.PruneConnectionTests(m.ctx, m.pruned.Add(-m.retention))
+	if err != nil {
+		if m.ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to prune PACS connection tests")
+		}
+		return
+	}
+	if deleted > 0 {
+		log.Info().Int64("deleted", deleted).Msg("Pruned PACS connection tests")
+	}
+}
+
+func (m *ConnectionMonitor) run() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	// The first round runs at startup so statuses are known before the first tick
+	for {
+		if err := m.pacsService.CheckConnections(m.ctx, m.degradeAfter); err != nil && m.ctx.Err() == nil {
+			log.Error().Err(err).Msg("PACS connection check failed")
+		}
+		m.prune()
+
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
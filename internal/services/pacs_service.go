@@ -1,77 +1,307 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/audit"
 	"github.com/otcheredev/ris-dicom-connector/internal/cache"
+	"github.com/otcheredev/ris-dicom-connector/internal/dicomquery"
 	"github.com/otcheredev/ris-dicom-connector/internal/models"
 	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+	"github.com/otcheredev/ris-dicom-connector/internal/transcode"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
 )
 
+// ErrRangeNotSatisfiable is returned when a requested byte range lies outside the instance
+var ErrRangeNotSatisfiable = adapters.ErrRangeNotSatisfiable
+
+// ErrNotFound is returned when the PACS has no object for the requested UID
+var ErrNotFound = adapters.ErrNotFound
+
+// ErrNotSupported is returned when the tenant's PACS does not support an operation
+var ErrNotSupported = adapters.ErrNotSupported
+
+// ErrInvalidRequest is returned when the PACS rejects a request as malformed
+var ErrInvalidRequest = adapters.ErrInvalidRequest
+
+// ErrTooLarge is returned when the PACS refuses a request whose result would be too large
+var ErrTooLarge = adapters.ErrTooLarge
+
+// ErrUnavailable is returned when the tenant's PACS cannot be reached
+var ErrUnavailable = adapters.ErrUnavailable
+
+// ErrPACSConfigNotFound is returned when a tenant has no PACS config with the requested ID
+var ErrPACSConfigNotFound = errors.New("PACS config not found")
+
+// ErrInvalidPACSConfig is returned when a PACS config request is malformed
+var ErrInvalidPACSConfig = errors.New("invalid PACS config")
+
+// ErrNoPACSConfigured is returned when a tenant has no active primary PACS
+var ErrNoPACSConfigured = errors.New("no primary PACS configured for tenant")
+
+// DefaultMaxResults caps study searches for tenants without a configured limit
+const DefaultMaxResults = 1000
+
 // PACSService handles business logic for PACS operations
 type PACSService struct {
 	pacsRepo       *repository.PACSRepository
 	auditRepo      *repository.AuditRepository
+	routingRepo    *repository.RoutingRepository
+	settingsRepo   *repository.TenantSettingsRepository
+	tenantRepo     *repository.TenantRepository
 	adapterFactory *adapters.AdapterFactory
 	cache          cache.Cache
+	cacheTTLs      CacheTTLs
+	events         EventPublisher // nil when events are not published
+
+	inflight     singleflight.Group // coalesces identical concurrent PACS fetches
+	streamsMu    sync.Mutex
+	streams      map[string]*instanceStream // instances being streamed to concurrent callers
+	revalidating sync.Map                   // keys of stale QIDO results being refreshed in the background
+	hot          *metadataTracker           // reads of cached metadata; nil when hot metadata is not refreshed
+
+	primaryMu   sync.Mutex
+	primaries   map[uuid.UUID]cachedPrimaryConfig // for cache lifetimes and quotas, briefly kept
+	generations map[uuid.UUID]cachedGeneration    // for cache keys, briefly kept
+
+	routingMu sync.Mutex
+	routes    map[uuid.UUID]cachedRoutingRules // briefly kept
+
+	settingsMu sync.Mutex
+	settings   map[uuid.UUID]cachedTenantSettings // briefly kept
+
+	failover *failover // nil when requests do not fail over
+
+	deident *deidentification // nil when de-identification is not enabled
+
+	rateLimits *rateLimits // nil when requests are not rate limited
+
+	breakers *circuitBreakers // nil when PACS calls have no circuit breakers
+
+	auditWriter *AuditService // nil when audit entries are written synchronously
+
+	usage *UsageService // nil when usage is not metered
+
+	index *studyIndex // nil when search results are not indexed
+
+	studyAuthz *studyAuthorization // nil when studies are served without authorization
+
+	// studyOpened is told of study metadata loaded from the PACS for a client;
+	// nil when nothing follows study opens
+	studyOpened func(tenantID uuid.UUID, studyUID string, metadata []models.Metadata)
 }
 
 // NewPACSService creates a new PACS service
 func NewPACSService(
 	pacsRepo *repository.PACSRepository,
 	auditRepo *repository.AuditRepository,
+	routingRepo *repository.RoutingRepository,
+	settingsRepo *repository.TenantSettingsRepository,
+	tenantRepo *repository.TenantRepository,
 	adapterFactory *adapters.AdapterFactory,
 	cache cache.Cache,
+	cacheTTLs CacheTTLs,
+	events EventPublisher,
 ) *PACSService {
 	return &PACSService{
 		pacsRepo:       pacsRepo,
 		auditRepo:      auditRepo,
+		routingRepo:    routingRepo,
+		settingsRepo:   settingsRepo,
+		tenantRepo:     tenantRepo,
 		adapterFactory: adapterFactory,
 		cache:          cache,
+		cacheTTLs:      cacheTTLs,
+		events:         events,
+		streams:        make(map[string]*instanceStream),
+		primaries:      make(map[uuid.UUID]cachedPrimaryConfig),
+		generations:    make(map[uuid.UUID]cachedGeneration),
+		routes:         make(map[uuid.UUID]cachedRoutingRules),
+		settings:       make(map[uuid.UUID]cachedTenantSettings),
+	}
+}
+
+// publish notifies the tenant's webhooks and the event bus of an event
+func (s *PACSService) publish(tenantID uuid.UUID, eventType string, data any) {
+	if s.events != nil {
+		s.events.Publish(tenantID, eventType, data)
+	}
+}
+
+// EnableAuditWriter has audit log entries written in the background by writer
+// instead of before the recording request continues
+func (s *PACSService) EnableAuditWriter(writer *AuditService) {
+	s.auditWriter = writer
+}
+
+// EnableUsageMetering meters the searches of each tenant with usage
+func (s *PACSService) EnableUsageMetering(usage *UsageService) {
+	s.usage = usage
+}
+
+// recordAudit writes an audit log entry and publishes it. The entry names the
+// user of the request it is recorded for and replaces the request's own entry.
+func (s *PACSService) recordAudit(ctx context.Context, entry *models.AuditLog) error {
+	if entry.User == "" {
+		entry.User = audit.User(ctx)
+		if userID, err := uuid.Parse(entry.User); err == nil {
+			entry.UserID = userID
+		}
+	}
+
+	if s.auditWriter != nil {
+		entry.ID = uuid.New()
+		entry.CreatedAt = time.Now()
+		s.auditWriter.Record(*entry)
+	} else if err := s.auditRepo.Create(ctx, entry); err != nil {
+		return err
+	}
+	audit.MarkRecorded(ctx)
+	s.publish(entry.TenantID, models.EventAuditRecorded, entry)
+	return nil
+}
+
+// publishQuery publishes the outcome of a query at a level and meters it
+func (s *PACSService) publishQuery(tenantID uuid.UUID, level, studyUID, seriesUID string, results int, start time.Time) {
+	if s.usage != nil {
+		s.usage.RecordQuery(tenantID)
+	}
+
+	event := map[string]any{
+		"level":       level,
+		"results":     results,
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	if studyUID != "" {
+		event["study_uid"] = studyUID
 	}
+	if seriesUID != "" {
+		event["series_uid"] = seriesUID
+	}
+	s.publish(tenantID, models.EventQueryExecuted, event)
 }
 
-// GetAdapter gets a PACS adapter for a tenant
+// GetAdapter gets the PACS adapter that handles a request of a tenant, chosen
+// by the tenant's routing rules
 func (s *PACSService) GetAdapter(ctx context.Context, tenantID uuid.UUID) (adapters.PACSAdapter, error) {
+	_, adapter, err := s.route(ctx, tenantID)
+	return adapter, err
+}
+
+// getPrimary returns the tenant's primary PACS config and its adapter
+func (s *PACSService) getPrimary(ctx context.Context, tenantID uuid.UUID) (*models.PACSConfig, adapters.PACSAdapter, error) {
 	// Get primary PACS config for tenant
 	config, err := s.pacsRepo.GetPrimaryByTenantID(ctx, tenantID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get PACS config: %w", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrNoPACSConfigured
+		}
+		return nil, nil, fmt.Errorf("failed to get PACS config: %w", err)
+	}
+
+	adapter, err := s.adapterFor(ctx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, adapter, nil
+}
+
+// adapterFor gets or creates the adapter of a PACS config, limited to the
+// config's call rate and behind its circuit breaker
+func (s *PACSService) adapterFor(ctx context.Context, config *models.PACSConfig) (adapters.PACSAdapter, error) {
+	if config.Type == models.PACSTypeFederated {
+		members, err := s.federationMembers(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		adapter, err := s.adapterFactory.GetFederatedAdapter(*config, members)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get adapter: %w", err)
+		}
+		return s.guardAdapter(config, adapter), nil
 	}
 
-	// Get or create adapter
 	adapter, err := s.adapterFactory.GetAdapter(*config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get adapter: %w", err)
 	}
+	return s.guardAdapter(config, adapter), nil
+}
+
+// guardAdapter wraps an adapter in the rate limit and circuit breaker of its
+// config. The breaker is outermost, so an open circuit fails fast without
+// waiting for the rate limit.
+func (s *PACSService) guardAdapter(config *models.PACSConfig, adapter adapters.PACSAdapter) adapters.PACSAdapter {
+	return s.breakAdapter(config, s.limitAdapter(config, adapter))
+}
+
+// federationMembers returns the member configs of a federated PACS in priority
+// order: the listed sources, or otherwise every other active PACS of the tenant
+func (s *PACSService) federationMembers(ctx context.Context, config *models.PACSConfig) ([]models.PACSConfig, error) {
+	configs, err := s.pacsRepo.GetByTenantID(ctx, config.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PACS configs: %w", err)
+	}
+
+	var members []models.PACSConfig
+	if len(config.FederatedSources) > 0 {
+		byID := make(map[string]models.PACSConfig, len(configs))
+		for _, c := range configs {
+			byID[c.ID.String()] = c
+		}
+		for _, id := range config.FederatedSources {
+			member, ok := byID[id]
+			if !ok || member.Type == models.PACSTypeFederated {
+				return nil, fmt.Errorf("federated PACS source %s is not an active PACS of the tenant", id)
+			}
+			members = append(members, member)
+		}
+	} else {
+		for _, c := range configs {
+			// XDS-I sources only retrieve; they cannot answer study queries
+			if c.ID == config.ID || c.Type == models.PACSTypeFederated || c.Type == models.PACSTypeXDSI {
+				continue
+			}
+			members = append(members, c)
+		}
+	}
 
-	return adapter, nil
+	if len(members) == 0 {
+		return nil, fmt.Errorf("federated PACS %s has no active member PACS", config.Name)
+	}
+	return members, nil
 }
 
 // CreatePACSConfig creates a new PACS configuration
 func (s *PACSService) CreatePACSConfig(ctx context.Context, tenantID uuid.UUID, req *models.PACSConfigRequest) (*models.PACSConfig, error) {
 	config := &models.PACSConfig{
-		TenantID:  tenantID,
-		Name:      req.Name,
-		Type:      req.Type,
-		Endpoint:  req.Endpoint,
-		Port:      req.Port,
-		AETitle:   req.AETitle,
-		Username:  req.Username,
-		IsPrimary: req.IsPrimary,
-		IsActive:  true,
+		TenantID: tenantID,
+		IsActive: true,
 	}
-
-	// TODO: Encrypt password and API key before storing
-	if req.Password != "" {
-		config.PasswordHash = req.Password // Should be encrypted
+	if err := applyPACSConfigRequest(config, req); err != nil {
+		return nil, err
+	}
+	if err := s.adapterFactory.CheckCredentials(config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPACSConfig, err)
 	}
-	if req.APIKey != "" {
-		config.APIKey = req.APIKey // Should be encrypted
+	if _, err := s.adapterFactory.SealCredentials(ctx, config); err != nil {
+		return nil, fmt.Errorf("failed to encrypt PACS credentials: %w", err)
 	}
 
 	// If this is set as primary, unset others
@@ -84,10 +314,280 @@ func (s *PACSService) CreatePACSConfig(ctx context.Context, tenantID uuid.UUID,
 	if err := s.pacsRepo.Create(ctx, config); err != nil {
 		return nil, fmt.Errorf("failed to create PACS config: %w", err)
 	}
+	if config.IsPrimary {
+		s.forgetPrimary(tenantID)
+	}
+	// A federated config or a new primary answers differently than before
+	if err := s.startCacheGeneration(ctx, tenantID); err != nil {
+		return nil, fmt.Errorf("failed to start a new cache generation: %w", err)
+	}
+
+	return config, nil
+}
+
+// UpdatePACSConfig replaces the settings of one of the tenant's PACS configs.
+// Secrets left empty in the request keep their stored values unless it clears
+// them or points the config at another PACS. The config's
+// cached adapter is dropped so the next request connects with the new
+// settings, and the config's connection is tested again.
+func (s *PACSService) UpdatePACSConfig(ctx context.Context, tenantID, configID uuid.UUID, req *models.PACSConfigRequest) (*models.PACSConfig, error) {
+	config, err := s.tenantPACSConfig(ctx, tenantID, configID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPACSConfigRequest(config, req); err != nil {
+		return nil, err
+	}
+	if err := s.adapterFactory.CheckCredentials(config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPACSConfig, err)
+	}
+	if _, err := s.adapterFactory.SealCredentials(ctx, config); err != nil {
+		return nil, fmt.Errorf("failed to encrypt PACS credentials: %w", err)
+	}
+	// Failed tests of the previous settings say nothing about the new ones
+	config.ConsecutiveFailures = 0
+	config.Degraded = false
+
+	if err := s.pacsRepo.Update(ctx, config); err != nil {
+		return nil, err
+	}
+	if req.IsPrimary {
+		if err := s.pacsRepo.SetPrimary(ctx, config.ID, tenantID); err != nil {
+			return nil, fmt.Errorf("failed to set primary: %w", err)
+		}
+	}
+	s.forgetPACSConfig(tenantID, config.ID)
+	// Entries cached through the previous settings may come from another PACS
+	if err := s.startCacheGeneration(ctx, tenantID); err != nil {
+		return nil, fmt.Errorf("failed to start a new cache generation: %w", err)
+	}
+
+	// A single test does not degrade the config, whose run of failures starts over
+	s.checkConnection(ctx, *config, 0)
+	return s.tenantPACSConfig(ctx, tenantID, configID)
+}
+
+// DeletePACSConfig deactivates and soft-deletes one of the tenant's PACS
+// configs, then closes its adapter once the calls using it are done. Routing
+// rules targeting the config are skipped from then on. A deleted config stays
+// listed in the tenant's history and can be restored.
+func (s *PACSService) DeletePACSConfig(ctx context.Context, tenantID, configID uuid.UUID) error {
+	config, err := s.tenantPACSConfig(ctx, tenantID, configID)
+	if err != nil {
+		return err
+	}
+	if config.IsActive {
+		if err := s.pacsRepo.SetActive(ctx, config.ID, false); err != nil {
+			return err
+		}
+	}
+	if err := s.pacsRepo.Delete(ctx, config.ID); err != nil {
+		return err
+	}
+	unfinished := s.retirePACSConfig(ctx, tenantID, config.ID)
+	if err := s.startCacheGeneration(ctx, tenantID); err != nil {
+		return fmt.Errorf("failed to start a new cache generation: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("config_id", config.ID.String()).
+		Str("pacs", config.Name).
+		Int("unfinished_calls", unfinished).
+		Msg("PACS config deleted")
+	return nil
+}
 
+// tenantPACSConfig returns a PACS config of the tenant, or ErrPACSConfigNotFound
+func (s *PACSService) tenantPACSConfig(ctx context.Context, tenantID, configID uuid.UUID) (*models.PACSConfig, error) {
+	config, err := s.pacsRepo.GetByID(ctx, configID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPACSConfigNotFound
+		}
+		return nil, err
+	}
+	if config.TenantID != tenantID {
+		return nil, ErrPACSConfigNotFound
+	}
 	return config, nil
 }
 
+// forgetPACSConfig drops what is kept about a PACS config after it changed or
+// was deleted: its cached adapter and those of the tenant's federated configs,
+// the tenant's primary config, and the config's circuit breaker and failover
+// state
+func (s *PACSService) forgetPACSConfig(tenantID, configID uuid.UUID) {
+	if err := s.adapterFactory.RemoveAdapter(configID); err != nil {
+		// The adapter is dropped even when closing it fails
+		log.Warn().Err(err).Str("config_id", configID.String()).Msg("Failed to close the adapter of a changed PACS config")
+	}
+	s.forgetPrimary(tenantID)
+	s.forgetCircuit(configID)
+	s.forgetFailover(tenantID, configID)
+}
+
+// applyPACSConfigRequest validates a PACS config request and sets its settings
+// on a config. Secrets left empty keep the config's current values.
+func applyPACSConfigRequest(config *models.PACSConfig, req *models.PACSConfigRequest) error {
+	if req.MaxResults < 0 {
+		return fmt.Errorf("%w: max_results must not be negative", ErrInvalidPACSConfig)
+	}
+	if req.InstanceCacheTTL < -1 || req.MetadataCacheTTL < -1 || req.QueryCacheTTL < -1 || req.ThumbnailCacheTTL < -1 {
+		return fmt.Errorf("%w: cache TTLs must be seconds, or -1 to disable caching", ErrInvalidPACSConfig)
+	}
+	if req.CacheQuotaMB < -1 || req.CacheQuotaObjects < -1 {
+		return fmt.Errorf("%w: cache quotas must be positive, or -1 for no limit", ErrInvalidPACSConfig)
+	}
+	if req.RateLimit < -1 || req.RateBurst < 0 {
+		return fmt.Errorf("%w: rate_limit must be calls per second, or -1 for no limit, and rate_burst must not be negative", ErrInvalidPACSConfig)
+	}
+	if req.RemoteTenantID != "" {
+		if _, err := uuid.Parse(req.RemoteTenantID); err != nil {
+			return fmt.Errorf("%w: remote_tenant_id must be a tenant UUID", ErrInvalidPACSConfig)
+		}
+	}
+	for _, id := range req.FederatedSources {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("%w: federated_sources entry %q is not a PACS config ID", ErrInvalidPACSConfig, id)
+		}
+	}
+	if _, err := adapters.LookupQuirks(req.Quirks); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPACSConfig, err)
+	}
+
+	redacted, err := normalizeRedactedAttributes(req.RedactedAttributes)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPACSConfig, err)
+	}
+
+	// Stored secrets are not sent to wherever the config is pointed at next
+	// unless the client sends them again
+	moved := config.Type != req.Type ||
+		config.Endpoint != req.Endpoint ||
+		config.Port != req.Port ||
+		config.BaseURL != req.BaseURL ||
+		config.BasePath != req.BasePath ||
+		config.OAuthTokenURL != req.OAuthTokenURL
+	if err := setSecrets(config, req, moved); err != nil {
+		return err
+	}
+
+	config.Name = req.Name
+	config.Type = req.Type
+	config.Endpoint = req.Endpoint
+	config.Port = req.Port
+	config.AETitle = req.AETitle
+	config.BaseURL = req.BaseURL
+	config.BasePath = req.BasePath
+	config.Username = req.Username
+	config.IsPrimary = req.IsPrimary
+
+	config.MaxResults = req.MaxResults
+
+	config.RateLimit = req.RateLimit
+	config.RateBurst = req.RateBurst
+
+	config.InstanceCacheTTL = req.InstanceCacheTTL
+	config.MetadataCacheTTL = req.MetadataCacheTTL
+	config.QueryCacheTTL = req.QueryCacheTTL
+	config.ThumbnailCacheTTL = req.ThumbnailCacheTTL
+
+	config.CacheQuotaMB = req.CacheQuotaMB
+	config.CacheQuotaObjects = req.CacheQuotaObjects
+
+	config.TLSCACert = req.TLSCACert
+	config.TLSClientCert = req.TLSClientCert
+	config.TLSInsecureSkipVerify = req.TLSInsecureSkipVerify
+
+	config.AuthMode = req.AuthMode
+	config.OAuthTokenURL = req.OAuthTokenURL
+	config.OAuthClientID = req.OAuthClientID
+	config.OAuthScopes = req.OAuthScopes
+
+	config.SigV4Region = req.SigV4Region
+	config.SigV4Service = req.SigV4Service
+	config.SigV4AccessKeyID = req.SigV4AccessKeyID
+
+	config.GCPProject = req.GCPProject
+	config.GCPLocation = req.GCPLocation
+	config.GCPDataset = req.GCPDataset
+	config.GCPDICOMStore = req.GCPDICOMStore
+
+	config.S3Bucket = req.S3Bucket
+	config.S3Region = req.S3Region
+	config.S3Prefix = req.S3Prefix
+	config.S3PathStyle = req.S3PathStyle
+	config.S3AccessKeyID = req.S3AccessKeyID
+
+	config.XDSRepositoryUniqueID = req.XDSRepositoryUniqueID
+	config.XDSHomeCommunityID = req.XDSHomeCommunityID
+
+	config.RemoteTenantID = req.RemoteTenantID
+
+	config.Quirks = req.Quirks
+
+	config.FederatedSources = req.FederatedSources
+
+	config.StripPrivateTags = req.StripPrivateTags
+	config.RedactedAttributes = redacted
+	return nil
+}
+
+// pacsSecret is a secret of a PACS config, by its request field, with the
+// value a request sent for it
+type pacsSecret struct {
+	name  string
+	field *string
+	value string
+}
+
+// setSecrets sets the secrets of a config from a request. Secrets are never
+// returned, so a client updating a config cannot send them back: one left empty
+// keeps its stored value, unless the request clears it or the config moved to
+// another PACS, where it must be sent again or cleared.
+func setSecrets(config *models.PACSConfig, req *models.PACSConfigRequest, moved bool) error {
+	secrets := []pacsSecret{
+		{"password", &config.PasswordHash, req.Password},
+		{"api_key", &config.APIKey, req.APIKey},
+		{"tls_client_key", &config.TLSClientKey, req.TLSClientKey},
+		{"oauth_client_secret", &config.OAuthClientSecret, req.OAuthClientSecret},
+		{"sigv4_secret_access_key", &config.SigV4SecretAccessKey, req.SigV4SecretAccessKey},
+		{"sigv4_session_token", &config.SigV4SessionToken, req.SigV4SessionToken},
+		{"gcp_service_account_key", &config.GCPServiceAccountKey, req.GCPServiceAccountKey},
+		{"s3_secret_access_key", &config.S3SecretAccessKey, req.S3SecretAccessKey},
+	}
+
+	clear := make(map[string]bool, len(req.ClearSecrets))
+	for _, name := range req.ClearSecrets {
+		if !slices.ContainsFunc(secrets, func(secret pacsSecret) bool {
+			return secret.name == name
+		}) {
+			return fmt.Errorf("%w: clear_secrets entry %q is not a secret", ErrInvalidPACSConfig, name)
+		}
+		clear[name] = true
+	}
+
+	var unsent []string
+	for _, secret := range secrets {
+		switch {
+		case clear[secret.name] && secret.value != "":
+			return fmt.Errorf("%w: %s is both sent and cleared", ErrInvalidPACSConfig, secret.name)
+		case clear[secret.name]:
+			*secret.field = ""
+		case secret.value != "":
+			*secret.field = secret.value
+		case moved && *secret.field != "":
+			unsent = append(unsent, secret.name)
+		}
+	}
+	if len(unsent) > 0 {
+		return fmt.Errorf("%w: the PACS address changed; send %s again or list them in clear_secrets",
+			ErrInvalidPACSConfig, strings.Join(unsent, ", "))
+	}
+	return nil
+}
+
 // TestConnection tests a PACS connection
 func (s *PACSService) TestConnection(ctx context.Context, req *models.ConnectionTestRequest) (*models.ConnectionStatus, error) {
 	// Create temporary config for testing
@@ -96,18 +596,76 @@ func (s *PACSService) TestConnection(ctx context.Context, req *models.Connection
 		Endpoint:     req.Endpoint,
 		Port:         req.Port,
 		AETitle:      req.AETitle,
+		BaseURL:      req.BaseURL,
+		BasePath:     req.BasePath,
 		Username:     req.Username,
 		PasswordHash: req.Password,
 		APIKey:       req.APIKey,
+
+		TLSCACert:             req.TLSCACert,
+		TLSClientCert:         req.TLSClientCert,
+		TLSClientKey:          req.TLSClientKey,
+		TLSInsecureSkipVerify: req.TLSInsecureSkipVerify,
+
+		AuthMode:          req.AuthMode,
+		OAuthTokenURL:     req.OAuthTokenURL,
+		OAuthClientID:     req.OAuthClientID,
+		OAuthClientSecret: req.OAuthClientSecret,
+		OAuthScopes:       req.OAuthScopes,
+
+		SigV4Region:          req.SigV4Region,
+		SigV4Service:         req.SigV4Service,
+		SigV4AccessKeyID:     req.SigV4AccessKeyID,
+		SigV4SecretAccessKey: req.SigV4SecretAccessKey,
+		SigV4SessionToken:    req.SigV4SessionToken,
+
+		GCPProject:           req.GCPProject,
+		GCPLocation:          req.GCPLocation,
+		GCPDataset:           req.GCPDataset,
+		GCPDICOMStore:        req.GCPDICOMStore,
+		GCPServiceAccountKey: req.GCPServiceAccountKey,
+
+		S3Bucket:          req.S3Bucket,
+		S3Region:          req.S3Region,
+		S3Prefix:          req.S3Prefix,
+		S3PathStyle:       req.S3PathStyle,
+		S3AccessKeyID:     req.S3AccessKeyID,
+		S3SecretAccessKey: req.S3SecretAccessKey,
+
+		XDSRepositoryUniqueID: req.XDSRepositoryUniqueID,
+		XDSHomeCommunityID:    req.XDSHomeCommunityID,
+
+		RemoteTenantID: req.RemoteTenantID,
+
+		Quirks: req.Quirks,
+	}
+
+	config, err := s.adapterFactory.ResolveCredentials(ctx, config)
+	if err != nil {
+		if errors.Is(err, adapters.ErrInvalidSecretReference) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPACSConfig, err)
+		}
+		return nil, err
 	}
 
 	// Create temporary adapter
 	var adapter adapters.PACSAdapter
-	var err error
 
 	switch req.Type {
-	case models.PACSTypeDICOMWeb, models.PACSTypeOrthanc:
+	case models.PACSTypeDICOMWeb:
 		adapter, err = adapters.NewDICOMWebAdapter(config)
+	case models.PACSTypeOrthanc:
+		adapter, err = adapters.NewOrthancAdapter(config)
+	case models.PACSTypeDcm4chee:
+		adapter, err = adapters.NewDcm4cheeAdapter(config)
+	case models.PACSTypeS3:
+		adapter, err = adapters.NewS3Adapter(config)
+	case models.PACSTypeGoogleHealthcare:
+		adapter, err = adapters.NewGoogleHealthcareAdapter(config)
+	case models.PACSTypeGRPC:
+		adapter, err = adapters.NewGRPCAdapter(config)
+	case models.PACSTypeConnector:
+		adapter, err = adapters.NewConnectorAdapter(config)
 	default:
 		return nil, fmt.Errorf("unsupported PACS type: %s", req.Type)
 	}
@@ -126,79 +684,1018 @@ func (s *PACSService) TestConnection(ctx context.Context, req *models.Connection
 	return status, nil
 }
 
-// FindStudies queries for studies
-func (s *PACSService) FindStudies(ctx context.Context, tenantID uuid.UUID, params models.QueryParams) ([]models.Study, error) {
-	adapter, err := s.GetAdapter(ctx, tenantID)
+// FindStudies queries for studies, capping the result count at the tenant's maximum.
+// It reports whether results were truncated because the cap was reached. Studies
+// the study authorizer denies the client are left out.
+func (s *PACSService) FindStudies(ctx context.Context, tenantID uuid.UUID, params models.QueryParams) ([]models.Study, bool, error) {
+	studies, truncated, err := s.findStudies(ctx, tenantID, params)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	studies, err = s.authorizedStudies(ctx, tenantID, studies)
+	if err != nil {
+		return nil, false, err
+	}
+	return studies, truncated, nil
+}
+
+// findStudies answers a study search from the cache, the study index or the
+// PACS the search is routed to. Routing rules can send the same search to
+// different PACS, so results are cached per routed config.
+func (s *PACSService) findStudies(ctx context.Context, tenantID uuid.UUID, params models.QueryParams) ([]models.Study, bool, error) {
+	start := time.Now()
+	config, _, err := s.route(withQueryHints(ctx, params), tenantID)
+	if err != nil {
+		return nil, false, err
+	}
+	cacheKey := studySearchCacheKey(s.cachePrefix(ctx, tenantID), config.ID, params)
+
+	var cached cachedStudySearch
+	if found, stale := s.getCachedQuery(ctx, cacheKey, &cached); found {
+		if stale {
+			s.revalidate(ctx, cacheKey, func(ctx context.Context) error {
+				_, _, err := s.searchStudies(ctx, config, params, cacheKey)
+				return err
+			})
+		}
+		s.publishQuery(tenantID, "STUDY", "", "", len(cached.Studies), start)
+		return cached.Studies, cached.Truncated, nil
+	}
+
+	if studies, truncated, ok := s.studiesFromIndex(ctx, config, params); ok {
+		s.publishQuery(tenantID, "STUDY", "", "", len(studies), start)
+		return studies, truncated, nil
+	}
+
+	studies, truncated, err := s.searchStudies(ctx, config, params, cacheKey)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.publishQuery(tenantID, "STUDY", "", "", len(studies), start)
+	return studies, truncated, nil
+}
+
+// searchStudies runs a study search on the PACS config it was routed to and
+// caches the result under cacheKey
+func (s *PACSService) searchStudies(ctx context.Context, config *models.PACSConfig, params models.QueryParams, cacheKey string) ([]models.Study, bool, error) {
+	adapter, err := s.adapterFor(ctx, config)
+	if err != nil {
+		return nil, false, err
+	}
+
+	studies, truncated, err := s.findStudiesOn(ctx, config, adapter, params)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.indexStudies(ctx, config, studies)
+	s.setCachedQuery(ctx, cacheKey, cachedStudySearch{Studies: studies, Truncated: truncated}, s.ttls(ctx, config.TenantID).queryTTL(config))
+	return studies, truncated, nil
+}
+
+// findStudiesOn runs a study search against one PACS config, capping the result
+// count at its maximum and reporting whether results were truncated. Searches
+// are limited to the tenant's allowed modalities.
+func (s *PACSService) findStudiesOn(ctx context.Context, config *models.PACSConfig, adapter adapters.PACSAdapter, params models.QueryParams) ([]models.Study, bool, error) {
+	maxResults := s.maxResults(ctx, config)
+
+	allowed := s.allowedModalities(ctx, config.TenantID)
+	if !restrictModalities(&params, allowed) {
+		return []models.Study{}, false, nil
+	}
+
+	// Ask for one more than the cap so truncation can be detected
+	capped := params.Limit <= 0 || params.Limit > maxResults
+	if capped {
+		params.Limit = maxResults + 1
+	}
+
+	// A multi-day window such as 08:00 on the first day to 12:00 on the last
+	// cannot be expressed with independent date and time matching, so the
+	// backend matches on the dates and the window is applied to the results
+	limit := params.Limit
+	window, combined := dicomquery.CombinedRange(params.StudyDate, params.StudyTime)
+	if combined {
+		params.StudyTime = ""
+		params.Limit = maxResults + 1
 	}
 
 	studies, err := adapter.FindStudies(ctx, params)
+	s.observePACS(ctx, config, err)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find studies: %w", err)
+		return nil, false, fmt.Errorf("failed to find studies: %w", err)
+	}
+
+	// Backends differ in PN case sensitivity and component handling, so names
+	// are matched again case-insensitively against the normalized pattern
+	namePattern := dicomquery.PersonNameQuery(params.PatientName)
+
+	if combined || namePattern != "" || allowed != nil {
+		matched := studies[:0]
+		for _, study := range studies {
+			if combined && !window.Contains(study.StudyDate, study.StudyTime) {
+				continue
+			}
+			if !modalityAllowed(study.ModalitiesInStudy, allowed) {
+				continue
+			}
+			if !dicomquery.MatchPersonName(namePattern, study.PatientName) {
+				continue
+			}
+			matched = append(matched, study)
+		}
+		studies = matched
+	}
+
+	newResponseFilter(config).filterFields(&studies)
+	normalizeStudies(studies)
+
+	// DIMSE C-FIND has no limit key, so enforce the requested limit here too
+	if len(studies) > limit {
+		studies = studies[:limit]
+	}
+
+	truncated := false
+	if capped && len(studies) > maxResults {
+		studies = studies[:maxResults]
+		truncated = true
+		log.Warn().
+			Str("tenant_id", config.TenantID.String()).
+			Str("pacs", config.Name).
+			Int("max_results", maxResults).
+			Msg("Study search truncated at tenant maximum")
 	}
 
-	return studies, nil
+	return studies, truncated, nil
 }
 
 // FindSeries queries for series
 func (s *PACSService) FindSeries(ctx context.Context, tenantID uuid.UUID, studyUID string) ([]models.Series, error) {
-	adapter, err := s.GetAdapter(ctx, tenantID)
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionSearch, studyUID, "", ""); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	cacheKey := s.cacheKey(ctx, tenantID, studyUID, "", "", "query:series")
+
+	var series []models.Series
+	if found, stale := s.getCachedQuery(ctx, cacheKey, &series); found {
+		if stale {
+			s.revalidate(ctx, cacheKey, func(ctx context.Context) error {
+				_, err := s.searchSeries(ctx, tenantID, studyUID, cacheKey)
+				return err
+			})
+		}
+		s.publishQuery(tenantID, "SERIES", studyUID, "", len(series), start)
+		return series, nil
+	}
+
+	if series, ok := s.seriesFromIndex(ctx, tenantID, studyUID); ok {
+		s.publishQuery(tenantID, "SERIES", studyUID, "", len(series), start)
+		return series, nil
+	}
+
+	series, err := s.searchSeries(ctx, tenantID, studyUID, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishQuery(tenantID, "SERIES", studyUID, "", len(series), start)
+	return series, nil
+}
+
+// searchSeries queries the PACS holding a study for its series and caches the
+// result under cacheKey
+func (s *PACSService) searchSeries(ctx context.Context, tenantID uuid.UUID, studyUID, cacheKey string) ([]models.Series, error) {
+	config, adapter, err := s.route(withStudyHint(ctx, studyUID), tenantID)
 	if err != nil {
 		return nil, err
 	}
 
 	series, err := adapter.FindSeries(ctx, studyUID)
+	s.observePACS(ctx, config, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find series: %w", err)
 	}
 
+	if allowed := s.allowedModalities(ctx, tenantID); allowed != nil {
+		series = slices.DeleteFunc(series, func(se models.Series) bool {
+			return !modalityAllowed([]string{se.Modality}, allowed)
+		})
+	}
+
+	newResponseFilter(config).filterFields(&series)
+	normalizeSeries(series)
+	s.indexSeries(ctx, tenantID, studyUID, series)
+	s.setCachedQuery(ctx, cacheKey, series, s.ttls(ctx, tenantID).queryTTL(config))
 	return series, nil
 }
 
 // FindInstances queries for instances
 func (s *PACSService) FindInstances(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID string) ([]models.Instance, error) {
-	adapter, err := s.GetAdapter(ctx, tenantID)
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionSearch, studyUID, seriesUID, ""); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	cacheKey := s.cacheKey(ctx, tenantID, studyUID, seriesUID, "", "query:instances")
+
+	var instances []models.Instance
+	if found, stale := s.getCachedQuery(ctx, cacheKey, &instances); found {
+		if stale {
+			s.revalidate(ctx, cacheKey, func(ctx context.Context) error {
+				_, err := s.searchInstances(ctx, tenantID, studyUID, seriesUID, cacheKey)
+				return err
+			})
+		}
+		s.publishQuery(tenantID, "IMAGE", studyUID, seriesUID, len(instances), start)
+		return instances, nil
+	}
+
+	if instances, ok := s.instancesFromIndex(ctx, tenantID, studyUID, seriesUID); ok {
+		s.publishQuery(tenantID, "IMAGE", studyUID, seriesUID, len(instances), start)
+		return instances, nil
+	}
+
+	instances, err := s.searchInstances(ctx, tenantID, studyUID, seriesUID, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishQuery(tenantID, "IMAGE", studyUID, seriesUID, len(instances), start)
+	return instances, nil
+}
+
+// searchInstances queries the PACS holding a study for the instances of a
+// series and caches the result under cacheKey
+func (s *PACSService) searchInstances(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, cacheKey string) ([]models.Instance, error) {
+	config, adapter, err := s.route(withStudyHint(ctx, studyUID), tenantID)
 	if err != nil {
 		return nil, err
 	}
 
 	instances, err := adapter.FindInstances(ctx, studyUID, seriesUID)
+	s.observePACS(ctx, config, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find instances: %w", err)
 	}
 
+	newResponseFilter(config).filterFields(&instances)
+	normalizeInstances(instances)
+	s.indexInstances(ctx, tenantID, studyUID, seriesUID, instances)
+	s.setCachedQuery(ctx, cacheKey, instances, s.ttls(ctx, tenantID).queryTTL(config))
 	return instances, nil
 }
 
 // GetInstance retrieves an instance with caching
-func (s *PACSService) GetInstance(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, instanceUID string) (io.ReadCloser, string, error) {
-	// Try cache first
-	cacheKey := cache.CacheKey(tenantID.String(), studyUID, seriesUID, instanceUID, "instance")
+func (s *PACSService) GetInstance(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionRetrieve, studyUID, seriesUID, instanceUID); err != nil {
+		return nil, "", err
+	}
+	// Try cache first (cached representations are keyed by transfer syntax)
+	suffix := "instance"
+	if opts.TransferSyntax != "" && opts.TransferSyntax != "*" {
+		suffix = "instance:" + opts.TransferSyntax
+	}
+	cacheKey := s.cacheKey(ctx, tenantID, studyUID, seriesUID, instanceUID, suffix)
 
-	_, err := s.cache.Get(ctx, cacheKey)
+	cached, err := s.cache.Get(ctx, cacheKey)
 	if err == nil {
 		// Cache hit
-		return io.NopCloser(io.Reader(nil)), "application/dicom", nil // TODO: Return proper reader
+		s.publishInstanceServed(tenantID, studyUID, seriesUID, instanceUID, "cache")
+		return cachedInstanceBody(cached, opts)
 	}
 
-	// Cache miss - fetch from PACS
-	adapter, err := s.GetAdapter(ctx, tenantID)
+	if s.knownMissing(ctx, tenantID, studyUID, seriesUID, instanceUID) {
+		return nil, "", fmt.Errorf("failed to get instance: %w", ErrNotFound)
+	}
+
+	// Cache miss - stream from PACS, once for concurrent requests of the whole instance
+	if opts.Range == "" {
+		data, contentType, err := s.streamInstanceShared(ctx, tenantID, cacheKey, studyUID, seriesUID, instanceUID, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		s.publishInstanceServed(tenantID, studyUID, seriesUID, instanceUID, "pacs")
+		return data, contentType, nil
+	}
+
+	// Partial retrievals are streamed to their caller alone
+	config, adapter, err := s.route(withStudyHint(ctx, studyUID), tenantID)
 	if err != nil {
 		return nil, "", err
 	}
 
-	data, contentType, err := adapter.GetInstance(ctx, studyUID, seriesUID, instanceUID)
+	data, contentType, err := adapter.GetInstance(ctx, studyUID, seriesUID, instanceUID, opts)
+	s.observePACS(ctx, config, err)
 	if err != nil {
+		s.rememberMissing(ctx, tenantID, studyUID, seriesUID, instanceUID, err)
 		return nil, "", fmt.Errorf("failed to get instance: %w", err)
 	}
+	s.publishInstanceServed(tenantID, studyUID, seriesUID, instanceUID, "pacs")
 
-	// TODO: Cache the data asynchronously
+	// Transcode when the archive could only provide a different transfer syntax
+	if needsTranscode(opts, contentType) {
+		data, contentType, err = transcodeInstance(data, contentType, opts.TransferSyntax)
+		if err != nil {
+			return nil, "", err
+		}
+		// Don't cache an instance the requested transfer syntax could not be produced for
+		if needsTranscode(opts, contentType) {
+			return data, contentType, nil
+		}
+	}
 
-	return data, contentType, nil
+	return s.cacheInstance(ctx, tenantID, cacheKey, data, contentType, opts), contentType, nil
 }
 
-// Add these methods to the PACSService
+// publishInstanceServed publishes the retrieval of an instance from the cache or the PACS
+func (s *PACSService) publishInstanceServed(tenantID uuid.UUID, studyUID, seriesUID, instanceUID, source string) {
+	s.publish(tenantID, models.EventInstanceServed, map[string]any{
+		"study_uid":    studyUID,
+		"series_uid":   seriesUID,
+		"instance_uid": instanceUID,
+		"source":       source,
+	})
+}
+
+// needsTranscode reports whether a retrieved instance is in a different transfer
+// syntax than the client requested. Partial (range) retrievals are never transcoded.
+func needsTranscode(opts models.RetrieveOptions, contentType string) bool {
+	if opts.TransferSyntax == "" || opts.TransferSyntax == "*" || opts.Range != "" {
+		return false
+	}
+	available := contentTypeTransferSyntax(contentType)
+	return available != "" && available != opts.TransferSyntax
+}
+
+// transcodeInstance re-encodes a retrieved instance in the target transfer syntax.
+// When the conversion is not supported the original instance is returned so the
+// caller can report the mismatch.
+func transcodeInstance(data io.ReadCloser, contentType, target string) (io.ReadCloser, string, error) {
+	source := contentTypeTransferSyntax(contentType)
+	if !transcode.Supported(source, target) {
+		return data, contentType, nil
+	}
+	defer data.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(data, transcode.MaxInstanceSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read instance: %w", err)
+	}
+	if len(raw) > transcode.MaxInstanceSize {
+		return nil, "", fmt.Errorf("%w: instance exceeds the transcoding size limit", ErrTooLarge)
+	}
+
+	start := time.Now()
+	transcoded, err := transcode.Transcode(raw, target)
+	if err != nil {
+		if errors.Is(err, transcode.ErrUnsupported) {
+			return io.NopCloser(bytes.NewReader(raw)), contentType, nil
+		}
+		return nil, "", err
+	}
+
+	log.Debug().
+		Str("from", source).
+		Str("to", target).
+		Int("size", len(transcoded)).
+		Dur("duration", time.Since(start)).
+		Msg("Instance transcoded")
+
+	return &models.InstanceBody{
+		ReadCloser:    io.NopCloser(bytes.NewReader(transcoded)),
+		ContentLength: int64(len(transcoded)),
+	}, mime.FormatMediaType("application/dicom", map[string]string{"transfer-syntax": target}), nil
+}
+
+// contentTypeTransferSyntax reports the transfer-syntax parameter of a content type
+func contentTypeTransferSyntax(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return params["transfer-syntax"]
+}
+
+// GetStudyMetadata retrieves metadata for every instance of a study
+func (s *PACSService) GetStudyMetadata(ctx context.Context, tenantID uuid.UUID, studyUID string) ([]models.Metadata, error) {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionMetadata, studyUID, "", ""); err != nil {
+		return nil, err
+	}
+	ref := metadataRef{studyUID: studyUID}
+	cacheKey := s.cacheKey(ctx, tenantID, studyUID, "", "", "metadata")
+
+	var metadata []models.Metadata
+	if s.getCachedJSON(ctx, cacheKey, &metadata) {
+		s.touchMetadata(tenantID, ref, cacheKey)
+		return metadata, nil
+	}
+
+	if s.knownMissing(ctx, tenantID, studyUID, "", "") {
+		return nil, fmt.Errorf("failed to get study metadata: %w", ErrNotFound)
+	}
+
+	value, err := s.coalesce(ctx, cacheKey, func(ctx context.Context) (any, error) {
+		value, err := s.loadMetadata(ctx, tenantID, ref, cacheKey)
+		if err == nil && s.studyOpened != nil {
+			s.studyOpened(tenantID, studyUID, value.([]models.Metadata))
+		}
+		return value, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]models.Metadata), nil
+}
+
+// GetSeriesMetadata retrieves metadata for every instance of a series
+func (s *PACSService) GetSeriesMetadata(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID string) ([]models.Metadata, error) {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionMetadata, studyUID, seriesUID, ""); err != nil {
+		return nil, err
+	}
+	ref := metadataRef{studyUID: studyUID, seriesUID: seriesUID}
+	cacheKey := s.cacheKey(ctx, tenantID, studyUID, seriesUID, "", "metadata")
+
+	var metadata []models.Metadata
+	if s.getCachedJSON(ctx, cacheKey, &metadata) {
+		s.touchMetadata(tenantID, ref, cacheKey)
+		return metadata, nil
+	}
+
+	if s.knownMissing(ctx, tenantID, studyUID, seriesUID, "") {
+		return nil, fmt.Errorf("failed to get series metadata: %w", ErrNotFound)
+	}
+
+	value, err := s.coalesce(ctx, cacheKey, func(ctx context.Context) (any, error) {
+		return s.loadMetadata(ctx, tenantID, ref, cacheKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]models.Metadata), nil
+}
+
+// GetInstanceMetadata retrieves metadata for a single instance
+func (s *PACSService) GetInstanceMetadata(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, instanceUID string) (*models.Metadata, error) {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionMetadata, studyUID, seriesUID, instanceUID); err != nil {
+		return nil, err
+	}
+	ref := metadataRef{studyUID: studyUID, seriesUID: seriesUID, instanceUID: instanceUID}
+	cacheKey := s.cacheKey(ctx, tenantID, studyUID, seriesUID, instanceUID, "metadata")
+
+	var cached models.Metadata
+	if s.getCachedJSON(ctx, cacheKey, &cached) {
+		s.touchMetadata(tenantID, ref, cacheKey)
+		return &cached, nil
+	}
+
+	if s.knownMissing(ctx, tenantID, studyUID, seriesUID, instanceUID) {
+		return nil, fmt.Errorf("failed to get instance metadata: %w", ErrNotFound)
+	}
+
+	value, err := s.coalesce(ctx, cacheKey, func(ctx context.Context) (any, error) {
+		return s.loadMetadata(ctx, tenantID, ref, cacheKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*models.Metadata), nil
+}
+
+// loadMetadata fetches study, series or instance metadata from the PACS,
+// filters it by the tenant's response policy, trims its padding and caches it.
+// Study and series metadata are returned as []models.Metadata, instance
+// metadata as *models.Metadata.
+func (s *PACSService) loadMetadata(ctx context.Context, tenantID uuid.UUID, ref metadataRef, cacheKey string) (any, error) {
+	config, adapter, err := s.route(withStudyHint(ctx, ref.studyUID), tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	switch {
+	case ref.instanceUID != "":
+		metadata, err := adapter.GetInstanceMetadata(ctx, ref.studyUID, ref.seriesUID, ref.instanceUID)
+		s.observePACS(ctx, config, err)
+		if err != nil {
+			s.rememberMissing(ctx, tenantID, ref.studyUID, ref.seriesUID, ref.instanceUID, err)
+			return nil, fmt.Errorf("failed to get instance metadata: %w", err)
+		}
+		newResponseFilter(config).filterAttributes(metadata.Attributes)
+		normalizeInstanceMetadata(metadata)
+		value = metadata
+	case ref.seriesUID != "":
+		metadata, err := adapter.GetSeriesMetadata(ctx, ref.studyUID, ref.seriesUID)
+		s.observePACS(ctx, config, err)
+		if err != nil {
+			s.rememberMissing(ctx, tenantID, ref.studyUID, ref.seriesUID, "", err)
+			return nil, fmt.Errorf("failed to get series metadata: %w", err)
+		}
+		newResponseFilter(config).filterMetadata(metadata)
+		normalizeMetadata(metadata)
+		value = metadata
+	default:
+		metadata, err := adapter.GetStudyMetadata(ctx, ref.studyUID)
+		s.observePACS(ctx, config, err)
+		if err != nil {
+			s.rememberMissing(ctx, tenantID, ref.studyUID, "", "", err)
+			return nil, fmt.Errorf("failed to get study metadata: %w", err)
+		}
+		newResponseFilter(config).filterMetadata(metadata)
+		normalizeMetadata(metadata)
+		value = metadata
+	}
+
+	ttl := s.ttls(ctx, tenantID).metadataTTL(config)
+	s.setCachedJSON(ctx, cacheKey, value, ttl)
+	s.metadataCached(tenantID, ref, cacheKey, ttl)
+	return value, nil
+}
+
+// FrameVisitor receives each frame of a frame retrieval as soon as it is fetched
+type FrameVisitor func(frame int, data io.ReadCloser, contentType string) error
+
+// RetrieveFrames fetches frames of a multi-frame instance one at a time, handing
+// each to visit before the next is requested so cine loops can start playing
+// before the whole object has been transferred
+func (s *PACSService) RetrieveFrames(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, instanceUID string, frames []int, visit FrameVisitor) error {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionRetrieve, studyUID, seriesUID, instanceUID); err != nil {
+		return err
+	}
+	_, adapter, err := s.route(withStudyHint(ctx, studyUID), tenantID)
+	if err != nil {
+		return err
+	}
+
+	for _, frame := range frames {
+		data, contentType, err := adapter.GetFrame(ctx, studyUID, seriesUID, instanceUID, frame)
+		if err != nil {
+			return fmt.Errorf("failed to get frame %d: %w", frame, err)
+		}
+
+		err = visit(frame, data, contentType)
+		data.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetRendered retrieves a server-side rendering of an instance, e.g. an MP4 of a
+// video instance
+func (s *PACSService) GetRendered(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, instanceUID, mediaType string) (io.ReadCloser, string, error) {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionRender, studyUID, seriesUID, instanceUID); err != nil {
+		return nil, "", err
+	}
+	_, adapter, err := s.route(withStudyHint(ctx, studyUID), tenantID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, contentType, err := adapter.GetRendered(ctx, studyUID, seriesUID, instanceUID, mediaType)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get rendered instance: %w", err)
+	}
+
+	return data, contentType, nil
+}
+
+// InstanceVisitor receives each instance of a series or study retrieval as soon as it is fetched
+type InstanceVisitor func(instance models.Instance, data io.ReadCloser, contentType string) error
+
+// RetrieveSeries fetches the instances of a series in instance number order,
+// handing each to visit before the next is requested
+func (s *PACSService) RetrieveSeries(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID string, opts models.RetrieveOptions, visit InstanceVisitor) error {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionRetrieve, studyUID, seriesUID, ""); err != nil {
+		return err
+	}
+	instances, err := s.FindInstances(ctx, tenantID, studyUID, seriesUID)
+	if err != nil {
+		return err
+	}
+	if len(instances) == 0 {
+		return ErrNotFound
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool {
+		return instances[i].InstanceNumber < instances[j].InstanceNumber
+	})
+
+	for _, instance := range instances {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, contentType, err := s.GetInstance(ctx, tenantID, studyUID, seriesUID, instance.SOPInstanceUID, opts)
+		if err != nil {
+			return err
+		}
+
+		err = visit(instance, data, contentType)
+		data.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RetrieveStudy fetches every instance of a study, series by series
+func (s *PACSService) RetrieveStudy(ctx context.Context, tenantID uuid.UUID, studyUID string, opts models.RetrieveOptions, visit InstanceVisitor) error {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionRetrieve, studyUID, "", ""); err != nil {
+		return err
+	}
+	series, err := s.FindSeries(ctx, tenantID, studyUID)
+	if err != nil {
+		return err
+	}
+	if len(series) == 0 {
+		return ErrNotFound
+	}
+
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].SeriesNumber < series[j].SeriesNumber
+	})
+
+	retrieved := 0
+	counted := func(instance models.Instance, data io.ReadCloser, contentType string) error {
+		retrieved++
+		return visit(instance, data, contentType)
+	}
+
+	for _, se := range series {
+		err := s.RetrieveSeries(ctx, tenantID, studyUID, se.SeriesInstanceUID, opts, counted)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+
+	s.publish(tenantID, models.EventStudyRetrieved, map[string]any{
+		"study_uid": studyUID,
+		"instances": retrieved,
+	})
+	return nil
+}
+
+// ObjectExists reports whether a study, series or instance exists. Cached
+// instances and recent probes answer locally; otherwise the PACS is probed
+// with a minimal query.
+func (s *PACSService) ObjectExists(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, instanceUID string) (bool, error) {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionSearch, studyUID, seriesUID, instanceUID); err != nil {
+		return false, err
+	}
+	if instanceUID != "" {
+		if ok, _ := s.cache.Exists(ctx, s.cacheKey(ctx, tenantID, studyUID, seriesUID, instanceUID, "instance")); ok {
+			return true, nil
+		}
+	}
+
+	existsKey := s.cacheKey(ctx, tenantID, studyUID, seriesUID, instanceUID, "exists")
+	if ok, _ := s.cache.Exists(ctx, existsKey); ok {
+		return true, nil
+	}
+	if s.knownMissing(ctx, tenantID, studyUID, seriesUID, instanceUID) {
+		return false, nil
+	}
+
+	config, adapter, err := s.route(withStudyHint(ctx, studyUID), tenantID)
+	if err != nil {
+		return false, err
+	}
+
+	exists, err := adapter.ObjectExists(ctx, studyUID, seriesUID, instanceUID)
+	s.observePACS(ctx, config, err)
+	if err != nil {
+		return false, fmt.Errorf("failed to probe object: %w", err)
+	}
+
+	if exists {
+		if s.cacheTTLs.Exists > 0 {
+			if err := s.cache.Set(ctx, existsKey, []byte{1}, s.cacheTTLs.Exists); err != nil {
+				log.Warn().Err(err).Str("key", existsKey).Msg("Failed to cache existence probe")
+			}
+		}
+	} else {
+		s.rememberMissing(ctx, tenantID, studyUID, seriesUID, instanceUID, ErrNotFound)
+	}
+
+	return exists, nil
+}
+
+// cachedInstanceBody serves a cached instance, honouring a single byte range when requested
+func cachedInstanceBody(entry []byte, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
+	contentType, data := decodeCachedInstance(entry)
+	if contentType == "" {
+		contentType = "application/dicom"
+		if opts.TransferSyntax != "" && opts.TransferSyntax != "*" {
+			contentType = fmt.Sprintf("application/dicom; transfer-syntax=%s", opts.TransferSyntax)
+		}
+	}
+
+	full := &models.InstanceBody{
+		ReadCloser:    io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+	}
+
+	if opts.Range == "" {
+		return full, contentType, nil
+	}
+
+	start, end, ok, err := parseByteRange(opts.Range, int64(len(data)))
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		// Unsupported range forms (e.g. multiple ranges) are ignored and the full body is served
+		return full, contentType, nil
+	}
+
+	return &models.InstanceBody{
+		ReadCloser:    io.NopCloser(bytes.NewReader(data[start : end+1])),
+		ContentLength: end - start + 1,
+		ContentRange:  fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)),
+	}, contentType, nil
+}
+
+// parseByteRange parses a single "bytes=start-end" range against a body of the given size.
+// It returns ok=false for range forms it does not handle.
+func parseByteRange(header string, size int64) (int64, int64, bool, error) {
+	spec, found := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+
+	startStr, endStr, found := strings.Cut(spec, "-")
+	if !found {
+		return 0, 0, false, nil
+	}
+
+	var start, end int64
+	switch {
+	case startStr == "":
+		// Suffix range: last N bytes
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false, ErrRangeNotSatisfiable
+		}
+		if n > size {
+			n = size
+		}
+		start, end = size-n, size-1
+	default:
+		var err error
+		start, err = strconv.ParseInt(startStr, 10, 64)
+		if err != nil {
+			return 0, 0, false, nil
+		}
+		end = size - 1
+		if endStr != "" {
+			end, err = strconv.ParseInt(endStr, 10, 64)
+			if err != nil {
+				return 0, 0, false, nil
+			}
+			if end >= size {
+				end = size - 1
+			}
+		}
+	}
+
+	if start < 0 || start >= size || start > end {
+		return 0, 0, false, ErrRangeNotSatisfiable
+	}
+
+	return start, end, true, nil
+}
+
+// GetThumbnail returns a JPEG thumbnail for an instance, caching the rendered result
+func (s *PACSService) GetThumbnail(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionRender, studyUID, seriesUID, instanceUID); err != nil {
+		return nil, err
+	}
+	cacheKey := s.cacheKey(ctx, tenantID, studyUID, seriesUID, instanceUID, thumbnailCacheSuffix(opts))
+
+	if data, err := s.cache.Get(ctx, cacheKey); err == nil {
+		return data, nil
+	}
+
+	_, adapter, err := s.route(withStudyHint(ctx, studyUID), tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := adapter.GetThumbnail(ctx, studyUID, seriesUID, instanceUID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thumbnail: %w", err)
+	}
+
+	s.cacheThumbnail(ctx, tenantID, cacheKey, data)
+	return data, nil
+}
+
+// GetSeriesThumbnail returns a thumbnail of the representative (middle) instance of a series
+func (s *PACSService) GetSeriesThumbnail(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID string, opts thumbnail.Options) ([]byte, error) {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionRender, studyUID, seriesUID, ""); err != nil {
+		return nil, err
+	}
+	cacheKey := s.cacheKey(ctx, tenantID, studyUID, seriesUID, "", thumbnailCacheSuffix(opts))
+
+	if data, err := s.cache.Get(ctx, cacheKey); err == nil {
+		return data, nil
+	}
+
+	instanceUID, err := s.representativeInstance(ctx, tenantID, studyUID, seriesUID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.GetThumbnail(ctx, tenantID, studyUID, seriesUID, instanceUID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheThumbnail(ctx, tenantID, cacheKey, data)
+	return data, nil
+}
+
+// GetStudyThumbnail returns a thumbnail of the first series in a study
+func (s *PACSService) GetStudyThumbnail(ctx context.Context, tenantID uuid.UUID, studyUID string, opts thumbnail.Options) ([]byte, error) {
+	if err := s.authorizeStudy(ctx, tenantID, StudyActionRender, studyUID, "", ""); err != nil {
+		return nil, err
+	}
+	cacheKey := s.cacheKey(ctx, tenantID, studyUID, "", "", thumbnailCacheSuffix(opts))
+
+	if data, err := s.cache.Get(ctx, cacheKey); err == nil {
+		return data, nil
+	}
+
+	series, err := s.FindSeries(ctx, tenantID, studyUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return nil, fmt.Errorf("study %s has no series", studyUID)
+	}
+
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].SeriesNumber < series[j].SeriesNumber
+	})
+
+	data, err := s.GetSeriesThumbnail(ctx, tenantID, studyUID, series[0].SeriesInstanceUID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheThumbnail(ctx, tenantID, cacheKey, data)
+	return data, nil
+}
+
+// representativeInstance picks the middle instance (by instance number) of a series
+func (s *PACSService) representativeInstance(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID string) (string, error) {
+	instances, err := s.FindInstances(ctx, tenantID, studyUID, seriesUID)
+	if err != nil {
+		return "", err
+	}
+	if len(instances) == 0 {
+		return "", fmt.Errorf("series %s has no instances", seriesUID)
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool {
+		return instances[i].InstanceNumber < instances[j].InstanceNumber
+	})
+
+	return instances[len(instances)/2].SOPInstanceUID, nil
+}
+
+func (s *PACSService) cacheThumbnail(ctx context.Context, tenantID uuid.UUID, cacheKey string, data []byte) {
+	ttl := s.ttls(ctx, tenantID).thumbnailTTL(s.cachedPrimary(ctx, tenantID))
+	if ttl <= 0 {
+		return
+	}
+	if err := s.cache.Set(ctx, cacheKey, data, ttl); err != nil {
+		log.Warn().Err(err).Str("cache_key", cacheKey).Msg("Failed to cache thumbnail")
+	}
+}
+
+func thumbnailCacheSuffix(opts thumbnail.Options) string {
+	return fmt.Sprintf("thumbnail:%d:%d", opts.Size, opts.Quality)
+}
+
+// dicomwebResources lists the DICOMweb resources served by the connector
+var dicomwebResources = []string{
+	"/studies",
+	"/studies/{study}/series",
+	"/studies/{study}/series/{series}/instances",
+	"/studies/{study}/metadata",
+	"/studies/{study}/series/{series}/metadata",
+	"/studies/{study}/series/{series}/instances/{instance}/metadata",
+	"/studies/{study}",
+	"/studies/{study}/series/{series}",
+	"/studies/{study}/series/{series}/instances/{instance}",
+	"/studies/{study}/series/{series}/instances/{instance}/frames/{frames}",
+	"/studies/{study}/series/{series}/instances/{instance}/rendered",
+	"/studies/{study}/thumbnail",
+	"/studies/{study}/series/{series}/thumbnail",
+	"/studies/{study}/series/{series}/instances/{instance}/thumbnail",
+	"/workitems",
+	"/workitems/{workitem}",
+	"/workitems/{workitem}/state",
+}
+
+// dicomwebHeadResources lists the resources that also answer HEAD, to count
+// the matches of a search or check that a study, series or instance exists
+var dicomwebHeadResources = []string{
+	"/studies",
+	"/studies/{study}/series",
+	"/studies/{study}/series/{series}/instances",
+	"/studies/{study}",
+	"/studies/{study}/series/{series}",
+	"/studies/{study}/series/{series}/instances/{instance}",
+}
+
+// unsupportedResources lists the DICOMweb resources a type of PACS cannot
+// serve; instances are not retrieved over DIMSE, so neither is anything
+// rendered from them
+var unsupportedResources = map[models.PACSType][]string{
+	models.PACSTypeDIMSE: {
+		"/studies/{study}",
+		"/studies/{study}/series/{series}",
+		"/studies/{study}/series/{series}/instances/{instance}",
+		"/studies/{study}/series/{series}/instances/{instance}/frames/{frames}",
+		"/studies/{study}/series/{series}/instances/{instance}/rendered",
+		"/studies/{study}/thumbnail",
+		"/studies/{study}/series/{series}/thumbnail",
+		"/studies/{study}/series/{series}/instances/{instance}/thumbnail",
+	},
+}
+
+// dimseSOPClasses maps DIMSE adapter capabilities to the SOP classes they negotiate
+var dimseSOPClasses = map[string]string{
+	"C-ECHO": "1.2.840.10008.1.1",           // Verification
+	"C-FIND": "1.2.840.10008.5.1.4.1.2.2.1", // Study Root Query/Retrieve - FIND
+	"C-MOVE": "1.2.840.10008.5.1.4.1.2.2.2", // Study Root Query/Retrieve - MOVE
+	"C-GET":  "1.2.840.10008.5.1.4.1.2.2.3", // Study Root Query/Retrieve - GET
+}
+
+// supportedTransferSyntaxes lists the transfer syntaxes the connector can decode
+var supportedTransferSyntaxes = []string{
+	"1.2.840.10008.1.2",      // Implicit VR Little Endian
+	"1.2.840.10008.1.2.1",    // Explicit VR Little Endian
+	"1.2.840.10008.1.2.2",    // Explicit VR Big Endian
+	"1.2.840.10008.1.2.4.50", // JPEG Baseline
+	"1.2.840.10008.1.2.4.51", // JPEG Extended
+	"1.2.840.10008.1.2.4.70", // JPEG Lossless SV1
+	"1.2.840.10008.1.2.4.90", // JPEG 2000 Lossless
+	"1.2.840.10008.1.2.4.91", // JPEG 2000
+	"1.2.840.10008.1.2.5",    // RLE Lossless
+}
+
+// GetCapabilities describes the services available for a tenant's primary PACS
+func (s *PACSService) GetCapabilities(ctx context.Context, tenantID uuid.UUID) (*models.CapabilitiesResponse, error) {
+	adapter, err := s.GetAdapter(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	services := adapter.Capabilities()
+	response := &models.CapabilitiesResponse{
+		PACSType:         adapter.Type(),
+		Services:         services,
+		Resources:        dicomwebResources,
+		HeadResources:    dicomwebHeadResources,
+		Unsupported:      unsupportedResources[adapter.Type()],
+		TransferSyntaxes: supportedTransferSyntaxes,
+		MediaTypes: []string{
+			"application/dicom+json",
+			"application/dicom",
+			`multipart/related; type="application/dicom"`,
+			`multipart/related; type="application/octet-stream"`,
+			"image/jpeg",
+			"video/mp4",
+		},
+	}
+
+	if len(response.Unsupported) > 0 {
+		response.Resources = nil
+		for _, resource := range dicomwebResources {
+			if !slices.Contains(response.Unsupported, resource) {
+				response.Resources = append(response.Resources, resource)
+			}
+		}
+	}
+
+	for _, service := range services {
+		if uid, ok := dimseSOPClasses[service]; ok {
+			response.SOPClasses = append(response.SOPClasses, uid)
+		}
+	}
+
+	return response, nil
+}
+
+// Add these methods to the PACSService
 
 // GetPACSConfigs retrieves all PACS configurations for a tenant
 func (s *PACSService) GetPACSConfigs(ctx context.Context, tenantID uuid.UUID) ([]models.PACSConfig, error) {
@@ -209,11 +1706,55 @@ func (s *PACSService) GetPACSConfigs(ctx context.Context, tenantID uuid.UUID) ([
 	return configs, nil
 }
 
-// GetPACSConfig retrieves a specific PACS configuration
-func (s *PACSService) GetPACSConfig(ctx context.Context, configID uuid.UUID) (*models.PACSConfig, error) {
-	config, err := s.pacsRepo.GetByID(ctx, configID)
+// GetPACSConfig retrieves one of a tenant's PACS configurations, or
+// ErrPACSConfigNotFound when the tenant has no such config
+func (s *PACSService) GetPACSConfig(ctx context.Context, tenantID, configID uuid.UUID) (*models.PACSConfig, error) {
+	return s.tenantPACSConfig(ctx, tenantID, configID)
+}
+
+// DeleteStudy deletes a study from the tenant's PACS, records an audit entry and
+// invalidates every cached object belonging to the study
+func (s *PACSService) DeleteStudy(ctx context.Context, tenantID uuid.UUID, studyUID, ipAddress, userAgent string) error {
+	start := time.Now()
+
+	_, adapter, err := s.route(withStudyHint(ctx, studyUID), tenantID)
+	if err == nil {
+		err = adapter.DeleteStudy(ctx, studyUID)
+	}
+
+	entry := &models.AuditLog{
+		TenantID:     tenantID,
+		Action:       "study.delete",
+		ResourceType: "study",
+		ResourceUID:  studyUID,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Status:       "success",
+		Duration:     time.Since(start).Milliseconds(),
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get PACS config: %w", err)
+		entry.Status = "failure"
+		entry.ErrorMessage = err.Error()
 	}
-	return config, nil
+	if auditErr := s.recordAudit(ctx, entry); auditErr != nil {
+		log.Error().Err(auditErr).Str("study_uid", studyUID).Msg("Failed to record study deletion audit entry")
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete study: %w", err)
+	}
+
+	for _, pattern := range []string{s.cacheKey(ctx, tenantID, studyUID, "", "", "*"), studySearchCachePattern(s.cachePrefix(ctx, tenantID))} {
+		if err := s.cache.Clear(ctx, pattern); err != nil {
+			log.Warn().Err(err).Str("study_uid", studyUID).Msg("Failed to invalidate cache for deleted study")
+		}
+	}
+	s.forgetIndexedStudy(ctx, tenantID, studyUID, false)
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("study_uid", studyUID).
+		Msg("Study deleted")
+
+	return nil
 }
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// ErrSyncStateNotFound is returned when the PACS synchronization has not run for a tenant
+var ErrSyncStateNotFound = errors.New("pacs synchronization has not run for the tenant")
+
+// syncAccessionPrefixes are the first characters of accession numbers a day is
+// split by when its search reaches the tenant's result cap
+const syncAccessionPrefixes = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// SyncConfig configures the PACS synchronization
+type SyncConfig struct {
+	Interval    time.Duration // how often each tenant's primary PACS is searched
+	InitialDays int           // days of studies the first run of a tenant indexes
+	OverlapDays int           // days before the last run's day searched again, for studies sent late
+}
+
+// SyncService keeps the study_index table in step with each tenant's primary
+// PACS. Every interval it searches the PACS for the studies dated from the day
+// of the tenant's last run to today and indexes them, publishing
+// study.discovered for studies not indexed before, so the connector notices
+// new studies without waiting for client queries. QIDO cannot search by
+// modification time, so studies sent late are only found within the overlap.
+// Connector instances sharing the database take turns per tenant.
+type SyncService struct {
+	pacsService *PACSService
+	pacsRepo    *repository.PACSRepository
+	repo        *repository.StudyIndexRepository
+	config      SyncConfig
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSyncService creates a PACS synchronization service; call Start to run its schedule
+func NewSyncService(pacsService *PACSService, pacsRepo *repository.PACSRepository, repo *repository.StudyIndexRepository, config SyncConfig) *SyncService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SyncService{
+		pacsService: pacsService,
+		pacsRepo:    pacsRepo,
+		repo:        repo,
+		config:      config,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start launches the schedule; the first run starts right away
+func (s *SyncService) Start() {
+	s.wg.Add(1)
+	go s.run()
+}
+
+// Stop cancels a running synchronization and waits for the schedule to exit
+func (s *SyncService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// GetSyncState returns the outcome of the tenant's last synchronization run
+func (s *SyncService) GetSyncState(ctx context.Context, tenantID uuid.UUID) (*models.StudySyncState, error) {
+	state, err := s.repo.GetSyncState(ctx, tenantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSyncStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if state.Indexed, err = s.repo.CountStudies(ctx, tenantID); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *SyncService) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.syncAll()
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncAll synchronizes every tenant with an active primary PACS config whose
+// last run started at least an interval ago
+func (s *SyncService) syncAll() {
+	primaries, err := s.pacsRepo.GetActivePrimaries(s.ctx)
+	if err != nil {
+		if s.ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to list tenants for PACS synchronization")
+		}
+		return
+	}
+
+	for _, config := range primaries {
+		if s.ctx.Err() != nil {
+			return
+		}
+		// Runs are claimed slightly early so a tick right at the interval isn't skipped
+		state, claimed, err := s.repo.ClaimSync(s.ctx, config.TenantID, time.Now(), s.config.Interval-s.config.Interval/10)
+		if err != nil {
+			log.Error().Err(err).Str("tenant_id", config.TenantID.String()).Msg("Failed to start PACS synchronization")
+			continue
+		}
+		if claimed {
+			s.syncTenant(state)
+		}
+	}
+}
+
+// syncTenant indexes the studies dated from the day of the tenant's last
+// successful run, less the overlap, to today, one day after another
+func (s *SyncService) syncTenant(state *models.StudySyncState) {
+	ctx := s.ctx
+	start := time.Now()
+	logger := log.With().Str("tenant_id", state.TenantID.String()).Logger()
+
+	initial := state.SyncedAt == nil
+	first := start.AddDate(0, 0, 1-s.config.InitialDays)
+	if !initial {
+		first = state.SyncedAt.AddDate(0, 0, -s.config.OverlapDays)
+	}
+	from := first.Format("20060102")
+	to := start.Format("20060102")
+
+	studies, added, truncated := 0, 0, 0
+	indexedFrom := state.IndexedFrom
+	if indexedFrom == "" {
+		indexedFrom = from
+	}
+	var err error
+	for day := first; day.Format("20060102") <= to; day = day.AddDate(0, 0, 1) {
+		var found, discovered, cut int
+		found, discovered, cut, err = s.syncDay(ctx, state.TenantID, day.Format("20060102"), initial)
+		if err != nil {
+			break
+		}
+		studies += found
+		added += discovered
+		truncated += cut
+		if cut > 0 {
+			// Studies of a truncated day may be missing from the index
+			indexedFrom = day.AddDate(0, 0, 1).Format("20060102")
+		}
+	}
+
+	if err != nil {
+		state.Error = err.Error()
+		if ctx.Err() != nil {
+			state.Error = "the connector shut down before the synchronization finished"
+		}
+	} else {
+		// Runs search on from the day of the previous one, so every day since
+		// the first successful run has been searched
+		state.IndexedFrom = indexedFrom
+		state.SyncedAt = &start
+		state.SyncedFrom = from
+		state.SyncedTo = to
+		state.Studies = studies
+		state.NewStudies = added
+		state.TruncatedWindows = truncated
+		state.Error = ""
+	}
+	// The outcome is saved even when the run was cancelled by shutdown
+	if updateErr := s.repo.UpdateSyncState(context.Background(), state); updateErr != nil {
+		logger.Error().Err(updateErr).Msg("Failed to record PACS synchronization")
+	}
+
+	logger = logger.With().
+		Str("from", from).
+		Str("to", to).
+		Int("studies", studies).
+		Int("new_studies", added).
+		Dur("duration", time.Since(start)).
+		Logger()
+	switch {
+	case err != nil && ctx.Err() == nil:
+		logger.Error().Err(err).Msg("PACS synchronization failed")
+	case truncated > 0:
+		logger.Warn().Int("truncated_windows", truncated).Msg("PACS synchronization missed studies beyond the tenant's result cap")
+	case err == nil:
+		logger.Info().Msg("PACS synchronized")
+	}
+}
+
+// syncDay indexes the tenant's studies dated on a day and reports how many
+// were found, how many were new and how many searches were truncated. New
+// studies are published unless this is the tenant's first run.
+func (s *SyncService) syncDay(ctx context.Context, tenantID uuid.UUID, date string, initial bool) (int, int, int, error) {
+	studies, pacsConfigID, truncated, err := s.pacsService.findStudiesOfDay(ctx, tenantID, date)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	studies = slices.DeleteFunc(studies, func(study models.Study) bool { return study.StudyInstanceUID == "" })
+	if len(studies) == 0 {
+		return 0, 0, truncated, nil
+	}
+
+	uids := make([]string, len(studies))
+	for i, study := range studies {
+		uids[i] = study.StudyInstanceUID
+	}
+	indexed, err := s.repo.IndexedUIDs(ctx, tenantID, uids)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	now := time.Now()
+	entries := make([]models.StudyIndexEntry, len(studies))
+	for i, study := range studies {
+		entries[i] = newStudyIndexEntry(tenantID, pacsConfigID, study, now)
+	}
+	if err := s.repo.Upsert(ctx, entries); err != nil {
+		return 0, 0, 0, err
+	}
+
+	added := 0
+	for _, study := range studies {
+		if indexed[study.StudyInstanceUID] {
+			continue
+		}
+		added++
+		if initial {
+			continue
+		}
+		s.pacsService.publish(tenantID, models.EventStudyDiscovered, map[string]any{
+			"study_uid":        study.StudyInstanceUID,
+			"accession_number": study.AccessionNumber,
+			"patient_id":       study.PatientID,
+			"study_date":       study.StudyDate,
+			"modalities":       study.ModalitiesInStudy,
+			"pacs_config_id":   pacsConfigID,
+		})
+	}
+	return len(studies), added, truncated, nil
+}
+
+// findStudiesOfDay lists the tenant's studies dated on a day (YYYYMMDD) on the
+// PACS its searches are routed to, bypassing the query cache. A day reaching
+// the tenant's result cap is searched again by the first character of the
+// accession number; it returns the PACS config searched and how many searches
+// were still truncated.
+func (s *PACSService) findStudiesOfDay(ctx context.Context, tenantID uuid.UUID, date string) ([]models.Study, uuid.UUID, int, error) {
+	params := models.QueryParams{StudyDate: date}
+	config, adapter, err := s.route(withQueryHints(ctx, params), tenantID)
+	if err != nil {
+		return nil, uuid.Nil, 0, err
+	}
+
+	studies, truncated, err := s.findStudiesOn(ctx, config, adapter, params)
+	if err != nil {
+		return nil, uuid.Nil, 0, err
+	}
+	if !truncated {
+		return studies, config.ID, 0, nil
+	}
+
+	seen := make(map[string]bool, len(studies))
+	for _, study := range studies {
+		seen[study.StudyInstanceUID] = true
+	}
+	windows := 0
+	for _, prefix := range syncAccessionPrefixes {
+		window := params
+		window.AccessionNumber = string(prefix) + "*"
+		found, truncated, err := s.findStudiesOn(ctx, config, adapter, window)
+		if err != nil {
+			return nil, uuid.Nil, 0, fmt.Errorf("failed to search accession numbers %s on %s: %w", window.AccessionNumber, date, err)
+		}
+		if truncated {
+			windows++
+		}
+		for _, study := range found {
+			if !seen[study.StudyInstanceUID] {
+				seen[study.StudyInstanceUID] = true
+				studies = append(studies, study)
+			}
+		}
+	}
+	return studies, config.ID, windows, nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// PatientTimeline returns a patient's studies from every PACS of the tenant,
+// merged by Study Instance UID and ordered newest first. Each study lists the
+// archives holding it, whether its metadata is cached and whether any of those
+// archives is healthy. A PACS that fails is reported as unavailable; an error
+// is returned only when all of them failed. A tenant that turned fan-out off
+// only searches its primary. Studies the study authorizer denies the client are
+// left out.
+func (s *PACSService) PatientTimeline(ctx context.Context, tenantID uuid.UUID, patientID string) (*models.PatientTimeline, error) {
+	configs, err := s.fanOutConfigs(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.fanOutEnabled(ctx, tenantID) {
+		configs = configs[:1]
+	}
+
+	params := models.QueryParams{PatientID: patientID}
+	results := make([]fanOutResult, len(configs))
+	var wg sync.WaitGroup
+	for i := range results {
+		results[i].config = &configs[i]
+		wg.Add(1)
+		go func(result *fanOutResult) {
+			defer wg.Done()
+			var adapter adapters.PACSAdapter
+			if adapter, result.err = s.adapterFor(ctx, result.config); result.err != nil {
+				return
+			}
+			result.studies, result.truncated, result.err = s.findStudiesOn(ctx, result.config, adapter, params)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	timeline := &models.PatientTimeline{PatientID: patientID, Studies: []models.TimelineStudy{}}
+	var firstErr error
+	index := make(map[string]int)
+	for _, result := range results {
+		source := models.StudySource{PACSConfigID: result.config.ID.String(), Name: result.config.Name}
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			log.Warn().
+				Err(result.err).
+				Str("tenant_id", tenantID.String()).
+				Str("pacs", result.config.Name).
+				Msg("Patient timeline search failed on a PACS, returning partial results")
+			timeline.Unavailable = append(timeline.Unavailable, source)
+			continue
+		}
+		timeline.Truncated = timeline.Truncated || result.truncated
+
+		for _, study := range result.studies {
+			// The primary's copy of a study held by several archives wins
+			if i, ok := index[study.StudyInstanceUID]; ok && study.StudyInstanceUID != "" {
+				entry := &timeline.Studies[i]
+				entry.Sources = append(entry.Sources, source)
+				entry.Available = entry.Available || !result.config.Degraded
+				continue
+			}
+			index[study.StudyInstanceUID] = len(timeline.Studies)
+			study.Source = &source
+			timeline.Studies = append(timeline.Studies, models.TimelineStudy{
+				Study:     study,
+				Sources:   []models.StudySource{source},
+				Available: !result.config.Degraded,
+			})
+			s.rememberStudySource(ctx, tenantID, study.StudyInstanceUID, result.config.ID)
+		}
+	}
+	if len(timeline.Unavailable) == len(results) {
+		return nil, firstErr
+	}
+
+	studies := make([]models.Study, len(timeline.Studies))
+	for i, study := range timeline.Studies {
+		studies[i] = study.Study
+	}
+	studies, err = s.authorizedStudies(ctx, tenantID, studies)
+	if err != nil {
+		return nil, err
+	}
+	if len(studies) < len(timeline.Studies) {
+		allowed := make(map[string]bool, len(studies))
+		for _, study := range studies {
+			allowed[study.StudyInstanceUID] = true
+		}
+		timeline.Studies = slices.DeleteFunc(timeline.Studies, func(study models.TimelineStudy) bool {
+			return !allowed[study.StudyInstanceUID]
+		})
+	}
+
+	for i := range timeline.Studies {
+		study := &timeline.Studies[i]
+		if study.StudyInstanceUID == "" {
+			continue
+		}
+		key := s.cacheKey(ctx, tenantID, study.StudyInstanceUID, "", "", "metadata")
+		cached, err := s.cache.Exists(ctx, key)
+		if err != nil {
+			log.Warn().Err(err).Str("cache_key", key).Msg("Failed to check the cache for a timeline study")
+		}
+		study.Cached = cached
+	}
+
+	// DICOM dates and times sort as strings; studies without a date go last
+	slices.SortStableFunc(timeline.Studies, func(a, b models.TimelineStudy) int {
+		if (a.StudyDate == "") != (b.StudyDate == "") {
+			if a.StudyDate == "" {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(b.StudyDate+b.StudyTime, a.StudyDate+a.StudyTime)
+	})
+	return timeline, nil
+}
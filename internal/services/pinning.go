@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/cache"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrStudyNotPinned is returned when unpinning a study that is not pinned
+	ErrStudyNotPinned = errors.New("study not pinned")
+	// ErrInvalidPin is returned when a pin request is malformed
+	ErrInvalidPin = errors.New("invalid pin")
+)
+
+const (
+	// maxStudyUIDLength is the longest UID DICOM allows
+	maxStudyUIDLength = 64
+	// pinRefreshTimeout bounds the refresh of one pinned study
+	pinRefreshTimeout = 30 * time.Minute
+	// pinQueueSize is how many newly pinned studies wait for their first refresh
+	pinQueueSize = 100
+)
+
+// PinConfig configures the refresh of pinned studies
+type PinConfig struct {
+	RefreshInterval time.Duration // how often pinned studies are checked for instances missing from the cache
+	TTL             time.Duration // how long instances fetched for a pinned study stay cached
+}
+
+// PinService keeps pinned studies in the cache. The caches skip the entries
+// of pinned studies when they evict, and a refresher fetches the instances of
+// pinned studies that are missing anyway, e.g. after they expired or Redis
+// evicted them. Pins made through another connector instance are picked up at
+// the next refresh.
+type PinService struct {
+	pacsService *PACSService
+	repo        *repository.PinRepository
+	pins        *cache.Pins
+	config      PinConfig
+
+	queued chan models.PinnedStudy // newly pinned studies to refresh right away
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPinService creates a pin service marking pinned studies in pins; call
+// Start to run its refresher
+func NewPinService(pacsService *PACSService, repo *repository.PinRepository, pins *cache.Pins, config PinConfig) *PinService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PinService{
+		pacsService: pacsService,
+		repo:        repo,
+		pins:        pins,
+		config:      config,
+		queued:      make(chan models.PinnedStudy, pinQueueSize),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start launches the refresher
+func (p *PinService) Start() {
+	p.wg.Add(1)
+	go p.run()
+}
+
+// Stop cancels a running refresh and waits for the refresher to exit
+func (p *PinService) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+// PinStudy pins a study of the tenant and queues the caching of its instances.
+// Pinning a pinned study again replaces its reason.
+func (p *PinService) PinStudy(ctx context.Context, tenantID uuid.UUID, studyUID string, req *models.PinRequest) (*models.PinnedStudy, error) {
+	if len(studyUID) > maxStudyUIDLength {
+		return nil, fmt.Errorf("%w: study UID must be at most %d characters", ErrInvalidPin, maxStudyUIDLength)
+	}
+
+	pin := &models.PinnedStudy{TenantID: tenantID, StudyUID: studyUID, Reason: req.Reason}
+	if err := p.repo.Save(ctx, pin); err != nil {
+		return nil, err
+	}
+	p.pins.Pin(tenantID.String(), studyUID)
+
+	// The saved row keeps its ID and outcome when the study was pinned before
+	saved, err := p.repo.Get(ctx, tenantID, studyUID)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case p.queued <- *saved:
+	default:
+		log.Warn().Str("study_uid", studyUID).Msg("Pin queue full; the study is cached at the next refresh")
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("study_uid", studyUID).
+		Msg("Study pinned")
+	return saved, nil
+}
+
+// GetPinnedStudies returns the tenant's pinned studies with the outcome of
+// their last refresh
+func (p *PinService) GetPinnedStudies(ctx context.Context, tenantID uuid.UUID) ([]models.PinnedStudy, error) {
+	return p.repo.GetByTenantID(ctx, tenantID)
+}
+
+// UnpinStudy unpins a study of the tenant. Its cached entries stay until they
+// expire or are evicted.
+func (p *PinService) UnpinStudy(ctx context.Context, tenantID uuid.UUID, studyUID string) error {
+	deleted, err := p.repo.Delete(ctx, tenantID, studyUID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrStudyNotPinned
+	}
+	p.pins.Unpin(tenantID.String(), studyUID)
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("study_uid", studyUID).
+		Msg("Study unpinned")
+	return nil
+}
+
+func (p *PinService) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.config.RefreshInterval)
+	defer ticker.Stop()
+
+	// The first round runs at startup so the pins are known to the caches
+	for {
+		p.refreshAll()
+
+	wait:
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case pin := <-p.queued:
+				p.refresh(pin)
+			case <-ticker.C:
+				break wait
+			}
+		}
+	}
+}
+
+// refreshAll reloads the pinned studies of every tenant into the pins and
+// refreshes each of them
+func (p *PinService) refreshAll() {
+	pinned, err := p.repo.GetAll(p.ctx)
+	if err != nil {
+		if p.ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to load pinned studies")
+		}
+		return
+	}
+
+	studies := make(map[string][]string)
+	for _, pin := range pinned {
+		tenant := pin.TenantID.String()
+		studies[tenant] = append(studies[tenant], pin.StudyUID)
+	}
+	p.pins.Replace(studies)
+
+	for _, pin := range pinned {
+		if p.ctx.Err() != nil {
+			return
+		}
+		p.refresh(pin)
+	}
+}
+
+// refresh caches the instances of a pinned study that are missing from the
+// cache and records the outcome
+func (p *PinService) refresh(pin models.PinnedStudy) {
+	ctx, cancel := context.WithTimeout(p.ctx, pinRefreshTimeout)
+	defer cancel()
+
+	start := time.Now()
+	total, fetched, err := p.pacsService.cacheStudy(ctx, pin.TenantID, pin.StudyUID, p.config.TTL)
+
+	logger := log.With().
+		Str("tenant_id", pin.TenantID.String()).
+		Str("study_uid", pin.StudyUID).
+		Int("instances", total).
+		Int("instances_fetched", fetched).
+		Dur("duration", time.Since(start)).
+		Logger()
+
+	now := time.Now()
+	pin.RefreshedAt = &now
+	pin.TotalInstances = total
+	pin.FetchedInstances = fetched
+	pin.Error = ""
+	if err != nil {
+		pin.Error = err.Error()
+	}
+	// A study unpinned meanwhile updates nothing
+	if updateErr := p.repo.UpdateRefresh(context.Background(), &pin); updateErr != nil {
+		logger.Error().Err(updateErr).Msg("Failed to record pinned study refresh")
+	}
+
+	switch {
+	case err != nil:
+		if p.ctx.Err() == nil {
+			logger.Error().Err(err).Msg("Pinned study refresh failed")
+		}
+	case fetched > 0:
+		logger.Info().Msg("Pinned study instances fetched again")
+	}
+}
+
+// cacheStudy caches every instance of a study that is not cached, from the
+// PACS the study is routed to, and reports how many instances the study has
+// and how many were fetched
+func (s *PACSService) cacheStudy(ctx context.Context, tenantID uuid.UUID, studyUID string, ttl time.Duration) (int, int, error) {
+	ctx = WithRouteHints(ctx, RouteHints{StudyUID: studyUID})
+	_, adapter, err := s.route(ctx, tenantID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	targets, err := s.studyInstances(ctx, tenantID, studyUID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fetched := 0
+	for _, target := range targets {
+		if err := ctx.Err(); err != nil {
+			return len(targets), fetched, err
+		}
+		added, _, err := s.prefetchInstance(ctx, tenantID, adapter, target, ttl)
+		if err != nil {
+			return len(targets), fetched, err
+		}
+		if added {
+			fetched++
+		}
+	}
+	return len(targets), fetched, nil
+}
@@ -0,0 +1,721 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/hl7"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Prefetch errors
+var (
+	ErrPrefetchJobNotFound    = errors.New("prefetch job not found")
+	ErrInvalidPrefetchRequest = errors.New("invalid prefetch request")
+)
+
+const (
+	// prefetchQueueSize bounds the orders waiting for a prefetch worker
+	prefetchQueueSize = 256
+	// prefetchJobTimeout bounds the prefetch of one patient's priors
+	prefetchJobTimeout = 15 * time.Minute
+	// prefetchStudyJobTimeout bounds a prefetch requested through the API
+	prefetchStudyJobTimeout = time.Hour
+	// prefetchMaxStudies bounds the studies of one prefetch request
+	prefetchMaxStudies = 100
+	// prefetchProgressInterval is how often a running job's progress is saved
+	prefetchProgressInterval = 2 * time.Second
+)
+
+// PrefetchOptions select the prior studies warmed for an order or an opened study
+type PrefetchOptions struct {
+	MaxPriors    int           // most recent relevant prior studies to warm
+	Lookback     time.Duration // how far back prior studies are considered; zero for no limit
+	SameModality bool          // only priors sharing a modality with the current study, when it is known
+	SameBodyPart bool          // only priors with a series of the current study's body part, when it is known
+	TTL          time.Duration // how long prefetched instances stay cached
+}
+
+// CurrentStudy is the ordered or opened study whose relevant priors are prefetched
+type CurrentStudy struct {
+	PatientID       string
+	AccessionNumber string   // excluded from the priors when set
+	StudyUID        string   // excluded from the priors when set
+	Modalities      []string // empty when unknown
+	BodyPart        string   // empty when unknown
+}
+
+// PrefetchPriors caches the instances of a patient's most recent prior studies
+// relevant to the current study, which is itself excluded. It returns the
+// number of instances added to the cache.
+func (s *PACSService) PrefetchPriors(ctx context.Context, tenantID uuid.UUID, current CurrentStudy, opts PrefetchOptions) (int, error) {
+	_, adapter, err := s.route(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	params := models.QueryParams{PatientID: current.PatientID}
+	if opts.Lookback > 0 {
+		params.StudyDate = time.Now().Add(-opts.Lookback).Format("20060102") + "-"
+	}
+	sameModality := opts.SameModality && len(current.Modalities) > 0
+	if sameModality {
+		params.Modalities = current.Modalities
+	}
+
+	studies, _, err := s.FindStudies(ctx, tenantID, params)
+	if err != nil {
+		return 0, err
+	}
+
+	candidates := studies[:0]
+	for _, study := range studies {
+		if current.AccessionNumber != "" && study.AccessionNumber == current.AccessionNumber {
+			continue
+		}
+		if current.StudyUID != "" && study.StudyInstanceUID == current.StudyUID {
+			continue
+		}
+		// Not every PACS matches on ModalitiesInStudy, so the studies are checked again
+		if sameModality && len(study.ModalitiesInStudy) > 0 && !sharesModality(study.ModalitiesInStudy, current.Modalities) {
+			continue
+		}
+		candidates = append(candidates, study)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].StudyDate+candidates[i].StudyTime > candidates[j].StudyDate+candidates[j].StudyTime
+	})
+
+	var priors []models.Study
+	for _, study := range candidates {
+		if opts.MaxPriors > 0 && len(priors) >= opts.MaxPriors {
+			break
+		}
+		if opts.SameBodyPart && current.BodyPart != "" {
+			relevant, err := s.hasBodyPart(ctx, tenantID, study.StudyInstanceUID, current.BodyPart)
+			if err != nil {
+				return 0, err
+			}
+			if !relevant {
+				continue
+			}
+		}
+		priors = append(priors, study)
+	}
+
+	cached := 0
+	for _, study := range priors {
+		targets, err := s.studyInstances(ctx, tenantID, study.StudyInstanceUID)
+		if err != nil {
+			return cached, err
+		}
+
+		for _, target := range targets {
+			if err := ctx.Err(); err != nil {
+				return cached, err
+			}
+			added, _, err := s.prefetchInstance(ctx, tenantID, adapter, target, opts.TTL)
+			if err != nil {
+				return cached, err
+			}
+			if added {
+				cached++
+			}
+		}
+	}
+
+	return cached, nil
+}
+
+// sharesModality reports whether a study has any of the modalities
+func sharesModality(studyModalities, modalities []string) bool {
+	return slices.ContainsFunc(studyModalities, func(modality string) bool {
+		return slices.ContainsFunc(modalities, func(m string) bool { return strings.EqualFold(m, modality) })
+	})
+}
+
+// hasBodyPart reports whether a series of a study examined the body part
+func (s *PACSService) hasBodyPart(ctx context.Context, tenantID uuid.UUID, studyUID, bodyPart string) (bool, error) {
+	series, err := s.FindSeries(ctx, tenantID, studyUID)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(series, func(se models.Series) bool {
+		return strings.EqualFold(se.BodyPartExamined, bodyPart)
+	}), nil
+}
+
+// prefetchTarget is an instance to warm
+type prefetchTarget struct {
+	studyUID       string
+	seriesUID      string
+	sopInstanceUID string
+}
+
+// studyInstances lists every instance of a study
+func (s *PACSService) studyInstances(ctx context.Context, tenantID uuid.UUID, studyUID string) ([]prefetchTarget, error) {
+	series, err := s.FindSeries(ctx, tenantID, studyUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []prefetchTarget
+	for _, se := range series {
+		instances, err := s.FindInstances(ctx, tenantID, studyUID, se.SeriesInstanceUID)
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range instances {
+			targets = append(targets, prefetchTarget{
+				studyUID:       studyUID,
+				seriesUID:      se.SeriesInstanceUID,
+				sopInstanceUID: instance.SOPInstanceUID,
+			})
+		}
+	}
+	return targets, nil
+}
+
+// prefetchInstance caches one instance and reports whether it was added and
+// how many bytes were read from the PACS. Instances already cached, gone from
+// the PACS or too large are skipped.
+func (s *PACSService) prefetchInstance(ctx context.Context, tenantID uuid.UUID, adapter adapters.PACSAdapter, target prefetchTarget, ttl time.Duration) (bool, int64, error) {
+	cacheKey := s.cacheKey(ctx, tenantID, target.studyUID, target.seriesUID, target.sopInstanceUID, "instance")
+	if ok, _ := s.cache.Exists(ctx, cacheKey); ok {
+		return false, 0, nil
+	}
+
+	data, contentType, err := adapter.GetInstance(ctx, target.studyUID, target.seriesUID, target.sopInstanceUID, models.RetrieveOptions{})
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			s.rememberMissing(ctx, tenantID, target.studyUID, target.seriesUID, target.sopInstanceUID, err)
+			return false, 0, nil
+		}
+		return false, 0, fmt.Errorf("failed to get instance: %w", err)
+	}
+
+	maxSize := s.cacheTTLs.maxInstanceSize()
+	raw, err := io.ReadAll(io.LimitReader(data, maxSize+1))
+	data.Close()
+	if err != nil {
+		return false, int64(len(raw)), fmt.Errorf("failed to read instance: %w", err)
+	}
+	if int64(len(raw)) > maxSize {
+		return false, int64(len(raw)), nil
+	}
+
+	if err := s.cache.Set(ctx, cacheKey, encodeCachedInstance(contentType, raw), ttl); err != nil {
+		return false, int64(len(raw)), fmt.Errorf("failed to cache instance: %w", err)
+	}
+	s.forgetMissing(ctx, tenantID, target.studyUID, target.seriesUID, target.sopInstanceUID)
+	return true, int64(len(raw)), nil
+}
+
+// PrefetchConfig configures the order-driven prefetch of prior studies and the
+// prefetch jobs requested through the API
+type PrefetchConfig struct {
+	DefaultTenant   uuid.UUID            // tenant for facilities without a mapping; uuid.Nil for none
+	FacilityTenants map[string]uuid.UUID // sending facility (MSH-4) to tenant
+	Workers         int
+	Options         PrefetchOptions
+	OnStudyOpen     bool // also prefetch the priors of studies whose metadata is loaded from the PACS
+}
+
+// prefetchJob is a queued prefetch of one patient's priors
+type prefetchJob struct {
+	tenantID uuid.UUID
+	current  CurrentStudy
+	priority string
+}
+
+func (j prefetchJob) key() string {
+	return j.tenantID.String() + ":" + j.current.PatientID
+}
+
+// prefetchTask is a queued prefetch: the priors of one patient, or a prefetch
+// job requested through the API when jobID is set
+type prefetchTask struct {
+	priors prefetchJob
+	jobID  uuid.UUID
+}
+
+// PrefetchService warms the cache in the background: with prior studies when
+// orders arrive over HL7, and with requested studies as pollable jobs. It
+// implements hl7.Handler; messages are queued and prefetched by the same workers,
+// STAT orders and jobs before routine ones.
+type PrefetchService struct {
+	pacsService *PACSService
+	repo        *repository.PrefetchRepository
+	config      PrefetchConfig
+
+	queue   *priorityQueue[prefetchTask]
+	mu      sync.Mutex
+	pending map[string]bool // queued or running prior prefetches by tenant and patient
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPrefetchService creates a prefetch service; call Start to run its workers
+func NewPrefetchService(pacsService *PACSService, repo *repository.PrefetchRepository, config PrefetchConfig) *PrefetchService {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &PrefetchService{
+		pacsService: pacsService,
+		repo:        repo,
+		config:      config,
+		queue:       newPriorityQueue[prefetchTask]("prefetch", prefetchQueueSize),
+		pending:     make(map[string]bool),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	if config.OnStudyOpen {
+		pacsService.studyOpened = p.StudyOpened
+	}
+	return p
+}
+
+// Start launches the prefetch workers
+func (p *PrefetchService) Start() {
+	for i := 0; i < p.config.Workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+}
+
+// Stop cancels running prefetches, waits for the workers to exit and fails the
+// prefetch jobs still queued, which do not survive a restart
+func (p *PrefetchService) Stop() {
+	p.cancel()
+	p.wg.Wait()
+
+	var queued []uuid.UUID
+	for _, task := range p.queue.drain() {
+		if task.jobID != uuid.Nil {
+			queued = append(queued, task.jobID)
+		}
+	}
+	if err := p.repo.FailUnfinished(context.Background(), queued, "the connector shut down before the prefetch started"); err != nil {
+		log.Error().Err(err).Msg("Failed to fail queued prefetch jobs")
+	}
+}
+
+// PrefetchStudies queues a job caching every instance of the requested
+// studies, given by their UIDs or an accession number. An accession number is
+// resolved to its studies when the job is created; study UIDs are not checked,
+// and studies unknown to the PACS add no instances.
+func (p *PrefetchService) PrefetchStudies(ctx context.Context, tenantID uuid.UUID, req *models.PrefetchRequest, ipAddress, userAgent string) (*models.PrefetchJob, error) {
+	accessionNumber := strings.TrimSpace(req.AccessionNumber)
+	studyUIDs := make([]string, 0, len(req.StudyUIDs))
+	seen := make(map[string]bool, len(req.StudyUIDs))
+	for _, uid := range req.StudyUIDs {
+		if uid = strings.TrimSpace(uid); uid != "" && !seen[uid] {
+			seen[uid] = true
+			studyUIDs = append(studyUIDs, uid)
+		}
+	}
+
+	switch {
+	case (len(studyUIDs) == 0) == (accessionNumber == ""):
+		return nil, fmt.Errorf("%w: give either study_uids or accession_number", ErrInvalidPrefetchRequest)
+	case len(studyUIDs) > prefetchMaxStudies:
+		return nil, fmt.Errorf("%w: at most %d studies can be prefetched at once", ErrInvalidPrefetchRequest, prefetchMaxStudies)
+	}
+	priority, err := jobPriority(req.Priority, ErrInvalidPrefetchRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if accessionNumber != "" {
+		studies, _, err := p.pacsService.FindStudies(ctx, tenantID, models.QueryParams{AccessionNumber: accessionNumber, Limit: prefetchMaxStudies})
+		if err != nil {
+			return nil, err
+		}
+		if len(studies) == 0 {
+			return nil, ErrNotFound
+		}
+		for _, study := range studies {
+			studyUIDs = append(studyUIDs, study.StudyInstanceUID)
+		}
+	}
+
+	job := &models.PrefetchJob{
+		TenantID:        tenantID,
+		StudyUIDs:       studyUIDs,
+		AccessionNumber: accessionNumber,
+		Status:          models.PrefetchPending,
+		Priority:        priority,
+	}
+	if err := p.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if !p.queue.push(priority, prefetchTask{jobID: job.ID}) {
+		job.Status = models.PrefetchFailed
+		job.Error = "prefetch queue is full"
+		if err := p.repo.Update(ctx, job); err != nil {
+			log.Error().Err(err).Str("prefetch_id", job.ID.String()).Msg("Failed to fail rejected prefetch job")
+		}
+		return nil, fmt.Errorf("%w: prefetch queue is full", ErrUnavailable)
+	}
+
+	for _, studyUID := range studyUIDs {
+		entry := &models.AuditLog{
+			TenantID:     tenantID,
+			Action:       "study.prefetch",
+			ResourceType: "study",
+			ResourceUID:  studyUID,
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+			Status:       "success",
+		}
+		if err := p.pacsService.recordAudit(ctx, entry); err != nil {
+			log.Error().Err(err).Msg("Failed to record prefetch audit entry")
+		}
+	}
+
+	return job, nil
+}
+
+// GetPrefetchJob returns a tenant's prefetch job with its progress
+func (p *PrefetchService) GetPrefetchJob(ctx context.Context, tenantID, id uuid.UUID) (*models.PrefetchJob, error) {
+	job, err := p.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && job.TenantID != tenantID) {
+		return nil, ErrPrefetchJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// HandleMessage queues a prefetch for new and changed ORM^O01 and OMI^O23 orders.
+// Other messages are accepted and ignored.
+func (p *PrefetchService) HandleMessage(ctx context.Context, msg *hl7.Message) error {
+	msgType, trigger := msg.Type()
+	if !(msgType == "ORM" && trigger == "O01") && !(msgType == "OMI" && trigger == "O23") {
+		return nil
+	}
+
+	// Only new (NW) and changed (XO) orders are worth warming; cancellations are not
+	switch msg.Field("ORC", 1) {
+	case "", "NW", "XO":
+	default:
+		return nil
+	}
+
+	tenantID, ok := p.config.FacilityTenants[msg.SendingFacility()]
+	if !ok {
+		tenantID = p.config.DefaultTenant
+	}
+	if tenantID == uuid.Nil {
+		return fmt.Errorf("no tenant is configured for sending facility %q", msg.SendingFacility())
+	}
+
+	patientID := msg.Component("PID", 3, 1)
+	if patientID == "" {
+		return fmt.Errorf("PID-3 patient identifier is missing")
+	}
+
+	// OMI carries the accession number in IPC-1; ORM conventionally in OBR-18
+	accessionNumber := msg.Component("IPC", 1, 1)
+	if accessionNumber == "" {
+		accessionNumber = msg.Component("OBR", 18, 1)
+	}
+
+	// OMI carries the modality in IPC-5; ORM conventionally in OBR-24
+	current := CurrentStudy{PatientID: patientID, AccessionNumber: accessionNumber}
+	modality := msg.Component("IPC", 5, 1)
+	if modality == "" {
+		modality = msg.Component("OBR", 24, 1)
+	}
+	if modality != "" {
+		current.Modalities = []string{strings.ToUpper(modality)}
+	}
+
+	p.enqueue(prefetchJob{tenantID: tenantID, current: current, priority: orderPriority(msg)})
+	return nil
+}
+
+// orderPriority returns the prefetch priority of an order: STAT when the
+// order's priority, in TQ1-9 or the quantity/timing of ORC-7 or OBR-27, is S
+func orderPriority(msg *hl7.Message) string {
+	for _, priority := range []string{
+		msg.Component("TQ1", 9, 1),
+		msg.Component("ORC", 7, 6),
+		msg.Component("OBR", 27, 6),
+	} {
+		if priority != "" {
+			if strings.EqualFold(priority, "S") {
+				return models.JobPriorityStat
+			}
+			break
+		}
+	}
+	return models.JobPriorityRoutine
+}
+
+// StudyOpened queues a prefetch of the relevant priors of a study whose
+// metadata was loaded from the PACS, typically because a viewer opened it.
+// The patient, modalities and body part are taken from the metadata.
+func (p *PrefetchService) StudyOpened(tenantID uuid.UUID, studyUID string, metadata []models.Metadata) {
+	current := CurrentStudy{StudyUID: studyUID}
+	for _, m := range metadata {
+		if current.PatientID == "" {
+			current.PatientID = metadataString(m.Attributes, "00100020")
+		}
+		if current.AccessionNumber == "" {
+			current.AccessionNumber = metadataString(m.Attributes, "00080050")
+		}
+		if current.BodyPart == "" {
+			current.BodyPart = metadataString(m.Attributes, "00180015")
+		}
+		if modality := strings.ToUpper(metadataString(m.Attributes, "00080060")); modality != "" && !slices.Contains(current.Modalities, modality) {
+			current.Modalities = append(current.Modalities, modality)
+		}
+	}
+	if current.PatientID == "" {
+		return
+	}
+
+	p.enqueue(prefetchJob{tenantID: tenantID, current: current, priority: models.JobPriorityRoutine})
+}
+
+// metadataString returns the first value of a string attribute in DICOM JSON
+// attributes, or "" when it is absent
+func metadataString(attrs map[string]interface{}, tag string) string {
+	element, ok := attrs[tag].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	switch values := element["Value"].(type) {
+	case []interface{}:
+		if len(values) > 0 {
+			if value, ok := values[0].(string); ok {
+				return strings.TrimSpace(value)
+			}
+		}
+	case []string:
+		if len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+	}
+	return ""
+}
+
+// enqueue queues a job unless the same patient is already queued or the queue is full
+func (p *PrefetchService) enqueue(job prefetchJob) {
+	p.mu.Lock()
+	if p.pending[job.key()] {
+		p.mu.Unlock()
+		return
+	}
+	p.pending[job.key()] = true
+	p.mu.Unlock()
+
+	if !p.queue.push(job.priority, prefetchTask{priors: job}) {
+		p.done(job)
+		log.Warn().
+			Str("tenant_id", job.tenantID.String()).
+			Str("patient_id", job.current.PatientID).
+			Msg("Prefetch queue full, dropping prior prefetch")
+	}
+}
+
+func (p *PrefetchService) done(job prefetchJob) {
+	p.mu.Lock()
+	delete(p.pending, job.key())
+	p.mu.Unlock()
+}
+
+func (p *PrefetchService) worker() {
+	defer p.wg.Done()
+
+	for {
+		task, ok := p.queue.pop(p.ctx)
+		if !ok {
+			return
+		}
+		if task.jobID != uuid.Nil {
+			p.runStudies(task.jobID)
+			continue
+		}
+		p.run(task.priors)
+	}
+}
+
+// run prefetches the priors of one job
+func (p *PrefetchService) run(job prefetchJob) {
+	defer p.done(job)
+
+	ctx, cancel := context.WithTimeout(p.ctx, prefetchJobTimeout)
+	defer cancel()
+
+	opts, enabled := p.pacsService.prefetchOptions(ctx, job.tenantID, p.config.Options)
+	if !enabled {
+		return
+	}
+
+	start := time.Now()
+	cached, err := p.pacsService.PrefetchPriors(ctx, job.tenantID, job.current, opts)
+
+	logger := log.With().
+		Str("tenant_id", job.tenantID.String()).
+		Str("patient_id", job.current.PatientID).
+		Str("accession_number", job.current.AccessionNumber).
+		Str("priority", job.priority).
+		Int("instances_cached", cached).
+		Dur("duration", time.Since(start)).
+		Logger()
+	event := map[string]any{
+		"job":              "prefetch",
+		"patient_id":       job.current.PatientID,
+		"accession_number": job.current.AccessionNumber,
+		"instances_cached": cached,
+		"status":           "success",
+	}
+	if err != nil {
+		event["status"] = "failure"
+		event["error"] = err.Error()
+	}
+	p.pacsService.publish(job.tenantID, models.EventRetrieveJobCompleted, event)
+
+	if err != nil {
+		logger.Error().Err(err).Msg("Prior study prefetch failed")
+		return
+	}
+	logger.Info().Msg("Prior studies prefetched")
+}
+
+// runStudies runs a prefetch job requested through the API and records its outcome
+func (p *PrefetchService) runStudies(id uuid.UUID) {
+	job, err := p.repo.GetByID(p.ctx, id)
+	if err != nil {
+		log.Error().Err(err).Str("prefetch_id", id.String()).Msg("Failed to load prefetch job")
+		return
+	}
+
+	job.Status = models.PrefetchRunning
+	if err := p.repo.Update(p.ctx, job); err != nil {
+		log.Error().Err(err).Str("prefetch_id", id.String()).Msg("Failed to start prefetch job")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, prefetchStudyJobTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err = p.prefetchStudies(ctx, job)
+
+	logger := log.With().
+		Str("tenant_id", job.TenantID.String()).
+		Str("prefetch_id", job.ID.String()).
+		Str("priority", job.Priority).
+		Int("studies", len(job.StudyUIDs)).
+		Int("instances", job.TotalInstances).
+		Int("instances_cached", job.CachedInstances).
+		Dur("duration", time.Since(start)).
+		Logger()
+
+	now := time.Now()
+	job.CompletedAt = &now
+	job.Status = models.PrefetchCompleted
+	if err != nil {
+		job.Status = models.PrefetchFailed
+		job.Error = err.Error()
+	}
+
+	// The job outcome is saved even when the prefetch was cancelled by shutdown
+	if updateErr := p.repo.Update(context.Background(), job); updateErr != nil {
+		logger.Error().Err(updateErr).Msg("Failed to record prefetch outcome")
+	}
+
+	event := map[string]any{
+		"job":              "prefetch",
+		"prefetch_id":      job.ID,
+		"study_uids":       job.StudyUIDs,
+		"instances":        job.TotalInstances,
+		"instances_cached": job.CachedInstances,
+		"status":           "success",
+	}
+	if job.AccessionNumber != "" {
+		event["accession_number"] = job.AccessionNumber
+	}
+	if err != nil {
+		event["status"] = "failure"
+		event["error"] = err.Error()
+	}
+	p.pacsService.publish(job.TenantID, models.EventRetrieveJobCompleted, event)
+
+	if err != nil {
+		logger.Error().Err(err).Msg("Study prefetch failed")
+		return
+	}
+	logger.Info().Msg("Studies prefetched")
+}
+
+// prefetchStudies lists the instances of the job's studies and caches them,
+// saving the job's progress as it goes
+func (p *PrefetchService) prefetchStudies(ctx context.Context, job *models.PrefetchJob) error {
+	_, adapter, err := p.pacsService.route(ctx, job.TenantID)
+	if err != nil {
+		return err
+	}
+
+	var targets []prefetchTarget
+	for _, studyUID := range job.StudyUIDs {
+		instances, err := p.pacsService.studyInstances(ctx, job.TenantID, studyUID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		targets = append(targets, instances...)
+	}
+
+	job.TotalInstances = len(targets)
+	p.saveProgress(ctx, job)
+
+	saved := time.Now()
+	for _, target := range targets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		added, _, err := p.pacsService.prefetchInstance(ctx, job.TenantID, adapter, target, p.config.Options.TTL)
+		if err != nil {
+			return err
+		}
+		job.ProcessedInstances++
+		if added {
+			job.CachedInstances++
+		}
+
+		if time.Since(saved) >= prefetchProgressInterval {
+			p.saveProgress(ctx, job)
+			saved = time.Now()
+		}
+	}
+	return nil
+}
+
+func (p *PrefetchService) saveProgress(ctx context.Context, job *models.PrefetchJob) {
+	if err := p.repo.Update(ctx, job); err != nil {
+		log.Warn().Err(err).Str("prefetch_id", job.ID.String()).Msg("Failed to save prefetch progress")
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// queryRevalidateTimeout bounds the background refresh of a stale QIDO result
+const queryRevalidateTimeout = time.Minute
+
+// cachedQuery is a cached QIDO result with the time it stops being fresh. A
+// stale result is still served for the staleness window past that time, while
+// it is fetched again in the background.
+type cachedQuery struct {
+	FreshUntil time.Time       `json:"fresh_until"`
+	Results    json.RawMessage `json:"results"`
+}
+
+// getCachedQuery decodes a cached QIDO result into v and reports whether it
+// was found and whether it is stale
+func (s *PACSService) getCachedQuery(ctx context.Context, key string, v any) (found, stale bool) {
+	var entry cachedQuery
+	if !s.getCachedJSON(ctx, key, &entry) || len(entry.Results) == 0 {
+		return false, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(entry.Results))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		log.Warn().Err(err).Str("cache_key", key).Msg("Failed to decode cached query results")
+		return false, false
+	}
+	return true, time.Now().After(entry.FreshUntil)
+}
+
+// setCachedQuery caches a QIDO result, fresh for ttl and kept for the
+// staleness window beyond it; a zero ttl disables caching
+func (s *PACSService) setCachedQuery(ctx context.Context, key string, v any, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	results, err := json.Marshal(v)
+	if err != nil {
+		log.Warn().Err(err).Str("cache_key", key).Msg("Failed to encode query results")
+		return
+	}
+	s.setCachedJSON(ctx, key, cachedQuery{FreshUntil: time.Now().Add(ttl), Results: results}, ttl+s.cacheTTLs.QueryStale)
+}
+
+// revalidate runs search in the background to replace a stale cached QIDO
+// result. Readers of the same stale result start one refresh between them;
+// the refresh outlives the request that found the result stale.
+func (s *PACSService) revalidate(ctx context.Context, key string, search func(ctx context.Context) error) {
+	if _, running := s.revalidating.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	go func() {
+		defer s.revalidating.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), queryRevalidateTimeout)
+		defer cancel()
+		if err := search(ctx); err != nil {
+			log.Warn().Err(err).Str("cache_key", key).Msg("Failed to revalidate stale query results")
+		}
+	}()
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/ratelimit"
+	"github.com/otcheredev/ris-dicom-connector/internal/thumbnail"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrRateLimited is returned when a PACS call waited longer than allowed for
+// the PACS's rate limit
+var ErrRateLimited = errors.New("PACS rate limit exceeded")
+
+// RateLimitConfig configures the default rate limits of tenants and PACS
+type RateLimitConfig struct {
+	Tenant  ratelimit.Limit // DICOMweb requests of each tenant
+	PACS    ratelimit.Limit // calls to each PACS config
+	MaxWait time.Duration   // how long a PACS call waits for its turn before failing with ErrRateLimited
+}
+
+// rateLimits is the limiter and default limits of a service with rate limiting
+type rateLimits struct {
+	limiter ratelimit.Limiter
+	config  RateLimitConfig
+}
+
+// EnableRateLimits limits the calls to each PACS, by its own limit or the
+// default one, and provides the tenant limits for TenantRateLimit
+func (s *PACSService) EnableRateLimits(limiter ratelimit.Limiter, config RateLimitConfig) {
+	s.rateLimits = &rateLimits{limiter: limiter, config: config}
+}
+
+// TenantRateLimit returns the request rate limit of a tenant: its own setting,
+// else the deployment default
+func (s *PACSService) TenantRateLimit(ctx context.Context, tenantID uuid.UUID) ratelimit.Limit {
+	if s.rateLimits == nil {
+		return ratelimit.Limit{}
+	}
+	settings := s.tenantSettings(ctx, tenantID)
+	if settings == nil {
+		return s.rateLimits.config.Tenant
+	}
+	return configuredLimit(settings.RateLimit, settings.RateBurst, s.rateLimits.config.Tenant)
+}
+
+// pacsRateLimit returns the call rate limit of a PACS config: its own, else
+// the deployment default
+func (s *PACSService) pacsRateLimit(config *models.PACSConfig) ratelimit.Limit {
+	return configuredLimit(config.RateLimit, config.RateBurst, s.rateLimits.config.PACS)
+}
+
+// configuredLimit applies a rate setting in requests per second over a
+// default: 0 keeps the default, -1 removes the limit
+func configuredLimit(rate, burst int, defaults ratelimit.Limit) ratelimit.Limit {
+	switch {
+	case rate < 0:
+		return ratelimit.Limit{}
+	case rate == 0:
+		return defaults
+	}
+	return ratelimit.PerSecond(rate, burst)
+}
+
+// limitAdapter wraps the adapter of a PACS config so that its calls wait for
+// the config's rate limit. Adapters of unlimited configs are returned as is.
+func (s *PACSService) limitAdapter(config *models.PACSConfig, adapter adapters.PACSAdapter) adapters.PACSAdapter {
+	if s.rateLimits == nil {
+		return adapter
+	}
+	limit := s.pacsRateLimit(config)
+	if limit.Unlimited() {
+		return adapter
+	}
+	return &limitedAdapter{
+		PACSAdapter: adapter,
+		limits:      s.rateLimits,
+		key:         "pacs:" + config.ID.String(),
+		name:        config.Name,
+		limit:       limit,
+	}
+}
+
+// limitedAdapter takes a token from its PACS's bucket before each call to the
+// PACS. Connection tests are not limited, so a busy PACS is not marked down.
+type limitedAdapter struct {
+	adapters.PACSAdapter
+	limits *rateLimits
+	key    string
+	name   string
+	limit  ratelimit.Limit
+}
+
+func (a *limitedAdapter) Unwrap() adapters.PACSAdapter {
+	return a.PACSAdapter
+}
+
+// wait waits for the PACS's turn. A failing limiter lets the call through.
+func (a *limitedAdapter) wait(ctx context.Context) error {
+	err := ratelimit.Wait(ctx, a.limits.limiter, a.key, a.limit, a.limits.config.MaxWait)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ratelimit.ErrLimited):
+		log.Warn().Str("pacs", a.name).Msg("PACS call rejected by its rate limit")
+		return fmt.Errorf("%w: %s", ErrRateLimited, a.name)
+	case ctx.Err() != nil:
+		return err
+	}
+	log.Warn().Err(err).Str("pacs", a.name).Msg("Rate limiter failed; letting the PACS call through")
+	return nil
+}
+
+func (a *limitedAdapter) FindStudies(ctx context.Context, params models.QueryParams) ([]models.Study, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, err
+	}
+	return a.PACSAdapter.FindStudies(ctx, params)
+}
+
+func (a *limitedAdapter) FindSeries(ctx context.Context, studyUID string) ([]models.Series, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, err
+	}
+	return a.PACSAdapter.FindSeries(ctx, studyUID)
+}
+
+func (a *limitedAdapter) FindInstances(ctx context.Context, studyUID, seriesUID string) ([]models.Instance, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, err
+	}
+	return a.PACSAdapter.FindInstances(ctx, studyUID, seriesUID)
+}
+
+func (a *limitedAdapter) ObjectExists(ctx context.Context, studyUID, seriesUID, instanceUID string) (bool, error) {
+	if err := a.wait(ctx); err != nil {
+		return false, err
+	}
+	return a.PACSAdapter.ObjectExists(ctx, studyUID, seriesUID, instanceUID)
+}
+
+func (a *limitedAdapter) GetInstance(ctx context.Context, studyUID, seriesUID, instanceUID string, opts models.RetrieveOptions) (io.ReadCloser, string, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, "", err
+	}
+	return a.PACSAdapter.GetInstance(ctx, studyUID, seriesUID, instanceUID, opts)
+}
+
+func (a *limitedAdapter) GetInstanceMetadata(ctx context.Context, studyUID, seriesUID, instanceUID string) (*models.Metadata, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, err
+	}
+	return a.PACSAdapter.GetInstanceMetadata(ctx, studyUID, seriesUID, instanceUID)
+}
+
+func (a *limitedAdapter) GetSeriesMetadata(ctx context.Context, studyUID, seriesUID string) ([]models.Metadata, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, err
+	}
+	return a.PACSAdapter.GetSeriesMetadata(ctx, studyUID, seriesUID)
+}
+
+func (a *limitedAdapter) GetStudyMetadata(ctx context.Context, studyUID string) ([]models.Metadata, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, err
+	}
+	return a.PACSAdapter.GetStudyMetadata(ctx, studyUID)
+}
+
+func (a *limitedAdapter) GetFrame(ctx context.Context, studyUID, seriesUID, instanceUID string, frame int) (io.ReadCloser, string, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, "", err
+	}
+	return a.PACSAdapter.GetFrame(ctx, studyUID, seriesUID, instanceUID, frame)
+}
+
+func (a *limitedAdapter) GetRendered(ctx context.Context, studyUID, seriesUID, instanceUID, mediaType string) (io.ReadCloser, string, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, "", err
+	}
+	return a.PACSAdapter.GetRendered(ctx, studyUID, seriesUID, instanceUID, mediaType)
+}
+
+func (a *limitedAdapter) DeleteStudy(ctx context.Context, studyUID string) error {
+	if err := a.wait(ctx); err != nil {
+		return err
+	}
+	return a.PACSAdapter.DeleteStudy(ctx, studyUID)
+}
+
+func (a *limitedAdapter) GetThumbnail(ctx context.Context, studyUID, seriesUID, instanceUID string, opts thumbnail.Options) ([]byte, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, err
+	}
+	return a.PACSAdapter.GetThumbnail(ctx, studyUID, seriesUID, instanceUID, opts)
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/dictionary/tags"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+)
+
+// responseFilter removes attributes a tenant does not want to leave the
+// connector from QIDO results and metadata
+type responseFilter struct {
+	stripPrivate bool
+	redacted     map[string]bool // tags as GGGGEEEE
+}
+
+// newResponseFilter builds the response filter for a PACS configuration.
+// It returns nil when the tenant has no filtering policy.
+func newResponseFilter(config *models.PACSConfig) *responseFilter {
+	if !config.StripPrivateTags && len(config.RedactedAttributes) == 0 {
+		return nil
+	}
+
+	f := &responseFilter{
+		stripPrivate: config.StripPrivateTags,
+		redacted:     make(map[string]bool, len(config.RedactedAttributes)),
+	}
+	for _, tag := range config.RedactedAttributes {
+		f.redacted[strings.ToUpper(tag)] = true
+	}
+	return f
+}
+
+// removes reports whether an attribute is dropped by the filter
+func (f *responseFilter) removes(tag string) bool {
+	tag = strings.ToUpper(tag)
+	if f.redacted[tag] {
+		return true
+	}
+	if f.stripPrivate && len(tag) == 8 {
+		// Private attributes have an odd group number
+		group, err := strconv.ParseUint(tag[:4], 16, 16)
+		return err == nil && group%2 == 1
+	}
+	return false
+}
+
+// filterAttributes removes attributes from a DICOM JSON dataset, including
+// those nested in sequence items
+func (f *responseFilter) filterAttributes(attrs map[string]interface{}) {
+	if f == nil {
+		return
+	}
+
+	for tag, attr := range attrs {
+		if f.removes(tag) {
+			delete(attrs, tag)
+			continue
+		}
+
+		element, ok := attr.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if items, ok := element["Value"].([]interface{}); ok {
+			for _, item := range items {
+				if nested, ok := item.(map[string]interface{}); ok {
+					f.filterAttributes(nested)
+				}
+			}
+		}
+	}
+}
+
+// filterMetadata applies the filter to the attributes of each metadata entry
+func (f *responseFilter) filterMetadata(metadata []models.Metadata) {
+	if f == nil {
+		return
+	}
+	for i := range metadata {
+		f.filterAttributes(metadata[i].Attributes)
+	}
+}
+
+// filterFields clears the fields of a QIDO result whose dicom struct tag is removed
+// by the filter. v must be a pointer to a slice of structs.
+func (f *responseFilter) filterFields(v interface{}) {
+	if f == nil {
+		return
+	}
+
+	items := reflect.ValueOf(v).Elem()
+	if items.Len() == 0 {
+		return
+	}
+
+	var fields []int
+	itemType := items.Type().Elem()
+	for i := 0; i < itemType.NumField(); i++ {
+		if tag := itemType.Field(i).Tag.Get("dicom"); tag != "" && f.removes(tag) {
+			fields = append(fields, i)
+		}
+	}
+
+	for i := 0; i < items.Len(); i++ {
+		for _, field := range fields {
+			value := items.Index(i).Field(field)
+			value.Set(reflect.Zero(value.Type()))
+		}
+	}
+}
+
+// normalizeRedactedAttributes resolves redacted attributes given by keyword or
+// GGGGEEEE tag to tags
+func normalizeRedactedAttributes(attributes []string) ([]string, error) {
+	normalized := make([]string, 0, len(attributes))
+	for _, attribute := range attributes {
+		attribute = strings.TrimSpace(attribute)
+		if attribute == "" {
+			continue
+		}
+
+		if len(attribute) == 8 {
+			if _, err := strconv.ParseUint(attribute, 16, 32); err == nil {
+				normalized = append(normalized, strings.ToUpper(attribute))
+				continue
+			}
+		}
+
+		tag := tags.GetTagFromName(attribute)
+		if tag == nil || tag.Name == "" {
+			return nil, fmt.Errorf("unknown redacted attribute: %s", attribute)
+		}
+		normalized = append(normalized, fmt.Sprintf("%04X%04X", tag.Group, tag.Element))
+	}
+	return normalized, nil
+}
@@ -0,0 +1,477 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Retrieve job errors
+var (
+	ErrRetrieveJobNotFound = errors.New("retrieve job not found")
+	ErrInvalidRetrieveJob  = errors.New("invalid retrieve job request")
+	ErrRetrieveJobState    = errors.New("retrieve job is not in a state that allows this")
+)
+
+const (
+	// retrieveJobTimeout bounds one run of a retrieve job
+	retrieveJobTimeout = 4 * time.Hour
+	// retrieveJobLease is how long a running job is hidden from other workers
+	// without its progress being saved; a job whose worker died is taken over
+	// once it lapses
+	retrieveJobLease = 2 * time.Minute
+	// retrieveJobProgressInterval is how often a running job's progress is saved
+	// and its cancellation checked
+	retrieveJobProgressInterval = 2 * time.Second
+)
+
+// RetrieveJobConfig configures the retrieve job workers
+type RetrieveJobConfig struct {
+	Workers          int           // concurrent jobs on this instance
+	PollInterval     time.Duration // how often queued jobs are looked for
+	InstanceAttempts int           // attempts at each instance before it is recorded as failed
+}
+
+// RetrieveJobService runs long retrievals of studies and series into the cache
+// as jobs. Jobs are queued in the database and claimed by the workers of every
+// connector instance, STAT jobs before routine ones; a job whose worker stops is
+// taken over by another. Each
+// instance is retried a few times, and those that still fail can be retried
+// later without retrieving the rest again.
+type RetrieveJobService struct {
+	pacsService *PACSService
+	repo        *repository.RetrieveJobRepository
+	config      RetrieveJobConfig
+
+	wake chan struct{}
+
+	mu      sync.Mutex
+	running map[uuid.UUID]context.CancelFunc // jobs running on this instance
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRetrieveJobService creates a retrieve job service; call Start to run its workers
+func NewRetrieveJobService(pacsService *PACSService, repo *repository.RetrieveJobRepository, config RetrieveJobConfig) *RetrieveJobService {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.InstanceAttempts <= 0 {
+		config.InstanceAttempts = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RetrieveJobService{
+		pacsService: pacsService,
+		repo:        repo,
+		config:      config,
+		wake:        make(chan struct{}, 1),
+		running:     make(map[uuid.UUID]context.CancelFunc),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start launches the retrieve job workers
+func (s *RetrieveJobService) Start() {
+	for i := 0; i < s.config.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	s.wg.Add(1)
+	go s.observeQueue()
+}
+
+// Stop stops the workers. Running jobs are returned to the queue and resume
+// on the next instance to claim them.
+func (s *RetrieveJobService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// signal wakes a worker without waiting for the next poll
+func (s *RetrieveJobService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// CreateJob queues the retrieval of a study, or one of its series, into the cache
+func (s *RetrieveJobService) CreateJob(ctx context.Context, tenantID uuid.UUID, req *models.RetrieveJobRequest, ipAddress, userAgent string) (*models.RetrieveJob, error) {
+	studyUID := strings.TrimSpace(req.StudyUID)
+	seriesUID := strings.TrimSpace(req.SeriesUID)
+	if studyUID == "" {
+		return nil, fmt.Errorf("%w: study_uid is required", ErrInvalidRetrieveJob)
+	}
+	priority, err := jobPriority(req.Priority, ErrInvalidRetrieveJob)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.pacsService.ObjectExists(ctx, tenantID, studyUID, seriesUID, "")
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	job := &models.RetrieveJob{
+		TenantID:  tenantID,
+		StudyUID:  studyUID,
+		SeriesUID: seriesUID,
+		Status:    models.RetrieveJobPending,
+		Priority:  priority,
+	}
+	if err := s.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	s.signal()
+
+	entry := &models.AuditLog{
+		TenantID:     tenantID,
+		Action:       "study.retrieve_job",
+		ResourceType: "study",
+		ResourceUID:  studyUID,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Status:       "success",
+	}
+	if err := s.pacsService.recordAudit(ctx, entry); err != nil {
+		log.Error().Err(err).Msg("Failed to record retrieve job audit entry")
+	}
+
+	return job, nil
+}
+
+// GetJob returns a tenant's retrieve job with its progress
+func (s *RetrieveJobService) GetJob(ctx context.Context, tenantID, id uuid.UUID) (*models.RetrieveJob, error) {
+	job, err := s.repo.GetByID(ctx, tenantID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRetrieveJobNotFound
+	}
+	return job, err
+}
+
+// CancelJob cancels a tenant's pending or running job. A running job stops
+// within a few seconds, on whichever instance runs it.
+func (s *RetrieveJobService) CancelJob(ctx context.Context, tenantID, id uuid.UUID) (*models.RetrieveJob, error) {
+	if _, err := s.GetJob(ctx, tenantID, id); err != nil {
+		return nil, err
+	}
+
+	cancelled, err := s.repo.Cancel(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, fmt.Errorf("%w: the job has already finished", ErrRetrieveJobState)
+	}
+
+	s.mu.Lock()
+	if cancel, ok := s.running[id]; ok {
+		cancel()
+	}
+	s.mu.Unlock()
+
+	return s.GetJob(ctx, tenantID, id)
+}
+
+// RetryJob queues a failed or cancelled job again. A job that processed all of
+// its instances retrieves only those that failed; any other starts over,
+// skipping instances that are already cached.
+func (s *RetrieveJobService) RetryJob(ctx context.Context, tenantID, id uuid.UUID) (*models.RetrieveJob, error) {
+	if _, err := s.GetJob(ctx, tenantID, id); err != nil {
+		return nil, err
+	}
+
+	requeued, err := s.repo.Requeue(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !requeued {
+		return nil, fmt.Errorf("%w: only failed or cancelled jobs can be retried", ErrRetrieveJobState)
+	}
+	s.signal()
+
+	return s.GetJob(ctx, tenantID, id)
+}
+
+// worker runs queued jobs on every poll and whenever new ones are queued
+func (s *RetrieveJobService) worker() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+
+		for s.ctx.Err() == nil {
+			job, err := s.repo.ClaimNext(s.ctx, time.Now(), retrieveJobLease)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to claim retrieve job")
+				break
+			}
+			if job == nil {
+				break
+			}
+			s.run(job)
+		}
+	}
+}
+
+// observeQueue records the depth of the queue per priority on every poll
+func (s *RetrieveJobService) observeQueue() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		pending, err := s.repo.CountPending(s.ctx)
+		if err != nil {
+			if s.ctx.Err() == nil {
+				log.Warn().Err(err).Msg("Failed to count queued retrieve jobs")
+			}
+		} else {
+			for _, priority := range models.JobPriorities {
+				jobQueueDepth.WithLabelValues("retrieve", priority).Set(float64(pending[priority]))
+			}
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run runs a claimed job and records its outcome
+func (s *RetrieveJobService) run(job *models.RetrieveJob) {
+	ctx, cancel := context.WithTimeout(s.ctx, retrieveJobTimeout)
+	defer cancel()
+
+	s.mu.Lock()
+	s.running[job.ID] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, job.ID)
+		s.mu.Unlock()
+	}()
+
+	logger := log.With().
+		Str("tenant_id", job.TenantID.String()).
+		Str("retrieve_job_id", job.ID.String()).
+		Str("study_uid", job.StudyUID).
+		Str("priority", job.Priority).
+		Logger()
+
+	start := time.Now()
+	err := s.retrieve(ctx, job)
+
+	switch {
+	case s.ctx.Err() != nil:
+		// Shutting down; another worker resumes the job
+		if err := s.repo.Release(context.Background(), job.ID); err != nil {
+			logger.Error().Err(err).Msg("Failed to release retrieve job")
+		}
+		return
+	case errors.Is(err, errRetrieveJobCancelled), errors.Is(err, context.Canceled):
+		logger.Info().Msg("Retrieve job cancelled")
+		return
+	}
+
+	now := time.Now()
+	job.CompletedAt = &now
+	job.Status = models.RetrieveJobCompleted
+	switch {
+	case err != nil:
+		job.Status = models.RetrieveJobFailed
+		job.Error = err.Error()
+	case job.FailedInstances > 0:
+		job.Status = models.RetrieveJobFailed
+		job.Error = fmt.Sprintf("%d of %d instances could not be retrieved", job.FailedInstances, job.TotalInstances)
+	}
+
+	finished, updateErr := s.repo.Finish(context.Background(), job)
+	if updateErr != nil {
+		logger.Error().Err(updateErr).Msg("Failed to record retrieve job outcome")
+		return
+	}
+	if !finished {
+		// Cancelled while finishing
+		return
+	}
+
+	event := map[string]any{
+		"job":              "retrieve",
+		"retrieve_job_id":  job.ID,
+		"study_uid":        job.StudyUID,
+		"instances":        job.TotalInstances,
+		"failed_instances": job.FailedInstances,
+		"bytes":            job.Bytes,
+		"status":           "success",
+	}
+	if job.SeriesUID != "" {
+		event["series_uid"] = job.SeriesUID
+	}
+	if job.Status == models.RetrieveJobFailed {
+		event["status"] = "failure"
+		event["error"] = job.Error
+	}
+	s.pacsService.publish(job.TenantID, models.EventRetrieveJobCompleted, event)
+
+	logger = logger.With().
+		Int("instances", job.TotalInstances).
+		Int("failed_instances", job.FailedInstances).
+		Int64("bytes", job.Bytes).
+		Dur("duration", time.Since(start)).
+		Logger()
+	if job.Status == models.RetrieveJobFailed {
+		logger.Error().Str("error", job.Error).Msg("Retrieve job failed")
+		return
+	}
+	logger.Info().Msg("Retrieve job completed")
+}
+
+// errRetrieveJobCancelled stops a job that was cancelled on another instance
+var errRetrieveJobCancelled = errors.New("retrieve job cancelled")
+
+// retrieve lists the job's instances and caches them, saving the job's
+// progress as it goes
+func (s *RetrieveJobService) retrieve(ctx context.Context, job *models.RetrieveJob) error {
+	ctx = withStudyHint(ctx, job.StudyUID)
+	config, adapter, err := s.pacsService.route(ctx, job.TenantID)
+	if err != nil {
+		return err
+	}
+
+	// A job that went through all of its instances before retries only the failed ones
+	var targets []prefetchTarget
+	if len(job.FailedInstanceUIDs) > 0 && job.CompletedInstances+job.FailedInstances == job.TotalInstances {
+		for _, uid := range job.FailedInstanceUIDs {
+			seriesUID, sopInstanceUID, _ := strings.Cut(uid, "/")
+			targets = append(targets, prefetchTarget{studyUID: job.StudyUID, seriesUID: seriesUID, sopInstanceUID: sopInstanceUID})
+		}
+	} else {
+		if targets, err = s.listTargets(ctx, job); err != nil {
+			return err
+		}
+		job.TotalInstances = len(targets)
+		job.CompletedInstances = 0
+		job.Bytes = 0
+	}
+	job.FailedInstances = 0
+	job.FailedInstanceUIDs = nil
+	if err := s.saveProgress(ctx, job); err != nil {
+		return err
+	}
+
+	ttl := s.pacsService.ttls(ctx, job.TenantID).instanceTTL(config)
+	saved := time.Now()
+	for _, target := range targets {
+		size, err := s.retrieveInstance(ctx, job.TenantID, adapter, target, ttl)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		job.Bytes += size
+		if err != nil {
+			job.FailedInstances++
+			job.FailedInstanceUIDs = append(job.FailedInstanceUIDs, target.seriesUID+"/"+target.sopInstanceUID)
+			log.Warn().Err(err).
+				Str("retrieve_job_id", job.ID.String()).
+				Str("instance_uid", target.sopInstanceUID).
+				Msg("Failed to retrieve instance")
+		} else {
+			job.CompletedInstances++
+		}
+
+		if time.Since(saved) >= retrieveJobProgressInterval {
+			if err := s.saveProgress(ctx, job); err != nil {
+				return err
+			}
+			saved = time.Now()
+		}
+	}
+	return nil
+}
+
+// listTargets lists the instances of the job's series, or of its whole study
+func (s *RetrieveJobService) listTargets(ctx context.Context, job *models.RetrieveJob) ([]prefetchTarget, error) {
+	if job.SeriesUID == "" {
+		return s.pacsService.studyInstances(ctx, job.TenantID, job.StudyUID)
+	}
+
+	instances, err := s.pacsService.FindInstances(ctx, job.TenantID, job.StudyUID, job.SeriesUID)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]prefetchTarget, 0, len(instances))
+	for _, instance := range instances {
+		targets = append(targets, prefetchTarget{
+			studyUID:       job.StudyUID,
+			seriesUID:      job.SeriesUID,
+			sopInstanceUID: instance.SOPInstanceUID,
+		})
+	}
+	return targets, nil
+}
+
+// retrieveInstance caches one instance, retrying failed attempts with a
+// growing delay, and returns the bytes read from the PACS
+func (s *RetrieveJobService) retrieveInstance(ctx context.Context, tenantID uuid.UUID, adapter adapters.PACSAdapter, target prefetchTarget, ttl time.Duration) (int64, error) {
+	var total int64
+	var err error
+	for attempt := 1; attempt <= s.config.InstanceAttempts; attempt++ {
+		var size int64
+		_, size, err = s.pacsService.prefetchInstance(ctx, tenantID, adapter, target, ttl)
+		total += size
+		if err == nil || attempt == s.config.InstanceAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+	return total, err
+}
+
+// saveProgress saves a running job's progress, renewing its lease, and stops
+// the job when it was cancelled
+func (s *RetrieveJobService) saveProgress(ctx context.Context, job *models.RetrieveJob) error {
+	running, err := s.repo.SaveProgress(ctx, job, time.Now().Add(retrieveJobLease))
+	if err != nil {
+		log.Warn().Err(err).Str("retrieve_job_id", job.ID.String()).Msg("Failed to save retrieve job progress")
+		return nil
+	}
+	if !running {
+		return errRetrieveJobCancelled
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/dicomquery"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidRoutingRule is returned when a routing rule request is malformed
+var ErrInvalidRoutingRule = errors.New("invalid routing rule")
+
+// ErrRoutingRuleNotFound is returned when a tenant has no routing rule with the requested ID
+var ErrRoutingRuleNotFound = errors.New("routing rule not found")
+
+// routingRulesTTL is how long a tenant's routing rules are kept before they are
+// read again. A rule changed through another connector instance takes effect
+// here within this time.
+const routingRulesTTL = 30 * time.Second
+
+// cachedRoutingRules are a tenant's routing rules as last read
+type cachedRoutingRules struct {
+	rules   []models.PACSRoutingRule
+	expires time.Time
+}
+
+// RouteHints describe a request to the routing rules. Department and calling
+// AE title come from the caller; modalities and study date from the query.
+type RouteHints struct {
+	Modalities     []string
+	StudyDate      string // DA or DA range
+	Department     string
+	CallingAETitle string
+	StudyUID       string // study a retrieval or series/instance query is for
+}
+
+type routeHintsKey struct{}
+
+// WithRouteHints returns a context whose requests are routed by hints
+func WithRouteHints(ctx context.Context, hints RouteHints) context.Context {
+	return context.WithValue(ctx, routeHintsKey{}, hints)
+}
+
+// routeHintsFrom returns the route hints of a request
+func routeHintsFrom(ctx context.Context) RouteHints {
+	hints, _ := ctx.Value(routeHintsKey{}).(RouteHints)
+	return hints
+}
+
+// withQueryHints adds the modalities and study date of a study query to the
+// request's route hints
+func withQueryHints(ctx context.Context, params models.QueryParams) context.Context {
+	hints := routeHintsFrom(ctx)
+	hints.Modalities = params.Modalities
+	hints.StudyDate = params.StudyDate
+	return WithRouteHints(ctx, hints)
+}
+
+// withStudyHint adds the study a request is for to its route hints
+func withStudyHint(ctx context.Context, studyUID string) context.Context {
+	hints := routeHintsFrom(ctx)
+	hints.StudyUID = studyUID
+	return WithRouteHints(ctx, hints)
+}
+
+// route returns the PACS config that handles a request of the tenant, and its
+// adapter: that a fan-out search found the request's study in, that of the
+// first active routing rule matching the request's hints, or otherwise the
+// tenant's primary, or its secondary while failed over
+func (s *PACSService) route(ctx context.Context, tenantID uuid.UUID) (*models.PACSConfig, adapters.PACSAdapter, error) {
+	hints := routeHintsFrom(ctx)
+	if hints.StudyUID != "" {
+		if config, adapter, ok := s.studySource(ctx, tenantID, hints.StudyUID); ok {
+			return config, adapter, nil
+		}
+	}
+
+	for _, rule := range s.routingRules(ctx, tenantID) {
+		if !rule.IsActive || !routingRuleMatches(rule, hints) {
+			continue
+		}
+
+		config, err := s.pacsRepo.GetByID(ctx, rule.PACSConfigID)
+		if err != nil || !config.IsActive || config.TenantID != tenantID {
+			log.Warn().Err(err).
+				Str("tenant_id", tenantID.String()).
+				Str("rule_id", rule.ID.String()).
+				Msg("Routing rule targets an unavailable PACS config; skipping it")
+			continue
+		}
+
+		adapter, err := s.adapterFor(ctx, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		return config, adapter, nil
+	}
+
+	return s.getActive(ctx, tenantID)
+}
+
+// routingRuleMatches reports whether every criterion set on a rule matches a request
+func routingRuleMatches(rule models.PACSRoutingRule, hints RouteHints) bool {
+	if len(rule.Modalities) > 0 && !slices.ContainsFunc(hints.Modalities, func(modality string) bool {
+		return slices.Contains(rule.Modalities, strings.ToUpper(modality))
+	}) {
+		return false
+	}
+
+	if rule.StudyDateFrom != "" || rule.StudyDateTo != "" {
+		start, end, isRange := strings.Cut(hints.StudyDate, "-")
+		if !isRange {
+			end = start
+		}
+		if rule.StudyDateFrom != "" && (start == "" || start < rule.StudyDateFrom) {
+			return false
+		}
+		if rule.StudyDateTo != "" && (end == "" || end > rule.StudyDateTo) {
+			return false
+		}
+	}
+
+	if len(rule.Departments) > 0 && !slices.ContainsFunc(rule.Departments, func(department string) bool {
+		return strings.EqualFold(department, hints.Department)
+	}) {
+		return false
+	}
+
+	if len(rule.AETitles) > 0 && !slices.Contains(rule.AETitles, hints.CallingAETitle) {
+		return false
+	}
+
+	return true
+}
+
+// routingRules returns the tenant's routing rules in evaluation order, read at
+// most every routingRulesTTL
+func (s *PACSService) routingRules(ctx context.Context, tenantID uuid.UUID) []models.PACSRoutingRule {
+	now := time.Now()
+	s.routingMu.Lock()
+	cached, ok := s.routes[tenantID]
+	s.routingMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.rules
+	}
+
+	rules, err := s.routingRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		// Keep the last known rules while the database is unavailable
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to read routing rules")
+		return cached.rules
+	}
+
+	s.routingMu.Lock()
+	s.routes[tenantID] = cachedRoutingRules{rules: rules, expires: now.Add(routingRulesTTL)}
+	s.routingMu.Unlock()
+	return rules
+}
+
+// forgetRoutingRules drops the kept routing rules of a tenant after they changed
+func (s *PACSService) forgetRoutingRules(tenantID uuid.UUID) {
+	s.routingMu.Lock()
+	defer s.routingMu.Unlock()
+
+	delete(s.routes, tenantID)
+}
+
+// CreateRoutingRule adds a routing rule to one of the tenant's PACS configs
+func (s *PACSService) CreateRoutingRule(ctx context.Context, tenantID uuid.UUID, req *models.RoutingRuleRequest) (*models.PACSRoutingRule, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRoutingRule)
+	}
+
+	config, err := s.pacsRepo.GetByID(ctx, req.PACSConfigID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: pacs_config_id is not a PACS config of the tenant", ErrInvalidRoutingRule)
+		}
+		return nil, err
+	}
+	if config.TenantID != tenantID {
+		return nil, fmt.Errorf("%w: pacs_config_id is not a PACS config of the tenant", ErrInvalidRoutingRule)
+	}
+
+	rule := &models.PACSRoutingRule{
+		TenantID:     tenantID,
+		Name:         req.Name,
+		Priority:     req.Priority,
+		PACSConfigID: req.PACSConfigID,
+		Departments:  req.Departments,
+		AETitles:     req.AETitles,
+		IsActive:     true,
+	}
+	for _, modality := range req.Modalities {
+		rule.Modalities = append(rule.Modalities, strings.ToUpper(strings.TrimSpace(modality)))
+	}
+	if rule.StudyDateFrom, err = routingRuleDate(req.StudyDateFrom); err != nil {
+		return nil, err
+	}
+	if rule.StudyDateTo, err = routingRuleDate(req.StudyDateTo); err != nil {
+		return nil, err
+	}
+	if rule.StudyDateFrom != "" && rule.StudyDateTo != "" && rule.StudyDateFrom > rule.StudyDateTo {
+		return nil, fmt.Errorf("%w: study_date_from is after study_date_to", ErrInvalidRoutingRule)
+	}
+
+	if err := s.routingRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.forgetRoutingRules(tenantID)
+	return rule, nil
+}
+
+// routingRuleDate normalizes a single date bound of a routing rule to DA
+func routingRuleDate(value string) (string, error) {
+	date, err := dicomquery.NormalizeDate(value)
+	if err != nil || strings.Contains(date, "-") {
+		return "", fmt.Errorf("%w: invalid date %q", ErrInvalidRoutingRule, value)
+	}
+	return date, nil
+}
+
+// GetRoutingRules lists the tenant's routing rules in evaluation order
+func (s *PACSService) GetRoutingRules(ctx context.Context, tenantID uuid.UUID) ([]models.PACSRoutingRule, error) {
+	return s.routingRepo.GetByTenantID(ctx, tenantID)
+}
+
+// DeleteRoutingRule removes a routing rule of the tenant
+func (s *PACSService) DeleteRoutingRule(ctx context.Context, tenantID, ruleID uuid.UUID) error {
+	deleted, err := s.routingRepo.Delete(ctx, tenantID, ruleID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrRoutingRuleNotFound
+	}
+	s.forgetRoutingRules(tenantID)
+	return nil
+}
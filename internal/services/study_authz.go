@@ -0,0 +1,267 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/audit"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrStudyAccessDenied is returned when the study authorizer denies a caller
+// access to a study, series or instance
+var ErrStudyAccessDenied = errors.New("access to the study is denied")
+
+// Study access actions, telling the authorizer how a resource is served
+const (
+	StudyActionSearch   = "search"   // QIDO-RS searches and existence checks
+	StudyActionMetadata = "metadata" // WADO-RS metadata
+	StudyActionRetrieve = "retrieve" // WADO-RS instances and frames
+	StudyActionRender   = "render"   // rendered images, videos and thumbnails
+)
+
+const (
+	// studyAuthzTimeout bounds one call to the study authorizer
+	studyAuthzTimeout = 5 * time.Second
+	// studyAuthzSearchConcurrency bounds the authorizer calls made at once to
+	// filter the results of a study search
+	studyAuthzSearchConcurrency = 8
+	// maxStudyAuthzDecisions bounds the cached decisions; expired ones are
+	// dropped when it is reached
+	maxStudyAuthzDecisions = 100000
+)
+
+// StudyAccessRequest is what a study authorizer decides on: whether a user of
+// a tenant may be served a study, or one of its series or instances
+type StudyAccessRequest struct {
+	TenantID    uuid.UUID `json:"tenant_id"`
+	User        string    `json:"user"` // empty when the caller is not identified
+	Action      string    `json:"action"`
+	StudyUID    string    `json:"study_uid"`
+	SeriesUID   string    `json:"series_uid,omitempty"`
+	InstanceUID string    `json:"instance_uid,omitempty"`
+}
+
+// StudyAuthorizer decides whether studies are served to a caller, for policies
+// such as limiting radiologists to the patients on their worklist
+type StudyAuthorizer interface {
+	// AuthorizeStudy reports whether the request is allowed; an error denies it
+	AuthorizeStudy(ctx context.Context, req StudyAccessRequest) (bool, error)
+}
+
+// studyAuthorization asks the authorizer about the studies served to clients,
+// caching its decisions
+type studyAuthorization struct {
+	authorizer StudyAuthorizer
+	ttl        time.Duration
+
+	mu        sync.Mutex
+	decisions map[StudyAccessRequest]studyAuthzDecision
+}
+
+// studyAuthzDecision is a decision of the authorizer as last made
+type studyAuthzDecision struct {
+	allowed bool
+	expires time.Time
+}
+
+type studyAuthzKey struct{}
+
+// WithStudyAuthorization returns a context whose reads of studies, series and
+// instances are put to the study authorizer, for the requests of clients.
+// Background work such as prefetching, exports and cache verification runs
+// without it, having been authorized when it was requested.
+func WithStudyAuthorization(ctx context.Context) context.Context {
+	return context.WithValue(ctx, studyAuthzKey{}, true)
+}
+
+// EnableStudyAuthorization puts the studies, series and instances served to
+// clients to authorizer, caching each decision for ttl
+func (s *PACSService) EnableStudyAuthorization(authorizer StudyAuthorizer, ttl time.Duration) {
+	s.studyAuthz = &studyAuthorization{
+		authorizer: authorizer,
+		ttl:        ttl,
+		decisions:  make(map[StudyAccessRequest]studyAuthzDecision),
+	}
+}
+
+// authorizeStudy returns ErrStudyAccessDenied unless the authorizer allows the
+// client a study resource. Without an authorizer, and outside client requests,
+// everything is allowed.
+func (s *PACSService) authorizeStudy(ctx context.Context, tenantID uuid.UUID, action, studyUID, seriesUID, instanceUID string) error {
+	if s.studyAuthz == nil || ctx.Value(studyAuthzKey{}) == nil {
+		return nil
+	}
+	req := StudyAccessRequest{
+		TenantID:    tenantID,
+		User:        audit.User(ctx),
+		Action:      action,
+		StudyUID:    studyUID,
+		SeriesUID:   seriesUID,
+		InstanceUID: instanceUID,
+	}
+	allowed, err := s.studyAuthz.decide(ctx, req)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		log.Info().
+			Str("tenant_id", tenantID.String()).
+			Str("user", req.User).
+			Str("action", action).
+			Str("study_uid", studyUID).
+			Str("series_uid", seriesUID).
+			Str("instance_uid", instanceUID).
+			Msg("Study access denied")
+		return ErrStudyAccessDenied
+	}
+	return nil
+}
+
+// authorizedStudies returns the studies of a search result the authorizer
+// allows the client, in their order
+func (s *PACSService) authorizedStudies(ctx context.Context, tenantID uuid.UUID, studies []models.Study) ([]models.Study, error) {
+	if s.studyAuthz == nil || ctx.Value(studyAuthzKey{}) == nil || len(studies) == 0 {
+		return studies, nil
+	}
+
+	allowed := make([]bool, len(studies))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(studyAuthzSearchConcurrency)
+	for i := range studies {
+		g.Go(func() error {
+			err := s.authorizeStudy(gctx, tenantID, StudyActionSearch, studies[i].StudyInstanceUID, "", "")
+			if errors.Is(err, ErrStudyAccessDenied) {
+				return nil
+			}
+			allowed[i] = err == nil
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Study, 0, len(studies))
+	for i, study := range studies {
+		if allowed[i] {
+			result = append(result, study)
+		}
+	}
+	return result, nil
+}
+
+// decide returns the authorizer's decision on a request, from the cache while
+// it is fresh
+func (a *studyAuthorization) decide(ctx context.Context, req StudyAccessRequest) (bool, error) {
+	now := time.Now()
+	a.mu.Lock()
+	decision, ok := a.decisions[req]
+	a.mu.Unlock()
+	if ok && now.Before(decision.expires) {
+		return decision.allowed, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, studyAuthzTimeout)
+	defer cancel()
+	allowed, err := a.authorizer.AuthorizeStudy(ctx, req)
+	if err != nil {
+		return false, fmt.Errorf("%w: study authorization failed: %v", ErrUnavailable, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.decisions) >= maxStudyAuthzDecisions {
+		for key, decision := range a.decisions {
+			if !now.Before(decision.expires) {
+				delete(a.decisions, key)
+			}
+		}
+	}
+	if a.ttl > 0 && len(a.decisions) < maxStudyAuthzDecisions {
+		a.decisions[req] = studyAuthzDecision{allowed: allowed, expires: now.Add(a.ttl)}
+	}
+	return allowed, nil
+}
+
+// HTTPStudyAuthorizer asks a policy endpoint about each study access, such as
+// an Open Policy Agent decision (POST /v1/data/<package>/<rule>) or a webhook
+// speaking the same protocol: the request is posted as {"input": {...}} and
+// the response must be {"result": true} or {"result": {"allow": true}}; any
+// other result denies access.
+type HTTPStudyAuthorizer struct {
+	client *http.Client
+	url    string
+	token  string // bearer token sent to the endpoint; empty sends none
+}
+
+// NewHTTPStudyAuthorizer creates an authorizer asking the policy endpoint at
+// rawURL
+func NewHTTPStudyAuthorizer(rawURL, token string) (*HTTPStudyAuthorizer, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid study authorization URL %q", rawURL)
+	}
+	return &HTTPStudyAuthorizer{
+		client: &http.Client{Timeout: studyAuthzTimeout},
+		url:    rawURL,
+		token:  token,
+	}, nil
+}
+
+// AuthorizeStudy posts the request to the policy endpoint
+func (a *HTTPStudyAuthorizer) AuthorizeStudy(ctx context.Context, req StudyAccessRequest) (bool, error) {
+	body, err := json.Marshal(map[string]StudyAccessRequest{"input": req})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode study authorization request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", a.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create study authorization request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if a.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return false, fmt.Errorf("policy endpoint returned status %d: %s", resp.StatusCode, string(msg))
+	}
+	var decision struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return false, fmt.Errorf("failed to decode policy decision: %w", err)
+	}
+
+	var allowed bool
+	if json.Unmarshal(decision.Result, &allowed) == nil {
+		return allowed, nil
+	}
+	var rule struct {
+		Allow bool `json:"allow"`
+	}
+	if json.Unmarshal(decision.Result, &rule) == nil {
+		return rule.Allow, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// Study index modes
+const (
+	StudyIndexOff      = "off"      // searches are neither indexed nor answered from the index
+	StudyIndexPopulate = "populate" // search results are indexed; every search goes to the PACS
+	StudyIndexPrefer   = "prefer"   // searches the index can answer are answered from it
+)
+
+// studyIndexWriters bounds the search results indexed at a time; results
+// arriving while every writer is busy are not indexed
+const studyIndexWriters = 4
+
+// studyIndexWriteTimeout bounds indexing the results of one search
+const studyIndexWriteTimeout = 30 * time.Second
+
+// StudyIndexConfig configures the local study index
+type StudyIndexConfig struct {
+	Mode   string        // StudyIndexOff, StudyIndexPopulate or StudyIndexPrefer
+	MaxAge time.Duration // how long after the PACS was last searched the index may answer
+}
+
+// studyIndex keeps the studies, series and instances the PACS returned in the
+// database so QIDO searches can be answered without the PACS
+type studyIndex struct {
+	repo    *repository.StudyIndexRepository
+	config  StudyIndexConfig
+	writers chan struct{}
+}
+
+// EnableStudyIndex has search results indexed in the database, and in prefer
+// mode searches the index covers answered from it
+func (s *PACSService) EnableStudyIndex(repo *repository.StudyIndexRepository, config StudyIndexConfig) {
+	s.index = &studyIndex{
+		repo:    repo,
+		config:  config,
+		writers: make(chan struct{}, studyIndexWriters),
+	}
+}
+
+// IndexAnswer reports to the caller of a search whether it was answered from
+// the study index
+type IndexAnswer struct {
+	IndexedAt *time.Time // when the PACS last listed the results; nil when the PACS was searched
+}
+
+type indexAnswerKey struct{}
+
+// WithIndexAnswer returns a context whose searches report through the returned
+// IndexAnswer whether they were answered from the study index
+func WithIndexAnswer(ctx context.Context) (context.Context, *IndexAnswer) {
+	answer := &IndexAnswer{}
+	return context.WithValue(ctx, indexAnswerKey{}, answer), answer
+}
+
+// reportIndexAnswer tells the caller a search was answered from the index
+func reportIndexAnswer(ctx context.Context, indexedAt time.Time) {
+	if answer, ok := ctx.Value(indexAnswerKey{}).(*IndexAnswer); ok {
+		answer.IndexedAt = &indexedAt
+	}
+}
+
+// newStudyIndexEntry builds the index entry of a study found on a PACS config
+func newStudyIndexEntry(tenantID, pacsConfigID uuid.UUID, study models.Study, now time.Time) models.StudyIndexEntry {
+	return models.StudyIndexEntry{
+		TenantID:           tenantID,
+		PACSConfigID:       pacsConfigID,
+		StudyInstanceUID:   study.StudyInstanceUID,
+		AccessionNumber:    study.AccessionNumber,
+		PatientID:          study.PatientID,
+		PatientName:        study.PatientName,
+		PatientBirthDate:   study.PatientBirthDate,
+		PatientSex:         study.PatientSex,
+		ReferringPhysician: study.ReferringPhysician,
+		StudyDate:          study.StudyDate,
+		StudyTime:          study.StudyTime,
+		StudyDescription:   study.StudyDescription,
+		ModalitiesInStudy:  study.ModalitiesInStudy,
+		NumberOfSeries:     study.NumberOfSeries,
+		NumberOfInstances:  study.NumberOfInstances,
+		FirstSeenAt:        now,
+		LastSeenAt:         now,
+	}
+}
+
+// indexInBackground runs an index write detached from the request, unless the
+// index is disabled or every writer is busy
+func (s *PACSService) indexInBackground(ctx context.Context, tenantID uuid.UUID, what string, write func(ctx context.Context, repo *repository.StudyIndexRepository) error) {
+	if s.index == nil {
+		return
+	}
+	select {
+	case s.index.writers <- struct{}{}:
+	default:
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), studyIndexWriteTimeout)
+	go func() {
+		defer func() { <-s.index.writers }()
+		defer cancel()
+		if err := write(ctx, s.index.repo); err != nil {
+			log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msgf("Failed to index %s", what)
+		}
+	}()
+}
+
+// indexStudies indexes the studies a search found on a PACS config
+func (s *PACSService) indexStudies(ctx context.Context, config *models.PACSConfig, studies []models.Study) {
+	if s.index == nil || len(studies) == 0 {
+		return
+	}
+	now := time.Now()
+	entries := make([]models.StudyIndexEntry, 0, len(studies))
+	for _, study := range studies {
+		if study.StudyInstanceUID != "" {
+			entries = append(entries, newStudyIndexEntry(config.TenantID, config.ID, study, now))
+		}
+	}
+	s.indexInBackground(ctx, config.TenantID, "studies", func(ctx context.Context, repo *repository.StudyIndexRepository) error {
+		return repo.Upsert(ctx, entries)
+	})
+}
+
+// indexSeries indexes the series of a study as a search listed them
+func (s *PACSService) indexSeries(ctx context.Context, tenantID uuid.UUID, studyUID string, series []models.Series) {
+	if s.index == nil {
+		return
+	}
+	now := time.Now()
+	entries := make([]models.SeriesIndexEntry, 0, len(series))
+	for _, se := range series {
+		dataset, err := json.Marshal(se)
+		if err != nil || se.SeriesInstanceUID == "" {
+			continue
+		}
+		entries = append(entries, models.SeriesIndexEntry{
+			TenantID:          tenantID,
+			StudyInstanceUID:  studyUID,
+			SeriesInstanceUID: se.SeriesInstanceUID,
+			Dataset:           string(dataset),
+			IndexedAt:         now,
+		})
+	}
+	s.indexInBackground(ctx, tenantID, "series", func(ctx context.Context, repo *repository.StudyIndexRepository) error {
+		return repo.ReplaceSeries(ctx, tenantID, studyUID, entries, now)
+	})
+}
+
+// indexInstances indexes the instances of a series as a search listed them
+func (s *PACSService) indexInstances(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID string, instances []models.Instance) {
+	if s.index == nil {
+		return
+	}
+	now := time.Now()
+	entries := make([]models.InstanceIndexEntry, 0, len(instances))
+	for _, instance := range instances {
+		dataset, err := json.Marshal(instance)
+		if err != nil || instance.SOPInstanceUID == "" {
+			continue
+		}
+		entries = append(entries, models.InstanceIndexEntry{
+			TenantID:          tenantID,
+			StudyInstanceUID:  studyUID,
+			SeriesInstanceUID: seriesUID,
+			SOPInstanceUID:    instance.SOPInstanceUID,
+			Dataset:           string(dataset),
+			IndexedAt:         now,
+		})
+	}
+	s.indexInBackground(ctx, tenantID, "instances", func(ctx context.Context, repo *repository.StudyIndexRepository) error {
+		return repo.ReplaceInstances(ctx, tenantID, seriesUID, entries, now)
+	})
+}
+
+// answersFromIndex reports whether searches may be answered from the index
+func (s *PACSService) answersFromIndex() bool {
+	return s.index != nil && s.index.config.Mode == StudyIndexPrefer
+}
+
+// fresh reports whether index data listed by the PACS at a time may still answer searches
+func (i *studyIndex) fresh(indexedAt *time.Time) bool {
+	return indexedAt != nil && time.Since(*indexedAt) <= i.config.MaxAge
+}
+
+// indexedStudyDates returns the first and last day of an exact study date or a
+// closed date range; the index can only tell a search of known days is complete
+func indexedStudyDates(studyDate string) (string, string, bool) {
+	from, to, isRange := strings.Cut(studyDate, "-")
+	if !isRange {
+		to = from
+	}
+	for _, day := range []string{from, to} {
+		if _, err := time.Parse("20060102", day); err != nil {
+			return "", "", false
+		}
+	}
+	return from, to, from <= to
+}
+
+// studiesFromIndex answers a study search from the index when the PACS
+// synchronization indexed every study the search can match and ran within the
+// maximum age. Only exact patient ID, accession number and study UID,
+// modalities and study dates are matched by the index; other searches, and
+// searches routed to another config than the tenant's synchronized primary, go
+// to the PACS.
+func (s *PACSService) studiesFromIndex(ctx context.Context, config *models.PACSConfig, params models.QueryParams) ([]models.Study, bool, bool) {
+	if !config.IsPrimary || !s.answersFromIndex() || params.PatientName != "" || params.StudyTime != "" || params.StudyDescription != "" {
+		return nil, false, false
+	}
+	for tag, value := range params.Filters {
+		if tag != "0020000D" || strings.ContainsAny(value, `\*?`) {
+			return nil, false, false
+		}
+	}
+	if strings.ContainsAny(params.PatientID+params.AccessionNumber, `\*?`) {
+		return nil, false, false
+	}
+	from, to, ok := indexedStudyDates(params.StudyDate)
+	if !ok {
+		return nil, false, false
+	}
+
+	tenantID := config.TenantID
+	state, err := s.index.repo.GetSyncState(ctx, tenantID)
+	if err != nil || !s.index.fresh(state.SyncedAt) || state.IndexedFrom == "" || from < state.IndexedFrom || to > state.SyncedTo {
+		return nil, false, false
+	}
+
+	// Index entries carry the attributes left by the tenant's response filter
+	if filter := newResponseFilter(config); filter != nil {
+		for _, tag := range []string{"0020000D", "00100020", "00080050", "00080020", "00080061"} {
+			if filter.removes(tag) {
+				return nil, false, false
+			}
+		}
+	}
+
+	maxResults := s.maxResults(ctx, config)
+	if !restrictModalities(&params, s.allowedModalities(ctx, tenantID)) {
+		reportIndexAnswer(ctx, *state.SyncedAt)
+		return []models.Study{}, false, true
+	}
+	for i, modality := range params.Modalities {
+		params.Modalities[i] = strings.ToUpper(modality)
+	}
+	capped := params.Limit <= 0 || params.Limit > maxResults
+	if capped {
+		params.Limit = maxResults + 1
+	}
+
+	entries, err := s.index.repo.SearchStudies(ctx, tenantID, config.ID, params)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to search the study index; searching the PACS")
+		return nil, false, false
+	}
+
+	studies := make([]models.Study, len(entries))
+	for i, entry := range entries {
+		studies[i] = models.Study{
+			StudyInstanceUID:   entry.StudyInstanceUID,
+			PatientID:          entry.PatientID,
+			PatientName:        entry.PatientName,
+			PatientBirthDate:   entry.PatientBirthDate,
+			PatientSex:         entry.PatientSex,
+			StudyDate:          entry.StudyDate,
+			StudyTime:          entry.StudyTime,
+			StudyDescription:   entry.StudyDescription,
+			AccessionNumber:    entry.AccessionNumber,
+			ReferringPhysician: entry.ReferringPhysician,
+			NumberOfSeries:     entry.NumberOfSeries,
+			NumberOfInstances:  entry.NumberOfInstances,
+			ModalitiesInStudy:  entry.ModalitiesInStudy,
+		}
+	}
+	newResponseFilter(config).filterFields(&studies)
+	normalizeStudies(studies)
+
+	truncated := capped && len(studies) > maxResults
+	if truncated {
+		studies = studies[:maxResults]
+	}
+	reportIndexAnswer(ctx, *state.SyncedAt)
+	return studies, truncated, true
+}
+
+// indexedStudy returns the index entry of a study when the tenant's requests
+// for it are routed to the PACS it was indexed from
+func (s *PACSService) indexedStudy(ctx context.Context, tenantID uuid.UUID, studyUID string) (*models.StudyIndexEntry, bool) {
+	study, err := s.index.repo.GetStudy(ctx, tenantID, studyUID)
+	if err != nil {
+		return nil, false
+	}
+	config, _, err := s.route(withStudyHint(ctx, studyUID), tenantID)
+	if err != nil || config.ID != study.PACSConfigID {
+		return nil, false
+	}
+	return study, true
+}
+
+// seriesFromIndex answers a series search from the index when the PACS listed
+// the study's series within the maximum age
+func (s *PACSService) seriesFromIndex(ctx context.Context, tenantID uuid.UUID, studyUID string) ([]models.Series, bool) {
+	if !s.answersFromIndex() {
+		return nil, false
+	}
+	study, ok := s.indexedStudy(ctx, tenantID, studyUID)
+	if !ok || !s.index.fresh(study.SeriesIndexedAt) {
+		return nil, false
+	}
+
+	entries, err := s.index.repo.GetSeries(ctx, tenantID, studyUID)
+	if err != nil {
+		return nil, false
+	}
+	series := make([]models.Series, len(entries))
+	for i, entry := range entries {
+		if err := json.Unmarshal([]byte(entry.Dataset), &series[i]); err != nil {
+			return nil, false
+		}
+	}
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].SeriesNumber < series[j].SeriesNumber
+	})
+
+	reportIndexAnswer(ctx, *study.SeriesIndexedAt)
+	return series, true
+}
+
+// instancesFromIndex answers an instance search from the index when the PACS
+// listed the series' instances within the maximum age
+func (s *PACSService) instancesFromIndex(ctx context.Context, tenantID uuid.UUID, studyUID, seriesUID string) ([]models.Instance, bool) {
+	if !s.answersFromIndex() {
+		return nil, false
+	}
+	if _, ok := s.indexedStudy(ctx, tenantID, studyUID); !ok {
+		return nil, false
+	}
+	series, err := s.index.repo.GetSeriesEntry(ctx, tenantID, seriesUID)
+	if err != nil || series.StudyInstanceUID != studyUID || !s.index.fresh(series.InstancesIndexedAt) {
+		return nil, false
+	}
+
+	entries, err := s.index.repo.GetInstances(ctx, tenantID, seriesUID)
+	if err != nil {
+		return nil, false
+	}
+	instances := make([]models.Instance, len(entries))
+	for i, entry := range entries {
+		if err := json.Unmarshal([]byte(entry.Dataset), &instances[i]); err != nil {
+			return nil, false
+		}
+	}
+	sort.SliceStable(instances, func(i, j int) bool {
+		return instances[i].InstanceNumber < instances[j].InstanceNumber
+	})
+
+	reportIndexAnswer(ctx, *series.InstancesIndexedAt)
+	return instances, true
+}
+
+// forgetIndexedStudy removes a study from the index, or with contentsOnly its
+// series and instances, so they are searched on the PACS again
+func (s *PACSService) forgetIndexedStudy(ctx context.Context, tenantID uuid.UUID, studyUID string, contentsOnly bool) {
+	if s.index == nil {
+		return
+	}
+	forget := s.index.repo.DeleteStudy
+	if contentsOnly {
+		forget = s.index.repo.ForgetStudyContents
+	}
+	if err := forget(ctx, tenantID, studyUID); err != nil {
+		log.Warn().Err(err).Str("tenant_id", tenantID.String()).Str("study_uid", studyUID).Msg("Failed to remove study from the index")
+	}
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Tenant errors
+var (
+	ErrTenantNotFound       = errors.New("tenant not found")
+	ErrInvalidTenantRequest = errors.New("invalid tenant request")
+	ErrTenantState          = errors.New("tenant state conflict")
+)
+
+// tenantLookupTTL is how long a tenant is kept before it is read again; a
+// tenant disabled through another connector instance is served here for up to
+// this long
+const tenantLookupTTL = 30 * time.Second
+
+// cachedTenant is a tenant as last read
+type cachedTenant struct {
+	tenant  models.Tenant
+	expires time.Time
+}
+
+// TenantService provisions tenants and manages their lifecycle, and tells the
+// tenant middleware whether a tenant may be served
+type TenantService struct {
+	pacsService *PACSService
+	keyService  *APIKeyService
+	repo        *repository.TenantRepository
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedTenant
+}
+
+// NewTenantService creates a tenant service
+func NewTenantService(pacsService *PACSService, keyService *APIKeyService, repo *repository.TenantRepository) *TenantService {
+	return &TenantService{
+		pacsService: pacsService,
+		keyService:  keyService,
+		repo:        repo,
+		cache:       make(map[uuid.UUID]cachedTenant),
+	}
+}
+
+// ImportExistingTenants provisions the tenants configured before tenants were
+// kept, so they are still served after an upgrade
+func (s *TenantService) ImportExistingTenants(ctx context.Context) error {
+	imported, err := s.repo.ImportExisting(ctx)
+	if err != nil {
+		return err
+	}
+	if imported > 0 {
+		log.Info().Int64("tenants", imported).Msg("Provisioned existing tenants")
+	}
+	return nil
+}
+
+// LookupTenant returns a tenant, or nil when it is not provisioned. Tenants are
+// kept for tenantLookupTTL, and the last one read is returned while the
+// database is unavailable.
+func (s *TenantService) LookupTenant(ctx context.Context, tenantID uuid.UUID) (*models.Tenant, error) {
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[tenantID]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return &cached.tenant, nil
+	}
+
+	tenant, err := s.repo.GetByID(ctx, tenantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.forget(tenantID)
+		return nil, nil
+	}
+	if err != nil {
+		if ok {
+			log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to read tenant, using the last one read")
+			return &cached.tenant, nil
+		}
+		return nil, err
+	}
+
+	// Only provisioned tenants are kept, so unknown IDs cannot fill the cache
+	s.mu.Lock()
+	s.cache[tenantID] = cachedTenant{tenant: *tenant, expires: now.Add(tenantLookupTTL)}
+	s.mu.Unlock()
+	return tenant, nil
+}
+
+// CreateTenant provisions a tenant, active from the start
+func (s *TenantService) CreateTenant(ctx context.Context, req *models.TenantRequest, ipAddress, userAgent string) (*models.Tenant, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidTenantRequest)
+	}
+	if len(name) > 255 {
+		return nil, fmt.Errorf("%w: name must be at most 255 characters", ErrInvalidTenantRequest)
+	}
+
+	tenant := &models.Tenant{Name: name, Status: models.TenantStatusActive}
+	if req.ID != nil {
+		if *req.ID == uuid.Nil {
+			return nil, fmt.Errorf("%w: id must not be the nil UUID", ErrInvalidTenantRequest)
+		}
+		exists, err := s.repo.ExistsWithDeleted(ctx, *req.ID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("%w: tenant %s was provisioned before", ErrTenantState, *req.ID)
+		}
+		tenant.ID = *req.ID
+	}
+	if err := s.repo.Create(ctx, tenant); err != nil {
+		return nil, err
+	}
+	s.recordAudit(ctx, tenant.ID, "tenant.create", ipAddress, userAgent)
+
+	log.Info().
+		Str("tenant_id", tenant.ID.String()).
+		Str("name", tenant.Name).
+		Msg("Tenant provisioned")
+	return tenant, nil
+}
+
+// GetTenants lists the tenants, of one status when status is not empty
+func (s *TenantService) GetTenants(ctx context.Context, status string) ([]models.Tenant, error) {
+	if status != "" && status != models.TenantStatusActive && status != models.TenantStatusDisabled {
+		return nil, fmt.Errorf("%w: status must be %s or %s", ErrInvalidTenantRequest, models.TenantStatusActive, models.TenantStatusDisabled)
+	}
+	return s.repo.List(ctx, status)
+}
+
+// GetTenant returns a tenant, or ErrTenantNotFound
+func (s *TenantService) GetTenant(ctx context.Context, tenantID uuid.UUID) (*models.Tenant, error) {
+	tenant, err := s.repo.GetByID(ctx, tenantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTenantNotFound
+	}
+	return tenant, err
+}
+
+// RenameTenant changes a tenant's name
+func (s *TenantService) RenameTenant(ctx context.Context, tenantID uuid.UUID, req *models.TenantRequest, ipAddress, userAgent string) (*models.Tenant, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidTenantRequest)
+	}
+	if len(name) > 255 {
+		return nil, fmt.Errorf("%w: name must be at most 255 characters", ErrInvalidTenantRequest)
+	}
+	if req.ID != nil && *req.ID != tenantID {
+		return nil, fmt.Errorf("%w: the ID of a tenant cannot be changed", ErrInvalidTenantRequest)
+	}
+
+	tenant, err := s.GetTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Rename(ctx, tenantID, name); err != nil {
+		return nil, err
+	}
+	tenant.Name = name
+	s.forget(tenantID)
+	s.recordAudit(ctx, tenantID, "tenant.rename", ipAddress, userAgent)
+	return tenant, nil
+}
+
+// DisableTenant stops serving a tenant's requests, whatever credential they
+// carry. Its configuration is kept.
+func (s *TenantService) DisableTenant(ctx context.Context, tenantID uuid.UUID, ipAddress, userAgent string) (*models.Tenant, error) {
+	tenant, err := s.GetTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !tenant.Active() {
+		return nil, fmt.Errorf("%w: the tenant is already disabled", ErrTenantState)
+	}
+
+	now := time.Now()
+	if err := s.repo.SetStatus(ctx, tenantID, models.TenantStatusDisabled, &now); err != nil {
+		return nil, err
+	}
+	tenant.Status = models.TenantStatusDisabled
+	tenant.DisabledAt = &now
+	s.forget(tenantID)
+	s.recordAudit(ctx, tenantID, "tenant.disable", ipAddress, userAgent)
+
+	log.Info().Str("tenant_id", tenantID.String()).Msg("Tenant disabled")
+	return tenant, nil
+}
+
+// EnableTenant serves a disabled tenant's requests again
+func (s *TenantService) EnableTenant(ctx context.Context, tenantID uuid.UUID, ipAddress, userAgent string) (*models.Tenant, error) {
+	tenant, err := s.GetTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.Active() {
+		return nil, fmt.Errorf("%w: the tenant is already active", ErrTenantState)
+	}
+
+	if err := s.repo.SetStatus(ctx, tenantID, models.TenantStatusActive, nil); err != nil {
+		return nil, err
+	}
+	tenant.Status = models.TenantStatusActive
+	tenant.DisabledAt = nil
+	s.forget(tenantID)
+	s.recordAudit(ctx, tenantID, "tenant.enable", ipAddress, userAgent)
+
+	log.Info().Str("tenant_id", tenantID.String()).Msg("Tenant enabled")
+	return tenant, nil
+}
+
+// DeleteTenant removes a disabled tenant. Its ID is not provisioned again, and
+// its data is left to be removed through the tenant's own routes beforehand.
+func (s *TenantService) DeleteTenant(ctx context.Context, tenantID uuid.UUID, ipAddress, userAgent string) error {
+	tenant, err := s.GetTenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if tenant.Active() {
+		return fmt.Errorf("%w: disable the tenant before deleting it", ErrTenantState)
+	}
+
+	if err := s.repo.Delete(ctx, tenantID); err != nil {
+		return err
+	}
+	s.forget(tenantID)
+	s.recordAudit(ctx, tenantID, "tenant.delete", ipAddress, userAgent)
+
+	log.Info().Str("tenant_id", tenantID.String()).Msg("Tenant deleted")
+	return nil
+}
+
+// RotateTenantKeys replaces every valid API key of a tenant, as RotateKey does
+// for one. A key that fails to rotate is left as it was and reported, so the
+// keys issued for the others are not lost.
+func (s *TenantService) RotateTenantKeys(ctx context.Context, tenantID uuid.UUID, req *models.APIKeyRotateRequest, ipAddress, userAgent string) (*models.TenantKeyRotation, error) {
+	if err := validateKeyRotation(req); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetTenant(ctx, tenantID); err != nil {
+		return nil, err
+	}
+	keys, err := s.keyService.GetKeys(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys already replaced, and waiting out their grace period, are left alone
+	replaced := make(map[uuid.UUID]bool)
+	for _, key := range keys {
+		if key.RotatedFromID != nil {
+			replaced[*key.RotatedFromID] = true
+		}
+	}
+
+	now := time.Now()
+	rotation := &models.TenantKeyRotation{Keys: []models.IssuedAPIKey{}}
+	var lastErr error
+	for _, key := range keys {
+		if key.RevokedAt != nil || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) || replaced[key.ID] {
+			continue
+		}
+		issued, err := s.keyService.RotateKey(ctx, tenantID, key.ID, req, ipAddress, userAgent)
+		if err != nil {
+			log.Error().Err(err).Str("tenant_id", tenantID.String()).Str("key_id", key.ID.String()).Msg("Failed to rotate API key")
+			rotation.Failed = append(rotation.Failed, key.ID)
+			lastErr = err
+			continue
+		}
+		rotation.Keys = append(rotation.Keys, *issued)
+	}
+	if len(rotation.Keys) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return rotation, nil
+}
+
+// forget drops a tenant from the cache after it changed
+func (s *TenantService) forget(tenantID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, tenantID)
+}
+
+// recordAudit records a tenant lifecycle action
+func (s *TenantService) recordAudit(ctx context.Context, tenantID uuid.UUID, action, ipAddress, userAgent string) {
+	entry := &models.AuditLog{
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "tenant",
+		ResourceUID:  tenantID.String(),
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Status:       "success",
+	}
+	if err := s.pacsService.recordAudit(ctx, entry); err != nil {
+		log.Error().Err(err).Str("action", action).Msg("Failed to record tenant audit entry")
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidTenantSettings is returned when a tenant settings request is malformed
+var ErrInvalidTenantSettings = errors.New("invalid tenant settings")
+
+// tenantSettingsTTL is how long a tenant's settings are kept before they are
+// read again. A change made through another connector instance takes effect
+// here within this time.
+const tenantSettingsTTL = 30 * time.Second
+
+// cachedTenantSettings are a tenant's settings as last read; settings is nil
+// when the tenant has none
+type cachedTenantSettings struct {
+	settings *models.TenantSettings
+	expires  time.Time
+}
+
+// tenantSettings returns the tenant's settings, read at most every
+// tenantSettingsTTL; it is nil when the tenant has none or they cannot be read
+func (s *PACSService) tenantSettings(ctx context.Context, tenantID uuid.UUID) *models.TenantSettings {
+	now := time.Now()
+	s.settingsMu.Lock()
+	cached, ok := s.settings[tenantID]
+	s.settingsMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.settings
+	}
+
+	settings, err := s.settingsRepo.Get(ctx, tenantID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			// Keep the last known settings while the database is unavailable
+			log.Warn().Err(err).Str("tenant_id", tenantID.String()).Msg("Failed to read tenant settings")
+			return cached.settings
+		}
+		settings = nil
+	}
+
+	s.settingsMu.Lock()
+	s.settings[tenantID] = cachedTenantSettings{settings: settings, expires: now.Add(tenantSettingsTTL)}
+	s.settingsMu.Unlock()
+	return settings
+}
+
+// forgetTenantSettings drops the kept settings of a tenant after they changed
+func (s *PACSService) forgetTenantSettings(tenantID uuid.UUID) {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	delete(s.settings, tenantID)
+}
+
+// ttls returns the cache lifetimes of the tenant, its settings applied over the
+// deployment defaults
+func (s *PACSService) ttls(ctx context.Context, tenantID uuid.UUID) CacheTTLs {
+	ttls := s.cacheTTLs
+	if settings := s.tenantSettings(ctx, tenantID); settings != nil {
+		ttls.Instance = tenantCacheTTL(settings.InstanceCacheTTL, ttls.Instance)
+		ttls.Metadata = tenantCacheTTL(settings.MetadataCacheTTL, ttls.Metadata)
+		ttls.Query = tenantCacheTTL(settings.QueryCacheTTL, ttls.Query)
+		ttls.Thumbnail = tenantCacheTTL(settings.ThumbnailCacheTTL, ttls.Thumbnail)
+	}
+	return ttls
+}
+
+// maxResults returns the study search cap of a PACS config: its own, else the
+// tenant's, else DefaultMaxResults
+func (s *PACSService) maxResults(ctx context.Context, config *models.PACSConfig) int {
+	if config.MaxResults > 0 {
+		return config.MaxResults
+	}
+	if settings := s.tenantSettings(ctx, config.TenantID); settings != nil && settings.MaxResults > 0 {
+		return settings.MaxResults
+	}
+	return DefaultMaxResults
+}
+
+// allowedModalities returns the modalities the tenant's searches are limited
+// to; nil allows all
+func (s *PACSService) allowedModalities(ctx context.Context, tenantID uuid.UUID) []string {
+	if settings := s.tenantSettings(ctx, tenantID); settings != nil && len(settings.AllowedModalities) > 0 {
+		return settings.AllowedModalities
+	}
+	return nil
+}
+
+// featureEnabled reports whether a tenant toggle leaves a feature on; features
+// are on unless the tenant turned them off
+func featureEnabled(toggle *bool) bool {
+	return toggle == nil || *toggle
+}
+
+// fanOutEnabled reports whether the tenant allows fan-out study searches
+func (s *PACSService) fanOutEnabled(ctx context.Context, tenantID uuid.UUID) bool {
+	settings := s.tenantSettings(ctx, tenantID)
+	return settings == nil || featureEnabled(settings.FanOutEnabled)
+}
+
+// failoverEnabled reports whether the tenant allows failover to a secondary PACS
+func (s *PACSService) failoverEnabled(ctx context.Context, tenantID uuid.UUID) bool {
+	settings := s.tenantSettings(ctx, tenantID)
+	return settings == nil || featureEnabled(settings.FailoverEnabled)
+}
+
+// prefetchOptions returns the prior prefetch rules of the tenant, its settings
+// applied over the deployment defaults, and whether prefetching is enabled
+func (s *PACSService) prefetchOptions(ctx context.Context, tenantID uuid.UUID, defaults PrefetchOptions) (PrefetchOptions, bool) {
+	settings := s.tenantSettings(ctx, tenantID)
+	if settings == nil {
+		return defaults, true
+	}
+
+	opts := defaults
+	if settings.PrefetchPriors != nil {
+		opts.MaxPriors = *settings.PrefetchPriors
+	}
+	if settings.PrefetchLookbackDays != nil {
+		opts.Lookback = time.Duration(*settings.PrefetchLookbackDays) * 24 * time.Hour
+	}
+	if settings.PrefetchSameModality != nil {
+		opts.SameModality = *settings.PrefetchSameModality
+	}
+	if settings.PrefetchSameBodyPart != nil {
+		opts.SameBodyPart = *settings.PrefetchSameBodyPart
+	}
+	return opts, featureEnabled(settings.PrefetchEnabled)
+}
+
+// GetTenantSettings returns the tenant's settings; a tenant without settings
+// gets empty ones, which apply the deployment defaults
+func (s *PACSService) GetTenantSettings(ctx context.Context, tenantID uuid.UUID) (*models.TenantSettings, error) {
+	settings, err := s.settingsRepo.Get(ctx, tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.TenantSettings{TenantID: tenantID}, nil
+		}
+		return nil, err
+	}
+	return settings, nil
+}
+
+// UpdateTenantSettings replaces the tenant's settings
+func (s *PACSService) UpdateTenantSettings(ctx context.Context, tenantID uuid.UUID, req *models.TenantSettingsRequest) (*models.TenantSettings, error) {
+	for name, ttl := range map[string]int{
+		"instance_cache_ttl":  req.InstanceCacheTTL,
+		"metadata_cache_ttl":  req.MetadataCacheTTL,
+		"query_cache_ttl":     req.QueryCacheTTL,
+		"thumbnail_cache_ttl": req.ThumbnailCacheTTL,
+	} {
+		if ttl < -1 {
+			return nil, fmt.Errorf("%w: %s must be -1 or more", ErrInvalidTenantSettings, name)
+		}
+	}
+	if req.MaxResults < 0 {
+		return nil, fmt.Errorf("%w: max_results must not be negative", ErrInvalidTenantSettings)
+	}
+	if req.RateLimit < -1 {
+		return nil, fmt.Errorf("%w: rate_limit must be requests per second, or -1 for no limit", ErrInvalidTenantSettings)
+	}
+	if req.RateBurst < 0 {
+		return nil, fmt.Errorf("%w: rate_burst must not be negative", ErrInvalidTenantSettings)
+	}
+	if req.PrefetchPriors != nil && *req.PrefetchPriors < 0 {
+		return nil, fmt.Errorf("%w: prefetch_priors must not be negative", ErrInvalidTenantSettings)
+	}
+	if req.PrefetchLookbackDays != nil && *req.PrefetchLookbackDays < 0 {
+		return nil, fmt.Errorf("%w: prefetch_lookback_days must not be negative", ErrInvalidTenantSettings)
+	}
+
+	var modalities []string
+	for _, modality := range req.AllowedModalities {
+		modality = strings.ToUpper(strings.TrimSpace(modality))
+		if modality == "" {
+			return nil, fmt.Errorf("%w: allowed_modalities must not contain empty values", ErrInvalidTenantSettings)
+		}
+		if !slices.Contains(modalities, modality) {
+			modalities = append(modalities, modality)
+		}
+	}
+
+	settings := &models.TenantSettings{
+		TenantID:             tenantID,
+		InstanceCacheTTL:     req.InstanceCacheTTL,
+		MetadataCacheTTL:     req.MetadataCacheTTL,
+		QueryCacheTTL:        req.QueryCacheTTL,
+		ThumbnailCacheTTL:    req.ThumbnailCacheTTL,
+		MaxResults:           req.MaxResults,
+		RateLimit:            req.RateLimit,
+		RateBurst:            req.RateBurst,
+		AllowedModalities:    modalities,
+		PrefetchPriors:       req.PrefetchPriors,
+		PrefetchLookbackDays: req.PrefetchLookbackDays,
+		PrefetchSameModality: req.PrefetchSameModality,
+		PrefetchSameBodyPart: req.PrefetchSameBodyPart,
+		FanOutEnabled:        req.FanOutEnabled,
+		PrefetchEnabled:      req.PrefetchEnabled,
+		FailoverEnabled:      req.FailoverEnabled,
+	}
+	if err := s.settingsRepo.Save(ctx, settings); err != nil {
+		return nil, err
+	}
+	s.forgetTenantSettings(tenantID)
+
+	// The stored row keeps its original creation time
+	return s.GetTenantSettings(ctx, tenantID)
+}
+
+// ResetTenantSettings removes the tenant's settings, so the deployment
+// defaults apply again
+func (s *PACSService) ResetTenantSettings(ctx context.Context, tenantID uuid.UUID) error {
+	if _, err := s.settingsRepo.Delete(ctx, tenantID); err != nil {
+		return err
+	}
+	s.forgetTenantSettings(tenantID)
+	return nil
+}
+
+// restrictModalities limits a study search to the allowed modalities. It
+// reports false when the search asks only for modalities that are not allowed.
+func restrictModalities(params *models.QueryParams, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	if len(params.Modalities) == 0 {
+		params.Modalities = allowed
+		return true
+	}
+
+	var modalities []string
+	for _, modality := range params.Modalities {
+		if slices.Contains(allowed, strings.ToUpper(modality)) {
+			modalities = append(modalities, modality)
+		}
+	}
+	params.Modalities = modalities
+	return len(modalities) > 0
+}
+
+// modalityAllowed reports whether a result with the given modalities may be
+// returned; results that do not report their modalities are kept
+func modalityAllowed(modalities, allowed []string) bool {
+	if len(allowed) == 0 || len(modalities) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(modalities, func(modality string) bool {
+		return slices.Contains(allowed, strings.ToUpper(modality))
+	})
+}
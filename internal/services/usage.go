@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidUsagePeriod is returned for a usage query whose period ends before
+// it starts or spans too many days
+var ErrInvalidUsagePeriod = errors.New("invalid usage period")
+
+const (
+	// usageFlushInterval is how often metered usage is added to the database
+	usageFlushInterval = time.Minute
+	// defaultUsagePeriodDays is how many days a usage report covers by default
+	defaultUsagePeriodDays = 30
+	// maxUsagePeriodDays is the longest period a usage report covers
+	maxUsagePeriodDays = 366
+	// usageDayLayout is how days are given and reported
+	usageDayLayout = "2006-01-02"
+)
+
+// usageKey identifies the usage of a tenant on a UTC day
+type usageKey struct {
+	tenantID uuid.UUID
+	day      time.Time
+}
+
+// UsageService meters the usage of each tenant for billing: searches,
+// retrievals, bytes served and cache reads, counted per UTC day. Counts are
+// kept in memory and added to the tenant_usage table every minute, so
+// metering never holds up a request and every connector instance adds its own.
+type UsageService struct {
+	repo *repository.UsageRepository
+
+	mu     sync.Mutex
+	counts map[usageKey]*models.UsageCounts // metered since the last flush
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewUsageService creates a usage service; call Start to run its writer
+func NewUsageService(repo *repository.UsageRepository) *UsageService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &UsageService{
+		repo:   repo,
+		counts: make(map[usageKey]*models.UsageCounts),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start launches the writer
+func (s *UsageService) Start() {
+	s.wg.Add(1)
+	go s.run()
+}
+
+// Stop waits for the writer to exit after writing the usage metered so far
+func (s *UsageService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// RecordQuery meters a search of the tenant
+func (s *UsageService) RecordQuery(tenantID uuid.UUID) {
+	s.add(tenantID, models.UsageCounts{Queries: 1})
+}
+
+// RecordRequest meters the response bytes of a request of the tenant and, for
+// a retrieval, the retrieval itself
+func (s *UsageService) RecordRequest(tenantID uuid.UUID, retrieval bool, bytes int64) {
+	usage := models.UsageCounts{BytesServed: bytes}
+	if retrieval {
+		usage.Retrievals = 1
+	}
+	s.add(tenantID, usage)
+}
+
+// RecordRead meters a cache read of the tenant whose ID the key starts with.
+// It makes the service a cache.Recorder.
+func (s *UsageService) RecordRead(key, tier string, hit bool, size int64, duration time.Duration) {
+	prefix, _, _ := strings.Cut(key, ":")
+	tenantID, err := uuid.Parse(prefix)
+	if err != nil {
+		return
+	}
+	if hit {
+		s.add(tenantID, models.UsageCounts{CacheHits: 1})
+	} else {
+		s.add(tenantID, models.UsageCounts{CacheMisses: 1})
+	}
+}
+
+// add meters usage of the tenant today
+func (s *UsageService) add(tenantID uuid.UUID, usage models.UsageCounts) {
+	s.addCounts(usageKey{tenantID: tenantID, day: usageDay(time.Now())}, usage)
+}
+
+// GetUsage reports the tenant's usage per day from the first to the last day
+// given, both YYYY-MM-DD. The last day defaults to today and the first to
+// defaultUsagePeriodDays before it. Usage metered in the last minute may not
+// be included yet.
+func (s *UsageService) GetUsage(ctx context.Context, tenantID uuid.UUID, from, to string) (*models.UsageReport, error) {
+	last := usageDay(time.Now())
+	if to != "" {
+		parsed, err := time.Parse(usageDayLayout, to)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to must be a YYYY-MM-DD date", ErrInvalidUsagePeriod)
+		}
+		last = parsed
+	}
+	first := last.AddDate(0, 0, 1-defaultUsagePeriodDays)
+	if from != "" {
+		parsed, err := time.Parse(usageDayLayout, from)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from must be a YYYY-MM-DD date", ErrInvalidUsagePeriod)
+		}
+		first = parsed
+	}
+	if last.Before(first) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrInvalidUsagePeriod)
+	}
+	if last.Sub(first) >= maxUsagePeriodDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: the period must not exceed %d days", ErrInvalidUsagePeriod, maxUsagePeriodDays)
+	}
+
+	usage, err := s.repo.GetByTenantID(ctx, tenantID, first, last)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.UsageReport{
+		TenantID: tenantID.String(),
+		From:     first.Format(usageDayLayout),
+		To:       last.Format(usageDayLayout),
+		Days:     make([]models.UsageDay, 0, len(usage)),
+	}
+	for _, day := range usage {
+		report.Days = append(report.Days, models.UsageDay{Day: day.Day.Format(usageDayLayout), UsageCounts: day.UsageCounts})
+		report.Total.Add(day.UsageCounts)
+	}
+	return report, nil
+}
+
+func (s *UsageService) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.flush()
+			return
+		case <-ticker.C:
+			s.flush()
+		}
+	}
+}
+
+// flush adds the usage metered since the last flush to the database. Usage
+// that fails to be written is kept for the next flush.
+func (s *UsageService) flush() {
+	s.mu.Lock()
+	pending := s.counts
+	s.counts = make(map[usageKey]*models.UsageCounts)
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	now := time.Now()
+	records := make([]models.TenantUsage, 0, len(pending))
+	for key, counts := range pending {
+		records = append(records, models.TenantUsage{TenantID: key.tenantID, Day: key.day, UsageCounts: *counts, UpdatedAt: now})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.repo.Add(ctx, records); err != nil {
+		log.Error().Err(err).Int("tenant_days", len(records)).Msg("Failed to record usage, retrying at the next flush")
+		for key, counts := range pending {
+			s.addCounts(key, *counts)
+		}
+	}
+}
+
+// addCounts adds to the usage metered for a tenant and day
+func (s *UsageService) addCounts(key usageKey, usage models.UsageCounts) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts, ok := s.counts[key]
+	if !ok {
+		counts = &models.UsageCounts{}
+		s.counts[key] = counts
+	}
+	counts.Add(usage)
+}
+
+// usageDay returns the UTC day of t
+func usageDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Viewer grant errors
+var (
+	ErrSMARTNotConfigured        = errors.New("SMART launch is not configured")
+	ErrInvalidLaunchToken        = errors.New("invalid or inactive SMART launch token")
+	ErrLaunchNotAuthorized       = errors.New("SMART launch does not grant access to the study")
+	ErrInvalidViewerGrant        = errors.New("invalid or expired viewer grant")
+	ErrInvalidViewerGrantRequest = errors.New("invalid viewer grant request")
+)
+
+const (
+	// viewerGrantTokenBytes is the entropy of grant tokens
+	viewerGrantTokenBytes = 32
+	// introspectionTimeout bounds a call to the SMART introspection endpoint
+	introspectionTimeout = 10 * time.Second
+)
+
+// ViewerGrantConfig configures SMART on FHIR launch hand-off
+type ViewerGrantConfig struct {
+	IntrospectionURL string // OAuth2 token introspection endpoint (RFC 7662) of the SMART authorization server
+	ClientID         string // credentials the connector introspects with
+	ClientSecret     string
+	TTL              time.Duration // lifetime of viewer grants
+}
+
+// smartIntrospection is the subset of an introspection response used for grants
+type smartIntrospection struct {
+	Active   bool   `json:"active"`
+	Scope    string `json:"scope"`
+	Subject  string `json:"sub"`
+	ClientID string `json:"client_id"`
+	Patient  string `json:"patient"`
+	Expires  int64  `json:"exp"`
+}
+
+// ViewerGrantService exchanges SMART on FHIR launch tokens for short-lived
+// viewer grants scoped to one study, and validates the grants presented on
+// DICOMweb requests
+type ViewerGrantService struct {
+	pacsService *PACSService
+	repo        *repository.ViewerGrantRepository
+	config      ViewerGrantConfig
+	client      *http.Client
+
+	// Grants cannot be revoked, so validated grants are kept in memory until they expire
+	mu    sync.Mutex
+	cache map[string]models.ViewerGrant
+}
+
+// NewViewerGrantService creates a viewer grant service
+func NewViewerGrantService(pacsService *PACSService, repo *repository.ViewerGrantRepository, config ViewerGrantConfig) *ViewerGrantService {
+	return &ViewerGrantService{
+		pacsService: pacsService,
+		repo:        repo,
+		config:      config,
+		client:      &http.Client{Timeout: introspectionTimeout},
+		cache:       make(map[string]models.ViewerGrant),
+	}
+}
+
+// CreateGrant verifies a SMART launch token with the authorization server,
+// resolves the study and mints a viewer grant for it. The grant expires after
+// the configured TTL, or with the launch token if that is sooner.
+func (s *ViewerGrantService) CreateGrant(ctx context.Context, tenantID uuid.UUID, req *models.ViewerGrantRequest, ipAddress, userAgent string) (*models.ViewerGrantResponse, error) {
+	if s.config.IntrospectionURL == "" {
+		return nil, ErrSMARTNotConfigured
+	}
+	if req.LaunchToken == "" {
+		return nil, fmt.Errorf("%w: launch_token is required", ErrInvalidViewerGrantRequest)
+	}
+	if (req.StudyInstanceUID == "") == (req.AccessionNumber == "") {
+		return nil, fmt.Errorf("%w: give either study_instance_uid or accession_number", ErrInvalidViewerGrantRequest)
+	}
+
+	launch, err := s.introspect(ctx, req.LaunchToken)
+	if err != nil {
+		return nil, err
+	}
+	if !allowsImagingStudyRead(launch.Scope) {
+		return nil, ErrLaunchNotAuthorized
+	}
+
+	study, err := s.resolveStudy(ctx, tenantID, req)
+	if err != nil {
+		return nil, err
+	}
+	// A launch in a patient's context only reaches that patient's studies
+	if launch.Patient != "" && study.PatientID != launch.Patient {
+		return nil, ErrLaunchNotAuthorized
+	}
+	studyUID := study.StudyInstanceUID
+
+	raw := make([]byte, viewerGrantTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate viewer grant token: %w", err)
+	}
+	token := models.ViewerGrantTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	expires := now.Add(s.config.TTL)
+	if launch.Expires > 0 && time.Unix(launch.Expires, 0).Before(expires) {
+		expires = time.Unix(launch.Expires, 0)
+	}
+
+	grant := &models.ViewerGrant{
+		TenantID:  tenantID,
+		StudyUID:  studyUID,
+		TokenHash: hashViewerGrantToken(token),
+		Subject:   launch.Subject,
+		PatientID: launch.Patient,
+		ClientID:  launch.ClientID,
+		ExpiresAt: expires,
+	}
+	if err := s.repo.Create(ctx, grant); err != nil {
+		return nil, err
+	}
+	s.deleteExpired(ctx, now)
+
+	entry := &models.AuditLog{
+		TenantID:     tenantID,
+		Action:       "viewer.grant",
+		ResourceType: "study",
+		ResourceUID:  studyUID,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Status:       "success",
+	}
+	if err := s.pacsService.recordAudit(ctx, entry); err != nil {
+		log.Error().Err(err).Msg("Failed to record viewer grant audit entry")
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("grant_id", grant.ID.String()).
+		Str("study_uid", studyUID).
+		Str("subject", launch.Subject).
+		Time("expires_at", expires).
+		Msg("Viewer grant issued")
+
+	return &models.ViewerGrantResponse{
+		AccessToken:      token,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(time.Until(expires).Seconds()),
+		ExpiresAt:        expires,
+		StudyInstanceUID: studyUID,
+	}, nil
+}
+
+// ValidateGrant returns the unexpired grant a token was issued for
+func (s *ViewerGrantService) ValidateGrant(ctx context.Context, token string) (*models.ViewerGrant, error) {
+	if !strings.HasPrefix(token, models.ViewerGrantTokenPrefix) {
+		return nil, ErrInvalidViewerGrant
+	}
+	hash := hashViewerGrantToken(token)
+	now := time.Now()
+
+	s.mu.Lock()
+	cached, ok := s.cache[hash]
+	s.mu.Unlock()
+	if ok && now.Before(cached.ExpiresAt) {
+		return &cached, nil
+	}
+
+	grant, err := s.repo.GetByTokenHash(ctx, hash, now)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidViewerGrant
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[hash] = *grant
+	s.mu.Unlock()
+	return grant, nil
+}
+
+// introspect asks the SMART authorization server whether a launch token is
+// active and what it grants
+func (s *ViewerGrantService) introspect(ctx context.Context, token string) (*smartIntrospection, error) {
+	form := url.Values{}
+	form.Set("token", token)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.config.IntrospectionURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create introspection request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.config.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute introspection request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("introspection endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result smartIntrospection
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode introspection response: %w", err)
+	}
+	if !result.Active {
+		return nil, ErrInvalidLaunchToken
+	}
+	return &result, nil
+}
+
+// resolveStudy looks up the requested study, which must exist in the tenant's
+// PACS. An accession number has to identify a single study.
+func (s *ViewerGrantService) resolveStudy(ctx context.Context, tenantID uuid.UUID, req *models.ViewerGrantRequest) (*models.Study, error) {
+	params := models.QueryParams{AccessionNumber: req.AccessionNumber, Limit: 2}
+	if req.StudyInstanceUID != "" {
+		params = models.QueryParams{Filters: map[string]string{"0020000D": req.StudyInstanceUID}, Limit: 1}
+	}
+
+	studies, _, err := s.pacsService.FindStudies(ctx, tenantID, params)
+	if err != nil {
+		return nil, err
+	}
+	switch len(studies) {
+	case 0:
+		return nil, ErrNotFound
+	case 1:
+		return &studies[0], nil
+	default:
+		return nil, fmt.Errorf("%w: accession number %s matches several studies; give study_instance_uid", ErrInvalidViewerGrantRequest, req.AccessionNumber)
+	}
+}
+
+// deleteExpired removes expired grants from the database and the cache
+func (s *ViewerGrantService) deleteExpired(ctx context.Context, now time.Time) {
+	if _, err := s.repo.DeleteExpired(ctx, now); err != nil {
+		log.Error().Err(err).Msg("Failed to delete expired viewer grants")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, grant := range s.cache {
+		if !now.Before(grant.ExpiresAt) {
+			delete(s.cache, hash)
+		}
+	}
+}
+
+func hashViewerGrantToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// allowsImagingStudyRead reports whether SMART scopes grant read access to
+// ImagingStudy, in v1 (patient/ImagingStudy.read) or v2 (user/ImagingStudy.rs) syntax
+func allowsImagingStudyRead(scopes string) bool {
+	for _, scope := range strings.Fields(scopes) {
+		_, resource, ok := strings.Cut(scope, "/")
+		if !ok {
+			continue
+		}
+		resourceType, permission, ok := strings.Cut(resource, ".")
+		if !ok || (resourceType != "ImagingStudy" && resourceType != "*") {
+			continue
+		}
+		// v2 permissions may carry a query, e.g. .rs?category=...
+		permission, _, _ = strings.Cut(permission, "?")
+		switch {
+		case permission == "read", permission == "*":
+			return true
+		case strings.Trim(permission, "cruds") == "" && strings.Contains(permission, "r"):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,496 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Webhook errors
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+)
+
+const (
+	// webhookEventQueueSize bounds the events waiting to be fanned out to webhooks
+	webhookEventQueueSize = 1024
+	// webhookClaimBatch is the most deliveries attempted per dispatch round
+	webhookClaimBatch = 50
+	// webhookBaseBackoff is the delay before the first retry; it doubles per attempt
+	webhookBaseBackoff = 30 * time.Second
+	// webhookMaxBackoff caps the delay between retries
+	webhookMaxBackoff = time.Hour
+	// webhookSecretBytes is the size of generated signing secrets
+	webhookSecretBytes = 32
+)
+
+// EventPublisher publishes connector events, to the tenant's webhooks or a
+// message bus. Publish must not block the caller; delivery happens in the background.
+type EventPublisher interface {
+	Publish(tenantID uuid.UUID, eventType string, data any)
+}
+
+// EventPublishers fans events out to several publishers
+type EventPublishers []EventPublisher
+
+// Publish publishes the event to every publisher
+func (p EventPublishers) Publish(tenantID uuid.UUID, eventType string, data any) {
+	for _, publisher := range p {
+		publisher.Publish(tenantID, eventType, data)
+	}
+}
+
+// WebhookConfig configures webhook delivery
+type WebhookConfig struct {
+	MaxAttempts  int           // attempts before a delivery is dead-lettered
+	Timeout      time.Duration // per-attempt request timeout
+	PollInterval time.Duration // how often due retries are looked for
+}
+
+// WebhookService manages tenant webhooks and delivers signed event
+// notifications with retries. Deliveries are stored before they are attempted,
+// so pending retries survive restarts, and those that exhaust their attempts
+// are kept as dead letters that can be redelivered.
+type WebhookService struct {
+	repo    *repository.WebhookRepository
+	config  WebhookConfig
+	client  *http.Client
+	secrets *adapters.CredentialCipher // nil when secrets are stored in plaintext
+
+	events chan models.WebhookEvent
+	wake   chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWebhookService creates a webhook service; call Start to deliver events
+func NewWebhookService(repo *repository.WebhookRepository, config WebhookConfig) *WebhookService {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookService{
+		repo:   repo,
+		config: config,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			// Redirects are not followed so a receiver cannot bounce signed events elsewhere
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		events: make(chan models.WebhookEvent, webhookEventQueueSize),
+		wake:   make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// EnableSecretEncryption encrypts the signing secrets of webhooks with the
+// credential cipher before they are stored
+func (s *WebhookService) EnableSecretEncryption(secrets *adapters.CredentialCipher) {
+	s.secrets = secrets
+}
+
+// EncryptWebhookSecrets encrypts the signing secrets every webhook still holds
+// in plaintext, deleted webhooks included, such as those stored before
+// encryption was enabled. It is safe to run on several connector instances at
+// once and returns the number of webhooks encrypted.
+func (s *WebhookService) EncryptWebhookSecrets(ctx context.Context) (int, error) {
+	if s.secrets == nil {
+		return 0, nil
+	}
+
+	webhooks, err := s.repo.GetAllWithDeleted(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	encrypted := 0
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		plaintext := webhook.Secret
+		sealed, err := s.secrets.SealWebhookSecret(ctx, webhook)
+		if err != nil {
+			return encrypted, fmt.Errorf("failed to encrypt the secret of webhook %s: %w", webhook.ID, err)
+		}
+		if !sealed {
+			continue
+		}
+		// Another instance may have encrypted it meanwhile
+		stored, err := s.repo.UpdateSecret(ctx, webhook.ID, plaintext, webhook.Secret)
+		if err != nil {
+			return encrypted, err
+		}
+		if stored {
+			encrypted++
+		}
+	}
+	return encrypted, nil
+}
+
+// CreateWebhook registers a webhook, generating its signing secret when none is given
+func (s *WebhookService) CreateWebhook(ctx context.Context, tenantID uuid.UUID, req *models.WebhookRequest) (*models.WebhookRegistration, error) {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	for _, event := range req.Events {
+		if !validEventType(event) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, event)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		raw := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = hex.EncodeToString(raw)
+	}
+
+	webhook := &models.Webhook{
+		TenantID: tenantID,
+		URL:      req.URL,
+		Secret:   secret,
+		Events:   req.Events,
+		IsActive: true,
+	}
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	if _, err := s.secrets.SealWebhookSecret(ctx, webhook); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("webhook_id", webhook.ID.String()).
+		Str("url", req.URL).
+		Msg("Webhook registered")
+
+	return &models.WebhookRegistration{Webhook: *webhook, Secret: secret}, nil
+}
+
+func validEventType(eventType string) bool {
+	for _, known := range models.WebhookEventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// GetWebhooks lists a tenant's webhooks
+func (s *WebhookService) GetWebhooks(ctx context.Context, tenantID uuid.UUID) ([]models.Webhook, error) {
+	return s.repo.GetByTenantID(ctx, tenantID)
+}
+
+// DeleteWebhook removes a tenant's webhook; its pending deliveries are dead-lettered when next attempted
+func (s *WebhookService) DeleteWebhook(ctx context.Context, tenantID, id uuid.UUID) error {
+	deleted, err := s.repo.Delete(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// GetDeliveries lists a tenant's deliveries, optionally filtered by status
+func (s *WebhookService) GetDeliveries(ctx context.Context, tenantID uuid.UUID, status string, limit, offset int) ([]models.WebhookDelivery, error) {
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		return nil, fmt.Errorf("%w: unknown delivery status %q", ErrInvalidWebhook, status)
+	}
+	return s.repo.GetDeliveries(ctx, tenantID, status, limit, offset)
+}
+
+// Redeliver requeues a dead-lettered delivery
+func (s *WebhookService) Redeliver(ctx context.Context, tenantID, id uuid.UUID) error {
+	requeued, err := s.repo.Redeliver(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if !requeued {
+		return fmt.Errorf("%w: no dead-lettered delivery %s", ErrWebhookNotFound, id)
+	}
+	s.signal()
+	return nil
+}
+
+// Publish queues an event for the tenant's subscribed webhooks. Events are
+// dropped with a warning when the queue is full rather than slowing the caller;
+// event types webhooks cannot subscribe to are ignored.
+func (s *WebhookService) Publish(tenantID uuid.UUID, eventType string, data any) {
+	if !validEventType(eventType) {
+		return
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Error().Err(err).Str("event_type", eventType).Msg("Failed to encode webhook event")
+		return
+	}
+
+	event := models.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		TenantID:  tenantID,
+		CreatedAt: time.Now().UTC(),
+		Data:      payload,
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		log.Warn().
+			Str("tenant_id", tenantID.String()).
+			Str("event_type", eventType).
+			Msg("Webhook event queue full, dropping event")
+	}
+}
+
+// Start launches the event fan-out and delivery workers
+func (s *WebhookService) Start() {
+	s.wg.Add(2)
+	go s.fanOut()
+	go s.dispatch()
+}
+
+// Stop stops the workers; queued events not yet stored are lost
+func (s *WebhookService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// signal wakes the dispatcher without waiting for the next poll
+func (s *WebhookService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// fanOut stores a delivery for every webhook subscribed to each published event
+func (s *WebhookService) fanOut() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case event := <-s.events:
+			if err := s.enqueue(s.ctx, event); err != nil {
+				log.Error().
+					Err(err).
+					Str("tenant_id", event.TenantID.String()).
+					Str("event_type", event.Type).
+					Msg("Failed to queue webhook deliveries")
+				continue
+			}
+			s.signal()
+		}
+	}
+}
+
+func (s *WebhookService) enqueue(ctx context.Context, event models.WebhookEvent) error {
+	webhooks, err := s.repo.GetActiveByTenantID(ctx, event.TenantID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			TenantID:      event.TenantID,
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: event.CreatedAt,
+		})
+	}
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+// dispatch attempts due deliveries on every poll and whenever new ones are queued
+func (s *WebhookService) dispatch() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	// A claimed delivery is hidden from other dispatchers for longer than an attempt can take
+	lease := 2*s.config.Timeout + time.Minute
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+
+		for s.ctx.Err() == nil {
+			deliveries, err := s.repo.ClaimDueDeliveries(s.ctx, time.Now(), lease, webhookClaimBatch)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to claim webhook deliveries")
+				break
+			}
+			for i := range deliveries {
+				s.attempt(&deliveries[i])
+			}
+			if len(deliveries) < webhookClaimBatch {
+				break
+			}
+		}
+	}
+}
+
+// attempt makes one delivery attempt and records its outcome
+func (s *WebhookService) attempt(delivery *models.WebhookDelivery) {
+	logger := log.With().
+		Str("tenant_id", delivery.TenantID.String()).
+		Str("webhook_id", delivery.WebhookID.String()).
+		Str("delivery_id", delivery.ID.String()).
+		Str("event_type", delivery.EventType).
+		Logger()
+
+	webhook, err := s.repo.GetByID(s.ctx, delivery.TenantID, delivery.WebhookID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !webhook.IsActive):
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = "webhook was removed or deactivated"
+	case err != nil:
+		logger.Error().Err(err).Msg("Failed to load webhook for delivery")
+		return
+	default:
+		delivery.Attempts++
+		statusCode, err := s.post(webhook, delivery)
+		delivery.LastStatusCode = statusCode
+
+		now := time.Now()
+		switch {
+		case err == nil:
+			delivery.Status = models.DeliveryDelivered
+			delivery.DeliveredAt = &now
+			delivery.LastError = ""
+			logger.Debug().Int("attempts", delivery.Attempts).Msg("Webhook delivered")
+		case delivery.Attempts >= s.config.MaxAttempts:
+			delivery.Status = models.DeliveryDead
+			delivery.LastError = err.Error()
+			logger.Warn().Err(err).Int("attempts", delivery.Attempts).Msg("Webhook delivery failed permanently, dead-lettered")
+		default:
+			delivery.LastError = err.Error()
+			delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+			logger.Debug().Err(err).Int("attempts", delivery.Attempts).Time("next_attempt_at", delivery.NextAttemptAt).Msg("Webhook delivery failed, will retry")
+		}
+	}
+
+	if err := s.repo.UpdateDelivery(s.ctx, delivery); err != nil {
+		logger.Error().Err(err).Msg("Failed to record webhook delivery attempt")
+	}
+}
+
+// post sends a delivery's payload, signed with the webhook secret. Any 2xx
+// response acknowledges the event.
+func (s *WebhookService) post(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.config.Timeout)
+	defer cancel()
+
+	secret, err := s.secrets.OpenWebhookSecret(ctx, webhook)
+	if err != nil {
+		return 0, err
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ris-dicom-connector-webhooks")
+	req.Header.Set("X-Webhook-ID", delivery.EventID.String())
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Signature", signWebhookPayload(secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhookPayload returns the signature header value t=<unix seconds>,v1=<hex
+// HMAC-SHA256 of "<unix seconds>.<body>">. Including the timestamp lets
+// receivers reject replayed deliveries.
+func signWebhookPayload(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the delay before the retry following the given attempt
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	return backoff
+}
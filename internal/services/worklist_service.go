@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/otcheredev/ris-dicom-connector/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// UPS attribute tags used by the worklist broker
+const (
+	tagSOPClassUID                    = "00080016"
+	tagSOPInstanceUID                 = "00080018"
+	tagAccessionNumber                = "00080050"
+	tagTransactionUID                 = "00081195"
+	tagPatientName                    = "00100010"
+	tagPatientID                      = "00100020"
+	tagStudyInstanceUID               = "0020000D"
+	tagScheduledProcedureStepStartDT  = "00404005"
+	tagProcedureStepState             = "00741000"
+	tagScheduledProcedureStepPriority = "00741200"
+	tagWorklistLabel                  = "00741202"
+	tagProcedureStepLabel             = "00741204"
+	upsPushSOPClassUID                = "1.2.840.10008.5.1.4.34.6.1"
+	defaultWorkitemPriority           = "MEDIUM"
+)
+
+// Worklist errors
+var (
+	ErrWorkitemNotFound       = errors.New("workitem not found")
+	ErrWorkitemExists         = errors.New("workitem already exists")
+	ErrInvalidWorkitem        = errors.New("invalid workitem")
+	ErrInvalidStateTransition = errors.New("invalid procedure step state transition")
+	ErrTransactionUIDMismatch = errors.New("transaction UID missing or incorrect")
+	ErrWorkitemStateConflict  = errors.New("workitem state changed concurrently")
+)
+
+// WorklistService implements the UPS-RS worklist broker
+type WorklistService struct {
+	repo *repository.WorklistRepository
+}
+
+// NewWorklistService creates a new worklist service
+func NewWorklistService(repo *repository.WorklistRepository) *WorklistService {
+	return &WorklistService{
+		repo: repo,
+	}
+}
+
+// CreateWorkitem stores a new workitem in the SCHEDULED state. The UID is taken
+// from the request, then the dataset, and generated when neither provides one.
+func (s *WorklistService) CreateWorkitem(ctx context.Context, tenantID uuid.UUID, uid string, dataset models.DICOMJSONDataset) (*models.Workitem, error) {
+	if dataset == nil {
+		return nil, fmt.Errorf("%w: empty dataset", ErrInvalidWorkitem)
+	}
+
+	if uid == "" {
+		uid = dataset.String(tagSOPInstanceUID)
+	}
+	if uid == "" {
+		uid = newUID()
+	}
+
+	if state := dataset.String(tagProcedureStepState); state != "" && models.ProcedureStepState(state) != models.ProcedureStepScheduled {
+		return nil, fmt.Errorf("%w: new workitems must be SCHEDULED, got %s", ErrInvalidWorkitem, state)
+	}
+	if dataset.String(tagTransactionUID) != "" {
+		return nil, fmt.Errorf("%w: transaction UID must not be set on creation", ErrInvalidWorkitem)
+	}
+
+	if _, err := s.repo.GetByUID(ctx, tenantID, uid); err == nil {
+		return nil, ErrWorkitemExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	dataset.SetString(tagSOPClassUID, "UI", upsPushSOPClassUID)
+	dataset.SetString(tagSOPInstanceUID, "UI", uid)
+	dataset.SetString(tagProcedureStepState, "CS", string(models.ProcedureStepScheduled))
+	if dataset.String(tagScheduledProcedureStepPriority) == "" {
+		dataset.SetString(tagScheduledProcedureStepPriority, "CS", defaultWorkitemPriority)
+	}
+
+	workitem := &models.Workitem{
+		TenantID:       tenantID,
+		SOPInstanceUID: uid,
+	}
+	if err := applyDataset(workitem, dataset); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, workitem); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("workitem_uid", uid).
+		Msg("Workitem created")
+
+	return workitem, nil
+}
+
+// GetWorkitem retrieves a workitem by UID
+func (s *WorklistService) GetWorkitem(ctx context.Context, tenantID uuid.UUID, uid string) (*models.Workitem, error) {
+	workitem, err := s.repo.GetByUID(ctx, tenantID, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorkitemNotFound
+		}
+		return nil, err
+	}
+	return workitem, nil
+}
+
+// SearchWorkitems searches the tenant's worklist
+func (s *WorklistService) SearchWorkitems(ctx context.Context, tenantID uuid.UUID, query models.WorkitemQuery) ([]models.Workitem, error) {
+	if query.Limit <= 0 || query.Limit > DefaultMaxResults {
+		query.Limit = DefaultMaxResults
+	}
+	return s.repo.Search(ctx, tenantID, query)
+}
+
+// UpdateWorkitem merges attributes into a workitem. Workitems that have been
+// claimed can only be updated by the performer holding the transaction UID.
+func (s *WorklistService) UpdateWorkitem(ctx context.Context, tenantID uuid.UUID, uid, transactionUID string, updates models.DICOMJSONDataset) (*models.Workitem, error) {
+	workitem, err := s.GetWorkitem(ctx, tenantID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if workitem.State.IsFinal() {
+		return nil, fmt.Errorf("%w: workitem is %s", ErrInvalidStateTransition, workitem.State)
+	}
+	if workitem.State == models.ProcedureStepInProgress && transactionUID != workitem.TransactionUID {
+		return nil, ErrTransactionUIDMismatch
+	}
+
+	// State, identity and locking attributes are managed by the state change transaction
+	for _, tag := range []string{tagProcedureStepState, tagTransactionUID, tagSOPClassUID, tagSOPInstanceUID} {
+		if _, ok := updates[tag]; ok {
+			return nil, fmt.Errorf("%w: attribute %s cannot be updated", ErrInvalidWorkitem, tag)
+		}
+	}
+
+	dataset, err := workitemDataset(workitem)
+	if err != nil {
+		return nil, err
+	}
+	for tag, attr := range updates {
+		dataset[tag] = attr
+	}
+
+	if err := applyDataset(workitem, dataset); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.UpdateIfState(ctx, workitem, workitem.State)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrWorkitemStateConflict
+	}
+
+	return workitem, nil
+}
+
+// ChangeState moves a workitem through the UPS state machine. Claiming a
+// SCHEDULED workitem (IN PROGRESS) records the performer's transaction UID,
+// which must then accompany the transition to COMPLETED or CANCELED.
+func (s *WorklistService) ChangeState(ctx context.Context, tenantID uuid.UUID, uid string, state models.ProcedureStepState, transactionUID string) (*models.Workitem, error) {
+	if transactionUID == "" {
+		return nil, ErrTransactionUIDMismatch
+	}
+
+	workitem, err := s.GetWorkitem(ctx, tenantID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	current := workitem.State
+	switch {
+	case current == models.ProcedureStepScheduled && state == models.ProcedureStepInProgress:
+		workitem.TransactionUID = transactionUID
+	case current == models.ProcedureStepInProgress && (state == models.ProcedureStepCompleted || state == models.ProcedureStepCanceled):
+		if transactionUID != workitem.TransactionUID {
+			return nil, ErrTransactionUIDMismatch
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidStateTransition, current, state)
+	}
+
+	dataset, err := workitemDataset(workitem)
+	if err != nil {
+		return nil, err
+	}
+	dataset.SetString(tagProcedureStepState, "CS", string(state))
+
+	if err := applyDataset(workitem, dataset); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.UpdateIfState(ctx, workitem, current)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrWorkitemStateConflict
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("workitem_uid", uid).
+		Str("from", string(current)).
+		Str("to", string(state)).
+		Msg("Workitem state changed")
+
+	return workitem, nil
+}
+
+// WorkitemDataset returns the DICOM JSON dataset of a workitem
+func (s *WorklistService) WorkitemDataset(workitem *models.Workitem) (models.DICOMJSONDataset, error) {
+	return workitemDataset(workitem)
+}
+
+func workitemDataset(workitem *models.Workitem) (models.DICOMJSONDataset, error) {
+	dataset := models.DICOMJSONDataset{}
+	if workitem.Dataset == "" {
+		return dataset, nil
+	}
+	if err := json.Unmarshal([]byte(workitem.Dataset), &dataset); err != nil {
+		return nil, fmt.Errorf("failed to decode workitem dataset: %w", err)
+	}
+	return dataset, nil
+}
+
+// applyDataset stores the dataset on the workitem and refreshes its indexed columns
+func applyDataset(workitem *models.Workitem, dataset models.DICOMJSONDataset) error {
+	encoded, err := json.Marshal(dataset)
+	if err != nil {
+		return fmt.Errorf("failed to encode workitem dataset: %w", err)
+	}
+
+	workitem.Dataset = string(encoded)
+	workitem.State = models.ProcedureStepState(dataset.String(tagProcedureStepState))
+	workitem.PatientID = dataset.String(tagPatientID)
+	workitem.PatientName = dataset.String(tagPatientName)
+	workitem.AccessionNumber = dataset.String(tagAccessionNumber)
+	workitem.StudyInstanceUID = dataset.String(tagStudyInstanceUID)
+	workitem.ProcedureStepLabel = dataset.String(tagProcedureStepLabel)
+	workitem.WorklistLabel = dataset.String(tagWorklistLabel)
+	workitem.Priority = dataset.String(tagScheduledProcedureStepPriority)
+	workitem.ScheduledStart = dataset.String(tagScheduledProcedureStepStartDT)
+
+	return nil
+}
+
+// newUID generates a DICOM UID under the UUID-derived 2.25 root
+func newUID() string {
+	id := uuid.New()
+	return "2.25." + new(big.Int).SetBytes(id[:]).String()
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/otcheredev/ris-dicom-connector/internal/adapters"
+	"github.com/otcheredev/ris-dicom-connector/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidDocumentSetRequest is returned when a RAD-69 retrieve request is incomplete
+var ErrInvalidDocumentSetRequest = errors.New("invalid imaging document set request")
+
+// imagingDocumentSource returns a RAD-69 retriever for the tenant's XDS-I.b Imaging
+// Document Source, which is usually configured next to the primary PACS rather than
+// as it. The returned release function must be called when the retrieve is done.
+func (s *PACSService) imagingDocumentSource(ctx context.Context, tenantID uuid.UUID) (adapters.ImagingDocumentRetriever, func(), error) {
+	configs, err := s.pacsRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, config := range configs {
+		if config.Type != models.PACSTypeXDSI {
+			continue
+		}
+
+		adapter, err := s.adapterFactory.GetAdapter(config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get adapter: %w", err)
+		}
+		if retriever, ok := adapters.Unwrap(adapter).(adapters.ImagingDocumentRetriever); ok {
+			return retriever, adapters.Hold(adapter), nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("%w: no XDS-I imaging document source is configured", ErrNotSupported)
+}
+
+// RetrieveImagingDocumentSet pulls instances from the tenant's XDS-I.b Imaging
+// Document Source with RAD-69, handing each to visit as it arrives
+func (s *PACSService) RetrieveImagingDocumentSet(ctx context.Context, tenantID uuid.UUID, req models.ImagingDocumentSetRequest, visit InstanceVisitor, ipAddress, userAgent string) error {
+	if req.StudyUID == "" {
+		return fmt.Errorf("%w: study_uid is required", ErrInvalidDocumentSetRequest)
+	}
+	documents := 0
+	for _, series := range req.Series {
+		if series.SeriesUID == "" {
+			return fmt.Errorf("%w: series_uid is required", ErrInvalidDocumentSetRequest)
+		}
+		documents += len(series.InstanceUIDs)
+	}
+	if documents == 0 {
+		return fmt.Errorf("%w: at least one instance UID is required", ErrInvalidDocumentSetRequest)
+	}
+
+	retriever, release, err := s.imagingDocumentSource(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	start := time.Now()
+	retrieved := 0
+	err = retriever.RetrieveImagingDocumentSet(ctx, req, func(doc models.ImagingDocument, data io.Reader) error {
+		retrieved++
+		return visit(models.Instance{SOPInstanceUID: doc.InstanceUID}, io.NopCloser(data), doc.MimeType)
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to retrieve imaging document set: %w", err)
+	}
+	s.recordArchiveAudit(ctx, tenantID, "xds.retrieve", "study", req.StudyUID, ipAddress, userAgent, start, err)
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("study_uid", req.StudyUID).
+		Int("requested", documents).
+		Int("retrieved", retrieved).
+		Dur("duration", time.Since(start)).
+		Msg("Imaging document set retrieved")
+
+	return nil
+}
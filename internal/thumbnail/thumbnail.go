@@ -0,0 +1,369 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // PACS previews may be PNG
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/dictionary/tags"
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/dictionary/transfersyntax"
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/media"
+)
+
+// Thumbnail defaults
+const (
+	DefaultSize    = 128
+	MaxSize        = 1024
+	DefaultQuality = 75
+)
+
+// Options controls how a thumbnail is rendered
+type Options struct {
+	Size    int // longest edge in pixels
+	Quality int // JPEG quality (1-100)
+}
+
+// Generate decodes a DICOM Part 10 object and renders a JPEG thumbnail of its
+// middle frame, applying the stored VOI window (or the pixel range) first
+func Generate(data []byte, opts Options) ([]byte, error) {
+	opts = normalizeOptions(opts)
+
+	obj, err := media.NewDCMObjFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DICOM object: %w", err)
+	}
+
+	// Decompress encapsulated pixel data to native little endian; the codecs
+	// return color pixel data as RGB
+	decoded := false
+	if ts := obj.GetTransferSyntax(); ts != nil && isEncapsulated(ts.UID) {
+		if err := obj.ChangeTransferSynx(transfersyntax.ExplicitVRLittleEndian); err != nil {
+			return nil, fmt.Errorf("failed to decode pixel data (%s): %w", ts.Name, err)
+		}
+		decoded = true
+	}
+
+	frame, err := readMiddleFrame(obj, decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	img := frame.render()
+	scaled := downscale(img, opts.Size)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: opts.Quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Resize scales an already rendered JPEG or PNG image (e.g. a PACS-side preview)
+// to the thumbnail size and re-encodes it as JPEG
+func Resize(data []byte, opts Options) ([]byte, error) {
+	opts = normalizeOptions(opts)
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode preview image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, downscale(img, opts.Size), &jpeg.Options{Quality: opts.Quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func normalizeOptions(opts Options) Options {
+	if opts.Size <= 0 {
+		opts.Size = DefaultSize
+	}
+	if opts.Size > MaxSize {
+		opts.Size = MaxSize
+	}
+	if opts.Quality <= 0 || opts.Quality > 100 {
+		opts.Quality = DefaultQuality
+	}
+	return opts
+}
+
+func isEncapsulated(uid string) bool {
+	switch uid {
+	case transfersyntax.ImplicitVRLittleEndian.UID,
+		transfersyntax.ExplicitVRLittleEndian.UID,
+		transfersyntax.ExplicitVRBigEndian.UID,
+		transfersyntax.DeflatedExplicitVRLittleEndian.UID:
+		return false
+	}
+	return true
+}
+
+// pixelFrame holds a single native frame and the attributes needed to render it
+type pixelFrame struct {
+	rows, cols    int
+	samples       int
+	bitsAllocated int
+	bitsStored    int
+	signed        bool
+	bigEndian     bool
+	monochrome1   bool
+	ybr           bool // color samples are Y, Cb and Cr rather than R, G and B
+	slope         float64
+	intercept     float64
+	windowCenter  float64
+	windowWidth   float64
+	hasWindow     bool
+	data          []byte
+}
+
+func readMiddleFrame(obj media.DcmObj, decoded bool) (*pixelFrame, error) {
+	photometric := strings.TrimSpace(obj.GetString(tags.PhotometricInterpretation))
+	f := &pixelFrame{
+		rows:          int(obj.GetUShort(tags.Rows)),
+		cols:          int(obj.GetUShort(tags.Columns)),
+		samples:       int(obj.GetUShort(tags.SamplesPerPixel)),
+		bitsAllocated: int(obj.GetUShort(tags.BitsAllocated)),
+		bitsStored:    int(obj.GetUShort(tags.BitsStored)),
+		signed:        obj.GetUShort(tags.PixelRepresentation) == 1,
+		bigEndian:     obj.IsBigEndian(),
+		monochrome1:   photometric == "MONOCHROME1",
+		slope:         parseDecimal(obj.GetString(tags.RescaleSlope), 1),
+		intercept:     parseDecimal(obj.GetString(tags.RescaleIntercept), 0),
+	}
+
+	if f.rows == 0 || f.cols == 0 {
+		return nil, fmt.Errorf("instance has no image pixel module")
+	}
+	if f.samples == 0 {
+		f.samples = 1
+	}
+	if f.bitsAllocated != 8 && f.bitsAllocated != 16 {
+		return nil, fmt.Errorf("unsupported bits allocated: %d", f.bitsAllocated)
+	}
+	if f.bitsStored == 0 {
+		f.bitsStored = f.bitsAllocated
+	}
+
+	// Native YBR_FULL_422 stores one Cb and Cr pair for every two pixels of a row
+	subsampled := false
+	if f.samples == 3 && !decoded {
+		switch photometric {
+		case "YBR_FULL":
+			f.ybr = true
+		case "YBR_FULL_422":
+			if f.bitsAllocated != 8 || f.cols%2 != 0 {
+				return nil, fmt.Errorf("unsupported YBR_FULL_422 pixel data")
+			}
+			f.ybr = true
+			subsampled = true
+		}
+	}
+
+	if wc, ww := obj.GetString(tags.WindowCenter), obj.GetString(tags.WindowWidth); wc != "" && ww != "" {
+		f.windowCenter = parseDecimal(wc, 0)
+		f.windowWidth = parseDecimal(ww, 0)
+		f.hasWindow = f.windowWidth > 0
+	}
+
+	pixelTag := obj.GetTag(tags.PixelData)
+	if pixelTag == nil || len(pixelTag.Data) == 0 {
+		return nil, fmt.Errorf("instance has no pixel data")
+	}
+
+	frames := 1
+	if n, err := strconv.Atoi(strings.TrimSpace(obj.GetString(tags.NumberOfFrames))); err == nil && n > 0 {
+		frames = n
+	}
+
+	frameSize := f.rows * f.cols * f.samples * f.bitsAllocated / 8
+	if subsampled {
+		frameSize = f.rows * f.cols * 2
+	}
+	index := frames / 2
+	start := index * frameSize
+	if start+frameSize > len(pixelTag.Data) {
+		// Fall back to the first frame for truncated or mislabelled objects
+		start = 0
+		if frameSize > len(pixelTag.Data) {
+			return nil, fmt.Errorf("pixel data is shorter than one frame")
+		}
+	}
+	f.data = pixelTag.Data[start : start+frameSize]
+
+	switch {
+	case subsampled:
+		f.data = upsample422(f.data, f.rows*f.cols)
+	case f.samples == 3 && obj.GetUShort(tags.PlanarConfiguration) == 1:
+		// Planar configuration 1 stores RRR..GGG..BBB; convert to interleaved
+		f.data = interleave(f.data, f.rows*f.cols, f.bitsAllocated/8)
+	}
+
+	return f, nil
+}
+
+// render converts the frame to an 8-bit image
+func (f *pixelFrame) render() image.Image {
+	if f.samples == 3 {
+		img := image.NewRGBA(image.Rect(0, 0, f.cols, f.rows))
+		step := f.bitsAllocated / 8
+		// The most significant byte of each sample
+		msb := step - 1
+		if f.bigEndian {
+			msb = 0
+		}
+		for i := 0; i < f.rows*f.cols; i++ {
+			off := i*3*step + msb
+			r, g, b := f.data[off], f.data[off+step], f.data[off+2*step]
+			if f.ybr {
+				r, g, b = color.YCbCrToRGB(r, g, b)
+			}
+			img.Pix[i*4] = r
+			img.Pix[i*4+1] = g
+			img.Pix[i*4+2] = b
+			img.Pix[i*4+3] = 0xFF
+		}
+		return img
+	}
+
+	values := make([]float64, f.rows*f.cols)
+	minVal, maxVal := math.MaxFloat64, -math.MaxFloat64
+	for i := range values {
+		v := f.slope*f.sample(i) + f.intercept
+		values[i] = v
+		minVal = math.Min(minVal, v)
+		maxVal = math.Max(maxVal, v)
+	}
+
+	low, high := minVal, maxVal
+	if f.hasWindow {
+		low = f.windowCenter - f.windowWidth/2
+		high = f.windowCenter + f.windowWidth/2
+	}
+	span := high - low
+	if span <= 0 {
+		span = 1
+	}
+
+	img := image.NewGray(image.Rect(0, 0, f.cols, f.rows))
+	for i, v := range values {
+		scaled := (v - low) / span * 255
+		scaled = math.Max(0, math.Min(255, scaled))
+		if f.monochrome1 {
+			scaled = 255 - scaled
+		}
+		img.Pix[i] = uint8(scaled)
+	}
+	return img
+}
+
+// sample returns the stored value of the i-th monochrome pixel
+func (f *pixelFrame) sample(i int) float64 {
+	if f.bitsAllocated == 8 {
+		if f.signed {
+			return float64(int8(f.data[i]))
+		}
+		return float64(f.data[i])
+	}
+
+	var raw uint16
+	if f.bigEndian {
+		raw = binary.BigEndian.Uint16(f.data[i*2:])
+	} else {
+		raw = binary.LittleEndian.Uint16(f.data[i*2:])
+	}
+
+	// Mask to bits stored and sign-extend when needed
+	raw &= uint16((1 << uint(f.bitsStored)) - 1)
+	if f.signed && raw&(1<<uint(f.bitsStored-1)) != 0 {
+		return float64(int32(raw) - (1 << uint(f.bitsStored)))
+	}
+	return float64(raw)
+}
+
+// downscale resizes an image so its longest edge is at most size, using box filtering
+func downscale(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	dw, dh := size, h*size/w
+	if h > w {
+		dw, dh = w*size/h, size
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, (y+1)*h/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, (x+1)*w/dw
+			var r, g, b, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, _ := src.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b = r+cr>>8, g+cg>>8, b+cb>>8
+					n++
+				}
+			}
+			if n == 0 {
+				n = 1
+			}
+			dst.Set(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), 0xFF})
+		}
+	}
+	return dst
+}
+
+// interleave converts planes of samples of sampleSize bytes each to pixels of
+// three interleaved samples
+func interleave(planar []byte, pixels, sampleSize int) []byte {
+	out := make([]byte, len(planar))
+	plane := pixels * sampleSize
+	for i := 0; i < pixels; i++ {
+		src, dst := i*sampleSize, 3*i*sampleSize
+		copy(out[dst:dst+sampleSize], planar[src:])
+		copy(out[dst+sampleSize:dst+2*sampleSize], planar[plane+src:])
+		copy(out[dst+2*sampleSize:dst+3*sampleSize], planar[2*plane+src:])
+	}
+	return out
+}
+
+// upsample422 expands 8-bit YBR_FULL_422 pixel data, stored as Y1 Y2 Cb Cr for
+// every pair of pixels, to three samples per pixel
+func upsample422(data []byte, pixels int) []byte {
+	out := make([]byte, pixels*3)
+	for i := 0; i+1 < pixels; i += 2 {
+		y1, y2, cb, cr := data[2*i], data[2*i+1], data[2*i+2], data[2*i+3]
+		copy(out[3*i:], []byte{y1, cb, cr, y2, cb, cr})
+	}
+	return out
+}
+
+// parseDecimal parses the first value of a (possibly multi-valued) DS attribute
+func parseDecimal(s string, fallback float64) float64 {
+	if idx := strings.Index(s, "\\"); idx >= 0 {
+		s = s[:idx]
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,58 @@
+// Package transcode converts retrieved DICOM instances between transfer syntaxes
+// when the archive cannot provide the one a client asked for.
+package transcode
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/dictionary/transfersyntax"
+	"github.com/OtchereDev/ris-common-sdk/pkg/io-dicom/media"
+)
+
+// MaxInstanceSize bounds the size of an instance that is decoded in memory for transcoding
+const MaxInstanceSize = 512 << 20
+
+// ErrUnsupported is returned when a conversion between two transfer syntaxes is not available
+var ErrUnsupported = errors.New("unsupported transfer syntax conversion")
+
+// Supported reports whether instances can be transcoded from one transfer syntax
+// to another. Both must be native or a codec the SDK can decode and encode
+// (JPEG baseline/extended/lossless and JPEG 2000).
+func Supported(from, to string) bool {
+	if from == to {
+		return true
+	}
+	return transfersyntax.SupportedTransferSyntax(from) && transfersyntax.SupportedTransferSyntax(to)
+}
+
+// Transcode decodes a DICOM Part 10 object and re-encodes its pixel data in the
+// target transfer syntax, returning the new Part 10 object
+func Transcode(data []byte, target string) ([]byte, error) {
+	ts := transfersyntax.GetTransferSyntaxFromUID(target)
+	if ts == nil || !transfersyntax.SupportedTransferSyntax(target) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, target)
+	}
+
+	obj, err := media.NewDCMObjFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DICOM object: %w", err)
+	}
+
+	source := obj.GetTransferSyntax()
+	if source == nil {
+		return nil, fmt.Errorf("%w: unknown source transfer syntax", ErrUnsupported)
+	}
+	if source.UID == ts.UID {
+		return data, nil
+	}
+	if !transfersyntax.SupportedTransferSyntax(source.UID) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, source.Name)
+	}
+
+	if err := obj.ChangeTransferSynx(ts); err != nil {
+		return nil, fmt.Errorf("failed to transcode %s to %s: %w", source.Name, ts.Name, err)
+	}
+
+	return obj.WriteToBytes(), nil
+}
@@ -0,0 +1,2321 @@
+// Connector API for internal services that prefer gRPC over the DICOMweb and
+// management REST endpoints. It exposes the same query, retrieve and
+// management operations with the same tenant isolation.
+//
+// Every call must carry the tenant in the x-tenant-id metadata. DeleteStudy
+// additionally requires "authorization: Bearer <admin token>". Errors use gRPC
+// status codes: NOT_FOUND, INVALID_ARGUMENT, UNIMPLEMENTED (operation not
+// supported by the tenant's PACS), FAILED_PRECONDITION (no PACS configured),
+// UNAVAILABLE, RESOURCE_EXHAUSTED and DEADLINE_EXCEEDED.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: connectorapi/v1/connector.proto
+
+package connectorapiv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ObjectRef addresses a study, series or instance; empty series and instance
+// UIDs select the study or series level
+type ObjectRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StudyUid      string                 `protobuf:"bytes,1,opt,name=study_uid,json=studyUid,proto3" json:"study_uid,omitempty"`
+	SeriesUid     string                 `protobuf:"bytes,2,opt,name=series_uid,json=seriesUid,proto3" json:"series_uid,omitempty"`
+	InstanceUid   string                 `protobuf:"bytes,3,opt,name=instance_uid,json=instanceUid,proto3" json:"instance_uid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ObjectRef) Reset() {
+	*x = ObjectRef{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ObjectRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ObjectRef) ProtoMessage() {}
+
+func (x *ObjectRef) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ObjectRef.ProtoReflect.Descriptor instead.
+func (*ObjectRef) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ObjectRef) GetStudyUid() string {
+	if x != nil {
+		return x.StudyUid
+	}
+	return ""
+}
+
+func (x *ObjectRef) GetSeriesUid() string {
+	if x != nil {
+		return x.SeriesUid
+	}
+	return ""
+}
+
+func (x *ObjectRef) GetInstanceUid() string {
+	if x != nil {
+		return x.InstanceUid
+	}
+	return ""
+}
+
+type SearchStudiesRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	PatientId        string                 `protobuf:"bytes,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	PatientName      string                 `protobuf:"bytes,2,opt,name=patient_name,json=patientName,proto3" json:"patient_name,omitempty"`
+	StudyDate        string                 `protobuf:"bytes,3,opt,name=study_date,json=studyDate,proto3" json:"study_date,omitempty"` // DICOM date or range, e.g. 20240101-20240131
+	StudyTime        string                 `protobuf:"bytes,4,opt,name=study_time,json=studyTime,proto3" json:"study_time,omitempty"`
+	AccessionNumber  string                 `protobuf:"bytes,5,opt,name=accession_number,json=accessionNumber,proto3" json:"accession_number,omitempty"`
+	Modalities       []string               `protobuf:"bytes,6,rep,name=modalities,proto3" json:"modalities,omitempty"` // matches studies containing any of the modalities
+	StudyDescription string                 `protobuf:"bytes,7,opt,name=study_description,json=studyDescription,proto3" json:"study_description,omitempty"`
+	Filters          map[string]string      `protobuf:"bytes,8,rep,name=filters,proto3" json:"filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // additional matching keys by tag (GGGGEEEE)
+	Limit            int32                  `protobuf:"varint,9,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset           int32                  `protobuf:"varint,10,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SearchStudiesRequest) Reset() {
+	*x = SearchStudiesRequest{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchStudiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchStudiesRequest) ProtoMessage() {}
+
+func (x *SearchStudiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchStudiesRequest.ProtoReflect.Descriptor instead.
+func (*SearchStudiesRequest) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SearchStudiesRequest) GetPatientId() string {
+	if x != nil {
+		return x.PatientId
+	}
+	return ""
+}
+
+func (x *SearchStudiesRequest) GetPatientName() string {
+	if x != nil {
+		return x.PatientName
+	}
+	return ""
+}
+
+func (x *SearchStudiesRequest) GetStudyDate() string {
+	if x != nil {
+		return x.StudyDate
+	}
+	return ""
+}
+
+func (x *SearchStudiesRequest) GetStudyTime() string {
+	if x != nil {
+		return x.StudyTime
+	}
+	return ""
+}
+
+func (x *SearchStudiesRequest) GetAccessionNumber() string {
+	if x != nil {
+		return x.AccessionNumber
+	}
+	return ""
+}
+
+func (x *SearchStudiesRequest) GetModalities() []string {
+	if x != nil {
+		return x.Modalities
+	}
+	return nil
+}
+
+func (x *SearchStudiesRequest) GetStudyDescription() string {
+	if x != nil {
+		return x.StudyDescription
+	}
+	return ""
+}
+
+func (x *SearchStudiesRequest) GetFilters() map[string]string {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *SearchStudiesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchStudiesRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type Study struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	StudyInstanceUid   string                 `protobuf:"bytes,1,opt,name=study_instance_uid,json=studyInstanceUid,proto3" json:"study_instance_uid,omitempty"`
+	PatientId          string                 `protobuf:"bytes,2,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	PatientName        string                 `protobuf:"bytes,3,opt,name=patient_name,json=patientName,proto3" json:"patient_name,omitempty"`
+	PatientBirthDate   string                 `protobuf:"bytes,4,opt,name=patient_birth_date,json=patientBirthDate,proto3" json:"patient_birth_date,omitempty"`
+	PatientSex         string                 `protobuf:"bytes,5,opt,name=patient_sex,json=patientSex,proto3" json:"patient_sex,omitempty"`
+	StudyDate          string                 `protobuf:"bytes,6,opt,name=study_date,json=studyDate,proto3" json:"study_date,omitempty"`
+	StudyTime          string                 `protobuf:"bytes,7,opt,name=study_time,json=studyTime,proto3" json:"study_time,omitempty"`
+	StudyDescription   string                 `protobuf:"bytes,8,opt,name=study_description,json=studyDescription,proto3" json:"study_description,omitempty"`
+	AccessionNumber    string                 `protobuf:"bytes,9,opt,name=accession_number,json=accessionNumber,proto3" json:"accession_number,omitempty"`
+	ReferringPhysician string                 `protobuf:"bytes,10,opt,name=referring_physician,json=referringPhysician,proto3" json:"referring_physician,omitempty"`
+	NumberOfSeries     int32                  `protobuf:"varint,11,opt,name=number_of_series,json=numberOfSeries,proto3" json:"number_of_series,omitempty"`
+	NumberOfInstances  int32                  `protobuf:"varint,12,opt,name=number_of_instances,json=numberOfInstances,proto3" json:"number_of_instances,omitempty"`
+	ModalitiesInStudy  []string               `protobuf:"bytes,13,rep,name=modalities_in_study,json=modalitiesInStudy,proto3" json:"modalities_in_study,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Study) Reset() {
+	*x = Study{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Study) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Study) ProtoMessage() {}
+
+func (x *Study) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Study.ProtoReflect.Descriptor instead.
+func (*Study) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Study) GetStudyInstanceUid() string {
+	if x != nil {
+		return x.StudyInstanceUid
+	}
+	return ""
+}
+
+func (x *Study) GetPatientId() string {
+	if x != nil {
+		return x.PatientId
+	}
+	return ""
+}
+
+func (x *Study) GetPatientName() string {
+	if x != nil {
+		return x.PatientName
+	}
+	return ""
+}
+
+func (x *Study) GetPatientBirthDate() string {
+	if x != nil {
+		return x.PatientBirthDate
+	}
+	return ""
+}
+
+func (x *Study) GetPatientSex() string {
+	if x != nil {
+		return x.PatientSex
+	}
+	return ""
+}
+
+func (x *Study) GetStudyDate() string {
+	if x != nil {
+		return x.StudyDate
+	}
+	return ""
+}
+
+func (x *Study) GetStudyTime() string {
+	if x != nil {
+		return x.StudyTime
+	}
+	return ""
+}
+
+func (x *Study) GetStudyDescription() string {
+	if x != nil {
+		return x.StudyDescription
+	}
+	return ""
+}
+
+func (x *Study) GetAccessionNumber() string {
+	if x != nil {
+		return x.AccessionNumber
+	}
+	return ""
+}
+
+func (x *Study) GetReferringPhysician() string {
+	if x != nil {
+		return x.ReferringPhysician
+	}
+	return ""
+}
+
+func (x *Study) GetNumberOfSeries() int32 {
+	if x != nil {
+		return x.NumberOfSeries
+	}
+	return 0
+}
+
+func (x *Study) GetNumberOfInstances() int32 {
+	if x != nil {
+		return x.NumberOfInstances
+	}
+	return 0
+}
+
+func (x *Study) GetModalitiesInStudy() []string {
+	if x != nil {
+		return x.ModalitiesInStudy
+	}
+	return nil
+}
+
+type SearchStudiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Studies       []*Study               `protobuf:"bytes,1,rep,name=studies,proto3" json:"studies,omitempty"`
+	Truncated     bool                   `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"` // results were capped at the tenant's maximum
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchStudiesResponse) Reset() {
+	*x = SearchStudiesResponse{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchStudiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchStudiesResponse) ProtoMessage() {}
+
+func (x *SearchStudiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchStudiesResponse.ProtoReflect.Descriptor instead.
+func (*SearchStudiesResponse) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SearchStudiesResponse) GetStudies() []*Study {
+	if x != nil {
+		return x.Studies
+	}
+	return nil
+}
+
+func (x *SearchStudiesResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+type SearchSeriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StudyUid      string                 `protobuf:"bytes,1,opt,name=study_uid,json=studyUid,proto3" json:"study_uid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchSeriesRequest) Reset() {
+	*x = SearchSeriesRequest{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchSeriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchSeriesRequest) ProtoMessage() {}
+
+func (x *SearchSeriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchSeriesRequest.ProtoReflect.Descriptor instead.
+func (*SearchSeriesRequest) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SearchSeriesRequest) GetStudyUid() string {
+	if x != nil {
+		return x.StudyUid
+	}
+	return ""
+}
+
+type Series struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	SeriesInstanceUid  string                 `protobuf:"bytes,1,opt,name=series_instance_uid,json=seriesInstanceUid,proto3" json:"series_instance_uid,omitempty"`
+	SeriesNumber       int32                  `protobuf:"varint,2,opt,name=series_number,json=seriesNumber,proto3" json:"series_number,omitempty"`
+	Modality           string                 `protobuf:"bytes,3,opt,name=modality,proto3" json:"modality,omitempty"`
+	SeriesDescription  string                 `protobuf:"bytes,4,opt,name=series_description,json=seriesDescription,proto3" json:"series_description,omitempty"`
+	SeriesDate         string                 `protobuf:"bytes,5,opt,name=series_date,json=seriesDate,proto3" json:"series_date,omitempty"`
+	SeriesTime         string                 `protobuf:"bytes,6,opt,name=series_time,json=seriesTime,proto3" json:"series_time,omitempty"`
+	BodyPartExamined   string                 `protobuf:"bytes,7,opt,name=body_part_examined,json=bodyPartExamined,proto3" json:"body_part_examined,omitempty"`
+	NumberOfInstances  int32                  `protobuf:"varint,8,opt,name=number_of_instances,json=numberOfInstances,proto3" json:"number_of_instances,omitempty"`
+	ProtocolName       string                 `protobuf:"bytes,9,opt,name=protocol_name,json=protocolName,proto3" json:"protocol_name,omitempty"`
+	PerformedProcedure string                 `protobuf:"bytes,10,opt,name=performed_procedure,json=performedProcedure,proto3" json:"performed_procedure,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Series) Reset() {
+	*x = Series{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Series) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Series) ProtoMessage() {}
+
+func (x *Series) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Series.ProtoReflect.Descriptor instead.
+func (*Series) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Series) GetSeriesInstanceUid() string {
+	if x != nil {
+		return x.SeriesInstanceUid
+	}
+	return ""
+}
+
+func (x *Series) GetSeriesNumber() int32 {
+	if x != nil {
+		return x.SeriesNumber
+	}
+	return 0
+}
+
+func (x *Series) GetModality() string {
+	if x != nil {
+		return x.Modality
+	}
+	return ""
+}
+
+func (x *Series) GetSeriesDescription() string {
+	if x != nil {
+		return x.SeriesDescription
+	}
+	return ""
+}
+
+func (x *Series) GetSeriesDate() string {
+	if x != nil {
+		return x.SeriesDate
+	}
+	return ""
+}
+
+func (x *Series) GetSeriesTime() string {
+	if x != nil {
+		return x.SeriesTime
+	}
+	return ""
+}
+
+func (x *Series) GetBodyPartExamined() string {
+	if x != nil {
+		return x.BodyPartExamined
+	}
+	return ""
+}
+
+func (x *Series) GetNumberOfInstances() int32 {
+	if x != nil {
+		return x.NumberOfInstances
+	}
+	return 0
+}
+
+func (x *Series) GetProtocolName() string {
+	if x != nil {
+		return x.ProtocolName
+	}
+	return ""
+}
+
+func (x *Series) GetPerformedProcedure() string {
+	if x != nil {
+		return x.PerformedProcedure
+	}
+	return ""
+}
+
+type SearchSeriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Series        []*Series              `protobuf:"bytes,1,rep,name=series,proto3" json:"series,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchSeriesResponse) Reset() {
+	*x = SearchSeriesResponse{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchSeriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchSeriesResponse) ProtoMessage() {}
+
+func (x *SearchSeriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchSeriesResponse.ProtoReflect.Descriptor instead.
+func (*SearchSeriesResponse) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SearchSeriesResponse) GetSeries() []*Series {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+type SearchInstancesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StudyUid      string                 `protobuf:"bytes,1,opt,name=study_uid,json=studyUid,proto3" json:"study_uid,omitempty"`
+	SeriesUid     string                 `protobuf:"bytes,2,opt,name=series_uid,json=seriesUid,proto3" json:"series_uid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchInstancesRequest) Reset() {
+	*x = SearchInstancesRequest{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchInstancesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchInstancesRequest) ProtoMessage() {}
+
+func (x *SearchInstancesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchInstancesRequest.ProtoReflect.Descriptor instead.
+func (*SearchInstancesRequest) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SearchInstancesRequest) GetStudyUid() string {
+	if x != nil {
+		return x.StudyUid
+	}
+	return ""
+}
+
+func (x *SearchInstancesRequest) GetSeriesUid() string {
+	if x != nil {
+		return x.SeriesUid
+	}
+	return ""
+}
+
+type Instance struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	SopInstanceUid            string                 `protobuf:"bytes,1,opt,name=sop_instance_uid,json=sopInstanceUid,proto3" json:"sop_instance_uid,omitempty"`
+	SopClassUid               string                 `protobuf:"bytes,2,opt,name=sop_class_uid,json=sopClassUid,proto3" json:"sop_class_uid,omitempty"`
+	InstanceNumber            int32                  `protobuf:"varint,3,opt,name=instance_number,json=instanceNumber,proto3" json:"instance_number,omitempty"`
+	TransferSyntaxUid         string                 `protobuf:"bytes,4,opt,name=transfer_syntax_uid,json=transferSyntaxUid,proto3" json:"transfer_syntax_uid,omitempty"`
+	Rows                      int32                  `protobuf:"varint,5,opt,name=rows,proto3" json:"rows,omitempty"`
+	Columns                   int32                  `protobuf:"varint,6,opt,name=columns,proto3" json:"columns,omitempty"`
+	BitsAllocated             int32                  `protobuf:"varint,7,opt,name=bits_allocated,json=bitsAllocated,proto3" json:"bits_allocated,omitempty"`
+	BitsStored                int32                  `protobuf:"varint,8,opt,name=bits_stored,json=bitsStored,proto3" json:"bits_stored,omitempty"`
+	HighBit                   int32                  `protobuf:"varint,9,opt,name=high_bit,json=highBit,proto3" json:"high_bit,omitempty"`
+	PixelRepresentation       int32                  `protobuf:"varint,10,opt,name=pixel_representation,json=pixelRepresentation,proto3" json:"pixel_representation,omitempty"`
+	PhotometricInterpretation string                 `protobuf:"bytes,11,opt,name=photometric_interpretation,json=photometricInterpretation,proto3" json:"photometric_interpretation,omitempty"`
+	SamplesPerPixel           int32                  `protobuf:"varint,12,opt,name=samples_per_pixel,json=samplesPerPixel,proto3" json:"samples_per_pixel,omitempty"`
+	NumberOfFrames            int32                  `protobuf:"varint,13,opt,name=number_of_frames,json=numberOfFrames,proto3" json:"number_of_frames,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *Instance) Reset() {
+	*x = Instance{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Instance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Instance) ProtoMessage() {}
+
+func (x *Instance) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Instance.ProtoReflect.Descriptor instead.
+func (*Instance) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Instance) GetSopInstanceUid() string {
+	if x != nil {
+		return x.SopInstanceUid
+	}
+	return ""
+}
+
+func (x *Instance) GetSopClassUid() string {
+	if x != nil {
+		return x.SopClassUid
+	}
+	return ""
+}
+
+func (x *Instance) GetInstanceNumber() int32 {
+	if x != nil {
+		return x.InstanceNumber
+	}
+	return 0
+}
+
+func (x *Instance) GetTransferSyntaxUid() string {
+	if x != nil {
+		return x.TransferSyntaxUid
+	}
+	return ""
+}
+
+func (x *Instance) GetRows() int32 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *Instance) GetColumns() int32 {
+	if x != nil {
+		return x.Columns
+	}
+	return 0
+}
+
+func (x *Instance) GetBitsAllocated() int32 {
+	if x != nil {
+		return x.BitsAllocated
+	}
+	return 0
+}
+
+func (x *Instance) GetBitsStored() int32 {
+	if x != nil {
+		return x.BitsStored
+	}
+	return 0
+}
+
+func (x *Instance) GetHighBit() int32 {
+	if x != nil {
+		return x.HighBit
+	}
+	return 0
+}
+
+func (x *Instance) GetPixelRepresentation() int32 {
+	if x != nil {
+		return x.PixelRepresentation
+	}
+	return 0
+}
+
+func (x *Instance) GetPhotometricInterpretation() string {
+	if x != nil {
+		return x.PhotometricInterpretation
+	}
+	return ""
+}
+
+func (x *Instance) GetSamplesPerPixel() int32 {
+	if x != nil {
+		return x.SamplesPerPixel
+	}
+	return 0
+}
+
+func (x *Instance) GetNumberOfFrames() int32 {
+	if x != nil {
+		return x.NumberOfFrames
+	}
+	return 0
+}
+
+type SearchInstancesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instances     []*Instance            `protobuf:"bytes,1,rep,name=instances,proto3" json:"instances,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchInstancesResponse) Reset() {
+	*x = SearchInstancesResponse{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchInstancesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchInstancesResponse) ProtoMessage() {}
+
+func (x *SearchInstancesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchInstancesResponse.ProtoReflect.Descriptor instead.
+func (*SearchInstancesResponse) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SearchInstancesResponse) GetInstances() []*Instance {
+	if x != nil {
+		return x.Instances
+	}
+	return nil
+}
+
+type Metadata struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	SopInstanceUid    string                 `protobuf:"bytes,1,opt,name=sop_instance_uid,json=sopInstanceUid,proto3" json:"sop_instance_uid,omitempty"`
+	SopClassUid       string                 `protobuf:"bytes,2,opt,name=sop_class_uid,json=sopClassUid,proto3" json:"sop_class_uid,omitempty"`
+	TransferSyntaxUid string                 `protobuf:"bytes,3,opt,name=transfer_syntax_uid,json=transferSyntaxUid,proto3" json:"transfer_syntax_uid,omitempty"`
+	DicomJson         []byte                 `protobuf:"bytes,4,opt,name=dicom_json,json=dicomJson,proto3" json:"dicom_json,omitempty"` // attributes as a DICOM JSON model object (PS3.18 Annex F)
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Metadata) GetSopInstanceUid() string {
+	if x != nil {
+		return x.SopInstanceUid
+	}
+	return ""
+}
+
+func (x *Metadata) GetSopClassUid() string {
+	if x != nil {
+		return x.SopClassUid
+	}
+	return ""
+}
+
+func (x *Metadata) GetTransferSyntaxUid() string {
+	if x != nil {
+		return x.TransferSyntaxUid
+	}
+	return ""
+}
+
+func (x *Metadata) GetDicomJson() []byte {
+	if x != nil {
+		return x.DicomJson
+	}
+	return nil
+}
+
+type MetadataList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      []*Metadata            `protobuf:"bytes,1,rep,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetadataList) Reset() {
+	*x = MetadataList{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetadataList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetadataList) ProtoMessage() {}
+
+func (x *MetadataList) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetadataList.ProtoReflect.Descriptor instead.
+func (*MetadataList) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *MetadataList) GetMetadata() []*Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type RetrieveInstanceRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Object         *ObjectRef             `protobuf:"bytes,1,opt,name=object,proto3" json:"object,omitempty"`
+	TransferSyntax string                 `protobuf:"bytes,2,opt,name=transfer_syntax,json=transferSyntax,proto3" json:"transfer_syntax,omitempty"` // empty or "*" accepts any transfer syntax
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RetrieveInstanceRequest) Reset() {
+	*x = RetrieveInstanceRequest{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetrieveInstanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrieveInstanceRequest) ProtoMessage() {}
+
+func (x *RetrieveInstanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrieveInstanceRequest.ProtoReflect.Descriptor instead.
+func (*RetrieveInstanceRequest) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RetrieveInstanceRequest) GetObject() *ObjectRef {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *RetrieveInstanceRequest) GetTransferSyntax() string {
+	if x != nil {
+		return x.TransferSyntax
+	}
+	return ""
+}
+
+type RetrieveRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Object         *ObjectRef             `protobuf:"bytes,1,opt,name=object,proto3" json:"object,omitempty"`
+	TransferSyntax string                 `protobuf:"bytes,2,opt,name=transfer_syntax,json=transferSyntax,proto3" json:"transfer_syntax,omitempty"` // empty or "*" accepts any transfer syntax
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RetrieveRequest) Reset() {
+	*x = RetrieveRequest{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetrieveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrieveRequest) ProtoMessage() {}
+
+func (x *RetrieveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrieveRequest.ProtoReflect.Descriptor instead.
+func (*RetrieveRequest) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RetrieveRequest) GetObject() *ObjectRef {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *RetrieveRequest) GetTransferSyntax() string {
+	if x != nil {
+		return x.TransferSyntax
+	}
+	return ""
+}
+
+type RetrieveRenderedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Object        *ObjectRef             `protobuf:"bytes,1,opt,name=object,proto3" json:"object,omitempty"`
+	MediaType     string                 `protobuf:"bytes,2,opt,name=media_type,json=mediaType,proto3" json:"media_type,omitempty"` // e.g. image/jpeg or video/mp4
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetrieveRenderedRequest) Reset() {
+	*x = RetrieveRenderedRequest{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetrieveRenderedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrieveRenderedRequest) ProtoMessage() {}
+
+func (x *RetrieveRenderedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrieveRenderedRequest.ProtoReflect.Descriptor instead.
+func (*RetrieveRenderedRequest) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RetrieveRenderedRequest) GetObject() *ObjectRef {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *RetrieveRenderedRequest) GetMediaType() string {
+	if x != nil {
+		return x.MediaType
+	}
+	return ""
+}
+
+// DataChunk is one piece of a streamed object
+type DataChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ContentType   string                 `protobuf:"bytes,1,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`        // first chunk only
+	ContentLength int64                  `protobuf:"varint,2,opt,name=content_length,json=contentLength,proto3" json:"content_length,omitempty"` // first chunk only; -1 or 0 when unknown
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataChunk) Reset() {
+	*x = DataChunk{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataChunk) ProtoMessage() {}
+
+func (x *DataChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataChunk.ProtoReflect.Descriptor instead.
+func (*DataChunk) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DataChunk) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *DataChunk) GetContentLength() int64 {
+	if x != nil {
+		return x.ContentLength
+	}
+	return 0
+}
+
+func (x *DataChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// InstanceHeader starts each instance of a series or study retrieve
+type InstanceHeader struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SopInstanceUid string                 `protobuf:"bytes,1,opt,name=sop_instance_uid,json=sopInstanceUid,proto3" json:"sop_instance_uid,omitempty"`
+	SopClassUid    string                 `protobuf:"bytes,2,opt,name=sop_class_uid,json=sopClassUid,proto3" json:"sop_class_uid,omitempty"`
+	InstanceNumber int32                  `protobuf:"varint,3,opt,name=instance_number,json=instanceNumber,proto3" json:"instance_number,omitempty"`
+	ContentType    string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *InstanceHeader) Reset() {
+	*x = InstanceHeader{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstanceHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstanceHeader) ProtoMessage() {}
+
+func (x *InstanceHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstanceHeader.ProtoReflect.Descriptor instead.
+func (*InstanceHeader) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *InstanceHeader) GetSopInstanceUid() string {
+	if x != nil {
+		return x.SopInstanceUid
+	}
+	return ""
+}
+
+func (x *InstanceHeader) GetSopClassUid() string {
+	if x != nil {
+		return x.SopClassUid
+	}
+	return ""
+}
+
+func (x *InstanceHeader) GetInstanceNumber() int32 {
+	if x != nil {
+		return x.InstanceNumber
+	}
+	return 0
+}
+
+func (x *InstanceHeader) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+// InstancePart is either the header of the next instance or a piece of its data
+type InstancePart struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Part:
+	//
+	//	*InstancePart_Header
+	//	*InstancePart_Data
+	Part          isInstancePart_Part `protobuf_oneof:"part"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstancePart) Reset() {
+	*x = InstancePart{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstancePart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstancePart) ProtoMessage() {}
+
+func (x *InstancePart) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstancePart.ProtoReflect.Descriptor instead.
+func (*InstancePart) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *InstancePart) GetPart() isInstancePart_Part {
+	if x != nil {
+		return x.Part
+	}
+	return nil
+}
+
+func (x *InstancePart) GetHeader() *InstanceHeader {
+	if x != nil {
+		if x, ok := x.Part.(*InstancePart_Header); ok {
+			return x.Header
+		}
+	}
+	return nil
+}
+
+func (x *InstancePart) GetData() []byte {
+	if x != nil {
+		if x, ok := x.Part.(*InstancePart_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+type isInstancePart_Part interface {
+	isInstancePart_Part()
+}
+
+type InstancePart_Header struct {
+	Header *InstanceHeader `protobuf:"bytes,1,opt,name=header,proto3,oneof"`
+}
+
+type InstancePart_Data struct {
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3,oneof"`
+}
+
+func (*InstancePart_Header) isInstancePart_Part() {}
+
+func (*InstancePart_Data) isInstancePart_Part() {}
+
+type GetThumbnailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Object        *ObjectRef             `protobuf:"bytes,1,opt,name=object,proto3" json:"object,omitempty"`    // study, series or instance
+	Size          int32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`       // longest edge in pixels; 0 for the default
+	Quality       int32                  `protobuf:"varint,3,opt,name=quality,proto3" json:"quality,omitempty"` // JPEG quality (1-100); 0 for the default
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetThumbnailRequest) Reset() {
+	*x = GetThumbnailRequest{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetThumbnailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetThumbnailRequest) ProtoMessage() {}
+
+func (x *GetThumbnailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetThumbnailRequest.ProtoReflect.Descriptor instead.
+func (*GetThumbnailRequest) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetThumbnailRequest) GetObject() *ObjectRef {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *GetThumbnailRequest) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *GetThumbnailRequest) GetQuality() int32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+type Thumbnail struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Jpeg          []byte                 `protobuf:"bytes,1,opt,name=jpeg,proto3" json:"jpeg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Thumbnail) Reset() {
+	*x = Thumbnail{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Thumbnail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Thumbnail) ProtoMessage() {}
+
+func (x *Thumbnail) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Thumbnail.ProtoReflect.Descriptor instead.
+func (*Thumbnail) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *Thumbnail) GetJpeg() []byte {
+	if x != nil {
+		return x.Jpeg
+	}
+	return nil
+}
+
+type GetCapabilitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCapabilitiesRequest) Reset() {
+	*x = GetCapabilitiesRequest{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCapabilitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCapabilitiesRequest) ProtoMessage() {}
+
+func (x *GetCapabilitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCapabilitiesRequest.ProtoReflect.Descriptor instead.
+func (*GetCapabilitiesRequest) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{20}
+}
+
+type Capabilities struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	PacsType         string                 `protobuf:"bytes,1,opt,name=pacs_type,json=pacsType,proto3" json:"pacs_type,omitempty"`
+	Services         []string               `protobuf:"bytes,2,rep,name=services,proto3" json:"services,omitempty"`
+	Resources        []string               `protobuf:"bytes,3,rep,name=resources,proto3" json:"resources,omitempty"`
+	SopClasses       []string               `protobuf:"bytes,4,rep,name=sop_classes,json=sopClasses,proto3" json:"sop_classes,omitempty"`
+	TransferSyntaxes []string               `protobuf:"bytes,5,rep,name=transfer_syntaxes,json=transferSyntaxes,proto3" json:"transfer_syntaxes,omitempty"`
+	MediaTypes       []string               `protobuf:"bytes,6,rep,name=media_types,json=mediaTypes,proto3" json:"media_types,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Capabilities) Reset() {
+	*x = Capabilities{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Capabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Capabilities) ProtoMessage() {}
+
+func (x *Capabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Capabilities.ProtoReflect.Descriptor instead.
+func (*Capabilities) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Capabilities) GetPacsType() string {
+	if x != nil {
+		return x.PacsType
+	}
+	return ""
+}
+
+func (x *Capabilities) GetServices() []string {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+func (x *Capabilities) GetResources() []string {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *Capabilities) GetSopClasses() []string {
+	if x != nil {
+		return x.SopClasses
+	}
+	return nil
+}
+
+func (x *Capabilities) GetTransferSyntaxes() []string {
+	if x != nil {
+		return x.TransferSyntaxes
+	}
+	return nil
+}
+
+func (x *Capabilities) GetMediaTypes() []string {
+	if x != nil {
+		return x.MediaTypes
+	}
+	return nil
+}
+
+type ListPACSConfigsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPACSConfigsRequest) Reset() {
+	*x = ListPACSConfigsRequest{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPACSConfigsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPACSConfigsRequest) ProtoMessage() {}
+
+func (x *ListPACSConfigsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPACSConfigsRequest.ProtoReflect.Descriptor instead.
+func (*ListPACSConfigsRequest) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{22}
+}
+
+type ListPACSConfigsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Configs       []*PACSConfig          `protobuf:"bytes,1,rep,name=configs,proto3" json:"configs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPACSConfigsResponse) Reset() {
+	*x = ListPACSConfigsResponse{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPACSConfigsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPACSConfigsResponse) ProtoMessage() {}
+
+func (x *ListPACSConfigsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPACSConfigsResponse.ProtoReflect.Descriptor instead.
+func (*ListPACSConfigsResponse) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListPACSConfigsResponse) GetConfigs() []*PACSConfig {
+	if x != nil {
+		return x.Configs
+	}
+	return nil
+}
+
+type GetPACSConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPACSConfigRequest) Reset() {
+	*x = GetPACSConfigRequest{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPACSConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPACSConfigRequest) ProtoMessage() {}
+
+func (x *GetPACSConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPACSConfigRequest.ProtoReflect.Descriptor instead.
+func (*GetPACSConfigRequest) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetPACSConfigRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// PACSConfig is a PACS configuration without its credentials
+type PACSConfig struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Id                     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                   string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Type                   string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Endpoint               string                 `protobuf:"bytes,4,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	Port                   int32                  `protobuf:"varint,5,opt,name=port,proto3" json:"port,omitempty"`
+	AeTitle                string                 `protobuf:"bytes,6,opt,name=ae_title,json=aeTitle,proto3" json:"ae_title,omitempty"`
+	BaseUrl                string                 `protobuf:"bytes,7,opt,name=base_url,json=baseUrl,proto3" json:"base_url,omitempty"`
+	IsPrimary              bool                   `protobuf:"varint,8,opt,name=is_primary,json=isPrimary,proto3" json:"is_primary,omitempty"`
+	IsActive               bool                   `protobuf:"varint,9,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	MaxResults             int32                  `protobuf:"varint,10,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"`
+	Quirks                 string                 `protobuf:"bytes,11,opt,name=quirks,proto3" json:"quirks,omitempty"`
+	LastConnectionStatus   bool                   `protobuf:"varint,12,opt,name=last_connection_status,json=lastConnectionStatus,proto3" json:"last_connection_status,omitempty"`
+	LastConnectionTestUnix int64                  `protobuf:"varint,13,opt,name=last_connection_test_unix,json=lastConnectionTestUnix,proto3" json:"last_connection_test_unix,omitempty"` // 0 when never tested
+	LastError              string                 `protobuf:"bytes,14,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *PACSConfig) Reset() {
+	*x = PACSConfig{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PACSConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PACSConfig) ProtoMessage() {}
+
+func (x *PACSConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PACSConfig.ProtoReflect.Descriptor instead.
+func (*PACSConfig) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *PACSConfig) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PACSConfig) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PACSConfig) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *PACSConfig) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *PACSConfig) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *PACSConfig) GetAeTitle() string {
+	if x != nil {
+		return x.AeTitle
+	}
+	return ""
+}
+
+func (x *PACSConfig) GetBaseUrl() string {
+	if x != nil {
+		return x.BaseUrl
+	}
+	return ""
+}
+
+func (x *PACSConfig) GetIsPrimary() bool {
+	if x != nil {
+		return x.IsPrimary
+	}
+	return false
+}
+
+func (x *PACSConfig) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *PACSConfig) GetMaxResults() int32 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+func (x *PACSConfig) GetQuirks() string {
+	if x != nil {
+		return x.Quirks
+	}
+	return ""
+}
+
+func (x *PACSConfig) GetLastConnectionStatus() bool {
+	if x != nil {
+		return x.LastConnectionStatus
+	}
+	return false
+}
+
+func (x *PACSConfig) GetLastConnectionTestUnix() int64 {
+	if x != nil {
+		return x.LastConnectionTestUnix
+	}
+	return 0
+}
+
+func (x *PACSConfig) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+// CreatePACSConfigRequest covers the common connection settings; TLS, OAuth2
+// and archive-specific settings are configured through the REST API
+type CreatePACSConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Endpoint      string                 `protobuf:"bytes,3,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	Port          int32                  `protobuf:"varint,4,opt,name=port,proto3" json:"port,omitempty"`
+	AeTitle       string                 `protobuf:"bytes,5,opt,name=ae_title,json=aeTitle,proto3" json:"ae_title,omitempty"`
+	BaseUrl       string                 `protobuf:"bytes,6,opt,name=base_url,json=baseUrl,proto3" json:"base_url,omitempty"`
+	BasePath      string                 `protobuf:"bytes,7,opt,name=base_path,json=basePath,proto3" json:"base_path,omitempty"`
+	Username      string                 `protobuf:"bytes,8,opt,name=username,proto3" json:"username,omitempty"`
+	Password      string                 `protobuf:"bytes,9,opt,name=password,proto3" json:"password,omitempty"`
+	ApiKey        string                 `protobuf:"bytes,10,opt,name=api_key,json=apiKey,proto3" json:"api_key,omitempty"`
+	IsPrimary     bool                   `protobuf:"varint,11,opt,name=is_primary,json=isPrimary,proto3" json:"is_primary,omitempty"`
+	MaxResults    int32                  `protobuf:"varint,12,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"`
+	Quirks        string                 `protobuf:"bytes,13,opt,name=quirks,proto3" json:"quirks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreatePACSConfigRequest) Reset() {
+	*x = CreatePACSConfigRequest{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePACSConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePACSConfigRequest) ProtoMessage() {}
+
+func (x *CreatePACSConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePACSConfigRequest.ProtoReflect.Descriptor instead.
+func (*CreatePACSConfigRequest) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *CreatePACSConfigRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreatePACSConfigRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CreatePACSConfigRequest) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *CreatePACSConfigRequest) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *CreatePACSConfigRequest) GetAeTitle() string {
+	if x != nil {
+		return x.AeTitle
+	}
+	return ""
+}
+
+func (x *CreatePACSConfigRequest) GetBaseUrl() string {
+	if x != nil {
+		return x.BaseUrl
+	}
+	return ""
+}
+
+func (x *CreatePACSConfigRequest) GetBasePath() string {
+	if x != nil {
+		return x.BasePath
+	}
+	return ""
+}
+
+func (x *CreatePACSConfigRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *CreatePACSConfigRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *CreatePACSConfigRequest) GetApiKey() string {
+	if x != nil {
+		return x.ApiKey
+	}
+	return ""
+}
+
+func (x *CreatePACSConfigRequest) GetIsPrimary() bool {
+	if x != nil {
+		return x.IsPrimary
+	}
+	return false
+}
+
+func (x *CreatePACSConfigRequest) GetMaxResults() int32 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+func (x *CreatePACSConfigRequest) GetQuirks() string {
+	if x != nil {
+		return x.Quirks
+	}
+	return ""
+}
+
+type DeleteStudyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteStudyResponse) Reset() {
+	*x = DeleteStudyResponse{}
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteStudyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteStudyResponse) ProtoMessage() {}
+
+func (x *DeleteStudyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_connectorapi_v1_connector_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteStudyResponse.ProtoReflect.Descriptor instead.
+func (*DeleteStudyResponse) Descriptor() ([]byte, []int) {
+	return file_connectorapi_v1_connector_proto_rawDescGZIP(), []int{27}
+}
+
+var File_connectorapi_v1_connector_proto protoreflect.FileDescriptor
+
+const file_connectorapi_v1_connector_proto_rawDesc = "" +
+	"\n" +
+	"\x1fconnectorapi/v1/connector.proto\x12\x13risconnector.api.v1\"j\n" +
+	"\tObjectRef\x12\x1b\n" +
+	"\tstudy_uid\x18\x01 \x01(\tR\bstudyUid\x12\x1d\n" +
+	"\n" +
+	"series_uid\x18\x02 \x01(\tR\tseriesUid\x12!\n" +
+	"\finstance_uid\x18\x03 \x01(\tR\vinstanceUid\"\xca\x03\n" +
+	"\x14SearchStudiesRequest\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x01 \x01(\tR\tpatientId\x12!\n" +
+	"\fpatient_name\x18\x02 \x01(\tR\vpatientName\x12\x1d\n" +
+	"\n" +
+	"study_date\x18\x03 \x01(\tR\tstudyDate\x12\x1d\n" +
+	"\n" +
+	"study_time\x18\x04 \x01(\tR\tstudyTime\x12)\n" +
+	"\x10accession_number\x18\x05 \x01(\tR\x0faccessionNumber\x12\x1e\n" +
+	"\n" +
+	"modalities\x18\x06 \x03(\tR\n" +
+	"modalities\x12+\n" +
+	"\x11study_description\x18\a \x01(\tR\x10studyDescription\x12P\n" +
+	"\afilters\x18\b \x03(\v26.risconnector.api.v1.SearchStudiesRequest.FiltersEntryR\afilters\x12\x14\n" +
+	"\x05limit\x18\t \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\n" +
+	" \x01(\x05R\x06offset\x1a:\n" +
+	"\fFiltersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x97\x04\n" +
+	"\x05Study\x12,\n" +
+	"\x12study_instance_uid\x18\x01 \x01(\tR\x10studyInstanceUid\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x02 \x01(\tR\tpatientId\x12!\n" +
+	"\fpatient_name\x18\x03 \x01(\tR\vpatientName\x12,\n" +
+	"\x12patient_birth_date\x18\x04 \x01(\tR\x10patientBirthDate\x12\x1f\n" +
+	"\vpatient_sex\x18\x05 \x01(\tR\n" +
+	"patientSex\x12\x1d\n" +
+	"\n" +
+	"study_date\x18\x06 \x01(\tR\tstudyDate\x12\x1d\n" +
+	"\n" +
+	"study_time\x18\a \x01(\tR\tstudyTime\x12+\n" +
+	"\x11study_description\x18\b \x01(\tR\x10studyDescription\x12)\n" +
+	"\x10accession_number\x18\t \x01(\tR\x0faccessionNumber\x12/\n" +
+	"\x13referring_physician\x18\n" +
+	" \x01(\tR\x12referringPhysician\x12(\n" +
+	"\x10number_of_series\x18\v \x01(\x05R\x0enumberOfSeries\x12.\n" +
+	"\x13number_of_instances\x18\f \x01(\x05R\x11numberOfInstances\x12.\n" +
+	"\x13modalities_in_study\x18\r \x03(\tR\x11modalitiesInStudy\"k\n" +
+	"\x15SearchStudiesResponse\x124\n" +
+	"\astudies\x18\x01 \x03(\v2\x1a.risconnector.api.v1.StudyR\astudies\x12\x1c\n" +
+	"\ttruncated\x18\x02 \x01(\bR\ttruncated\"2\n" +
+	"\x13SearchSeriesRequest\x12\x1b\n" +
+	"\tstudy_uid\x18\x01 \x01(\tR\bstudyUid\"\x9e\x03\n" +
+	"\x06Series\x12.\n" +
+	"\x13series_instance_uid\x18\x01 \x01(\tR\x11seriesInstanceUid\x12#\n" +
+	"\rseries_number\x18\x02 \x01(\x05R\fseriesNumber\x12\x1a\n" +
+	"\bmodality\x18\x03 \x01(\tR\bmodality\x12-\n" +
+	"\x12series_description\x18\x04 \x01(\tR\x11seriesDescription\x12\x1f\n" +
+	"\vseries_date\x18\x05 \x01(\tR\n" +
+	"seriesDate\x12\x1f\n" +
+	"\vseries_time\x18\x06 \x01(\tR\n" +
+	"seriesTime\x12,\n" +
+	"\x12body_part_examined\x18\a \x01(\tR\x10bodyPartExamined\x12.\n" +
+	"\x13number_of_instances\x18\b \x01(\x05R\x11numberOfInstances\x12#\n" +
+	"\rprotocol_name\x18\t \x01(\tR\fprotocolName\x12/\n" +
+	"\x13performed_procedure\x18\n" +
+	" \x01(\tR\x12performedProcedure\"K\n" +
+	"\x14SearchSeriesResponse\x123\n" +
+	"\x06series\x18\x01 \x03(\v2\x1b.risconnector.api.v1.SeriesR\x06series\"T\n" +
+	"\x16SearchInstancesRequest\x12\x1b\n" +
+	"\tstudy_uid\x18\x01 \x01(\tR\bstudyUid\x12\x1d\n" +
+	"\n" +
+	"series_uid\x18\x02 \x01(\tR\tseriesUid\"\x8a\x04\n" +
+	"\bInstance\x12(\n" +
+	"\x10sop_instance_uid\x18\x01 \x01(\tR\x0esopInstanceUid\x12\"\n" +
+	"\rsop_class_uid\x18\x02 \x01(\tR\vsopClassUid\x12'\n" +
+	"\x0finstance_number\x18\x03 \x01(\x05R\x0einstanceNumber\x12.\n" +
+	"\x13transfer_syntax_uid\x18\x04 \x01(\tR\x11transferSyntaxUid\x12\x12\n" +
+	"\x04rows\x18\x05 \x01(\x05R\x04rows\x12\x18\n" +
+	"\acolumns\x18\x06 \x01(\x05R\acolumns\x12%\n" +
+	"\x0ebits_allocated\x18\a \x01(\x05R\rbitsAllocated\x12\x1f\n" +
+	"\vbits_stored\x18\b \x01(\x05R\n" +
+	"bitsStored\x12\x19\n" +
+	"\bhigh_bit\x18\t \x01(\x05R\ahighBit\x121\n" +
+	"\x14pixel_representation\x18\n" +
+	" \x01(\x05R\x13pixelRepresentation\x12=\n" +
+	"\x1aphotometric_interpretation\x18\v \x01(\tR\x19photometricInterpretation\x12*\n" +
+	"\x11samples_per_pixel\x18\f \x01(\x05R\x0fsamplesPerPixel\x12(\n" +
+	"\x10number_of_frames\x18\r \x01(\x05R\x0enumberOfFrames\"V\n" +
+	"\x17SearchInstancesResponse\x12;\n" +
+	"\tinstances\x18\x01 \x03(\v2\x1d.risconnector.api.v1.InstanceR\tinstances\"\xa7\x01\n" +
+	"\bMetadata\x12(\n" +
+	"\x10sop_instance_uid\x18\x01 \x01(\tR\x0esopInstanceUid\x12\"\n" +
+	"\rsop_class_uid\x18\x02 \x01(\tR\vsopClassUid\x12.\n" +
+	"\x13transfer_syntax_uid\x18\x03 \x01(\tR\x11transferSyntaxUid\x12\x1d\n" +
+	"\n" +
+	"dicom_json\x18\x04 \x01(\fR\tdicomJson\"I\n" +
+	"\fMetadataList\x129\n" +
+	"\bmetadata\x18\x01 \x03(\v2\x1d.risconnector.api.v1.MetadataR\bmetadata\"z\n" +
+	"\x17RetrieveInstanceRequest\x126\n" +
+	"\x06object\x18\x01 \x01(\v2\x1e.risconnector.api.v1.ObjectRefR\x06object\x12'\n" +
+	"\x0ftransfer_syntax\x18\x02 \x01(\tR\x0etransferSyntax\"r\n" +
+	"\x0fRetrieveRequest\x126\n" +
+	"\x06object\x18\x01 \x01(\v2\x1e.risconnector.api.v1.ObjectRefR\x06object\x12'\n" +
+	"\x0ftransfer_syntax\x18\x02 \x01(\tR\x0etransferSyntax\"p\n" +
+	"\x17RetrieveRenderedRequest\x126\n" +
+	"\x06object\x18\x01 \x01(\v2\x1e.risconnector.api.v1.ObjectRefR\x06object\x12\x1d\n" +
+	"\n" +
+	"media_type\x18\x02 \x01(\tR\tmediaType\"i\n" +
+	"\tDataChunk\x12!\n" +
+	"\fcontent_type\x18\x01 \x01(\tR\vcontentType\x12%\n" +
+	"\x0econtent_length\x18\x02 \x01(\x03R\rcontentLength\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"\xaa\x01\n" +
+	"\x0eInstanceHeader\x12(\n" +
+	"\x10sop_instance_uid\x18\x01 \x01(\tR\x0esopInstanceUid\x12\"\n" +
+	"\rsop_class_uid\x18\x02 \x01(\tR\vsopClassUid\x12'\n" +
+	"\x0finstance_number\x18\x03 \x01(\x05R\x0einstanceNumber\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\"k\n" +
+	"\fInstancePart\x12=\n" +
+	"\x06header\x18\x01 \x01(\v2#.risconnector.api.v1.InstanceHeaderH\x00R\x06header\x12\x14\n" +
+	"\x04data\x18\x02 \x01(\fH\x00R\x04dataB\x06\n" +
+	"\x04part\"{\n" +
+	"\x13GetThumbnailRequest\x126\n" +
+	"\x06object\x18\x01 \x01(\v2\x1e.risconnector.api.v1.ObjectRefR\x06object\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x05R\x04size\x12\x18\n" +
+	"\aquality\x18\x03 \x01(\x05R\aquality\"\x1f\n" +
+	"\tThumbnail\x12\x12\n" +
+	"\x04jpeg\x18\x01 \x01(\fR\x04jpeg\"\x18\n" +
+	"\x16GetCapabilitiesRequest\"\xd4\x01\n" +
+	"\fCapabilities\x12\x1b\n" +
+	"\tpacs_type\x18\x01 \x01(\tR\bpacsType\x12\x1a\n" +
+	"\bservices\x18\x02 \x03(\tR\bservices\x12\x1c\n" +
+	"\tresources\x18\x03 \x03(\tR\tresources\x12\x1f\n" +
+	"\vsop_classes\x18\x04 \x03(\tR\n" +
+	"sopClasses\x12+\n" +
+	"\x11transfer_syntaxes\x18\x05 \x03(\tR\x10transferSyntaxes\x12\x1f\n" +
+	"\vmedia_types\x18\x06 \x03(\tR\n" +
+	"mediaTypes\"\x18\n" +
+	"\x16ListPACSConfigsRequest\"T\n" +
+	"\x17ListPACSConfigsResponse\x129\n" +
+	"\aconfigs\x18\x01 \x03(\v2\x1f.risconnector.api.v1.PACSConfigR\aconfigs\"&\n" +
+	"\x14GetPACSConfigRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xaf\x03\n" +
+	"\n" +
+	"PACSConfig\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x1a\n" +
+	"\bendpoint\x18\x04 \x01(\tR\bendpoint\x12\x12\n" +
+	"\x04port\x18\x05 \x01(\x05R\x04port\x12\x19\n" +
+	"\bae_title\x18\x06 \x01(\tR\aaeTitle\x12\x19\n" +
+	"\bbase_url\x18\a \x01(\tR\abaseUrl\x12\x1d\n" +
+	"\n" +
+	"is_primary\x18\b \x01(\bR\tisPrimary\x12\x1b\n" +
+	"\tis_active\x18\t \x01(\bR\bisActive\x12\x1f\n" +
+	"\vmax_results\x18\n" +
+	" \x01(\x05R\n" +
+	"maxResults\x12\x16\n" +
+	"\x06quirks\x18\v \x01(\tR\x06quirks\x124\n" +
+	"\x16last_connection_status\x18\f \x01(\bR\x14lastConnectionStatus\x129\n" +
+	"\x19last_connection_test_unix\x18\r \x01(\x03R\x16lastConnectionTestUnix\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x0e \x01(\tR\tlastError\"\xed\x02\n" +
+	"\x17CreatePACSConfigRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1a\n" +
+	"\bendpoint\x18\x03 \x01(\tR\bendpoint\x12\x12\n" +
+	"\x04port\x18\x04 \x01(\x05R\x04port\x12\x19\n" +
+	"\bae_title\x18\x05 \x01(\tR\aaeTitle\x12\x19\n" +
+	"\bbase_url\x18\x06 \x01(\tR\abaseUrl\x12\x1b\n" +
+	"\tbase_path\x18\a \x01(\tR\bbasePath\x12\x1a\n" +
+	"\busername\x18\b \x01(\tR\busername\x12\x1a\n" +
+	"\bpassword\x18\t \x01(\tR\bpassword\x12\x17\n" +
+	"\aapi_key\x18\n" +
+	" \x01(\tR\x06apiKey\x12\x1d\n" +
+	"\n" +
+	"is_primary\x18\v \x01(\bR\tisPrimary\x12\x1f\n" +
+	"\vmax_results\x18\f \x01(\x05R\n" +
+	"maxResults\x12\x16\n" +
+	"\x06quirks\x18\r \x01(\tR\x06quirks\"\x15\n" +
+	"\x13DeleteStudyResponse2\x97\f\n" +
+	"\x10ConnectorService\x12f\n" +
+	"\rSearchStudies\x12).risconnector.api.v1.SearchStudiesRequest\x1a*.risconnector.api.v1.SearchStudiesResponse\x12c\n" +
+	"\fSearchSeries\x12(.risconnector.api.v1.SearchSeriesRequest\x1a).risconnector.api.v1.SearchSeriesResponse\x12l\n" +
+	"\x0fSearchInstances\x12+.risconnector.api.v1.SearchInstancesRequest\x1a,.risconnector.api.v1.SearchInstancesResponse\x12U\n" +
+	"\x10GetStudyMetadata\x12\x1e.risconnector.api.v1.ObjectRef\x1a!.risconnector.api.v1.MetadataList\x12V\n" +
+	"\x11GetSeriesMetadata\x12\x1e.risconnector.api.v1.ObjectRef\x1a!.risconnector.api.v1.MetadataList\x12T\n" +
+	"\x13GetInstanceMetadata\x12\x1e.risconnector.api.v1.ObjectRef\x1a\x1d.risconnector.api.v1.Metadata\x12b\n" +
+	"\x10RetrieveInstance\x12,.risconnector.api.v1.RetrieveInstanceRequest\x1a\x1e.risconnector.api.v1.DataChunk0\x01\x12[\n" +
+	"\x0eRetrieveSeries\x12$.risconnector.api.v1.RetrieveRequest\x1a!.risconnector.api.v1.InstancePart0\x01\x12Z\n" +
+	"\rRetrieveStudy\x12$.risconnector.api.v1.RetrieveRequest\x1a!.risconnector.api.v1.InstancePart0\x01\x12b\n" +
+	"\x10RetrieveRendered\x12,.risconnector.api.v1.RetrieveRenderedRequest\x1a\x1e.risconnector.api.v1.DataChunk0\x01\x12X\n" +
+	"\fGetThumbnail\x12(.risconnector.api.v1.GetThumbnailRequest\x1a\x1e.risconnector.api.v1.Thumbnail\x12a\n" +
+	"\x0fGetCapabilities\x12+.risconnector.api.v1.GetCapabilitiesRequest\x1a!.risconnector.api.v1.Capabilities\x12l\n" +
+	"\x0fListPACSConfigs\x12+.risconnector.api.v1.ListPACSConfigsRequest\x1a,.risconnector.api.v1.ListPACSConfigsResponse\x12[\n" +
+	"\rGetPACSConfig\x12).risconnector.api.v1.GetPACSConfigRequest\x1a\x1f.risconnector.api.v1.PACSConfig\x12a\n" +
+	"\x10CreatePACSConfig\x12,.risconnector.api.v1.CreatePACSConfigRequest\x1a\x1f.risconnector.api.v1.PACSConfig\x12W\n" +
+	"\vDeleteStudy\x12\x1e.risconnector.api.v1.ObjectRef\x1a(.risconnector.api.v1.DeleteStudyResponseBNZLgithub.com/otcheredev/ris-dicom-connector/pkg/connectorapi/v1;connectorapiv1b\x06proto3"
+
+var (
+	file_connectorapi_v1_connector_proto_rawDescOnce sync.Once
+	file_connectorapi_v1_connector_proto_rawDescData []byte
+)
+
+func file_connectorapi_v1_connector_proto_rawDescGZIP() []byte {
+	file_connectorapi_v1_connector_proto_rawDescOnce.Do(func() {
+		file_connectorapi_v1_connector_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_connectorapi_v1_connector_proto_rawDesc), len(file_connectorapi_v1_connector_proto_rawDesc)))
+	})
+	return file_connectorapi_v1_connector_proto_rawDescData
+}
+
+var file_connectorapi_v1_connector_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
+var file_connectorapi_v1_connector_proto_goTypes = []any{
+	(*ObjectRef)(nil),               // 0: risconnector.api.v1.ObjectRef
+	(*SearchStudiesRequest)(nil),    // 1: risconnector.api.v1.SearchStudiesRequest
+	(*Study)(nil),                   // 2: risconnector.api.v1.Study
+	(*SearchStudiesResponse)(nil),   // 3: risconnector.api.v1.SearchStudiesResponse
+	(*SearchSeriesRequest)(nil),     // 4: risconnector.api.v1.SearchSeriesRequest
+	(*Series)(nil),                  // 5: risconnector.api.v1.Series
+	(*SearchSeriesResponse)(nil),    // 6: risconnector.api.v1.SearchSeriesResponse
+	(*SearchInstancesRequest)(nil),  // 7: risconnector.api.v1.SearchInstancesRequest
+	(*Instance)(nil),                // 8: risconnector.api.v1.Instance
+	(*SearchInstancesResponse)(nil), // 9: risconnector.api.v1.SearchInstancesResponse
+	(*Metadata)(nil),                // 10: risconnector.api.v1.Metadata
+	(*MetadataList)(nil),            // 11: risconnector.api.v1.MetadataList
+	(*RetrieveInstanceRequest)(nil), // 12: risconnector.api.v1.RetrieveInstanceRequest
+	(*RetrieveRequest)(nil),         // 13: risconnector.api.v1.RetrieveRequest
+	(*RetrieveRenderedRequest)(nil), // 14: risconnector.api.v1.RetrieveRenderedRequest
+	(*DataChunk)(nil),               // 15: risconnector.api.v1.DataChunk
+	(*InstanceHeader)(nil),          // 16: risconnector.api.v1.InstanceHeader
+	(*InstancePart)(nil),            // 17: risconnector.api.v1.InstancePart
+	(*GetThumbnailRequest)(nil),     // 18: risconnector.api.v1.GetThumbnailRequest
+	(*Thumbnail)(nil),               // 19: risconnector.api.v1.Thumbnail
+	(*GetCapabilitiesRequest)(nil),  // 20: risconnector.api.v1.GetCapabilitiesRequest
+	(*Capabilities)(nil),            // 21: risconnector.api.v1.Capabilities
+	(*ListPACSConfigsRequest)(nil),  // 22: risconnector.api.v1.ListPACSConfigsRequest
+	(*ListPACSConfigsResponse)(nil), // 23: risconnector.api.v1.ListPACSConfigsResponse
+	(*GetPACSConfigRequest)(nil),    // 24: risconnector.api.v1.GetPACSConfigRequest
+	(*PACSConfig)(nil),              // 25: risconnector.api.v1.PACSConfig
+	(*CreatePACSConfigRequest)(nil), // 26: risconnector.api.v1.CreatePACSConfigRequest
+	(*DeleteStudyResponse)(nil),     // 27: risconnector.api.v1.DeleteStudyResponse
+	nil,                             // 28: risconnector.api.v1.SearchStudiesRequest.FiltersEntry
+}
+var file_connectorapi_v1_connector_proto_depIdxs = []int32{
+	28, // 0: risconnector.api.v1.SearchStudiesRequest.filters:type_name -> risconnector.api.v1.SearchStudiesRequest.FiltersEntry
+	2,  // 1: risconnector.api.v1.SearchStudiesResponse.studies:type_name -> risconnector.api.v1.Study
+	5,  // 2: risconnector.api.v1.SearchSeriesResponse.series:type_name -> risconnector.api.v1.Series
+	8,  // 3: risconnector.api.v1.SearchInstancesResponse.instances:type_name -> risconnector.api.v1.Instance
+	10, // 4: risconnector.api.v1.MetadataList.metadata:type_name -> risconnector.api.v1.Metadata
+	0,  // 5: risconnector.api.v1.RetrieveInstanceRequest.object:type_name -> risconnector.api.v1.ObjectRef
+	0,  // 6: risconnector.api.v1.RetrieveRequest.object:type_name -> risconnector.api.v1.ObjectRef
+	0,  // 7: risconnector.api.v1.RetrieveRenderedRequest.object:type_name -> risconnector.api.v1.ObjectRef
+	16, // 8: risconnector.api.v1.InstancePart.header:type_name -> risconnector.api.v1.InstanceHeader
+	0,  // 9: risconnector.api.v1.GetThumbnailRequest.object:type_name -> risconnector.api.v1.ObjectRef
+	25, // 10: risconnector.api.v1.ListPACSConfigsResponse.configs:type_name -> risconnector.api.v1.PACSConfig
+	1,  // 11: risconnector.api.v1.ConnectorService.SearchStudies:input_type -> risconnector.api.v1.SearchStudiesRequest
+	4,  // 12: risconnector.api.v1.ConnectorService.SearchSeries:input_type -> risconnector.api.v1.SearchSeriesRequest
+	7,  // 13: risconnector.api.v1.ConnectorService.SearchInstances:input_type -> risconnector.api.v1.SearchInstancesRequest
+	0,  // 14: risconnector.api.v1.ConnectorService.GetStudyMetadata:input_type -> risconnector.api.v1.ObjectRef
+	0,  // 15: risconnector.api.v1.ConnectorService.GetSeriesMetadata:input_type -> risconnector.api.v1.ObjectRef
+	0,  // 16: risconnector.api.v1.ConnectorService.GetInstanceMetadata:input_type -> risconnector.api.v1.ObjectRef
+	12, // 17: risconnector.api.v1.ConnectorService.RetrieveInstance:input_type -> risconnector.api.v1.RetrieveInstanceRequest
+	13, // 18: risconnector.api.v1.ConnectorService.RetrieveSeries:input_type -> risconnector.api.v1.RetrieveRequest
+	13, // 19: risconnector.api.v1.ConnectorService.RetrieveStudy:input_type -> risconnector.api.v1.RetrieveRequest
+	14, // 20: risconnector.api.v1.ConnectorService.RetrieveRendered:input_type -> risconnector.api.v1.RetrieveRenderedRequest
+	18, // 21: risconnector.api.v1.ConnectorService.GetThumbnail:input_type -> risconnector.api.v1.GetThumbnailRequest
+	20, // 22: risconnector.api.v1.ConnectorService.GetCapabilities:input_type -> risconnector.api.v1.GetCapabilitiesRequest
+	22, // 23: risconnector.api.v1.ConnectorService.ListPACSConfigs:input_type -> risconnector.api.v1.ListPACSConfigsRequest
+	24, // 24: risconnector.api.v1.ConnectorService.GetPACSConfig:input_type -> risconnector.api.v1.GetPACSConfigRequest
+	26, // 25: risconnector.api.v1.ConnectorService.CreatePACSConfig:input_type -> risconnector.api.v1.CreatePACSConfigRequest
+	0,  // 26: risconnector.api.v1.ConnectorService.DeleteStudy:input_type -> risconnector.api.v1.ObjectRef
+	3,  // 27: risconnector.api.v1.ConnectorService.SearchStudies:output_type -> risconnector.api.v1.SearchStudiesResponse
+	6,  // 28: risconnector.api.v1.ConnectorService.SearchSeries:output_type -> risconnector.api.v1.SearchSeriesResponse
+	9,  // 29: risconnector.api.v1.ConnectorService.SearchInstances:output_type -> risconnector.api.v1.SearchInstancesResponse
+	11, // 30: risconnector.api.v1.ConnectorService.GetStudyMetadata:output_type -> risconnector.api.v1.MetadataList
+	11, // 31: risconnector.api.v1.ConnectorService.GetSeriesMetadata:output_type -> risconnector.api.v1.MetadataList
+	10, // 32: risconnector.api.v1.ConnectorService.GetInstanceMetadata:output_type -> risconnector.api.v1.Metadata
+	15, // 33: risconnector.api.v1.ConnectorService.RetrieveInstance:output_type -> risconnector.api.v1.DataChunk
+	17, // 34: risconnector.api.v1.ConnectorService.RetrieveSeries:output_type -> risconnector.api.v1.InstancePart
+	17, // 35: risconnector.api.v1.ConnectorService.RetrieveStudy:output_type -> risconnector.api.v1.InstancePart
+	15, // 36: risconnector.api.v1.ConnectorService.RetrieveRendered:output_type -> risconnector.api.v1.DataChunk
+	19, // 37: risconnector.api.v1.ConnectorService.GetThumbnail:output_type -> risconnector.api.v1.Thumbnail
+	21, // 38: risconnector.api.v1.ConnectorService.GetCapabilities:output_type -> risconnector.api.v1.Capabilities
+	23, // 39: risconnector.api.v1.ConnectorService.ListPACSConfigs:output_type -> risconnector.api.v1.ListPACSConfigsResponse
+	25, // 40: risconnector.api.v1.ConnectorService.GetPACSConfig:output_type -> risconnector.api.v1.PACSConfig
+	25, // 41: risconnector.api.v1.ConnectorService.CreatePACSConfig:output_type -> risconnector.api.v1.PACSConfig
+	27, // 42: risconnector.api.v1.ConnectorService.DeleteStudy:output_type -> risconnector.api.v1.DeleteStudyResponse
+	27, // [27:43] is the sub-list for method output_type
+	11, // [11:27] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_connectorapi_v1_connector_proto_init() }
+func file_connectorapi_v1_connector_proto_init() {
+	if File_connectorapi_v1_connector_proto != nil {
+		return
+	}
+	file_connectorapi_v1_connector_proto_msgTypes[17].OneofWrappers = []any{
+		(*InstancePart_Header)(nil),
+		(*InstancePart_Data)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_connectorapi_v1_connector_proto_rawDesc), len(file_connectorapi_v1_connector_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   29,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_connectorapi_v1_connector_proto_goTypes,
+		DependencyIndexes: file_connectorapi_v1_connector_proto_depIdxs,
+		MessageInfos:      file_connectorapi_v1_connector_proto_msgTypes,
+	}.Build()
+	File_connectorapi_v1_connector_proto = out.File
+	file_connectorapi_v1_connector_proto_goTypes = nil
+	file_connectorapi_v1_connector_proto_depIdxs = nil
+}
@@ -0,0 +1,726 @@
+// Connector API for internal services that prefer gRPC over the DICOMweb and
+// management REST endpoints. It exposes the same query, retrieve and
+// management operations with the same tenant isolation.
+//
+// Every call must carry the tenant in the x-tenant-id metadata. DeleteStudy
+// additionally requires "authorization: Bearer <admin token>". Errors use gRPC
+// status codes: NOT_FOUND, INVALID_ARGUMENT, UNIMPLEMENTED (operation not
+// supported by the tenant's PACS), FAILED_PRECONDITION (no PACS configured),
+// UNAVAILABLE, RESOURCE_EXHAUSTED and DEADLINE_EXCEEDED.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: connectorapi/v1/connector.proto
+
+package connectorapiv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ConnectorService_SearchStudies_FullMethodName       = "/risconnector.api.v1.ConnectorService/SearchStudies"
+	ConnectorService_SearchSeries_FullMethodName        = "/risconnector.api.v1.ConnectorService/SearchSeries"
+	ConnectorService_SearchInstances_FullMethodName     = "/risconnector.api.v1.ConnectorService/SearchInstances"
+	ConnectorService_GetStudyMetadata_FullMethodName    = "/risconnector.api.v1.ConnectorService/GetStudyMetadata"
+	ConnectorService_GetSeriesMetadata_FullMethodName   = "/risconnector.api.v1.ConnectorService/GetSeriesMetadata"
+	ConnectorService_GetInstanceMetadata_FullMethodName = "/risconnector.api.v1.ConnectorService/GetInstanceMetadata"
+	ConnectorService_RetrieveInstance_FullMethodName    = "/risconnector.api.v1.ConnectorService/RetrieveInstance"
+	ConnectorService_RetrieveSeries_FullMethodName      = "/risconnector.api.v1.ConnectorService/RetrieveSeries"
+	ConnectorService_RetrieveStudy_FullMethodName       = "/risconnector.api.v1.ConnectorService/RetrieveStudy"
+	ConnectorService_RetrieveRendered_FullMethodName    = "/risconnector.api.v1.ConnectorService/RetrieveRendered"
+	ConnectorService_GetThumbnail_FullMethodName        = "/risconnector.api.v1.ConnectorService/GetThumbnail"
+	ConnectorService_GetCapabilities_FullMethodName     = "/risconnector.api.v1.ConnectorService/GetCapabilities"
+	ConnectorService_ListPACSConfigs_FullMethodName     = "/risconnector.api.v1.ConnectorService/ListPACSConfigs"
+	ConnectorService_GetPACSConfig_FullMethodName       = "/risconnector.api.v1.ConnectorService/GetPACSConfig"
+	ConnectorService_CreatePACSConfig_FullMethodName    = "/risconnector.api.v1.ConnectorService/CreatePACSConfig"
+	ConnectorService_DeleteStudy_FullMethodName         = "/risconnector.api.v1.ConnectorService/DeleteStudy"
+)
+
+// ConnectorServiceClient is the client API for ConnectorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ConnectorServiceClient interface {
+	// Query (QIDO-RS equivalents)
+	SearchStudies(ctx context.Context, in *SearchStudiesRequest, opts ...grpc.CallOption) (*SearchStudiesResponse, error)
+	SearchSeries(ctx context.Context, in *SearchSeriesRequest, opts ...grpc.CallOption) (*SearchSeriesResponse, error)
+	SearchInstances(ctx context.Context, in *SearchInstancesRequest, opts ...grpc.CallOption) (*SearchInstancesResponse, error)
+	// Metadata (WADO-RS metadata equivalents)
+	GetStudyMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*MetadataList, error)
+	GetSeriesMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*MetadataList, error)
+	GetInstanceMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*Metadata, error)
+	// Retrieve. RetrieveInstance and RetrieveRendered stream a single object with
+	// the content type in the first chunk. RetrieveSeries and RetrieveStudy stream
+	// every instance in turn, each starting with a part carrying its header.
+	RetrieveInstance(ctx context.Context, in *RetrieveInstanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChunk], error)
+	RetrieveSeries(ctx context.Context, in *RetrieveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InstancePart], error)
+	RetrieveStudy(ctx context.Context, in *RetrieveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InstancePart], error)
+	RetrieveRendered(ctx context.Context, in *RetrieveRenderedRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChunk], error)
+	GetThumbnail(ctx context.Context, in *GetThumbnailRequest, opts ...grpc.CallOption) (*Thumbnail, error)
+	// Management
+	GetCapabilities(ctx context.Context, in *GetCapabilitiesRequest, opts ...grpc.CallOption) (*Capabilities, error)
+	ListPACSConfigs(ctx context.Context, in *ListPACSConfigsRequest, opts ...grpc.CallOption) (*ListPACSConfigsResponse, error)
+	GetPACSConfig(ctx context.Context, in *GetPACSConfigRequest, opts ...grpc.CallOption) (*PACSConfig, error)
+	CreatePACSConfig(ctx context.Context, in *CreatePACSConfigRequest, opts ...grpc.CallOption) (*PACSConfig, error)
+	DeleteStudy(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*DeleteStudyResponse, error)
+}
+
+type connectorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConnectorServiceClient(cc grpc.ClientConnInterface) ConnectorServiceClient {
+	return &connectorServiceClient{cc}
+}
+
+func (c *connectorServiceClient) SearchStudies(ctx context.Context, in *SearchStudiesRequest, opts ...grpc.CallOption) (*SearchStudiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchStudiesResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_SearchStudies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) SearchSeries(ctx context.Context, in *SearchSeriesRequest, opts ...grpc.CallOption) (*SearchSeriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchSeriesResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_SearchSeries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) SearchInstances(ctx context.Context, in *SearchInstancesRequest, opts ...grpc.CallOption) (*SearchInstancesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchInstancesResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_SearchInstances_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) GetStudyMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*MetadataList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MetadataList)
+	err := c.cc.Invoke(ctx, ConnectorService_GetStudyMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) GetSeriesMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*MetadataList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MetadataList)
+	err := c.cc.Invoke(ctx, ConnectorService_GetSeriesMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) GetInstanceMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*Metadata, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Metadata)
+	err := c.cc.Invoke(ctx, ConnectorService_GetInstanceMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) RetrieveInstance(ctx context.Context, in *RetrieveInstanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConnectorService_ServiceDesc.Streams[0], ConnectorService_RetrieveInstance_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RetrieveInstanceRequest, DataChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConnectorService_RetrieveInstanceClient = grpc.ServerStreamingClient[DataChunk]
+
+func (c *connectorServiceClient) RetrieveSeries(ctx context.Context, in *RetrieveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InstancePart], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConnectorService_ServiceDesc.Streams[1], ConnectorService_RetrieveSeries_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RetrieveRequest, InstancePart]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConnectorService_RetrieveSeriesClient = grpc.ServerStreamingClient[InstancePart]
+
+func (c *connectorServiceClient) RetrieveStudy(ctx context.Context, in *RetrieveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InstancePart], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConnectorService_ServiceDesc.Streams[2], ConnectorService_RetrieveStudy_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RetrieveRequest, InstancePart]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConnectorService_RetrieveStudyClient = grpc.ServerStreamingClient[InstancePart]
+
+func (c *connectorServiceClient) RetrieveRendered(ctx context.Context, in *RetrieveRenderedRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConnectorService_ServiceDesc.Streams[3], ConnectorService_RetrieveRendered_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RetrieveRenderedRequest, DataChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConnectorService_RetrieveRenderedClient = grpc.ServerStreamingClient[DataChunk]
+
+func (c *connectorServiceClient) GetThumbnail(ctx context.Context, in *GetThumbnailRequest, opts ...grpc.CallOption) (*Thumbnail, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Thumbnail)
+	err := c.cc.Invoke(ctx, ConnectorService_GetThumbnail_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) GetCapabilities(ctx context.Context, in *GetCapabilitiesRequest, opts ...grpc.CallOption) (*Capabilities, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Capabilities)
+	err := c.cc.Invoke(ctx, ConnectorService_GetCapabilities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) ListPACSConfigs(ctx context.Context, in *ListPACSConfigsRequest, opts ...grpc.CallOption) (*ListPACSConfigsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPACSConfigsResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_ListPACSConfigs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) GetPACSConfig(ctx context.Context, in *GetPACSConfigRequest, opts ...grpc.CallOption) (*PACSConfig, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PACSConfig)
+	err := c.cc.Invoke(ctx, ConnectorService_GetPACSConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) CreatePACSConfig(ctx context.Context, in *CreatePACSConfigRequest, opts ...grpc.CallOption) (*PACSConfig, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PACSConfig)
+	err := c.cc.Invoke(ctx, ConnectorService_CreatePACSConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) DeleteStudy(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*DeleteStudyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteStudyResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_DeleteStudy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ConnectorServiceServer is the server API for ConnectorService service.
+// All implementations must embed UnimplementedConnectorServiceServer
+// for forward compatibility.
+type ConnectorServiceServer interface {
+	// Query (QIDO-RS equivalents)
+	SearchStudies(context.Context, *SearchStudiesRequest) (*SearchStudiesResponse, error)
+	SearchSeries(context.Context, *SearchSeriesRequest) (*SearchSeriesResponse, error)
+	SearchInstances(context.Context, *SearchInstancesRequest) (*SearchInstancesResponse, error)
+	// Metadata (WADO-RS metadata equivalents)
+	GetStudyMetadata(context.Context, *ObjectRef) (*MetadataList, error)
+	GetSeriesMetadata(context.Context, *ObjectRef) (*MetadataList, error)
+	GetInstanceMetadata(context.Context, *ObjectRef) (*Metadata, error)
+	// Retrieve. RetrieveInstance and RetrieveRendered stream a single object with
+	// the content type in the first chunk. RetrieveSeries and RetrieveStudy stream
+	// every instance in turn, each starting with a part carrying its header.
+	RetrieveInstance(*RetrieveInstanceRequest, grpc.ServerStreamingServer[DataChunk]) error
+	RetrieveSeries(*RetrieveRequest, grpc.ServerStreamingServer[InstancePart]) error
+	RetrieveStudy(*RetrieveRequest, grpc.ServerStreamingServer[InstancePart]) error
+	RetrieveRendered(*RetrieveRenderedRequest, grpc.ServerStreamingServer[DataChunk]) error
+	GetThumbnail(context.Context, *GetThumbnailRequest) (*Thumbnail, error)
+	// Management
+	GetCapabilities(context.Context, *GetCapabilitiesRequest) (*Capabilities, error)
+	ListPACSConfigs(context.Context, *ListPACSConfigsRequest) (*ListPACSConfigsResponse, error)
+	GetPACSConfig(context.Context, *GetPACSConfigRequest) (*PACSConfig, error)
+	CreatePACSConfig(context.Context, *CreatePACSConfigRequest) (*PACSConfig, error)
+	DeleteStudy(context.Context, *ObjectRef) (*DeleteStudyResponse, error)
+	mustEmbedUnimplementedConnectorServiceServer()
+}
+
+// UnimplementedConnectorServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConnectorServiceServer struct{}
+
+func (UnimplementedConnectorServiceServer) SearchStudies(context.Context, *SearchStudiesRequest) (*SearchStudiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchStudies not implemented")
+}
+func (UnimplementedConnectorServiceServer) SearchSeries(context.Context, *SearchSeriesRequest) (*SearchSeriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchSeries not implemented")
+}
+func (UnimplementedConnectorServiceServer) SearchInstances(context.Context, *SearchInstancesRequest) (*SearchInstancesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchInstances not implemented")
+}
+func (UnimplementedConnectorServiceServer) GetStudyMetadata(context.Context, *ObjectRef) (*MetadataList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStudyMetadata not implemented")
+}
+func (UnimplementedConnectorServiceServer) GetSeriesMetadata(context.Context, *ObjectRef) (*MetadataList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSeriesMetadata not implemented")
+}
+func (UnimplementedConnectorServiceServer) GetInstanceMetadata(context.Context, *ObjectRef) (*Metadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInstanceMetadata not implemented")
+}
+func (UnimplementedConnectorServiceServer) RetrieveInstance(*RetrieveInstanceRequest, grpc.ServerStreamingServer[DataChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method RetrieveInstance not implemented")
+}
+func (UnimplementedConnectorServiceServer) RetrieveSeries(*RetrieveRequest, grpc.ServerStreamingServer[InstancePart]) error {
+	return status.Errorf(codes.Unimplemented, "method RetrieveSeries not implemented")
+}
+func (UnimplementedConnectorServiceServer) RetrieveStudy(*RetrieveRequest, grpc.ServerStreamingServer[InstancePart]) error {
+	return status.Errorf(codes.Unimplemented, "method RetrieveStudy not implemented")
+}
+func (UnimplementedConnectorServiceServer) RetrieveRendered(*RetrieveRenderedRequest, grpc.ServerStreamingServer[DataChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method RetrieveRendered not implemented")
+}
+func (UnimplementedConnectorServiceServer) GetThumbnail(context.Context, *GetThumbnailRequest) (*Thumbnail, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThumbnail not implemented")
+}
+func (UnimplementedConnectorServiceServer) GetCapabilities(context.Context, *GetCapabilitiesRequest) (*Capabilities, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCapabilities not implemented")
+}
+func (UnimplementedConnectorServiceServer) ListPACSConfigs(context.Context, *ListPACSConfigsRequest) (*ListPACSConfigsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPACSConfigs not implemented")
+}
+func (UnimplementedConnectorServiceServer) GetPACSConfig(context.Context, *GetPACSConfigRequest) (*PACSConfig, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPACSConfig not implemented")
+}
+func (UnimplementedConnectorServiceServer) CreatePACSConfig(context.Context, *CreatePACSConfigRequest) (*PACSConfig, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePACSConfig not implemented")
+}
+func (UnimplementedConnectorServiceServer) DeleteStudy(context.Context, *ObjectRef) (*DeleteStudyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteStudy not implemented")
+}
+func (UnimplementedConnectorServiceServer) mustEmbedUnimplementedConnectorServiceServer() {}
+func (UnimplementedConnectorServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeConnectorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConnectorServiceServer will
+// result in compilation errors.
+type UnsafeConnectorServiceServer interface {
+	mustEmbedUnimplementedConnectorServiceServer()
+}
+
+func RegisterConnectorServiceServer(s grpc.ServiceRegistrar, srv ConnectorServiceServer) {
+	// If the following call pancis, it indicates UnimplementedConnectorServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConnectorService_ServiceDesc, srv)
+}
+
+func _ConnectorService_SearchStudies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchStudiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).SearchStudies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_SearchStudies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).SearchStudies(ctx, req.(*SearchStudiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_SearchSeries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchSeriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).SearchSeries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_SearchSeries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).SearchSeries(ctx, req.(*SearchSeriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_SearchInstances_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchInstancesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).SearchInstances(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_SearchInstances_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).SearchInstances(ctx, req.(*SearchInstancesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_GetStudyMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObjectRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).GetStudyMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_GetStudyMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).GetStudyMetadata(ctx, req.(*ObjectRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_GetSeriesMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObjectRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).GetSeriesMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_GetSeriesMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).GetSeriesMetadata(ctx, req.(*ObjectRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_GetInstanceMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObjectRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).GetInstanceMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_GetInstanceMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).GetInstanceMetadata(ctx, req.(*ObjectRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_RetrieveInstance_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RetrieveInstanceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServiceServer).RetrieveInstance(m, &grpc.GenericServerStream[RetrieveInstanceRequest, DataChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConnectorService_RetrieveInstanceServer = grpc.ServerStreamingServer[DataChunk]
+
+func _ConnectorService_RetrieveSeries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RetrieveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServiceServer).RetrieveSeries(m, &grpc.GenericServerStream[RetrieveRequest, InstancePart]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConnectorService_RetrieveSeriesServer = grpc.ServerStreamingServer[InstancePart]
+
+func _ConnectorService_RetrieveStudy_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RetrieveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServiceServer).RetrieveStudy(m, &grpc.GenericServerStream[RetrieveRequest, InstancePart]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConnectorService_RetrieveStudyServer = grpc.ServerStreamingServer[InstancePart]
+
+func _ConnectorService_RetrieveRendered_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RetrieveRenderedRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServiceServer).RetrieveRendered(m, &grpc.GenericServerStream[RetrieveRenderedRequest, DataChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConnectorService_RetrieveRenderedServer = grpc.ServerStreamingServer[DataChunk]
+
+func _ConnectorService_GetThumbnail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThumbnailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).GetThumbnail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_GetThumbnail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).GetThumbnail(ctx, req.(*GetThumbnailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_GetCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCapabilitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).GetCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_GetCapabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).GetCapabilities(ctx, req.(*GetCapabilitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_ListPACSConfigs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPACSConfigsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).ListPACSConfigs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_ListPACSConfigs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).ListPACSConfigs(ctx, req.(*ListPACSConfigsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_GetPACSConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPACSConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).GetPACSConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_GetPACSConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).GetPACSConfig(ctx, req.(*GetPACSConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_CreatePACSConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePACSConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).CreatePACSConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_CreatePACSConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).CreatePACSConfig(ctx, req.(*CreatePACSConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_DeleteStudy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObjectRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).DeleteStudy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_DeleteStudy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).DeleteStudy(ctx, req.(*ObjectRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ConnectorService_ServiceDesc is the grpc.ServiceDesc for ConnectorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConnectorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "risconnector.api.v1.ConnectorService",
+	HandlerType: (*ConnectorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SearchStudies",
+			Handler:    _ConnectorService_SearchStudies_Handler,
+		},
+		{
+			MethodName: "SearchSeries",
+			Handler:    _ConnectorService_SearchSeries_Handler,
+		},
+		{
+			MethodName: "SearchInstances",
+			Handler:    _ConnectorService_SearchInstances_Handler,
+		},
+		{
+			MethodName: "GetStudyMetadata",
+			Handler:    _ConnectorService_GetStudyMetadata_Handler,
+		},
+		{
+			MethodName: "GetSeriesMetadata",
+			Handler:    _ConnectorService_GetSeriesMetadata_Handler,
+		},
+		{
+			MethodName: "GetInstanceMetadata",
+			Handler:    _ConnectorService_GetInstanceMetadata_Handler,
+		},
+		{
+			MethodName: "GetThumbnail",
+			Handler:    _ConnectorService_GetThumbnail_Handler,
+		},
+		{
+			MethodName: "GetCapabilities",
+			Handler:    _ConnectorService_GetCapabilities_Handler,
+		},
+		{
+			MethodName: "ListPACSConfigs",
+			Handler:    _ConnectorService_ListPACSConfigs_Handler,
+		},
+		{
+			MethodName: "GetPACSConfig",
+			Handler:    _ConnectorService_GetPACSConfig_Handler,
+		},
+		{
+			MethodName: "CreatePACSConfig",
+			Handler:    _ConnectorService_CreatePACSConfig_Handler,
+		},
+		{
+			MethodName: "DeleteStudy",
+			Handler:    _ConnectorService_DeleteStudy_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RetrieveInstance",
+			Handler:       _ConnectorService_RetrieveInstance_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RetrieveSeries",
+			Handler:       _ConnectorService_RetrieveSeries_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RetrieveStudy",
+			Handler:       _ConnectorService_RetrieveStudy_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RetrieveRendered",
+			Handler:       _ConnectorService_RetrieveRendered_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "connectorapi/v1/connector.proto",
+}
@@ -0,0 +1,1797 @@
+// PACS adapter plugin protocol. A sidecar implementing PACSAdapter lets the
+// connector reach archives whose vendor SDK cannot be compiled into it; configure
+// it as a PACS of type "grpc" with the sidecar's address as endpoint and port.
+//
+// Every call carries the x-tenant-id and x-pacs-config-id metadata so one
+// sidecar can serve several tenants. Errors are reported with gRPC status
+// codes: NOT_FOUND, UNIMPLEMENTED, INVALID_ARGUMENT, RESOURCE_EXHAUSTED,
+// OUT_OF_RANGE (unsatisfiable byte range) and UNAVAILABLE map onto the
+// connector's own error classes.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: pacsplugin/v1/pacsplugin.proto
+
+package pacspluginv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ObjectRef addresses a study, series or instance; empty series and instance
+// UIDs select the study or series level
+type ObjectRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StudyUid      string                 `protobuf:"bytes,1,opt,name=study_uid,json=studyUid,proto3" json:"study_uid,omitempty"`
+	SeriesUid     string                 `protobuf:"bytes,2,opt,name=series_uid,json=seriesUid,proto3" json:"series_uid,omitempty"`
+	InstanceUid   string                 `protobuf:"bytes,3,opt,name=instance_uid,json=instanceUid,proto3" json:"instance_uid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ObjectRef) Reset() {
+	*x = ObjectRef{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ObjectRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ObjectRef) ProtoMessage() {}
+
+func (x *ObjectRef) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ObjectRef.ProtoReflect.Descriptor instead.
+func (*ObjectRef) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ObjectRef) GetStudyUid() string {
+	if x != nil {
+		return x.StudyUid
+	}
+	return ""
+}
+
+func (x *ObjectRef) GetSeriesUid() string {
+	if x != nil {
+		return x.SeriesUid
+	}
+	return ""
+}
+
+func (x *ObjectRef) GetInstanceUid() string {
+	if x != nil {
+		return x.InstanceUid
+	}
+	return ""
+}
+
+type FindStudiesRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	PatientId        string                 `protobuf:"bytes,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	PatientName      string                 `protobuf:"bytes,2,opt,name=patient_name,json=patientName,proto3" json:"patient_name,omitempty"`
+	StudyDate        string                 `protobuf:"bytes,3,opt,name=study_date,json=studyDate,proto3" json:"study_date,omitempty"` // DICOM date or range, e.g. 20240101-20240131
+	StudyTime        string                 `protobuf:"bytes,4,opt,name=study_time,json=studyTime,proto3" json:"study_time,omitempty"`
+	AccessionNumber  string                 `protobuf:"bytes,5,opt,name=accession_number,json=accessionNumber,proto3" json:"accession_number,omitempty"`
+	Modalities       []string               `protobuf:"bytes,6,rep,name=modalities,proto3" json:"modalities,omitempty"` // matches studies containing any of the modalities
+	StudyDescription string                 `protobuf:"bytes,7,opt,name=study_description,json=studyDescription,proto3" json:"study_description,omitempty"`
+	Filters          map[string]string      `protobuf:"bytes,8,rep,name=filters,proto3" json:"filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // additional matching keys by tag (GGGGEEEE)
+	Limit            int32                  `protobuf:"varint,9,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset           int32                  `protobuf:"varint,10,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *FindStudiesRequest) Reset() {
+	*x = FindStudiesRequest{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindStudiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindStudiesRequest) ProtoMessage() {}
+
+func (x *FindStudiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindStudiesRequest.ProtoReflect.Descriptor instead.
+func (*FindStudiesRequest) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FindStudiesRequest) GetPatientId() string {
+	if x != nil {
+		return x.PatientId
+	}
+	return ""
+}
+
+func (x *FindStudiesRequest) GetPatientName() string {
+	if x != nil {
+		return x.PatientName
+	}
+	return ""
+}
+
+func (x *FindStudiesRequest) GetStudyDate() string {
+	if x != nil {
+		return x.StudyDate
+	}
+	return ""
+}
+
+func (x *FindStudiesRequest) GetStudyTime() string {
+	if x != nil {
+		return x.StudyTime
+	}
+	return ""
+}
+
+func (x *FindStudiesRequest) GetAccessionNumber() string {
+	if x != nil {
+		return x.AccessionNumber
+	}
+	return ""
+}
+
+func (x *FindStudiesRequest) GetModalities() []string {
+	if x != nil {
+		return x.Modalities
+	}
+	return nil
+}
+
+func (x *FindStudiesRequest) GetStudyDescription() string {
+	if x != nil {
+		return x.StudyDescription
+	}
+	return ""
+}
+
+func (x *FindStudiesRequest) GetFilters() map[string]string {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *FindStudiesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *FindStudiesRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type Study struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	StudyInstanceUid   string                 `protobuf:"bytes,1,opt,name=study_instance_uid,json=studyInstanceUid,proto3" json:"study_instance_uid,omitempty"`
+	PatientId          string                 `protobuf:"bytes,2,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	PatientName        string                 `protobuf:"bytes,3,opt,name=patient_name,json=patientName,proto3" json:"patient_name,omitempty"`
+	PatientBirthDate   string                 `protobuf:"bytes,4,opt,name=patient_birth_date,json=patientBirthDate,proto3" json:"patient_birth_date,omitempty"`
+	PatientSex         string                 `protobuf:"bytes,5,opt,name=patient_sex,json=patientSex,proto3" json:"patient_sex,omitempty"`
+	StudyDate          string                 `protobuf:"bytes,6,opt,name=study_date,json=studyDate,proto3" json:"study_date,omitempty"`
+	StudyTime          string                 `protobuf:"bytes,7,opt,name=study_time,json=studyTime,proto3" json:"study_time,omitempty"`
+	StudyDescription   string                 `protobuf:"bytes,8,opt,name=study_description,json=studyDescription,proto3" json:"study_description,omitempty"`
+	AccessionNumber    string                 `protobuf:"bytes,9,opt,name=accession_number,json=accessionNumber,proto3" json:"accession_number,omitempty"`
+	ReferringPhysician string                 `protobuf:"bytes,10,opt,name=referring_physician,json=referringPhysician,proto3" json:"referring_physician,omitempty"`
+	NumberOfSeries     int32                  `protobuf:"varint,11,opt,name=number_of_series,json=numberOfSeries,proto3" json:"number_of_series,omitempty"`
+	NumberOfInstances  int32                  `protobuf:"varint,12,opt,name=number_of_instances,json=numberOfInstances,proto3" json:"number_of_instances,omitempty"`
+	ModalitiesInStudy  []string               `protobuf:"bytes,13,rep,name=modalities_in_study,json=modalitiesInStudy,proto3" json:"modalities_in_study,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Study) Reset() {
+	*x = Study{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Study) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Study) ProtoMessage() {}
+
+func (x *Study) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Study.ProtoReflect.Descriptor instead.
+func (*Study) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Study) GetStudyInstanceUid() string {
+	if x != nil {
+		return x.StudyInstanceUid
+	}
+	return ""
+}
+
+func (x *Study) GetPatientId() string {
+	if x != nil {
+		return x.PatientId
+	}
+	return ""
+}
+
+func (x *Study) GetPatientName() string {
+	if x != nil {
+		return x.PatientName
+	}
+	return ""
+}
+
+func (x *Study) GetPatientBirthDate() string {
+	if x != nil {
+		return x.PatientBirthDate
+	}
+	return ""
+}
+
+func (x *Study) GetPatientSex() string {
+	if x != nil {
+		return x.PatientSex
+	}
+	return ""
+}
+
+func (x *Study) GetStudyDate() string {
+	if x != nil {
+		return x.StudyDate
+	}
+	return ""
+}
+
+func (x *Study) GetStudyTime() string {
+	if x != nil {
+		return x.StudyTime
+	}
+	return ""
+}
+
+func (x *Study) GetStudyDescription() string {
+	if x != nil {
+		return x.StudyDescription
+	}
+	return ""
+}
+
+func (x *Study) GetAccessionNumber() string {
+	if x != nil {
+		return x.AccessionNumber
+	}
+	return ""
+}
+
+func (x *Study) GetReferringPhysician() string {
+	if x != nil {
+		return x.ReferringPhysician
+	}
+	return ""
+}
+
+func (x *Study) GetNumberOfSeries() int32 {
+	if x != nil {
+		return x.NumberOfSeries
+	}
+	return 0
+}
+
+func (x *Study) GetNumberOfInstances() int32 {
+	if x != nil {
+		return x.NumberOfInstances
+	}
+	return 0
+}
+
+func (x *Study) GetModalitiesInStudy() []string {
+	if x != nil {
+		return x.ModalitiesInStudy
+	}
+	return nil
+}
+
+type FindStudiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Studies       []*Study               `protobuf:"bytes,1,rep,name=studies,proto3" json:"studies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindStudiesResponse) Reset() {
+	*x = FindStudiesResponse{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindStudiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindStudiesResponse) ProtoMessage() {}
+
+func (x *FindStudiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindStudiesResponse.ProtoReflect.Descriptor instead.
+func (*FindStudiesResponse) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FindStudiesResponse) GetStudies() []*Study {
+	if x != nil {
+		return x.Studies
+	}
+	return nil
+}
+
+type FindSeriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StudyUid      string                 `protobuf:"bytes,1,opt,name=study_uid,json=studyUid,proto3" json:"study_uid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindSeriesRequest) Reset() {
+	*x = FindSeriesRequest{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindSeriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindSeriesRequest) ProtoMessage() {}
+
+func (x *FindSeriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindSeriesRequest.ProtoReflect.Descriptor instead.
+func (*FindSeriesRequest) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FindSeriesRequest) GetStudyUid() string {
+	if x != nil {
+		return x.StudyUid
+	}
+	return ""
+}
+
+type Series struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	SeriesInstanceUid  string                 `protobuf:"bytes,1,opt,name=series_instance_uid,json=seriesInstanceUid,proto3" json:"series_instance_uid,omitempty"`
+	SeriesNumber       int32                  `protobuf:"varint,2,opt,name=series_number,json=seriesNumber,proto3" json:"series_number,omitempty"`
+	Modality           string                 `protobuf:"bytes,3,opt,name=modality,proto3" json:"modality,omitempty"`
+	SeriesDescription  string                 `protobuf:"bytes,4,opt,name=series_description,json=seriesDescription,proto3" json:"series_description,omitempty"`
+	SeriesDate         string                 `protobuf:"bytes,5,opt,name=series_date,json=seriesDate,proto3" json:"series_date,omitempty"`
+	SeriesTime         string                 `protobuf:"bytes,6,opt,name=series_time,json=seriesTime,proto3" json:"series_time,omitempty"`
+	BodyPartExamined   string                 `protobuf:"bytes,7,opt,name=body_part_examined,json=bodyPartExamined,proto3" json:"body_part_examined,omitempty"`
+	NumberOfInstances  int32                  `protobuf:"varint,8,opt,name=number_of_instances,json=numberOfInstances,proto3" json:"number_of_instances,omitempty"`
+	ProtocolName       string                 `protobuf:"bytes,9,opt,name=protocol_name,json=protocolName,proto3" json:"protocol_name,omitempty"`
+	PerformedProcedure string                 `protobuf:"bytes,10,opt,name=performed_procedure,json=performedProcedure,proto3" json:"performed_procedure,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Series) Reset() {
+	*x = Series{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Series) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Series) ProtoMessage() {}
+
+func (x *Series) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Series.ProtoReflect.Descriptor instead.
+func (*Series) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Series) GetSeriesInstanceUid() string {
+	if x != nil {
+		return x.SeriesInstanceUid
+	}
+	return ""
+}
+
+func (x *Series) GetSeriesNumber() int32 {
+	if x != nil {
+		return x.SeriesNumber
+	}
+	return 0
+}
+
+func (x *Series) GetModality() string {
+	if x != nil {
+		return x.Modality
+	}
+	return ""
+}
+
+func (x *Series) GetSeriesDescription() string {
+	if x != nil {
+		return x.SeriesDescription
+	}
+	return ""
+}
+
+func (x *Series) GetSeriesDate() string {
+	if x != nil {
+		return x.SeriesDate
+	}
+	return ""
+}
+
+func (x *Series) GetSeriesTime() string {
+	if x != nil {
+		return x.SeriesTime
+	}
+	return ""
+}
+
+func (x *Series) GetBodyPartExamined() string {
+	if x != nil {
+		return x.BodyPartExamined
+	}
+	return ""
+}
+
+func (x *Series) GetNumberOfInstances() int32 {
+	if x != nil {
+		return x.NumberOfInstances
+	}
+	return 0
+}
+
+func (x *Series) GetProtocolName() string {
+	if x != nil {
+		return x.ProtocolName
+	}
+	return ""
+}
+
+func (x *Series) GetPerformedProcedure() string {
+	if x != nil {
+		return x.PerformedProcedure
+	}
+	return ""
+}
+
+type FindSeriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Series        []*Series              `protobuf:"bytes,1,rep,name=series,proto3" json:"series,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindSeriesResponse) Reset() {
+	*x = FindSeriesResponse{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindSeriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindSeriesResponse) ProtoMessage() {}
+
+func (x *FindSeriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindSeriesResponse.ProtoReflect.Descriptor instead.
+func (*FindSeriesResponse) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FindSeriesResponse) GetSeries() []*Series {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+type FindInstancesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StudyUid      string                 `protobuf:"bytes,1,opt,name=study_uid,json=studyUid,proto3" json:"study_uid,omitempty"`
+	SeriesUid     string                 `protobuf:"bytes,2,opt,name=series_uid,json=seriesUid,proto3" json:"series_uid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindInstancesRequest) Reset() {
+	*x = FindInstancesRequest{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindInstancesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindInstancesRequest) ProtoMessage() {}
+
+func (x *FindInstancesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindInstancesRequest.ProtoReflect.Descriptor instead.
+func (*FindInstancesRequest) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FindInstancesRequest) GetStudyUid() string {
+	if x != nil {
+		return x.StudyUid
+	}
+	return ""
+}
+
+func (x *FindInstancesRequest) GetSeriesUid() string {
+	if x != nil {
+		return x.SeriesUid
+	}
+	return ""
+}
+
+type Instance struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	SopInstanceUid            string                 `protobuf:"bytes,1,opt,name=sop_instance_uid,json=sopInstanceUid,proto3" json:"sop_instance_uid,omitempty"`
+	SopClassUid               string                 `protobuf:"bytes,2,opt,name=sop_class_uid,json=sopClassUid,proto3" json:"sop_class_uid,omitempty"`
+	InstanceNumber            int32                  `protobuf:"varint,3,opt,name=instance_number,json=instanceNumber,proto3" json:"instance_number,omitempty"`
+	TransferSyntaxUid         string                 `protobuf:"bytes,4,opt,name=transfer_syntax_uid,json=transferSyntaxUid,proto3" json:"transfer_syntax_uid,omitempty"`
+	Rows                      int32                  `protobuf:"varint,5,opt,name=rows,proto3" json:"rows,omitempty"`
+	Columns                   int32                  `protobuf:"varint,6,opt,name=columns,proto3" json:"columns,omitempty"`
+	BitsAllocated             int32                  `protobuf:"varint,7,opt,name=bits_allocated,json=bitsAllocated,proto3" json:"bits_allocated,omitempty"`
+	BitsStored                int32                  `protobuf:"varint,8,opt,name=bits_stored,json=bitsStored,proto3" json:"bits_stored,omitempty"`
+	HighBit                   int32                  `protobuf:"varint,9,opt,name=high_bit,json=highBit,proto3" json:"high_bit,omitempty"`
+	PixelRepresentation       int32                  `protobuf:"varint,10,opt,name=pixel_representation,json=pixelRepresentation,proto3" json:"pixel_representation,omitempty"`
+	PhotometricInterpretation string                 `protobuf:"bytes,11,opt,name=photometric_interpretation,json=photometricInterpretation,proto3" json:"photometric_interpretation,omitempty"`
+	SamplesPerPixel           int32                  `protobuf:"varint,12,opt,name=samples_per_pixel,json=samplesPerPixel,proto3" json:"samples_per_pixel,omitempty"`
+	NumberOfFrames            int32                  `protobuf:"varint,13,opt,name=number_of_frames,json=numberOfFrames,proto3" json:"number_of_frames,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *Instance) Reset() {
+	*x = Instance{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Instance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Instance) ProtoMessage() {}
+
+func (x *Instance) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Instance.ProtoReflect.Descriptor instead.
+func (*Instance) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Instance) GetSopInstanceUid() string {
+	if x != nil {
+		return x.SopInstanceUid
+	}
+	return ""
+}
+
+func (x *Instance) GetSopClassUid() string {
+	if x != nil {
+		return x.SopClassUid
+	}
+	return ""
+}
+
+func (x *Instance) GetInstanceNumber() int32 {
+	if x != nil {
+		return x.InstanceNumber
+	}
+	return 0
+}
+
+func (x *Instance) GetTransferSyntaxUid() string {
+	if x != nil {
+		return x.TransferSyntaxUid
+	}
+	return ""
+}
+
+func (x *Instance) GetRows() int32 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *Instance) GetColumns() int32 {
+	if x != nil {
+		return x.Columns
+	}
+	return 0
+}
+
+func (x *Instance) GetBitsAllocated() int32 {
+	if x != nil {
+		return x.BitsAllocated
+	}
+	return 0
+}
+
+func (x *Instance) GetBitsStored() int32 {
+	if x != nil {
+		return x.BitsStored
+	}
+	return 0
+}
+
+func (x *Instance) GetHighBit() int32 {
+	if x != nil {
+		return x.HighBit
+	}
+	return 0
+}
+
+func (x *Instance) GetPixelRepresentation() int32 {
+	if x != nil {
+		return x.PixelRepresentation
+	}
+	return 0
+}
+
+func (x *Instance) GetPhotometricInterpretation() string {
+	if x != nil {
+		return x.PhotometricInterpretation
+	}
+	return ""
+}
+
+func (x *Instance) GetSamplesPerPixel() int32 {
+	if x != nil {
+		return x.SamplesPerPixel
+	}
+	return 0
+}
+
+func (x *Instance) GetNumberOfFrames() int32 {
+	if x != nil {
+		return x.NumberOfFrames
+	}
+	return 0
+}
+
+type FindInstancesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instances     []*Instance            `protobuf:"bytes,1,rep,name=instances,proto3" json:"instances,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindInstancesResponse) Reset() {
+	*x = FindInstancesResponse{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindInstancesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindInstancesResponse) ProtoMessage() {}
+
+func (x *FindInstancesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindInstancesResponse.ProtoReflect.Descriptor instead.
+func (*FindInstancesResponse) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FindInstancesResponse) GetInstances() []*Instance {
+	if x != nil {
+		return x.Instances
+	}
+	return nil
+}
+
+type ObjectExistsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exists        bool                   `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ObjectExistsResponse) Reset() {
+	*x = ObjectExistsResponse{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ObjectExistsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ObjectExistsResponse) ProtoMessage() {}
+
+func (x *ObjectExistsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ObjectExistsResponse.ProtoReflect.Descriptor instead.
+func (*ObjectExistsResponse) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ObjectExistsResponse) GetExists() bool {
+	if x != nil {
+		return x.Exists
+	}
+	return false
+}
+
+type GetInstanceRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Object         *ObjectRef             `protobuf:"bytes,1,opt,name=object,proto3" json:"object,omitempty"`
+	TransferSyntax string                 `protobuf:"bytes,2,opt,name=transfer_syntax,json=transferSyntax,proto3" json:"transfer_syntax,omitempty"` // empty or "*" accepts any transfer syntax
+	Range          string                 `protobuf:"bytes,3,opt,name=range,proto3" json:"range,omitempty"`                                         // HTTP Range header value for partial retrieval
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetInstanceRequest) Reset() {
+	*x = GetInstanceRequest{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInstanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInstanceRequest) ProtoMessage() {}
+
+func (x *GetInstanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInstanceRequest.ProtoReflect.Descriptor instead.
+func (*GetInstanceRequest) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetInstanceRequest) GetObject() *ObjectRef {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *GetInstanceRequest) GetTransferSyntax() string {
+	if x != nil {
+		return x.TransferSyntax
+	}
+	return ""
+}
+
+func (x *GetInstanceRequest) GetRange() string {
+	if x != nil {
+		return x.Range
+	}
+	return ""
+}
+
+type GetFrameRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Object        *ObjectRef             `protobuf:"bytes,1,opt,name=object,proto3" json:"object,omitempty"`
+	Frame         int32                  `protobuf:"varint,2,opt,name=frame,proto3" json:"frame,omitempty"` // 1-based
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFrameRequest) Reset() {
+	*x = GetFrameRequest{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFrameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFrameRequest) ProtoMessage() {}
+
+func (x *GetFrameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFrameRequest.ProtoReflect.Descriptor instead.
+func (*GetFrameRequest) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetFrameRequest) GetObject() *ObjectRef {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *GetFrameRequest) GetFrame() int32 {
+	if x != nil {
+		return x.Frame
+	}
+	return 0
+}
+
+type GetRenderedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Object        *ObjectRef             `protobuf:"bytes,1,opt,name=object,proto3" json:"object,omitempty"`
+	MediaType     string                 `protobuf:"bytes,2,opt,name=media_type,json=mediaType,proto3" json:"media_type,omitempty"` // e.g. image/jpeg or video/mp4
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRenderedRequest) Reset() {
+	*x = GetRenderedRequest{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRenderedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRenderedRequest) ProtoMessage() {}
+
+func (x *GetRenderedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRenderedRequest.ProtoReflect.Descriptor instead.
+func (*GetRenderedRequest) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetRenderedRequest) GetObject() *ObjectRef {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *GetRenderedRequest) GetMediaType() string {
+	if x != nil {
+		return x.MediaType
+	}
+	return ""
+}
+
+// DataChunk is one piece of a streamed object
+type DataChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ContentType   string                 `protobuf:"bytes,1,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`        // first chunk only
+	ContentLength int64                  `protobuf:"varint,2,opt,name=content_length,json=contentLength,proto3" json:"content_length,omitempty"` // first chunk only; -1 or 0 when unknown
+	ContentRange  string                 `protobuf:"bytes,3,opt,name=content_range,json=contentRange,proto3" json:"content_range,omitempty"`     // first chunk only; set for partial retrieval
+	Data          []byte                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataChunk) Reset() {
+	*x = DataChunk{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataChunk) ProtoMessage() {}
+
+func (x *DataChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataChunk.ProtoReflect.Descriptor instead.
+func (*DataChunk) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DataChunk) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *DataChunk) GetContentLength() int64 {
+	if x != nil {
+		return x.ContentLength
+	}
+	return 0
+}
+
+func (x *DataChunk) GetContentRange() string {
+	if x != nil {
+		return x.ContentRange
+	}
+	return ""
+}
+
+func (x *DataChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type Metadata struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	SopInstanceUid    string                 `protobuf:"bytes,1,opt,name=sop_instance_uid,json=sopInstanceUid,proto3" json:"sop_instance_uid,omitempty"`
+	SopClassUid       string                 `protobuf:"bytes,2,opt,name=sop_class_uid,json=sopClassUid,proto3" json:"sop_class_uid,omitempty"`
+	TransferSyntaxUid string                 `protobuf:"bytes,3,opt,name=transfer_syntax_uid,json=transferSyntaxUid,proto3" json:"transfer_syntax_uid,omitempty"`
+	DicomJson         []byte                 `protobuf:"bytes,4,opt,name=dicom_json,json=dicomJson,proto3" json:"dicom_json,omitempty"` // attributes as a DICOM JSON model object (PS3.18 Annex F)
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Metadata) GetSopInstanceUid() string {
+	if x != nil {
+		return x.SopInstanceUid
+	}
+	return ""
+}
+
+func (x *Metadata) GetSopClassUid() string {
+	if x != nil {
+		return x.SopClassUid
+	}
+	return ""
+}
+
+func (x *Metadata) GetTransferSyntaxUid() string {
+	if x != nil {
+		return x.TransferSyntaxUid
+	}
+	return ""
+}
+
+func (x *Metadata) GetDicomJson() []byte {
+	if x != nil {
+		return x.DicomJson
+	}
+	return nil
+}
+
+type MetadataList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      []*Metadata            `protobuf:"bytes,1,rep,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetadataList) Reset() {
+	*x = MetadataList{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetadataList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetadataList) ProtoMessage() {}
+
+func (x *MetadataList) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetadataList.ProtoReflect.Descriptor instead.
+func (*MetadataList) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *MetadataList) GetMetadata() []*Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type DeleteStudyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteStudyResponse) Reset() {
+	*x = DeleteStudyResponse{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteStudyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteStudyResponse) ProtoMessage() {}
+
+func (x *DeleteStudyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteStudyResponse.ProtoReflect.Descriptor instead.
+func (*DeleteStudyResponse) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{17}
+}
+
+type GetThumbnailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Object        *ObjectRef             `protobuf:"bytes,1,opt,name=object,proto3" json:"object,omitempty"`
+	Size          int32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`       // longest edge in pixels
+	Quality       int32                  `protobuf:"varint,3,opt,name=quality,proto3" json:"quality,omitempty"` // JPEG quality (1-100)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetThumbnailRequest) Reset() {
+	*x = GetThumbnailRequest{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetThumbnailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetThumbnailRequest) ProtoMessage() {}
+
+func (x *GetThumbnailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetThumbnailRequest.ProtoReflect.Descriptor instead.
+func (*GetThumbnailRequest) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetThumbnailRequest) GetObject() *ObjectRef {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *GetThumbnailRequest) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *GetThumbnailRequest) GetQuality() int32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+type Thumbnail struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Jpeg          []byte                 `protobuf:"bytes,1,opt,name=jpeg,proto3" json:"jpeg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Thumbnail) Reset() {
+	*x = Thumbnail{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Thumbnail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Thumbnail) ProtoMessage() {}
+
+func (x *Thumbnail) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Thumbnail.ProtoReflect.Descriptor instead.
+func (*Thumbnail) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *Thumbnail) GetJpeg() []byte {
+	if x != nil {
+		return x.Jpeg
+	}
+	return nil
+}
+
+type TestConnectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TestConnectionRequest) Reset() {
+	*x = TestConnectionRequest{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestConnectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestConnectionRequest) ProtoMessage() {}
+
+func (x *TestConnectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestConnectionRequest.ProtoReflect.Descriptor instead.
+func (*TestConnectionRequest) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{20}
+}
+
+type ConnectionStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IsConnected   bool                   `protobuf:"varint,1,opt,name=is_connected,json=isConnected,proto3" json:"is_connected,omitempty"`
+	ErrorMessage  string                 `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	Capabilities  []string               `protobuf:"bytes,3,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectionStatus) Reset() {
+	*x = ConnectionStatus{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectionStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectionStatus) ProtoMessage() {}
+
+func (x *ConnectionStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectionStatus.ProtoReflect.Descriptor instead.
+func (*ConnectionStatus) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ConnectionStatus) GetIsConnected() bool {
+	if x != nil {
+		return x.IsConnected
+	}
+	return false
+}
+
+func (x *ConnectionStatus) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *ConnectionStatus) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type CapabilitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CapabilitiesRequest) Reset() {
+	*x = CapabilitiesRequest{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CapabilitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CapabilitiesRequest) ProtoMessage() {}
+
+func (x *CapabilitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CapabilitiesRequest.ProtoReflect.Descriptor instead.
+func (*CapabilitiesRequest) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{22}
+}
+
+type CapabilitiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Capabilities  []string               `protobuf:"bytes,1,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CapabilitiesResponse) Reset() {
+	*x = CapabilitiesResponse{}
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CapabilitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CapabilitiesResponse) ProtoMessage() {}
+
+func (x *CapabilitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pacsplugin_v1_pacsplugin_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CapabilitiesResponse.ProtoReflect.Descriptor instead.
+func (*CapabilitiesResponse) Descriptor() ([]byte, []int) {
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CapabilitiesResponse) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+var File_pacsplugin_v1_pacsplugin_proto protoreflect.FileDescriptor
+
+const file_pacsplugin_v1_pacsplugin_proto_rawDesc = "" +
+	"\n" +
+	"\x1epacsplugin/v1/pacsplugin.proto\x12\x1arisconnector.pacsplugin.v1\"j\n" +
+	"\tObjectRef\x12\x1b\n" +
+	"\tstudy_uid\x18\x01 \x01(\tR\bstudyUid\x12\x1d\n" +
+	"\n" +
+	"series_uid\x18\x02 \x01(\tR\tseriesUid\x12!\n" +
+	"\finstance_uid\x18\x03 \x01(\tR\vinstanceUid\"\xcd\x03\n" +
+	"\x12FindStudiesRequest\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x01 \x01(\tR\tpatientId\x12!\n" +
+	"\fpatient_name\x18\x02 \x01(\tR\vpatientName\x12\x1d\n" +
+	"\n" +
+	"study_date\x18\x03 \x01(\tR\tstudyDate\x12\x1d\n" +
+	"\n" +
+	"study_time\x18\x04 \x01(\tR\tstudyTime\x12)\n" +
+	"\x10accession_number\x18\x05 \x01(\tR\x0faccessionNumber\x12\x1e\n" +
+	"\n" +
+	"modalities\x18\x06 \x03(\tR\n" +
+	"modalities\x12+\n" +
+	"\x11study_description\x18\a \x01(\tR\x10studyDescription\x12U\n" +
+	"\afilters\x18\b \x03(\v2;.risconnector.pacsplugin.v1.FindStudiesRequest.FiltersEntryR\afilters\x12\x14\n" +
+	"\x05limit\x18\t \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\n" +
+	" \x01(\x05R\x06offset\x1a:\n" +
+	"\fFiltersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x97\x04\n" +
+	"\x05Study\x12,\n" +
+	"\x12study_instance_uid\x18\x01 \x01(\tR\x10studyInstanceUid\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x02 \x01(\tR\tpatientId\x12!\n" +
+	"\fpatient_name\x18\x03 \x01(\tR\vpatientName\x12,\n" +
+	"\x12patient_birth_date\x18\x04 \x01(\tR\x10patientBirthDate\x12\x1f\n" +
+	"\vpatient_sex\x18\x05 \x01(\tR\n" +
+	"patientSex\x12\x1d\n" +
+	"\n" +
+	"study_date\x18\x06 \x01(\tR\tstudyDate\x12\x1d\n" +
+	"\n" +
+	"study_time\x18\a \x01(\tR\tstudyTime\x12+\n" +
+	"\x11study_description\x18\b \x01(\tR\x10studyDescription\x12)\n" +
+	"\x10accession_number\x18\t \x01(\tR\x0faccessionNumber\x12/\n" +
+	"\x13referring_physician\x18\n" +
+	" \x01(\tR\x12referringPhysician\x12(\n" +
+	"\x10number_of_series\x18\v \x01(\x05R\x0enumberOfSeries\x12.\n" +
+	"\x13number_of_instances\x18\f \x01(\x05R\x11numberOfInstances\x12.\n" +
+	"\x13modalities_in_study\x18\r \x03(\tR\x11modalitiesInStudy\"R\n" +
+	"\x13FindStudiesResponse\x12;\n" +
+	"\astudies\x18\x01 \x03(\v2!.risconnector.pacsplugin.v1.StudyR\astudies\"0\n" +
+	"\x11FindSeriesRequest\x12\x1b\n" +
+	"\tstudy_uid\x18\x01 \x01(\tR\bstudyUid\"\x9e\x03\n" +
+	"\x06Series\x12.\n" +
+	"\x13series_instance_uid\x18\x01 \x01(\tR\x11seriesInstanceUid\x12#\n" +
+	"\rseries_number\x18\x02 \x01(\x05R\fseriesNumber\x12\x1a\n" +
+	"\bmodality\x18\x03 \x01(\tR\bmodality\x12-\n" +
+	"\x12series_description\x18\x04 \x01(\tR\x11seriesDescription\x12\x1f\n" +
+	"\vseries_date\x18\x05 \x01(\tR\n" +
+	"seriesDate\x12\x1f\n" +
+	"\vseries_time\x18\x06 \x01(\tR\n" +
+	"seriesTime\x12,\n" +
+	"\x12body_part_examined\x18\a \x01(\tR\x10bodyPartExamined\x12.\n" +
+	"\x13number_of_instances\x18\b \x01(\x05R\x11numberOfInstances\x12#\n" +
+	"\rprotocol_name\x18\t \x01(\tR\fprotocolName\x12/\n" +
+	"\x13performed_procedure\x18\n" +
+	" \x01(\tR\x12performedProcedure\"P\n" +
+	"\x12FindSeriesResponse\x12:\n" +
+	"\x06series\x18\x01 \x03(\v2\".risconnector.pacsplugin.v1.SeriesR\x06series\"R\n" +
+	"\x14FindInstancesRequest\x12\x1b\n" +
+	"\tstudy_uid\x18\x01 \x01(\tR\bstudyUid\x12\x1d\n" +
+	"\n" +
+	"series_uid\x18\x02 \x01(\tR\tseriesUid\"\x8a\x04\n" +
+	"\bInstance\x12(\n" +
+	"\x10sop_instance_uid\x18\x01 \x01(\tR\x0esopInstanceUid\x12\"\n" +
+	"\rsop_class_uid\x18\x02 \x01(\tR\vsopClassUid\x12'\n" +
+	"\x0finstance_number\x18\x03 \x01(\x05R\x0einstanceNumber\x12.\n" +
+	"\x13transfer_syntax_uid\x18\x04 \x01(\tR\x11transferSyntaxUid\x12\x12\n" +
+	"\x04rows\x18\x05 \x01(\x05R\x04rows\x12\x18\n" +
+	"\acolumns\x18\x06 \x01(\x05R\acolumns\x12%\n" +
+	"\x0ebits_allocated\x18\a \x01(\x05R\rbitsAllocated\x12\x1f\n" +
+	"\vbits_stored\x18\b \x01(\x05R\n" +
+	"bitsStored\x12\x19\n" +
+	"\bhigh_bit\x18\t \x01(\x05R\ahighBit\x121\n" +
+	"\x14pixel_representation\x18\n" +
+	" \x01(\x05R\x13pixelRepresentation\x12=\n" +
+	"\x1aphotometric_interpretation\x18\v \x01(\tR\x19photometricInterpretation\x12*\n" +
+	"\x11samples_per_pixel\x18\f \x01(\x05R\x0fsamplesPerPixel\x12(\n" +
+	"\x10number_of_frames\x18\r \x01(\x05R\x0enumberOfFrames\"[\n" +
+	"\x15FindInstancesResponse\x12B\n" +
+	"\tinstances\x18\x01 \x03(\v2$.risconnector.pacsplugin.v1.InstanceR\tinstances\".\n" +
+	"\x14ObjectExistsResponse\x12\x16\n" +
+	"\x06exists\x18\x01 \x01(\bR\x06exists\"\x92\x01\n" +
+	"\x12GetInstanceRequest\x12=\n" +
+	"\x06object\x18\x01 \x01(\v2%.risconnector.pacsplugin.v1.ObjectRefR\x06object\x12'\n" +
+	"\x0ftransfer_syntax\x18\x02 \x01(\tR\x0etransferSyntax\x12\x14\n" +
+	"\x05range\x18\x03 \x01(\tR\x05range\"f\n" +
+	"\x0fGetFrameRequest\x12=\n" +
+	"\x06object\x18\x01 \x01(\v2%.risconnector.pacsplugin.v1.ObjectRefR\x06object\x12\x14\n" +
+	"\x05frame\x18\x02 \x01(\x05R\x05frame\"r\n" +
+	"\x12GetRenderedRequest\x12=\n" +
+	"\x06object\x18\x01 \x01(\v2%.risconnector.pacsplugin.v1.ObjectRefR\x06object\x12\x1d\n" +
+	"\n" +
+	"media_type\x18\x02 \x01(\tR\tmediaType\"\x8e\x01\n" +
+	"\tDataChunk\x12!\n" +
+	"\fcontent_type\x18\x01 \x01(\tR\vcontentType\x12%\n" +
+	"\x0econtent_length\x18\x02 \x01(\x03R\rcontentLength\x12#\n" +
+	"\rcontent_range\x18\x03 \x01(\tR\fcontentRange\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\"\xa7\x01\n" +
+	"\bMetadata\x12(\n" +
+	"\x10sop_instance_uid\x18\x01 \x01(\tR\x0esopInstanceUid\x12\"\n" +
+	"\rsop_class_uid\x18\x02 \x01(\tR\vsopClassUid\x12.\n" +
+	"\x13transfer_syntax_uid\x18\x03 \x01(\tR\x11transferSyntaxUid\x12\x1d\n" +
+	"\n" +
+	"dicom_json\x18\x04 \x01(\fR\tdicomJson\"P\n" +
+	"\fMetadataList\x12@\n" +
+	"\bmetadata\x18\x01 \x03(\v2$.risconnector.pacsplugin.v1.MetadataR\bmetadata\"\x15\n" +
+	"\x13DeleteStudyResponse\"\x82\x01\n" +
+	"\x13GetThumbnailRequest\x12=\n" +
+	"\x06object\x18\x01 \x01(\v2%.risconnector.pacsplugin.v1.ObjectRefR\x06object\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x05R\x04size\x12\x18\n" +
+	"\aquality\x18\x03 \x01(\x05R\aquality\"\x1f\n" +
+	"\tThumbnail\x12\x12\n" +
+	"\x04jpeg\x18\x01 \x01(\fR\x04jpeg\"\x17\n" +
+	"\x15TestConnectionRequest\"~\n" +
+	"\x10ConnectionStatus\x12!\n" +
+	"\fis_connected\x18\x01 \x01(\bR\visConnected\x12#\n" +
+	"\rerror_message\x18\x02 \x01(\tR\ferrorMessage\x12\"\n" +
+	"\fcapabilities\x18\x03 \x03(\tR\fcapabilities\"\x15\n" +
+	"\x13CapabilitiesRequest\":\n" +
+	"\x14CapabilitiesResponse\x12\"\n" +
+	"\fcapabilities\x18\x01 \x03(\tR\fcapabilities2\xdf\v\n" +
+	"\vPACSAdapter\x12n\n" +
+	"\vFindStudies\x12..risconnector.pacsplugin.v1.FindStudiesRequest\x1a/.risconnector.pacsplugin.v1.FindStudiesResponse\x12k\n" +
+	"\n" +
+	"FindSeries\x12-.risconnector.pacsplugin.v1.FindSeriesRequest\x1a..risconnector.pacsplugin.v1.FindSeriesResponse\x12t\n" +
+	"\rFindInstances\x120.risconnector.pacsplugin.v1.FindInstancesRequest\x1a1.risconnector.pacsplugin.v1.FindInstancesResponse\x12g\n" +
+	"\fObjectExists\x12%.risconnector.pacsplugin.v1.ObjectRef\x1a0.risconnector.pacsplugin.v1.ObjectExistsResponse\x12f\n" +
+	"\vGetInstance\x12..risconnector.pacsplugin.v1.GetInstanceRequest\x1a%.risconnector.pacsplugin.v1.DataChunk0\x01\x12b\n" +
+	"\x13GetInstanceMetadata\x12%.risconnector.pacsplugin.v1.ObjectRef\x1a$.risconnector.pacsplugin.v1.Metadata\x12d\n" +
+	"\x11GetSeriesMetadata\x12%.risconnector.pacsplugin.v1.ObjectRef\x1a(.risconnector.pacsplugin.v1.MetadataList\x12c\n" +
+	"\x10GetStudyMetadata\x12%.risconnector.pacsplugin.v1.ObjectRef\x1a(.risconnector.pacsplugin.v1.MetadataList\x12`\n" +
+	"\bGetFrame\x12+.risconnector.pacsplugin.v1.GetFrameRequest\x1a%.risconnector.pacsplugin.v1.DataChunk0\x01\x12f\n" +
+	"\vGetRendered\x12..risconnector.pacsplugin.v1.GetRenderedRequest\x1a%.risconnector.pacsplugin.v1.DataChunk0\x01\x12e\n" +
+	"\vDeleteStudy\x12%.risconnector.pacsplugin.v1.ObjectRef\x1a/.risconnector.pacsplugin.v1.DeleteStudyResponse\x12f\n" +
+	"\fGetThumbnail\x12/.risconnector.pacsplugin.v1.GetThumbnailRequest\x1a%.risconnector.pacsplugin.v1.Thumbnail\x12q\n" +
+	"\x0eTestConnection\x121.risconnector.pacsplugin.v1.TestConnectionRequest\x1a,.risconnector.pacsplugin.v1.ConnectionStatus\x12q\n" +
+	"\fCapabilities\x12/.risconnector.pacsplugin.v1.CapabilitiesRequest\x1a0.risconnector.pacsplugin.v1.CapabilitiesResponseBJZHgithub.com/otcheredev/ris-dicom-connector/pkg/pacsplugin/v1;pacspluginv1b\x06proto3"
+
+var (
+	file_pacsplugin_v1_pacsplugin_proto_rawDescOnce sync.Once
+	file_pacsplugin_v1_pacsplugin_proto_rawDescData []byte
+)
+
+func file_pacsplugin_v1_pacsplugin_proto_rawDescGZIP() []byte {
+	file_pacsplugin_v1_pacsplugin_proto_rawDescOnce.Do(func() {
+		file_pacsplugin_v1_pacsplugin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pacsplugin_v1_pacsplugin_proto_rawDesc), len(file_pacsplugin_v1_pacsplugin_proto_rawDesc)))
+	})
+	return file_pacsplugin_v1_pacsplugin_proto_rawDescData
+}
+
+var file_pacsplugin_v1_pacsplugin_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_pacsplugin_v1_pacsplugin_proto_goTypes = []any{
+	(*ObjectRef)(nil),             // 0: risconnector.pacsplugin.v1.ObjectRef
+	(*FindStudiesRequest)(nil),    // 1: risconnector.pacsplugin.v1.FindStudiesRequest
+	(*Study)(nil),                 // 2: risconnector.pacsplugin.v1.Study
+	(*FindStudiesResponse)(nil),   // 3: risconnector.pacsplugin.v1.FindStudiesResponse
+	(*FindSeriesRequest)(nil),     // 4: risconnector.pacsplugin.v1.FindSeriesRequest
+	(*Series)(nil),                // 5: risconnector.pacsplugin.v1.Series
+	(*FindSeriesResponse)(nil),    // 6: risconnector.pacsplugin.v1.FindSeriesResponse
+	(*FindInstancesRequest)(nil),  // 7: risconnector.pacsplugin.v1.FindInstancesRequest
+	(*Instance)(nil),              // 8: risconnector.pacsplugin.v1.Instance
+	(*FindInstancesResponse)(nil), // 9: risconnector.pacsplugin.v1.FindInstancesResponse
+	(*ObjectExistsResponse)(nil),  // 10: risconnector.pacsplugin.v1.ObjectExistsResponse
+	(*GetInstanceRequest)(nil),    // 11: risconnector.pacsplugin.v1.GetInstanceRequest
+	(*GetFrameRequest)(nil),       // 12: risconnector.pacsplugin.v1.GetFrameRequest
+	(*GetRenderedRequest)(nil),    // 13: risconnector.pacsplugin.v1.GetRenderedRequest
+	(*DataChunk)(nil),             // 14: risconnector.pacsplugin.v1.DataChunk
+	(*Metadata)(nil),              // 15: risconnector.pacsplugin.v1.Metadata
+	(*MetadataList)(nil),          // 16: risconnector.pacsplugin.v1.MetadataList
+	(*DeleteStudyResponse)(nil),   // 17: risconnector.pacsplugin.v1.DeleteStudyResponse
+	(*GetThumbnailRequest)(nil),   // 18: risconnector.pacsplugin.v1.GetThumbnailRequest
+	(*Thumbnail)(nil),             // 19: risconnector.pacsplugin.v1.Thumbnail
+	(*TestConnectionRequest)(nil), // 20: risconnector.pacsplugin.v1.TestConnectionRequest
+	(*ConnectionStatus)(nil),      // 21: risconnector.pacsplugin.v1.ConnectionStatus
+	(*CapabilitiesRequest)(nil),   // 22: risconnector.pacsplugin.v1.CapabilitiesRequest
+	(*CapabilitiesResponse)(nil),  // 23: risconnector.pacsplugin.v1.CapabilitiesResponse
+	nil,                           // 24: risconnector.pacsplugin.v1.FindStudiesRequest.FiltersEntry
+}
+var file_pacsplugin_v1_pacsplugin_proto_depIdxs = []int32{
+	24, // 0: risconnector.pacsplugin.v1.FindStudiesRequest.filters:type_name -> risconnector.pacsplugin.v1.FindStudiesRequest.FiltersEntry
+	2,  // 1: risconnector.pacsplugin.v1.FindStudiesResponse.studies:type_name -> risconnector.pacsplugin.v1.Study
+	5,  // 2: risconnector.pacsplugin.v1.FindSeriesResponse.series:type_name -> risconnector.pacsplugin.v1.Series
+	8,  // 3: risconnector.pacsplugin.v1.FindInstancesResponse.instances:type_name -> risconnector.pacsplugin.v1.Instance
+	0,  // 4: risconnector.pacsplugin.v1.GetInstanceRequest.object:type_name -> risconnector.pacsplugin.v1.ObjectRef
+	0,  // 5: risconnector.pacsplugin.v1.GetFrameRequest.object:type_name -> risconnector.pacsplugin.v1.ObjectRef
+	0,  // 6: risconnector.pacsplugin.v1.GetRenderedRequest.object:type_name -> risconnector.pacsplugin.v1.ObjectRef
+	15, // 7: risconnector.pacsplugin.v1.MetadataList.metadata:type_name -> risconnector.pacsplugin.v1.Metadata
+	0,  // 8: risconnector.pacsplugin.v1.GetThumbnailRequest.object:type_name -> risconnector.pacsplugin.v1.ObjectRef
+	1,  // 9: risconnector.pacsplugin.v1.PACSAdapter.FindStudies:input_type -> risconnector.pacsplugin.v1.FindStudiesRequest
+	4,  // 10: risconnector.pacsplugin.v1.PACSAdapter.FindSeries:input_type -> risconnector.pacsplugin.v1.FindSeriesRequest
+	7,  // 11: risconnector.pacsplugin.v1.PACSAdapter.FindInstances:input_type -> risconnector.pacsplugin.v1.FindInstancesRequest
+	0,  // 12: risconnector.pacsplugin.v1.PACSAdapter.ObjectExists:input_type -> risconnector.pacsplugin.v1.ObjectRef
+	11, // 13: risconnector.pacsplugin.v1.PACSAdapter.GetInstance:input_type -> risconnector.pacsplugin.v1.GetInstanceRequest
+	0,  // 14: risconnector.pacsplugin.v1.PACSAdapter.GetInstanceMetadata:input_type -> risconnector.pacsplugin.v1.ObjectRef
+	0,  // 15: risconnector.pacsplugin.v1.PACSAdapter.GetSeriesMetadata:input_type -> risconnector.pacsplugin.v1.ObjectRef
+	0,  // 16: risconnector.pacsplugin.v1.PACSAdapter.GetStudyMetadata:input_type -> risconnector.pacsplugin.v1.ObjectRef
+	12, // 17: risconnector.pacsplugin.v1.PACSAdapter.GetFrame:input_type -> risconnector.pacsplugin.v1.GetFrameRequest
+	13, // 18: risconnector.pacsplugin.v1.PACSAdapter.GetRendered:input_type -> risconnector.pacsplugin.v1.GetRenderedRequest
+	0,  // 19: risconnector.pacsplugin.v1.PACSAdapter.DeleteStudy:input_type -> risconnector.pacsplugin.v1.ObjectRef
+	18, // 20: risconnector.pacsplugin.v1.PACSAdapter.GetThumbnail:input_type -> risconnector.pacsplugin.v1.GetThumbnailRequest
+	20, // 21: risconnector.pacsplugin.v1.PACSAdapter.TestConnection:input_type -> risconnector.pacsplugin.v1.TestConnectionRequest
+	22, // 22: risconnector.pacsplugin.v1.PACSAdapter.Capabilities:input_type -> risconnector.pacsplugin.v1.CapabilitiesRequest
+	3,  // 23: risconnector.pacsplugin.v1.PACSAdapter.FindStudies:output_type -> risconnector.pacsplugin.v1.FindStudiesResponse
+	6,  // 24: risconnector.pacsplugin.v1.PACSAdapter.FindSeries:output_type -> risconnector.pacsplugin.v1.FindSeriesResponse
+	9,  // 25: risconnector.pacsplugin.v1.PACSAdapter.FindInstances:output_type -> risconnector.pacsplugin.v1.FindInstancesResponse
+	10, // 26: risconnector.pacsplugin.v1.PACSAdapter.ObjectExists:output_type -> risconnector.pacsplugin.v1.ObjectExistsResponse
+	14, // 27: risconnector.pacsplugin.v1.PACSAdapter.GetInstance:output_type -> risconnector.pacsplugin.v1.DataChunk
+	15, // 28: risconnector.pacsplugin.v1.PACSAdapter.GetInstanceMetadata:output_type -> risconnector.pacsplugin.v1.Metadata
+	16, // 29: risconnector.pacsplugin.v1.PACSAdapter.GetSeriesMetadata:output_type -> risconnector.pacsplugin.v1.MetadataList
+	16, // 30: risconnector.pacsplugin.v1.PACSAdapter.GetStudyMetadata:output_type -> risconnector.pacsplugin.v1.MetadataList
+	14, // 31: risconnector.pacsplugin.v1.PACSAdapter.GetFrame:output_type -> risconnector.pacsplugin.v1.DataChunk
+	14, // 32: risconnector.pacsplugin.v1.PACSAdapter.GetRendered:output_type -> risconnector.pacsplugin.v1.DataChunk
+	17, // 33: risconnector.pacsplugin.v1.PACSAdapter.DeleteStudy:output_type -> risconnector.pacsplugin.v1.DeleteStudyResponse
+	19, // 34: risconnector.pacsplugin.v1.PACSAdapter.GetThumbnail:output_type -> risconnector.pacsplugin.v1.Thumbnail
+	21, // 35: risconnector.pacsplugin.v1.PACSAdapter.TestConnection:output_type -> risconnector.pacsplugin.v1.ConnectionStatus
+	23, // 36: risconnector.pacsplugin.v1.PACSAdapter.Capabilities:output_type -> risconnector.pacsplugin.v1.CapabilitiesResponse
+	23, // [23:37] is the sub-list for method output_type
+	9,  // [9:23] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_pacsplugin_v1_pacsplugin_proto_init() }
+func file_pacsplugin_v1_pacsplugin_proto_init() {
+	if File_pacsplugin_v1_pacsplugin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pacsplugin_v1_pacsplugin_proto_rawDesc), len(file_pacsplugin_v1_pacsplugin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   25,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pacsplugin_v1_pacsplugin_proto_goTypes,
+		DependencyIndexes: file_pacsplugin_v1_pacsplugin_proto_depIdxs,
+		MessageInfos:      file_pacsplugin_v1_pacsplugin_proto_msgTypes,
+	}.Build()
+	File_pacsplugin_v1_pacsplugin_proto = out.File
+	file_pacsplugin_v1_pacsplugin_proto_goTypes = nil
+	file_pacsplugin_v1_pacsplugin_proto_depIdxs = nil
+}
@@ -0,0 +1,647 @@
+// PACS adapter plugin protocol. A sidecar implementing PACSAdapter lets the
+// connector reach archives whose vendor SDK cannot be compiled into it; configure
+// it as a PACS of type "grpc" with the sidecar's address as endpoint and port.
+//
+// Every call carries the x-tenant-id and x-pacs-config-id metadata so one
+// sidecar can serve several tenants. Errors are reported with gRPC status
+// codes: NOT_FOUND, UNIMPLEMENTED, INVALID_ARGUMENT, RESOURCE_EXHAUSTED,
+// OUT_OF_RANGE (unsatisfiable byte range) and UNAVAILABLE map onto the
+// connector's own error classes.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: pacsplugin/v1/pacsplugin.proto
+
+package pacspluginv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PACSAdapter_FindStudies_FullMethodName         = "/risconnector.pacsplugin.v1.PACSAdapter/FindStudies"
+	PACSAdapter_FindSeries_FullMethodName          = "/risconnector.pacsplugin.v1.PACSAdapter/FindSeries"
+	PACSAdapter_FindInstances_FullMethodName       = "/risconnector.pacsplugin.v1.PACSAdapter/FindInstances"
+	PACSAdapter_ObjectExists_FullMethodName        = "/risconnector.pacsplugin.v1.PACSAdapter/ObjectExists"
+	PACSAdapter_GetInstance_FullMethodName         = "/risconnector.pacsplugin.v1.PACSAdapter/GetInstance"
+	PACSAdapter_GetInstanceMetadata_FullMethodName = "/risconnector.pacsplugin.v1.PACSAdapter/GetInstanceMetadata"
+	PACSAdapter_GetSeriesMetadata_FullMethodName   = "/risconnector.pacsplugin.v1.PACSAdapter/GetSeriesMetadata"
+	PACSAdapter_GetStudyMetadata_FullMethodName    = "/risconnector.pacsplugin.v1.PACSAdapter/GetStudyMetadata"
+	PACSAdapter_GetFrame_FullMethodName            = "/risconnector.pacsplugin.v1.PACSAdapter/GetFrame"
+	PACSAdapter_GetRendered_FullMethodName         = "/risconnector.pacsplugin.v1.PACSAdapter/GetRendered"
+	PACSAdapter_DeleteStudy_FullMethodName         = "/risconnector.pacsplugin.v1.PACSAdapter/DeleteStudy"
+	PACSAdapter_GetThumbnail_FullMethodName        = "/risconnector.pacsplugin.v1.PACSAdapter/GetThumbnail"
+	PACSAdapter_TestConnection_FullMethodName      = "/risconnector.pacsplugin.v1.PACSAdapter/TestConnection"
+	PACSAdapter_Capabilities_FullMethodName        = "/risconnector.pacsplugin.v1.PACSAdapter/Capabilities"
+)
+
+// PACSAdapterClient is the client API for PACSAdapter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PACSAdapterClient interface {
+	// Query operations
+	FindStudies(ctx context.Context, in *FindStudiesRequest, opts ...grpc.CallOption) (*FindStudiesResponse, error)
+	FindSeries(ctx context.Context, in *FindSeriesRequest, opts ...grpc.CallOption) (*FindSeriesResponse, error)
+	FindInstances(ctx context.Context, in *FindInstancesRequest, opts ...grpc.CallOption) (*FindInstancesResponse, error)
+	ObjectExists(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*ObjectExistsResponse, error)
+	// Retrieve operations. Streams send the content type in the first chunk.
+	GetInstance(ctx context.Context, in *GetInstanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChunk], error)
+	GetInstanceMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*Metadata, error)
+	GetSeriesMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*MetadataList, error)
+	GetStudyMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*MetadataList, error)
+	GetFrame(ctx context.Context, in *GetFrameRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChunk], error)
+	GetRendered(ctx context.Context, in *GetRenderedRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChunk], error)
+	// Storage management
+	DeleteStudy(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*DeleteStudyResponse, error)
+	// GetThumbnail may return UNIMPLEMENTED; the connector then renders the
+	// thumbnail from GetInstance itself
+	GetThumbnail(ctx context.Context, in *GetThumbnailRequest, opts ...grpc.CallOption) (*Thumbnail, error)
+	// Connection management
+	TestConnection(ctx context.Context, in *TestConnectionRequest, opts ...grpc.CallOption) (*ConnectionStatus, error)
+	Capabilities(ctx context.Context, in *CapabilitiesRequest, opts ...grpc.CallOption) (*CapabilitiesResponse, error)
+}
+
+type pACSAdapterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPACSAdapterClient(cc grpc.ClientConnInterface) PACSAdapterClient {
+	return &pACSAdapterClient{cc}
+}
+
+func (c *pACSAdapterClient) FindStudies(ctx context.Context, in *FindStudiesRequest, opts ...grpc.CallOption) (*FindStudiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindStudiesResponse)
+	err := c.cc.Invoke(ctx, PACSAdapter_FindStudies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pACSAdapterClient) FindSeries(ctx context.Context, in *FindSeriesRequest, opts ...grpc.CallOption) (*FindSeriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindSeriesResponse)
+	err := c.cc.Invoke(ctx, PACSAdapter_FindSeries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pACSAdapterClient) FindInstances(ctx context.Context, in *FindInstancesRequest, opts ...grpc.CallOption) (*FindInstancesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindInstancesResponse)
+	err := c.cc.Invoke(ctx, PACSAdapter_FindInstances_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pACSAdapterClient) ObjectExists(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*ObjectExistsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ObjectExistsResponse)
+	err := c.cc.Invoke(ctx, PACSAdapter_ObjectExists_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pACSAdapterClient) GetInstance(ctx context.Context, in *GetInstanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PACSAdapter_ServiceDesc.Streams[0], PACSAdapter_GetInstance_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetInstanceRequest, DataChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PACSAdapter_GetInstanceClient = grpc.ServerStreamingClient[DataChunk]
+
+func (c *pACSAdapterClient) GetInstanceMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*Metadata, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Metadata)
+	err := c.cc.Invoke(ctx, PACSAdapter_GetInstanceMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pACSAdapterClient) GetSeriesMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*MetadataList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MetadataList)
+	err := c.cc.Invoke(ctx, PACSAdapter_GetSeriesMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pACSAdapterClient) GetStudyMetadata(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*MetadataList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MetadataList)
+	err := c.cc.Invoke(ctx, PACSAdapter_GetStudyMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pACSAdapterClient) GetFrame(ctx context.Context, in *GetFrameRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PACSAdapter_ServiceDesc.Streams[1], PACSAdapter_GetFrame_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetFrameRequest, DataChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PACSAdapter_GetFrameClient = grpc.ServerStreamingClient[DataChunk]
+
+func (c *pACSAdapterClient) GetRendered(ctx context.Context, in *GetRenderedRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PACSAdapter_ServiceDesc.Streams[2], PACSAdapter_GetRendered_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetRenderedRequest, DataChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PACSAdapter_GetRenderedClient = grpc.ServerStreamingClient[DataChunk]
+
+func (c *pACSAdapterClient) DeleteStudy(ctx context.Context, in *ObjectRef, opts ...grpc.CallOption) (*DeleteStudyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteStudyResponse)
+	err := c.cc.Invoke(ctx, PACSAdapter_DeleteStudy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pACSAdapterClient) GetThumbnail(ctx context.Context, in *GetThumbnailRequest, opts ...grpc.CallOption) (*Thumbnail, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Thumbnail)
+	err := c.cc.Invoke(ctx, PACSAdapter_GetThumbnail_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pACSAdapterClient) TestConnection(ctx context.Context, in *TestConnectionRequest, opts ...grpc.CallOption) (*ConnectionStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConnectionStatus)
+	err := c.cc.Invoke(ctx, PACSAdapter_TestConnection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pACSAdapterClient) Capabilities(ctx context.Context, in *CapabilitiesRequest, opts ...grpc.CallOption) (*CapabilitiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CapabilitiesResponse)
+	err := c.cc.Invoke(ctx, PACSAdapter_Capabilities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PACSAdapterServer is the server API for PACSAdapter service.
+// All implementations must embed UnimplementedPACSAdapterServer
+// for forward compatibility.
+type PACSAdapterServer interface {
+	// Query operations
+	FindStudies(context.Context, *FindStudiesRequest) (*FindStudiesResponse, error)
+	FindSeries(context.Context, *FindSeriesRequest) (*FindSeriesResponse, error)
+	FindInstances(context.Context, *FindInstancesRequest) (*FindInstancesResponse, error)
+	ObjectExists(context.Context, *ObjectRef) (*ObjectExistsResponse, error)
+	// Retrieve operations. Streams send the content type in the first chunk.
+	GetInstance(*GetInstanceRequest, grpc.ServerStreamingServer[DataChunk]) error
+	GetInstanceMetadata(context.Context, *ObjectRef) (*Metadata, error)
+	GetSeriesMetadata(context.Context, *ObjectRef) (*MetadataList, error)
+	GetStudyMetadata(context.Context, *ObjectRef) (*MetadataList, error)
+	GetFrame(*GetFrameRequest, grpc.ServerStreamingServer[DataChunk]) error
+	GetRendered(*GetRenderedRequest, grpc.ServerStreamingServer[DataChunk]) error
+	// Storage management
+	DeleteStudy(context.Context, *ObjectRef) (*DeleteStudyResponse, error)
+	// GetThumbnail may return UNIMPLEMENTED; the connector then renders the
+	// thumbnail from GetInstance itself
+	GetThumbnail(context.Context, *GetThumbnailRequest) (*Thumbnail, error)
+	// Connection management
+	TestConnection(context.Context, *TestConnectionRequest) (*ConnectionStatus, error)
+	Capabilities(context.Context, *CapabilitiesRequest) (*CapabilitiesResponse, error)
+	mustEmbedUnimplementedPACSAdapterServer()
+}
+
+// UnimplementedPACSAdapterServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPACSAdapterServer struct{}
+
+func (UnimplementedPACSAdapterServer) FindStudies(context.Context, *FindStudiesRequest) (*FindStudiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindStudies not implemented")
+}
+func (UnimplementedPACSAdapterServer) FindSeries(context.Context, *FindSeriesRequest) (*FindSeriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindSeries not implemented")
+}
+func (UnimplementedPACSAdapterServer) FindInstances(context.Context, *FindInstancesRequest) (*FindInstancesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindInstances not implemented")
+}
+func (UnimplementedPACSAdapterServer) ObjectExists(context.Context, *ObjectRef) (*ObjectExistsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ObjectExists not implemented")
+}
+func (UnimplementedPACSAdapterServer) GetInstance(*GetInstanceRequest, grpc.ServerStreamingServer[DataChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method GetInstance not implemented")
+}
+func (UnimplementedPACSAdapterServer) GetInstanceMetadata(context.Context, *ObjectRef) (*Metadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInstanceMetadata not implemented")
+}
+func (UnimplementedPACSAdapterServer) GetSeriesMetadata(context.Context, *ObjectRef) (*MetadataList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSeriesMetadata not implemented")
+}
+func (UnimplementedPACSAdapterServer) GetStudyMetadata(context.Context, *ObjectRef) (*MetadataList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStudyMetadata not implemented")
+}
+func (UnimplementedPACSAdapterServer) GetFrame(*GetFrameRequest, grpc.ServerStreamingServer[DataChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method GetFrame not implemented")
+}
+func (UnimplementedPACSAdapterServer) GetRendered(*GetRenderedRequest, grpc.ServerStreamingServer[DataChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method GetRendered not implemented")
+}
+func (UnimplementedPACSAdapterServer) DeleteStudy(context.Context, *ObjectRef) (*DeleteStudyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteStudy not implemented")
+}
+func (UnimplementedPACSAdapterServer) GetThumbnail(context.Context, *GetThumbnailRequest) (*Thumbnail, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThumbnail not implemented")
+}
+func (UnimplementedPACSAdapterServer) TestConnection(context.Context, *TestConnectionRequest) (*ConnectionStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TestConnection not implemented")
+}
+func (UnimplementedPACSAdapterServer) Capabilities(context.Context, *CapabilitiesRequest) (*CapabilitiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Capabilities not implemented")
+}
+func (UnimplementedPACSAdapterServer) mustEmbedUnimplementedPACSAdapterServer() {}
+func (UnimplementedPACSAdapterServer) testEmbeddedByValue()                     {}
+
+// UnsafePACSAdapterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PACSAdapterServer will
+// result in compilation errors.
+type UnsafePACSAdapterServer interface {
+	mustEmbedUnimplementedPACSAdapterServer()
+}
+
+func RegisterPACSAdapterServer(s grpc.ServiceRegistrar, srv PACSAdapterServer) {
+	// If the following call pancis, it indicates UnimplementedPACSAdapterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PACSAdapter_ServiceDesc, srv)
+}
+
+func _PACSAdapter_FindStudies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindStudiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PACSAdapterServer).FindStudies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PACSAdapter_FindStudies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PACSAdapterServer).FindStudies(ctx, req.(*FindStudiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PACSAdapter_FindSeries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindSeriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PACSAdapterServer).FindSeries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PACSAdapter_FindSeries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PACSAdapterServer).FindSeries(ctx, req.(*FindSeriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PACSAdapter_FindInstances_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindInstancesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PACSAdapterServer).FindInstances(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PACSAdapter_FindInstances_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PACSAdapterServer).FindInstances(ctx, req.(*FindInstancesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PACSAdapter_ObjectExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObjectRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PACSAdapterServer).ObjectExists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PACSAdapter_ObjectExists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PACSAdapterServer).ObjectExists(ctx, req.(*ObjectRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PACSAdapter_GetInstance_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetInstanceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PACSAdapterServer).GetInstance(m, &grpc.GenericServerStream[GetInstanceRequest, DataChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PACSAdapter_GetInstanceServer = grpc.ServerStreamingServer[DataChunk]
+
+func _PACSAdapter_GetInstanceMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObjectRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PACSAdapterServer).GetInstanceMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PACSAdapter_GetInstanceMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PACSAdapterServer).GetInstanceMetadata(ctx, req.(*ObjectRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PACSAdapter_GetSeriesMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObjectRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PACSAdapterServer).GetSeriesMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PACSAdapter_GetSeriesMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PACSAdapterServer).GetSeriesMetadata(ctx, req.(*ObjectRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PACSAdapter_GetStudyMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObjectRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PACSAdapterServer).GetStudyMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PACSAdapter_GetStudyMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PACSAdapterServer).GetStudyMetadata(ctx, req.(*ObjectRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PACSAdapter_GetFrame_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetFrameRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PACSAdapterServer).GetFrame(m, &grpc.GenericServerStream[GetFrameRequest, DataChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PACSAdapter_GetFrameServer = grpc.ServerStreamingServer[DataChunk]
+
+func _PACSAdapter_GetRendered_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRenderedRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PACSAdapterServer).GetRendered(m, &grpc.GenericServerStream[GetRenderedRequest, DataChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PACSAdapter_GetRenderedServer = grpc.ServerStreamingServer[DataChunk]
+
+func _PACSAdapter_DeleteStudy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObjectRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PACSAdapterServer).DeleteStudy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PACSAdapter_DeleteStudy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PACSAdapterServer).DeleteStudy(ctx, req.(*ObjectRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PACSAdapter_GetThumbnail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThumbnailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PACSAdapterServer).GetThumbnail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PACSAdapter_GetThumbnail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PACSAdapterServer).GetThumbnail(ctx, req.(*GetThumbnailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PACSAdapter_TestConnection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TestConnectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PACSAdapterServer).TestConnection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PACSAdapter_TestConnection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PACSAdapterServer).TestConnection(ctx, req.(*TestConnectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PACSAdapter_Capabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CapabilitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PACSAdapterServer).Capabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PACSAdapter_Capabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PACSAdapterServer).Capabilities(ctx, req.(*CapabilitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PACSAdapter_ServiceDesc is the grpc.ServiceDesc for PACSAdapter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PACSAdapter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "risconnector.pacsplugin.v1.PACSAdapter",
+	HandlerType: (*PACSAdapterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "FindStudies",
+			Handler:    _PACSAdapter_FindStudies_Handler,
+		},
+		{
+			MethodName: "FindSeries",
+			Handler:    _PACSAdapter_FindSeries_Handler,
+		},
+		{
+			MethodName: "FindInstances",
+			Handler:    _PACSAdapter_FindInstances_Handler,
+		},
+		{
+			MethodName: "ObjectExists",
+			Handler:    _PACSAdapter_ObjectExists_Handler,
+		},
+		{
+			MethodName: "GetInstanceMetadata",
+			Handler:    _PACSAdapter_GetInstanceMetadata_Handler,
+		},
+		{
+			MethodName: "GetSeriesMetadata",
+			Handler:    _PACSAdapter_GetSeriesMetadata_Handler,
+		},
+		{
+			MethodName: "GetStudyMetadata",
+			Handler:    _PACSAdapter_GetStudyMetadata_Handler,
+		},
+		{
+			MethodName: "DeleteStudy",
+			Handler:    _PACSAdapter_DeleteStudy_Handler,
+		},
+		{
+			MethodName: "GetThumbnail",
+			Handler:    _PACSAdapter_GetThumbnail_Handler,
+		},
+		{
+			MethodName: "TestConnection",
+			Handler:    _PACSAdapter_TestConnection_Handler,
+		},
+		{
+			MethodName: "Capabilities",
+			Handler:    _PACSAdapter_Capabilities_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetInstance",
+			Handler:       _PACSAdapter_GetInstance_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetFrame",
+			Handler:       _PACSAdapter_GetFrame_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetRendered",
+			Handler:       _PACSAdapter_GetRendered_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pacsplugin/v1/pacsplugin.proto",
+}